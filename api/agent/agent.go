@@ -95,6 +95,25 @@ func (agent *updateAgent) Stop() error {
 	return nil
 }
 
+// SetReportIntervals changes the interval for reporting the running desired state feedback and the delay for reporting the current state.
+// The running notifiers use the changed values for their next report.
+func (agent *updateAgent) SetReportIntervals(desiredStateFeedbackInterval, currentStateDelay time.Duration) {
+	agent.desiredStateFeedbackLock.Lock()
+	agent.desiredStateFeedbackReportInterval = desiredStateFeedbackInterval
+	if agent.desiredStateFeedbackNotifier != nil {
+		agent.desiredStateFeedbackNotifier.setInterval(desiredStateFeedbackInterval)
+	}
+	agent.desiredStateFeedbackLock.Unlock()
+
+	agent.currentStateLock.Lock()
+	defer agent.currentStateLock.Unlock()
+
+	agent.currentStateReportDelay = currentStateDelay
+	if agent.currentStateNotifier != nil {
+		agent.currentStateNotifier.setInterval(currentStateDelay)
+	}
+}
+
 func (agent *updateAgent) stopDesiredStateNotifier() {
 	agent.desiredStateFeedbackLock.Lock()
 	defer agent.desiredStateFeedbackLock.Unlock()
@@ -122,10 +141,25 @@ func (agent *updateAgent) getCurrentState(ctx context.Context, activityID string
 
 func (agent *updateAgent) HandleDesiredState(activityID string, timestamp int64, desiredState *types.DesiredState) error {
 	logger.Debug("Received desired state request: activity-id=%s, timestamp=%d", activityID, timestamp)
-	go agent.applyDesiredState(activityID, desiredState)
+	go agent.applyDesiredState(api.WithDesiredStateTimestamp(agent.ctx, timestamp), activityID, desiredState)
+	return nil
+}
+
+// HandleSignedDesiredState applies the desired state, which signature has been verified, passing the signer identity to the update manager.
+func (agent *updateAgent) HandleSignedDesiredState(activityID string, timestamp int64, signer string, desiredState *types.DesiredState) error {
+	logger.Debug("Received signed desired state request: activity-id=%s, timestamp=%d, signer=%s", activityID, timestamp, signer)
+	ctx := api.WithDesiredStateSigner(api.WithDesiredStateTimestamp(agent.ctx, timestamp), signer)
+	go agent.applyDesiredState(ctx, activityID, desiredState)
 	return nil
 }
 
+// HandleRejectedDesiredState records the desired state rejected before it is applied, if supported by the update manager.
+func (agent *updateAgent) HandleRejectedDesiredState(activityID string, signer string, reason error) {
+	if handler, ok := agent.manager.(api.RejectedDesiredStateHandler); ok {
+		handler.HandleRejectedDesiredState(activityID, signer, reason)
+	}
+}
+
 func (agent *updateAgent) HandleDesiredStateCommand(activityID string, timestamp int64, desiredStateCommand *types.DesiredStateCommand) error {
 	logger.Debug("Received desired state command request: activity-id=%s, timestamp=%d, command=%s, baseline=%s", activityID, timestamp, desiredStateCommand.Command, desiredStateCommand.Baseline)
 	go agent.commandDesiredState(activityID, desiredStateCommand)
@@ -133,7 +167,7 @@ func (agent *updateAgent) HandleDesiredStateCommand(activityID string, timestamp
 }
 
 func (agent *updateAgent) HandleCurrentStateGet(activityID string, timestamp int64) error {
-	logger.Debug("Received current state get request: activity-id=%s, timestamp=%s", activityID, timestamp)
+	logger.Debug("Received current state get request: activity-id=%s, timestamp=%d", activityID, timestamp)
 	currentState, err := agent.getCurrentState(agent.ctx, activityID)
 	if err != nil {
 		return err
@@ -146,12 +180,12 @@ func (agent *updateAgent) HandleCurrentStateGet(activityID string, timestamp int
 	return nil
 }
 
-func (agent *updateAgent) applyDesiredState(activityID string, desiredState *types.DesiredState) {
+func (agent *updateAgent) applyDesiredState(ctx context.Context, activityID string, desiredState *types.DesiredState) {
 	agent.clientLock.Lock()
 	defer agent.clientLock.Unlock()
 
 	logger.Trace("applying desired state...")
-	agent.manager.Apply(agent.ctx, activityID, desiredState)
+	agent.manager.Apply(ctx, activityID, desiredState)
 }
 
 func (agent *updateAgent) commandDesiredState(activityID string, desiredStateCommand *types.DesiredStateCommand) {
@@ -161,3 +195,40 @@ func (agent *updateAgent) commandDesiredState(activityID string, desiredStateCom
 	logger.Trace("applying desired state command...")
 	agent.manager.Command(agent.ctx, activityID, desiredStateCommand)
 }
+
+// ActivityStatus returns the status of the update activity in progress, if supported by the update manager.
+func (agent *updateAgent) ActivityStatus() *types.ActivityStatus {
+	if provider, ok := agent.manager.(api.ActivityStatusProvider); ok {
+		return provider.ActivityStatus()
+	}
+	return nil
+}
+
+// CurrentState returns the current state of the update manager, without publishing it.
+func (agent *updateAgent) CurrentState(ctx context.Context, activityID string) (*types.Inventory, error) {
+	return agent.manager.Get(ctx, activityID)
+}
+
+// ActivityHistory returns the recorded update activities matching the given query, if supported by the update manager.
+func (agent *updateAgent) ActivityHistory(query *types.ActivityHistoryQuery) *types.ActivityHistory {
+	if provider, ok := agent.manager.(api.ActivityHistoryProvider); ok {
+		return provider.ActivityHistory(query)
+	}
+	return nil
+}
+
+// ArtifactCache returns the artifact cache shared between the domain agents, if supported and enabled by the update manager.
+func (agent *updateAgent) ArtifactCache() api.ArtifactCache {
+	if provider, ok := agent.manager.(api.ArtifactCacheProvider); ok {
+		return provider.ArtifactCache()
+	}
+	return nil
+}
+
+// Compliance compares the given desired state with the current state, if supported by the update manager.
+func (agent *updateAgent) Compliance(desiredState *types.DesiredState) *types.ComplianceReport {
+	if provider, ok := agent.manager.(api.ComplianceProvider); ok {
+		return provider.Compliance(desiredState)
+	}
+	return nil
+}
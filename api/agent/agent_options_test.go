@@ -14,6 +14,7 @@ package agent
 
 import (
 	"testing"
+	"time"
 
 	"github.com/eclipse-kanto/update-manager/test"
 	"github.com/eclipse-kanto/update-manager/test/mocks"
@@ -53,3 +54,22 @@ func TestWithDesiredStateFeedbackReportInterval(t *testing.T) {
 	}
 	assert.Equal(t, expAgent, actualAgent)
 }
+
+func TestSetReportIntervals(t *testing.T) {
+	mockCtr := gomock.NewController(t)
+	defer mockCtr.Finish()
+
+	mockClient := mocks.NewMockUpdateAgentClient(mockCtr)
+	updAgent := NewUpdateAgent(mockClient, mocks.NewMockUpdateManager(mockCtr),
+		WithCurrentStateReportDelay(time.Hour), WithDesiredStateFeedbackReportInterval(time.Hour)).(*updateAgent)
+
+	// the pending current state is not reported, once the current state delay is disabled
+	updAgent.HandleCurrentStateEvent("testDomain", "", test.Inventory)
+	updAgent.SetReportIntervals(test.Interval, 0)
+	assert.Equal(t, test.Interval, updAgent.desiredStateFeedbackReportInterval)
+	assert.Equal(t, time.Duration(0), updAgent.currentStateReportDelay)
+
+	mockClient.EXPECT().SendCurrentState("", test.Inventory)
+	updAgent.HandleCurrentStateEvent("testDomain", "", test.Inventory)
+	assert.Nil(t, updAgent.currentStateNotifier)
+}
@@ -19,6 +19,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/eclipse-kanto/update-manager/api"
 	"github.com/eclipse-kanto/update-manager/api/types"
 	"github.com/eclipse-kanto/update-manager/test"
 	"github.com/eclipse-kanto/update-manager/test/mocks"
@@ -142,11 +143,37 @@ func TestHandleDesiredState(t *testing.T) {
 	}
 
 	ch := make(chan bool, 1)
-	mockUpdateManager.EXPECT().Apply(context.Background(), test.ActivityID, dummyDesiredState).DoAndReturn(
+	mockUpdateManager.EXPECT().Apply(gomock.Any(), test.ActivityID, dummyDesiredState).DoAndReturn(
 		func(ctx context.Context, activityID string, state *types.DesiredState) {
+			timestamp, ok := api.DesiredStateTimestamp(ctx)
+			assert.True(t, ok)
+			assert.Equal(t, int64(123), timestamp)
 			ch <- true
 		})
-	assert.NoError(t, updAgent.HandleDesiredState(test.ActivityID, 0, dummyDesiredState))
+	assert.NoError(t, updAgent.HandleDesiredState(test.ActivityID, 123, dummyDesiredState))
+	<-ch
+}
+
+func TestHandleSignedDesiredState(t *testing.T) {
+	mockCtr := gomock.NewController(t)
+	defer mockCtr.Finish()
+
+	mockUpdateManager := mocks.NewMockUpdateManager(mockCtr)
+	updAgent := &updateAgent{
+		client:  mocks.NewMockUpdateAgentClient(mockCtr),
+		manager: mockUpdateManager,
+		ctx:     context.Background(),
+	}
+
+	ch := make(chan bool, 1)
+	mockUpdateManager.EXPECT().Apply(gomock.Any(), test.ActivityID, dummyDesiredState).DoAndReturn(
+		func(ctx context.Context, activityID string, state *types.DesiredState) {
+			assert.Equal(t, test.SignerSubject, api.DesiredStateSigner(ctx))
+			timestamp, _ := api.DesiredStateTimestamp(ctx)
+			assert.Equal(t, int64(123), timestamp)
+			ch <- true
+		})
+	assert.NoError(t, updAgent.HandleSignedDesiredState(test.ActivityID, 123, test.SignerSubject, dummyDesiredState))
 	<-ch
 }
 
@@ -215,3 +242,101 @@ func TestHandleCurrentStateGet(t *testing.T) {
 	})
 
 }
+
+func TestActivityStatus(t *testing.T) {
+	mockCtr := gomock.NewController(t)
+	defer mockCtr.Finish()
+
+	activity := &types.ActivityStatus{ActivityID: test.ActivityID, Status: types.StatusRunning}
+	mockProvider := mocks.NewMockActivityStatusProvider(mockCtr)
+	mockProvider.EXPECT().ActivityStatus().Return(activity)
+
+	updAgent := &updateAgent{
+		manager: &struct {
+			*mocks.MockUpdateManager
+			*mocks.MockActivityStatusProvider
+		}{mocks.NewMockUpdateManager(mockCtr), mockProvider},
+	}
+	assert.Equal(t, activity, updAgent.ActivityStatus())
+
+	updAgent.manager = mocks.NewMockUpdateManager(mockCtr)
+	assert.Nil(t, updAgent.ActivityStatus())
+}
+
+func TestActivityHistory(t *testing.T) {
+	mockCtr := gomock.NewController(t)
+	defer mockCtr.Finish()
+
+	query := &types.ActivityHistoryQuery{ActivityID: test.ActivityID}
+	history := &types.ActivityHistory{Activities: []*types.ActivityRecord{{ActivityID: test.ActivityID, Status: types.StatusCompleted}}}
+	mockProvider := mocks.NewMockActivityHistoryProvider(mockCtr)
+	mockProvider.EXPECT().ActivityHistory(query).Return(history)
+
+	updAgent := &updateAgent{
+		manager: &struct {
+			*mocks.MockUpdateManager
+			*mocks.MockActivityHistoryProvider
+		}{mocks.NewMockUpdateManager(mockCtr), mockProvider},
+	}
+	assert.Equal(t, history, updAgent.ActivityHistory(query))
+
+	updAgent.manager = mocks.NewMockUpdateManager(mockCtr)
+	assert.Nil(t, updAgent.ActivityHistory(query))
+}
+
+func TestCompliance(t *testing.T) {
+	mockCtr := gomock.NewController(t)
+	defer mockCtr.Finish()
+
+	desiredState := &types.DesiredState{Domains: []*types.Domain{{ID: "containers"}}}
+	report := &types.ComplianceReport{Compliant: true}
+	mockProvider := mocks.NewMockComplianceProvider(mockCtr)
+	mockProvider.EXPECT().Compliance(desiredState).Return(report)
+
+	updAgent := &updateAgent{
+		manager: &struct {
+			*mocks.MockUpdateManager
+			*mocks.MockComplianceProvider
+		}{mocks.NewMockUpdateManager(mockCtr), mockProvider},
+	}
+	assert.Equal(t, report, updAgent.Compliance(desiredState))
+
+	updAgent.manager = mocks.NewMockUpdateManager(mockCtr)
+	assert.Nil(t, updAgent.Compliance(desiredState))
+}
+
+func TestCurrentState(t *testing.T) {
+	mockCtr := gomock.NewController(t)
+	defer mockCtr.Finish()
+
+	mockManager := mocks.NewMockUpdateManager(mockCtr)
+	mockClient := mocks.NewMockUpdateAgentClient(mockCtr)
+	updAgent := &updateAgent{manager: mockManager, client: mockClient}
+
+	// the current state is not published, i.e. the update agent client is not used
+	mockManager.EXPECT().Get(context.Background(), "test-id").Return(test.Inventory, nil)
+	currentState, err := updAgent.CurrentState(context.Background(), "test-id")
+	assert.NoError(t, err)
+	assert.Equal(t, test.Inventory, currentState)
+}
+
+type testArtifactCacheProvider struct {
+	*mocks.MockUpdateManager
+	cache api.ArtifactCache
+}
+
+func (provider *testArtifactCacheProvider) ArtifactCache() api.ArtifactCache {
+	return provider.cache
+}
+
+func TestArtifactCache(t *testing.T) {
+	mockCtr := gomock.NewController(t)
+	defer mockCtr.Finish()
+
+	cache := &struct{ api.ArtifactCache }{}
+	updAgent := &updateAgent{manager: &testArtifactCacheProvider{mocks.NewMockUpdateManager(mockCtr), cache}}
+	assert.Equal(t, cache, updAgent.ArtifactCache())
+
+	updAgent.manager = mocks.NewMockUpdateManager(mockCtr)
+	assert.Nil(t, updAgent.ArtifactCache())
+}
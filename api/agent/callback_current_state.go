@@ -24,6 +24,11 @@ func (agent *updateAgent) HandleCurrentStateEvent(name string, activityID string
 	logger.Debug("handle current state event for domain and activityId '%s' - '%s'", name, activityID)
 
 	if agent.currentStateReportDelay == 0 {
+		if agent.currentStateNotifier != nil {
+			// the delay is disabled on configuration reload, so the pending current state is outdated
+			agent.currentStateNotifier.stop()
+			agent.currentStateNotifier = nil
+		}
 		agent.publishCurrentState(activityID, currentState)
 		return
 	}
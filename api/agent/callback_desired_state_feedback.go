@@ -20,6 +20,9 @@ import (
 func (agent *updateAgent) HandleDesiredStateFeedbackEvent(domain string, activityID string, baseline string, status types.StatusType, message string, actions []*types.Action) {
 	logger.Debug("handle desired state feedback event for domain and activityId '%s' - '%s'", domain, activityID)
 
+	agent.desiredStateFeedbackLock.Lock()
+	defer agent.desiredStateFeedbackLock.Unlock()
+
 	if status != types.StatusRunning {
 		agent.publishDesiredStateFeedback(activityID, &types.DesiredStateFeedback{
 			Baseline: baseline,
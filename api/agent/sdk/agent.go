@@ -0,0 +1,71 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package sdk
+
+import (
+	"time"
+
+	"github.com/eclipse-kanto/update-manager/api"
+	"github.com/eclipse-kanto/update-manager/api/agent"
+	"github.com/eclipse-kanto/update-manager/mqtt"
+)
+
+const defaultFeedbackInterval = time.Second
+
+type agentOptions struct {
+	feedbackInterval  time.Duration
+	currentStateDelay time.Duration
+	clientOptions     []mqtt.UpdateAgentClientOption
+}
+
+// AgentOption defines an optional configuration of an update agent created with NewUpdateAgent.
+type AgentOption func(*agentOptions)
+
+// WithFeedbackInterval defines the interval, which the progress of the actions is reported at most once per, one second by default.
+// A zero interval disables the progress reports.
+func WithFeedbackInterval(interval time.Duration) AgentOption {
+	return func(options *agentOptions) {
+		options.feedbackInterval = interval
+	}
+}
+
+// WithCurrentStateDelay defines the delay of the current state reports, which are not related to an update, e.g. if the current state
+// changes meanwhile, only the latest current state will be sent. The current state is reported immediately by default.
+func WithCurrentStateDelay(delay time.Duration) AgentOption {
+	return func(options *agentOptions) {
+		options.currentStateDelay = delay
+	}
+}
+
+// WithClientOptions defines the options of the MQTT client of the update agent, e.g. the signature verification of the desired states.
+func WithClientOptions(clientOptions ...mqtt.UpdateAgentClientOption) AgentOption {
+	return func(options *agentOptions) {
+		options.clientOptions = append(options.clientOptions, clientOptions...)
+	}
+}
+
+// NewUpdateAgent creates an update agent for the domain of the given update manager, which communicates with the Update Manager
+// over the MQTT broker of the given connection configuration.
+func NewUpdateAgent(config *mqtt.ConnectionConfig, manager UpdateManager, opts ...AgentOption) (api.UpdateAgent, error) {
+	options := &agentOptions{feedbackInterval: defaultFeedbackInterval}
+	for _, opt := range opts {
+		opt(options)
+	}
+	client, err := mqtt.NewUpdateAgentClient(manager.Name(), config, options.clientOptions...)
+	if err != nil {
+		return nil, err
+	}
+	return agent.NewUpdateAgent(client, manager,
+		agent.WithDesiredStateFeedbackReportInterval(options.feedbackInterval),
+		agent.WithCurrentStateReportDelay(options.currentStateDelay)), nil
+}
@@ -0,0 +1,258 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package sdk
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/eclipse-kanto/update-manager/api"
+	"github.com/eclipse-kanto/update-manager/api/types"
+)
+
+const (
+	pathCache            = "/cache"
+	pathCacheArtifact    = "/cache/artifact"
+	pathCacheReservation = "/cache/reservation"
+	pathCacheCommit      = "/cache/commit"
+
+	unixSocketPrefix = "unix://"
+
+	artifactCacheTimeout = 2 * time.Minute
+)
+
+// artifactCacheError is an artifact cache error reported by the local HTTP API, which wraps the respective api error.
+type artifactCacheError struct {
+	err     error
+	message string
+}
+
+func (err *artifactCacheError) Error() string {
+	return err.message
+}
+
+func (err *artifactCacheError) Unwrap() error {
+	return err.err
+}
+
+type artifactCacheClient struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewArtifactCacheClient creates a client of the artifact cache shared between the domain agents, which is provided by the Update Manager
+// through its local HTTP API on the given address, either a unix socket in the format 'unix:///path/to/socket' or a 'host:port' address.
+func NewArtifactCacheClient(address string) api.ArtifactCache {
+	client := &artifactCacheClient{
+		baseURL:    address,
+		httpClient: &http.Client{Timeout: artifactCacheTimeout},
+	}
+	if strings.HasPrefix(address, unixSocketPrefix) {
+		socket := strings.TrimPrefix(address, unixSocketPrefix)
+		client.baseURL = "http://localhost"
+		client.httpClient.Transport = &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return (&net.Dialer{}).DialContext(ctx, "unix", socket)
+			},
+		}
+	} else if !strings.Contains(address, "://") {
+		client.baseURL = "http://" + address
+	}
+	client.baseURL = strings.TrimSuffix(client.baseURL, "/")
+	return client
+}
+
+func (client *artifactCacheClient) Lookup(digest string) (*types.CachedArtifact, error) {
+	cached := &types.CachedArtifact{}
+	if err := client.do(http.MethodGet, pathCacheArtifact+"?digest="+url.QueryEscape(digest), nil, cached, api.ErrArtifactNotCached); err != nil {
+		return nil, err
+	}
+	return cached, nil
+}
+
+func (client *artifactCacheClient) Reserve(digest string, size int64) (*types.ArtifactReservation, error) {
+	reservation := &types.ArtifactReservation{}
+	if err := client.do(http.MethodPost, pathCacheReservation, &types.ArtifactReservation{Digest: digest, Size: size}, reservation, nil); err != nil {
+		return nil, err
+	}
+	return reservation, nil
+}
+
+func (client *artifactCacheClient) Commit(reservationID string) (*types.CachedArtifact, error) {
+	cached := &types.CachedArtifact{}
+	if err := client.do(http.MethodPost, pathCacheCommit+"?reservationId="+url.QueryEscape(reservationID), nil, cached,
+		api.ErrArtifactReservationNotFound); err != nil {
+		return nil, err
+	}
+	return cached, nil
+}
+
+func (client *artifactCacheClient) Release(reservationID string) error {
+	return client.do(http.MethodDelete, pathCacheReservation+"?reservationId="+url.QueryEscape(reservationID), nil, nil,
+		api.ErrArtifactReservationNotFound)
+}
+
+func (client *artifactCacheClient) Usage() (*types.ArtifactCacheUsage, error) {
+	usage := &types.ArtifactCacheUsage{}
+	if err := client.do(http.MethodGet, pathCache, nil, usage, nil); err != nil {
+		return nil, err
+	}
+	return usage, nil
+}
+
+// do sends the given request payload, if any, and parses the response into the given result, if any. The error responses are returned
+// as errors wrapping the api error of their status code, the given not found error for status code 404.
+func (client *artifactCacheClient) do(method, path string, payload, result interface{}, notFound error) error {
+	var body io.Reader
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	request, err := http.NewRequest(method, client.baseURL+path, body)
+	if err != nil {
+		return err
+	}
+	if payload != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+	response, err := client.httpClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	data, err := io.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+	if response.StatusCode >= http.StatusBadRequest {
+		errorResponse := &struct {
+			Error string `json:"error"`
+		}{}
+		message := fmt.Sprintf("artifact cache request failed with status %d", response.StatusCode)
+		if err := json.Unmarshal(data, errorResponse); err == nil && errorResponse.Error != "" {
+			message = errorResponse.Error
+		}
+		switch response.StatusCode {
+		case http.StatusNotFound:
+			return &artifactCacheError{err: notFound, message: message}
+		case http.StatusInsufficientStorage:
+			return &artifactCacheError{err: api.ErrArtifactCacheFull, message: message}
+		case http.StatusBadRequest:
+			return &artifactCacheError{err: api.ErrInvalidArtifact, message: message}
+		}
+		return errors.New(message)
+	}
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(data, result)
+}
+
+// FetchArtifact links, or copies if not possible, the cached artifact with the given digest to the given file, so that the download
+// of the artifact can be skipped. False is returned if the artifact is not cached.
+func FetchArtifact(cache api.ArtifactCache, digest, file string) (bool, error) {
+	cached, err := cache.Lookup(digest)
+	if errors.Is(err, api.ErrArtifactNotCached) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if err := linkOrCopy(cached.Path, file); err != nil {
+		if os.IsNotExist(err) {
+			// the artifact has been evicted meanwhile
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// StoreArtifact stores the given downloaded file in the artifact cache with the given digest. The file is linked, or copied if not possible,
+// to the path of a reservation for the artifact, which is then committed. The file shall not be modified afterwards.
+func StoreArtifact(cache api.ArtifactCache, digest, file string) error {
+	info, err := os.Stat(file)
+	if err != nil {
+		return err
+	}
+	reservation, err := cache.Reserve(digest, info.Size())
+	if err != nil {
+		return err
+	}
+	if err := linkOrCopy(file, reservation.Path); err != nil {
+		cache.Release(reservation.ID)
+		return err
+	}
+	_, err = cache.Commit(reservation.ID)
+	return err
+}
+
+// linkOrCopy replaces the given target file with a hard link of the given source file, or with a copy of it if the hard link
+// cannot be created, e.g. on another file system.
+func linkOrCopy(source, target string) error {
+	link := target + ".link"
+	if err := os.Remove(link); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.Link(source, link); err == nil {
+		if err := os.Rename(link, target); err != nil {
+			os.Remove(link)
+			return err
+		}
+		return nil
+	}
+	in, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	temp, err := os.CreateTemp(filepath.Dir(target), filepath.Base(target)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if err := temp.Chmod(0644); err != nil {
+		temp.Close()
+		os.Remove(temp.Name())
+		return err
+	}
+	if _, err := io.Copy(temp, in); err != nil {
+		temp.Close()
+		os.Remove(temp.Name())
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		os.Remove(temp.Name())
+		return err
+	}
+	if err := os.Rename(temp.Name(), target); err != nil {
+		os.Remove(temp.Name())
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package sdk
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/eclipse-kanto/update-manager/api"
+	"github.com/eclipse-kanto/update-manager/api/types"
+	"github.com/eclipse-kanto/update-manager/rest"
+	"github.com/eclipse-kanto/update-manager/test/mocks"
+	"github.com/eclipse-kanto/update-manager/updatem/cache"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testArtifactCacheHandler struct {
+	*mocks.MockUpdateAgentHandler
+	cache api.ArtifactCache
+}
+
+func (handler *testArtifactCacheHandler) ArtifactCache() api.ArtifactCache {
+	return handler.cache
+}
+
+// newTestArtifactCacheClient starts the local HTTP API with an artifact cache in a temporary directory and returns a client of it.
+func newTestArtifactCacheClient(t *testing.T) api.ArtifactCache {
+	mockCtrl := gomock.NewController(t)
+	dir := t.TempDir()
+	artifactCache, err := cache.New(&cache.Config{Dir: filepath.Join(dir, "cache"), MaxSize: 1, ReservationTimeout: "1m"})
+	require.NoError(t, err)
+
+	mockDelegate := mocks.NewMockUpdateAgentClient(mockCtrl)
+	mockDelegate.EXPECT().Domain().Return("device").AnyTimes()
+	mockDelegate.EXPECT().Start(gomock.Any()).Return(nil)
+	mockDelegate.EXPECT().Stop().Return(nil)
+	address := "unix://" + filepath.Join(dir, "um.sock")
+	server, err := rest.NewUpdateAgentClient(mockDelegate, &rest.ServerConfig{Address: address})
+	require.NoError(t, err)
+	require.NoError(t, server.Start(&testArtifactCacheHandler{mocks.NewMockUpdateAgentHandler(mockCtrl), artifactCache}))
+	t.Cleanup(func() {
+		server.Stop()
+	})
+	return NewArtifactCacheClient(address)
+}
+
+func TestArtifactCacheClient(t *testing.T) {
+	client := newTestArtifactCacheClient(t)
+	dir := t.TempDir()
+	sum := sha256.Sum256([]byte("data"))
+	digest := "sha256:" + hex.EncodeToString(sum[:])
+
+	fetched, err := FetchArtifact(client, digest, filepath.Join(dir, "fetched"))
+	require.NoError(t, err)
+	assert.False(t, fetched)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "downloaded"), []byte("data"), 0644))
+	require.NoError(t, StoreArtifact(client, digest, filepath.Join(dir, "downloaded")))
+	fetched, err = FetchArtifact(client, digest, filepath.Join(dir, "fetched"))
+	require.NoError(t, err)
+	assert.True(t, fetched)
+	data, err := os.ReadFile(filepath.Join(dir, "fetched"))
+	require.NoError(t, err)
+	assert.Equal(t, "data", string(data))
+
+	usage, err := client.Usage()
+	require.NoError(t, err)
+	assert.Equal(t, &types.ArtifactCacheUsage{Artifacts: 1, Size: 4, MaxSize: 1 << 20}, usage)
+
+	// the artifact is verified on commit
+	assert.True(t, errors.Is(StoreArtifact(client, "sha256:"+hex.EncodeToString(make([]byte, 32)), filepath.Join(dir, "downloaded")),
+		api.ErrInvalidArtifact))
+	_, err = client.Reserve(digest, 2<<20)
+	assert.True(t, errors.Is(err, api.ErrArtifactCacheFull))
+	_, err = client.Reserve("md5:abc", 0)
+	assert.True(t, errors.Is(err, api.ErrInvalidArtifact))
+	_, err = client.Commit("unknown")
+	assert.True(t, errors.Is(err, api.ErrArtifactReservationNotFound))
+
+	reservation, err := client.Reserve(digest, 4)
+	require.NoError(t, err)
+	assert.NoError(t, client.Release(reservation.ID))
+	assert.True(t, errors.Is(client.Release(reservation.ID), api.ErrArtifactReservationNotFound))
+}
+
+func TestLinkOrCopy(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "source"), []byte("new"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "target"), []byte("old"), 0644))
+
+	require.NoError(t, linkOrCopy(filepath.Join(dir, "source"), filepath.Join(dir, "target")))
+	data, err := os.ReadFile(filepath.Join(dir, "target"))
+	require.NoError(t, err)
+	assert.Equal(t, "new", string(data))
+
+	// the target file is kept, if the source file does not exist
+	assert.True(t, os.IsNotExist(linkOrCopy(filepath.Join(dir, "missing"), filepath.Join(dir, "target"))))
+	assert.FileExists(t, filepath.Join(dir, "target"))
+}
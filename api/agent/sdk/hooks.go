@@ -0,0 +1,107 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package sdk
+
+import (
+	"context"
+
+	"github.com/eclipse-kanto/update-manager/api/types"
+)
+
+// Hooks defines the domain specific operations of an update agent. The update agent SDK receives the desired states and commands,
+// enforces the status transitions of the baselines and actions, reports the desired state feedback and calls the hooks on the way.
+type Hooks interface {
+	// CurrentState returns the current state of the domain.
+	CurrentState(ctx context.Context) (*types.Inventory, error)
+	// Identify returns the actions required to bring the domain to its desired state.
+	Identify(ctx context.Context, activityID string, domain *types.Domain) ([]*Action, error)
+	// Download downloads the artifacts of the given action.
+	Download(ctx context.Context, action *Action) error
+	// Update installs, modifies or removes the component of the given action.
+	Update(ctx context.Context, action *Action) error
+	// Activate activates the updated component of the given action.
+	Activate(ctx context.Context, action *Action) error
+	// Rollback restores the component of the given action to its state before the update.
+	Rollback(ctx context.Context, action *Action) error
+	// Cleanup releases the resources held for the given action, e.g. the downloaded artifacts, it is called for each action once the update is over.
+	Cleanup(ctx context.Context, action *Action) error
+}
+
+// NopHooks can be embedded by the hooks of update agents, which do not need to download, activate, rollback or clean up components.
+type NopHooks struct{}
+
+// Download does nothing.
+func (NopHooks) Download(ctx context.Context, action *Action) error {
+	return nil
+}
+
+// Activate does nothing.
+func (NopHooks) Activate(ctx context.Context, action *Action) error {
+	return nil
+}
+
+// Rollback does nothing.
+func (NopHooks) Rollback(ctx context.Context, action *Action) error {
+	return nil
+}
+
+// Cleanup does nothing.
+func (NopHooks) Cleanup(ctx context.Context, action *Action) error {
+	return nil
+}
+
+// Action defines an action identified for a component of the domain.
+type Action struct {
+	// Component holds the desired component with its configuration, or the component to be removed.
+	Component *types.ComponentWithConfig
+	// Remove denotes that the component is to be removed, removal actions are not downloaded nor activated.
+	Remove bool
+
+	status    types.ActionStatusType
+	progress  uint8
+	message   string
+	manager   *updateManager
+	operation *operation
+}
+
+// Status returns the current status of the action.
+func (action *Action) Status() types.ActionStatusType {
+	if action.manager == nil {
+		return action.status
+	}
+	action.manager.actionsLock.Lock()
+	defer action.manager.actionsLock.Unlock()
+
+	return action.status
+}
+
+// Progress reports the progress in percentage and an optional message for the running phase of the action.
+// The progress is reported with the desired state feedback, throttled by the feedback report interval of the update agent.
+func (action *Action) Progress(progress uint8, message string) {
+	if action.manager != nil {
+		action.manager.progress(action, progress, message)
+	}
+}
+
+func (action *Action) toAction() *types.Action {
+	component := &types.Component{}
+	if action.Component != nil {
+		component = &types.Component{ID: action.Component.ID, Version: action.Component.Version}
+	}
+	return &types.Action{
+		Component: component,
+		Status:    action.status,
+		Progress:  action.progress,
+		Message:   action.message,
+	}
+}
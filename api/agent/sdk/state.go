@@ -0,0 +1,113 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package sdk
+
+import (
+	"github.com/eclipse-kanto/update-manager/api/types"
+
+	"github.com/pkg/errors"
+)
+
+// commandStates maps the desired state commands to the baseline statuses, which they are accepted in.
+var commandStates = map[types.CommandType][]types.StatusType{
+	types.CommandDownload: {types.StatusIdentified},
+	types.CommandUpdate:   {types.BaselineStatusDownloadSuccess},
+	types.CommandActivate: {types.BaselineStatusUpdateSuccess},
+	types.CommandRollback: {types.BaselineStatusDownloadSuccess, types.BaselineStatusUpdateSuccess,
+		types.BaselineStatusUpdateFailure, types.BaselineStatusActivationFailure},
+	types.CommandCleanup: {types.StatusIdentified, types.BaselineStatusDownloadSuccess, types.BaselineStatusDownloadFailure,
+		types.BaselineStatusUpdateSuccess, types.BaselineStatusUpdateFailure, types.BaselineStatusActivationSuccess,
+		types.BaselineStatusActivationFailure, types.BaselineStatusRollbackSuccess, types.BaselineStatusRollbackFailure},
+}
+
+// commandStatuses maps the desired state commands to the baseline statuses reported while running, on success and on failure.
+var commandStatuses = map[types.CommandType][3]types.StatusType{
+	types.CommandDownload: {types.BaselineStatusDownloading, types.BaselineStatusDownloadSuccess, types.BaselineStatusDownloadFailure},
+	types.CommandUpdate:   {types.BaselineStatusUpdating, types.BaselineStatusUpdateSuccess, types.BaselineStatusUpdateFailure},
+	types.CommandActivate: {types.BaselineStatusActivating, types.BaselineStatusActivationSuccess, types.BaselineStatusActivationFailure},
+	types.CommandRollback: {types.BaselineStatusRollback, types.BaselineStatusRollbackSuccess, types.BaselineStatusRollbackFailure},
+	types.CommandCleanup:  {types.BaselineStatusCleanup, types.BaselineStatusCleanupSuccess, types.BaselineStatusCleanupFailure},
+}
+
+// actionTransitions maps the action statuses to the action statuses, which an action can move to.
+var actionTransitions = map[types.ActionStatusType][]types.ActionStatusType{
+	types.ActionStatusIdentified:      {types.ActionStatusDownloading, types.ActionStatusRemoving},
+	types.ActionStatusDownloading:     {types.ActionStatusDownloadSuccess, types.ActionStatusDownloadFailure},
+	types.ActionStatusDownloadSuccess: {types.ActionStatusUpdating},
+	types.ActionStatusUpdating:        {types.ActionStatusUpdateSuccess, types.ActionStatusUpdateFailure},
+	types.ActionStatusUpdateSuccess:   {types.ActionStatusActivating},
+	types.ActionStatusActivating:      {types.ActionStatusActivationSuccess, types.ActionStatusActivationFailure},
+	types.ActionStatusRemoving:        {types.ActionStatusRemovalSuccess, types.ActionStatusRemovalFailure},
+}
+
+// actionPhases maps the desired state commands to the action statuses, which an action is processed from,
+// and the action statuses reported while running, on success and on failure.
+var actionPhases = map[types.CommandType]map[types.ActionStatusType][3]types.ActionStatusType{
+	types.CommandDownload: {
+		types.ActionStatusIdentified: {types.ActionStatusDownloading, types.ActionStatusDownloadSuccess, types.ActionStatusDownloadFailure},
+	},
+	types.CommandUpdate: {
+		types.ActionStatusDownloadSuccess: {types.ActionStatusUpdating, types.ActionStatusUpdateSuccess, types.ActionStatusUpdateFailure},
+		types.ActionStatusIdentified:      {types.ActionStatusRemoving, types.ActionStatusRemovalSuccess, types.ActionStatusRemovalFailure},
+	},
+	types.CommandActivate: {
+		types.ActionStatusUpdateSuccess: {types.ActionStatusActivating, types.ActionStatusActivationSuccess, types.ActionStatusActivationFailure},
+	},
+}
+
+// actionPhase returns the action statuses of the given command phase for the given action, or false if the action is not processed in this phase.
+func actionPhase(command types.CommandType, action *Action) ([3]types.ActionStatusType, bool) {
+	phase, ok := actionPhases[command][action.status]
+	if !ok || action.Remove != (phase[0] == types.ActionStatusRemoving) {
+		return phase, false
+	}
+	return phase, true
+}
+
+// checkCommand returns an error if the given command is not accepted in the given baseline status.
+func checkCommand(status types.StatusType, command types.CommandType) error {
+	states, ok := commandStates[command]
+	if !ok {
+		return errors.Errorf("unknown command '%s'", command)
+	}
+	for _, state := range states {
+		if state == status {
+			return nil
+		}
+	}
+	return errors.Errorf("command '%s' is not accepted in status '%s'", command, status)
+}
+
+// checkTransition returns an error if an action cannot move from the given status to the next one.
+func checkTransition(status, next types.ActionStatusType) error {
+	for _, allowed := range actionTransitions[status] {
+		if allowed == next {
+			return nil
+		}
+	}
+	return errors.Errorf("action cannot move from status '%s' to status '%s'", status, next)
+}
+
+// isInProgress returns true if the given action status denotes a running phase, which progress can be reported for.
+func isInProgress(status types.ActionStatusType) bool {
+	return status == types.ActionStatusDownloading || status == types.ActionStatusUpdating ||
+		status == types.ActionStatusActivating || status == types.ActionStatusRemoving
+}
+
+// isCompleted returns true if the given action status denotes that the action has completed successfully.
+func isCompleted(action *Action) bool {
+	if action.Remove {
+		return action.status == types.ActionStatusRemovalSuccess
+	}
+	return action.status == types.ActionStatusActivationSuccess
+}
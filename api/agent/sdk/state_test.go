@@ -0,0 +1,45 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package sdk
+
+import (
+	"testing"
+
+	"github.com/eclipse-kanto/update-manager/api/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckCommand(t *testing.T) {
+	assert.NoError(t, checkCommand(types.StatusIdentified, types.CommandDownload))
+	assert.NoError(t, checkCommand(types.BaselineStatusDownloadSuccess, types.CommandUpdate))
+	assert.NoError(t, checkCommand(types.BaselineStatusUpdateSuccess, types.CommandActivate))
+	assert.NoError(t, checkCommand(types.BaselineStatusActivationFailure, types.CommandRollback))
+	assert.NoError(t, checkCommand(types.BaselineStatusRollbackSuccess, types.CommandCleanup))
+
+	assert.EqualError(t, checkCommand(types.StatusIdentified, types.CommandUpdate), "command 'UPDATE' is not accepted in status 'IDENTIFIED'")
+	assert.EqualError(t, checkCommand(types.BaselineStatusDownloadFailure, types.CommandUpdate), "command 'UPDATE' is not accepted in status 'DOWNLOAD_FAILURE'")
+	assert.EqualError(t, checkCommand(types.BaselineStatusCleanupSuccess, types.CommandCleanup), "command 'CLEANUP' is not accepted in status 'CLEANUP_SUCCESS'")
+	assert.EqualError(t, checkCommand(types.StatusIdentified, "UNKNOWN"), "unknown command 'UNKNOWN'")
+}
+
+func TestCheckTransition(t *testing.T) {
+	assert.NoError(t, checkTransition(types.ActionStatusIdentified, types.ActionStatusDownloading))
+	assert.NoError(t, checkTransition(types.ActionStatusIdentified, types.ActionStatusRemoving))
+	assert.NoError(t, checkTransition(types.ActionStatusUpdateSuccess, types.ActionStatusActivating))
+
+	assert.EqualError(t, checkTransition(types.ActionStatusIdentified, types.ActionStatusUpdating),
+		"action cannot move from status 'IDENTIFIED' to status 'UPDATING'")
+	assert.EqualError(t, checkTransition(types.ActionStatusActivationSuccess, types.ActionStatusDownloading),
+		"action cannot move from status 'ACTIVATION_SUCCESS' to status 'DOWNLOADING'")
+}
@@ -0,0 +1,360 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package sdk
+
+import (
+	"context"
+	"sync"
+
+	"github.com/eclipse-kanto/update-manager/api"
+	"github.com/eclipse-kanto/update-manager/api/types"
+	"github.com/eclipse-kanto/update-manager/logger"
+
+	"github.com/pkg/errors"
+)
+
+// UpdateManager defines an update manager of a domain, which is driven by hooks.
+type UpdateManager interface {
+	api.UpdateManager
+	// PublishCurrentState reports the current state of the domain, e.g. when it has changed outside of an update.
+	PublishCurrentState(ctx context.Context)
+}
+
+type updateManager struct {
+	name          string
+	hooks         Hooks
+	eventCallback api.UpdateManagerCallback
+
+	operation *operation
+
+	operationLock sync.Mutex
+	actionsLock   sync.Mutex
+}
+
+type operation struct {
+	activityID string
+	baselines  []*types.Baseline
+	actions    []*Action
+	// statuses holds the status of the commanded baselines, the empty baseline stands for all actions
+	statuses map[string]types.StatusType
+	cleaned  map[*Action]bool
+	done     bool
+}
+
+// NewUpdateManager creates an update manager for the given domain, which brings the domain to its desired state by calling the given hooks.
+// The update manager can be run as update agent with NewUpdateAgent or agent.NewUpdateAgent.
+func NewUpdateManager(domain string, hooks Hooks) UpdateManager {
+	return &updateManager{
+		name:  domain,
+		hooks: hooks,
+	}
+}
+
+func (updateManager *updateManager) Name() string {
+	return updateManager.name
+}
+
+func (updateManager *updateManager) SetCallback(callback api.UpdateManagerCallback) {
+	updateManager.eventCallback = callback
+}
+
+func (updateManager *updateManager) WatchEvents(ctx context.Context) {
+	// no events to watch, the current state is reported on request, after each update and on PublishCurrentState
+}
+
+func (updateManager *updateManager) Get(ctx context.Context, activityID string) (*types.Inventory, error) {
+	return updateManager.hooks.CurrentState(ctx)
+}
+
+func (updateManager *updateManager) Dispose() error {
+	return nil
+}
+
+func (updateManager *updateManager) PublishCurrentState(ctx context.Context) {
+	updateManager.publishCurrentState(ctx, "")
+}
+
+// Apply identifies the actions required to bring the domain to the given desired state. An operation in progress is superseded.
+func (updateManager *updateManager) Apply(ctx context.Context, activityID string, desiredState *types.DesiredState) {
+	updateManager.operationLock.Lock()
+	defer updateManager.operationLock.Unlock()
+
+	if previous := updateManager.operation; previous != nil && !previous.done {
+		previous.done = true
+		updateManager.feedback(previous.activityID, "", types.StatusSuperseded, "", updateManager.snapshot(previous))
+	}
+	operation := &operation{
+		activityID: activityID,
+		statuses:   map[string]types.StatusType{},
+		cleaned:    map[*Action]bool{},
+	}
+	updateManager.operation = operation
+	domain := &types.Domain{ID: updateManager.name}
+	if desiredState != nil {
+		operation.baselines = desiredState.Baselines
+		for _, desiredDomain := range desiredState.Domains {
+			if desiredDomain != nil && desiredDomain.ID == updateManager.name {
+				domain = desiredDomain
+			}
+		}
+	}
+
+	updateManager.feedback(activityID, "", types.StatusIdentifying, "", nil)
+	actions, err := updateManager.hooks.Identify(ctx, activityID, domain)
+	if err != nil {
+		operation.done = true
+		updateManager.feedback(activityID, "", types.StatusIdentificationFailed, err.Error(), nil)
+		return
+	}
+	updateManager.actionsLock.Lock()
+	for _, action := range actions {
+		if action != nil {
+			action.status = types.ActionStatusIdentified
+			action.progress = 0
+			action.message = ""
+			action.manager = updateManager
+			action.operation = operation
+			operation.actions = append(operation.actions, action)
+		}
+	}
+	updateManager.actionsLock.Unlock()
+
+	updateManager.feedback(activityID, "", types.StatusIdentified, "", updateManager.snapshot(operation))
+	if len(operation.actions) == 0 {
+		operation.done = true
+		updateManager.feedback(activityID, "", types.StatusCompleted, "", updateManager.snapshot(operation))
+	}
+}
+
+// Command runs the given command for the actions of the commanded baseline, if the baseline status allows it.
+func (updateManager *updateManager) Command(ctx context.Context, activityID string, command *types.DesiredStateCommand) {
+	updateManager.operationLock.Lock()
+	defer updateManager.operationLock.Unlock()
+
+	log := logger.WithFields(logger.Fields{Domain: updateManager.name, ActivityID: activityID})
+	operation := updateManager.operation
+	if command == nil {
+		log.Warn("ignoring empty desired state command")
+		return
+	}
+	if operation == nil || operation.activityID != activityID || operation.done {
+		log.Warn("ignoring desired state command '%s' for unknown or finished activity", command.Command)
+		return
+	}
+	actions, err := operation.baselineActions(updateManager.name, command.Baseline)
+	if err != nil {
+		log.WarnErr(err, "ignoring desired state command '%s'", command.Command)
+		return
+	}
+	status, ok := operation.statuses[command.Baseline]
+	if !ok {
+		status = types.StatusIdentified
+	}
+	if err := checkCommand(status, command.Command); err != nil {
+		log.WarnErr(err, "ignoring desired state command for baseline '%s'", command.Baseline)
+		return
+	}
+
+	statuses := commandStatuses[command.Command]
+	operation.statuses[command.Baseline] = statuses[0]
+	updateManager.feedback(activityID, command.Baseline, statuses[0], "", updateManager.snapshot(operation))
+	switch command.Command {
+	case types.CommandRollback:
+		err = updateManager.rollback(ctx, actions)
+	case types.CommandCleanup:
+		err = updateManager.cleanup(ctx, operation, actions)
+	default:
+		err = updateManager.runPhase(ctx, operation, command.Command, actions)
+	}
+	result, message := statuses[1], ""
+	if err != nil {
+		result, message = statuses[2], err.Error()
+	}
+	operation.statuses[command.Baseline] = result
+	updateManager.feedback(activityID, command.Baseline, result, message, updateManager.snapshot(operation))
+
+	if command.Command == types.CommandCleanup && len(operation.cleaned) == len(operation.actions) {
+		updateManager.finish(ctx, operation)
+	}
+}
+
+// runPhase runs the download, update or activate phase for the given actions, until an action fails.
+func (updateManager *updateManager) runPhase(ctx context.Context, operation *operation, command types.CommandType, actions []*Action) error {
+	hook := map[types.CommandType]func(context.Context, *Action) error{
+		types.CommandDownload: updateManager.hooks.Download,
+		types.CommandUpdate:   updateManager.hooks.Update,
+		types.CommandActivate: updateManager.hooks.Activate,
+	}[command]
+	for _, action := range actions {
+		updateManager.actionsLock.Lock()
+		phase, ok := actionPhase(command, action)
+		updateManager.actionsLock.Unlock()
+		if !ok {
+			continue
+		}
+		if err := updateManager.setStatus(action, phase[0], ""); err != nil {
+			return err
+		}
+		updateManager.feedback(operation.activityID, "", types.StatusRunning, "", updateManager.snapshot(operation))
+		if err := hook(ctx, action); err != nil {
+			if statusErr := updateManager.setStatus(action, phase[2], err.Error()); statusErr != nil {
+				return statusErr
+			}
+			return errors.Wrapf(err, "action for component %s failed", action.Component.ID)
+		}
+		if err := updateManager.setStatus(action, phase[1], ""); err != nil {
+			return err
+		}
+		updateManager.feedback(operation.activityID, "", types.StatusRunning, "", updateManager.snapshot(operation))
+	}
+	return nil
+}
+
+// rollback restores the components of the given actions, which have been updated or removed.
+func (updateManager *updateManager) rollback(ctx context.Context, actions []*Action) error {
+	var result error
+	for _, action := range actions {
+		switch action.Status() {
+		case types.ActionStatusIdentified, types.ActionStatusDownloadSuccess, types.ActionStatusDownloadFailure:
+			continue
+		}
+		if err := updateManager.hooks.Rollback(ctx, action); err != nil && result == nil {
+			result = errors.Wrapf(err, "rollback of component %s failed", action.Component.ID)
+		}
+	}
+	return result
+}
+
+// cleanup releases the resources held for the given actions, even if some of them fail.
+func (updateManager *updateManager) cleanup(ctx context.Context, operation *operation, actions []*Action) error {
+	var result error
+	for _, action := range actions {
+		operation.cleaned[action] = true
+		if err := updateManager.hooks.Cleanup(ctx, action); err != nil && result == nil {
+			result = errors.Wrapf(err, "cleanup of component %s failed", action.Component.ID)
+		}
+	}
+	return result
+}
+
+// finish reports the operation as completed if all actions and baselines have completed successfully, or as incomplete otherwise,
+// and reports the current state of the domain.
+func (updateManager *updateManager) finish(ctx context.Context, operation *operation) {
+	operation.done = true
+	status := types.StatusCompleted
+	for _, baselineStatus := range operation.statuses {
+		if baselineStatus != types.BaselineStatusCleanupSuccess {
+			status = types.StatusIncomplete
+		}
+	}
+	updateManager.actionsLock.Lock()
+	for _, action := range operation.actions {
+		if !isCompleted(action) {
+			status = types.StatusIncomplete
+		}
+	}
+	updateManager.actionsLock.Unlock()
+	updateManager.feedback(operation.activityID, "", status, "", updateManager.snapshot(operation))
+	updateManager.publishCurrentState(ctx, operation.activityID)
+}
+
+func (updateManager *updateManager) setStatus(action *Action, status types.ActionStatusType, message string) error {
+	updateManager.actionsLock.Lock()
+	defer updateManager.actionsLock.Unlock()
+
+	if err := checkTransition(action.status, status); err != nil {
+		return err
+	}
+	action.status = status
+	action.message = message
+	switch {
+	case isInProgress(status):
+		action.progress = 0
+	case message == "":
+		action.progress = 100
+	}
+	return nil
+}
+
+func (updateManager *updateManager) progress(action *Action, progress uint8, message string) {
+	updateManager.actionsLock.Lock()
+	if !isInProgress(action.status) {
+		updateManager.actionsLock.Unlock()
+		logger.Debug("[%s] ignoring progress of action in status '%s'", updateManager.name, action.status)
+		return
+	}
+	if progress > 100 {
+		progress = 100
+	}
+	if action.progress == progress && action.message == message {
+		updateManager.actionsLock.Unlock()
+		return
+	}
+	action.progress = progress
+	action.message = message
+	updateManager.actionsLock.Unlock()
+
+	updateManager.feedback(action.operation.activityID, "", types.StatusRunning, "", updateManager.snapshot(action.operation))
+}
+
+// snapshot returns a copy of the actions of the given operation, so that the reported actions do not change with the following updates.
+func (updateManager *updateManager) snapshot(operation *operation) []*types.Action {
+	updateManager.actionsLock.Lock()
+	defer updateManager.actionsLock.Unlock()
+
+	actions := make([]*types.Action, 0, len(operation.actions))
+	for _, action := range operation.actions {
+		actions = append(actions, action.toAction())
+	}
+	return actions
+}
+
+func (updateManager *updateManager) feedback(activityID, baseline string, status types.StatusType, message string, actions []*types.Action) {
+	if updateManager.eventCallback != nil {
+		updateManager.eventCallback.HandleDesiredStateFeedbackEvent(updateManager.name, activityID, baseline, status, message, actions)
+	}
+}
+
+func (updateManager *updateManager) publishCurrentState(ctx context.Context, activityID string) {
+	currentState, err := updateManager.hooks.CurrentState(ctx)
+	if err != nil {
+		logger.ErrorErr(err, "[%s] cannot get current state", updateManager.name)
+		return
+	}
+	if updateManager.eventCallback != nil {
+		updateManager.eventCallback.HandleCurrentStateEvent(updateManager.name, activityID, currentState)
+	}
+}
+
+// baselineActions returns the actions for the components of the given baseline, or all actions for the empty baseline.
+func (operation *operation) baselineActions(domain, baseline string) ([]*Action, error) {
+	if baseline == "" {
+		return operation.actions, nil
+	}
+	for _, desiredBaseline := range operation.baselines {
+		if desiredBaseline == nil || desiredBaseline.Title != baseline {
+			continue
+		}
+		var actions []*Action
+		for _, action := range operation.actions {
+			for _, component := range desiredBaseline.Components {
+				if action.Component != nil && component == domain+":"+action.Component.ID {
+					actions = append(actions, action)
+					break
+				}
+			}
+		}
+		return actions, nil
+	}
+	return nil, errors.Errorf("unknown baseline '%s'", baseline)
+}
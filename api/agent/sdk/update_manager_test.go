@@ -0,0 +1,320 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package sdk
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/eclipse-kanto/update-manager/api/types"
+	"github.com/eclipse-kanto/update-manager/mqtt"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testDomain     = "test"
+	testActivityID = "test-activity-id"
+)
+
+type testFeedback struct {
+	baseline string
+	status   types.StatusType
+	message  string
+	actions  []*types.Action
+}
+
+type testCallback struct {
+	lock          sync.Mutex
+	feedbacks     []*testFeedback
+	currentStates []*types.Inventory
+}
+
+func (callback *testCallback) HandleDesiredStateFeedbackEvent(domain, activityID, baseline string, status types.StatusType, message string, actions []*types.Action) {
+	callback.lock.Lock()
+	defer callback.lock.Unlock()
+
+	callback.feedbacks = append(callback.feedbacks, &testFeedback{baseline: baseline, status: status, message: message, actions: actions})
+}
+
+func (callback *testCallback) HandleCurrentStateEvent(domain, activityID string, currentState *types.Inventory) {
+	callback.lock.Lock()
+	defer callback.lock.Unlock()
+
+	callback.currentStates = append(callback.currentStates, currentState)
+}
+
+// statuses returns the reported statuses except RUNNING and clears the recorded feedback.
+func (callback *testCallback) statuses() []types.StatusType {
+	callback.lock.Lock()
+	defer callback.lock.Unlock()
+
+	var statuses []types.StatusType
+	for _, feedback := range callback.feedbacks {
+		if feedback.status != types.StatusRunning {
+			statuses = append(statuses, feedback.status)
+		}
+	}
+	callback.feedbacks = nil
+	return statuses
+}
+
+func (callback *testCallback) last() *testFeedback {
+	callback.lock.Lock()
+	defer callback.lock.Unlock()
+
+	return callback.feedbacks[len(callback.feedbacks)-1]
+}
+
+type testHooks struct {
+	identifyErr error
+	errs        map[string]error
+	calls       []string
+	inventory   *types.Inventory
+}
+
+func (hooks *testHooks) CurrentState(ctx context.Context) (*types.Inventory, error) {
+	return hooks.inventory, nil
+}
+
+func (hooks *testHooks) Identify(ctx context.Context, activityID string, domain *types.Domain) ([]*Action, error) {
+	if hooks.identifyErr != nil {
+		return nil, hooks.identifyErr
+	}
+	var actions []*Action
+	for _, component := range domain.Components {
+		actions = append(actions, &Action{Component: component, Remove: component.Version == ""})
+	}
+	return actions, nil
+}
+
+func (hooks *testHooks) call(phase string, action *Action) error {
+	hooks.calls = append(hooks.calls, phase+":"+action.Component.ID)
+	action.Progress(50, phase)
+	return hooks.errs[phase+":"+action.Component.ID]
+}
+
+func (hooks *testHooks) Download(ctx context.Context, action *Action) error {
+	return hooks.call("download", action)
+}
+
+func (hooks *testHooks) Update(ctx context.Context, action *Action) error {
+	return hooks.call("update", action)
+}
+
+func (hooks *testHooks) Activate(ctx context.Context, action *Action) error {
+	return hooks.call("activate", action)
+}
+
+func (hooks *testHooks) Rollback(ctx context.Context, action *Action) error {
+	return hooks.call("rollback", action)
+}
+
+func (hooks *testHooks) Cleanup(ctx context.Context, action *Action) error {
+	return hooks.call("cleanup", action)
+}
+
+func testDesiredState() *types.DesiredState {
+	return &types.DesiredState{
+		Baselines: []*types.Baseline{{Title: "app", Components: []string{testDomain + ":app"}}},
+		Domains: []*types.Domain{
+			{ID: "other", Components: []*types.ComponentWithConfig{{Component: types.Component{ID: "other", Version: "1.0"}}}},
+			{ID: testDomain, Components: []*types.ComponentWithConfig{
+				{Component: types.Component{ID: "app", Version: "2.0"}},
+				{Component: types.Component{ID: "legacy"}},
+			}},
+		},
+	}
+}
+
+func newTestUpdateManager(hooks *testHooks) (UpdateManager, *testCallback) {
+	callback := &testCallback{}
+	manager := NewUpdateManager(testDomain, hooks)
+	manager.SetCallback(callback)
+	return manager, callback
+}
+
+func command(manager UpdateManager, commandType types.CommandType, baseline string) {
+	manager.Command(context.Background(), testActivityID, &types.DesiredStateCommand{Command: commandType, Baseline: baseline})
+}
+
+func TestUpdateManagerCompleted(t *testing.T) {
+	hooks := &testHooks{inventory: &types.Inventory{SoftwareNodes: []*types.SoftwareNode{{InventoryNode: types.InventoryNode{ID: testDomain + ":app"}}}}}
+	manager, callback := newTestUpdateManager(hooks)
+	assert.Equal(t, testDomain, manager.Name())
+
+	manager.Apply(context.Background(), testActivityID, testDesiredState())
+	assert.Equal(t, []types.StatusType{types.StatusIdentifying, types.StatusIdentified}, callback.statuses())
+
+	for _, step := range []struct {
+		command  types.CommandType
+		statuses []types.StatusType
+	}{
+		{types.CommandDownload, []types.StatusType{types.BaselineStatusDownloading, types.BaselineStatusDownloadSuccess}},
+		{types.CommandUpdate, []types.StatusType{types.BaselineStatusUpdating, types.BaselineStatusUpdateSuccess}},
+		{types.CommandActivate, []types.StatusType{types.BaselineStatusActivating, types.BaselineStatusActivationSuccess}},
+		{types.CommandCleanup, []types.StatusType{types.BaselineStatusCleanup, types.BaselineStatusCleanupSuccess, types.StatusCompleted}},
+	} {
+		command(manager, step.command, "")
+		assert.Equal(t, step.statuses, callback.statuses(), step.command)
+	}
+	assert.Equal(t, []string{"download:app", "update:app", "update:legacy", "activate:app", "cleanup:app", "cleanup:legacy"}, hooks.calls)
+	assert.Equal(t, []*types.Inventory{hooks.inventory}, callback.currentStates)
+
+	// the operation is finished, further commands are ignored
+	command(manager, types.CommandCleanup, "")
+	assert.Nil(t, callback.statuses())
+}
+
+func TestUpdateManagerActions(t *testing.T) {
+	hooks := &testHooks{}
+	manager, callback := newTestUpdateManager(hooks)
+
+	manager.Apply(context.Background(), testActivityID, testDesiredState())
+	assert.Equal(t, []*types.Action{
+		{Component: &types.Component{ID: "app", Version: "2.0"}, Status: types.ActionStatusIdentified},
+		{Component: &types.Component{ID: "legacy"}, Status: types.ActionStatusIdentified},
+	}, callback.last().actions)
+
+	command(manager, types.CommandDownload, "")
+	assert.Equal(t, &types.Action{Component: &types.Component{ID: "app", Version: "2.0"}, Status: types.ActionStatusDownloading, Progress: 50, Message: "download"},
+		callback.feedbacks[4].actions[0])
+	assert.Equal(t, []*types.Action{
+		{Component: &types.Component{ID: "app", Version: "2.0"}, Status: types.ActionStatusDownloadSuccess, Progress: 100},
+		{Component: &types.Component{ID: "legacy"}, Status: types.ActionStatusIdentified},
+	}, callback.last().actions)
+
+	command(manager, types.CommandUpdate, "")
+	assert.Equal(t, []*types.Action{
+		{Component: &types.Component{ID: "app", Version: "2.0"}, Status: types.ActionStatusUpdateSuccess, Progress: 100},
+		{Component: &types.Component{ID: "legacy"}, Status: types.ActionStatusRemovalSuccess, Progress: 100},
+	}, callback.last().actions)
+}
+
+func TestUpdateManagerFailures(t *testing.T) {
+	t.Run("test_identification_failed", func(t *testing.T) {
+		manager, callback := newTestUpdateManager(&testHooks{identifyErr: errors.New("invalid component")})
+		manager.Apply(context.Background(), testActivityID, testDesiredState())
+		assert.Equal(t, []types.StatusType{types.StatusIdentifying, types.StatusIdentificationFailed}, callback.statuses())
+	})
+	t.Run("test_no_actions", func(t *testing.T) {
+		manager, callback := newTestUpdateManager(&testHooks{})
+		manager.Apply(context.Background(), testActivityID, &types.DesiredState{})
+		assert.Equal(t, []types.StatusType{types.StatusIdentifying, types.StatusIdentified, types.StatusCompleted}, callback.statuses())
+	})
+	t.Run("test_update_failed_rollback", func(t *testing.T) {
+		hooks := &testHooks{errs: map[string]error{"update:app": errors.New("no space left")}}
+		manager, callback := newTestUpdateManager(hooks)
+		manager.Apply(context.Background(), testActivityID, testDesiredState())
+		command(manager, types.CommandDownload, "")
+		callback.statuses()
+
+		command(manager, types.CommandUpdate, "")
+		feedback := callback.last()
+		assert.Equal(t, types.BaselineStatusUpdateFailure, feedback.status)
+		assert.Equal(t, "action for component app failed: no space left", feedback.message)
+		assert.Equal(t, &types.Action{Component: &types.Component{ID: "app", Version: "2.0"}, Status: types.ActionStatusUpdateFailure, Progress: 50, Message: "no space left"},
+			feedback.actions[0])
+		assert.Equal(t, types.ActionStatusIdentified, feedback.actions[1].Status)
+		callback.statuses()
+
+		// activate is not accepted after a failed update
+		command(manager, types.CommandActivate, "")
+		assert.Nil(t, callback.statuses())
+
+		command(manager, types.CommandRollback, "")
+		command(manager, types.CommandCleanup, "")
+		assert.Equal(t, []types.StatusType{types.BaselineStatusRollback, types.BaselineStatusRollbackSuccess,
+			types.BaselineStatusCleanup, types.BaselineStatusCleanupSuccess, types.StatusIncomplete}, callback.statuses())
+		assert.Equal(t, []string{"download:app", "update:app", "rollback:app", "cleanup:app", "cleanup:legacy"}, hooks.calls)
+	})
+	t.Run("test_cleanup_failed", func(t *testing.T) {
+		manager, callback := newTestUpdateManager(&testHooks{errs: map[string]error{"cleanup:legacy": errors.New("busy")}})
+		manager.Apply(context.Background(), testActivityID, testDesiredState())
+		for _, commandType := range []types.CommandType{types.CommandDownload, types.CommandUpdate, types.CommandActivate, types.CommandCleanup} {
+			command(manager, commandType, "")
+		}
+		statuses := callback.statuses()
+		assert.Equal(t, []types.StatusType{types.BaselineStatusCleanupFailure, types.StatusIncomplete}, statuses[len(statuses)-2:])
+	})
+}
+
+func TestUpdateManagerCommands(t *testing.T) {
+	hooks := &testHooks{}
+	manager, callback := newTestUpdateManager(hooks)
+	manager.Apply(context.Background(), testActivityID, testDesiredState())
+	callback.statuses()
+
+	t.Run("test_ignored_commands", func(t *testing.T) {
+		manager.Command(context.Background(), "unknown-activity", &types.DesiredStateCommand{Command: types.CommandDownload})
+		manager.Command(context.Background(), testActivityID, nil)
+		command(manager, types.CommandDownload, "unknown")
+		command(manager, types.CommandUpdate, "")
+		assert.Nil(t, callback.statuses())
+		assert.Nil(t, hooks.calls)
+	})
+	t.Run("test_baseline_commands", func(t *testing.T) {
+		command(manager, types.CommandDownload, "app")
+		assert.Equal(t, "app", callback.last().baseline)
+		assert.Equal(t, []types.StatusType{types.BaselineStatusDownloading, types.BaselineStatusDownloadSuccess}, callback.statuses())
+		command(manager, types.CommandCleanup, "app")
+		assert.Equal(t, []types.StatusType{types.BaselineStatusCleanup, types.BaselineStatusCleanupSuccess}, callback.statuses())
+		assert.Equal(t, []string{"download:app", "cleanup:app"}, hooks.calls)
+	})
+	t.Run("test_superseded", func(t *testing.T) {
+		manager.Apply(context.Background(), "new-activity-id", &types.DesiredState{})
+		assert.Equal(t, []types.StatusType{types.StatusSuperseded, types.StatusIdentifying, types.StatusIdentified, types.StatusCompleted}, callback.statuses())
+	})
+}
+
+func TestActionProgress(t *testing.T) {
+	manager, callback := newTestUpdateManager(&testHooks{})
+	manager.Apply(context.Background(), testActivityID, testDesiredState())
+	callback.statuses()
+
+	action := manager.(*updateManager).operation.actions[0]
+	assert.Equal(t, types.ActionStatusIdentified, action.Status())
+	action.Progress(10, "not running")
+	assert.Nil(t, callback.feedbacks)
+
+	require.NoError(t, manager.(*updateManager).setStatus(action, types.ActionStatusDownloading, ""))
+	action.Progress(150, "")
+	action.Progress(100, "")
+	require.Len(t, callback.feedbacks, 1)
+	assert.Equal(t, types.StatusRunning, callback.feedbacks[0].status)
+	assert.Equal(t, uint8(100), callback.feedbacks[0].actions[0].Progress)
+
+	(&Action{}).Progress(10, "no manager")
+}
+
+func TestPublishCurrentState(t *testing.T) {
+	hooks := &testHooks{inventory: &types.Inventory{}}
+	manager, callback := newTestUpdateManager(hooks)
+	manager.PublishCurrentState(context.Background())
+	assert.Equal(t, []*types.Inventory{hooks.inventory}, callback.currentStates)
+
+	currentState, err := manager.Get(context.Background(), testActivityID)
+	assert.NoError(t, err)
+	assert.Equal(t, hooks.inventory, currentState)
+	assert.NoError(t, manager.Dispose())
+}
+
+func TestNewUpdateAgent(t *testing.T) {
+	updateAgent, err := NewUpdateAgent(mqtt.NewDefaultConfig(), NewUpdateManager(testDomain, &testHooks{}),
+		WithFeedbackInterval(0), WithCurrentStateDelay(0), WithClientOptions())
+	assert.NoError(t, err)
+	assert.NotNil(t, updateAgent)
+}
@@ -48,6 +48,13 @@ func (t *currentStateNotifier) set(activityID string, currentState *types.Invent
 	}
 }
 
+func (t *currentStateNotifier) setInterval(interval time.Duration) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.interval = interval
+}
+
 func (t *currentStateNotifier) stop() {
 	t.lock.Lock()
 	defer t.lock.Unlock()
@@ -60,6 +60,13 @@ func (t *desiredStateFeedbackNotifier) set(activityID string, actions []*types.A
 	}
 }
 
+func (t *desiredStateFeedbackNotifier) setInterval(interval time.Duration) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.interval = interval
+}
+
 func (t *desiredStateFeedbackNotifier) stop() {
 	t.lock.Lock()
 	defer t.lock.Unlock()
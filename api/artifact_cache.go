@@ -0,0 +1,45 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package api
+
+import (
+	"errors"
+
+	"github.com/eclipse-kanto/update-manager/api/types"
+)
+
+var (
+	// ErrArtifactNotCached is returned if there is no cached artifact with the requested digest
+	ErrArtifactNotCached = errors.New("artifact is not cached")
+	// ErrArtifactReservationNotFound is returned if there is no pending reservation with the requested ID, e.g. it has expired
+	ErrArtifactReservationNotFound = errors.New("artifact reservation not found")
+	// ErrArtifactCacheFull is returned if an artifact does not fit into the disk quota of the artifact cache, even after eviction
+	ErrArtifactCacheFull = errors.New("artifact cache quota exceeded")
+	// ErrInvalidArtifact is returned if a digest or size is invalid, or if a committed artifact does not match its reservation
+	ErrInvalidArtifact = errors.New("invalid artifact")
+)
+
+// ArtifactCache defines functions for sharing the downloaded artifacts between the domain agents by their content digest.
+// An artifact is stored by reserving space for it, writing it to the path of the reservation and committing the reservation.
+type ArtifactCache interface {
+	Lookup(digest string) (*types.CachedArtifact, error)
+	Reserve(digest string, size int64) (*types.ArtifactReservation, error)
+	Commit(reservationID string) (*types.CachedArtifact, error)
+	Release(reservationID string) error
+	Usage() (*types.ArtifactCacheUsage, error)
+}
+
+// ArtifactCacheProvider defines a function for retrieving the artifact cache, which is nil if the artifact cache is not enabled
+type ArtifactCacheProvider interface {
+	ArtifactCache() ArtifactCache
+}
@@ -21,6 +21,17 @@ type UpdateAgentHandler interface {
 	HandleCurrentStateGet(string, int64) error
 }
 
+// SignedDesiredStateHandler defines a function for handling a desired state, which signature has been verified
+type SignedDesiredStateHandler interface {
+	HandleSignedDesiredState(activityID string, timestamp int64, signer string, desiredState *types.DesiredState) error
+}
+
+// RejectedDesiredStateHandler defines a function for handling a desired state, which is rejected before it is applied,
+// e.g. because of a missing or invalid signature. The signer is empty, if not known.
+type RejectedDesiredStateHandler interface {
+	HandleRejectedDesiredState(activityID string, signer string, reason error)
+}
+
 // BaseClient defines a common interface for both UpdateAgentClient and DesiredStateClient
 type BaseClient interface {
 	Domain() string
@@ -44,6 +55,11 @@ type StateHandler interface {
 	HandleCurrentState(string, int64, *types.Inventory) error
 }
 
+// ConnectionStatusProvider defines a function for retrieving the health status of the connection to the MQTT broker
+type ConnectionStatusProvider interface {
+	ConnectionStatus() *types.ConnectionStatus
+}
+
 // DesiredStateClient defines an interface for triggering requests towards an Update Agent implementation
 type DesiredStateClient interface {
 	BaseClient
@@ -0,0 +1,65 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package types
+
+// ActivityRecord defines the history record of an update activity.
+type ActivityRecord struct {
+	ActivityID       string                `json:"activityId"`
+	DesiredStateHash string                `json:"desiredStateHash,omitempty"`
+	Signer           string                `json:"signer,omitempty"`
+	StartTime        int64                 `json:"startTime,omitempty"`
+	EndTime          int64                 `json:"endTime,omitempty"`
+	Status           StatusType            `json:"status,omitempty"`
+	Message          string                `json:"message,omitempty"`
+	Phases           []*PhaseRecord        `json:"phases,omitempty"`
+	Domains          map[string]StatusType `json:"domains,omitempty"`
+	Consents         []*ConsentRecord      `json:"consents,omitempty"`
+	Reboot           *RebootRecord         `json:"reboot,omitempty"`
+	// Rejected denotes an update activity, which desired state is rejected before it is applied
+	Rejected bool `json:"rejected,omitempty"`
+}
+
+// PhaseRecord defines the time when an update activity has reached a given status.
+type PhaseRecord struct {
+	Status    StatusType `json:"status"`
+	Timestamp int64      `json:"timestamp"`
+}
+
+// ConsentRecord defines an owner consent request and the owner decision, if any.
+type ConsentRecord struct {
+	Command      CommandType       `json:"command"`
+	RequestTime  int64             `json:"requestTime"`
+	Status       ConsentStatusType `json:"status,omitempty"`
+	User         string            `json:"user,omitempty"`
+	DecisionTime int64             `json:"decisionTime,omitempty"`
+}
+
+// RebootRecord defines the reboot outcome of an update activity.
+type RebootRecord struct {
+	Required  bool   `json:"required"`
+	Enabled   bool   `json:"enabled"`
+	Timestamp int64  `json:"timestamp,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// ActivityHistoryQuery defines the payload of an activity history request.
+type ActivityHistoryQuery struct {
+	ActivityID string `json:"activityId,omitempty"`
+	Since      int64  `json:"since,omitempty"`
+	Limit      int    `json:"limit,omitempty"`
+}
+
+// ActivityHistory defines the payload of an activity history response, the latest activities come first.
+type ActivityHistory struct {
+	Activities []*ActivityRecord `json:"activities"`
+}
@@ -0,0 +1,21 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package types
+
+// ActivityStatus defines the payload holding the status of an update activity in progress.
+type ActivityStatus struct {
+	ActivityID string                `json:"activityId,omitempty"`
+	Status     StatusType            `json:"status,omitempty"`
+	Domains    map[string]StatusType `json:"domains,omitempty"`
+	Actions    []*Action             `json:"actions,omitempty"`
+}
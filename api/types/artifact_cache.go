@@ -0,0 +1,45 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package types
+
+// CachedArtifact defines an artifact stored in the artifact cache shared between the domain agents.
+type CachedArtifact struct {
+	// Digest is the content digest of the artifact in the format sha256:<hex>
+	Digest string `json:"digest"`
+	Size   int64  `json:"size"`
+	// Path is the local file of the artifact, which is to be copied or linked by the domain agent right after the lookup
+	Path string `json:"path"`
+}
+
+// ArtifactReservation defines a reservation of space in the artifact cache for an artifact, which is about to be stored.
+type ArtifactReservation struct {
+	ID     string `json:"id,omitempty"`
+	Digest string `json:"digest"`
+	// Size is the expected size of the artifact in bytes, zero if unknown
+	Size int64 `json:"size,omitempty"`
+	// Path is the local file, which the artifact is to be written to before the reservation is committed
+	Path string `json:"path,omitempty"`
+	// Expires is the time in milliseconds since the Unix epoch, after which the reservation is released if not committed
+	Expires int64 `json:"expires,omitempty"`
+}
+
+// ArtifactCacheUsage defines the disk usage of the artifact cache.
+type ArtifactCacheUsage struct {
+	Artifacts int `json:"artifacts"`
+	// Size is the total size of the cached artifacts in bytes
+	Size int64 `json:"size"`
+	// Reserved is the total size of the pending reservations in bytes
+	Reserved int64 `json:"reserved"`
+	// MaxSize is the disk quota of the artifact cache in bytes
+	MaxSize int64 `json:"maxSize"`
+}
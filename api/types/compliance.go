@@ -0,0 +1,53 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package types
+
+// ComplianceStatus represents the compliance of a single component with the desired state.
+type ComplianceStatus string
+
+const (
+	// ComplianceInstalled denotes a component, which is installed with the desired version
+	ComplianceInstalled ComplianceStatus = "INSTALLED"
+	// ComplianceVersionMismatch denotes a component, which is installed with a version different from the desired one
+	ComplianceVersionMismatch ComplianceStatus = "VERSION_MISMATCH"
+	// ComplianceMissing denotes a desired component, which is not installed
+	ComplianceMissing ComplianceStatus = "MISSING"
+	// ComplianceExtra denotes an installed component of a desired domain, which is not part of the desired state
+	ComplianceExtra ComplianceStatus = "EXTRA"
+)
+
+// ComplianceReport defines the payload of a compliance response, i.e. the comparison of a desired state with the current state.
+type ComplianceReport struct {
+	Compliant  bool                   `json:"compliant"`
+	Components []*ComponentCompliance `json:"components,omitempty"`
+}
+
+// ComponentCompliance defines the compliance of a single component with the desired state.
+type ComponentCompliance struct {
+	Domain            string              `json:"domain"`
+	ID                string              `json:"id"`
+	Status            ComplianceStatus    `json:"status"`
+	DesiredVersion    string              `json:"desiredVersion,omitempty"`
+	CurrentVersion    string              `json:"currentVersion,omitempty"`
+	ConfigDifferences []*ConfigDifference `json:"configDifferences,omitempty"`
+}
+
+// ConfigDifference defines a configuration key, which value in the current state differs from the desired one.
+type ConfigDifference struct {
+	Key          string `json:"key"`
+	DesiredValue string `json:"desiredValue,omitempty"`
+	CurrentValue string `json:"currentValue,omitempty"`
+}
+
+// OriginalIDParameter is the parameter holding the original ID of a node, which ID is namespaced in the aggregated inventory.
+const OriginalIDParameter = "originalId"
@@ -0,0 +1,42 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package types
+
+// ConnectionState defines the state of the connection to the MQTT broker.
+type ConnectionState string
+
+const (
+	// ConnectionStateDisconnected denotes that the client is not started or is stopped.
+	ConnectionStateDisconnected ConnectionState = "DISCONNECTED"
+	// ConnectionStateConnecting denotes that the client is establishing its initial connection.
+	ConnectionStateConnecting ConnectionState = "CONNECTING"
+	// ConnectionStateConnected denotes that the client is connected.
+	ConnectionStateConnected ConnectionState = "CONNECTED"
+	// ConnectionStateReconnecting denotes that the connection is lost and the client is reconnecting.
+	ConnectionStateReconnecting ConnectionState = "RECONNECTING"
+)
+
+// ConnectionStatus defines the payload holding the health status of the connection to the MQTT broker.
+type ConnectionStatus struct {
+	State      ConnectionState `json:"state"`
+	Broker     string          `json:"broker,omitempty"`
+	Subscribed bool            `json:"subscribed"`
+	Since      int64           `json:"since"`
+	Reconnects int             `json:"reconnects"`
+	LastError  string          `json:"lastError,omitempty"`
+}
+
+// IsHealthy returns true if the client is connected and all its subscriptions are in place.
+func (status *ConnectionStatus) IsHealthy() bool {
+	return status != nil && status.State == ConnectionStateConnected && status.Subscribed
+}
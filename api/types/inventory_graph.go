@@ -0,0 +1,235 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package types
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// InventoryGraph is an indexed, read-only view of an inventory, which allows the traversal of the nodes via their associations.
+type InventoryGraph struct {
+	nodes    map[string]*InventoryNode
+	order    []string
+	children map[string][]string
+	parents  map[string][]string
+}
+
+// InventoryValidationError holds all integrity problems found in an inventory graph.
+type InventoryValidationError struct {
+	Problems []string
+}
+
+// Error returns all integrity problems as a single message.
+func (err *InventoryValidationError) Error() string {
+	return "invalid inventory: " + strings.Join(err.Problems, "; ")
+}
+
+// NewInventoryGraph indexes the nodes and associations of the given inventory. The graph is built even if the inventory is invalid,
+// duplicate node IDs are resolved to the first node and dangling associations are ignored, use Validate to find such problems.
+func NewInventoryGraph(inventory *Inventory) *InventoryGraph {
+	graph := &InventoryGraph{
+		nodes:    map[string]*InventoryNode{},
+		children: map[string][]string{},
+		parents:  map[string][]string{},
+	}
+	if inventory == nil {
+		return graph
+	}
+	for _, node := range inventoryNodes(inventory) {
+		if _, ok := graph.nodes[node.ID]; !ok {
+			graph.nodes[node.ID] = node
+			graph.order = append(graph.order, node.ID)
+		}
+	}
+	for _, association := range inventory.Associations {
+		if association == nil || !graph.Contains(association.SourceID) || !graph.Contains(association.TargetID) {
+			continue
+		}
+		graph.children[association.SourceID] = append(graph.children[association.SourceID], association.TargetID)
+		graph.parents[association.TargetID] = append(graph.parents[association.TargetID], association.SourceID)
+	}
+	return graph
+}
+
+// ValidateInventory checks the integrity of the given inventory graph: the node IDs must be non-empty and unique,
+// the associations must link existing nodes and there must be no cycles between the nodes.
+func ValidateInventory(inventory *Inventory) error {
+	if inventory == nil {
+		return nil
+	}
+	var problems []string
+	seen := map[string]bool{}
+	for _, node := range inventoryNodes(inventory) {
+		if node.ID == "" {
+			problems = append(problems, fmt.Sprintf("node '%s' has no ID", node.Name))
+			continue
+		}
+		if seen[node.ID] {
+			problems = append(problems, fmt.Sprintf("duplicate node ID '%s'", node.ID))
+		}
+		seen[node.ID] = true
+	}
+	for _, association := range inventory.Associations {
+		if association == nil {
+			continue
+		}
+		if !seen[association.SourceID] {
+			problems = append(problems, fmt.Sprintf("association %s -> %s refers to unknown source node", association.SourceID, association.TargetID))
+		}
+		if !seen[association.TargetID] {
+			problems = append(problems, fmt.Sprintf("association %s -> %s refers to unknown target node", association.SourceID, association.TargetID))
+		}
+	}
+	if cycle := NewInventoryGraph(inventory).FindCycle(); cycle != nil {
+		problems = append(problems, "cycle between nodes "+strings.Join(cycle, " -> "))
+	}
+	if len(problems) > 0 {
+		return &InventoryValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// DuplicateNodeIDs returns the node IDs, which are reported in more than one of the given inventories, mapped to the sorted keys of the inventories reporting them.
+func DuplicateNodeIDs(inventories map[string]*Inventory) map[string][]string {
+	owners := map[string][]string{}
+	for key, inventory := range inventories {
+		if inventory == nil {
+			continue
+		}
+		ids := map[string]bool{}
+		for _, node := range inventoryNodes(inventory) {
+			ids[node.ID] = true
+		}
+		for id := range ids {
+			owners[id] = append(owners[id], key)
+		}
+	}
+	duplicates := map[string][]string{}
+	for id, keys := range owners {
+		if len(keys) > 1 {
+			sort.Strings(keys)
+			duplicates[id] = keys
+		}
+	}
+	return duplicates
+}
+
+// Contains returns true if the graph has a node with the given ID.
+func (graph *InventoryGraph) Contains(id string) bool {
+	_, ok := graph.nodes[id]
+	return ok
+}
+
+// Node returns the node with the given ID or nil if there is no such node.
+func (graph *InventoryGraph) Node(id string) *InventoryNode {
+	return graph.nodes[id]
+}
+
+// Children returns the IDs of the nodes, which are targets of associations with the given source node.
+func (graph *InventoryGraph) Children(id string) []string {
+	return graph.children[id]
+}
+
+// Parents returns the IDs of the nodes, which are sources of associations with the given target node.
+func (graph *InventoryGraph) Parents(id string) []string {
+	return graph.parents[id]
+}
+
+// Roots returns the IDs of the nodes without parents, in the order the nodes are listed in the inventory.
+func (graph *InventoryGraph) Roots() []string {
+	var roots []string
+	for _, id := range graph.order {
+		if len(graph.parents[id]) == 0 {
+			roots = append(roots, id)
+		}
+	}
+	return roots
+}
+
+// PathToRoot returns the IDs of the nodes from the given node up to its root, following the first parent of each node.
+// The path ends before a node would be visited twice, so that it is finite even if the graph is not acyclic.
+func (graph *InventoryGraph) PathToRoot(id string) []string {
+	if !graph.Contains(id) {
+		return nil
+	}
+	visited := map[string]bool{}
+	var path []string
+	for current := id; !visited[current]; {
+		visited[current] = true
+		path = append(path, current)
+		parents := graph.parents[current]
+		if len(parents) == 0 {
+			break
+		}
+		current = parents[0]
+	}
+	return path
+}
+
+// FindCycle returns the IDs of the nodes forming a cycle, starting and ending with the same node, or nil if the graph is acyclic.
+func (graph *InventoryGraph) FindCycle() []string {
+	const (
+		unvisited = iota
+		inProgress
+		done
+	)
+	state := map[string]int{}
+	var stack []string
+	var visit func(id string) []string
+	visit = func(id string) []string {
+		state[id] = inProgress
+		stack = append(stack, id)
+		for _, child := range graph.children[id] {
+			switch state[child] {
+			case inProgress:
+				for i, stackID := range stack {
+					if stackID == child {
+						return append(append([]string{}, stack[i:]...), child)
+					}
+				}
+			case unvisited:
+				if cycle := visit(child); cycle != nil {
+					return cycle
+				}
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[id] = done
+		return nil
+	}
+	for _, id := range graph.order {
+		if state[id] == unvisited {
+			if cycle := visit(id); cycle != nil {
+				return cycle
+			}
+		}
+	}
+	return nil
+}
+
+func inventoryNodes(inventory *Inventory) []*InventoryNode {
+	var nodes []*InventoryNode
+	for _, node := range inventory.HardwareNodes {
+		if node != nil {
+			nodes = append(nodes, &node.InventoryNode)
+		}
+	}
+	for _, node := range inventory.SoftwareNodes {
+		if node != nil {
+			nodes = append(nodes, &node.InventoryNode)
+		}
+	}
+	return nodes
+}
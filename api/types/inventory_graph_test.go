@@ -0,0 +1,113 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testGraphInventory(associations ...*Association) *Inventory {
+	return &Inventory{
+		HardwareNodes: []*HardwareNode{{InventoryNode: InventoryNode{ID: "hw"}}},
+		SoftwareNodes: []*SoftwareNode{
+			{InventoryNode: InventoryNode{ID: "root"}},
+			{InventoryNode: InventoryNode{ID: "app"}},
+			{InventoryNode: InventoryNode{ID: "lib"}},
+		},
+		Associations: associations,
+	}
+}
+
+func TestValidateInventory(t *testing.T) {
+	testCases := map[string]struct {
+		inventory *Inventory
+		problems  []string
+	}{
+		"test_nil": {},
+		"test_valid": {
+			inventory: testGraphInventory(&Association{SourceID: "root", TargetID: "app"}, &Association{SourceID: "app", TargetID: "lib"},
+				&Association{SourceID: "root", TargetID: "lib"}),
+		},
+		"test_dangling_association": {
+			inventory: testGraphInventory(&Association{SourceID: "root", TargetID: "missing"}, &Association{SourceID: "other", TargetID: "app"}),
+			problems: []string{
+				"association root -> missing refers to unknown target node",
+				"association other -> app refers to unknown source node",
+			},
+		},
+		"test_duplicate_and_empty_id": {
+			inventory: &Inventory{
+				HardwareNodes: []*HardwareNode{{InventoryNode: InventoryNode{ID: "node"}}},
+				SoftwareNodes: []*SoftwareNode{{InventoryNode: InventoryNode{ID: "node"}}, {InventoryNode: InventoryNode{Name: "unnamed"}}},
+			},
+			problems: []string{"duplicate node ID 'node'", "node 'unnamed' has no ID"},
+		},
+		"test_cycle": {
+			inventory: testGraphInventory(&Association{SourceID: "root", TargetID: "app"}, &Association{SourceID: "app", TargetID: "lib"},
+				&Association{SourceID: "lib", TargetID: "app"}),
+			problems: []string{"cycle between nodes app -> lib -> app"},
+		},
+		"test_self_association": {
+			inventory: testGraphInventory(&Association{SourceID: "hw", TargetID: "hw"}),
+			problems:  []string{"cycle between nodes hw -> hw"},
+		},
+	}
+	for testName, testCase := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			err := ValidateInventory(testCase.inventory)
+			if testCase.problems == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.IsType(t, &InventoryValidationError{}, err)
+			assert.ElementsMatch(t, testCase.problems, err.(*InventoryValidationError).Problems)
+		})
+	}
+}
+
+func TestInventoryGraphTraversal(t *testing.T) {
+	graph := NewInventoryGraph(testGraphInventory(&Association{SourceID: "hw", TargetID: "root"},
+		&Association{SourceID: "root", TargetID: "app"}, &Association{SourceID: "root", TargetID: "lib"},
+		&Association{SourceID: "app", TargetID: "missing"}))
+
+	assert.True(t, graph.Contains("lib"))
+	assert.False(t, graph.Contains("missing"))
+	assert.Equal(t, "app", graph.Node("app").ID)
+	assert.Nil(t, graph.Node("missing"))
+	assert.Equal(t, []string{"app", "lib"}, graph.Children("root"))
+	assert.Empty(t, graph.Children("app"))
+	assert.Equal(t, []string{"root"}, graph.Parents("lib"))
+	assert.Equal(t, []string{"hw"}, graph.Roots())
+	assert.Equal(t, []string{"lib", "root", "hw"}, graph.PathToRoot("lib"))
+	assert.Nil(t, graph.PathToRoot("missing"))
+	assert.Nil(t, graph.FindCycle())
+}
+
+func TestInventoryGraphPathToRootWithCycle(t *testing.T) {
+	graph := NewInventoryGraph(testGraphInventory(&Association{SourceID: "app", TargetID: "lib"}, &Association{SourceID: "lib", TargetID: "app"}))
+
+	assert.Equal(t, []string{"lib", "app"}, graph.PathToRoot("lib"))
+	assert.Equal(t, []string{"app", "lib", "app"}, graph.FindCycle())
+}
+
+func TestDuplicateNodeIDs(t *testing.T) {
+	duplicates := DuplicateNodeIDs(map[string]*Inventory{
+		"containers":  testGraphInventory(),
+		"self-update": {HardwareNodes: []*HardwareNode{{InventoryNode: InventoryNode{ID: "hw"}}}, SoftwareNodes: []*SoftwareNode{{InventoryNode: InventoryNode{ID: "os"}}}},
+		"safety":      {SoftwareNodes: []*SoftwareNode{{InventoryNode: InventoryNode{ID: "app"}}}},
+		"empty":       nil,
+	})
+	assert.Equal(t, map[string][]string{"hw": {"containers", "self-update"}, "app": {"containers", "safety"}}, duplicates)
+}
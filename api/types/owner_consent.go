@@ -25,6 +25,8 @@ const (
 // OwnerConsentFeedback defines the payload for Owner Consent Feedback.
 type OwnerConsentFeedback struct {
 	Status ConsentStatusType `json:"status,omitempty"`
+	// User optionally identifies who approved or denied the update operation, it is recorded in the activity history.
+	User string `json:"user,omitempty"`
 	// time field for scheduling could be added here
 }
 
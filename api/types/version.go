@@ -0,0 +1,41 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package types
+
+const (
+	// VersionSchemeKey is the key of the domain or component configuration, which defines the scheme the component versions are compared by
+	VersionSchemeKey = "versionScheme"
+	// AvailableVersionsKey is the key of the component configuration, which lists the comma separated versions a version constraint can be resolved to
+	AvailableVersionsKey = "availableVersions"
+)
+
+// VersionScheme returns the name of the version scheme of the given component of the domain, the component configuration takes
+// precedence over the domain one. An empty name stands for the scheme automatically detected from the versions.
+func (domain *Domain) VersionScheme(component *ComponentWithConfig) string {
+	if component != nil {
+		if name, ok := parameterValue(component.Config, VersionSchemeKey); ok {
+			return name
+		}
+	}
+	name, _ := parameterValue(domain.Config, VersionSchemeKey)
+	return name
+}
+
+func parameterValue(parameters []*KeyValuePair, key string) (string, bool) {
+	for _, parameter := range parameters {
+		if parameter != nil && parameter.Key == key {
+			return parameter.Value, true
+		}
+	}
+	return "", false
+}
@@ -14,6 +14,7 @@ package api
 
 import (
 	"context"
+	"time"
 
 	"github.com/eclipse-kanto/update-manager/api/types"
 )
@@ -24,11 +25,48 @@ type UpdateAgent interface {
 	Stop() error
 }
 
+// ReportIntervalsSetter defines a function for changing the interval for reporting the running desired state feedback
+// and the delay for reporting the current state of an update agent, e.g. when the configuration is reloaded.
+type ReportIntervalsSetter interface {
+	SetReportIntervals(desiredStateFeedbackInterval, currentStateDelay time.Duration)
+}
+
+type contextKey string
+
+const (
+	desiredStateSignerKey    contextKey = "desiredStateSigner"
+	desiredStateTimestampKey contextKey = "desiredStateTimestamp"
+)
+
+// WithDesiredStateSigner returns a copy of the given context, which carries the identity of the verified signer of the desired state.
+func WithDesiredStateSigner(ctx context.Context, signer string) context.Context {
+	return context.WithValue(ctx, desiredStateSignerKey, signer)
+}
+
+// DesiredStateSigner returns the identity of the verified signer of the desired state carried by the given context, if any.
+func DesiredStateSigner(ctx context.Context) string {
+	signer, _ := ctx.Value(desiredStateSignerKey).(string)
+	return signer
+}
+
+// WithDesiredStateTimestamp returns a copy of the given context, which carries the timestamp of the desired state message.
+func WithDesiredStateTimestamp(ctx context.Context, timestamp int64) context.Context {
+	return context.WithValue(ctx, desiredStateTimestampKey, timestamp)
+}
+
+// DesiredStateTimestamp returns the timestamp of the desired state message carried by the given context and true, if any.
+func DesiredStateTimestamp(ctx context.Context) (int64, bool) {
+	timestamp, ok := ctx.Value(desiredStateTimestampKey).(int64)
+	return timestamp, ok
+}
+
 // UpdateManagerConfig holds configuration properties for an update manager.
 type UpdateManagerConfig struct {
 	Name           string `json:"-"`
 	RebootRequired bool   `json:"rebootRequired"`
 	ReadTimeout    string `json:"readTimeout"`
+	// Topics overrides the MQTT topics of the domain agent by topic name, e.g. desiredState or currentState
+	Topics map[string]string `json:"topics,omitempty"`
 }
 
 // UpdateManager provides the orchestration management abstraction
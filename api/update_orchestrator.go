@@ -25,3 +25,23 @@ type UpdateOrchestrator interface {
 	DesiredStateFeedbackHandler
 	OwnerConsentHandler
 }
+
+// ActivityStatusProvider defines a function for retrieving the status of the update activity in progress
+type ActivityStatusProvider interface {
+	ActivityStatus() *types.ActivityStatus
+}
+
+// CurrentStateProvider defines a function for retrieving the current state, without publishing it
+type CurrentStateProvider interface {
+	CurrentState(ctx context.Context, activityID string) (*types.Inventory, error)
+}
+
+// ActivityHistoryProvider defines a function for querying the history of the update activities
+type ActivityHistoryProvider interface {
+	ActivityHistory(query *types.ActivityHistoryQuery) *types.ActivityHistory
+}
+
+// ComplianceProvider defines a function for comparing a desired state with the current state, without applying it
+type ComplianceProvider interface {
+	Compliance(desiredState *types.DesiredState) *types.ComplianceReport
+}
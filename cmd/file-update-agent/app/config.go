@@ -0,0 +1,114 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package app
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/eclipse-kanto/update-manager/api/types"
+	"github.com/eclipse-kanto/update-manager/config"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	domainDefault                 = "files"
+	stateFileDefault              = "/var/lib/file-update-agent/state.json"
+	downloadDirDefault            = "/var/lib/file-update-agent/downloads"
+	targetDirDefault              = "/var/lib/file-update-agent/files"
+	reportFeedbackIntervalDefault = "1s"
+)
+
+// Config represents the file update agent configuration.
+type Config struct {
+	*config.BaseConfig
+	// StateFile is the file, which holds the installed files of the domain
+	StateFile string `json:"stateFile"`
+	// DownloadDir is the directory, which the artifacts are downloaded to
+	DownloadDir string `json:"downloadDir"`
+	// TargetDir is the directory, which the downloaded artifacts are moved to on activation
+	TargetDir string `json:"targetDir"`
+	// SoftwareType is the type of the software nodes reported for the installed files
+	SoftwareType           types.SoftwareType `json:"softwareType"`
+	ReportFeedbackInterval string             `json:"reportFeedbackInterval"`
+	// ArtifactCache is the address of the Update Manager local HTTP API, which provides the artifact cache shared between the domain agents
+	ArtifactCache string `json:"artifactCache,omitempty"`
+}
+
+func newDefaultConfig() *Config {
+	baseConfig := config.DefaultDomainConfig(domainDefault)
+	baseConfig.ThingsEnabled = false
+	return &Config{
+		BaseConfig:             baseConfig,
+		StateFile:              stateFileDefault,
+		DownloadDir:            downloadDirDefault,
+		TargetDir:              targetDirDefault,
+		SoftwareType:           types.SoftwareTypeRaw,
+		ReportFeedbackInterval: reportFeedbackIntervalDefault,
+	}
+}
+
+// LoadConfig loads the configuration from the configuration file, the environment variables and the command line flags.
+func LoadConfig(version string) (*Config, error) {
+	cfg := newDefaultConfig()
+	if configFilePath := config.ParseConfigFilePath(); configFilePath != "" {
+		if err := config.LoadConfigFromFile(configFilePath, cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	flagSet := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	config.SetupFlags(flagSet, cfg.BaseConfig)
+	flagSet.StringVar(&cfg.StateFile, "state-file", config.EnvToString("STATE_FILE", cfg.StateFile), "Specify the file, which holds the installed files of the domain")
+	flagSet.StringVar(&cfg.DownloadDir, "download-dir", config.EnvToString("DOWNLOAD_DIR", cfg.DownloadDir), "Specify the directory, which the artifacts are downloaded to")
+	flagSet.StringVar(&cfg.TargetDir, "target-dir", config.EnvToString("TARGET_DIR", cfg.TargetDir), "Specify the directory, which the downloaded artifacts are moved to on activation")
+	flagSet.StringVar((*string)(&cfg.SoftwareType), "software-type", config.EnvToString("SOFTWARE_TYPE", string(cfg.SoftwareType)), "Specify the type of the software nodes reported for the installed files - possible values are IMAGE, RAW, DATA, APPLICATION, CONTAINER")
+	flagSet.StringVar(&cfg.ReportFeedbackInterval, "report-feedback-interval", config.EnvToString("REPORT_FEEDBACK_INTERVAL", cfg.ReportFeedbackInterval), "Specify the interval, which the download progress is reported at most once per. Value should be a positive integer number followed by a unit suffix, such as '1s', '10s', etc")
+	flagSet.StringVar(&cfg.ArtifactCache, "artifact-cache", config.EnvToString("ARTIFACT_CACHE", cfg.ArtifactCache), "Specify the address of the Update Manager local HTTP API, which provides the artifact cache shared between the domain agents, either a unix socket in the format 'unix:///path/to/socket' or a 'host:port' address. The artifact cache is not used if not set")
+	fVersion := flagSet.Bool("version", false, "Prints current version and exits")
+	if err := flagSet.Parse(os.Args[1:]); err != nil {
+		return nil, err
+	}
+	if *fVersion {
+		fmt.Println(version)
+		os.Exit(0)
+	}
+	return cfg, validateConfig(cfg)
+}
+
+func validateConfig(cfg *Config) error {
+	if cfg.Domain == "" {
+		return errors.New("the domain is not set")
+	}
+	if cfg.StateFile == "" {
+		return errors.New("the state file is not set")
+	}
+	if cfg.DownloadDir == "" {
+		return errors.New("the download directory is not set")
+	}
+	if cfg.TargetDir == "" {
+		return errors.New("the target directory is not set")
+	}
+	switch cfg.SoftwareType {
+	case types.SoftwareTypeImage, types.SoftwareTypeRaw, types.SoftwareTypeData, types.SoftwareTypeApplication, types.SoftwareTypeContainer:
+	default:
+		return errors.Errorf("unknown software type '%s'", cfg.SoftwareType)
+	}
+	if interval, err := time.ParseDuration(cfg.ReportFeedbackInterval); err != nil || interval <= 0 {
+		return errors.Errorf("invalid reportFeedbackInterval '%s', a positive duration is expected", cfg.ReportFeedbackInterval)
+	}
+	return nil
+}
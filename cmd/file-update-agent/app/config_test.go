@@ -0,0 +1,36 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateConfig(t *testing.T) {
+	assert.NoError(t, validateConfig(newDefaultConfig()))
+
+	for expectedErr, modify := range map[string]func(*Config){
+		"the domain is not set":                                                func(cfg *Config) { cfg.Domain = "" },
+		"the state file is not set":                                            func(cfg *Config) { cfg.StateFile = "" },
+		"the download directory is not set":                                    func(cfg *Config) { cfg.DownloadDir = "" },
+		"the target directory is not set":                                      func(cfg *Config) { cfg.TargetDir = "" },
+		"unknown software type 'BINARY'":                                       func(cfg *Config) { cfg.SoftwareType = "BINARY" },
+		"invalid reportFeedbackInterval '0s', a positive duration is expected": func(cfg *Config) { cfg.ReportFeedbackInterval = "0s" },
+	} {
+		cfg := newDefaultConfig()
+		modify(cfg)
+		assert.EqualError(t, validateConfig(cfg), expectedErr)
+	}
+}
@@ -0,0 +1,418 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package app
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/eclipse-kanto/update-manager/api"
+	"github.com/eclipse-kanto/update-manager/api/agent/sdk"
+	"github.com/eclipse-kanto/update-manager/api/types"
+	"github.com/eclipse-kanto/update-manager/logger"
+	"github.com/eclipse-kanto/update-manager/util/download"
+	"github.com/eclipse-kanto/update-manager/util/version"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	urlKey      = "url"
+	sizeKey     = "size"
+	sha256Key   = "sha256"
+	fileNameKey = "fileName"
+
+	backupDir = "backup"
+)
+
+var fileNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-][A-Za-z0-9._-]*$`)
+
+// fileHooks implements the update agent hooks by downloading the artifacts of the components to the download directory and moving them
+// to the target directory on activation. The installed files are kept in the state file.
+type fileHooks struct {
+	cfg     *Config
+	version string
+	client  *http.Client
+	// cache is the artifact cache shared between the domain agents, nil if not configured
+	cache api.ArtifactCache
+
+	// changes holds the changes of the updated or removed components for their rollback
+	changes   map[string]*fileChange
+	stateLock sync.Mutex
+}
+
+// fileChange describes the change of the installed file of a component.
+type fileChange struct {
+	// previous is the installed file before the update, nil if not installed
+	previous *stateFile
+	// backup is the path, which the previously installed file is moved to during the update, empty if there is no backup
+	backup string
+	// activated denotes that the new file is moved to the target directory
+	activated bool
+}
+
+func newFileHooks(cfg *Config, version string, client *http.Client) *fileHooks {
+	hooks := &fileHooks{
+		cfg:     cfg,
+		version: version,
+		client:  client,
+		changes: map[string]*fileChange{},
+	}
+	if cfg.ArtifactCache != "" {
+		hooks.cache = sdk.NewArtifactCacheClient(cfg.ArtifactCache)
+	}
+	return hooks
+}
+
+func (hooks *fileHooks) CurrentState(ctx context.Context) (*types.Inventory, error) {
+	hooks.stateLock.Lock()
+	defer hooks.stateLock.Unlock()
+
+	installed, err := loadState(hooks.cfg.StateFile)
+	if err != nil {
+		return nil, err
+	}
+	return installed.inventory(hooks.cfg.Domain, hooks.version, hooks.cfg.SoftwareType), nil
+}
+
+// Identify returns an update action for each desired component, which is not installed with the desired version and configuration,
+// and a removal action for each installed component, which is not desired. The identification fails if a desired component has
+// no valid artifact configuration, or if more than one desired component is to be installed with the same file name.
+func (hooks *fileHooks) Identify(ctx context.Context, activityID string, domain *types.Domain) ([]*sdk.Action, error) {
+	hooks.stateLock.Lock()
+	defer hooks.stateLock.Unlock()
+
+	installed, err := loadState(hooks.cfg.StateFile)
+	if err != nil {
+		return nil, err
+	}
+	hooks.changes = map[string]*fileChange{}
+	var actions []*sdk.Action
+	desired := map[string]bool{}
+	fileNames := map[string]string{}
+	for _, component := range domain.Components {
+		if component == nil {
+			continue
+		}
+		file, err := newStateFile(component)
+		if err != nil {
+			return nil, err
+		}
+		if other, ok := fileNames[file.FileName]; ok {
+			return nil, errors.Errorf("components %s and %s have the same file name '%s'", other, component.ID, file.FileName)
+		}
+		fileNames[file.FileName] = component.ID
+		desired[component.ID] = true
+		scheme, _ := version.ParseScheme(domain.VersionScheme(component))
+		if current := installed.file(component.ID); current == nil || !version.Matches(scheme, component.Version, current.Version) || !current.matches(file) {
+			actions = append(actions, &sdk.Action{Component: component})
+		}
+	}
+	for _, file := range installed.Files {
+		if !desired[file.ID] {
+			actions = append(actions, &sdk.Action{
+				Component: &types.ComponentWithConfig{Component: types.Component{ID: file.ID, Version: file.Version}},
+				Remove:    true,
+			})
+		}
+	}
+	return actions, nil
+}
+
+// Download downloads the artifact of the component to the download directory, the download is skipped if the artifact is already
+// downloaded or cached in the artifact cache, and resumed if a previous download of the artifact has been interrupted.
+// The downloaded artifact is stored in the artifact cache, if configured.
+func (hooks *fileHooks) Download(ctx context.Context, action *sdk.Action) error {
+	file, err := newStateFile(action.Component)
+	if err != nil {
+		return err
+	}
+	artifact := file.artifact()
+	staged := hooks.stagedPath(file)
+	if download.Verify(artifact, staged) == nil {
+		logger.Debug("[%s] artifact of component %s is already downloaded", hooks.cfg.Domain, action.Component.ID)
+		return nil
+	}
+	if err := os.MkdirAll(hooks.cfg.DownloadDir, 0755); err != nil {
+		return errors.Wrap(err, "cannot create download directory")
+	}
+	digest := "sha256:" + strings.ToLower(artifact.SHA256)
+	if hooks.cache != nil {
+		fetched, err := sdk.FetchArtifact(hooks.cache, digest, staged)
+		if err != nil {
+			logger.WarnErr(err, "[%s] cannot fetch artifact of component %s from the artifact cache", hooks.cfg.Domain, action.Component.ID)
+		} else if fetched {
+			logger.Debug("[%s] artifact of component %s is taken from the artifact cache", hooks.cfg.Domain, action.Component.ID)
+			return nil
+		}
+	}
+	logger.Debug("[%s] downloading artifact of component %s from %s", hooks.cfg.Domain, action.Component.ID, artifact.URL)
+	var reported int64 = -1
+	err = download.Download(ctx, hooks.client, artifact, staged, func(downloaded, total int64) {
+		if total <= 0 {
+			return
+		}
+		if percent := downloaded * 100 / total; percent != reported {
+			reported = percent
+			action.Progress(uint8(percent), "")
+		}
+	})
+	if err != nil || hooks.cache == nil {
+		return err
+	}
+	if err := sdk.StoreArtifact(hooks.cache, digest, staged); err != nil {
+		logger.WarnErr(err, "[%s] cannot store artifact of component %s in the artifact cache", hooks.cfg.Domain, action.Component.ID)
+	}
+	return nil
+}
+
+// Update verifies the downloaded artifact and backs up the installed file of the component, if any, to the backup directory.
+// The installed file is kept in the target directory until it is replaced on activation, unless the component is to be removed,
+// in which case the installed file is moved to the backup directory and removed from the state file.
+func (hooks *fileHooks) Update(ctx context.Context, action *sdk.Action) error {
+	if !action.Remove {
+		file, err := newStateFile(action.Component)
+		if err != nil {
+			return err
+		}
+		if err := download.Verify(file.artifact(), hooks.stagedPath(file)); err != nil {
+			return err
+		}
+	}
+
+	hooks.stateLock.Lock()
+	defer hooks.stateLock.Unlock()
+
+	installed, err := loadState(hooks.cfg.StateFile)
+	if err != nil {
+		return err
+	}
+	change := &fileChange{previous: installed.file(action.Component.ID)}
+	hooks.changes[action.Component.ID] = change
+	if change.previous != nil {
+		backup := filepath.Join(hooks.cfg.DownloadDir, backupDir, change.previous.FileName)
+		if err := os.MkdirAll(filepath.Dir(backup), 0755); err != nil {
+			return errors.Wrap(err, "cannot create backup directory")
+		}
+		target := hooks.targetPath(change.previous)
+		var err error
+		if action.Remove {
+			err = os.Rename(target, backup)
+		} else {
+			// the installed file is kept in the target directory until it is replaced on activation
+			err = backupFile(target, backup)
+		}
+		if err != nil && !os.IsNotExist(errors.Cause(err)) {
+			return errors.Wrapf(err, "cannot back up file %s", change.previous.FileName)
+		}
+		if err == nil {
+			change.backup = backup
+		}
+	}
+	if !action.Remove {
+		return nil
+	}
+	installed.set(action.Component.ID, nil)
+	return installed.save(hooks.cfg.StateFile)
+}
+
+// Activate copies the downloaded artifact to the target directory and records the installed file in the state file.
+// The file is first written to a temporary file and then renamed, so that an incomplete file is never visible in the target directory.
+func (hooks *fileHooks) Activate(ctx context.Context, action *sdk.Action) error {
+	file, err := newStateFile(action.Component)
+	if err != nil {
+		return err
+	}
+	target := hooks.targetPath(file)
+	if err := copyFile(hooks.stagedPath(file), target); err != nil {
+		return err
+	}
+
+	hooks.stateLock.Lock()
+	defer hooks.stateLock.Unlock()
+
+	if change := hooks.changes[action.Component.ID]; change != nil {
+		change.activated = true
+		if change.previous != nil && change.previous.FileName != file.FileName {
+			// the previously installed file is backed up, so it is removed only if replaced by a file with another name
+			if err := os.Remove(hooks.targetPath(change.previous)); err != nil && !os.IsNotExist(err) {
+				return errors.Wrapf(err, "cannot remove file %s", change.previous.FileName)
+			}
+		}
+	}
+	installed, err := loadState(hooks.cfg.StateFile)
+	if err != nil {
+		return err
+	}
+	installed.set(action.Component.ID, file)
+	return installed.save(hooks.cfg.StateFile)
+}
+
+// Rollback removes the activated file of the component, restores the previously installed file from the backup directory
+// and records it in the state file.
+func (hooks *fileHooks) Rollback(ctx context.Context, action *sdk.Action) error {
+	hooks.stateLock.Lock()
+	defer hooks.stateLock.Unlock()
+
+	change, ok := hooks.changes[action.Component.ID]
+	if !ok {
+		return nil
+	}
+	if change.activated {
+		file, err := newStateFile(action.Component)
+		if err != nil {
+			return err
+		}
+		if err := os.Remove(hooks.targetPath(file)); err != nil && !os.IsNotExist(err) {
+			return errors.Wrapf(err, "cannot remove file %s", file.FileName)
+		}
+		change.activated = false
+	}
+	if change.backup != "" {
+		if err := os.Rename(change.backup, hooks.targetPath(change.previous)); err != nil {
+			return errors.Wrapf(err, "cannot restore file %s", change.previous.FileName)
+		}
+		change.backup = ""
+	}
+	installed, err := loadState(hooks.cfg.StateFile)
+	if err != nil {
+		return err
+	}
+	installed.set(action.Component.ID, change.previous)
+	if err := installed.save(hooks.cfg.StateFile); err != nil {
+		return err
+	}
+	delete(hooks.changes, action.Component.ID)
+	return nil
+}
+
+// Cleanup removes the backup and the downloaded artifact of the component, if it has been updated or removed successfully.
+// Otherwise, they are kept, so that the downloaded artifact is reused when the update is applied again.
+func (hooks *fileHooks) Cleanup(ctx context.Context, action *sdk.Action) error {
+	status := action.Status()
+	if status != types.ActionStatusActivationSuccess && status != types.ActionStatusRemovalSuccess {
+		return nil
+	}
+
+	hooks.stateLock.Lock()
+	change := hooks.changes[action.Component.ID]
+	delete(hooks.changes, action.Component.ID)
+	hooks.stateLock.Unlock()
+
+	if change != nil && change.backup != "" {
+		if err := os.Remove(change.backup); err != nil && !os.IsNotExist(err) {
+			return errors.Wrapf(err, "cannot remove backup of file %s", change.previous.FileName)
+		}
+	}
+	if action.Remove {
+		return nil
+	}
+	file, err := newStateFile(action.Component)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(hooks.stagedPath(file)); err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "cannot remove downloaded artifact")
+	}
+	return nil
+}
+
+// stagedPath returns the path of the downloaded artifact of the given file, the artifacts are stored by their checksum.
+func (hooks *fileHooks) stagedPath(file *stateFile) string {
+	return filepath.Join(hooks.cfg.DownloadDir, strings.ToLower(file.SHA256))
+}
+
+func (hooks *fileHooks) targetPath(file *stateFile) string {
+	return filepath.Join(hooks.cfg.TargetDir, file.FileName)
+}
+
+// newStateFile returns the file to be installed for the given desired component, as described by its configuration.
+func newStateFile(component *types.ComponentWithConfig) (*stateFile, error) {
+	file := &stateFile{ID: component.ID, Version: component.Version, FileName: component.ID}
+	for _, pair := range component.Config {
+		if pair == nil {
+			continue
+		}
+		switch pair.Key {
+		case urlKey:
+			file.URL = pair.Value
+		case sizeKey:
+			size, err := strconv.ParseInt(pair.Value, 10, 64)
+			if err != nil {
+				return nil, errors.Errorf("invalid size '%s' of component %s", pair.Value, component.ID)
+			}
+			file.Size = size
+		case sha256Key:
+			file.SHA256 = pair.Value
+		case fileNameKey:
+			file.FileName = pair.Value
+		}
+	}
+	if err := file.artifact().Validate(); err != nil {
+		return nil, errors.Wrapf(err, "invalid artifact of component %s", component.ID)
+	}
+	if !fileNamePattern.MatchString(file.FileName) {
+		return nil, errors.Errorf("invalid file name '%s' of component %s", file.FileName, component.ID)
+	}
+	return file, nil
+}
+
+// copyFile copies the given file to the given target file atomically, the target directory is created if missing.
+// backupFile creates the backup of the given file as a hard link or, if not possible, e.g. on another file system, as a copy.
+func backupFile(file, backup string) error {
+	if err := os.Remove(backup); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.Link(file, backup); err == nil || os.IsNotExist(err) {
+		return err
+	}
+	return copyFile(file, backup)
+}
+
+func copyFile(source, target string) error {
+	in, err := os.Open(source)
+	if err != nil {
+		return errors.Wrapf(err, "cannot open file %s", source)
+	}
+	defer in.Close()
+
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return errors.Wrap(err, "cannot create target directory")
+	}
+	temp := target + ".tmp"
+	out, err := os.Create(temp)
+	if err != nil {
+		return errors.Wrapf(err, "cannot create file %s", temp)
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(temp)
+		return errors.Wrapf(err, "cannot write file %s", temp)
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(temp)
+		return errors.Wrapf(err, "cannot write file %s", temp)
+	}
+	if err := os.Rename(temp, target); err != nil {
+		os.Remove(temp)
+		return errors.Wrapf(err, "cannot move file to %s", target)
+	}
+	return nil
+}
@@ -0,0 +1,240 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package app
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	"github.com/eclipse-kanto/update-manager/api/agent/sdk"
+	"github.com/eclipse-kanto/update-manager/api/types"
+	"github.com/eclipse-kanto/update-manager/updatem/cache"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestHooks(t *testing.T, installed ...*stateFile) *fileHooks {
+	dir := t.TempDir()
+	cfg := newDefaultConfig()
+	cfg.StateFile = filepath.Join(dir, "state.json")
+	cfg.DownloadDir = filepath.Join(dir, "downloads")
+	cfg.TargetDir = filepath.Join(dir, "files")
+	require.NoError(t, (&state{Files: installed}).save(cfg.StateFile))
+	return newFileHooks(cfg, "1.0.0", http.DefaultClient)
+}
+
+func newTestServer(t *testing.T, content map[string]string) (*httptest.Server, *int32) {
+	requests := new(int32)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(requests, 1)
+		data, ok := content[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(data))
+	}))
+	t.Cleanup(server.Close)
+	return server, requests
+}
+
+func checksum(data string) string {
+	sum := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(sum[:])
+}
+
+func fileComponent(id, version, url, data string, config ...*types.KeyValuePair) *types.ComponentWithConfig {
+	return &types.ComponentWithConfig{
+		Component: types.Component{ID: id, Version: version},
+		Config: append([]*types.KeyValuePair{
+			{Key: urlKey, Value: url},
+			{Key: sizeKey, Value: strconv.Itoa(len(data))},
+			{Key: sha256Key, Value: checksum(data)},
+		}, config...),
+	}
+}
+
+func TestFileHooksIdentify(t *testing.T) {
+	hooks := newTestHooks(t,
+		&stateFile{ID: "app", Version: "1.0", FileName: "app", URL: "http://host/app", Size: 3, SHA256: checksum("app")},
+		&stateFile{ID: "config", Version: "1.0", FileName: "config", URL: "http://host/config", Size: 6, SHA256: checksum("config")},
+		&stateFile{ID: "legacy", Version: "0.1", FileName: "legacy", URL: "http://host/legacy", SHA256: checksum("legacy")},
+	)
+
+	actions, err := hooks.Identify(context.Background(), "activity", &types.Domain{ID: "files", Components: []*types.ComponentWithConfig{
+		fileComponent("app", "1.0", "http://host/app", "app"),
+		fileComponent("config", "1.0", "http://host/config", "config", &types.KeyValuePair{Key: fileNameKey, Value: "config.json"}),
+		fileComponent("new", "1.0", "http://host/new", "new"),
+	}})
+	require.NoError(t, err)
+	require.Len(t, actions, 3)
+	assert.Equal(t, "config", actions[0].Component.ID)
+	assert.Equal(t, "new", actions[1].Component.ID)
+	assert.Equal(t, &sdk.Action{Component: &types.ComponentWithConfig{Component: types.Component{ID: "legacy", Version: "0.1"}}, Remove: true}, actions[2])
+}
+
+func TestFileHooksIdentifyInvalid(t *testing.T) {
+	hooks := newTestHooks(t)
+	for expectedErr, components := range map[string][]*types.ComponentWithConfig{
+		"invalid artifact of component app: invalid artifact URL 'ftp://host/app', an HTTP or HTTPS URL is expected": {
+			fileComponent("app", "1.0", "ftp://host/app", "app"),
+		},
+		"invalid size 'big' of component app": {
+			fileComponent("app", "1.0", "http://host/app", "app", &types.KeyValuePair{Key: sizeKey, Value: "big"}),
+		},
+		"invalid file name '../app' of component app": {
+			fileComponent("app", "1.0", "http://host/app", "app", &types.KeyValuePair{Key: fileNameKey, Value: "../app"}),
+		},
+		"components app and tool have the same file name 'app'": {
+			fileComponent("app", "1.0", "http://host/app", "app"),
+			fileComponent("tool", "1.0", "http://host/tool", "tool", &types.KeyValuePair{Key: fileNameKey, Value: "app"}),
+		},
+	} {
+		_, err := hooks.Identify(context.Background(), "activity", &types.Domain{ID: "files", Components: components})
+		assert.EqualError(t, err, expectedErr)
+	}
+}
+
+func TestFileHooksUpdate(t *testing.T) {
+	server, requests := newTestServer(t, map[string]string{"/app/1.1": "app 1.1"})
+	hooks := newTestHooks(t,
+		&stateFile{ID: "app", Version: "1.0", FileName: "app", URL: server.URL + "/app/1.0", SHA256: checksum("app 1.0")},
+		&stateFile{ID: "legacy", Version: "0.1", FileName: "legacy.bin", URL: server.URL + "/legacy", SHA256: checksum("legacy")},
+	)
+	require.NoError(t, os.MkdirAll(hooks.cfg.TargetDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(hooks.cfg.TargetDir, "app"), []byte("app 1.0"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(hooks.cfg.TargetDir, "legacy.bin"), []byte("legacy"), 0644))
+
+	manager := sdk.NewUpdateManager("files", hooks)
+	desiredState := &types.DesiredState{Domains: []*types.Domain{{ID: "files", Components: []*types.ComponentWithConfig{
+		fileComponent("app", "1.1", server.URL+"/app/1.1", "app 1.1"),
+	}}}}
+	manager.Apply(context.Background(), "activity", desiredState)
+	for _, command := range []types.CommandType{types.CommandDownload, types.CommandUpdate} {
+		manager.Command(context.Background(), "activity", &types.DesiredStateCommand{Command: command})
+	}
+	// the installed file is kept until activation, while the removed one is moved to the backup directory
+	assertFile(t, filepath.Join(hooks.cfg.TargetDir, "app"), "app 1.0")
+	assertFile(t, filepath.Join(hooks.cfg.DownloadDir, backupDir, "app"), "app 1.0")
+	assert.NoFileExists(t, filepath.Join(hooks.cfg.TargetDir, "legacy.bin"))
+
+	manager.Command(context.Background(), "activity", &types.DesiredStateCommand{Command: types.CommandActivate})
+	assertFile(t, filepath.Join(hooks.cfg.TargetDir, "app"), "app 1.1")
+	assert.NoFileExists(t, filepath.Join(hooks.cfg.TargetDir, "legacy.bin"))
+	assert.FileExists(t, filepath.Join(hooks.cfg.DownloadDir, backupDir, "app"))
+	inventory, err := manager.Get(context.Background(), "activity")
+	require.NoError(t, err)
+	require.Len(t, inventory.SoftwareNodes, 2)
+	assert.Equal(t, "files:app", inventory.SoftwareNodes[1].ID)
+	assert.Equal(t, "1.1", inventory.SoftwareNodes[1].Version)
+	assert.Equal(t, desiredState.Domains[0].Components[0].Config, inventory.SoftwareNodes[1].Parameters[:3])
+
+	// the cleanup removes the backups and the downloaded artifacts of the successful actions
+	manager.Command(context.Background(), "activity", &types.DesiredStateCommand{Command: types.CommandCleanup})
+	assert.NoFileExists(t, filepath.Join(hooks.cfg.DownloadDir, backupDir, "app"))
+	assert.NoFileExists(t, filepath.Join(hooks.cfg.DownloadDir, backupDir, "legacy.bin"))
+	assert.NoFileExists(t, filepath.Join(hooks.cfg.DownloadDir, checksum("app 1.1")))
+	assert.Equal(t, int32(1), atomic.LoadInt32(requests))
+}
+
+func TestFileHooksRollback(t *testing.T) {
+	server, _ := newTestServer(t, map[string]string{"/app/1.1": "app 1.1"})
+	installed := &stateFile{ID: "app", Version: "1.0", FileName: "app.bin", URL: server.URL + "/app/1.0", SHA256: checksum("app 1.0")}
+	hooks := newTestHooks(t, installed)
+	require.NoError(t, os.MkdirAll(hooks.cfg.TargetDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(hooks.cfg.TargetDir, "app.bin"), []byte("app 1.0"), 0644))
+
+	manager := sdk.NewUpdateManager("files", hooks)
+	manager.Apply(context.Background(), "activity", &types.DesiredState{Domains: []*types.Domain{{ID: "files", Components: []*types.ComponentWithConfig{
+		fileComponent("app", "1.1", server.URL+"/app/1.1", "app 1.1", &types.KeyValuePair{Key: fileNameKey, Value: "app-1.1.bin"}),
+	}}}})
+	for _, command := range []types.CommandType{types.CommandDownload, types.CommandUpdate, types.CommandRollback} {
+		manager.Command(context.Background(), "activity", &types.DesiredStateCommand{Command: command})
+	}
+
+	assertFile(t, filepath.Join(hooks.cfg.TargetDir, "app.bin"), "app 1.0")
+	assert.NoFileExists(t, filepath.Join(hooks.cfg.TargetDir, "app-1.1.bin"))
+	state, err := loadState(hooks.cfg.StateFile)
+	require.NoError(t, err)
+	assert.Equal(t, []*stateFile{installed}, state.Files)
+}
+
+func TestFileHooksActivateRenamed(t *testing.T) {
+	server, _ := newTestServer(t, map[string]string{"/app/1.1": "app 1.1"})
+	hooks := newTestHooks(t, &stateFile{ID: "app", Version: "1.0", FileName: "app.bin", URL: server.URL + "/app/1.0", SHA256: checksum("app 1.0")})
+	require.NoError(t, os.MkdirAll(hooks.cfg.TargetDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(hooks.cfg.TargetDir, "app.bin"), []byte("app 1.0"), 0644))
+	action := &sdk.Action{Component: fileComponent("app", "1.1", server.URL+"/app/1.1", "app 1.1", &types.KeyValuePair{Key: fileNameKey, Value: "app-1.1.bin"})}
+
+	require.NoError(t, hooks.Download(context.Background(), action))
+	require.NoError(t, hooks.Update(context.Background(), action))
+	assertFile(t, filepath.Join(hooks.cfg.TargetDir, "app.bin"), "app 1.0")
+	require.NoError(t, hooks.Activate(context.Background(), action))
+	// the previously installed file is removed, as it is replaced by a file with another name
+	assertFile(t, filepath.Join(hooks.cfg.TargetDir, "app-1.1.bin"), "app 1.1")
+	assert.NoFileExists(t, filepath.Join(hooks.cfg.TargetDir, "app.bin"))
+	assertFile(t, filepath.Join(hooks.cfg.DownloadDir, backupDir, "app.bin"), "app 1.0")
+}
+
+func TestFileHooksDownload(t *testing.T) {
+	server, requests := newTestServer(t, map[string]string{"/app": "app"})
+	hooks := newTestHooks(t)
+	action := &sdk.Action{Component: fileComponent("app", "1.0", server.URL+"/app", "app")}
+
+	require.NoError(t, hooks.Download(context.Background(), action))
+	assertFile(t, filepath.Join(hooks.cfg.DownloadDir, checksum("app")), "app")
+	// the download is skipped, if the artifact is already downloaded
+	require.NoError(t, hooks.Download(context.Background(), action))
+	assert.Equal(t, int32(1), atomic.LoadInt32(requests))
+
+	action = &sdk.Action{Component: fileComponent("tool", "1.0", server.URL+"/app", "tool")}
+	assert.Error(t, hooks.Download(context.Background(), action))
+	assert.NoFileExists(t, filepath.Join(hooks.cfg.DownloadDir, checksum("tool")))
+	// the update fails, if the artifact is not downloaded
+	assert.Error(t, hooks.Update(context.Background(), action))
+}
+
+func TestFileHooksDownloadArtifactCache(t *testing.T) {
+	server, requests := newTestServer(t, map[string]string{"/app": "app"})
+	artifactCache, err := cache.New(&cache.Config{Dir: t.TempDir(), MaxSize: 1, ReservationTimeout: "1m"})
+	require.NoError(t, err)
+
+	hooks := newTestHooks(t)
+	hooks.cache = artifactCache
+	require.NoError(t, hooks.Download(context.Background(), &sdk.Action{Component: fileComponent("app", "1.0", server.URL+"/app", "app")}))
+	usage, err := artifactCache.Usage()
+	require.NoError(t, err)
+	assert.Equal(t, 1, usage.Artifacts)
+
+	// the artifact is taken from the cache by another domain agent
+	other := newTestHooks(t)
+	other.cache = artifactCache
+	require.NoError(t, other.Download(context.Background(), &sdk.Action{Component: fileComponent("copy", "1.0", server.URL+"/app", "app")}))
+	assertFile(t, filepath.Join(other.cfg.DownloadDir, checksum("app")), "app")
+	assert.Equal(t, int32(1), atomic.LoadInt32(requests))
+}
+
+func assertFile(t *testing.T, file, expected string) {
+	data, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Equal(t, expected, string(data))
+}
@@ -0,0 +1,52 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package app
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/eclipse-kanto/update-manager/api/agent"
+	"github.com/eclipse-kanto/update-manager/api/agent/sdk"
+	"github.com/eclipse-kanto/update-manager/logger"
+	"github.com/eclipse-kanto/update-manager/mqtt"
+)
+
+// Launch starts the file update agent and runs it until a termination signal is received.
+func Launch(cfg *Config, version string) error {
+	client, err := mqtt.NewUpdateAgentClient(cfg.Domain, cfg.MQTT)
+	if err != nil {
+		return err
+	}
+	feedbackInterval, _ := time.ParseDuration(cfg.ReportFeedbackInterval)
+	updateAgent := agent.NewUpdateAgent(client, sdk.NewUpdateManager(cfg.Domain, newFileHooks(cfg, version, http.DefaultClient)),
+		agent.WithDesiredStateFeedbackReportInterval(feedbackInterval))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	logger.Debug("starting file update agent for domain %s", cfg.Domain)
+	if err := updateAgent.Start(ctx); err != nil {
+		return err
+	}
+
+	signalChan := make(chan os.Signal, 1)
+	signal.Notify(signalChan, syscall.SIGINT, syscall.SIGTERM)
+	sig := <-signalChan
+	logger.Debug("received OS SIGNAL >> %d ! Will exit!", sig)
+	cancel()
+	return updateAgent.Stop()
+}
@@ -0,0 +1,141 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package app
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/eclipse-kanto/update-manager/api/types"
+	"github.com/eclipse-kanto/update-manager/util/download"
+
+	"github.com/pkg/errors"
+)
+
+// state holds the installed files of the domain.
+type state struct {
+	Files []*stateFile `json:"files"`
+}
+
+type stateFile struct {
+	ID       string `json:"id"`
+	Version  string `json:"version"`
+	FileName string `json:"fileName"`
+	URL      string `json:"url"`
+	Size     int64  `json:"size,omitempty"`
+	SHA256   string `json:"sha256"`
+}
+
+// loadState reads the state file, there are no installed files if the file does not exist.
+func loadState(file string) (*state, error) {
+	data, err := os.ReadFile(file)
+	if os.IsNotExist(err) {
+		return &state{}, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot read state file")
+	}
+	installed := &state{}
+	if err := json.Unmarshal(data, installed); err != nil {
+		return nil, errors.Wrapf(err, "cannot parse state file %s", file)
+	}
+	return installed, nil
+}
+
+// save writes the state file atomically, so that a failure does not leave a partially written state file.
+func (installed *state) save(file string) error {
+	data, err := json.MarshalIndent(installed, "", "\t")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return errors.Wrap(err, "cannot create state file directory")
+	}
+	temp := file + ".tmp"
+	if err := os.WriteFile(temp, data, 0644); err != nil {
+		return errors.Wrap(err, "cannot write state file")
+	}
+	return errors.Wrap(os.Rename(temp, file), "cannot write state file")
+}
+
+// file returns the installed file of the given component, or nil if not installed.
+func (installed *state) file(id string) *stateFile {
+	for _, file := range installed.Files {
+		if file.ID == id {
+			return file
+		}
+	}
+	return nil
+}
+
+// set sets the installed file of the given component, the file is removed from the state if nil.
+func (installed *state) set(id string, file *stateFile) {
+	files := installed.Files[:0]
+	for _, installedFile := range installed.Files {
+		if installedFile.ID != id {
+			files = append(files, installedFile)
+		}
+	}
+	if file != nil {
+		files = append(files, file)
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].ID < files[j].ID
+	})
+	installed.Files = files
+}
+
+// inventory returns the current state of the domain: the update agent node linked to the nodes of the installed files.
+func (installed *state) inventory(domain, version string, softwareType types.SoftwareType) *types.Inventory {
+	agentNode := &types.SoftwareNode{
+		InventoryNode: types.InventoryNode{
+			ID:         domain + "-update-agent",
+			Version:    version,
+			Name:       "File Update Agent",
+			Parameters: []*types.KeyValuePair{{Key: "domain", Value: domain}},
+		},
+		Type: types.SoftwareTypeApplication,
+	}
+	inventory := &types.Inventory{SoftwareNodes: []*types.SoftwareNode{agentNode}}
+	for _, file := range installed.Files {
+		node := &types.SoftwareNode{
+			InventoryNode: types.InventoryNode{ID: domain + ":" + file.ID, Version: file.Version, Parameters: file.parameters()},
+			Type:          softwareType,
+		}
+		inventory.SoftwareNodes = append(inventory.SoftwareNodes, node)
+		inventory.Associations = append(inventory.Associations, &types.Association{SourceID: agentNode.ID, TargetID: node.ID})
+	}
+	return inventory
+}
+
+// parameters returns the node parameters of the installed file, which match the component configuration the file is installed with.
+func (file *stateFile) parameters() []*types.KeyValuePair {
+	parameters := []*types.KeyValuePair{{Key: urlKey, Value: file.URL}}
+	if file.Size > 0 {
+		parameters = append(parameters, &types.KeyValuePair{Key: sizeKey, Value: strconv.FormatInt(file.Size, 10)})
+	}
+	return append(parameters, &types.KeyValuePair{Key: sha256Key, Value: file.SHA256}, &types.KeyValuePair{Key: fileNameKey, Value: file.FileName})
+}
+
+// matches returns true if the installed file has the same artifact and file name as the given one.
+func (file *stateFile) matches(other *stateFile) bool {
+	return file.FileName == other.FileName && file.URL == other.URL && file.Size == other.Size && strings.EqualFold(file.SHA256, other.SHA256)
+}
+
+func (file *stateFile) artifact() *download.Artifact {
+	return &download.Artifact{URL: file.URL, Size: file.Size, SHA256: file.SHA256}
+}
@@ -0,0 +1,66 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/eclipse-kanto/update-manager/api/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestState(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "state", "state.json")
+	installed, err := loadState(file)
+	require.NoError(t, err)
+	assert.Empty(t, installed.Files)
+
+	installed.set("b", &stateFile{ID: "b", Version: "1.0", FileName: "b.bin"})
+	installed.set("a", &stateFile{ID: "a", Version: "2.0", FileName: "a.bin"})
+	require.NoError(t, installed.save(file))
+
+	installed, err = loadState(file)
+	require.NoError(t, err)
+	assert.Equal(t, []*stateFile{{ID: "a", Version: "2.0", FileName: "a.bin"}, {ID: "b", Version: "1.0", FileName: "b.bin"}}, installed.Files)
+	installed.set("a", nil)
+	assert.Nil(t, installed.file("a"))
+	assert.Equal(t, &stateFile{ID: "b", Version: "1.0", FileName: "b.bin"}, installed.file("b"))
+
+	require.NoError(t, os.WriteFile(file, []byte("invalid"), 0644))
+	_, err = loadState(file)
+	assert.Error(t, err)
+}
+
+func TestStateInventory(t *testing.T) {
+	installed := &state{Files: []*stateFile{{ID: "model", Version: "2.1", FileName: "model.bin", URL: "https://example.com/model.bin", Size: 42, SHA256: "abc"}}}
+	assert.Equal(t, &types.Inventory{
+		SoftwareNodes: []*types.SoftwareNode{
+			{
+				InventoryNode: types.InventoryNode{ID: "files-update-agent", Version: "1.0.0", Name: "File Update Agent",
+					Parameters: []*types.KeyValuePair{{Key: "domain", Value: "files"}}},
+				Type: types.SoftwareTypeApplication,
+			},
+			{
+				InventoryNode: types.InventoryNode{ID: "files:model", Version: "2.1", Parameters: []*types.KeyValuePair{
+					{Key: "url", Value: "https://example.com/model.bin"}, {Key: "size", Value: "42"}, {Key: "sha256", Value: "abc"}, {Key: "fileName", Value: "model.bin"},
+				}},
+				Type: types.SoftwareTypeRaw,
+			},
+		},
+		Associations: []*types.Association{{SourceID: "files-update-agent", TargetID: "files:model"}},
+	}, installed.inventory("files", "1.0.0", types.SoftwareTypeRaw))
+}
@@ -0,0 +1,44 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package main
+
+import (
+	"log"
+	"os"
+
+	"github.com/eclipse-kanto/update-manager/cmd/file-update-agent/app"
+	"github.com/eclipse-kanto/update-manager/logger"
+)
+
+var (
+	version = "development"
+)
+
+func main() {
+	cfg, err := app.LoadConfig(version)
+	if err != nil {
+		log.Fatal("failed to load local configuration: ", err)
+	}
+
+	loggerOut, err := logger.SetupLogger(cfg.Log, "[file-update-agent]")
+	if err != nil {
+		log.Fatal("failed to initialize logger: ", err)
+	}
+	defer loggerOut.Close()
+
+	if err := app.Launch(cfg, version); err != nil {
+		logger.ErrorErr(err, "failed to run file update agent")
+		loggerOut.Close()
+		os.Exit(1)
+	}
+}
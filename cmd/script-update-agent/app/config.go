@@ -0,0 +1,169 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package app
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/eclipse-kanto/update-manager/api/types"
+	"github.com/eclipse-kanto/update-manager/config"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	domainDefault                 = "scripts"
+	stateFileDefault              = "/var/lib/script-update-agent/state.json"
+	scriptTimeoutDefault          = "10m"
+	reportFeedbackIntervalDefault = "1s"
+)
+
+// Config represents the script update agent configuration.
+type Config struct {
+	*config.BaseConfig
+	// StateFile is the file, which holds the installed components of the domain
+	StateFile string `json:"stateFile"`
+	// SoftwareType is the type of the software nodes reported for the installed components
+	SoftwareType types.SoftwareType `json:"softwareType"`
+	// ScriptTimeout is the default timeout of the scripts
+	ScriptTimeout          string `json:"scriptTimeout"`
+	ReportFeedbackInterval string `json:"reportFeedbackInterval"`
+	// Scripts holds the scripts run for the phases of all components
+	Scripts *Scripts `json:"scripts,omitempty"`
+	// Components holds the scripts run for the phases of the single components by component ID, which take precedence over the common scripts
+	Components map[string]*Scripts `json:"components,omitempty"`
+}
+
+// Scripts holds the scripts run for the phases of a component, a phase without script is skipped.
+type Scripts struct {
+	Download *Script `json:"download,omitempty"`
+	Update   *Script `json:"update,omitempty"`
+	Activate *Script `json:"activate,omitempty"`
+	Rollback *Script `json:"rollback,omitempty"`
+	Cleanup  *Script `json:"cleanup,omitempty"`
+}
+
+// Script holds a shell command and its timeout, the script timeout is used if not set.
+type Script struct {
+	Command string `json:"command"`
+	Timeout string `json:"timeout,omitempty"`
+}
+
+func newDefaultConfig() *Config {
+	baseConfig := config.DefaultDomainConfig(domainDefault)
+	baseConfig.ThingsEnabled = false
+	return &Config{
+		BaseConfig:             baseConfig,
+		StateFile:              stateFileDefault,
+		SoftwareType:           types.SoftwareTypeApplication,
+		ScriptTimeout:          scriptTimeoutDefault,
+		ReportFeedbackInterval: reportFeedbackIntervalDefault,
+	}
+}
+
+// LoadConfig loads the configuration from the configuration file, the environment variables and the command line flags.
+func LoadConfig(version string) (*Config, error) {
+	cfg := newDefaultConfig()
+	if configFilePath := config.ParseConfigFilePath(); configFilePath != "" {
+		if err := config.LoadConfigFromFile(configFilePath, cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	flagSet := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	config.SetupFlags(flagSet, cfg.BaseConfig)
+	flagSet.StringVar(&cfg.StateFile, "state-file", config.EnvToString("STATE_FILE", cfg.StateFile), "Specify the file, which holds the installed components of the domain")
+	flagSet.StringVar((*string)(&cfg.SoftwareType), "software-type", config.EnvToString("SOFTWARE_TYPE", string(cfg.SoftwareType)), "Specify the type of the software nodes reported for the installed components - possible values are IMAGE, RAW, DATA, APPLICATION, CONTAINER")
+	flagSet.StringVar(&cfg.ScriptTimeout, "script-timeout", config.EnvToString("SCRIPT_TIMEOUT", cfg.ScriptTimeout), "Specify the default timeout of the scripts. Value should be a positive integer number followed by a unit suffix, such as '60s', '10m', etc")
+	flagSet.StringVar(&cfg.ReportFeedbackInterval, "report-feedback-interval", config.EnvToString("REPORT_FEEDBACK_INTERVAL", cfg.ReportFeedbackInterval), "Specify the interval, which the progress of the scripts is reported at most once per. Value should be a positive integer number followed by a unit suffix, such as '1s', '10s', etc")
+	fVersion := flagSet.Bool("version", false, "Prints current version and exits")
+	if err := flagSet.Parse(os.Args[1:]); err != nil {
+		return nil, err
+	}
+	if *fVersion {
+		fmt.Println(version)
+		os.Exit(0)
+	}
+	return cfg, validateConfig(cfg)
+}
+
+func validateConfig(cfg *Config) error {
+	if cfg.Domain == "" {
+		return errors.New("the domain is not set")
+	}
+	if cfg.StateFile == "" {
+		return errors.New("the state file is not set")
+	}
+	switch cfg.SoftwareType {
+	case types.SoftwareTypeImage, types.SoftwareTypeRaw, types.SoftwareTypeData, types.SoftwareTypeApplication, types.SoftwareTypeContainer:
+	default:
+		return errors.Errorf("unknown software type '%s'", cfg.SoftwareType)
+	}
+	if err := validateDuration("scriptTimeout", cfg.ScriptTimeout); err != nil {
+		return err
+	}
+	if err := validateDuration("reportFeedbackInterval", cfg.ReportFeedbackInterval); err != nil {
+		return err
+	}
+	if err := cfg.Scripts.validate("scripts"); err != nil {
+		return err
+	}
+	for id, scripts := range cfg.Components {
+		if err := scripts.validate("components." + id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (scripts *Scripts) validate(property string) error {
+	for phase, script := range scripts.byPhase() {
+		if script == nil {
+			continue
+		}
+		if script.Command == "" {
+			return errors.Errorf("the command of %s.%s is not set", property, phase)
+		}
+		if script.Timeout != "" {
+			if err := validateDuration(property+"."+phase+".timeout", script.Timeout); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// byPhase returns the scripts by phase name, there are no scripts if nil.
+func (scripts *Scripts) byPhase() map[string]*Script {
+	if scripts == nil {
+		return nil
+	}
+	return map[string]*Script{
+		phaseDownload: scripts.Download,
+		phaseUpdate:   scripts.Update,
+		phaseActivate: scripts.Activate,
+		phaseRollback: scripts.Rollback,
+		phaseCleanup:  scripts.Cleanup,
+	}
+}
+
+func validateDuration(property, value string) error {
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration <= 0 {
+		return errors.Errorf("invalid %s '%s', a positive duration is expected", property, value)
+	}
+	return nil
+}
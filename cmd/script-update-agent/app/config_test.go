@@ -0,0 +1,54 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package app
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/eclipse-kanto/update-manager/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateConfig(t *testing.T) {
+	assert.NoError(t, validateConfig(newDefaultConfig()))
+
+	for expectedErr, modify := range map[string]func(*Config){
+		"the domain is not set":                                                   func(cfg *Config) { cfg.Domain = "" },
+		"the state file is not set":                                               func(cfg *Config) { cfg.StateFile = "" },
+		"unknown software type 'BINARY'":                                          func(cfg *Config) { cfg.SoftwareType = "BINARY" },
+		"invalid scriptTimeout '0s', a positive duration is expected":             func(cfg *Config) { cfg.ScriptTimeout = "0s" },
+		"invalid reportFeedbackInterval 'often', a positive duration is expected": func(cfg *Config) { cfg.ReportFeedbackInterval = "often" },
+		"the command of scripts.update is not set":                                func(cfg *Config) { cfg.Scripts = &Scripts{Update: &Script{}} },
+		"invalid components.app.cleanup.timeout '-1m', a positive duration is expected": func(cfg *Config) {
+			cfg.Components = map[string]*Scripts{"app": {Cleanup: &Script{Command: "true", Timeout: "-1m"}}}
+		},
+	} {
+		cfg := newDefaultConfig()
+		modify(cfg)
+		assert.EqualError(t, validateConfig(cfg), expectedErr)
+	}
+}
+
+func TestLoadConfigFile(t *testing.T) {
+	cfg := newDefaultConfig()
+	require.NoError(t, config.LoadConfigFromFile(filepath.Join("testdata", "config.json"), cfg))
+	assert.NoError(t, validateConfig(cfg))
+	assert.Equal(t, "files", cfg.Domain)
+	assert.Equal(t, "/var/lib/files-update-agent/state.json", cfg.StateFile)
+	assert.Equal(t, "5m", cfg.ScriptTimeout)
+	assert.Equal(t, &Script{Command: "cp \"$UA_CONFIG_SOURCE\" /opt/files/", Timeout: "1m"}, cfg.Scripts.Update)
+	assert.Equal(t, &Script{Command: "/opt/files/flash.sh"}, cfg.Components["firmware"].Activate)
+}
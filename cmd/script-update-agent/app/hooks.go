@@ -0,0 +1,221 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package app
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/eclipse-kanto/update-manager/api/agent/sdk"
+	"github.com/eclipse-kanto/update-manager/api/types"
+	"github.com/eclipse-kanto/update-manager/logger"
+	"github.com/eclipse-kanto/update-manager/util/version"
+)
+
+const (
+	phaseDownload = "download"
+	phaseUpdate   = "update"
+	phaseActivate = "activate"
+	phaseRollback = "rollback"
+	phaseCleanup  = "cleanup"
+
+	envPrefix = "UA_"
+)
+
+// scriptHooks implements the update agent hooks by running the configured scripts and keeps the installed components in the state file.
+type scriptHooks struct {
+	cfg     *Config
+	version string
+	timeout time.Duration
+
+	// domainConfig holds the configuration of the domain in the desired state, which is passed to the scripts of all components
+	domainConfig []*types.KeyValuePair
+	// previous holds the installed versions of the updated components for their rollback, nil if not installed
+	previous  map[string]*string
+	stateLock sync.Mutex
+}
+
+func newScriptHooks(cfg *Config, version string) *scriptHooks {
+	timeout, _ := time.ParseDuration(cfg.ScriptTimeout)
+	return &scriptHooks{
+		cfg:      cfg,
+		version:  version,
+		timeout:  timeout,
+		previous: map[string]*string{},
+	}
+}
+
+func (hooks *scriptHooks) CurrentState(ctx context.Context) (*types.Inventory, error) {
+	hooks.stateLock.Lock()
+	defer hooks.stateLock.Unlock()
+
+	installed, err := loadState(hooks.cfg.StateFile)
+	if err != nil {
+		return nil, err
+	}
+	return installed.inventory(hooks.cfg.Domain, hooks.version, hooks.cfg.SoftwareType), nil
+}
+
+// Identify returns an update action for each desired component, which is not installed with the desired version,
+// and a removal action for each installed component, which is not desired.
+func (hooks *scriptHooks) Identify(ctx context.Context, activityID string, domain *types.Domain) ([]*sdk.Action, error) {
+	hooks.stateLock.Lock()
+	defer hooks.stateLock.Unlock()
+
+	installed, err := loadState(hooks.cfg.StateFile)
+	if err != nil {
+		return nil, err
+	}
+	hooks.domainConfig = domain.Config
+	hooks.previous = map[string]*string{}
+	var actions []*sdk.Action
+	desired := map[string]bool{}
+	for _, component := range domain.Components {
+		if component == nil {
+			continue
+		}
+		desired[component.ID] = true
+		scheme, _ := version.ParseScheme(domain.VersionScheme(component))
+		if installedVersion, ok := installed.version(component.ID); !ok || !version.Matches(scheme, component.Version, installedVersion) {
+			actions = append(actions, &sdk.Action{Component: component})
+		}
+	}
+	for _, component := range installed.Components {
+		if !desired[component.ID] {
+			actions = append(actions, &sdk.Action{
+				Component: &types.ComponentWithConfig{Component: types.Component{ID: component.ID, Version: component.Version}},
+				Remove:    true,
+			})
+		}
+	}
+	return actions, nil
+}
+
+func (hooks *scriptHooks) Download(ctx context.Context, action *sdk.Action) error {
+	return hooks.run(ctx, phaseDownload, action)
+}
+
+// Update runs the update script and records the new version of the component, or its removal, in the state file.
+func (hooks *scriptHooks) Update(ctx context.Context, action *sdk.Action) error {
+	if err := hooks.run(ctx, phaseUpdate, action); err != nil {
+		return err
+	}
+	var version *string
+	if !action.Remove {
+		version = &action.Component.Version
+	}
+	return hooks.setInstalled(action.Component.ID, version, true)
+}
+
+func (hooks *scriptHooks) Activate(ctx context.Context, action *sdk.Action) error {
+	return hooks.run(ctx, phaseActivate, action)
+}
+
+// Rollback runs the rollback script and records the version of the component before the update in the state file.
+func (hooks *scriptHooks) Rollback(ctx context.Context, action *sdk.Action) error {
+	if err := hooks.run(ctx, phaseRollback, action); err != nil {
+		return err
+	}
+	hooks.stateLock.Lock()
+	previous, ok := hooks.previous[action.Component.ID]
+	hooks.stateLock.Unlock()
+	if !ok {
+		return nil
+	}
+	return hooks.setInstalled(action.Component.ID, previous, false)
+}
+
+func (hooks *scriptHooks) Cleanup(ctx context.Context, action *sdk.Action) error {
+	return hooks.run(ctx, phaseCleanup, action)
+}
+
+// setInstalled sets the installed version of the given component in the state file, the component is removed if the version is nil.
+// The previously installed version is kept for the rollback, if requested.
+func (hooks *scriptHooks) setInstalled(id string, version *string, keepPrevious bool) error {
+	hooks.stateLock.Lock()
+	defer hooks.stateLock.Unlock()
+
+	installed, err := loadState(hooks.cfg.StateFile)
+	if err != nil {
+		return err
+	}
+	if keepPrevious {
+		if previous, ok := installed.version(id); ok {
+			hooks.previous[id] = &previous
+		} else {
+			hooks.previous[id] = nil
+		}
+	}
+	installed.set(id, version)
+	return installed.save(hooks.cfg.StateFile)
+}
+
+// run runs the script of the given phase for the component of the given action, the phases without script are skipped.
+func (hooks *scriptHooks) run(ctx context.Context, phase string, action *sdk.Action) error {
+	script := hooks.script(action.Component.ID, phase)
+	if script == nil {
+		return nil
+	}
+	timeout := hooks.timeout
+	if script.Timeout != "" {
+		timeout, _ = time.ParseDuration(script.Timeout)
+	}
+	logger.Debug("[%s] running %s script for component %s", hooks.cfg.Domain, phase, action.Component.ID)
+	return runScript(ctx, script.Command, timeout, hooks.env(phase, action), action.Progress)
+}
+
+// script returns the script of the given phase for the given component, the component scripts take precedence over the common ones.
+func (hooks *scriptHooks) script(id, phase string) *Script {
+	if script := hooks.cfg.Components[id].byPhase()[phase]; script != nil {
+		return script
+	}
+	return hooks.cfg.Scripts.byPhase()[phase]
+}
+
+// env returns the environment variables, which describe the phase and the component to the script.
+// The component configuration takes precedence over the domain configuration.
+func (hooks *scriptHooks) env(phase string, action *sdk.Action) []string {
+	env := []string{
+		envPrefix + "DOMAIN=" + hooks.cfg.Domain,
+		envPrefix + "PHASE=" + phase,
+		envPrefix + "COMPONENT_ID=" + action.Component.ID,
+		envPrefix + "COMPONENT_VERSION=" + action.Component.Version,
+		envPrefix + "COMPONENT_REMOVE=" + strconv.FormatBool(action.Remove),
+	}
+	hooks.stateLock.Lock()
+	config := append(append([]*types.KeyValuePair{}, hooks.domainConfig...), action.Component.Config...)
+	hooks.stateLock.Unlock()
+	for _, pair := range config {
+		if pair != nil {
+			env = append(env, envPrefix+"CONFIG_"+envName(pair.Key)+"="+pair.Value)
+		}
+	}
+	return env
+}
+
+// envName converts the given configuration key to an environment variable name, e.g. "max-size" to "MAX_SIZE".
+func envName(key string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		default:
+			return '_'
+		}
+	}, key)
+}
@@ -0,0 +1,119 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+//go:build !windows
+// +build !windows
+
+package app
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/eclipse-kanto/update-manager/api/agent/sdk"
+	"github.com/eclipse-kanto/update-manager/api/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestHooks(t *testing.T, scripts *Scripts, installed ...*stateComponent) (*scriptHooks, string) {
+	dir := t.TempDir()
+	cfg := newDefaultConfig()
+	cfg.Domain = "files"
+	cfg.StateFile = filepath.Join(dir, "state.json")
+	cfg.Scripts = scripts
+	require.NoError(t, (&state{Components: installed}).save(cfg.StateFile))
+	return newScriptHooks(cfg, "1.0.0"), dir
+}
+
+func TestScriptHooksIdentify(t *testing.T) {
+	hooks, _ := newTestHooks(t, nil, &stateComponent{ID: "app", Version: "1.0.0"}, &stateComponent{ID: "legacy", Version: "0.1"},
+		&stateComponent{ID: "tool", Version: "2.0"})
+
+	actions, err := hooks.Identify(context.Background(), "activity", &types.Domain{ID: "files", Components: []*types.ComponentWithConfig{
+		{Component: types.Component{ID: "app", Version: "1.1.0"}},
+		{Component: types.Component{ID: "tool", Version: "v2"}},
+		{Component: types.Component{ID: "new", Version: "1.0"}},
+	}})
+	require.NoError(t, err)
+	require.Len(t, actions, 3)
+	assert.Equal(t, &sdk.Action{Component: &types.ComponentWithConfig{Component: types.Component{ID: "app", Version: "1.1.0"}}}, actions[0])
+	assert.Equal(t, "new", actions[1].Component.ID)
+	assert.Equal(t, &sdk.Action{Component: &types.ComponentWithConfig{Component: types.Component{ID: "legacy", Version: "0.1"}}, Remove: true}, actions[2])
+}
+
+func TestScriptHooksUpdate(t *testing.T) {
+	hooks, dir := newTestHooks(t, &Scripts{
+		Download: &Script{Command: `echo "$UA_PHASE $UA_COMPONENT_ID $UA_COMPONENT_VERSION $UA_CONFIG_TARGET_DIR" >> ` + filepath.Join(t.TempDir(), "log")},
+		Update:   &Script{Command: `[ "$UA_COMPONENT_REMOVE" = true ] && rm "$UA_CONFIG_TARGET_DIR/$UA_COMPONENT_ID" || echo "$UA_COMPONENT_VERSION" > "$UA_CONFIG_TARGET_DIR/$UA_COMPONENT_ID"`},
+		Rollback: &Script{Command: "true"},
+	}, &stateComponent{ID: "legacy", Version: "0.1"})
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "legacy"), []byte("0.1"), 0644))
+
+	manager := sdk.NewUpdateManager("files", hooks)
+	manager.Apply(context.Background(), "activity", &types.DesiredState{Domains: []*types.Domain{{
+		ID:         "files",
+		Config:     []*types.KeyValuePair{{Key: "target-dir", Value: dir}},
+		Components: []*types.ComponentWithConfig{{Component: types.Component{ID: "app", Version: "1.1.0"}}},
+	}}})
+	for _, command := range []types.CommandType{types.CommandDownload, types.CommandUpdate} {
+		manager.Command(context.Background(), "activity", &types.DesiredStateCommand{Command: command})
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "app"))
+	require.NoError(t, err)
+	assert.Equal(t, "1.1.0\n", string(data))
+	assert.NoFileExists(t, filepath.Join(dir, "legacy"))
+	inventory, err := manager.Get(context.Background(), "activity")
+	require.NoError(t, err)
+	require.Len(t, inventory.SoftwareNodes, 2)
+	assert.Equal(t, types.InventoryNode{ID: "files:app", Version: "1.1.0"}, inventory.SoftwareNodes[1].InventoryNode)
+
+	// the rollback restores the previously installed components in the state file
+	manager.Command(context.Background(), "activity", &types.DesiredStateCommand{Command: types.CommandRollback})
+	installed, err := loadState(hooks.cfg.StateFile)
+	require.NoError(t, err)
+	assert.Equal(t, []*stateComponent{{ID: "legacy", Version: "0.1"}}, installed.Components)
+}
+
+func TestScriptHooksFailure(t *testing.T) {
+	hooks, _ := newTestHooks(t, &Scripts{Update: &Script{Command: "echo 'checksum mismatch' >&2; exit 1"}})
+	action := &sdk.Action{Component: &types.ComponentWithConfig{Component: types.Component{ID: "app", Version: "1.0"}}}
+
+	assert.EqualError(t, hooks.Update(context.Background(), action), "checksum mismatch: exit status 1")
+	installed, err := loadState(hooks.cfg.StateFile)
+	require.NoError(t, err)
+	assert.Empty(t, installed.Components)
+	// the phases without script are skipped
+	assert.NoError(t, hooks.Activate(context.Background(), action))
+}
+
+func TestEnvName(t *testing.T) {
+	assert.Equal(t, "TARGET_DIR", envName("target-dir"))
+	assert.Equal(t, "MAX_SIZE_2", envName("max.Size_2"))
+}
+
+func TestScriptHooksEnv(t *testing.T) {
+	hooks, _ := newTestHooks(t, nil)
+	hooks.domainConfig = []*types.KeyValuePair{{Key: "mode", Value: "domain"}, {Key: "target-dir", Value: "/opt"}}
+	action := &sdk.Action{Component: &types.ComponentWithConfig{Component: types.Component{ID: "app", Version: "1.0"},
+		Config: []*types.KeyValuePair{{Key: "mode", Value: "component"}}}}
+
+	assert.Equal(t, []string{"UA_DOMAIN=files", "UA_PHASE=update", "UA_COMPONENT_ID=app", "UA_COMPONENT_VERSION=1.0", "UA_COMPONENT_REMOVE=false",
+		"UA_CONFIG_MODE=domain", "UA_CONFIG_TARGET_DIR=/opt", "UA_CONFIG_MODE=component"}, hooks.env(phaseUpdate, action))
+	// the last value of a duplicated environment variable is used
+	assert.NoError(t, runScript(context.Background(), `[ "$UA_CONFIG_MODE" = component ]`, time.Minute, hooks.env(phaseUpdate, action), nil))
+}
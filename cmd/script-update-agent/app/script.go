@@ -0,0 +1,136 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package app
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/eclipse-kanto/update-manager/logger"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	progressPrefix = "PROGRESS "
+	maxErrorOutput = 1024
+)
+
+// runScript runs the given command in the system shell with the given additional environment variables. The standard output lines
+// in the format "PROGRESS <percent> [message]" are reported as progress, the last line of the standard error output is returned as error
+// message if the command fails. The command and its child processes are killed on timeout.
+func runScript(ctx context.Context, command string, timeout time.Duration, env []string, progress func(uint8, string)) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	cmd := shellCommand(command)
+	cmd.Env = append(os.Environ(), env...)
+	stdout := &lineWriter{line: func(line string) {
+		if percent, message, ok := parseProgress(line); ok {
+			progress(percent, message)
+		} else {
+			logger.Debug("[script] %s", line)
+		}
+	}}
+	stderr := &tailWriter{max: maxErrorOutput}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if err := cmd.Start(); err != nil {
+		return errors.Wrap(err, "cannot start script")
+	}
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			killProcessTree(cmd)
+		case <-done:
+		}
+	}()
+	err := cmd.Wait()
+	stdout.flush()
+	if ctx.Err() == context.DeadlineExceeded {
+		return errors.Errorf("script timed out after %s", timeout)
+	}
+	if ctx.Err() != nil {
+		return errors.Wrap(ctx.Err(), "script cancelled")
+	}
+	if err != nil {
+		if message := stderr.lastLine(); message != "" {
+			return errors.Wrap(err, message)
+		}
+		return err
+	}
+	return nil
+}
+
+// parseProgress parses a progress line in the format "PROGRESS <percent> [message]".
+func parseProgress(line string) (uint8, string, bool) {
+	if !strings.HasPrefix(line, progressPrefix) {
+		return 0, "", false
+	}
+	value, message, _ := strings.Cut(strings.TrimSpace(strings.TrimPrefix(line, progressPrefix)), " ")
+	percent, err := strconv.ParseUint(value, 10, 8)
+	if err != nil || percent > 100 {
+		return 0, "", false
+	}
+	return uint8(percent), strings.TrimSpace(message), true
+}
+
+// lineWriter calls the line function for each written line.
+type lineWriter struct {
+	buffer bytes.Buffer
+	line   func(string)
+}
+
+func (writer *lineWriter) Write(data []byte) (int, error) {
+	writer.buffer.Write(data)
+	for {
+		index := bytes.IndexByte(writer.buffer.Bytes(), '\n')
+		if index < 0 {
+			return len(data), nil
+		}
+		line := string(writer.buffer.Next(index + 1))
+		writer.line(strings.TrimRight(line, "\r\n"))
+	}
+}
+
+func (writer *lineWriter) flush() {
+	if writer.buffer.Len() > 0 {
+		writer.line(strings.TrimRight(writer.buffer.String(), "\r\n"))
+		writer.buffer.Reset()
+	}
+}
+
+// tailWriter keeps the last written bytes up to the maximum size.
+type tailWriter struct {
+	data []byte
+	max  int
+}
+
+func (writer *tailWriter) Write(data []byte) (int, error) {
+	writer.data = append(writer.data, data...)
+	if len(writer.data) > writer.max {
+		writer.data = writer.data[len(writer.data)-writer.max:]
+	}
+	return len(data), nil
+}
+
+func (writer *tailWriter) lastLine() string {
+	lines := strings.Split(strings.TrimSpace(string(writer.data)), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
@@ -0,0 +1,74 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+//go:build !windows
+// +build !windows
+
+package app
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunScript(t *testing.T) {
+	t.Run("test_progress", func(t *testing.T) {
+		var progress []uint8
+		var messages []string
+		err := runScript(context.Background(), `echo "PROGRESS 10 copying $UA_TEST"; echo other; printf "PROGRESS 100"`, time.Minute, []string{"UA_TEST=files"},
+			func(percent uint8, message string) {
+				progress = append(progress, percent)
+				messages = append(messages, message)
+			})
+		assert.NoError(t, err)
+		assert.Equal(t, []uint8{10, 100}, progress)
+		assert.Equal(t, []string{"copying files", ""}, messages)
+	})
+	t.Run("test_failure", func(t *testing.T) {
+		err := runScript(context.Background(), "echo first >&2; echo 'no space left' >&2; exit 3", time.Minute, nil, nil)
+		assert.EqualError(t, err, "no space left: exit status 3")
+	})
+	t.Run("test_timeout", func(t *testing.T) {
+		start := time.Now()
+		err := runScript(context.Background(), "sleep 10 & sleep 10; wait", 100*time.Millisecond, nil, nil)
+		assert.EqualError(t, err, "script timed out after 100ms")
+		assert.Less(t, time.Since(start), 5*time.Second)
+	})
+	t.Run("test_cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		assert.Error(t, runScript(ctx, "sleep 10", time.Minute, nil, nil))
+	})
+}
+
+func TestParseProgress(t *testing.T) {
+	for line, expected := range map[string]struct {
+		percent uint8
+		message string
+		ok      bool
+	}{
+		"PROGRESS 42":               {42, "", true},
+		"PROGRESS 7 step 2 of 3":    {7, "step 2 of 3", true},
+		"PROGRESS 101":              {0, "", false},
+		"PROGRESS abc":              {0, "", false},
+		"progress 10":               {0, "", false},
+		"downloading... PROGRESS 5": {0, "", false},
+	} {
+		percent, message, ok := parseProgress(line)
+		assert.Equal(t, expected.percent, percent, line)
+		assert.Equal(t, expected.message, message, line)
+		assert.Equal(t, expected.ok, ok, line)
+	}
+}
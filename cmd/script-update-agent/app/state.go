@@ -0,0 +1,116 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package app
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/eclipse-kanto/update-manager/api/types"
+
+	"github.com/pkg/errors"
+)
+
+// state holds the installed components of the domain.
+type state struct {
+	Components []*stateComponent `json:"components"`
+}
+
+type stateComponent struct {
+	ID      string `json:"id"`
+	Version string `json:"version"`
+}
+
+// loadState reads the state file, there are no installed components if the file does not exist.
+func loadState(file string) (*state, error) {
+	data, err := os.ReadFile(file)
+	if os.IsNotExist(err) {
+		return &state{}, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot read state file")
+	}
+	installed := &state{}
+	if err := json.Unmarshal(data, installed); err != nil {
+		return nil, errors.Wrapf(err, "cannot parse state file %s", file)
+	}
+	return installed, nil
+}
+
+// save writes the state file atomically, so that a failure does not leave a partially written state file.
+func (installed *state) save(file string) error {
+	data, err := json.MarshalIndent(installed, "", "\t")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return errors.Wrap(err, "cannot create state file directory")
+	}
+	temp := file + ".tmp"
+	if err := os.WriteFile(temp, data, 0644); err != nil {
+		return errors.Wrap(err, "cannot write state file")
+	}
+	return errors.Wrap(os.Rename(temp, file), "cannot write state file")
+}
+
+// version returns the installed version of the given component and true, or false if the component is not installed.
+func (installed *state) version(id string) (string, bool) {
+	for _, component := range installed.Components {
+		if component.ID == id {
+			return component.Version, true
+		}
+	}
+	return "", false
+}
+
+// set sets the installed version of the given component, the component is removed if the version is nil.
+func (installed *state) set(id string, version *string) {
+	components := installed.Components[:0]
+	for _, component := range installed.Components {
+		if component.ID != id {
+			components = append(components, component)
+		}
+	}
+	if version != nil {
+		components = append(components, &stateComponent{ID: id, Version: *version})
+	}
+	sort.Slice(components, func(i, j int) bool {
+		return components[i].ID < components[j].ID
+	})
+	installed.Components = components
+}
+
+// inventory returns the current state of the domain: the update agent node linked to the nodes of the installed components.
+func (installed *state) inventory(domain, version string, softwareType types.SoftwareType) *types.Inventory {
+	agentNode := &types.SoftwareNode{
+		InventoryNode: types.InventoryNode{
+			ID:         domain + "-update-agent",
+			Version:    version,
+			Name:       "Script Update Agent",
+			Parameters: []*types.KeyValuePair{{Key: "domain", Value: domain}},
+		},
+		Type: types.SoftwareTypeApplication,
+	}
+	inventory := &types.Inventory{SoftwareNodes: []*types.SoftwareNode{agentNode}}
+	for _, component := range installed.Components {
+		node := &types.SoftwareNode{
+			InventoryNode: types.InventoryNode{ID: domain + ":" + component.ID, Version: component.Version},
+			Type:          softwareType,
+		}
+		inventory.SoftwareNodes = append(inventory.SoftwareNodes, node)
+		inventory.Associations = append(inventory.Associations, &types.Association{SourceID: agentNode.ID, TargetID: node.ID})
+	}
+	return inventory
+}
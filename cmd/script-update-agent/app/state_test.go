@@ -0,0 +1,65 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/eclipse-kanto/update-manager/api/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestState(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "state", "state.json")
+	installed, err := loadState(file)
+	require.NoError(t, err)
+	assert.Empty(t, installed.Components)
+
+	version := "1.0.0"
+	installed.set("b", &version)
+	installed.set("a", &version)
+	require.NoError(t, installed.save(file))
+
+	installed, err = loadState(file)
+	require.NoError(t, err)
+	assert.Equal(t, []*stateComponent{{ID: "a", Version: "1.0.0"}, {ID: "b", Version: "1.0.0"}}, installed.Components)
+	installed.set("a", nil)
+	_, ok := installed.version("a")
+	assert.False(t, ok)
+	installedVersion, ok := installed.version("b")
+	assert.True(t, ok)
+	assert.Equal(t, "1.0.0", installedVersion)
+
+	require.NoError(t, os.WriteFile(file, []byte("invalid"), 0644))
+	_, err = loadState(file)
+	assert.Error(t, err)
+}
+
+func TestStateInventory(t *testing.T) {
+	installed := &state{Components: []*stateComponent{{ID: "firmware", Version: "2.1"}}}
+	assert.Equal(t, &types.Inventory{
+		SoftwareNodes: []*types.SoftwareNode{
+			{
+				InventoryNode: types.InventoryNode{ID: "files-update-agent", Version: "1.0.0", Name: "Script Update Agent",
+					Parameters: []*types.KeyValuePair{{Key: "domain", Value: "files"}}},
+				Type: types.SoftwareTypeApplication,
+			},
+			{InventoryNode: types.InventoryNode{ID: "files:firmware", Version: "2.1"}, Type: types.SoftwareTypeRaw},
+		},
+		Associations: []*types.Association{{SourceID: "files-update-agent", TargetID: "files:firmware"}},
+	}, installed.inventory("files", "1.0.0", types.SoftwareTypeRaw))
+}
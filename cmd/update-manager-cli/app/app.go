@@ -0,0 +1,184 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package app
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"syscall"
+	"time"
+
+	"github.com/eclipse-kanto/update-manager/logger"
+	"github.com/eclipse-kanto/update-manager/mqtt"
+)
+
+const (
+	defaultDomain  = "device"
+	defaultTimeout = 30 * time.Second
+)
+
+type options struct {
+	domain      string
+	httpAddress string
+	logLevel    string
+	timeout     time.Duration
+	mqtt        *mqtt.ConnectionConfig
+}
+
+type command struct {
+	usage       string
+	description string
+	run         func(cli *cli, args []string) error
+}
+
+var commands = map[string]*command{
+	"apply": {
+		usage:       "apply -f <file> [-activity <id>] [-watch]",
+		description: "Apply a desired state from a JSON or YAML file",
+		run:         (*cli).runApply,
+	},
+	"watch": {
+		usage:       "watch [-activity <id>]",
+		description: "Watch the desired state feedback of the update activities",
+		run:         (*cli).runWatch,
+	},
+	"inventory": {
+		usage:       "inventory [-json]",
+		description: "Print the current state inventory as a tree",
+		run:         (*cli).runInventory,
+	},
+	"consent": {
+		usage:       "consent show|approve|deny [-activity <id>]",
+		description: "Show, approve or deny the pending owner consent",
+		run:         (*cli).runConsent,
+	},
+	"command": {
+		usage:       "command download|update|activate|rollback|cleanup [-baseline <name>] [-activity <id>] [-watch]",
+		description: "Send a desired state command",
+		run:         (*cli).runCommand,
+	},
+}
+
+type cli struct {
+	out       io.Writer
+	errOut    io.Writer
+	options   *options
+	ctx       context.Context
+	newClient func(*options) updateManagerClient
+}
+
+// Run executes the command-line client with the given arguments, the results are printed to the given writer.
+func Run(args []string, out io.Writer) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	cli := &cli{
+		out:       out,
+		errOut:    os.Stderr,
+		ctx:       ctx,
+		newClient: newUpdateManagerClient,
+	}
+	return cli.run(args)
+}
+
+func newUpdateManagerClient(opts *options) updateManagerClient {
+	if opts.httpAddress != "" {
+		return newHTTPUpdateManagerClient(opts.httpAddress)
+	}
+	return newMQTTUpdateManagerClient(opts.domain, opts.mqtt)
+}
+
+func (cli *cli) run(args []string) error {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		cli.printUsage()
+		return nil
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		cli.printUsage()
+		return fmt.Errorf("unknown command '%s'", args[0])
+	}
+	return cmd.run(cli, args[1:])
+}
+
+func (cli *cli) printUsage() {
+	fmt.Fprintln(cli.out, "Usage: update-manager-cli <command> [flags]")
+	fmt.Fprintln(cli.out)
+	fmt.Fprintln(cli.out, "Commands:")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(cli.out, "  %-10s %s\n", name, commands[name].description)
+		fmt.Fprintf(cli.out, "             %s\n", commands[name].usage)
+	}
+	fmt.Fprintln(cli.out)
+	fmt.Fprintln(cli.out, "Run 'update-manager-cli <command> -h' to list the flags of a command.")
+}
+
+// newFlagSet creates a flag set for the given command with the flags for connecting to the update manager.
+func (cli *cli) newFlagSet(name string) *flag.FlagSet {
+	cli.options = &options{mqtt: mqtt.NewDefaultConfig()}
+
+	flagSet := flag.NewFlagSet(name, flag.ContinueOnError)
+	flagSet.SetOutput(cli.errOut)
+	flagSet.StringVar(&cli.options.domain, "domain", defaultDomain, "Specify the domain of the update manager, used as MQTT topic prefix")
+	flagSet.StringVar(&cli.options.httpAddress, "http-address", "", "Specify the address of the update manager local HTTP API, either a unix socket in the format 'unix:///path/to/socket' or a 'host:port' address. If set, the local HTTP API is used instead of MQTT")
+	flagSet.StringVar(&cli.options.logLevel, "log-level", "ERROR", "Set the log level - possible values are ERROR, WARN, INFO, DEBUG, TRACE")
+	flagSet.DurationVar(&cli.options.timeout, "timeout", defaultTimeout, "Specify the timeout to wait for a response from the update manager")
+
+	flagSet.StringVar(&cli.options.mqtt.Broker, "mqtt-conn-broker", cli.options.mqtt.Broker, "Address of the MQTT server/broker, the format is: scheme://host:port")
+	flagSet.StringVar(&cli.options.mqtt.Username, "mqtt-conn-username", "", "Username that is a part of the credentials")
+	flagSet.StringVar(&cli.options.mqtt.Password, "mqtt-conn-password", "", "Password that is a part of the credentials")
+	flagSet.StringVar(&cli.options.mqtt.CACert, "mqtt-conn-ca-cert", "", "Specify the PEM encoded CA certificates file")
+	flagSet.StringVar(&cli.options.mqtt.Cert, "mqtt-conn-cert", "", "Specify the PEM encoded certificate file to authenticate to the MQTT server/broker")
+	flagSet.StringVar(&cli.options.mqtt.Key, "mqtt-conn-key", "", "Specify the PEM encoded unencrypted private key file to authenticate to the MQTT server/broker")
+	return flagSet
+}
+
+// parseFlags parses the flags, which can be placed both before and after the positional arguments.
+func parseFlags(flagSet *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := flagSet.Parse(args); err != nil {
+			return nil, err
+		}
+		if flagSet.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, flagSet.Arg(0))
+		args = flagSet.Args()[1:]
+	}
+}
+
+func (cli *cli) connect() (updateManagerClient, error) {
+	if _, err := logger.SetupLogger(&logger.LogConfig{LogLevel: cli.options.logLevel}, "[update-manager-cli]"); err != nil {
+		return nil, err
+	}
+	client := cli.newClient(cli.options)
+	if err := client.Connect(); err != nil {
+		return nil, fmt.Errorf("cannot connect to the update manager: %w", err)
+	}
+	return client, nil
+}
+
+func (cli *cli) withTimeout() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(cli.ctx, cli.options.timeout)
+}
@@ -0,0 +1,50 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package app
+
+import (
+	"context"
+
+	"github.com/eclipse-kanto/update-manager/api/types"
+)
+
+// feedbackMessage holds a desired state feedback received from the update manager.
+type feedbackMessage struct {
+	ActivityID string
+	Timestamp  int64
+	Feedback   *types.DesiredStateFeedback
+}
+
+// consentMessage holds an owner consent request received from the update manager.
+type consentMessage struct {
+	ActivityID string
+	Timestamp  int64
+	Consent    *types.OwnerConsent
+}
+
+// updateManagerClient defines the operations towards a running update manager instance, independently of the used transport.
+type updateManagerClient interface {
+	Connect() error
+	Close()
+
+	SendDesiredState(activityID string, desiredState *types.DesiredState) error
+	SendDesiredStateCommand(activityID string, command *types.DesiredStateCommand) error
+	SendOwnerConsentFeedback(activityID string, feedback *types.OwnerConsentFeedback) error
+
+	// CurrentState requests the current state and waits for the response.
+	CurrentState(ctx context.Context, activityID string) (*types.Inventory, error)
+	// OwnerConsent returns the pending owner consent request or waits for the next one.
+	OwnerConsent(ctx context.Context) (*consentMessage, error)
+	// WatchFeedback calls the given function for each received desired state feedback until it returns false or the context is done.
+	WatchFeedback(ctx context.Context, handle func(*feedbackMessage) bool) error
+}
@@ -0,0 +1,206 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/eclipse-kanto/update-manager/api/types"
+)
+
+const (
+	pathDesiredState         = "/desiredstate"
+	pathDesiredStateCommand  = "/desiredstate/command"
+	pathDesiredStateFeedback = "/desiredstatefeedback"
+	pathCurrentState         = "/currentstate"
+	pathOwnerConsent         = "/ownerconsent"
+
+	unixSocketPrefix = "unix://"
+
+	defaultPollInterval = time.Second
+)
+
+type httpError struct {
+	status  int
+	message string
+}
+
+func (err *httpError) Error() string {
+	return fmt.Sprintf("%d %s: %s", err.status, http.StatusText(err.status), err.message)
+}
+
+func isNotFound(err error) bool {
+	httpErr, ok := err.(*httpError)
+	return ok && httpErr.status == http.StatusNotFound
+}
+
+type httpUpdateManagerClient struct {
+	baseURL      string
+	httpClient   *http.Client
+	pollInterval time.Duration
+}
+
+func newHTTPUpdateManagerClient(address string) *httpUpdateManagerClient {
+	client := &httpUpdateManagerClient{
+		baseURL:      address,
+		httpClient:   &http.Client{},
+		pollInterval: defaultPollInterval,
+	}
+	if strings.HasPrefix(address, unixSocketPrefix) {
+		socket := strings.TrimPrefix(address, unixSocketPrefix)
+		client.baseURL = "http://localhost"
+		client.httpClient.Transport = &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return (&net.Dialer{}).DialContext(ctx, "unix", socket)
+			},
+		}
+	} else if !strings.Contains(address, "://") {
+		client.baseURL = "http://" + address
+	}
+	client.baseURL = strings.TrimSuffix(client.baseURL, "/")
+	return client
+}
+
+// Connect does nothing, a connection to the local HTTP API is established per request.
+func (client *httpUpdateManagerClient) Connect() error {
+	return nil
+}
+
+// Close closes the idle connections to the local HTTP API.
+func (client *httpUpdateManagerClient) Close() {
+	client.httpClient.CloseIdleConnections()
+}
+
+func (client *httpUpdateManagerClient) SendDesiredState(activityID string, desiredState *types.DesiredState) error {
+	return client.post(pathDesiredState, activityID, desiredState)
+}
+
+func (client *httpUpdateManagerClient) SendDesiredStateCommand(activityID string, command *types.DesiredStateCommand) error {
+	return client.post(pathDesiredStateCommand, activityID, command)
+}
+
+func (client *httpUpdateManagerClient) SendOwnerConsentFeedback(activityID string, feedback *types.OwnerConsentFeedback) error {
+	return client.post(pathOwnerConsent, activityID, feedback)
+}
+
+func (client *httpUpdateManagerClient) CurrentState(ctx context.Context, activityID string) (*types.Inventory, error) {
+	inventory := &types.Inventory{}
+	if _, err := client.get(ctx, pathCurrentState+"?activityId="+url.QueryEscape(activityID), inventory); err != nil {
+		return nil, err
+	}
+	return inventory, nil
+}
+
+func (client *httpUpdateManagerClient) OwnerConsent(ctx context.Context) (*consentMessage, error) {
+	for {
+		consent := &types.OwnerConsent{}
+		envelope, err := client.get(ctx, pathOwnerConsent, consent)
+		if err == nil {
+			return &consentMessage{ActivityID: envelope.ActivityID, Timestamp: envelope.Timestamp, Consent: consent}, nil
+		}
+		if !isNotFound(err) {
+			return nil, err
+		}
+		if err := client.wait(ctx); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// WatchFeedback polls the last desired state feedback and calls the given function each time it is changed.
+func (client *httpUpdateManagerClient) WatchFeedback(ctx context.Context, handle func(*feedbackMessage) bool) error {
+	var last *types.Envelope
+	for {
+		feedback := &types.DesiredStateFeedback{}
+		envelope, err := client.get(ctx, pathDesiredStateFeedback, feedback)
+		if err != nil && !isNotFound(err) {
+			return err
+		}
+		if err == nil && (last == nil || last.ActivityID != envelope.ActivityID || last.Timestamp != envelope.Timestamp) {
+			last = envelope
+			if !handle(&feedbackMessage{ActivityID: envelope.ActivityID, Timestamp: envelope.Timestamp, Feedback: feedback}) {
+				return nil
+			}
+		}
+		if err := client.wait(ctx); err != nil {
+			return err
+		}
+	}
+}
+
+func (client *httpUpdateManagerClient) wait(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(client.pollInterval):
+		return nil
+	}
+}
+
+func (client *httpUpdateManagerClient) post(path string, activityID string, payload interface{}) error {
+	body, err := types.ToEnvelope(activityID, payload)
+	if err != nil {
+		return err
+	}
+	request, err := http.NewRequest(http.MethodPost, client.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	_, err = client.do(request)
+	return err
+}
+
+func (client *httpUpdateManagerClient) get(ctx context.Context, path string, payload interface{}) (*types.Envelope, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, client.baseURL+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	body, err := client.do(request)
+	if err != nil {
+		return nil, err
+	}
+	return types.FromEnvelope(body, payload)
+}
+
+func (client *httpUpdateManagerClient) do(request *http.Request) ([]byte, error) {
+	response, err := client.httpClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode >= http.StatusBadRequest {
+		result := &struct {
+			Error string `json:"error"`
+		}{}
+		if err := json.Unmarshal(body, result); err != nil || result.Error == "" {
+			result.Error = strings.TrimSpace(string(body))
+		}
+		return nil, &httpError{status: response.StatusCode, message: result.Error}
+	}
+	return body, nil
+}
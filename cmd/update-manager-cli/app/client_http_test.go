@@ -0,0 +1,130 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package app
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/eclipse-kanto/update-manager/api/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewHTTPUpdateManagerClient(t *testing.T) {
+	assert.Equal(t, "http://localhost", newHTTPUpdateManagerClient("unix:///run/um.sock").baseURL)
+	assert.Equal(t, "http://127.0.0.1:8080", newHTTPUpdateManagerClient("127.0.0.1:8080").baseURL)
+	assert.Equal(t, "https://localhost:8443", newHTTPUpdateManagerClient("https://localhost:8443/").baseURL)
+}
+
+func TestHTTPClientRequests(t *testing.T) {
+	var (
+		lock     sync.Mutex
+		received = map[string]string{}
+	)
+	mux := http.NewServeMux()
+	for _, path := range []string{pathDesiredState, pathDesiredStateCommand} {
+		mux.HandleFunc(path, func(writer http.ResponseWriter, request *http.Request) {
+			body, _ := io.ReadAll(request.Body)
+			lock.Lock()
+			received[request.URL.Path] = string(body)
+			lock.Unlock()
+			writer.WriteHeader(http.StatusAccepted)
+		})
+	}
+	mux.HandleFunc(pathOwnerConsent, func(writer http.ResponseWriter, request *http.Request) {
+		if request.Method == http.MethodPost {
+			writer.WriteHeader(http.StatusConflict)
+			fmt.Fprint(writer, `{"error":"no pending owner consent"}`)
+			return
+		}
+		fmt.Fprint(writer, `{"activityId":"test-id","timestamp":123,"payload":{"command":"UPDATE"}}`)
+	})
+	mux.HandleFunc(pathCurrentState, func(writer http.ResponseWriter, request *http.Request) {
+		fmt.Fprintf(writer, `{"activityId":"%s","payload":{"softwareNodes":[{"id":"update-manager"}]}}`, request.URL.Query().Get("activityId"))
+	})
+
+	socket := filepath.Join(t.TempDir(), "um.sock")
+	listener, err := net.Listen("unix", socket)
+	assert.NoError(t, err)
+	server := &http.Server{Handler: mux}
+	go server.Serve(listener)
+	defer server.Close()
+
+	client := newHTTPUpdateManagerClient(unixSocketPrefix + socket)
+	assert.NoError(t, client.Connect())
+	defer client.Close()
+
+	assert.NoError(t, client.SendDesiredState("test-id", &types.DesiredState{Domains: []*types.Domain{{ID: "containers"}}}))
+	assert.NoError(t, client.SendDesiredStateCommand("test-id", &types.DesiredStateCommand{Command: types.CommandUpdate}))
+	assert.Contains(t, received[pathDesiredState], `"payload":{"domains":[{"id":"containers"}]}`)
+	assert.Contains(t, received[pathDesiredStateCommand], `"payload":{"command":"UPDATE"}`)
+
+	err = client.SendOwnerConsentFeedback("test-id", &types.OwnerConsentFeedback{Status: types.StatusApproved})
+	assert.EqualError(t, err, "409 Conflict: no pending owner consent")
+
+	consent, err := client.OwnerConsent(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, &consentMessage{ActivityID: "test-id", Timestamp: 123, Consent: &types.OwnerConsent{Command: types.CommandUpdate}}, consent)
+
+	inventory, err := client.CurrentState(context.Background(), "test-id")
+	assert.NoError(t, err)
+	assert.Equal(t, "update-manager", inventory.SoftwareNodes[0].ID)
+}
+
+func TestHTTPClientWatchFeedback(t *testing.T) {
+	responses := []string{
+		"",
+		`{"activityId":"test-id","timestamp":1,"payload":{"status":"RUNNING"}}`,
+		`{"activityId":"test-id","timestamp":1,"payload":{"status":"RUNNING"}}`,
+		`{"activityId":"test-id","timestamp":2,"payload":{"status":"COMPLETED"}}`,
+	}
+	var (
+		lock  sync.Mutex
+		count int
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+		if count >= len(responses) || responses[count] == "" {
+			writer.WriteHeader(http.StatusNotFound)
+		} else {
+			fmt.Fprint(writer, responses[count])
+		}
+		count++
+	}))
+	defer server.Close()
+
+	client := newHTTPUpdateManagerClient(server.URL)
+	client.pollInterval = time.Millisecond
+
+	var statuses []types.StatusType
+	err := client.WatchFeedback(context.Background(), func(message *feedbackMessage) bool {
+		statuses = append(statuses, message.Feedback.Status)
+		return message.Feedback.Status != types.StatusCompleted
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []types.StatusType{types.StatusRunning, types.StatusCompleted}, statuses)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, client.WatchFeedback(ctx, func(*feedbackMessage) bool { return true }), context.Canceled)
+}
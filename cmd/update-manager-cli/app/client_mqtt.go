@@ -0,0 +1,164 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package app
+
+import (
+	"context"
+
+	"github.com/eclipse-kanto/update-manager/api"
+	"github.com/eclipse-kanto/update-manager/api/types"
+	"github.com/eclipse-kanto/update-manager/logger"
+	"github.com/eclipse-kanto/update-manager/mqtt"
+)
+
+const messageBufferSize = 16
+
+type mqttUpdateManagerClient struct {
+	domain string
+	config *mqtt.ConnectionConfig
+
+	consentClient api.OwnerConsentAgentClient
+	stateClient   api.DesiredStateClient
+
+	currentStates chan *types.Envelope
+	feedbacks     chan *feedbackMessage
+	consents      chan *consentMessage
+}
+
+func newMQTTUpdateManagerClient(domain string, config *mqtt.ConnectionConfig) *mqttUpdateManagerClient {
+	return &mqttUpdateManagerClient{
+		domain:        domain,
+		config:        config,
+		currentStates: make(chan *types.Envelope, messageBufferSize),
+		feedbacks:     make(chan *feedbackMessage, messageBufferSize),
+		consents:      make(chan *consentMessage, messageBufferSize),
+	}
+}
+
+// Connect connects to the MQTT broker and subscribes for the update manager outgoing messages.
+func (client *mqttUpdateManagerClient) Connect() error {
+	consentClient, err := mqtt.NewOwnerConsentAgentClient(client.domain, client.config)
+	if err != nil {
+		return err
+	}
+	if err := consentClient.Start(client); err != nil {
+		return err
+	}
+	stateClient, err := mqtt.NewDesiredStateClient(client.domain, consentClient)
+	if err == nil {
+		err = stateClient.Start(client)
+	}
+	if err != nil {
+		consentClient.Stop()
+		return err
+	}
+	client.consentClient = consentClient
+	client.stateClient = stateClient
+	return nil
+}
+
+// Close unsubscribes and disconnects from the MQTT broker.
+func (client *mqttUpdateManagerClient) Close() {
+	if client.stateClient != nil {
+		if err := client.stateClient.Stop(); err != nil {
+			logger.WarnErr(err, "error stopping desired state client")
+		}
+		client.stateClient = nil
+	}
+	if client.consentClient != nil {
+		if err := client.consentClient.Stop(); err != nil {
+			logger.WarnErr(err, "error stopping owner consent client")
+		}
+		client.consentClient = nil
+	}
+}
+
+func (client *mqttUpdateManagerClient) SendDesiredState(activityID string, desiredState *types.DesiredState) error {
+	return client.stateClient.SendDesiredState(activityID, desiredState)
+}
+
+func (client *mqttUpdateManagerClient) SendDesiredStateCommand(activityID string, command *types.DesiredStateCommand) error {
+	return client.stateClient.SendDesiredStateCommand(activityID, command)
+}
+
+func (client *mqttUpdateManagerClient) SendOwnerConsentFeedback(activityID string, feedback *types.OwnerConsentFeedback) error {
+	return client.consentClient.SendOwnerConsentFeedback(activityID, feedback)
+}
+
+func (client *mqttUpdateManagerClient) CurrentState(ctx context.Context, activityID string) (*types.Inventory, error) {
+	if err := client.stateClient.SendCurrentStateGet(activityID); err != nil {
+		return nil, err
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case envelope := <-client.currentStates:
+			if envelope.ActivityID == activityID {
+				return envelope.Payload.(*types.Inventory), nil
+			}
+		}
+	}
+}
+
+func (client *mqttUpdateManagerClient) OwnerConsent(ctx context.Context) (*consentMessage, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case consent := <-client.consents:
+		return consent, nil
+	}
+}
+
+func (client *mqttUpdateManagerClient) WatchFeedback(ctx context.Context, handle func(*feedbackMessage) bool) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case feedback := <-client.feedbacks:
+			if !handle(feedback) {
+				return nil
+			}
+		}
+	}
+}
+
+// HandleCurrentState queues the received current state.
+func (client *mqttUpdateManagerClient) HandleCurrentState(activityID string, timestamp int64, inventory *types.Inventory) error {
+	select {
+	case client.currentStates <- &types.Envelope{ActivityID: activityID, Timestamp: timestamp, Payload: inventory}:
+	default:
+		logger.Warn("dropping message for activity '%s', too many messages are not processed", activityID)
+	}
+	return nil
+}
+
+// HandleDesiredStateFeedback queues the received desired state feedback.
+func (client *mqttUpdateManagerClient) HandleDesiredStateFeedback(activityID string, timestamp int64, feedback *types.DesiredStateFeedback) error {
+	select {
+	case client.feedbacks <- &feedbackMessage{ActivityID: activityID, Timestamp: timestamp, Feedback: feedback}:
+	default:
+		logger.Warn("dropping message for activity '%s', too many messages are not processed", activityID)
+	}
+	return nil
+}
+
+// HandleOwnerConsent queues the received owner consent request.
+func (client *mqttUpdateManagerClient) HandleOwnerConsent(activityID string, timestamp int64, consent *types.OwnerConsent) error {
+	select {
+	case client.consents <- &consentMessage{ActivityID: activityID, Timestamp: timestamp, Consent: consent}:
+	default:
+		logger.Warn("dropping message for activity '%s', too many messages are not processed", activityID)
+	}
+	return nil
+}
@@ -0,0 +1,239 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/eclipse-kanto/update-manager/api/types"
+
+	"github.com/google/uuid"
+	"gopkg.in/yaml.v3"
+)
+
+func (cli *cli) runApply(args []string) error {
+	flagSet := cli.newFlagSet("apply")
+	file := flagSet.String("f", "", "Specify the JSON or YAML file with the desired state")
+	activityID := flagSet.String("activity", "", "Specify the activity ID, generated if not set")
+	watch := flagSet.Bool("watch", false, "Watch the desired state feedback until the activity is finished")
+	if _, err := parseFlags(flagSet, args); err != nil {
+		return err
+	}
+	if *file == "" {
+		return fmt.Errorf("desired state file is not specified")
+	}
+	desiredState, err := loadDesiredState(*file)
+	if err != nil {
+		return err
+	}
+	if *activityID == "" {
+		*activityID = uuid.New().String()
+	}
+
+	client, err := cli.connect()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if err := client.SendDesiredState(*activityID, desiredState); err != nil {
+		return fmt.Errorf("cannot send desired state: %w", err)
+	}
+	fmt.Fprintf(cli.out, "desired state sent, activity ID: %s\n", *activityID)
+	if *watch {
+		return cli.watchFeedback(client, *activityID)
+	}
+	return nil
+}
+
+func (cli *cli) runWatch(args []string) error {
+	flagSet := cli.newFlagSet("watch")
+	activityID := flagSet.String("activity", "", "Watch only the feedback for the given activity ID and exit when it is finished")
+	if _, err := parseFlags(flagSet, args); err != nil {
+		return err
+	}
+
+	client, err := cli.connect()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	return cli.watchFeedback(client, *activityID)
+}
+
+func (cli *cli) watchFeedback(client updateManagerClient, activityID string) error {
+	err := client.WatchFeedback(cli.ctx, func(message *feedbackMessage) bool {
+		if activityID != "" && message.ActivityID != activityID {
+			return true
+		}
+		printFeedback(cli.out, message)
+		return activityID == "" || !isFinalStatus(message.Feedback.Status)
+	})
+	if err != nil && cli.ctx.Err() != nil {
+		// interrupted by the user
+		return nil
+	}
+	return err
+}
+
+func (cli *cli) runInventory(args []string) error {
+	flagSet := cli.newFlagSet("inventory")
+	asJSON := flagSet.Bool("json", false, "Print the inventory as JSON")
+	if _, err := parseFlags(flagSet, args); err != nil {
+		return err
+	}
+
+	client, err := cli.connect()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	ctx, cancel := cli.withTimeout()
+	defer cancel()
+	inventory, err := client.CurrentState(ctx, uuid.New().String())
+	if err != nil {
+		return fmt.Errorf("cannot get current state: %w", err)
+	}
+	if *asJSON {
+		encoder := json.NewEncoder(cli.out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(inventory)
+	}
+	printInventory(cli.out, inventory)
+	return nil
+}
+
+func (cli *cli) runConsent(args []string) error {
+	flagSet := cli.newFlagSet("consent")
+	activityID := flagSet.String("activity", "", "Specify the activity ID of the owner consent, the pending owner consent is used if not set")
+	positional, err := parseFlags(flagSet, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return fmt.Errorf("expected exactly one of show, approve or deny")
+	}
+	var status types.ConsentStatusType
+	switch positional[0] {
+	case "show":
+	case "approve":
+		status = types.StatusApproved
+	case "deny":
+		status = types.StatusDenied
+	default:
+		return fmt.Errorf("unknown owner consent action '%s'", positional[0])
+	}
+
+	client, err := cli.connect()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if status == "" || *activityID == "" {
+		ctx, cancel := cli.withTimeout()
+		defer cancel()
+		consent, err := client.OwnerConsent(ctx)
+		if err != nil {
+			return fmt.Errorf("no pending owner consent: %w", err)
+		}
+		fmt.Fprintf(cli.out, "owner consent requested for %s, activity ID: %s\n", consent.Consent.Command, consent.ActivityID)
+		*activityID = consent.ActivityID
+	}
+	if status == "" {
+		return nil
+	}
+	if err := client.SendOwnerConsentFeedback(*activityID, &types.OwnerConsentFeedback{Status: status}); err != nil {
+		return fmt.Errorf("cannot send owner consent feedback: %w", err)
+	}
+	fmt.Fprintf(cli.out, "owner consent %s, activity ID: %s\n", strings.ToLower(string(status)), *activityID)
+	return nil
+}
+
+func (cli *cli) runCommand(args []string) error {
+	flagSet := cli.newFlagSet("command")
+	activityID := flagSet.String("activity", "", "Specify the activity ID of the update activity")
+	baseline := flagSet.String("baseline", "", "Specify the baseline the command is applied to, all baselines if not set")
+	watch := flagSet.Bool("watch", false, "Watch the desired state feedback until the activity is finished")
+	positional, err := parseFlags(flagSet, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return fmt.Errorf("expected exactly one command")
+	}
+	command := types.CommandType(strings.ToUpper(positional[0]))
+	switch command {
+	case types.CommandDownload, types.CommandUpdate, types.CommandActivate, types.CommandRollback, types.CommandCleanup:
+	default:
+		return fmt.Errorf("unknown command '%s'", positional[0])
+	}
+	if *activityID == "" {
+		return fmt.Errorf("activity ID is not specified")
+	}
+
+	client, err := cli.connect()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if err := client.SendDesiredStateCommand(*activityID, &types.DesiredStateCommand{Command: command, Baseline: *baseline}); err != nil {
+		return fmt.Errorf("cannot send desired state command: %w", err)
+	}
+	fmt.Fprintf(cli.out, "%s command sent, activity ID: %s\n", command, *activityID)
+	if *watch {
+		return cli.watchFeedback(client, *activityID)
+	}
+	return nil
+}
+
+// loadDesiredState reads a desired state from a JSON or YAML file, either as plain desired state or wrapped in an envelope.
+func loadDesiredState(file string) (*types.DesiredState, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	ext := strings.ToLower(filepath.Ext(file))
+	if ext == ".yaml" || ext == ".yml" {
+		var content interface{}
+		if err := yaml.Unmarshal(data, &content); err != nil {
+			return nil, fmt.Errorf("cannot parse desired state file '%s': %w", file, err)
+		}
+		if data, err = json.Marshal(content); err != nil {
+			return nil, fmt.Errorf("cannot parse desired state file '%s': %w", file, err)
+		}
+	}
+
+	content := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &content); err != nil {
+		return nil, fmt.Errorf("cannot parse desired state file '%s': %w", file, err)
+	}
+	if payload, ok := content["payload"]; ok {
+		data = payload
+	}
+	desiredState := &types.DesiredState{}
+	if err := json.Unmarshal(data, desiredState); err != nil {
+		return nil, fmt.Errorf("cannot parse desired state file '%s': %w", file, err)
+	}
+	if len(desiredState.Domains) == 0 {
+		return nil, fmt.Errorf("desired state file '%s' does not contain any domains", file)
+	}
+	return desiredState, nil
+}
@@ -0,0 +1,249 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package app
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/eclipse-kanto/update-manager/api/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testClient struct {
+	connected bool
+
+	desiredStates map[string]*types.DesiredState
+	commands      map[string]*types.DesiredStateCommand
+	consents      map[string]*types.OwnerConsentFeedback
+
+	inventory *types.Inventory
+	consent   *consentMessage
+	feedbacks []*feedbackMessage
+}
+
+func newTestClient() *testClient {
+	return &testClient{
+		desiredStates: map[string]*types.DesiredState{},
+		commands:      map[string]*types.DesiredStateCommand{},
+		consents:      map[string]*types.OwnerConsentFeedback{},
+	}
+}
+
+func (client *testClient) Connect() error {
+	client.connected = true
+	return nil
+}
+
+func (client *testClient) Close() {
+	client.connected = false
+}
+
+func (client *testClient) SendDesiredState(activityID string, desiredState *types.DesiredState) error {
+	client.desiredStates[activityID] = desiredState
+	return nil
+}
+
+func (client *testClient) SendDesiredStateCommand(activityID string, command *types.DesiredStateCommand) error {
+	client.commands[activityID] = command
+	return nil
+}
+
+func (client *testClient) SendOwnerConsentFeedback(activityID string, feedback *types.OwnerConsentFeedback) error {
+	client.consents[activityID] = feedback
+	return nil
+}
+
+func (client *testClient) CurrentState(ctx context.Context, activityID string) (*types.Inventory, error) {
+	return client.inventory, nil
+}
+
+func (client *testClient) OwnerConsent(ctx context.Context) (*consentMessage, error) {
+	if client.consent == nil {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	return client.consent, nil
+}
+
+func (client *testClient) WatchFeedback(ctx context.Context, handle func(*feedbackMessage) bool) error {
+	for _, feedback := range client.feedbacks {
+		if !handle(feedback) {
+			return nil
+		}
+	}
+	return nil
+}
+
+func newTestCLI(client *testClient) (*cli, *bytes.Buffer) {
+	out := &bytes.Buffer{}
+	return &cli{
+		out:    out,
+		errOut: io.Discard,
+		ctx:    context.Background(),
+		newClient: func(*options) updateManagerClient {
+			return client
+		},
+	}, out
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func TestRunUsage(t *testing.T) {
+	cli, out := newTestCLI(newTestClient())
+	assert.NoError(t, cli.run(nil))
+	assert.Contains(t, out.String(), "Usage: update-manager-cli")
+	assert.EqualError(t, cli.run([]string{"unknown"}), "unknown command 'unknown'")
+}
+
+func TestParseFlags(t *testing.T) {
+	cli, _ := newTestCLI(newTestClient())
+	flagSet := cli.newFlagSet("test")
+	activityID := flagSet.String("activity", "", "")
+
+	positional, err := parseFlags(flagSet, []string{"-domain", "vehicle", "approve", "-activity", "test-id"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"approve"}, positional)
+	assert.Equal(t, "test-id", *activityID)
+	assert.Equal(t, "vehicle", cli.options.domain)
+}
+
+func TestLoadDesiredState(t *testing.T) {
+	expected := &types.DesiredState{
+		Domains: []*types.Domain{
+			{
+				ID: "containers",
+				Components: []*types.ComponentWithConfig{
+					{Component: types.Component{ID: "hello-world", Version: "1.0.0"}},
+				},
+			},
+		},
+	}
+	tests := map[string]struct {
+		name    string
+		content string
+		err     bool
+	}{
+		"test_json": {
+			name:    "ds.json",
+			content: `{"domains":[{"id":"containers","components":[{"id":"hello-world","version":"1.0.0"}]}]}`,
+		},
+		"test_json_envelope": {
+			name:    "ds.json",
+			content: `{"activityId":"test-id","payload":{"domains":[{"id":"containers","components":[{"id":"hello-world","version":"1.0.0"}]}]}}`,
+		},
+		"test_yaml": {
+			name:    "ds.yaml",
+			content: "domains:\n  - id: containers\n    components:\n      - id: hello-world\n        version: 1.0.0\n",
+		},
+		"test_no_domains": {
+			name:    "ds.json",
+			content: `{"baselines":[]}`,
+			err:     true,
+		},
+		"test_invalid": {
+			name:    "ds.yml",
+			content: "domains: [",
+			err:     true,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			desiredState, err := loadDesiredState(writeFile(t, test.name, test.content))
+			if test.err {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, expected, desiredState)
+			}
+		})
+	}
+}
+
+func TestRunApply(t *testing.T) {
+	client := newTestClient()
+	client.feedbacks = []*feedbackMessage{
+		{ActivityID: "other-id", Feedback: &types.DesiredStateFeedback{Status: types.StatusRunning}},
+		{ActivityID: "test-id", Feedback: &types.DesiredStateFeedback{Status: types.StatusRunning}},
+		{ActivityID: "test-id", Feedback: &types.DesiredStateFeedback{Status: types.StatusCompleted}},
+		{ActivityID: "test-id", Feedback: &types.DesiredStateFeedback{Status: types.StatusRunning, Message: "not printed"}},
+	}
+	cli, out := newTestCLI(client)
+	file := writeFile(t, "ds.json", `{"domains":[{"id":"containers"}]}`)
+
+	assert.NoError(t, cli.run([]string{"apply", "-f", file, "-activity", "test-id", "-watch"}))
+	assert.Equal(t, "containers", client.desiredStates["test-id"].Domains[0].ID)
+	assert.False(t, client.connected)
+	assert.Contains(t, out.String(), "test-id COMPLETED")
+	assert.NotContains(t, out.String(), "other-id")
+	assert.NotContains(t, out.String(), "not printed")
+
+	assert.EqualError(t, cli.run([]string{"apply"}), "desired state file is not specified")
+}
+
+func TestRunCommand(t *testing.T) {
+	client := newTestClient()
+	cli, out := newTestCLI(client)
+
+	assert.NoError(t, cli.run([]string{"command", "download", "-activity", "test-id", "-baseline", "containers:app"}))
+	assert.Equal(t, &types.DesiredStateCommand{Command: types.CommandDownload, Baseline: "containers:app"}, client.commands["test-id"])
+	assert.Contains(t, out.String(), "DOWNLOAD command sent")
+
+	assert.EqualError(t, cli.run([]string{"command", "reboot", "-activity", "test-id"}), "unknown command 'reboot'")
+	assert.EqualError(t, cli.run([]string{"command", "update"}), "activity ID is not specified")
+	assert.Error(t, cli.run([]string{"command"}))
+}
+
+func TestRunConsent(t *testing.T) {
+	client := newTestClient()
+	client.consent = &consentMessage{ActivityID: "test-id", Consent: &types.OwnerConsent{Command: types.CommandUpdate}}
+	cli, out := newTestCLI(client)
+
+	assert.NoError(t, cli.run([]string{"consent", "show"}))
+	assert.Contains(t, out.String(), "owner consent requested for UPDATE, activity ID: test-id")
+	assert.Empty(t, client.consents)
+
+	assert.NoError(t, cli.run([]string{"consent", "approve"}))
+	assert.Equal(t, types.StatusApproved, client.consents["test-id"].Status)
+
+	assert.NoError(t, cli.run([]string{"consent", "deny", "-activity", "another-id"}))
+	assert.Equal(t, types.StatusDenied, client.consents["another-id"].Status)
+
+	client.consent = nil
+	assert.Error(t, cli.run([]string{"consent", "approve", "-timeout", "10ms"}))
+	assert.EqualError(t, cli.run([]string{"consent", "accept"}), "unknown owner consent action 'accept'")
+}
+
+func TestRunInventory(t *testing.T) {
+	client := newTestClient()
+	client.inventory = &types.Inventory{
+		SoftwareNodes: []*types.SoftwareNode{{InventoryNode: types.InventoryNode{ID: "update-manager", Version: "1.0.0"}, Type: types.SoftwareTypeApplication}},
+	}
+	cli, out := newTestCLI(client)
+
+	assert.NoError(t, cli.run([]string{"inventory"}))
+	assert.Equal(t, "update-manager 1.0.0 [APPLICATION]\n", out.String())
+
+	out.Reset()
+	assert.NoError(t, cli.run([]string{"inventory", "-json"}))
+	assert.Contains(t, out.String(), `"id": "update-manager"`)
+}
@@ -0,0 +1,144 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package app
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/eclipse-kanto/update-manager/api/types"
+)
+
+const progressBarWidth = 20
+
+// printFeedback prints the desired state feedback with a progress bar per action.
+func printFeedback(out io.Writer, message *feedbackMessage) {
+	feedback := message.Feedback
+	line := fmt.Sprintf("[%s] %s %s", formatTimestamp(message.Timestamp), message.ActivityID, feedback.Status)
+	if feedback.Baseline != "" {
+		line += fmt.Sprintf(" (baseline %s)", feedback.Baseline)
+	}
+	if feedback.Message != "" {
+		line += ": " + feedback.Message
+	}
+	fmt.Fprintln(out, line)
+
+	for _, action := range feedback.Actions {
+		component := ""
+		if action.Component != nil {
+			component = strings.TrimSpace(action.Component.ID + " " + action.Component.Version)
+		}
+		line := fmt.Sprintf("  %-40s %-20s %s", component, action.Status, progressBar(action.Progress))
+		if action.Message != "" {
+			line += " " + action.Message
+		}
+		fmt.Fprintln(out, line)
+	}
+}
+
+func progressBar(progress uint8) string {
+	if progress > 100 {
+		progress = 100
+	}
+	done := int(progress) * progressBarWidth / 100
+	return fmt.Sprintf("[%s%s] %3d%%", strings.Repeat("#", done), strings.Repeat(".", progressBarWidth-done), progress)
+}
+
+func formatTimestamp(timestamp int64) string {
+	if timestamp == 0 {
+		return time.Now().Format("15:04:05")
+	}
+	return time.UnixMilli(timestamp).Format("15:04:05")
+}
+
+// isFinalStatus returns true if no further feedback is expected for the activity.
+func isFinalStatus(status types.StatusType) bool {
+	switch status {
+	case types.StatusCompleted, types.StatusIncomplete, types.StatusIncompleteInconsistent,
+		types.StatusSuperseded, types.StatusIdentificationFailed:
+		return true
+	}
+	return false
+}
+
+// printInventory prints the inventory graph as a tree, following the associations from the root nodes.
+func printInventory(out io.Writer, inventory *types.Inventory) {
+	labels := map[string]string{}
+	var ids []string
+	for _, node := range inventory.HardwareNodes {
+		labels[node.ID] = nodeLabel(&node.InventoryNode, "HARDWARE")
+		ids = append(ids, node.ID)
+	}
+	for _, node := range inventory.SoftwareNodes {
+		labels[node.ID] = nodeLabel(&node.InventoryNode, string(node.Type))
+		ids = append(ids, node.ID)
+	}
+
+	children := map[string][]string{}
+	targets := map[string]bool{}
+	for _, association := range inventory.Associations {
+		children[association.SourceID] = append(children[association.SourceID], association.TargetID)
+		targets[association.TargetID] = true
+	}
+
+	visited := map[string]bool{}
+	for _, id := range ids {
+		if !targets[id] {
+			printNode(out, id, labels, children, visited, "", "")
+		}
+	}
+	// nodes that are part of a cycle without a root node
+	for _, id := range ids {
+		if !visited[id] {
+			printNode(out, id, labels, children, visited, "", "")
+		}
+	}
+}
+
+func printNode(out io.Writer, id string, labels map[string]string, children map[string][]string, visited map[string]bool, prefix, childPrefix string) {
+	label, ok := labels[id]
+	if !ok {
+		label = id + " (unknown node)"
+	}
+	if visited[id] {
+		fmt.Fprintf(out, "%s%s (see above)\n", prefix, id)
+		return
+	}
+	visited[id] = true
+	fmt.Fprintf(out, "%s%s\n", prefix, label)
+
+	nodeChildren := children[id]
+	for i, child := range nodeChildren {
+		if i == len(nodeChildren)-1 {
+			printNode(out, child, labels, children, visited, childPrefix+"└── ", childPrefix+"    ")
+		} else {
+			printNode(out, child, labels, children, visited, childPrefix+"├── ", childPrefix+"│   ")
+		}
+	}
+}
+
+func nodeLabel(node *types.InventoryNode, nodeType string) string {
+	label := node.ID
+	if node.Version != "" {
+		label += " " + node.Version
+	}
+	if node.Name != "" && node.Name != node.ID {
+		label += fmt.Sprintf(" \"%s\"", node.Name)
+	}
+	if nodeType != "" {
+		label += fmt.Sprintf(" [%s]", nodeType)
+	}
+	return label
+}
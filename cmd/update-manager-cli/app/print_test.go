@@ -0,0 +1,91 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package app
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/eclipse-kanto/update-manager/api/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrintInventory(t *testing.T) {
+	inventory := &types.Inventory{
+		HardwareNodes: []*types.HardwareNode{
+			{InventoryNode: types.InventoryNode{ID: "ecu", Name: "Main ECU"}},
+		},
+		SoftwareNodes: []*types.SoftwareNode{
+			{InventoryNode: types.InventoryNode{ID: "update-manager", Version: "1.0.0"}, Type: types.SoftwareTypeApplication},
+			{InventoryNode: types.InventoryNode{ID: "containers", Version: "0.1.0"}, Type: types.SoftwareTypeApplication},
+			{InventoryNode: types.InventoryNode{ID: "containers:hello-world", Version: "latest"}, Type: types.SoftwareTypeContainer},
+			{InventoryNode: types.InventoryNode{ID: "self-update", Version: "0.2.0"}, Type: types.SoftwareTypeApplication},
+		},
+		Associations: []*types.Association{
+			{SourceID: "update-manager", TargetID: "containers"},
+			{SourceID: "update-manager", TargetID: "self-update"},
+			{SourceID: "containers", TargetID: "containers:hello-world"},
+			{SourceID: "self-update", TargetID: "os"},
+		},
+	}
+	out := &bytes.Buffer{}
+	printInventory(out, inventory)
+	assert.Equal(t, `ecu "Main ECU" [HARDWARE]
+update-manager 1.0.0 [APPLICATION]
+├── containers 0.1.0 [APPLICATION]
+│   └── containers:hello-world latest [CONTAINER]
+└── self-update 0.2.0 [APPLICATION]
+    └── os (unknown node)
+`, out.String())
+}
+
+func TestPrintInventoryCycle(t *testing.T) {
+	inventory := &types.Inventory{
+		SoftwareNodes: []*types.SoftwareNode{
+			{InventoryNode: types.InventoryNode{ID: "a"}},
+			{InventoryNode: types.InventoryNode{ID: "b"}},
+		},
+		Associations: []*types.Association{
+			{SourceID: "a", TargetID: "b"},
+			{SourceID: "b", TargetID: "a"},
+		},
+	}
+	out := &bytes.Buffer{}
+	printInventory(out, inventory)
+	assert.Equal(t, "a\n└── b\n    └── a (see above)\n", out.String())
+}
+
+func TestPrintFeedback(t *testing.T) {
+	out := &bytes.Buffer{}
+	printFeedback(out, &feedbackMessage{
+		ActivityID: "test-id",
+		Feedback: &types.DesiredStateFeedback{
+			Status:  types.StatusRunning,
+			Message: "in progress",
+			Actions: []*types.Action{
+				{Component: &types.Component{ID: "containers:app", Version: "1.0"}, Status: types.ActionStatusDownloading, Progress: 50},
+			},
+		},
+	})
+	assert.Contains(t, out.String(), "test-id RUNNING: in progress\n")
+	assert.Contains(t, out.String(), "containers:app 1.0")
+	assert.Contains(t, out.String(), "DOWNLOADING")
+	assert.Contains(t, out.String(), "[##########..........]  50%")
+}
+
+func TestProgressBar(t *testing.T) {
+	assert.Equal(t, "[....................]   0%", progressBar(0))
+	assert.Equal(t, "[####################] 100%", progressBar(100))
+	assert.Equal(t, "[####################] 100%", progressBar(255))
+}
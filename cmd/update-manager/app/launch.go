@@ -51,12 +51,48 @@ func Launch(cfg *config.Config, client api.UpdateAgentClient, updateManager api.
 	var signalChan = make(chan os.Signal, 1)
 	signal.Notify(signalChan, syscall.SIGINT, syscall.SIGQUIT, syscall.SIGTERM, syscall.SIGHUP)
 
-	sig := <-signalChan
-	cancel()
-	logger.Debug("received OS SIGNAL >> %d ! Will exit!", sig)
-	stopComponent(ua)
+	configChanged := config.WatchConfigFile(ctx, util.ParseDuration("config-watch-interval", cfg.ConfigWatchInterval, 0, 0))
+	current := cfg
+	for {
+		select {
+		case sig := <-signalChan:
+			if sig == syscall.SIGHUP {
+				logger.Info("received SIGHUP, reloading configuration")
+				current = reloadConfig(cfg, current, updateManager)
+				continue
+			}
+			cancel()
+			logger.Debug("received OS SIGNAL >> %d ! Will exit!", sig)
+			stopComponent(ua)
+			return nil
+		case <-configChanged:
+			logger.Info("configuration file changed, reloading configuration")
+			current = reloadConfig(cfg, current, updateManager)
+		}
+	}
+}
 
-	return nil
+// reloadConfig loads the configuration again and applies the changes, which do not require restart. The changes, which require restart,
+// are checked against the configuration on start. The reloaded configuration is returned, or the current one if the reload fails.
+func reloadConfig(initial, current *config.Config, updateManager api.UpdateManager) *config.Config {
+	reloaded, err := config.ReloadConfig(initial)
+	if err != nil {
+		logger.ErrorErr(err, "cannot reload configuration, keeping the current one")
+		return current
+	}
+	if reloaded.Log.LogLevel != current.Log.LogLevel {
+		logger.SetLogLevel(reloaded.Log.LogLevel)
+		logger.Info("log level changed to %s", reloaded.Log.LogLevel)
+	}
+	if changed := config.RestartRequired(initial, reloaded); len(changed) > 0 {
+		logger.Warn("changes of configuration properties %v are applied on restart", changed)
+	}
+	if reloadable, ok := updateManager.(config.Reloadable); ok {
+		reloadable.ReloadConfig(reloaded)
+	} else {
+		logger.Warn("configuration reload is not supported by the update manager")
+	}
+	return reloaded
 }
 
 func startComponent(ctx context.Context, agent api.UpdateAgent) error {
@@ -13,6 +13,7 @@
 package main
 
 import (
+	"fmt"
 	"log"
 	"os"
 
@@ -21,7 +22,12 @@ import (
 	"github.com/eclipse-kanto/update-manager/config"
 	"github.com/eclipse-kanto/update-manager/logger"
 	"github.com/eclipse-kanto/update-manager/mqtt"
+	"github.com/eclipse-kanto/update-manager/mqtt/queue"
+	"github.com/eclipse-kanto/update-manager/rest"
 	"github.com/eclipse-kanto/update-manager/updatem/orchestration"
+	"github.com/eclipse-kanto/update-manager/util/jws"
+
+	"github.com/pkg/errors"
 )
 
 var (
@@ -31,8 +37,17 @@ var (
 func main() {
 	cfg, err := config.LoadConfig(version)
 	if err != nil {
+		var validationErr *config.ValidationError
+		if errors.As(err, &validationErr) {
+			fmt.Println(err)
+			os.Exit(1)
+		}
 		log.Fatal("failed to load local configuration: ", err)
 	}
+	if cfg.ValidateOnly() {
+		fmt.Println("configuration is valid")
+		return
+	}
 
 	loggerOut, err := logger.SetupLogger(cfg.Log, "[update-manager]")
 	if err != nil {
@@ -47,7 +62,7 @@ func main() {
 	}
 
 	if err != nil {
-		logger.Error("failed to init Update Manager", err, nil)
+		logger.ErrorErr(err, "failed to init Update Manager")
 		loggerOut.Close()
 		os.Exit(1)
 	}
@@ -61,10 +76,29 @@ func initUpdateManager(cfg *config.Config) (api.UpdateAgentClient, api.UpdateMan
 		err error
 	)
 
+	var (
+		opts     []mqtt.UpdateAgentClientOption
+		httpOpts []rest.UpdateAgentClientOption
+	)
+	if cfg.Signature.IsEnabled() {
+		verifier, err := jws.NewVerifier(cfg.Signature)
+		if err != nil {
+			return nil, nil, err
+		}
+		opts = append(opts, mqtt.WithSignatureVerifier(verifier))
+		httpOpts = append(httpOpts, rest.WithSignatureVerifier(verifier))
+	}
+	outboundQueue, err := queue.NewQueue(cfg.Queue)
+	if err != nil {
+		return nil, nil, err
+	}
+	if outboundQueue != nil {
+		opts = append(opts, mqtt.WithOutboundQueue(outboundQueue))
+	}
 	if cfg.ThingsEnabled {
-		uac, err = mqtt.NewUpdateAgentThingsClient(cfg.Domain, cfg.MQTT)
+		uac, err = mqtt.NewUpdateAgentThingsClient(cfg.Domain, cfg.MQTT, opts...)
 	} else {
-		uac, err = mqtt.NewUpdateAgentClient(cfg.Domain, cfg.MQTT)
+		uac, err = mqtt.NewUpdateAgentClient(cfg.Domain, cfg.MQTT, opts...)
 	}
 	if err != nil {
 		return nil, nil, err
@@ -81,6 +115,18 @@ func initUpdateManager(cfg *config.Config) (api.UpdateAgentClient, api.UpdateMan
 			}
 		}
 	}
+	if cfg.HTTP.IsEnabled() {
+		if uac, err = rest.NewUpdateAgentClient(uac, cfg.HTTP, httpOpts...); err != nil {
+			return nil, nil, err
+		}
+		if len(cfg.OwnerConsentCommands) != 0 {
+			if occ, err = rest.NewOwnerConsentClient(cfg.Domain, uac, occ); err != nil {
+				return nil, nil, err
+			}
+		}
+	} else if cfg.ArtifactCache.IsEnabled() {
+		logger.Warn("the artifact cache is not available to the domain agents, as the local HTTP API is disabled")
+	}
 	if um, err = orchestration.NewUpdateManager(version, cfg, uac, orchestration.NewUpdateOrchestrator(cfg, occ)); err != nil {
 		return nil, nil, err
 	}
@@ -13,9 +13,6 @@
 package config
 
 import (
-	"encoding/json"
-	"os"
-
 	"github.com/eclipse-kanto/update-manager/logger"
 	"github.com/eclipse-kanto/update-manager/mqtt"
 )
@@ -37,6 +34,7 @@ func DefaultDomainConfig(domain string) *BaseConfig {
 			LogFileSize:   logFileSizeDefault,
 			LogFileCount:  logFileCountDefault,
 			LogFileMaxAge: logFileMaxAgeDefault,
+			LogFormat:     logFormatDefault,
 		},
 		MQTT:          mqtt.NewDefaultConfig(),
 		Domain:        domain,
@@ -45,13 +43,7 @@ func DefaultDomainConfig(domain string) *BaseConfig {
 }
 
 // LoadConfigFromFile reads the file contents and unmarshal them into the given config structure.
+// The file is read as YAML if its extension is '.yaml' or '.yml', otherwise as JSON.
 func LoadConfigFromFile(filePath string, config interface{}) error {
-	file, err := os.ReadFile(filePath)
-	if err != nil {
-		return err
-	}
-	if err = json.Unmarshal(file, config); err != nil {
-		return err
-	}
-	return nil
+	return LoadConfigFromFiles(config, filePath)
 }
@@ -0,0 +1,320 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package config
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// envPrefix is the prefix of the environment variables, which are mapped to configuration properties
+	envPrefix = "UM_"
+	// envPathSeparator separates the JSON property names in the environment variable names
+	envPathSeparator = "__"
+
+	redactedValue = "******"
+)
+
+// secretProperties are the lower case parts of the JSON property names, which hold secret values
+var secretProperties = []string{"password", "secret", "token"}
+
+// applyEnv sets the configuration properties from the environment variables with prefix UM_, followed by the JSON path
+// of the property, where the property names are in upper snake case and separated by double underscores,
+// e.g. UM_CONNECTION__MAX_RECONNECT_INTERVAL or UM_AGENTS__SELF_UPDATE__READ_TIMEOUT. The used environment variables are reported
+// to the given output, with the values of the secret properties redacted.
+func applyEnv(config interface{}, environ []string, output io.Writer) {
+	for _, env := range environ {
+		key, value, ok := strings.Cut(env, "=")
+		if !ok || !strings.HasPrefix(key, envPrefix) {
+			continue
+		}
+		path := strings.Split(strings.TrimPrefix(key, envPrefix), envPathSeparator)
+		if err := setProperty(reflect.ValueOf(config), path, value); err != nil {
+			fmt.Fprintf(output, "cannot use ENV variable %s: %v\n", key, err)
+			continue
+		}
+		if isSecretProperty(path[len(path)-1]) {
+			value = redactedValue
+		}
+		fmt.Fprintf(output, "using ENV variable %s with value %s\n", key, value)
+	}
+}
+
+// applyLegacyEnv sets the configuration properties from the deprecated environment variables without prefix, which are named
+// after the respective flags, e.g. LOG_LEVEL for the log-level flag. They are applied before the UM_ prefixed environment variables,
+// so that the latter take precedence. The used deprecated environment variables are reported to the given output.
+func applyLegacyEnv(cfg *Config, environ []string, output io.Writer) {
+	flagSet := flag.NewFlagSet("", flag.ContinueOnError)
+	// adding the flags sets their default values, i.e. the values of the deprecated environment variables, to the configuration
+	setupUpdateManagerFlags(flagSet, cfg, legacyEnv(environ, output, nil))
+}
+
+// applyLegacyAgentsEnv sets the domain agent properties from the deprecated environment variables without prefix, e.g.
+// CONTAINERS_READ_TIMEOUT. As the domain agents are known only after the UM_ prefixed environment variables are applied, the deprecated
+// environment variables of the properties, which are set with UM_ prefixed environment variables, are not applied.
+func applyLegacyAgentsEnv(cfg *Config, environ []string, output io.Writer) {
+	for name, agent := range cfg.Agents {
+		if agent == nil {
+			continue
+		}
+		prefix := strings.ReplaceAll(strings.ToUpper(name), "-", "_") + "_"
+		env := legacyEnv(environ, output, func(key string) bool {
+			return isEnvSet(environ, "agents", name, strings.TrimPrefix(key, prefix))
+		})
+		agent.RebootRequired = env.toBool(prefix+"REBOOT_REQUIRED", agent.RebootRequired)
+		agent.ReadTimeout = env.toString(prefix+"READ_TIMEOUT", agent.ReadTimeout)
+	}
+}
+
+// legacyEnv returns the lookup of the deprecated environment variables without prefix, which reports their use as deprecated
+// to the given output. The environment variables, for which the given skip function returns true, are not looked up.
+func legacyEnv(environ []string, output io.Writer, skip func(key string) bool) flagEnv {
+	return flagEnv{
+		lookup: func(key string) (string, bool) {
+			if skip != nil && skip(key) {
+				return "", false
+			}
+			for _, env := range environ {
+				if name, value, ok := strings.Cut(env, "="); ok && name == key {
+					fmt.Fprintf(output, "ENV variable %s is deprecated, use the %s prefixed ENV variable of the configuration property instead\n", key, envPrefix)
+					return value, true
+				}
+			}
+			return "", false
+		},
+		output: output,
+	}
+}
+
+// isEnvSet returns if the property with the given JSON path is set with an UM_ prefixed environment variable.
+func isEnvSet(environ []string, path ...string) bool {
+	for _, env := range environ {
+		key, _, ok := strings.Cut(env, "=")
+		if !ok || !strings.HasPrefix(key, envPrefix) {
+			continue
+		}
+		envPath := strings.Split(strings.TrimPrefix(key, envPrefix), envPathSeparator)
+		if len(envPath) != len(path) {
+			continue
+		}
+		matches := true
+		for i := range path {
+			matches = matches && normalizeName(envPath[i]) == normalizeName(path[i])
+		}
+		if matches {
+			return true
+		}
+	}
+	return false
+}
+
+func setProperty(value reflect.Value, path []string, str string) error {
+	for value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			value.Set(reflect.New(value.Type().Elem()))
+		}
+		value = value.Elem()
+	}
+	if len(path) == 0 {
+		return setValue(value, str)
+	}
+	switch value.Kind() {
+	case reflect.Struct:
+		field, ok := findField(value, path[0])
+		if !ok {
+			return errors.Errorf("unknown configuration property %s", path[0])
+		}
+		return setProperty(field, path[1:], str)
+	case reflect.Map:
+		if value.Type().Key().Kind() != reflect.String {
+			return errors.Errorf("unsupported configuration property %s", path[0])
+		}
+		if value.IsNil() {
+			value.Set(reflect.MakeMap(value.Type()))
+		}
+		key := findMapKey(value, path[0])
+		// the map elements are not addressable, so a copy is set and stored back
+		elem := reflect.New(value.Type().Elem()).Elem()
+		if existing := value.MapIndex(key); existing.IsValid() {
+			elem.Set(existing)
+		}
+		if err := setProperty(elem, path[1:], str); err != nil {
+			return err
+		}
+		value.SetMapIndex(key, elem)
+		return nil
+	default:
+		return errors.Errorf("unknown configuration property %s", path[0])
+	}
+}
+
+func setValue(value reflect.Value, str string) error {
+	switch value.Kind() {
+	case reflect.String:
+		value.SetString(str)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(str)
+		if err != nil {
+			return err
+		}
+		value.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(str, 10, value.Type().Bits())
+		if err != nil {
+			return err
+		}
+		value.SetInt(i)
+	case reflect.Slice:
+		if value.Type().Elem().Kind() == reflect.String && !strings.HasPrefix(strings.TrimSpace(str), "[") {
+			// comma-separated list of values
+			return setStrings(value, str)
+		}
+		return json.Unmarshal([]byte(str), value.Addr().Interface())
+	case reflect.Map:
+		if value.Type().Elem().Kind() == reflect.String && !strings.HasPrefix(strings.TrimSpace(str), "{") {
+			// comma-separated list of key=value pairs
+			return setKeyValues(value, str)
+		}
+		return json.Unmarshal([]byte(str), value.Addr().Interface())
+	default:
+		return json.Unmarshal([]byte(str), value.Addr().Interface())
+	}
+	return nil
+}
+
+func setStrings(value reflect.Value, str string) error {
+	values := reflect.MakeSlice(value.Type(), 0, 0)
+	for _, item := range strings.Split(str, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			values = reflect.Append(values, reflect.ValueOf(item).Convert(value.Type().Elem()))
+		}
+	}
+	value.Set(values)
+	return nil
+}
+
+func setKeyValues(value reflect.Value, str string) error {
+	values := reflect.MakeMap(value.Type())
+	for _, pair := range strings.Split(str, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		key, val, ok := strings.Cut(pair, "=")
+		if !ok {
+			return errors.Errorf("invalid key=value pair '%s'", pair)
+		}
+		values.SetMapIndex(reflect.ValueOf(strings.TrimSpace(key)).Convert(value.Type().Key()),
+			reflect.ValueOf(strings.TrimSpace(val)).Convert(value.Type().Elem()))
+	}
+	value.Set(values)
+	return nil
+}
+
+// findField returns the struct field with the given JSON name, also searching in the embedded structs.
+func findField(value reflect.Value, name string) (reflect.Value, bool) {
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		jsonName, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if jsonName == "-" {
+			continue
+		}
+		if jsonName == "" && field.Anonymous {
+			embedded := value.Field(i)
+			if embedded.Kind() == reflect.Ptr {
+				if embedded.IsNil() {
+					embedded.Set(reflect.New(embedded.Type().Elem()))
+				}
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				if found, ok := findField(embedded, name); ok {
+					return found, true
+				}
+			}
+			continue
+		}
+		if jsonName == "" {
+			jsonName = field.Name
+		}
+		if normalizeName(jsonName) == normalizeName(name) {
+			return value.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// findMapKey returns the existing map key, which matches the given name, or a new lower case key with dashes instead of underscores.
+func findMapKey(value reflect.Value, name string) reflect.Value {
+	for _, key := range value.MapKeys() {
+		if normalizeName(key.String()) == normalizeName(name) {
+			return key
+		}
+	}
+	return reflect.ValueOf(strings.ReplaceAll(strings.ToLower(name), "_", "-")).Convert(value.Type().Key())
+}
+
+func normalizeName(name string) string {
+	return strings.NewReplacer("_", "", "-", "").Replace(strings.ToLower(name))
+}
+
+func isSecretProperty(name string) bool {
+	name = normalizeName(name)
+	for _, secret := range secretProperties {
+		if strings.Contains(name, secret) {
+			return true
+		}
+	}
+	return false
+}
+
+// Redacted returns the JSON representation of the given configuration, where the values of the secret properties, e.g. passwords, are redacted.
+func Redacted(config interface{}) ([]byte, error) {
+	data, err := json.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+	var content interface{}
+	if err = json.Unmarshal(data, &content); err != nil {
+		return nil, err
+	}
+	redactSecrets(content)
+	return json.MarshalIndent(content, "", "  ")
+}
+
+func redactSecrets(content interface{}) {
+	switch value := content.(type) {
+	case map[string]interface{}:
+		for key, item := range value {
+			if str, ok := item.(string); ok && str != "" && isSecretProperty(key) {
+				value[key] = redactedValue
+			} else {
+				redactSecrets(item)
+			}
+		}
+	case []interface{}:
+		for _, item := range value {
+			redactSecrets(item)
+		}
+	}
+}
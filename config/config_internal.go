@@ -13,8 +13,19 @@
 package config
 
 import (
+	"io"
+	"os"
+
 	"github.com/eclipse-kanto/update-manager/api"
 	"github.com/eclipse-kanto/update-manager/api/types"
+	"github.com/eclipse-kanto/update-manager/logger"
+	"github.com/eclipse-kanto/update-manager/mqtt/queue"
+	"github.com/eclipse-kanto/update-manager/rest"
+	"github.com/eclipse-kanto/update-manager/updatem/cache"
+	"github.com/eclipse-kanto/update-manager/updatem/history"
+	"github.com/eclipse-kanto/update-manager/updatem/replay"
+	"github.com/eclipse-kanto/update-manager/util/jws"
+	"github.com/eclipse-kanto/update-manager/util/secret"
 )
 
 const (
@@ -24,6 +35,7 @@ const (
 	logFileSizeDefault   = 2
 	logFileCountDefault  = 5
 	logFileMaxAgeDefault = 28
+	logFormatDefault     = logger.LogFormatText
 
 	domainDefault                 = "device"
 	rebootEnabledDefault          = true
@@ -46,8 +58,23 @@ type Config struct {
 	ReportFeedbackInterval string                              `json:"reportFeedbackInterval"`
 	CurrentStateDelay      string                              `json:"currentStateDelay"`
 	PhaseTimeout           string                              `json:"phaseTimeout"`
+	PhaseTimeoutPause      bool                                `json:"phaseTimeoutPause"`
 	OwnerConsentCommands   []types.CommandType                 `json:"ownerConsentCommands"`
 	OwnerConsentTimeout    string                              `json:"ownerConsentTimeout"`
+	HTTP                   *rest.ServerConfig                  `json:"http,omitempty"`
+	History                *history.Config                     `json:"history,omitempty"`
+	Signature              *jws.Config                         `json:"signature,omitempty"`
+	Secrets                *secret.Config                      `json:"secrets,omitempty"`
+	Replay                 *replay.Config                      `json:"replay,omitempty"`
+	Queue                  *queue.Config                       `json:"queue,omitempty"`
+	ArtifactCache          *cache.Config                       `json:"artifactCache,omitempty"`
+	// NamespaceNodeIDs enables prefixing the inventory node IDs of each domain with the domain name in the aggregated inventory
+	NamespaceNodeIDs bool `json:"namespaceNodeIds"`
+	// ConfigWatchInterval is the interval for checking the config file for changes, the config file is reloaded only on SIGHUP if not set
+	ConfigWatchInterval string `json:"configWatchInterval,omitempty"`
+
+	// startup holds the config sources and the flag values parsed on start, which are used on reload
+	startup *startupValues
 }
 
 func newDefaultConfig() *Config {
@@ -60,6 +87,13 @@ func newDefaultConfig() *Config {
 		CurrentStateDelay:      currentStateDelayDefault,
 		PhaseTimeout:           phaseTimeoutDefault,
 		OwnerConsentTimeout:    ownerConsentTimeoutDefault,
+		HTTP:                   rest.NewDefaultConfig(),
+		History:                history.NewDefaultConfig(),
+		Signature:              jws.NewDefaultConfig(),
+		Secrets:                secret.NewDefaultConfig(),
+		Replay:                 replay.NewDefaultConfig(),
+		Queue:                  queue.NewDefaultConfig(),
+		ArtifactCache:          cache.NewDefaultConfig(),
 	}
 }
 
@@ -79,16 +113,68 @@ func addAgentConfig(config map[string]*api.UpdateManagerConfig, name string) {
 	}
 }
 
-// LoadConfig loads a new configuration instance using flags and config file (if set).
+// LoadConfig loads a new configuration instance using flags, environment variables, config file and drop-in config files (if set).
+// The config sources are applied in the following order, each overriding the previous ones: config file, drop-in config files
+// in alphabetical order, deprecated environment variables without prefix, UM_ prefixed environment variables, flags.
+// If the configuration is invalid, a ValidationError with all found problems is returned.
 func LoadConfig(version string) (*Config, error) {
-	configFilePath := ParseConfigFilePath()
+	startup := &startupValues{configFilePath: ParseConfigFilePath()}
+	startup.configDir = parseConfigDir(startup.configFilePath)
+	startup.domains = parseDomainsFlag()
+	v := &validator{}
+	config, err := loadSources(startup, v, os.Stdout)
+	if err != nil {
+		return nil, err
+	}
+	sources, err := toJSONObject(config)
+	if err != nil {
+		return nil, err
+	}
+	startup.validateOnly = parseConfigFlags(config, version)
+	v.validateConfig(config)
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+	effective, err := toJSONObject(config)
+	if err != nil {
+		return nil, err
+	}
+	startup.flagValues = flagValues(nil, sources, effective)
+	config.startup = startup
+	return config, nil
+}
+
+// ValidateOnly returns if the configuration shall be only validated without starting the update manager, i.e. if the
+// validate-config flag is set.
+func (config *Config) ValidateOnly() bool {
+	return config.startup != nil && config.startup.validateOnly
+}
+
+// loadSources loads the configuration from the config file, the drop-in config files and the environment variables
+// and prepares the agents configuration for the domains, all as on start. The problems with the config files are added to the given
+// validator and the used environment variables are reported to the given output.
+func loadSources(startup *startupValues, v *validator, envOutput io.Writer) (*Config, error) {
 	config := newDefaultConfig()
-	if configFilePath != "" {
-		if err := LoadConfigFromFile(configFilePath, config); err != nil {
+	if files := configFiles(startup.configFilePath, startup.configDir); len(files) > 0 {
+		unknown, err := loadConfigFiles(config, files)
+		if err != nil {
 			return nil, err
 		}
+		v.problems = append(v.problems, unknown...)
 	}
-	parseFlags(config, version)
+	environ := os.Environ()
+	applyLegacyEnv(config, environ, envOutput)
+	applyEnv(config, environ, envOutput)
+	var domains map[string]bool
+	if startup.domains != nil {
+		// the domains are removed from the map, when their agent configuration is prepared
+		domains = make(map[string]bool, len(startup.domains))
+		for domain := range startup.domains {
+			domains[domain] = true
+		}
+	}
+	prepareAgentsConfig(config, domains)
+	applyLegacyAgentsEnv(config, environ, envOutput)
 	return config, nil
 }
 
@@ -97,7 +183,10 @@ func prepareAgentsConfig(cfg *Config, domains map[string]bool) {
 		cfg.Agents = newDefaultAgentsConfig()
 	} else {
 		for name, agent := range cfg.Agents {
-			agent.Name = name
+			// the domains without agent configuration are reported by the validation
+			if agent != nil {
+				agent.Name = name
+			}
 		}
 	}
 	if len(domains) > 0 {
@@ -0,0 +1,256 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/eclipse-kanto/update-manager/logger"
+
+	"github.com/pkg/errors"
+)
+
+// Reloadable is implemented by the components, which apply a reloaded configuration without restart.
+type Reloadable interface {
+	ReloadConfig(cfg *Config)
+}
+
+// startupValues holds the config sources and the values of the configuration properties set with flags on start,
+// so that the configuration can be reloaded without parsing the flags again.
+type startupValues struct {
+	configFilePath string
+	configDir      string
+	domains        map[string]bool
+	flagValues     []*flagValue
+	validateOnly   bool
+}
+
+// flagValue is a configuration property, which value is set with a flag on start.
+type flagValue struct {
+	path  []string
+	value string
+}
+
+// ReloadConfig loads the configuration again from the same config file, drop-in config files and environment variables as
+// the given configuration loaded on start and applies the values set with flags on start over them. Unlike on start, the flags
+// are not parsed and the used environment variables are not reported again.
+func ReloadConfig(initial *Config) (*Config, error) {
+	startup := initial.startup
+	if startup == nil {
+		return nil, errors.New("configuration is not loaded from the config sources")
+	}
+	v := &validator{}
+	config, err := loadSources(startup, v, io.Discard)
+	if err != nil {
+		return nil, err
+	}
+	for _, flagValue := range startup.flagValues {
+		if err := setProperty(reflect.ValueOf(config), flagValue.path, flagValue.value); err != nil {
+			return nil, errors.Wrapf(err, "cannot set flag value of configuration property %s", strings.Join(flagValue.path, "."))
+		}
+	}
+	v.validateConfig(config)
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+	config.startup = startup
+	return config, nil
+}
+
+// toJSONObject returns the JSON representation of the given configuration as generic JSON object.
+func toJSONObject(config *Config) (map[string]interface{}, error) {
+	data, err := json.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+	content := map[string]interface{}{}
+	return content, json.Unmarshal(data, &content)
+}
+
+// flagValues returns the properties, which differ in the given JSON representations of the configuration before and after
+// the flags are parsed, with their values after the flags are parsed.
+func flagValues(path []string, before, after map[string]interface{}) []*flagValue {
+	var values []*flagValue
+	for _, key := range sortedKeys(before, after) {
+		propertyPath := append(append([]string{}, path...), key)
+		beforeValue, beforeOk := before[key]
+		afterValue, afterOk := after[key]
+		beforeObject, beforeIsObject := beforeValue.(map[string]interface{})
+		afterObject, afterIsObject := afterValue.(map[string]interface{})
+		switch {
+		case beforeIsObject && afterIsObject:
+			values = append(values, flagValues(propertyPath, beforeObject, afterObject)...)
+		case !afterOk:
+			// the properties with zero value are omitted
+			if beforeValue != nil {
+				values = append(values, &flagValue{path: propertyPath, value: zeroJSONValue(beforeValue)})
+			}
+		case afterValue != nil && (!beforeOk || !reflect.DeepEqual(beforeValue, afterValue)):
+			values = append(values, &flagValue{path: propertyPath, value: jsonValue(afterValue)})
+		}
+	}
+	return values
+}
+
+func sortedKeys(objects ...map[string]interface{}) []string {
+	var keys []string
+	seen := map[string]bool{}
+	for _, object := range objects {
+		for key := range object {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// jsonValue returns the given JSON value in the format of the environment variables, i.e. strings as they are and all other values as JSON.
+func jsonValue(value interface{}) string {
+	if str, ok := value.(string); ok {
+		return str
+	}
+	data, _ := json.Marshal(value)
+	return string(data)
+}
+
+func zeroJSONValue(value interface{}) string {
+	switch value.(type) {
+	case string:
+		return ""
+	case bool:
+		return "false"
+	case float64:
+		return "0"
+	case []interface{}:
+		return "[]"
+	default:
+		return "{}"
+	}
+}
+
+// RestartRequired returns the JSON names of the configuration properties, which differ in the given configurations,
+// but cannot be applied without restart.
+func RestartRequired(current, reloaded *Config) []string {
+	var changed []string
+	check := func(name string, currentValue, reloadedValue interface{}) {
+		if !reflect.DeepEqual(currentValue, reloadedValue) {
+			changed = append(changed, name)
+		}
+	}
+	currentLog, reloadedLog := *current.Log, *reloaded.Log
+	currentLog.LogLevel, reloadedLog.LogLevel = "", ""
+	check("log", currentLog, reloadedLog)
+	check("connection", current.MQTT, reloaded.MQTT)
+	check("domain", current.Domain, reloaded.Domain)
+	check("thingsEnabled", current.ThingsEnabled, reloaded.ThingsEnabled)
+	check("phaseTimeoutPause", current.PhaseTimeoutPause, reloaded.PhaseTimeoutPause)
+	if len(current.OwnerConsentCommands) == 0 {
+		// the owner consent client is not started if no commands are configured on start
+		check("ownerConsentCommands", current.OwnerConsentCommands, reloaded.OwnerConsentCommands)
+	}
+	check("http", current.HTTP, reloaded.HTTP)
+	check("history", current.History, reloaded.History)
+	check("signature", current.Signature, reloaded.Signature)
+	check("secrets", current.Secrets, reloaded.Secrets)
+	if current.Replay != nil && reloaded.Replay != nil {
+		// the anti-rollback is checked on each desired state
+		currentReplay, reloadedReplay := *current.Replay, *reloaded.Replay
+		currentReplay.AntiRollback, reloadedReplay.AntiRollback = false, false
+		check("replay", currentReplay, reloadedReplay)
+	} else {
+		check("replay", current.Replay, reloaded.Replay)
+	}
+	check("queue", current.Queue, reloaded.Queue)
+	check("artifactCache", current.ArtifactCache, reloaded.ArtifactCache)
+	check("configWatchInterval", current.ConfigWatchInterval, reloaded.ConfigWatchInterval)
+	return changed
+}
+
+// WatchConfigFile checks the config file and the drop-in config files for changes with the given interval until the context is done.
+// A value is sent to the returned channel on each change. If the interval is not positive or there are no config files,
+// nothing is watched and the returned channel is nil.
+func WatchConfigFile(ctx context.Context, interval time.Duration) <-chan struct{} {
+	configFilePath := ParseConfigFilePath()
+	configDir := parseConfigDir(configFilePath)
+	if interval <= 0 || (configFilePath == "" && !isDir(configDir)) {
+		return nil
+	}
+	changed := make(chan struct{}, 1)
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		modTime := configModTime(configFilePath, configDir)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			if current := configModTime(configFilePath, configDir); !current.Equal(modTime) {
+				logger.Debug("configuration files changed")
+				modTime = current
+				select {
+				case changed <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}()
+	return changed
+}
+
+// configModTime returns the latest modification time of the config file, the drop-in directory and the drop-in config files.
+// The modification time of the directory changes if a drop-in config file is added or removed.
+func configModTime(configFilePath, configDir string) time.Time {
+	var modTime time.Time
+	if configFilePath != "" {
+		modTime = fileModTime(configFilePath)
+	}
+	if !isDir(configDir) {
+		return modTime
+	}
+	for _, file := range append(configFiles("", configDir), configDir) {
+		if current := fileModTime(file); current.After(modTime) {
+			modTime = current
+		}
+	}
+	return modTime
+}
+
+func fileModTime(file string) time.Time {
+	info, err := os.Stat(file)
+	if err != nil {
+		logger.WarnErr(err, "cannot check the config file '%s' for changes", file)
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+func isDir(path string) bool {
+	if path == "" {
+		return false
+	}
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
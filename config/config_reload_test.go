@@ -0,0 +1,121 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package config
+
+import (
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/eclipse-kanto/update-manager/api/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReloadConfig(t *testing.T) {
+	oldArgs, oldCommandLine := os.Args, flag.CommandLine
+	defer func() {
+		os.Args = oldArgs
+		flag.CommandLine = oldCommandLine
+	}()
+
+	configFile := filepath.Join(t.TempDir(), "config.json")
+	assert.NoError(t, os.WriteFile(configFile, []byte(`{"log":{"logLevel":"DEBUG"},"phaseTimeout":"5m","rebootAfter":"1m"}`), 0600))
+	os.Args = []string{oldArgs[0], "--config-file", configFile, "--reboot-enabled=false", "--things-enabled=false", "--phase-timeout=7m",
+		"--domains=containers,self-update"}
+	initial, err := LoadConfig("")
+	assert.NoError(t, err)
+	assert.Equal(t, "7m", initial.PhaseTimeout)
+
+	_, err = ReloadConfig(newDefaultConfig())
+	assert.Error(t, err)
+
+	// the flags are not parsed again on reload
+	os.Args = []string{oldArgs[0]}
+	commandLine := flag.CommandLine
+	assert.NoError(t, os.WriteFile(configFile, []byte(`{"log":{"logLevel":"TRACE"},"phaseTimeout":"9m","rebootAfter":"2m"}`), 0600))
+	cfg, err := ReloadConfig(initial)
+	assert.NoError(t, err)
+	assert.Equal(t, commandLine, flag.CommandLine)
+	assert.Equal(t, "TRACE", cfg.Log.LogLevel)
+	assert.Equal(t, "2m", cfg.RebootAfter)
+	assert.Equal(t, "7m", cfg.PhaseTimeout)
+	assert.False(t, cfg.RebootEnabled)
+	assert.False(t, cfg.ThingsEnabled)
+	assert.Equal(t, initial.Agents, cfg.Agents)
+	assert.Nil(t, RestartRequired(initial, cfg))
+
+	assert.NoError(t, os.WriteFile(configFile, []byte(`{"log":`), 0600))
+	_, err = ReloadConfig(initial)
+	assert.Error(t, err)
+
+	assert.NoError(t, os.WriteFile(configFile, []byte(`{"phaseTimeout":"invalid","rebootAfter":"invalid"}`), 0600))
+	_, err = ReloadConfig(initial)
+	assert.Error(t, err)
+}
+
+func TestRestartRequired(t *testing.T) {
+	current := newDefaultConfig()
+
+	reloaded := newDefaultConfig()
+	reloaded.Log.LogLevel = "TRACE"
+	reloaded.PhaseTimeout = "1m"
+	reloaded.RebootEnabled = false
+	reloaded.Replay.AntiRollback = true
+	reloaded.Agents = newDefaultAgentsConfig()
+	assert.Nil(t, RestartRequired(current, reloaded))
+
+	reloaded.Log.LogFile = "update-manager.log"
+	reloaded.MQTT.Broker = "tcp://fallback:1883"
+	reloaded.OwnerConsentCommands = []types.CommandType{types.CommandDownload}
+	reloaded.Replay.Enabled = true
+	reloaded.ConfigWatchInterval = "1m"
+	assert.Equal(t, []string{"log", "connection", "ownerConsentCommands", "replay", "configWatchInterval"}, RestartRequired(current, reloaded))
+
+	current.OwnerConsentCommands = []types.CommandType{types.CommandUpdate}
+	assert.NotContains(t, RestartRequired(current, reloaded), "ownerConsentCommands")
+}
+
+func TestWatchConfigFile(t *testing.T) {
+	oldArgs := os.Args
+	defer func() { os.Args = oldArgs }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	os.Args = []string{oldArgs[0]}
+	assert.Nil(t, WatchConfigFile(ctx, time.Millisecond))
+
+	configFile := filepath.Join(t.TempDir(), "config.json")
+	assert.NoError(t, os.WriteFile(configFile, []byte(`{}`), 0600))
+	os.Args = []string{oldArgs[0], "--config-file=" + configFile}
+	assert.Nil(t, WatchConfigFile(ctx, 0))
+
+	changed := WatchConfigFile(ctx, 10*time.Millisecond)
+	assert.NotNil(t, changed)
+	select {
+	case <-changed:
+		t.Fatal("unexpected change of the config file")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	assert.NoError(t, os.Chtimes(configFile, time.Now(), time.Now().Add(time.Minute)))
+	select {
+	case <-changed:
+	case <-time.After(time.Second):
+		t.Fatal("change of the config file not detected")
+	}
+}
@@ -0,0 +1,147 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package config
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/eclipse-kanto/update-manager/logger"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	configDirFlagID  = "config-dir"
+	configDirDefault = "config.d"
+)
+
+// LoadConfigFromFiles reads the given JSON or YAML files and unmarshals their merged contents into the given config structure.
+// The files are merged in the given order, the objects are merged recursively and all other values, including arrays, are replaced.
+func LoadConfigFromFiles(config interface{}, filePaths ...string) error {
+	_, err := loadConfigFiles(config, filePaths)
+	return err
+}
+
+// loadConfigFiles reads and merges the given config files into the given config structure and returns the problems
+// for the properties in the config files, which are not defined in the config structure.
+func loadConfigFiles(config interface{}, filePaths []string) ([]string, error) {
+	var unknown []string
+	merged := map[string]interface{}{}
+	for _, filePath := range filePaths {
+		content, err := readConfigFile(filePath)
+		if err != nil {
+			return nil, err
+		}
+		if config != nil {
+			for _, property := range unknownProperties(content, reflect.TypeOf(config), "") {
+				unknown = append(unknown, fmt.Sprintf("%s: unknown property in config file %s", property, filePath))
+			}
+		}
+		mergeConfig(merged, content)
+	}
+	data, err := json.Marshal(merged)
+	if err != nil {
+		return nil, err
+	}
+	return unknown, json.Unmarshal(data, config)
+}
+
+// readConfigFile reads a JSON or YAML config file, depending on its extension, as generic JSON object.
+func readConfigFile(filePath string) (map[string]interface{}, error) {
+	file, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	content := map[string]interface{}{}
+	if isYAML(filePath) {
+		if err = yaml.Unmarshal(file, &content); err != nil {
+			return nil, errors.Wrapf(err, "cannot parse config file %s", filePath)
+		}
+		// the YAML objects are unmarshalled as maps with string keys, so that they can be used as JSON objects
+		if _, err = json.Marshal(content); err != nil {
+			return nil, errors.Wrapf(err, "cannot parse config file %s", filePath)
+		}
+		return content, nil
+	}
+	if err = json.Unmarshal(file, &content); err != nil {
+		return nil, err
+	}
+	return content, nil
+}
+
+func mergeConfig(target, source map[string]interface{}) {
+	for key, value := range source {
+		sourceObject, sourceIsObject := value.(map[string]interface{})
+		targetObject, targetIsObject := target[key].(map[string]interface{})
+		if sourceIsObject && targetIsObject {
+			mergeConfig(targetObject, sourceObject)
+		} else {
+			target[key] = value
+		}
+	}
+}
+
+// configFiles returns the config file, if set, followed by the JSON and YAML files from the drop-in directory, ordered by name.
+func configFiles(configFilePath, configDir string) []string {
+	var files []string
+	if configFilePath != "" {
+		files = append(files, configFilePath)
+	}
+	if configDir == "" {
+		return files
+	}
+	entries, err := os.ReadDir(configDir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logger.WarnErr(err, "cannot read the config directory %s", configDir)
+		}
+		return files
+	}
+	var dropIns []string
+	for _, entry := range entries {
+		if entry.Type().IsRegular() && (isYAML(entry.Name()) || strings.EqualFold(filepath.Ext(entry.Name()), ".json")) {
+			dropIns = append(dropIns, filepath.Join(configDir, entry.Name()))
+		}
+	}
+	sort.Strings(dropIns)
+	return append(files, dropIns...)
+}
+
+// parseConfigDir returns the value for the drop-in config directory if set, otherwise the config.d directory next to the config file.
+func parseConfigDir(configFilePath string) string {
+	var configDir string
+	flagSet := flag.NewFlagSet("", flag.ContinueOnError)
+	flagSet.SetOutput(io.Discard)
+	flagSet.StringVar(&configDir, configDirFlagID, EnvToString("CONFIG_DIR", ""), configDirDesc)
+	if err := flagSet.Parse(getFlagArgs(configDirFlagID)); err != nil {
+		logger.ErrorErr(err, "Cannot parse the config-dir flag")
+	}
+	if configDir == "" && configFilePath != "" {
+		configDir = filepath.Join(filepath.Dir(configFilePath), configDirDefault)
+	}
+	return configDir
+}
+
+func isYAML(filePath string) bool {
+	ext := strings.ToLower(filepath.Ext(filePath))
+	return ext == ".yaml" || ext == ".yml"
+}
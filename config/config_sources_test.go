@@ -0,0 +1,195 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package config
+
+import (
+	"encoding/json"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/eclipse-kanto/update-manager/api/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadConfigFromYAMLFile(t *testing.T) {
+	t.Run("test_yaml_valid", func(t *testing.T) {
+		expected := newDefaultConfig()
+		assert.NoError(t, LoadConfigFromFile("../config/testdata/config.json", expected))
+
+		cfg := newDefaultConfig()
+		assert.NoError(t, LoadConfigFromFile("../config/testdata/config.yaml", cfg))
+		assert.Equal(t, expected, cfg)
+	})
+	t.Run("test_yaml_invalid", func(t *testing.T) {
+		configFile := filepath.Join(t.TempDir(), "config.yml")
+		assert.NoError(t, os.WriteFile(configFile, []byte("log: [\n"), 0600))
+		assert.Error(t, LoadConfigFromFile(configFile, newDefaultConfig()))
+	})
+}
+
+func TestLoadConfigWithDropIns(t *testing.T) {
+	oldArgs, oldCommandLine := os.Args, flag.CommandLine
+	defer func() {
+		os.Args = oldArgs
+		flag.CommandLine = oldCommandLine
+	}()
+
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "config.json")
+	dropInDir := filepath.Join(dir, configDirDefault)
+	assert.NoError(t, os.Mkdir(dropInDir, 0700))
+	assert.NoError(t, os.WriteFile(configFile, []byte(`{"phaseTimeout":"5m","connection":{"broker":"tcp://broker:1883","username":"user"},"agents":{"containers":{"readTimeout":"30s"}}}`), 0600))
+	assert.NoError(t, os.WriteFile(filepath.Join(dropInDir, "20-timeouts.json"), []byte(`{"phaseTimeout":"7m","rebootAfter":"1m"}`), 0600))
+	assert.NoError(t, os.WriteFile(filepath.Join(dropInDir, "10-connection.yaml"), []byte("connection:\n  username: other\n  topicVariables:\n    tenant: t1\nagents:\n  containers:\n    rebootRequired: true\n"), 0600))
+	assert.NoError(t, os.WriteFile(filepath.Join(dropInDir, "30-ignored.txt"), []byte(`{"phaseTimeout":"9m"}`), 0600))
+
+	t.Run("test_drop_ins_merged_in_order", func(t *testing.T) {
+		os.Args = []string{oldArgs[0], "--config-file", configFile}
+		cfg, err := LoadConfig("")
+		assert.NoError(t, err)
+		assert.Equal(t, "7m", cfg.PhaseTimeout)
+		assert.Equal(t, "1m", cfg.RebootAfter)
+		assert.Equal(t, "tcp://broker:1883", cfg.MQTT.Broker)
+		assert.Equal(t, "other", cfg.MQTT.Username)
+		assert.Equal(t, map[string]string{"tenant": "t1"}, cfg.MQTT.TopicVariables)
+		assert.Equal(t, "30s", cfg.Agents[domainContainers].ReadTimeout)
+		assert.True(t, cfg.Agents[domainContainers].RebootRequired)
+	})
+
+	t.Run("test_drop_ins_without_config_file", func(t *testing.T) {
+		os.Args = []string{oldArgs[0], "--config-dir=" + dropInDir}
+		cfg, err := LoadConfig("")
+		assert.NoError(t, err)
+		assert.Equal(t, "7m", cfg.PhaseTimeout)
+		assert.Equal(t, "other", cfg.MQTT.Username)
+		assert.Equal(t, newDefaultConfig().MQTT.Broker, cfg.MQTT.Broker)
+	})
+
+	t.Run("test_drop_in_invalid", func(t *testing.T) {
+		assert.NoError(t, os.WriteFile(filepath.Join(dropInDir, "40-invalid.json"), []byte(`{"phaseTimeout":`), 0600))
+		os.Args = []string{oldArgs[0], "--config-file", configFile}
+		_, err := LoadConfig("")
+		assert.Error(t, err)
+	})
+}
+
+func TestApplyEnv(t *testing.T) {
+	cfg := newDefaultConfig()
+	cfg.Agents = newDefaultAgentsConfig()
+	applyEnv(cfg, []string{
+		"UM_PHASE_TIMEOUT=3m",
+		"UM_REBOOT_ENABLED=false",
+		"UM_LOG__LOG_FILE_SIZE=10",
+		"UM_CONNECTION__MAX_RECONNECT_INTERVAL=5m",
+		"UM_CONNECTION__PASSWORD=secret",
+		"UM_CONNECTION__CIPHER_SUITES=TLS_AES_128_GCM_SHA256, TLS_AES_256_GCM_SHA384",
+		"UM_CONNECTION__TOPIC_VARIABLES=tenant=t1,device=d1",
+		`UM_CONNECTION__BROKERS=[{"url":"tcp://fallback:1883","priority":2}]`,
+		"UM_OWNER_CONSENT_COMMANDS=DOWNLOAD,UPDATE",
+		"UM_HISTORY__FILE=history.json",
+		"UM_AGENTS__CONTAINERS__READ_TIMEOUT=2m",
+		"UM_AGENTS__SELF_UPDATE__REBOOT_REQUIRED=true",
+		`UM_AGENTS__SELF_UPDATE__TOPICS={"desiredState":"legacy/desiredstate"}`,
+		"UM_REBOOT_AFTER",
+		"UM_UNKNOWN=value",
+		"UM_HISTORY__FILE_SIZE=invalid",
+		"PHASE_TIMEOUT=1m",
+	}, io.Discard)
+
+	assert.Equal(t, "3m", cfg.PhaseTimeout)
+	assert.False(t, cfg.RebootEnabled)
+	assert.Equal(t, rebootAfterDefault, cfg.RebootAfter)
+	assert.Equal(t, 10, cfg.Log.LogFileSize)
+	assert.Equal(t, "5m", cfg.MQTT.MaxReconnectInterval)
+	assert.Equal(t, "secret", cfg.MQTT.Password)
+	assert.Equal(t, []string{"TLS_AES_128_GCM_SHA256", "TLS_AES_256_GCM_SHA384"}, cfg.MQTT.CipherSuites)
+	assert.Equal(t, map[string]string{"tenant": "t1", "device": "d1"}, cfg.MQTT.TopicVariables)
+	assert.Equal(t, 1, len(cfg.MQTT.Brokers))
+	assert.Equal(t, "tcp://fallback:1883", cfg.MQTT.Brokers[0].URL)
+	assert.Equal(t, 2, cfg.MQTT.Brokers[0].Priority)
+	assert.Equal(t, []types.CommandType{types.CommandDownload, types.CommandUpdate}, cfg.OwnerConsentCommands)
+	assert.Equal(t, "history.json", cfg.History.File)
+	assert.Equal(t, newDefaultConfig().History.FileSize, cfg.History.FileSize)
+	assert.Equal(t, "2m", cfg.Agents[domainContainers].ReadTimeout)
+	assert.True(t, cfg.Agents["self-update"].RebootRequired)
+	assert.Equal(t, map[string]string{"desiredState": "legacy/desiredstate"}, cfg.Agents["self-update"].Topics)
+}
+
+func TestLoadConfigEnvPrecedence(t *testing.T) {
+	oldArgs, oldCommandLine := os.Args, flag.CommandLine
+	defer func() {
+		os.Args = oldArgs
+		flag.CommandLine = oldCommandLine
+	}()
+
+	configFile := filepath.Join(t.TempDir(), "config.json")
+	assert.NoError(t, os.WriteFile(configFile, []byte(`{"log":{"logLevel":"WARN"},"phaseTimeout":"5m","agents":{"containers":{"readTimeout":"30s"}}}`), 0600))
+	t.Setenv("LOG_LEVEL", "DEBUG")
+	t.Setenv("UM_LOG__LOG_LEVEL", "TRACE")
+	t.Setenv("PHASE_TIMEOUT", "7m")
+	t.Setenv("REBOOT_AFTER", "2m")
+	t.Setenv("UM_REBOOT_AFTER", "3m")
+	t.Setenv("CONTAINERS_READ_TIMEOUT", "2m")
+	t.Setenv("UM_AGENTS__CONTAINERS__READ_TIMEOUT", "3m")
+	t.Setenv("CONTAINERS_REBOOT_REQUIRED", "true")
+
+	t.Run("test_um_env_overrides_legacy_env", func(t *testing.T) {
+		os.Args = []string{oldArgs[0], "--config-file", configFile}
+		cfg, err := LoadConfig("")
+		assert.NoError(t, err)
+		assert.Equal(t, "TRACE", cfg.Log.LogLevel)
+		assert.Equal(t, "7m", cfg.PhaseTimeout)
+		assert.Equal(t, "3m", cfg.RebootAfter)
+		assert.Equal(t, "3m", cfg.Agents[domainContainers].ReadTimeout)
+		assert.True(t, cfg.Agents[domainContainers].RebootRequired)
+		assert.False(t, cfg.ValidateOnly())
+	})
+
+	t.Run("test_flags_override_env", func(t *testing.T) {
+		os.Args = []string{oldArgs[0], "--config-file", configFile, "--log-level=ERROR", "--reboot-after=1h", "--containers-read-timeout=4m"}
+		cfg, err := LoadConfig("")
+		assert.NoError(t, err)
+		assert.Equal(t, "ERROR", cfg.Log.LogLevel)
+		assert.Equal(t, "1h", cfg.RebootAfter)
+		assert.Equal(t, "4m", cfg.Agents[domainContainers].ReadTimeout)
+	})
+
+	t.Run("test_validate_only", func(t *testing.T) {
+		os.Args = []string{oldArgs[0], "--config-file", configFile, "--validate-config"}
+		cfg, err := LoadConfig("")
+		assert.NoError(t, err)
+		assert.True(t, cfg.ValidateOnly())
+	})
+}
+
+func TestRedacted(t *testing.T) {
+	cfg := newDefaultConfig()
+	cfg.MQTT.Username = "user"
+	cfg.MQTT.Password = "s3cr3t"
+
+	data, err := Redacted(cfg)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "s3cr3t")
+
+	content := map[string]interface{}{}
+	assert.NoError(t, json.Unmarshal(data, &content))
+	connection := content["connection"].(map[string]interface{})
+	assert.Equal(t, redactedValue, connection["password"])
+	assert.Equal(t, "user", connection["username"])
+	assert.Equal(t, cfg.PhaseTimeout, content["phaseTimeout"])
+	assert.Equal(t, "s3cr3t", cfg.MQTT.Password)
+}
@@ -21,6 +21,13 @@ import (
 
 	"github.com/eclipse-kanto/update-manager/logger"
 	"github.com/eclipse-kanto/update-manager/mqtt"
+	"github.com/eclipse-kanto/update-manager/mqtt/queue"
+	"github.com/eclipse-kanto/update-manager/rest"
+	"github.com/eclipse-kanto/update-manager/updatem/cache"
+	"github.com/eclipse-kanto/update-manager/updatem/history"
+	"github.com/eclipse-kanto/update-manager/updatem/replay"
+	"github.com/eclipse-kanto/update-manager/util/jws"
+	"github.com/eclipse-kanto/update-manager/util/secret"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -43,17 +50,23 @@ func TestNewDefaultConfig(t *testing.T) {
 				LogFileSize:   2,
 				LogFileCount:  5,
 				LogFileMaxAge: 28,
+				LogFormat:     "text",
 			},
 			MQTT: &mqtt.ConnectionConfig{
-				Broker:             "tcp://localhost:1883",
-				KeepAlive:          "20s",
-				DisconnectTimeout:  "250ms",
-				Username:           "",
-				Password:           "",
-				ConnectTimeout:     "30s",
-				AcknowledgeTimeout: "15s",
-				SubscribeTimeout:   "15s",
-				UnsubscribeTimeout: "5s",
+				Broker:               "tcp://localhost:1883",
+				KeepAlive:            "20s",
+				DisconnectTimeout:    "250ms",
+				Username:             "",
+				Password:             "",
+				ConnectTimeout:       "30s",
+				AcknowledgeTimeout:   "15s",
+				SubscribeTimeout:     "15s",
+				UnsubscribeTimeout:   "5s",
+				MinTLSVersion:        "1.2",
+				MaxReconnectInterval: "10m",
+				FailbackInterval:     "1m",
+				ProtocolVersion:      "3.1.1",
+				TopicPrefix:          "{domain}",
 			},
 			Domain:        "device",
 			ThingsEnabled: true,
@@ -65,6 +78,36 @@ func TestNewDefaultConfig(t *testing.T) {
 		CurrentStateDelay:      "30s",
 		PhaseTimeout:           "10m",
 		OwnerConsentTimeout:    "30m",
+		HTTP: &rest.ServerConfig{
+			Address:      "",
+			ReadTimeout:  "1m",
+			WriteTimeout: "2m",
+		},
+		History: &history.Config{
+			File:       "",
+			FileSize:   2,
+			FileCount:  10,
+			FileMaxAge: 0,
+		},
+		Signature: &jws.Config{},
+		Secrets:   &secret.Config{},
+		Replay: &replay.Config{
+			Enabled:      false,
+			MaxAge:       "24h",
+			File:         "",
+			ActivityIDs:  1000,
+			AntiRollback: false,
+		},
+		Queue: &queue.Config{
+			Enabled:     false,
+			File:        "",
+			MaxMessages: 1000,
+		},
+		ArtifactCache: &cache.Config{
+			Dir:                "",
+			MaxSize:            1024,
+			ReservationTimeout: "1h",
+		},
 	}
 
 	cfg := newDefaultConfig()
@@ -102,6 +145,7 @@ func TestLoadConfigFromFile(t *testing.T) {
 			"containers": {
 				RebootRequired: true,
 				ReadTimeout:    "30s",
+				Topics:         map[string]string{"desiredState": "legacy/containers/desiredstate"},
 			},
 			"test-domain": {
 				RebootRequired: true,
@@ -117,17 +161,39 @@ func TestLoadConfigFromFile(t *testing.T) {
 					LogFileSize:   3,
 					LogFileCount:  6,
 					LogFileMaxAge: 29,
+					LogFormat:     "json",
+					LogSyslog:     true,
 				},
 				MQTT: &mqtt.ConnectionConfig{
-					Broker:             "www",
-					KeepAlive:          "500ms",
-					DisconnectTimeout:  "500ms",
-					Username:           "username",
-					Password:           "pass",
-					ConnectTimeout:     "500ms",
-					AcknowledgeTimeout: "500ms",
-					SubscribeTimeout:   "500ms",
-					UnsubscribeTimeout: "500ms",
+					Broker:               "www",
+					KeepAlive:            "500ms",
+					DisconnectTimeout:    "500ms",
+					Username:             "username",
+					Password:             "pass",
+					ConnectTimeout:       "500ms",
+					AcknowledgeTimeout:   "500ms",
+					SubscribeTimeout:     "500ms",
+					UnsubscribeTimeout:   "500ms",
+					MaxReconnectInterval: "1m",
+
+					KeyPassphraseFile: "passphrase.txt",
+					CRLFile:           "ca.crl",
+					ServerName:        "broker.local",
+					MinTLSVersion:     "1.3",
+					CipherSuites:      []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"},
+
+					Brokers: []*mqtt.BrokerConfig{
+						{URL: "ssl://localhost:8883", Priority: 1, CACert: "ca.crt"},
+						{URL: "tcp://fallback:1883", Priority: 2},
+					},
+					FailbackInterval: "2m",
+
+					ProtocolVersion:         "5",
+					SharedSubscriptionGroup: "update-agents",
+					MessageExpiry:           "1h",
+
+					TopicPrefix:    "{tenant}/{device}/{domain}",
+					TopicVariables: map[string]string{"tenant": "test-tenant", "device": "test-device"},
 				},
 				Domain:        "mydomain",
 				ThingsEnabled: false,
@@ -138,8 +204,47 @@ func TestLoadConfigFromFile(t *testing.T) {
 			ReportFeedbackInterval: "2m",
 			CurrentStateDelay:      "1m",
 			PhaseTimeout:           "2m",
+			PhaseTimeoutPause:      true,
 			OwnerConsentTimeout:    "4m",
 			OwnerConsentCommands:   []types.CommandType{types.CommandDownload},
+			HTTP: &rest.ServerConfig{
+				Address:      "unix:///tmp/update-manager.sock",
+				ReadTimeout:  "30s",
+				WriteTimeout: "1m",
+			},
+			History: &history.Config{
+				File:       "/var/lib/update-manager/history.log",
+				FileSize:   5,
+				FileCount:  3,
+				FileMaxAge: 365,
+			},
+			Signature: &jws.Config{
+				CACert: "/etc/update-manager/signature-ca.crt",
+			},
+			Secrets: &secret.Config{
+				KeyFile:  "/etc/update-manager/device.key",
+				StoreDir: "/etc/update-manager/secrets",
+				KeysFile: "/var/lib/update-manager/secret-keys.json",
+			},
+			Replay: &replay.Config{
+				Enabled:      true,
+				MaxAge:       "1h",
+				File:         "/var/lib/update-manager/replay.json",
+				ActivityIDs:  100,
+				AntiRollback: true,
+			},
+			Queue: &queue.Config{
+				Enabled:     true,
+				File:        "/var/lib/update-manager/queue.json",
+				MaxMessages: 100,
+			},
+			ArtifactCache: &cache.Config{
+				Dir:                "/var/lib/update-manager/artifacts",
+				MaxSize:            4096,
+				ReservationTimeout: "30m",
+			},
+			NamespaceNodeIDs:    true,
+			ConfigWatchInterval: "30s",
 		}
 		assert.True(t, reflect.DeepEqual(*cfg, expectedConfigValues))
 	})
@@ -0,0 +1,317 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/eclipse-kanto/update-manager/api"
+	"github.com/eclipse-kanto/update-manager/api/types"
+	"github.com/eclipse-kanto/update-manager/logger"
+	"github.com/eclipse-kanto/update-manager/mqtt"
+	"github.com/eclipse-kanto/update-manager/util/tls"
+)
+
+var ownerConsentCommands = []types.CommandType{types.CommandDownload, types.CommandUpdate, types.CommandActivate}
+
+// ValidationError holds all problems found in the configuration.
+type ValidationError struct {
+	Problems []string
+}
+
+func (err *ValidationError) Error() string {
+	return fmt.Sprintf("invalid configuration:\n  - %s", strings.Join(err.Problems, "\n  - "))
+}
+
+type validator struct {
+	problems []string
+}
+
+func (v *validator) addf(format string, args ...interface{}) {
+	v.problems = append(v.problems, fmt.Sprintf(format, args...))
+}
+
+func (v *validator) err() error {
+	if len(v.problems) == 0 {
+		return nil
+	}
+	return &ValidationError{Problems: v.problems}
+}
+
+// Validate checks the configuration and returns a ValidationError with all found problems, i.e. invalid durations,
+// unknown owner consent commands, domains without agent configuration, invalid broker URLs and missing TLS files.
+func Validate(cfg *Config) error {
+	v := &validator{}
+	v.validateConfig(cfg)
+	return v.err()
+}
+
+func (v *validator) validateConfig(cfg *Config) {
+	if cfg.BaseConfig != nil && cfg.Log != nil {
+		switch strings.ToLower(cfg.Log.LogFormat) {
+		case "", logger.LogFormatText, logger.LogFormatJSON:
+		default:
+			v.addf("log.logFormat: unsupported log format '%s', the supported values are: text, json", cfg.Log.LogFormat)
+		}
+	}
+	v.duration("rebootAfter", cfg.RebootAfter)
+	v.duration("reportFeedbackInterval", cfg.ReportFeedbackInterval)
+	v.duration("currentStateDelay", cfg.CurrentStateDelay)
+	v.duration("phaseTimeout", cfg.PhaseTimeout)
+	v.duration("ownerConsentTimeout", cfg.OwnerConsentTimeout)
+	v.duration("configWatchInterval", cfg.ConfigWatchInterval)
+	for _, command := range cfg.OwnerConsentCommands {
+		if !isOwnerConsentCommand(command) {
+			v.addf("ownerConsentCommands: unknown command '%s', the supported values are: DOWNLOAD, UPDATE, ACTIVATE", command)
+		}
+	}
+	v.validateAgents(cfg.Agents)
+	if cfg.BaseConfig != nil && cfg.MQTT != nil {
+		v.validateConnection(cfg.MQTT)
+	}
+	if cfg.HTTP != nil {
+		v.duration("http.readTimeout", cfg.HTTP.ReadTimeout)
+		v.duration("http.writeTimeout", cfg.HTTP.WriteTimeout)
+	}
+	if cfg.Replay != nil {
+		v.duration("replay.maxAge", cfg.Replay.MaxAge)
+	}
+	if cfg.Signature != nil {
+		v.file("signature.caCert", cfg.Signature.CACert)
+	}
+	if cfg.Secrets != nil {
+		v.file("secrets.keyFile", cfg.Secrets.KeyFile)
+	}
+	if cfg.ArtifactCache.IsEnabled() {
+		if cfg.ArtifactCache.MaxSize <= 0 {
+			v.addf("artifactCache.maxSize: invalid value %d, a positive number of megabytes is expected", cfg.ArtifactCache.MaxSize)
+		}
+		v.duration("artifactCache.reservationTimeout", cfg.ArtifactCache.ReservationTimeout)
+	}
+}
+
+func (v *validator) validateAgents(agents map[string]*api.UpdateManagerConfig) {
+	if len(agents) == 0 {
+		v.addf("agents: no domains configured")
+	}
+	names := make([]string, 0, len(agents))
+	for name := range agents {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if strings.TrimSpace(name) == "" {
+			v.addf("agents: empty domain name")
+			continue
+		}
+		agent := agents[name]
+		if agent == nil {
+			v.addf("agents.%s: domain without agent configuration", name)
+			continue
+		}
+		v.duration(fmt.Sprintf("agents.%s.readTimeout", name), agent.ReadTimeout)
+	}
+}
+
+func (v *validator) validateConnection(connection *mqtt.ConnectionConfig) {
+	v.duration("connection.keepAlive", connection.KeepAlive)
+	v.duration("connection.disconnectTimeout", connection.DisconnectTimeout)
+	v.duration("connection.connectTimeout", connection.ConnectTimeout)
+	v.duration("connection.acknowledgeTimeout", connection.AcknowledgeTimeout)
+	v.duration("connection.subscribeTimeout", connection.SubscribeTimeout)
+	v.duration("connection.unsubscribeTimeout", connection.UnsubscribeTimeout)
+	v.duration("connection.maxReconnectInterval", connection.MaxReconnectInterval)
+	v.duration("connection.failbackInterval", connection.FailbackInterval)
+	v.duration("connection.messageExpiry", connection.MessageExpiry)
+
+	if err := (&tls.Settings{MinVersion: connection.MinTLSVersion, CipherSuites: connection.CipherSuites}).Validate(); err != nil {
+		v.addf("connection: %v", err)
+	}
+
+	property := "connection"
+	for i, broker := range connection.Brokers {
+		if broker == nil || broker.URL == "" {
+			v.addf("connection.brokers[%d]: missing broker URL", i)
+		}
+	}
+	if len(connection.Brokers) > 0 {
+		property = "connection.brokers"
+	}
+	for _, broker := range mqtt.ResolveBrokers(connection) {
+		v.validateBroker(property, broker)
+	}
+}
+
+func (v *validator) validateBroker(property string, broker *mqtt.BrokerConfig) {
+	u, err := url.Parse(broker.URL)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		v.addf("%s: invalid broker URL '%s'", property, broker.URL)
+		return
+	}
+	if !mqtt.IsConnectionSecure(u.Scheme) {
+		return
+	}
+	property = fmt.Sprintf("%s[%s]", property, u.Redacted())
+	if broker.CACert == "" {
+		v.addf("%s: secure connection without CA certificate", property)
+		return
+	}
+	valid := v.file(property+".caCert", broker.CACert)
+	valid = v.file(property+".cert", broker.Cert) && valid
+	valid = v.file(property+".key", broker.Key) && valid
+	valid = v.file(property+".keyPassphraseFile", broker.KeyPassphraseFile) && valid
+	valid = v.file(property+".crlFile", broker.CRLFile) && valid
+	if (broker.Cert == "") != (broker.Key == "") {
+		v.addf("%s: both the client certificate and the private key must be set", property)
+		return
+	}
+	if !valid {
+		return
+	}
+	// the minimum TLS version and the cipher suites are already validated for the connection
+	if _, err := tls.NewTLSConfigFromSettings(&tls.Settings{
+		CACert:            broker.CACert,
+		Cert:              broker.Cert,
+		Key:               broker.Key,
+		KeyPassphraseFile: broker.KeyPassphraseFile,
+		CRLFile:           broker.CRLFile,
+	}); err != nil {
+		v.addf("%s: %v", property, err)
+	}
+}
+
+func (v *validator) duration(property, value string) {
+	if value == "" {
+		return
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		v.addf("%s: invalid duration '%s', value should be a positive integer number followed by a unit suffix, such as '60s', '10m', etc", property, value)
+	} else if duration < 0 {
+		v.addf("%s: negative duration '%s'", property, value)
+	}
+}
+
+func (v *validator) file(property, path string) bool {
+	if path == "" {
+		return true
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		v.addf("%s: file '%s' does not exist or cannot be accessed", property, path)
+		return false
+	}
+	if info.IsDir() {
+		v.addf("%s: '%s' is a directory", property, path)
+		return false
+	}
+	return true
+}
+
+// unknownProperties returns the JSON paths of the properties in the given config file content, which are not defined in the given config type.
+func unknownProperties(content map[string]interface{}, configType reflect.Type, path string) []string {
+	for configType.Kind() == reflect.Ptr {
+		configType = configType.Elem()
+	}
+	var unknown []string
+	for key, value := range content {
+		propertyPath := key
+		if path != "" {
+			propertyPath = path + "." + key
+		}
+		switch configType.Kind() {
+		case reflect.Struct:
+			field, ok := findFieldType(configType, key)
+			if !ok {
+				unknown = append(unknown, propertyPath)
+				continue
+			}
+			unknown = append(unknown, unknownNestedProperties(value, field, propertyPath)...)
+		case reflect.Map:
+			unknown = append(unknown, unknownNestedProperties(value, configType.Elem(), propertyPath)...)
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
+
+func unknownNestedProperties(value interface{}, valueType reflect.Type, path string) []string {
+	for valueType.Kind() == reflect.Ptr {
+		valueType = valueType.Elem()
+	}
+	switch content := value.(type) {
+	case map[string]interface{}:
+		if valueType.Kind() == reflect.Struct || valueType.Kind() == reflect.Map {
+			return unknownProperties(content, valueType, path)
+		}
+	case []interface{}:
+		if valueType.Kind() != reflect.Slice {
+			return nil
+		}
+		var unknown []string
+		for i, item := range content {
+			unknown = append(unknown, unknownNestedProperties(item, valueType.Elem(), fmt.Sprintf("%s[%d]", path, i))...)
+		}
+		return unknown
+	}
+	return nil
+}
+
+// findFieldType returns the type of the struct field with the given JSON name, also searching in the embedded structs.
+func findFieldType(structType reflect.Type, name string) (reflect.Type, bool) {
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		jsonName, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if jsonName == "-" {
+			continue
+		}
+		if jsonName == "" && field.Anonymous {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				if found, ok := findFieldType(embedded, name); ok {
+					return found, true
+				}
+			}
+			continue
+		}
+		if jsonName == "" {
+			jsonName = field.Name
+		}
+		// the JSON property names are matched case-insensitively, as by the JSON decoder
+		if strings.EqualFold(jsonName, name) {
+			return field.Type, true
+		}
+	}
+	return nil, false
+}
+
+func isOwnerConsentCommand(command types.CommandType) bool {
+	for _, supported := range ownerConsentCommands {
+		if command == supported {
+			return true
+		}
+	}
+	return false
+}
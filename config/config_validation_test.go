@@ -0,0 +1,126 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package config
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/eclipse-kanto/update-manager/api"
+	"github.com/eclipse-kanto/update-manager/api/types"
+	"github.com/eclipse-kanto/update-manager/mqtt"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const tlsTestdata = "../util/tls/testdata/"
+
+func TestValidate(t *testing.T) {
+	t.Run("test_default_config_valid", func(t *testing.T) {
+		cfg := newDefaultConfig()
+		cfg.Agents = newDefaultAgentsConfig()
+		assert.NoError(t, Validate(cfg))
+	})
+
+	t.Run("test_tls_config_valid", func(t *testing.T) {
+		cfg := newDefaultConfig()
+		cfg.Agents = newDefaultAgentsConfig()
+		cfg.MQTT.Broker = "ssl://localhost:8883"
+		cfg.MQTT.CACert = tlsTestdata + "ca.crt"
+		cfg.MQTT.Cert = tlsTestdata + "certificate.pem"
+		cfg.MQTT.Key = tlsTestdata + "key.pem"
+		cfg.MQTT.MinTLSVersion = "1.3"
+		assert.NoError(t, Validate(cfg))
+	})
+
+	t.Run("test_all_problems_reported", func(t *testing.T) {
+		cfg := newDefaultConfig()
+		cfg.Agents = map[string]*api.UpdateManagerConfig{
+			"containers":  {ReadTimeout: "1 minute"},
+			"self-update": nil,
+		}
+		cfg.Log.LogFormat = "xml"
+		cfg.PhaseTimeout = "10"
+		cfg.RebootAfter = "-1m"
+		cfg.OwnerConsentCommands = []types.CommandType{types.CommandDownload, "INSTALL"}
+		cfg.MQTT.KeepAlive = "abc"
+		cfg.MQTT.MinTLSVersion = "1.1"
+		cfg.MQTT.Brokers = []*mqtt.BrokerConfig{
+			{URL: "ssl://primary:8883", CACert: tlsTestdata + "missing.crt", Cert: tlsTestdata + "certificate.pem"},
+			{URL: "localhost", Priority: 2},
+			{Priority: 3},
+		}
+		cfg.Signature.CACert = tlsTestdata
+		cfg.Secrets.KeyFile = tlsTestdata + "missing.key"
+		cfg.ArtifactCache.Dir = "/var/lib/update-manager/artifacts"
+		cfg.ArtifactCache.MaxSize = 0
+		cfg.ArtifactCache.ReservationTimeout = "1 hour"
+
+		err := Validate(cfg)
+		assert.IsType(t, &ValidationError{}, err)
+		assert.Equal(t, []string{
+			"log.logFormat: unsupported log format 'xml', the supported values are: text, json",
+			"rebootAfter: negative duration '-1m'",
+			"phaseTimeout: invalid duration '10', value should be a positive integer number followed by a unit suffix, such as '60s', '10m', etc",
+			"ownerConsentCommands: unknown command 'INSTALL', the supported values are: DOWNLOAD, UPDATE, ACTIVATE",
+			"agents.containers.readTimeout: invalid duration '1 minute', value should be a positive integer number followed by a unit suffix, such as '60s', '10m', etc",
+			"agents.self-update: domain without agent configuration",
+			"connection.keepAlive: invalid duration 'abc', value should be a positive integer number followed by a unit suffix, such as '60s', '10m', etc",
+			"connection: unsupported minimum TLS version 1.1, the supported values are: 1.2, 1.3",
+			"connection.brokers[2]: missing broker URL",
+			"connection.brokers[ssl://primary:8883].caCert: file '" + tlsTestdata + "missing.crt' does not exist or cannot be accessed",
+			"connection.brokers[ssl://primary:8883]: both the client certificate and the private key must be set",
+			"connection.brokers: invalid broker URL 'localhost'",
+			"signature.caCert: '" + tlsTestdata + "' is a directory",
+			"secrets.keyFile: file '" + tlsTestdata + "missing.key' does not exist or cannot be accessed",
+			"artifactCache.maxSize: invalid value 0, a positive number of megabytes is expected",
+			"artifactCache.reservationTimeout: invalid duration '1 hour', value should be a positive integer number followed by a unit suffix, such as '60s', '10m', etc",
+		}, err.(*ValidationError).Problems)
+	})
+
+	t.Run("test_no_agents", func(t *testing.T) {
+		cfg := newDefaultConfig()
+		cfg.Agents = map[string]*api.UpdateManagerConfig{}
+		assert.EqualError(t, Validate(cfg), "invalid configuration:\n  - agents: no domains configured")
+	})
+
+	t.Run("test_secure_connection_without_ca", func(t *testing.T) {
+		cfg := newDefaultConfig()
+		cfg.Agents = newDefaultAgentsConfig()
+		cfg.MQTT.Broker = "ssl://localhost:8883"
+		assert.EqualError(t, Validate(cfg), "invalid configuration:\n  - connection[ssl://localhost:8883]: secure connection without CA certificate")
+	})
+}
+
+func TestLoadConfigInvalid(t *testing.T) {
+	oldArgs, oldCommandLine := os.Args, flag.CommandLine
+	defer func() {
+		os.Args = oldArgs
+		flag.CommandLine = oldCommandLine
+	}()
+
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	assert.NoError(t, os.WriteFile(configFile, []byte("phaseTimeout: \"5\"\nconnection:\n  brokr: tcp://localhost:1883\n  brokers:\n  - url: tcp://localhost:1883\n    prio: 1\nagents:\n  containers:\n    readTimeout: 1m\n    topic: {}\n"), 0600))
+	os.Args = []string{oldArgs[0], "--config-file", configFile, "--reboot-after=1h"}
+
+	_, err := LoadConfig("")
+	assert.IsType(t, &ValidationError{}, err)
+	assert.Equal(t, []string{
+		"agents.containers.topic: unknown property in config file " + configFile,
+		"connection.brokers[0].prio: unknown property in config file " + configFile,
+		"connection.brokr: unknown property in config file " + configFile,
+		"phaseTimeout: invalid duration '5', value should be a positive integer number followed by a unit suffix, such as '60s', '10m', etc",
+	}, err.(*ValidationError).Problems)
+}
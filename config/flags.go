@@ -16,10 +16,14 @@ import (
 	"flag"
 	"fmt"
 	"io"
+	"net/url"
 	"os"
+	"sort"
 	"strconv"
+	"strings"
 
 	"github.com/eclipse-kanto/update-manager/logger"
+	"github.com/eclipse-kanto/update-manager/mqtt"
 )
 
 const (
@@ -29,32 +33,166 @@ const (
 
 // SetupFlags adds common flags for the configuration of all update agents
 func SetupFlags(flagSet *flag.FlagSet, cfg *BaseConfig) {
+	setupFlags(flagSet, cfg, processEnv())
+}
+
+func setupFlags(flagSet *flag.FlagSet, cfg *BaseConfig, env flagEnv) {
 	flagSet.String(configFileFlagID, "", "Specify the configuration file")
 
 	// init log flags
-	flagSet.StringVar(&cfg.Log.LogLevel, "log-level", EnvToString("LOG_LEVEL", cfg.Log.LogLevel), "Set the log level - possible values are ERROR, WARN, INFO, DEBUG, TRACE")
-	flagSet.StringVar(&cfg.Log.LogFile, "log-file", EnvToString("LOG_FILE", cfg.Log.LogFile), "Set the log file")
-	flagSet.IntVar(&cfg.Log.LogFileSize, "log-file-size", int(EnvToInt("LOG_FILE_SIZE", int64(cfg.Log.LogFileSize))), "Set the maximum size in megabytes of the log file before it gets rotated")
-	flagSet.IntVar(&cfg.Log.LogFileCount, "log-file-count", int(EnvToInt("LOG_FILE_COUNT", int64(cfg.Log.LogFileCount))), "Set the maximum number of old log files to retain")
-	flagSet.IntVar(&cfg.Log.LogFileMaxAge, "log-file-max-age", int(EnvToInt("LOG_FILE_MAX_AGE", int64(cfg.Log.LogFileMaxAge))), "Set the maximum number of days to retain old log files based on the timestamp encoded in their filename")
+	flagSet.StringVar(&cfg.Log.LogLevel, "log-level", env.toString("LOG_LEVEL", cfg.Log.LogLevel), "Set the log level - possible values are ERROR, WARN, INFO, DEBUG, TRACE")
+	flagSet.StringVar(&cfg.Log.LogFile, "log-file", env.toString("LOG_FILE", cfg.Log.LogFile), "Set the log file")
+	flagSet.IntVar(&cfg.Log.LogFileSize, "log-file-size", int(env.toInt("LOG_FILE_SIZE", int64(cfg.Log.LogFileSize))), "Set the maximum size in megabytes of the log file before it gets rotated")
+	flagSet.IntVar(&cfg.Log.LogFileCount, "log-file-count", int(env.toInt("LOG_FILE_COUNT", int64(cfg.Log.LogFileCount))), "Set the maximum number of old log files to retain")
+	flagSet.IntVar(&cfg.Log.LogFileMaxAge, "log-file-max-age", int(env.toInt("LOG_FILE_MAX_AGE", int64(cfg.Log.LogFileMaxAge))), "Set the maximum number of days to retain old log files based on the timestamp encoded in their filename")
+	flagSet.StringVar(&cfg.Log.LogFormat, "log-format", env.toString("LOG_FORMAT", cfg.Log.LogFormat), "Set the format of the log messages - possible values are text, json. In json format, each message is a JSON object with the level, component, domain, activityId and phase as separate properties")
+	flagSet.BoolVar(&cfg.Log.LogSyslog, "log-syslog", env.toBool("LOG_SYSLOG", cfg.Log.LogSyslog), "Send the log messages also to the local syslog, e.g. journald, in addition to the log file or the standard error output")
 
 	// init connection flags
-	flagSet.StringVar(&cfg.MQTT.Broker, "mqtt-conn-broker", EnvToString("MQTT_CONN_BROKER", cfg.MQTT.Broker), "Address of the MQTT server/broker that the update manager will connect for the communication, the format is: scheme://host:port")
-	flagSet.StringVar(&cfg.MQTT.KeepAlive, "mqtt-conn-keep-alive", EnvToString("MQTT_CONN_KEEP_ALIVE", cfg.MQTT.KeepAlive), "Keep alive duration for the MQTT requests as duration string")
-	flagSet.StringVar(&cfg.MQTT.DisconnectTimeout, "mqtt-conn-disconnect-timeout", EnvToString("MQTT_CONN_DISCONNECT_TIMEOUT", cfg.MQTT.DisconnectTimeout), "Disconnect timeout for the MQTT server/broker as duration string")
-	flagSet.StringVar(&cfg.MQTT.Username, "mqtt-conn-username", EnvToString("MQTT_CONN_USERNAME", cfg.MQTT.Username), "Username that is a part of the credentials")
-	flagSet.StringVar(&cfg.MQTT.Password, "mqtt-conn-password", EnvToString("MQTT_CONN_PASSWORD", cfg.MQTT.Password), "Password that is a part of the credentials")
-	flagSet.StringVar(&cfg.MQTT.ConnectTimeout, "mqtt-conn-connect-timeout", EnvToString("MQTT_CONN_CONNECT_TIMEOUT", cfg.MQTT.ConnectTimeout), "Connect timeout for the MQTT server/broker as duration string")
-	flagSet.StringVar(&cfg.MQTT.AcknowledgeTimeout, "mqtt-conn-ack-timeout", EnvToString("MQTT_CONN_ACK_TIMEOUT", cfg.MQTT.AcknowledgeTimeout), "Acknowledge timeout for the MQTT requests as duration string")
-	flagSet.StringVar(&cfg.MQTT.SubscribeTimeout, "mqtt-conn-sub-timeout", EnvToString("MQTT_CONN_SUB_TIMEOUT", cfg.MQTT.SubscribeTimeout), "Subscribe timeout for the MQTT requests as duration string")
-	flagSet.StringVar(&cfg.MQTT.UnsubscribeTimeout, "mqtt-conn-unsub-timeout", EnvToString("MQTT_CONN_UNSUB_TIMEOUT", cfg.MQTT.UnsubscribeTimeout), "Unsubscribe timeout for the MQTT requests as duration string")
-	flagSet.StringVar(&cfg.MQTT.CACert, "mqtt-conn-ca-cert", EnvToString("MQTT_CONN_CA_CERT", cfg.MQTT.CACert), "Specify the PEM encoded CA certificates file")
-	flagSet.StringVar(&cfg.MQTT.Cert, "mqtt-conn-cert", EnvToString("MQTT_CONN_CERT", cfg.MQTT.Cert), "Specify the PEM encoded certificate file to authenticate to the MQTT server/broker")
-	flagSet.StringVar(&cfg.MQTT.Key, "mqtt-conn-key", EnvToString("MQTT_CONN_KEY", cfg.MQTT.Key), "Specify the PEM encoded unencrypted private key file to authenticate to the MQTT server/broker")
+	flagSet.StringVar(&cfg.MQTT.Broker, "mqtt-conn-broker", env.toString("MQTT_CONN_BROKER", cfg.MQTT.Broker), "Address of the MQTT server/broker that the update manager will connect for the communication, the format is: scheme://host:port")
+	flagSet.StringVar(&cfg.MQTT.KeepAlive, "mqtt-conn-keep-alive", env.toString("MQTT_CONN_KEEP_ALIVE", cfg.MQTT.KeepAlive), "Keep alive duration for the MQTT requests as duration string")
+	flagSet.StringVar(&cfg.MQTT.DisconnectTimeout, "mqtt-conn-disconnect-timeout", env.toString("MQTT_CONN_DISCONNECT_TIMEOUT", cfg.MQTT.DisconnectTimeout), "Disconnect timeout for the MQTT server/broker as duration string")
+	flagSet.StringVar(&cfg.MQTT.Username, "mqtt-conn-username", env.toString("MQTT_CONN_USERNAME", cfg.MQTT.Username), "Username that is a part of the credentials")
+	flagSet.StringVar(&cfg.MQTT.Password, "mqtt-conn-password", env.toString("MQTT_CONN_PASSWORD", cfg.MQTT.Password), "Password that is a part of the credentials")
+	flagSet.StringVar(&cfg.MQTT.ConnectTimeout, "mqtt-conn-connect-timeout", env.toString("MQTT_CONN_CONNECT_TIMEOUT", cfg.MQTT.ConnectTimeout), "Connect timeout for the MQTT server/broker as duration string")
+	flagSet.StringVar(&cfg.MQTT.AcknowledgeTimeout, "mqtt-conn-ack-timeout", env.toString("MQTT_CONN_ACK_TIMEOUT", cfg.MQTT.AcknowledgeTimeout), "Acknowledge timeout for the MQTT requests as duration string")
+	flagSet.StringVar(&cfg.MQTT.SubscribeTimeout, "mqtt-conn-sub-timeout", env.toString("MQTT_CONN_SUB_TIMEOUT", cfg.MQTT.SubscribeTimeout), "Subscribe timeout for the MQTT requests as duration string")
+	flagSet.StringVar(&cfg.MQTT.UnsubscribeTimeout, "mqtt-conn-unsub-timeout", env.toString("MQTT_CONN_UNSUB_TIMEOUT", cfg.MQTT.UnsubscribeTimeout), "Unsubscribe timeout for the MQTT requests as duration string")
+	flagSet.StringVar(&cfg.MQTT.CACert, "mqtt-conn-ca-cert", env.toString("MQTT_CONN_CA_CERT", cfg.MQTT.CACert), "Specify the PEM encoded CA certificates file")
+	flagSet.StringVar(&cfg.MQTT.Cert, "mqtt-conn-cert", env.toString("MQTT_CONN_CERT", cfg.MQTT.Cert), "Specify the PEM encoded certificate file to authenticate to the MQTT server/broker")
+	flagSet.StringVar(&cfg.MQTT.Key, "mqtt-conn-key", env.toString("MQTT_CONN_KEY", cfg.MQTT.Key), "Specify the PEM encoded private key file to authenticate to the MQTT server/broker, the key must be unencrypted if no passphrase file is specified")
+	flagSet.StringVar(&cfg.MQTT.KeyPassphraseFile, "mqtt-conn-key-passphrase-file", env.toString("MQTT_CONN_KEY_PASSPHRASE_FILE", cfg.MQTT.KeyPassphraseFile), "Specify the file holding the passphrase of the encrypted private key")
+	flagSet.StringVar(&cfg.MQTT.CRLFile, "mqtt-conn-crl-file", env.toString("MQTT_CONN_CRL_FILE", cfg.MQTT.CRLFile), "Specify the PEM or DER encoded certificate revocation lists file, used to check the MQTT server/broker certificate chain")
+	flagSet.StringVar(&cfg.MQTT.ServerName, "mqtt-conn-server-name", env.toString("MQTT_CONN_SERVER_NAME", cfg.MQTT.ServerName), "Server name used to verify the MQTT server/broker certificate, if it differs from the host of the broker address")
+	flagSet.StringVar(&cfg.MQTT.MinTLSVersion, "mqtt-conn-min-tls-version", env.toString("MQTT_CONN_MIN_TLS_VERSION", cfg.MQTT.MinTLSVersion), "Minimum TLS version for the secure connection to the MQTT server/broker, the supported values are: 1.2, 1.3")
+	cipherSuites := &listFlag{values: &cfg.MQTT.CipherSuites}
+	if err := cipherSuites.Set(env.toString("MQTT_CONN_CIPHER_SUITES", "")); err != nil {
+		fmt.Fprintf(env.output, "cannot use ENV variable %s: %v\n", "MQTT_CONN_CIPHER_SUITES", err)
+	}
+	flagSet.Var(cipherSuites, "mqtt-conn-cipher-suites", "Comma-separated list of TLS 1.2 cipher suite `names` for the secure connection to the MQTT server/broker, e.g. TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256. If not set, all secure cipher suites are used")
+	flagSet.StringVar(&cfg.MQTT.MaxReconnectInterval, "mqtt-conn-max-reconnect-interval", env.toString("MQTT_CONN_MAX_RECONNECT_INTERVAL", cfg.MQTT.MaxReconnectInterval), "Maximum interval between the reconnect attempts to the MQTT server/broker as duration string, the interval is doubled after each failed attempt up to this value")
+	flagSet.StringVar(&cfg.MQTT.ProtocolVersion, "mqtt-conn-protocol-version", env.toString("MQTT_CONN_PROTOCOL_VERSION", cfg.MQTT.ProtocolVersion), "MQTT protocol version used for the communication, the supported values are: 3.1.1, 5")
+	flagSet.StringVar(&cfg.MQTT.SharedSubscriptionGroup, "mqtt-conn-shared-subscription-group", env.toString("MQTT_CONN_SHARED_SUBSCRIPTION_GROUP", cfg.MQTT.SharedSubscriptionGroup), "Shared subscription group for the update agent request topics, applicable for MQTT 5 only")
+	flagSet.StringVar(&cfg.MQTT.MessageExpiry, "mqtt-conn-message-expiry", env.toString("MQTT_CONN_MESSAGE_EXPIRY", cfg.MQTT.MessageExpiry), "Expiry interval of the published desired state messages as duration string, applicable for MQTT 5 only. If not set, the messages do not expire")
+	flagSet.StringVar(&cfg.MQTT.TopicPrefix, "mqtt-conn-topic-prefix", env.toString("MQTT_CONN_TOPIC_PREFIX", cfg.MQTT.TopicPrefix), "Template of the MQTT topics prefix, e.g. '{tenant}/{device}/{domain}'. The {domain} placeholder is replaced with the MQTT domain identifier, the other placeholders with the values of the topic variables")
+	topicVariables := &keyValueFlag{values: &cfg.MQTT.TopicVariables}
+	if err := topicVariables.Set(env.toString("MQTT_CONN_TOPIC_VARIABLES", "")); err != nil {
+		fmt.Fprintf(env.output, "cannot use ENV variable %s: %v\n", "MQTT_CONN_TOPIC_VARIABLES", err)
+	}
+	flagSet.Var(topicVariables, "mqtt-conn-topic-variables", "Comma-separated list of `key=value` pairs, used to replace the respective {key} placeholders in the MQTT topics prefix")
+
+	brokers := &brokersFlag{brokers: &cfg.MQTT.Brokers}
+	if err := brokers.Set(env.toString("MQTT_CONN_BROKERS", "")); err != nil {
+		fmt.Fprintf(env.output, "cannot use ENV variable %s: %v\n", "MQTT_CONN_BROKERS", err)
+	}
+	flagSet.Var(brokers, "mqtt-conn-brokers", "Comma-separated list of MQTT server/broker `urls` in the order of their priority, the update manager fails over to the next one if a broker is not reachable. If set, the mqtt-conn-broker flag is not used")
+	flagSet.StringVar(&cfg.MQTT.FailbackInterval, "mqtt-conn-failback-interval", env.toString("MQTT_CONN_FAILBACK_INTERVAL", cfg.MQTT.FailbackInterval), "Interval for checking if the MQTT server/broker with the highest priority is reachable again while connected to a fallback one as duration string")
+
+	flagSet.StringVar(&cfg.Domain, "domain", env.toString("DOMAIN", cfg.Domain), "Specify the Domain of this update agent, used as MQTT topic prefix.")
+
+	flagSet.BoolVar(&cfg.ThingsEnabled, "things-enabled", env.toBool("THINGS_ENABLED", cfg.ThingsEnabled), "Specify whether the UpdateManager will behave as a things.")
+}
+
+// keyValueFlag is a flag value, which sets the entries of a map from a comma-separated list of key=value pairs.
+type keyValueFlag struct {
+	values *map[string]string
+}
+
+func (f *keyValueFlag) String() string {
+	if f.values == nil || len(*f.values) == 0 {
+		return ""
+	}
+	pairs := make([]string, 0, len(*f.values))
+	for key, value := range *f.values {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+func (f *keyValueFlag) Set(value string) error {
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" {
+			return fmt.Errorf("invalid key=value pair '%s'", pair)
+		}
+		if *f.values == nil {
+			*f.values = map[string]string{}
+		}
+		(*f.values)[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+	}
+	return nil
+}
+
+// listFlag is a flag value, which sets a list of values from a comma-separated list.
+type listFlag struct {
+	values *[]string
+}
+
+func (f *listFlag) String() string {
+	if f.values == nil {
+		return ""
+	}
+	return strings.Join(*f.values, ",")
+}
+
+func (f *listFlag) Set(value string) error {
+	var values []string
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); len(v) > 0 {
+			values = append(values, v)
+		}
+	}
+	if len(values) > 0 {
+		*f.values = values
+	}
+	return nil
+}
 
-	flagSet.StringVar(&cfg.Domain, "domain", EnvToString("DOMAIN", cfg.Domain), "Specify the Domain of this update agent, used as MQTT topic prefix.")
+// brokersFlag is a flag value, which sets the brokers from a comma-separated list of URLs in the order of their priority.
+// The TLS settings of the already configured brokers with the same URL are kept.
+type brokersFlag struct {
+	brokers *[]*mqtt.BrokerConfig
+}
 
-	flagSet.BoolVar(&cfg.ThingsEnabled, "things-enabled", EnvToBool("THINGS_ENABLED", cfg.ThingsEnabled), "Specify whether the UpdateManager will behave as a things.")
+func (f *brokersFlag) String() string {
+	if f.brokers == nil {
+		return ""
+	}
+	urls := make([]string, len(*f.brokers))
+	for i, broker := range *f.brokers {
+		urls[i] = broker.URL
+	}
+	return strings.Join(urls, ",")
+}
+
+func (f *brokersFlag) Set(value string) error {
+	var brokers []*mqtt.BrokerConfig
+	for _, brokerURL := range strings.Split(value, ",") {
+		brokerURL = strings.TrimSpace(brokerURL)
+		if brokerURL == "" {
+			continue
+		}
+		if _, err := url.Parse(brokerURL); err != nil {
+			return fmt.Errorf("invalid broker URL '%s'", brokerURL)
+		}
+		broker := &mqtt.BrokerConfig{URL: brokerURL}
+		for _, configured := range *f.brokers {
+			if configured != nil && configured.URL == brokerURL {
+				*broker = *configured
+			}
+		}
+		broker.Priority = len(brokers)
+		brokers = append(brokers, broker)
+	}
+	if len(brokers) > 0 {
+		*f.brokers = brokers
+	}
+	return nil
 }
 
 // ParseConfigFilePath returns the value for configuration file path if set.
@@ -71,40 +209,69 @@ func ParseConfigFilePath() string {
 
 // EnvToString check if an ENV variable is set and returns its value as a string. If not set, the default value is returned.
 func EnvToString(key string, value string) string {
-	envVal, ok := os.LookupEnv(key)
+	return processEnv().toString(key, value)
+}
+
+// EnvToBool check if an ENV variable is set and returns its value as a bool. If not set, the default value is returned.
+func EnvToBool(key string, value bool) bool {
+	return processEnv().toBool(key, value)
+}
+
+// EnvToInt check if an ENV variable is set and returns its value as an integer. If not set or value is not an integer, the default value is returned.
+func EnvToInt(key string, value int64) int64 {
+	return processEnv().toInt(key, value)
+}
+
+// flagEnv looks up the environment variables, which are used as default values of the flags, e.g. LOG_LEVEL for the log-level flag.
+// The used environment variables are reported to the output.
+type flagEnv struct {
+	lookup func(key string) (string, bool)
+	output io.Writer
+}
+
+// processEnv returns the lookup of the environment variables of the process.
+func processEnv() flagEnv {
+	return flagEnv{lookup: os.LookupEnv, output: os.Stdout}
+}
+
+// noEnv returns a lookup, which does not find any environment variable, so that the flags default to the configured values.
+func noEnv() flagEnv {
+	return flagEnv{lookup: func(string) (string, bool) { return "", false }, output: io.Discard}
+}
+
+func (env flagEnv) toString(key string, value string) string {
+	envVal, ok := env.lookup(key)
 	if !ok {
 		return value
 	}
-	fmt.Printf("using ENV variable %s with value %s\n", key, envVal)
+	fmt.Fprintf(env.output, "using ENV variable %s with value %s\n", key, envVal)
 	return envVal
 }
 
-// EnvToBool check if an ENV variable is set and returns its value as a bool. If not set, the default value is returned.
-func EnvToBool(key string, value bool) bool {
-	envVal, ok := os.LookupEnv(key)
+func (env flagEnv) toBool(key string, value bool) bool {
+	envVal, ok := env.lookup(key)
 	if !ok {
 		return value
 	}
 	boolVal, err := strconv.ParseBool(envVal)
 	if err != nil {
-		fmt.Printf("cannot use ENV variable %s with value %s\n", key, envVal)
+		fmt.Fprintf(env.output, "cannot use ENV variable %s with value %s\n", key, envVal)
 		return value
 	}
-	fmt.Printf("using ENV variable %s with value %s\n", key, envVal)
+	fmt.Fprintf(env.output, "using ENV variable %s with value %s\n", key, envVal)
 	return boolVal
 }
 
-// EnvToInt check if an ENV variable is set and returns its value as an integer. If not set or value is not an integer, the default value is returned.
-func EnvToInt(key string, value int64) int64 {
-	envVal, ok := os.LookupEnv(key)
+func (env flagEnv) toInt(key string, value int64) int64 {
+	envVal, ok := env.lookup(key)
 	if !ok {
 		return value
 	}
 	intVal, err := strconv.ParseInt(envVal, 10, 0)
 	if err != nil {
-		fmt.Printf("cannot use ENV variable %s with value %s\n", key, envVal)
+		fmt.Fprintf(env.output, "cannot use ENV variable %s with value %s\n", key, envVal)
 		return value
 	}
-	fmt.Printf("using ENV variable %s with value %s\n", key, envVal)
+	fmt.Fprintf(env.output, "using ENV variable %s with value %s\n", key, envVal)
 	return intVal
 }
@@ -29,34 +29,78 @@ const (
 	domainsDesc                = "Specify a comma-separated list of domains handled by the update manager"
 	ownerConsentCommandsFlagID = "owner-consent-commands"
 	ownerConsentCommandsDesc   = "Specify a comma-separated list of commands, before which an owner consent should be granted. Possible values are: 'download', 'update', 'activate'"
+	configDirDesc              = "Specify the drop-in directory with JSON or YAML configuration files, which are merged in alphabetical order over the configuration file. Defaults to the 'config.d' directory next to the configuration file"
+	printConfigFlagID          = "print-config"
+	validateConfigFlagID       = "validate-config"
 )
 
 // SetupAllUpdateManagerFlags adds all flags for the configuration of the update manager
 func SetupAllUpdateManagerFlags(flagSet *flag.FlagSet, cfg *Config) {
-	SetupFlags(flagSet, cfg.BaseConfig)
-
-	flagSet.String(domainsFlagID, "", domainsDesc)
+	setupUpdateManagerFlags(flagSet, cfg, processEnv())
+	setupAgentsConfigFlags(flagSet, cfg, processEnv())
+}
 
-	flagSet.BoolVar(&cfg.RebootEnabled, "reboot-enabled", EnvToBool("REBOOT_ENABLED", cfg.RebootEnabled), "Specify a flag that controls the enabling/disabling of the reboot process after successful update operation")
-	flagSet.StringVar(&cfg.RebootAfter, "reboot-after", EnvToString("REBOOT_AFTER", cfg.RebootAfter), "Specify the timeout in cron format to wait before a reboot process is initiated after successful update operation. Value should be a positive integer number followed by a unit suffix, such as '60s', '10m', etc")
+// setupUpdateManagerFlags adds the flags for the configuration of the update manager, except the ones of the domain agents.
+func setupUpdateManagerFlags(flagSet *flag.FlagSet, cfg *Config, env flagEnv) {
+	setupFlags(flagSet, cfg.BaseConfig, env)
 
-	flagSet.StringVar(&cfg.PhaseTimeout, "phase-timeout", EnvToString("PHASE_TIMEOUT", cfg.PhaseTimeout), "Specify the timeout for completing an Update Orchestration phase. Value should be a positive integer number followed by a unit suffix, such as '60s', '10m', etc")
-	flagSet.StringVar(&cfg.ReportFeedbackInterval, "report-feedback-interval", EnvToString("REPORT_FEEDBACK_INTERVAL", cfg.ReportFeedbackInterval), "Specify the time interval for reporting intermediate desired state feedback messages during an active update operation. Value should be a positive integer number followed by a unit suffix, such as '60s', '10m', etc")
-	flagSet.StringVar(&cfg.CurrentStateDelay, "current-state-delay", EnvToString("CURRENT_STATE_DELAY", cfg.CurrentStateDelay), "Specify the time delay for reporting current state messages. Value should be a positive integer number followed by a unit suffix, such as '60s', '10m', etc")
-	flagSet.StringVar(&cfg.OwnerConsentTimeout, "owner-consent-timeout", EnvToString("OWNER_CONSENT_TIMEOUT", cfg.OwnerConsentTimeout), "Specify the timeout to wait for owner consent. Value should be a positive integer number followed by a unit suffix, such as '60s', '10m', etc")
-	setupAgentsConfigFlags(flagSet, cfg)
+	flagSet.String(domainsFlagID, "", domainsDesc)
+	flagSet.String(configDirFlagID, "", configDirDesc)
+
+	flagSet.BoolVar(&cfg.RebootEnabled, "reboot-enabled", env.toBool("REBOOT_ENABLED", cfg.RebootEnabled), "Specify a flag that controls the enabling/disabling of the reboot process after successful update operation")
+	flagSet.StringVar(&cfg.RebootAfter, "reboot-after", env.toString("REBOOT_AFTER", cfg.RebootAfter), "Specify the timeout in cron format to wait before a reboot process is initiated after successful update operation. Value should be a positive integer number followed by a unit suffix, such as '60s', '10m', etc")
+
+	flagSet.StringVar(&cfg.PhaseTimeout, "phase-timeout", env.toString("PHASE_TIMEOUT", cfg.PhaseTimeout), "Specify the timeout for completing an Update Orchestration phase. Value should be a positive integer number followed by a unit suffix, such as '60s', '10m', etc")
+	flagSet.BoolVar(&cfg.PhaseTimeoutPause, "phase-timeout-pause", env.toBool("PHASE_TIMEOUT_PAUSE", cfg.PhaseTimeoutPause), "Specify a flag that controls the pausing of the Update Orchestration phase timeout while the connection to the MQTT broker is not established")
+	flagSet.StringVar(&cfg.ReportFeedbackInterval, "report-feedback-interval", env.toString("REPORT_FEEDBACK_INTERVAL", cfg.ReportFeedbackInterval), "Specify the time interval for reporting intermediate desired state feedback messages during an active update operation. Value should be a positive integer number followed by a unit suffix, such as '60s', '10m', etc")
+	flagSet.StringVar(&cfg.CurrentStateDelay, "current-state-delay", env.toString("CURRENT_STATE_DELAY", cfg.CurrentStateDelay), "Specify the time delay for reporting current state messages. Value should be a positive integer number followed by a unit suffix, such as '60s', '10m', etc")
+	flagSet.StringVar(&cfg.OwnerConsentTimeout, "owner-consent-timeout", env.toString("OWNER_CONSENT_TIMEOUT", cfg.OwnerConsentTimeout), "Specify the timeout to wait for owner consent. Value should be a positive integer number followed by a unit suffix, such as '60s', '10m', etc")
+	flagSet.StringVar(&cfg.HTTP.Address, "http-address", env.toString("HTTP_ADDRESS", cfg.HTTP.Address), "Specify the address of the local HTTP API, either a unix socket in the format 'unix:///path/to/socket' or a 'host:port' address. The local HTTP API is disabled if not set")
+	flagSet.StringVar(&cfg.HTTP.ReadTimeout, "http-read-timeout", env.toString("HTTP_READ_TIMEOUT", cfg.HTTP.ReadTimeout), "Specify the timeout for reading a local HTTP API request. Value should be a positive integer number followed by a unit suffix, such as '60s', '10m', etc")
+	flagSet.StringVar(&cfg.HTTP.WriteTimeout, "http-write-timeout", env.toString("HTTP_WRITE_TIMEOUT", cfg.HTTP.WriteTimeout), "Specify the timeout for writing a local HTTP API response. Value should be a positive integer number followed by a unit suffix, such as '60s', '10m', etc")
+	flagSet.StringVar(&cfg.History.File, "history-file", env.toString("HISTORY_FILE", cfg.History.File), "Specify the file, where the activity history is stored. The activity history is kept only in memory if not set")
+	flagSet.IntVar(&cfg.History.FileSize, "history-file-size", int(env.toInt("HISTORY_FILE_SIZE", int64(cfg.History.FileSize))), "Specify the maximum size in megabytes of the activity history file before it gets rotated")
+	flagSet.IntVar(&cfg.History.FileCount, "history-file-count", int(env.toInt("HISTORY_FILE_COUNT", int64(cfg.History.FileCount))), "Specify the maximum number of old activity history files to retain")
+	flagSet.IntVar(&cfg.History.FileMaxAge, "history-file-max-age", int(env.toInt("HISTORY_FILE_MAX_AGE", int64(cfg.History.FileMaxAge))), "Specify the maximum number of days to retain old activity history files based on the timestamp encoded in their filename")
+	flagSet.StringVar(&cfg.Signature.CACert, "signature-ca-cert", env.toString("SIGNATURE_CA_CERT", cfg.Signature.CACert), "Specify the PEM encoded CA certificates file, used to verify the signature of the desired state. Unsigned desired states are rejected if set")
+	flagSet.StringVar(&cfg.Secrets.KeyFile, "secrets-key-file", env.toString("SECRETS_KEY_FILE", cfg.Secrets.KeyFile), "Specify the PEM encoded RSA private key file of the device, used to decrypt the encrypted secret configuration values of the desired state before they are forwarded to the domain agent")
+	flagSet.StringVar(&cfg.Secrets.StoreDir, "secrets-store-dir", env.toString("SECRETS_STORE_DIR", cfg.Secrets.StoreDir), "Specify the directory of the local secret store, which holds a file per secret referenced by the secret configuration values of the desired state")
+	flagSet.StringVar(&cfg.Secrets.KeysFile, "secrets-keys-file", env.toString("SECRETS_KEYS_FILE", cfg.Secrets.KeysFile), "Specify the file, which keeps the configuration keys of the secret values of the installed components across restarts, so that the parameters reported with these keys are redacted in the current state. If not set, the keys are kept only in memory")
+	flagSet.BoolVar(&cfg.Replay.Enabled, "replay-enabled", env.toBool("REPLAY_ENABLED", cfg.Replay.Enabled), "Specify a flag that controls the enabling/disabling of the protection against replayed desired states, based on their timestamp and activity ID")
+	flagSet.StringVar(&cfg.Replay.MaxAge, "replay-max-age", env.toString("REPLAY_MAX_AGE", cfg.Replay.MaxAge), "Specify the maximum age of an accepted desired state, based on its timestamp. Value should be a positive integer number followed by a unit suffix, such as '60s', '10m', etc or '0' to disable the age check")
+	flagSet.StringVar(&cfg.Replay.File, "replay-file", env.toString("REPLAY_FILE", cfg.Replay.File), "Specify the file, where the last accepted timestamp and the recently seen activity IDs are stored. The replay protection state is kept only in memory if not set")
+	flagSet.IntVar(&cfg.Replay.ActivityIDs, "replay-activity-ids", int(env.toInt("REPLAY_ACTIVITY_IDS", int64(cfg.Replay.ActivityIDs))), "Specify the number of recently seen activity IDs, which are rejected if received again")
+	flagSet.BoolVar(&cfg.Replay.AntiRollback, "anti-rollback", env.toBool("ANTI_ROLLBACK", cfg.Replay.AntiRollback), "Specify a flag that controls the enabling/disabling of the rejection of desired states with component versions lower than the installed ones, unless explicitly allowed with the 'allowRollback' domain or component configuration")
+	flagSet.BoolVar(&cfg.Queue.Enabled, "queue-enabled", env.toBool("QUEUE_ENABLED", cfg.Queue.Enabled), "Specify a flag that controls the enabling/disabling of the outbound queue, which keeps the current state and desired state feedback messages until they are successfully sent, e.g. while the MQTT broker is not reachable")
+	flagSet.StringVar(&cfg.Queue.File, "queue-file", env.toString("QUEUE_FILE", cfg.Queue.File), "Specify the file, where the outbound queue is stored, so that the queued messages are kept after restart. The outbound queue is kept only in memory if not set")
+	flagSet.IntVar(&cfg.Queue.MaxMessages, "queue-max-messages", int(env.toInt("QUEUE_MAX_MESSAGES", int64(cfg.Queue.MaxMessages))), "Specify the maximum number of messages in the outbound queue, the oldest non-terminal messages are dropped if exceeded")
+	flagSet.StringVar(&cfg.ArtifactCache.Dir, "artifact-cache-dir", env.toString("ARTIFACT_CACHE_DIR", cfg.ArtifactCache.Dir), "Specify the directory of the artifact cache, which is shared between the domain agents through the local HTTP API. The artifact cache is disabled if not set")
+	flagSet.IntVar(&cfg.ArtifactCache.MaxSize, "artifact-cache-max-size", int(env.toInt("ARTIFACT_CACHE_MAX_SIZE", int64(cfg.ArtifactCache.MaxSize))), "Specify the maximum size in megabytes of the artifact cache, the least recently used artifacts are evicted if exceeded")
+	flagSet.StringVar(&cfg.ArtifactCache.ReservationTimeout, "artifact-cache-reservation-timeout", env.toString("ARTIFACT_CACHE_RESERVATION_TIMEOUT", cfg.ArtifactCache.ReservationTimeout), "Specify the timeout, after which a reservation in the artifact cache is released if not committed. Value should be a positive integer number followed by a unit suffix, such as '60s', '10m', etc")
+	flagSet.BoolVar(&cfg.NamespaceNodeIDs, "namespace-node-ids", env.toBool("NAMESPACE_NODE_IDS", cfg.NamespaceNodeIDs), "Specify a flag that controls the prefixing of the inventory node IDs of each domain with the domain name in the reported current state, so that the node IDs of different domains do not collide. The original node IDs are kept in the 'originalId' node parameter")
+	flagSet.StringVar(&cfg.ConfigWatchInterval, "config-watch-interval", env.toString("CONFIG_WATCH_INTERVAL", cfg.ConfigWatchInterval), "Specify the interval for checking the configuration file for changes, the changed configuration is reloaded without restart. Value should be a positive integer number followed by a unit suffix, such as '60s', '10m', etc. If not set, the configuration is reloaded only on SIGHUP")
 }
 
-func parseFlags(cfg *Config, version string) {
+// parseFlags parses the flags into the given configuration and returns if only the configuration should be validated.
+func parseFlags(cfg *Config, version string) bool {
 	domains := parseDomainsFlag()
 	prepareAgentsConfig(cfg, domains)
+	return parseConfigFlags(cfg, version)
+}
 
+// parseConfigFlags parses the command line flags into the given configuration, which agents configuration is already prepared.
+// It returns true if the configuration shall be only validated.
+func parseConfigFlags(cfg *Config, version string) bool {
 	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
 	flagSet := flag.CommandLine
 
-	SetupAllUpdateManagerFlags(flagSet, cfg)
+	// the environment variables are already applied to the configuration, so the flags default to the configured values
+	setupUpdateManagerFlags(flagSet, cfg, noEnv())
+	setupAgentsConfigFlags(flagSet, cfg, noEnv())
 
 	fVersion := flagSet.Bool("version", false, "Prints current version and exits")
+	fPrintConfig := flagSet.Bool(printConfigFlagID, false, "Prints the effective configuration with redacted secrets and exits")
+	fValidateConfig := flagSet.Bool(validateConfigFlagID, false, "Validates the configuration, prints all found problems and exits with non-zero exit code if the configuration is invalid")
 	listCommands := flagSet.String(ownerConsentCommandsFlagID, "", ownerConsentCommandsDesc)
 	if err := flagSet.Parse(os.Args[1:]); err != nil {
 		logger.ErrorErr(err, "Cannot parse command flags")
@@ -70,6 +114,17 @@ func parseFlags(cfg *Config, version string) {
 	if len(*listCommands) != 0 {
 		cfg.OwnerConsentCommands = parseOwnerConsentCommandsFlag(*listCommands)
 	}
+
+	if *fPrintConfig {
+		data, err := Redacted(cfg)
+		if err != nil {
+			logger.ErrorErr(err, "Cannot print the configuration")
+			os.Exit(1)
+		}
+		fmt.Println(string(data))
+		os.Exit(0)
+	}
+	return *fValidateConfig
 }
 
 func parseOwnerConsentCommandsFlag(listCommands string) []types.CommandType {
@@ -120,8 +175,11 @@ func getFlagArgs(flag string) []string {
 	return []string{}
 }
 
-func setupAgentsConfigFlags(flagSet *flag.FlagSet, cfg *Config) {
+func setupAgentsConfigFlags(flagSet *flag.FlagSet, cfg *Config, env flagEnv) {
 	for _, agent := range cfg.Agents {
+		if agent == nil {
+			continue
+		}
 		rr := fmt.Sprintf("%s-reboot-required", agent.Name)
 		rto := fmt.Sprintf("%s-read-timeout", agent.Name)
 		rrEV := fmt.Sprintf("%s_REBOOT_REQUIRED", strings.ReplaceAll(strings.ToUpper(agent.Name), "-", "_"))
@@ -133,7 +191,7 @@ func setupAgentsConfigFlags(flagSet *flag.FlagSet, cfg *Config) {
 			rtoDef = readTimeoutDefault
 		}
 
-		flagSet.BoolVar(&agent.RebootRequired, rr, EnvToBool(rrEV, rrDef), "Specify the reboot required flag for the given domain.")
-		flagSet.StringVar(&agent.ReadTimeout, rto, EnvToString(rtoEV, rtoDef), "Specify the read timeout for the given domain. Value should be a positive integer number followed by a unit suffix, such as '60s', '10m', etc")
+		flagSet.BoolVar(&agent.RebootRequired, rr, env.toBool(rrEV, rrDef), "Specify the reboot required flag for the given domain.")
+		flagSet.StringVar(&agent.ReadTimeout, rto, env.toString(rtoEV, rtoDef), "Specify the read timeout for the given domain. Value should be a positive integer number followed by a unit suffix, such as '60s', '10m', etc")
 	}
 }
@@ -22,6 +22,7 @@ import (
 
 	"github.com/eclipse-kanto/update-manager/api"
 	"github.com/eclipse-kanto/update-manager/api/types"
+	"github.com/eclipse-kanto/update-manager/mqtt"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -55,6 +56,14 @@ func TestSetupFlags(t *testing.T) {
 			flag:         "log-file-max-age",
 			expectedType: reflect.Int.String(),
 		},
+		"test_flags_log_format": {
+			flag:         "log-format",
+			expectedType: reflect.String.String(),
+		},
+		"test_flags_log_syslog": {
+			flag:         "log-syslog",
+			expectedType: reflect.Bool.String(),
+		},
 		"test_flags_mqtt_conn_broker": {
 			flag:         "mqtt-conn-broker",
 			expectedType: reflect.String.String(),
@@ -103,6 +112,58 @@ func TestSetupFlags(t *testing.T) {
 			flag:         "mqtt-conn-key",
 			expectedType: reflect.String.String(),
 		},
+		"test_flags_mqtt-conn-key-passphrase-file": {
+			flag:         "mqtt-conn-key-passphrase-file",
+			expectedType: reflect.String.String(),
+		},
+		"test_flags_mqtt-conn-crl-file": {
+			flag:         "mqtt-conn-crl-file",
+			expectedType: reflect.String.String(),
+		},
+		"test_flags_mqtt-conn-server-name": {
+			flag:         "mqtt-conn-server-name",
+			expectedType: reflect.String.String(),
+		},
+		"test_flags_mqtt-conn-min-tls-version": {
+			flag:         "mqtt-conn-min-tls-version",
+			expectedType: reflect.String.String(),
+		},
+		"test_flags_mqtt-conn-cipher-suites": {
+			flag:         "mqtt-conn-cipher-suites",
+			expectedType: "names",
+		},
+		"test_flags_mqtt-conn-max-reconnect-interval": {
+			flag:         "mqtt-conn-max-reconnect-interval",
+			expectedType: reflect.String.String(),
+		},
+		"test_flags_mqtt-conn-brokers": {
+			flag:         "mqtt-conn-brokers",
+			expectedType: "urls",
+		},
+		"test_flags_mqtt-conn-failback-interval": {
+			flag:         "mqtt-conn-failback-interval",
+			expectedType: reflect.String.String(),
+		},
+		"test_flags_mqtt-conn-protocol-version": {
+			flag:         "mqtt-conn-protocol-version",
+			expectedType: reflect.String.String(),
+		},
+		"test_flags_mqtt-conn-shared-subscription-group": {
+			flag:         "mqtt-conn-shared-subscription-group",
+			expectedType: reflect.String.String(),
+		},
+		"test_flags_mqtt-conn-message-expiry": {
+			flag:         "mqtt-conn-message-expiry",
+			expectedType: reflect.String.String(),
+		},
+		"test_flags_mqtt-conn-topic-prefix": {
+			flag:         "mqtt-conn-topic-prefix",
+			expectedType: reflect.String.String(),
+		},
+		"test_flags_mqtt-conn-topic-variables": {
+			flag:         "mqtt-conn-topic-variables",
+			expectedType: "key=value",
+		},
 		"test_flags_domain": {
 			flag:         "domain",
 			expectedType: reflect.String.String(),
@@ -131,10 +192,98 @@ func TestSetupFlags(t *testing.T) {
 			flag:         "phase-timeout",
 			expectedType: reflect.String.String(),
 		},
+		"test_flags_phase_timeout_pause": {
+			flag:         "phase-timeout-pause",
+			expectedType: reflect.Bool.String(),
+		},
 		"test_flags_owner_consent_timeout": {
 			flag:         "owner-consent-timeout",
 			expectedType: reflect.String.String(),
 		},
+		"test_flags_http_address": {
+			flag:         "http-address",
+			expectedType: reflect.String.String(),
+		},
+		"test_flags_http_read_timeout": {
+			flag:         "http-read-timeout",
+			expectedType: reflect.String.String(),
+		},
+		"test_flags_http_write_timeout": {
+			flag:         "http-write-timeout",
+			expectedType: reflect.String.String(),
+		},
+		"test_flags_history_file": {
+			flag:         "history-file",
+			expectedType: reflect.String.String(),
+		},
+		"test_flags_history_file_size": {
+			flag:         "history-file-size",
+			expectedType: reflect.Int.String(),
+		},
+		"test_flags_history_file_count": {
+			flag:         "history-file-count",
+			expectedType: reflect.Int.String(),
+		},
+		"test_flags_history_file_max_age": {
+			flag:         "history-file-max-age",
+			expectedType: reflect.Int.String(),
+		},
+		"test_flags_signature_ca_cert": {
+			flag:         "signature-ca-cert",
+			expectedType: reflect.String.String(),
+		},
+		"test_flags_secrets_key_file": {
+			flag:         "secrets-key-file",
+			expectedType: reflect.String.String(),
+		},
+		"test_flags_secrets_store_dir": {
+			flag:         "secrets-store-dir",
+			expectedType: reflect.String.String(),
+		},
+		"test_flags_replay_enabled": {
+			flag:         "replay-enabled",
+			expectedType: reflect.Bool.String(),
+		},
+		"test_flags_replay_max_age": {
+			flag:         "replay-max-age",
+			expectedType: reflect.String.String(),
+		},
+		"test_flags_replay_file": {
+			flag:         "replay-file",
+			expectedType: reflect.String.String(),
+		},
+		"test_flags_replay_activity_ids": {
+			flag:         "replay-activity-ids",
+			expectedType: reflect.Int.String(),
+		},
+		"test_flags_anti_rollback": {
+			flag:         "anti-rollback",
+			expectedType: reflect.Bool.String(),
+		},
+		"test_flags_queue_enabled": {
+			flag:         "queue-enabled",
+			expectedType: reflect.Bool.String(),
+		},
+		"test_flags_queue_file": {
+			flag:         "queue-file",
+			expectedType: reflect.String.String(),
+		},
+		"test_flags_queue_max_messages": {
+			flag:         "queue-max-messages",
+			expectedType: reflect.Int.String(),
+		},
+		"test_flags_namespace_node_ids": {
+			flag:         "namespace-node-ids",
+			expectedType: reflect.Bool.String(),
+		},
+		"test_flags_config_watch_interval": {
+			flag:         "config-watch-interval",
+			expectedType: reflect.String.String(),
+		},
+		"test_flags_config_dir": {
+			flag:         "config-dir",
+			expectedType: reflect.String.String(),
+		},
 	}
 	for testName, testCase := range tests {
 		t.Run(testName, func(t *testing.T) {
@@ -278,6 +427,52 @@ func TestParseOwnerConsentCommandsFlag(t *testing.T) {
 	})
 }
 
+func TestKeyValueFlag(t *testing.T) {
+	var values map[string]string
+	f := &keyValueFlag{values: &values}
+	assert.Equal(t, "", f.String())
+
+	assert.NoError(t, f.Set(""))
+	assert.Nil(t, values)
+	assert.NoError(t, f.Set("tenant=test-tenant, device = test-device,"))
+	assert.Equal(t, map[string]string{"tenant": "test-tenant", "device": "test-device"}, values)
+	assert.Equal(t, "device=test-device,tenant=test-tenant", f.String())
+	assert.NoError(t, f.Set("device="))
+	assert.Equal(t, "", values["device"])
+
+	assert.EqualError(t, f.Set("tenant"), "invalid key=value pair 'tenant'")
+	assert.EqualError(t, f.Set("=value"), "invalid key=value pair '=value'")
+}
+
+func TestListFlag(t *testing.T) {
+	var values []string
+	f := &listFlag{values: &values}
+	assert.Equal(t, "", f.String())
+
+	assert.NoError(t, f.Set(" , "))
+	assert.Nil(t, values)
+	assert.NoError(t, f.Set("a, b,"))
+	assert.Equal(t, []string{"a", "b"}, values)
+	assert.Equal(t, "a,b", f.String())
+}
+
+func TestBrokersFlag(t *testing.T) {
+	brokers := []*mqtt.BrokerConfig{{URL: "ssl://local:8883", Priority: 5, CACert: "ca.crt"}}
+	f := &brokersFlag{brokers: &brokers}
+	assert.Equal(t, "ssl://local:8883", f.String())
+
+	assert.NoError(t, f.Set(""))
+	assert.Equal(t, 1, len(brokers))
+	assert.NoError(t, f.Set("tcp://fallback:1883, ssl://local:8883,"))
+	assert.Equal(t, []*mqtt.BrokerConfig{
+		{URL: "tcp://fallback:1883", Priority: 0},
+		{URL: "ssl://local:8883", Priority: 1, CACert: "ca.crt"},
+	}, brokers)
+	assert.Equal(t, "tcp://fallback:1883,ssl://local:8883", f.String())
+
+	assert.EqualError(t, f.Set("://local"), "invalid broker URL '://local'")
+}
+
 func TestParseFlags(t *testing.T) {
 	testVersion := "testVersion"
 	t.Run("test_config_agent_not_configured_with_domain_specific_flags", func(t *testing.T) {
@@ -295,6 +490,7 @@ func TestParseFlags(t *testing.T) {
 				Name:           "containers",
 				RebootRequired: true,
 				ReadTimeout:    "30s",
+				Topics:         map[string]string{"desiredState": "legacy/containers/desiredstate"},
 			},
 			"test-domain": {
 				Name:           "test-domain",
@@ -373,6 +569,7 @@ func TestParseFlags(t *testing.T) {
 				Name:           "containers",
 				RebootRequired: true,
 				ReadTimeout:    "30s",
+				Topics:         map[string]string{"desiredState": "legacy/containers/desiredstate"},
 			},
 			"test-domain": {
 				Name:           "test-domain",
@@ -13,12 +13,15 @@
 package logger
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"log"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	"gopkg.in/natefinch/lumberjack.v2"
 )
@@ -30,6 +33,10 @@ type LogConfig struct {
 	LogFileSize   int    `json:"logFileSize,omitempty"`
 	LogFileCount  int    `json:"logFileCount,omitempty"`
 	LogFileMaxAge int    `json:"logFileMaxAge,omitempty"`
+	// LogFormat is the format of the log messages, either text or json
+	LogFormat string `json:"logFormat,omitempty"`
+	// LogSyslog enables sending the log messages also to the local syslog, e.g. journald
+	LogSyslog bool `json:"logSyslog,omitempty"`
 }
 
 // LogLevel - Error(1), Warn(2), Info(3), Debug(4) or Trace(5)
@@ -44,6 +51,14 @@ const (
 	TRACE
 )
 
+// Constants for log format
+const (
+	// LogFormatText is the default format of the log messages, the context fields are added to the message text
+	LogFormatText = "text"
+	// LogFormatJSON formats each log message as a JSON object with the context fields as separate properties
+	LogFormatJSON = "json"
+)
+
 const (
 	logFlags int = log.Ldate | log.Ltime | log.Lmicroseconds | log.Lmsgprefix
 
@@ -54,15 +69,63 @@ const (
 	tPrefix = "TRACE  "
 
 	prefix = " %s "
+
+	jsonTimeFormat = "2006-01-02T15:04:05.000000Z07:00"
 )
 
 var (
 	logger *log.Logger
-	level  LogLevel
+	// level holds the LogLevel, it is accessed atomically as it can be changed at runtime
+	level int32
+
+	jsonFormat bool
+	component  string
+	sink       syslogSink
+
+	levelPrefixes = map[LogLevel]string{ERROR: ePrefix, WARN: wPrefix, INFO: iPrefix, DEBUG: dPrefix, TRACE: tPrefix}
+	levelNames    = map[LogLevel]string{ERROR: "ERROR", WARN: "WARN", INFO: "INFO", DEBUG: "DEBUG", TRACE: "TRACE"}
 )
 
+// Fields holds the context of a log message, i.e. the domain, the update activity and its phase.
+type Fields struct {
+	Domain     string
+	ActivityID string
+	Phase      string
+}
+
+// ContextLogger logs messages with the context fields it is created with.
+type ContextLogger struct {
+	fields Fields
+}
+
+// WithFields returns a logger, which adds the given context fields to each log message.
+func WithFields(fields Fields) *ContextLogger {
+	return &ContextLogger{fields: fields}
+}
+
+type record struct {
+	Time       string `json:"time"`
+	Level      string `json:"level"`
+	Component  string `json:"component,omitempty"`
+	Domain     string `json:"domain,omitempty"`
+	ActivityID string `json:"activityId,omitempty"`
+	Phase      string `json:"phase,omitempty"`
+	Message    string `json:"message"`
+	Error      string `json:"error,omitempty"`
+}
+
 // SetupLogger initializes logger with the provided configuration
 func SetupLogger(logConfig *LogConfig, componentPrefix string) (io.WriteCloser, error) {
+	switch strings.ToLower(logConfig.LogFormat) {
+	case "", LogFormatText:
+		jsonFormat = false
+	case LogFormatJSON:
+		jsonFormat = true
+	default:
+		return nil, fmt.Errorf("unsupported log format %s, the supported values are: %s, %s", logConfig.LogFormat, LogFormatText, LogFormatJSON)
+	}
+	component = strings.Trim(componentPrefix, "[] ")
+
 	loggerOut := io.WriteCloser(&nopWriterCloser{out: os.Stderr})
 	if len(logConfig.LogFile) > 0 {
 		err := os.MkdirAll(filepath.Dir(logConfig.LogFile), 0755)
@@ -81,106 +144,223 @@ func SetupLogger(logConfig *LogConfig, componentPrefix string) (io.WriteCloser,
 		}
 	}
 
+	sink = nil
+	if logConfig.LogSyslog {
+		syslogOut, err := newSyslogSink(component)
+		if err != nil {
+			return nil, err
+		}
+		sink = syslogOut
+		loggerOut = &syslogCloser{WriteCloser: loggerOut, sink: syslogOut}
+	}
+
 	log.SetOutput(loggerOut)
 	log.SetFlags(logFlags)
 
-	logger = log.New(loggerOut, fmt.Sprintf(prefix, componentPrefix), logFlags)
+	if jsonFormat {
+		logger = log.New(loggerOut, "", 0)
+	} else {
+		logger = log.New(loggerOut, fmt.Sprintf(prefix, componentPrefix), logFlags)
+	}
+
+	SetLogLevel(logConfig.LogLevel)
+
+	return loggerOut, nil
+}
 
-	// Parse log level
-	switch strings.ToUpper(logConfig.LogLevel) {
+// SetLogLevel changes the log level at runtime, the unknown values are treated as ERROR
+func SetLogLevel(logLevel string) {
+	atomic.StoreInt32(&level, int32(parseLogLevel(logLevel)))
+}
+
+func parseLogLevel(logLevel string) LogLevel {
+	switch strings.ToUpper(logLevel) {
 	case "INFO":
-		level = INFO
+		return INFO
 	case "WARN":
-		level = WARN
+		return WARN
 	case "DEBUG":
-		level = DEBUG
+		return DEBUG
 	case "TRACE":
-		level = TRACE
+		return TRACE
 	default:
-		level = ERROR
+		return ERROR
 	}
+}
 
-	return loggerOut, nil
+func currentLevel() LogLevel {
+	return LogLevel(atomic.LoadInt32(&level))
+}
+
+// output writes the formatted message with the given context fields and error, if the log level is >= the given one.
+func output(lvl LogLevel, fields *Fields, err error, format string, v ...interface{}) {
+	if currentLevel() < lvl || logger == nil {
+		return
+	}
+	message := fmt.Errorf(format, v...).Error()
+	var line string
+	if jsonFormat {
+		line = formatJSON(lvl, fields, err, message)
+		logger.Println(line)
+	} else {
+		line = formatText(fields, err, message)
+		logger.Println(levelPrefixes[lvl] + " " + line)
+	}
+	if sink != nil {
+		sink.write(lvl, line)
+	}
+}
+
+func formatText(fields *Fields, err error, message string) string {
+	var line strings.Builder
+	if fields != nil && fields.Domain != "" {
+		line.WriteString("[" + fields.Domain + "] ")
+	}
+	line.WriteString(message)
+	if err != nil {
+		line.WriteString(" " + err.Error())
+	}
+	if fields != nil && (fields.ActivityID != "" || fields.Phase != "") {
+		var context []string
+		if fields.ActivityID != "" {
+			context = append(context, "activityId="+fields.ActivityID)
+		}
+		if fields.Phase != "" {
+			context = append(context, "phase="+fields.Phase)
+		}
+		line.WriteString(" [" + strings.Join(context, " ") + "]")
+	}
+	return line.String()
+}
+
+func formatJSON(lvl LogLevel, fields *Fields, err error, message string) string {
+	entry := &record{
+		Time:      time.Now().Format(jsonTimeFormat),
+		Level:     levelNames[lvl],
+		Component: component,
+		Message:   message,
+	}
+	if fields != nil {
+		entry.Domain, entry.ActivityID, entry.Phase = fields.Domain, fields.ActivityID, fields.Phase
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	data, jsonErr := json.Marshal(entry)
+	if jsonErr != nil {
+		return fmt.Sprintf(`{"level":%q,"message":%q}`, entry.Level, message)
+	}
+	return string(data)
 }
 
 // Error logs the given formatted message and value, if level is >= ERROR
 func Error(format string, v ...interface{}) {
-	if level >= ERROR {
-		logger.Println(fmt.Errorf(fmt.Sprint(ePrefix, " ", format), v...))
-	}
+	output(ERROR, nil, nil, format, v...)
 }
 
 // ErrorErr logs the given value, formatted message and error, if level is >= ERROR
 func ErrorErr(err error, format string, v ...interface{}) {
-	if level >= ERROR {
-		logger.Println(fmt.Errorf(fmt.Sprint(ePrefix, " ", format, " ", err), v...))
-	}
+	output(ERROR, nil, err, format, v...)
 }
 
 // Warn logs the given formatted message and value, if level is >= WARN
 func Warn(format string, v ...interface{}) {
-	if level >= WARN {
-		logger.Printf(fmt.Sprint(wPrefix, " ", format), v...)
-	}
+	output(WARN, nil, nil, format, v...)
 }
 
 // WarnErr logs the given value, formatted message and error, if level is >= WARN
 func WarnErr(err error, format string, v ...interface{}) {
-	if level >= WARN {
-		logger.Println(fmt.Errorf(fmt.Sprint(wPrefix, " ", format, " ", err), v...))
-	}
+	output(WARN, nil, err, format, v...)
 }
 
 // Info logs the given formatted message and value, if level is >= INFO
 func Info(format string, v ...interface{}) {
-	if level >= INFO {
-		logger.Printf(fmt.Sprint(iPrefix, " ", format), v...)
-	}
+	output(INFO, nil, nil, format, v...)
 }
 
 // InfoErr logs the given value, formatted message and error, if level is >= INFO
 func InfoErr(err error, format string, v ...interface{}) {
-	if level >= INFO {
-		logger.Println(fmt.Errorf(fmt.Sprint(iPrefix, " ", format, " ", err), v...))
-	}
+	output(INFO, nil, err, format, v...)
 }
 
 // Debug logs the given formatted message and value, if level is >= DEBUG
 func Debug(format string, v ...interface{}) {
-	if IsDebugEnabled() {
-		logger.Printf(fmt.Sprint(dPrefix, " ", format), v...)
-	}
+	output(DEBUG, nil, nil, format, v...)
 }
 
 // DebugErr logs the given value, formatted message and error, if level is >= DEBUG
 func DebugErr(err error, format string, v ...interface{}) {
-	if IsDebugEnabled() {
-		logger.Println(fmt.Errorf(fmt.Sprint(dPrefix, " ", format, " ", err), v...))
-	}
+	output(DEBUG, nil, err, format, v...)
 }
 
 // Trace logs the given formatted message and value, if level is >= TRACE
 func Trace(format string, v ...interface{}) {
-	if IsTraceEnabled() {
-		logger.Printf(fmt.Sprint(tPrefix, " ", format), v...)
-	}
+	output(TRACE, nil, nil, format, v...)
 }
 
 // TraceErr logs the given value, formatted message and error, if level is >= TRACE
 func TraceErr(err error, format string, v ...interface{}) {
-	if IsTraceEnabled() {
-		logger.Println(fmt.Errorf(fmt.Sprint(tPrefix, " ", format, " ", err), v...))
-	}
+	output(TRACE, nil, err, format, v...)
+}
+
+// Error logs the given formatted message and value with the context fields, if level is >= ERROR
+func (l *ContextLogger) Error(format string, v ...interface{}) {
+	output(ERROR, &l.fields, nil, format, v...)
+}
+
+// ErrorErr logs the given value, formatted message and error with the context fields, if level is >= ERROR
+func (l *ContextLogger) ErrorErr(err error, format string, v ...interface{}) {
+	output(ERROR, &l.fields, err, format, v...)
+}
+
+// Warn logs the given formatted message and value with the context fields, if level is >= WARN
+func (l *ContextLogger) Warn(format string, v ...interface{}) {
+	output(WARN, &l.fields, nil, format, v...)
+}
+
+// WarnErr logs the given value, formatted message and error with the context fields, if level is >= WARN
+func (l *ContextLogger) WarnErr(err error, format string, v ...interface{}) {
+	output(WARN, &l.fields, err, format, v...)
+}
+
+// Info logs the given formatted message and value with the context fields, if level is >= INFO
+func (l *ContextLogger) Info(format string, v ...interface{}) {
+	output(INFO, &l.fields, nil, format, v...)
+}
+
+// InfoErr logs the given value, formatted message and error with the context fields, if level is >= INFO
+func (l *ContextLogger) InfoErr(err error, format string, v ...interface{}) {
+	output(INFO, &l.fields, err, format, v...)
+}
+
+// Debug logs the given formatted message and value with the context fields, if level is >= DEBUG
+func (l *ContextLogger) Debug(format string, v ...interface{}) {
+	output(DEBUG, &l.fields, nil, format, v...)
+}
+
+// DebugErr logs the given value, formatted message and error with the context fields, if level is >= DEBUG
+func (l *ContextLogger) DebugErr(err error, format string, v ...interface{}) {
+	output(DEBUG, &l.fields, err, format, v...)
+}
+
+// Trace logs the given formatted message and value with the context fields, if level is >= TRACE
+func (l *ContextLogger) Trace(format string, v ...interface{}) {
+	output(TRACE, &l.fields, nil, format, v...)
+}
+
+// TraceErr logs the given value, formatted message and error with the context fields, if level is >= TRACE
+func (l *ContextLogger) TraceErr(err error, format string, v ...interface{}) {
+	output(TRACE, &l.fields, err, format, v...)
 }
 
 // IsDebugEnabled returns true if log level is above DEBUG
 func IsDebugEnabled() bool {
-	return level >= DEBUG
+	return currentLevel() >= DEBUG
 }
 
 // IsTraceEnabled returns true if log level is above TRACE
 func IsTraceEnabled() bool {
-	return level >= TRACE
+	return currentLevel() >= TRACE
 }
 
 type nopWriterCloser struct {
@@ -196,3 +376,24 @@ func (w *nopWriterCloser) Write(p []byte) (n int, err error) {
 func (*nopWriterCloser) Close() error {
 	return nil
 }
+
+// syslogSink writes the log messages to the local syslog with the priority of their log level.
+type syslogSink interface {
+	io.Closer
+	write(lvl LogLevel, line string)
+}
+
+// syslogCloser closes the syslog sink together with the log output.
+type syslogCloser struct {
+	io.WriteCloser
+	sink syslogSink
+}
+
+// Close closes the log output and the syslog sink
+func (c *syslogCloser) Close() error {
+	sinkErr := c.sink.Close()
+	if err := c.WriteCloser.Close(); err != nil {
+		return err
+	}
+	return sinkErr
+}
@@ -14,12 +14,14 @@ package logger
 
 import (
 	"bufio"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 )
 
 // TestLogLevelError tests logger functions with log level set to ERROR.
@@ -47,6 +49,99 @@ func TestLogLevelTrace(t *testing.T) {
 	validate("TRACE", true, true, true, true, true, t)
 }
 
+// TestSetLogLevel tests changing the log level at runtime.
+func TestSetLogLevel(t *testing.T) {
+	loggerOut, _ := SetupLogger(&LogConfig{LogLevel: "ERROR"}, "[logger-test]")
+	defer loggerOut.Close()
+
+	if IsDebugEnabled() {
+		t.Fatal("debug enabled with log level ERROR")
+	}
+	SetLogLevel("debug")
+	if !IsDebugEnabled() || IsTraceEnabled() {
+		t.Fatal("debug not enabled with log level DEBUG")
+	}
+	SetLogLevel("unknown")
+	if currentLevel() != ERROR {
+		t.Fatalf("unexpected log level %d for unknown value", currentLevel())
+	}
+}
+
+// TestContextLogger tests logging with context fields in text format.
+func TestContextLogger(t *testing.T) {
+	dir := t.TempDir()
+	log := filepath.Join(dir, "context.log")
+	loggerOut, err := SetupLogger(&LogConfig{LogFile: log, LogLevel: "DEBUG", LogFormat: "text"}, "[logger-test]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer loggerOut.Close()
+
+	WithFields(Fields{Domain: "containers", ActivityID: "activity-1", Phase: "DOWNLOADING"}).ErrorErr(fmt.Errorf("testError"), "context log [%s]", "param1")
+	if !search(log, t, ePrefix, "[containers] context log [param1] testError [activityId=activity-1 phase=DOWNLOADING]") {
+		t.Error("context log entry not found")
+	}
+	WithFields(Fields{Domain: "containers"}).Debug("context log without activity")
+	if !search(log, t, dPrefix, "[containers] context log without activity") {
+		t.Error("context log entry without activity not found")
+	}
+	WithFields(Fields{ActivityID: "activity-2"}).Trace("context trace log")
+	if search(log, t, "context trace log") {
+		t.Error("unexpected trace log entry")
+	}
+}
+
+// TestJSONFormat tests logging in JSON format.
+func TestJSONFormat(t *testing.T) {
+	dir := t.TempDir()
+	log := filepath.Join(dir, "json.log")
+	loggerOut, err := SetupLogger(&LogConfig{LogFile: log, LogLevel: "INFO", LogFormat: "JSON"}, "[logger-test]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		loggerOut.Close()
+		SetupLogger(&LogConfig{}, "[logger-test]")
+	}()
+
+	Info("plain log %d", 1)
+	WithFields(Fields{Domain: "containers", ActivityID: "activity-1", Phase: "UPDATING"}).WarnErr(fmt.Errorf("testError"), "context log")
+	Debug("debug log")
+
+	data, err := os.ReadFile(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("unexpected number of log lines %d: %s", len(lines), data)
+	}
+	expected := []record{
+		{Level: "INFO", Component: "logger-test", Message: "plain log 1"},
+		{Level: "WARN", Component: "logger-test", Domain: "containers", ActivityID: "activity-1", Phase: "UPDATING", Message: "context log", Error: "testError"},
+	}
+	for i, line := range lines {
+		entry := record{}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("invalid JSON log line %s: %v", line, err)
+		}
+		if _, err := time.Parse(jsonTimeFormat, entry.Time); err != nil {
+			t.Errorf("invalid time in JSON log line %s: %v", line, err)
+		}
+		entry.Time = ""
+		if entry != expected[i] {
+			t.Errorf("unexpected JSON log line %s", line)
+		}
+	}
+}
+
+// TestUnsupportedFormat tests the logger setup with unsupported log format.
+func TestUnsupportedFormat(t *testing.T) {
+	if _, err := SetupLogger(&LogConfig{LogFormat: "xml"}, "[logger-test]"); err == nil {
+		t.Fatal("expected error for unsupported log format")
+	}
+}
+
 // TestNopWriter tests logger functions without writer.
 func TestNopWriter(t *testing.T) {
 	// Prepare
@@ -0,0 +1,50 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+//go:build !windows && !plan9
+// +build !windows,!plan9
+
+package logger
+
+import (
+	"log/syslog"
+)
+
+type syslogWriter struct {
+	writer *syslog.Writer
+}
+
+func newSyslogSink(tag string) (syslogSink, error) {
+	writer, err := syslog.New(syslog.LOG_INFO|syslog.LOG_DAEMON, tag)
+	if err != nil {
+		return nil, err
+	}
+	return &syslogWriter{writer: writer}, nil
+}
+
+func (w *syslogWriter) write(lvl LogLevel, line string) {
+	// the errors are ignored, as they cannot be logged
+	switch lvl {
+	case ERROR:
+		_ = w.writer.Err(line)
+	case WARN:
+		_ = w.writer.Warning(line)
+	case INFO:
+		_ = w.writer.Info(line)
+	default:
+		_ = w.writer.Debug(line)
+	}
+}
+
+func (w *syslogWriter) Close() error {
+	return w.writer.Close()
+}
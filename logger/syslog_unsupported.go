@@ -0,0 +1,22 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+//go:build windows || plan9
+// +build windows plan9
+
+package logger
+
+import "errors"
+
+func newSyslogSink(tag string) (syslogSink, error) {
+	return nil, errors.New("logging to syslog is not supported on this platform")
+}
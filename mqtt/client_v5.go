@@ -0,0 +1,441 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package mqtt
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/eclipse-kanto/update-manager/logger"
+
+	"github.com/eclipse/paho.golang/autopaho"
+	"github.com/eclipse/paho.golang/paho"
+	pahomqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+const (
+	// protocolVersion311 is the MQTT 3.1.1 protocol version, used by default
+	protocolVersion311 = "3.1.1"
+	// protocolVersion5 is the MQTT 5 protocol version
+	protocolVersion5 = "5"
+
+	userPropertyActivityID   = "activityId"
+	prefixSharedSubscription = "$share/"
+
+	connectRetryDelay = 10 * time.Second
+)
+
+// pahoV5Client adapts the MQTT 5 client to the MQTT 3.1.1 client interface, so that the same update agent, desired state
+// and owner consent clients could be used with both protocol versions. The activity ID of the published envelopes is
+// added as user property, response topic, correlation data and message expiry are supported via PublishWithProperties.
+type pahoV5Client struct {
+	config    *internalConnectionConfig
+	state     *connectionState
+	onConnect pahomqtt.OnConnectHandler
+
+	lock      sync.Mutex
+	connected bool
+	brokers   []*url.URL
+	// failedAttempts counts the failed connect attempts since the last connect, the brokers are tried in order
+	failedAttempts int
+	routes         map[string]pahomqtt.MessageHandler
+	connection     *autopaho.ConnectionManager
+	cancel         context.CancelFunc
+	clientCfg      autopaho.ClientConfig
+}
+
+func newClientV5(config *internalConnectionConfig, state *connectionState, onConnect pahomqtt.OnConnectHandler) (pahomqtt.Client, error) {
+	brokers := config.brokers()
+	urls, tlsConfigs, err := config.brokerURLs()
+	if err != nil {
+		return nil, err
+	}
+	client := &pahoV5Client{
+		config:    config,
+		state:     state,
+		onConnect: onConnect,
+		brokers:   urls,
+		routes:    map[string]pahomqtt.MessageHandler{},
+	}
+	client.clientCfg = autopaho.ClientConfig{
+		BrokerUrls:        urls,
+		KeepAlive:         uint16(config.KeepAlive.Seconds()),
+		ConnectRetryDelay: retryDelay(config.MaxReconnectInterval),
+		ConnectTimeout:    config.ConnectTimeout,
+		OnConnectionUp:    client.onConnectionUp,
+		OnConnectError:    client.onConnectError,
+		ClientConfig: paho.ClientConfig{
+			ClientID:      uuid.New().String(),
+			Router:        paho.NewSingleHandlerRouter(client.route),
+			OnClientError: client.onConnectionLost,
+			OnServerDisconnect: func(disconnect *paho.Disconnect) {
+				client.onConnectionLost(errors.Errorf("disconnected by the MQTT broker with reason code %d", disconnect.ReasonCode))
+			},
+		},
+	}
+	client.clientCfg.SetUsernamePassword(config.Username, []byte(config.Password))
+	var tlsBroker *BrokerConfig
+	for i, broker := range brokers {
+		if !IsConnectionSecure(urls[i].Scheme) {
+			continue
+		}
+		if tlsBroker == nil {
+			tlsBroker = broker
+			client.clientCfg.TlsCfg = tlsConfigs[urls[i].String()]
+		} else if !broker.sameTLSSettings(tlsBroker) {
+			return nil, errors.New("different TLS settings per broker are not supported with MQTT 5")
+		}
+	}
+	return client, nil
+}
+
+func (client *pahoV5Client) onConnectionUp(_ *autopaho.ConnectionManager, _ *paho.Connack) {
+	client.lock.Lock()
+	client.connected = true
+	broker := client.brokers[client.failedAttempts%len(client.brokers)].Redacted()
+	client.failedAttempts = 0
+	client.lock.Unlock()
+	logger.Debug("connected to MQTT broker '%s' using MQTT %s", broker, protocolVersion5)
+	client.state.connected(broker)
+	if client.onConnect != nil {
+		// the subscriptions cannot be completed within the connection up callback
+		go client.onConnect(client)
+	}
+}
+
+func (client *pahoV5Client) onConnectError(err error) {
+	client.lock.Lock()
+	client.failedAttempts++
+	client.lock.Unlock()
+	logger.WarnErr(err, "cannot connect to MQTT broker")
+	client.state.lost(err)
+}
+
+func (client *pahoV5Client) onConnectionLost(err error) {
+	client.lock.Lock()
+	client.connected = false
+	client.failedAttempts = 0
+	client.lock.Unlock()
+	logger.WarnErr(err, "connection to MQTT broker lost")
+	client.state.lost(err)
+}
+
+// retryDelay returns the delay between the connect attempts, which does not exceed the given maximum reconnect interval.
+func retryDelay(maxReconnectInterval time.Duration) time.Duration {
+	if maxReconnectInterval > 0 && maxReconnectInterval < connectRetryDelay {
+		return maxReconnectInterval
+	}
+	return connectRetryDelay
+}
+
+// IsConnected returns true if the client is connected to the MQTT broker.
+func (client *pahoV5Client) IsConnected() bool {
+	client.lock.Lock()
+	defer client.lock.Unlock()
+	return client.connected
+}
+
+// IsConnectionOpen returns true if the client is connected to the MQTT broker.
+func (client *pahoV5Client) IsConnectionOpen() bool {
+	return client.IsConnected()
+}
+
+// Connect starts connecting to the MQTT broker, reconnecting automatically if the connection is lost.
+// The returned token is completed once the connection is established.
+func (client *pahoV5Client) Connect() pahomqtt.Token {
+	client.lock.Lock()
+	defer client.lock.Unlock()
+
+	client.failedAttempts = 0
+	ctx, cancel := context.WithCancel(context.Background())
+	connection, err := autopaho.NewConnection(ctx, client.clientCfg)
+	if err != nil {
+		cancel()
+		return newToken(err)
+	}
+	client.connection = connection
+	client.cancel = cancel
+	return runToken(func() error {
+		return connection.AwaitConnection(ctx)
+	})
+}
+
+// Disconnect closes the connection to the MQTT broker, waiting at most the given quiesce time in milliseconds.
+func (client *pahoV5Client) Disconnect(quiesce uint) {
+	client.lock.Lock()
+	connection, cancel := client.connection, client.cancel
+	client.connection, client.cancel, client.connected = nil, nil, false
+	client.lock.Unlock()
+
+	if connection == nil {
+		return
+	}
+	ctx, cancelTimeout := context.WithTimeout(context.Background(), time.Duration(quiesce)*time.Millisecond)
+	defer cancelTimeout()
+	if err := connection.Disconnect(ctx); err != nil {
+		logger.WarnErr(err, "error disconnecting from MQTT broker '%s'", client.config.Broker)
+	}
+	cancel()
+}
+
+// Publish publishes the given payload, adding the activity ID of the envelope, if any, as user property.
+func (client *pahoV5Client) Publish(topic string, qos byte, retained bool, payload interface{}) pahomqtt.Token {
+	return client.PublishWithProperties(topic, qos, retained, toBytes(payload), nil)
+}
+
+// PublishWithProperties publishes the given payload with the given MQTT 5 properties, adding the activity ID of the envelope, if any, as user property.
+func (client *pahoV5Client) PublishWithProperties(topic string, qos byte, retained bool, payload []byte, properties *publishProperties) pahomqtt.Token {
+	publish := &paho.Publish{
+		Topic:      topic,
+		QoS:        qos,
+		Retain:     retained,
+		Payload:    payload,
+		Properties: &paho.PublishProperties{},
+	}
+	if activityID := envelopeActivityID(payload); activityID != "" {
+		publish.Properties.User.Add(userPropertyActivityID, activityID)
+	}
+	if properties != nil {
+		publish.Properties.ResponseTopic = properties.responseTopic
+		publish.Properties.CorrelationData = properties.correlationData
+		if properties.messageExpiry > 0 {
+			expiry := uint32(properties.messageExpiry.Seconds())
+			publish.Properties.MessageExpiry = &expiry
+		}
+	}
+	return client.run(func(ctx context.Context, connection *autopaho.ConnectionManager) error {
+		_, err := connection.Publish(ctx, publish)
+		return err
+	}, client.config.AcknowledgeTimeout)
+}
+
+// Subscribe subscribes for the given topic filter.
+func (client *pahoV5Client) Subscribe(topic string, qos byte, callback pahomqtt.MessageHandler) pahomqtt.Token {
+	return client.SubscribeMultiple(map[string]byte{topic: qos}, callback)
+}
+
+// SubscribeMultiple subscribes for the given topic filters.
+func (client *pahoV5Client) SubscribeMultiple(filters map[string]byte, callback pahomqtt.MessageHandler) pahomqtt.Token {
+	subscribe := &paho.Subscribe{Subscriptions: map[string]paho.SubscribeOptions{}}
+	for topic, qos := range filters {
+		subscribe.Subscriptions[topic] = paho.SubscribeOptions{QoS: qos}
+		client.AddRoute(topic, callback)
+	}
+	return client.run(func(ctx context.Context, connection *autopaho.ConnectionManager) error {
+		_, err := connection.Subscribe(ctx, subscribe)
+		return err
+	}, client.config.SubscribeTimeout)
+}
+
+// Unsubscribe unsubscribes from the given topic filters.
+func (client *pahoV5Client) Unsubscribe(topics ...string) pahomqtt.Token {
+	client.lock.Lock()
+	for _, topic := range topics {
+		delete(client.routes, topic)
+	}
+	client.lock.Unlock()
+	return client.run(func(ctx context.Context, connection *autopaho.ConnectionManager) error {
+		_, err := connection.Unsubscribe(ctx, &paho.Unsubscribe{Topics: topics})
+		return err
+	}, client.config.UnsubscribeTimeout)
+}
+
+// AddRoute sets the handler for the messages received on the given topic filter, replacing any previous one.
+func (client *pahoV5Client) AddRoute(topic string, callback pahomqtt.MessageHandler) {
+	client.lock.Lock()
+	defer client.lock.Unlock()
+	client.routes[topic] = callback
+}
+
+// route dispatches the received message to the handlers of the matching topic filters.
+// The handlers are invoked outside the lock, so that they could subscribe or unsubscribe.
+func (client *pahoV5Client) route(publish *paho.Publish) {
+	var handlers []pahomqtt.MessageHandler
+	client.lock.Lock()
+	for filter, handler := range client.routes {
+		if topicMatches(filter, publish.Topic) {
+			handlers = append(handlers, handler)
+		}
+	}
+	client.lock.Unlock()
+	message := &messageV5{publish: publish}
+	for _, handler := range handlers {
+		handler(client, message)
+	}
+}
+
+// topicMatches returns true if the given topic matches the given topic filter, which could be a shared subscription one.
+func topicMatches(filter, topic string) bool {
+	if strings.HasPrefix(filter, prefixSharedSubscription) {
+		parts := strings.SplitN(filter, "/", 3)
+		if len(parts) < 3 {
+			return false
+		}
+		filter = parts[2]
+	}
+	filterLevels := strings.Split(filter, "/")
+	topicLevels := strings.Split(topic, "/")
+	for i, level := range filterLevels {
+		if level == "#" {
+			return true
+		}
+		if i >= len(topicLevels) || (level != "+" && level != topicLevels[i]) {
+			return false
+		}
+	}
+	return len(filterLevels) == len(topicLevels)
+}
+
+// OptionsReader is not supported by the MQTT 5 client and returns empty options.
+func (client *pahoV5Client) OptionsReader() pahomqtt.ClientOptionsReader {
+	return pahomqtt.ClientOptionsReader{}
+}
+
+func (client *pahoV5Client) run(operation func(context.Context, *autopaho.ConnectionManager) error, timeout time.Duration) pahomqtt.Token {
+	client.lock.Lock()
+	connection := client.connection
+	client.lock.Unlock()
+	if connection == nil {
+		return newToken(autopaho.ConnectionDownError)
+	}
+	return runToken(func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		return operation(ctx, connection)
+	})
+}
+
+func toBytes(payload interface{}) []byte {
+	switch p := payload.(type) {
+	case []byte:
+		return p
+	case string:
+		return []byte(p)
+	default:
+		return nil
+	}
+}
+
+func envelopeActivityID(payload []byte) string {
+	envelope := &struct {
+		ActivityID string `json:"activityId"`
+	}{}
+	if err := json.Unmarshal(payload, envelope); err != nil {
+		return ""
+	}
+	return envelope.ActivityID
+}
+
+// messageV5 adapts a received MQTT 5 message to the MQTT 3.1.1 message interface, exposing its MQTT 5 properties.
+type messageV5 struct {
+	publish *paho.Publish
+}
+
+func (message *messageV5) Duplicate() bool {
+	return false
+}
+
+func (message *messageV5) Qos() byte {
+	return message.publish.QoS
+}
+
+func (message *messageV5) Retained() bool {
+	return message.publish.Retain
+}
+
+func (message *messageV5) Topic() string {
+	return message.publish.Topic
+}
+
+func (message *messageV5) MessageID() uint16 {
+	return message.publish.PacketID
+}
+
+func (message *messageV5) Payload() []byte {
+	return message.publish.Payload
+}
+
+// Ack is a no-op, as the received messages are acknowledged automatically.
+func (message *messageV5) Ack() {}
+
+// ResponseTopic returns the response topic of the message, if any.
+func (message *messageV5) ResponseTopic() string {
+	if message.publish.Properties == nil {
+		return ""
+	}
+	return message.publish.Properties.ResponseTopic
+}
+
+// CorrelationData returns the correlation data of the message, if any.
+func (message *messageV5) CorrelationData() []byte {
+	if message.publish.Properties == nil {
+		return nil
+	}
+	return message.publish.Properties.CorrelationData
+}
+
+// token is a MQTT 3.1.1 token, which is completed when the asynchronous operation of the MQTT 5 client is done.
+type token struct {
+	done chan struct{}
+	err  error
+}
+
+func newToken(err error) *token {
+	t := &token{done: make(chan struct{}), err: err}
+	close(t.done)
+	return t
+}
+
+func runToken(operation func() error) *token {
+	t := &token{done: make(chan struct{})}
+	go func() {
+		t.err = operation()
+		close(t.done)
+	}()
+	return t
+}
+
+func (t *token) Wait() bool {
+	<-t.done
+	return true
+}
+
+func (t *token) WaitTimeout(timeout time.Duration) bool {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-t.done:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
+func (t *token) Done() <-chan struct{} {
+	return t.done
+}
+
+func (t *token) Error() error {
+	select {
+	case <-t.done:
+		return t.err
+	default:
+		return nil
+	}
+}
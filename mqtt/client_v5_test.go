@@ -0,0 +1,177 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package mqtt
+
+import (
+	"testing"
+	"time"
+
+	"github.com/eclipse/paho.golang/autopaho"
+	"github.com/eclipse/paho.golang/paho"
+	pahomqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewClientProtocolVersion(t *testing.T) {
+	tests := map[string]struct {
+		config     *internalConnectionConfig
+		expectedV5 bool
+		err        string
+	}{
+		"test_default": {
+			config: &internalConnectionConfig{Broker: "tcp://localhost:1883"},
+		},
+		"test_v311": {
+			config: &internalConnectionConfig{Broker: "tcp://localhost:1883", ProtocolVersion: "3.1.1"},
+		},
+		"test_v5": {
+			config:     &internalConnectionConfig{Broker: "tcp://localhost:1883", ProtocolVersion: "5"},
+			expectedV5: true,
+		},
+		"test_v5_secure_without_ca": {
+			config: &internalConnectionConfig{Broker: "ssl://localhost:8883", ProtocolVersion: "5"},
+			err:    "connection is secure, but no TLS configuration is provided",
+		},
+		"test_v5_brokers": {
+			config: &internalConnectionConfig{ProtocolVersion: "5", Brokers: []*BrokerConfig{
+				{URL: "ssl://localhost:8883", CACert: "../util/tls/testdata/ca.crt"},
+				{URL: "tcp://fallback:1883"},
+			}},
+			expectedV5: true,
+		},
+		"test_v5_brokers_different_tls": {
+			config: &internalConnectionConfig{ProtocolVersion: "5", Brokers: []*BrokerConfig{
+				{URL: "ssl://localhost:8883", CACert: "../util/tls/testdata/ca.crt"},
+				{URL: "ssl://fallback:8883", CACert: "../util/tls/testdata/ca.crt", Cert: "../util/tls/testdata/certificate.pem", Key: "../util/tls/testdata/key.pem"},
+			}},
+			err: "different TLS settings per broker are not supported with MQTT 5",
+		},
+		"test_v311_brokers_different_tls": {
+			config: &internalConnectionConfig{Brokers: []*BrokerConfig{
+				{URL: "ssl://localhost:8883", CACert: "../util/tls/testdata/ca.crt"},
+				{URL: "ssl://fallback:8883", CACert: "../util/tls/testdata/ca.crt", Cert: "../util/tls/testdata/certificate.pem", Key: "../util/tls/testdata/key.pem"},
+			}},
+		},
+		"test_unsupported": {
+			config: &internalConnectionConfig{Broker: "tcp://localhost:1883", ProtocolVersion: "4"},
+			err:    "unsupported MQTT protocol version '4'",
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			client, err := newClient(test.config, nil, nil)
+			if test.err != "" {
+				assert.EqualError(t, err, test.err)
+				return
+			}
+			assert.NoError(t, err)
+			_, isV5 := client.(*pahoV5Client)
+			assert.Equal(t, test.expectedV5, isV5)
+		})
+	}
+}
+
+func TestTopicMatches(t *testing.T) {
+	tests := map[string]struct {
+		filter   string
+		topic    string
+		expected bool
+	}{
+		"test_equal":            {filter: "a/b", topic: "a/b", expected: true},
+		"test_different":        {filter: "a/b", topic: "a/c"},
+		"test_longer_topic":     {filter: "a/b", topic: "a/b/c"},
+		"test_shorter_topic":    {filter: "a/b/c", topic: "a/b"},
+		"test_single_level":     {filter: "a/+/c", topic: "a/b/c", expected: true},
+		"test_multi_level":      {filter: "a/#", topic: "a/b/c", expected: true},
+		"test_shared":           {filter: "$share/group/a/b", topic: "a/b", expected: true},
+		"test_shared_different": {filter: "$share/group/a/b", topic: "a/c"},
+		"test_shared_invalid":   {filter: "$share/group", topic: "group"},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expected, topicMatches(test.filter, test.topic))
+		})
+	}
+}
+
+func TestClientV5Routes(t *testing.T) {
+	client, err := newClientV5(&internalConnectionConfig{Broker: "tcp://localhost:1883", UnsubscribeTimeout: time.Second}, nil, nil)
+	assert.NoError(t, err)
+	v5Client := client.(*pahoV5Client)
+
+	var received []pahomqtt.Message
+	handler := func(_ pahomqtt.Client, message pahomqtt.Message) {
+		received = append(received, message)
+	}
+	client.AddRoute("$share/group/test/desiredstate", handler)
+	client.AddRoute("test/desiredstate", handler)
+	client.AddRoute("test/currentstate", handler)
+
+	v5Client.route(&paho.Publish{Topic: "test/desiredstate", Payload: []byte("{}"), Properties: &paho.PublishProperties{
+		ResponseTopic:   "test/response",
+		CorrelationData: []byte("correlation"),
+	}})
+	assert.Equal(t, 2, len(received))
+	assert.Equal(t, "test/desiredstate", received[0].Topic())
+	assert.Equal(t, []byte("{}"), received[0].Payload())
+	assert.Equal(t, "test/response", received[0].(propertiesMessage).ResponseTopic())
+	assert.Equal(t, []byte("correlation"), received[0].(propertiesMessage).CorrelationData())
+
+	token := client.Unsubscribe("test/desiredstate", "$share/group/test/desiredstate")
+	assert.True(t, token.WaitTimeout(time.Second))
+	assert.Equal(t, autopaho.ConnectionDownError, token.Error())
+
+	received = nil
+	v5Client.route(&paho.Publish{Topic: "test/desiredstate"})
+	assert.Empty(t, received)
+	v5Client.route(&paho.Publish{Topic: "test/currentstate"})
+	assert.Equal(t, 1, len(received))
+	assert.Equal(t, "", received[0].(propertiesMessage).ResponseTopic())
+	assert.Nil(t, received[0].(propertiesMessage).CorrelationData())
+}
+
+func TestClientV5NotConnected(t *testing.T) {
+	client, err := newClientV5(&internalConnectionConfig{Broker: "tcp://localhost:1883", AcknowledgeTimeout: time.Second}, nil, nil)
+	assert.NoError(t, err)
+
+	assert.False(t, client.IsConnected())
+	assert.False(t, client.IsConnectionOpen())
+	token := client.Publish("test/desiredstate", 1, false, []byte("{}"))
+	assert.True(t, token.Wait())
+	assert.Equal(t, autopaho.ConnectionDownError, token.Error())
+	client.Disconnect(0)
+}
+
+func TestEnvelopeActivityID(t *testing.T) {
+	assert.Equal(t, "test-activity", envelopeActivityID([]byte(`{"activityId":"test-activity","timestamp":123}`)))
+	assert.Equal(t, "", envelopeActivityID([]byte(`{"timestamp":123}`)))
+	assert.Equal(t, "", envelopeActivityID([]byte("invalid")))
+	assert.Equal(t, []byte("test"), toBytes("test"))
+	assert.Equal(t, []byte("test"), toBytes([]byte("test")))
+	assert.Nil(t, toBytes(1))
+}
+
+func TestToken(t *testing.T) {
+	done := make(chan struct{})
+	token := runToken(func() error {
+		<-done
+		return autopaho.ConnectionDownError
+	})
+	assert.False(t, token.WaitTimeout(time.Millisecond))
+	assert.NoError(t, token.Error())
+	close(done)
+	assert.True(t, token.Wait())
+	<-token.Done()
+	assert.Equal(t, autopaho.ConnectionDownError, token.Error())
+	assert.True(t, newToken(nil).WaitTimeout(time.Millisecond))
+}
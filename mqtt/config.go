@@ -14,20 +14,42 @@ package mqtt
 
 const (
 	// default mqtt connection config
-	defaultBroker             = "tcp://localhost:1883"
-	defaultKeepAlive          = "20s"
-	defaultDisconnectTimeout  = "250ms"
-	defaultUsername           = ""
-	defaultPassword           = ""
-	defaultConnectTimeout     = "30s"
-	defaultAcknowledgeTimeout = "15s"
-	defaultSubscribeTimeout   = "15s"
-	defaultUnsubscribeTimeout = "5s"
-	defaultCACert             = ""
-	defaultCert               = ""
-	defaultKey                = ""
+	defaultBroker               = "tcp://localhost:1883"
+	defaultKeepAlive            = "20s"
+	defaultDisconnectTimeout    = "250ms"
+	defaultUsername             = ""
+	defaultPassword             = ""
+	defaultConnectTimeout       = "30s"
+	defaultAcknowledgeTimeout   = "15s"
+	defaultSubscribeTimeout     = "15s"
+	defaultUnsubscribeTimeout   = "5s"
+	defaultCACert               = ""
+	defaultCert                 = ""
+	defaultKey                  = ""
+	defaultMinTLSVersion        = "1.2"
+	defaultMaxReconnectInterval = "10m"
+	defaultFailbackInterval     = "1m"
+	defaultProtocolVersion      = protocolVersion311
+	defaultSharedSubGroup       = ""
+	defaultMessageExpiry        = ""
+	defaultTopicPrefix          = placeholderDomain
 )
 
+// BrokerConfig represents the address and the TLS settings of a single MQTT broker. The TLS settings of the connection config are used, if not set.
+type BrokerConfig struct {
+	URL string `json:"url"`
+	// Priority of the broker, the brokers with lower values are preferred
+	Priority int    `json:"priority,omitempty"`
+	CACert   string `json:"caCert,omitempty"`
+	Cert     string `json:"cert,omitempty"`
+	Key      string `json:"key,omitempty"`
+	// KeyPassphraseFile holds the passphrase of an encrypted private key
+	KeyPassphraseFile string `json:"keyPassphraseFile,omitempty"`
+	CRLFile           string `json:"crlFile,omitempty"`
+	// ServerName overrides the host name used to verify the broker certificate
+	ServerName string `json:"serverName,omitempty"`
+}
+
 // ConnectionConfig represents the mqtt client connection config
 type ConnectionConfig struct {
 	Broker             string `json:"broker,omitempty"`
@@ -42,6 +64,24 @@ type ConnectionConfig struct {
 	CACert             string `json:"caCert,omitempty"`
 	Cert               string `json:"cert,omitempty"`
 	Key                string `json:"key,omitempty"`
+	// TLS hardening config
+	KeyPassphraseFile string   `json:"keyPassphraseFile,omitempty"`
+	CRLFile           string   `json:"crlFile,omitempty"`
+	ServerName        string   `json:"serverName,omitempty"`
+	MinTLSVersion     string   `json:"minTlsVersion,omitempty"`
+	CipherSuites      []string `json:"cipherSuites,omitempty"`
+	// MaxReconnectInterval is the upper bound of the exponential backoff between the reconnect attempts
+	MaxReconnectInterval string `json:"maxReconnectInterval,omitempty"`
+	// Brokers to fail over between by priority, the broker address above is not used if set
+	Brokers          []*BrokerConfig `json:"brokers,omitempty"`
+	FailbackInterval string          `json:"failbackInterval,omitempty"`
+	// MQTT 5 specific config
+	ProtocolVersion         string `json:"protocolVersion,omitempty"`
+	SharedSubscriptionGroup string `json:"sharedSubscriptionGroup,omitempty"`
+	MessageExpiry           string `json:"messageExpiry,omitempty"`
+	// topic namespace config
+	TopicPrefix    string            `json:"topicPrefix,omitempty"`
+	TopicVariables map[string]string `json:"topicVariables,omitempty"`
 }
 
 // NewDefaultConfig returns a default mqtt client connection config instance
@@ -59,5 +99,16 @@ func NewDefaultConfig() *ConnectionConfig {
 		CACert:             defaultCACert,
 		Cert:               defaultCert,
 		Key:                defaultKey,
+
+		MinTLSVersion: defaultMinTLSVersion,
+
+		MaxReconnectInterval: defaultMaxReconnectInterval,
+		FailbackInterval:     defaultFailbackInterval,
+
+		ProtocolVersion:         defaultProtocolVersion,
+		SharedSubscriptionGroup: defaultSharedSubGroup,
+		MessageExpiry:           defaultMessageExpiry,
+
+		TopicPrefix: defaultTopicPrefix,
 	}
 }
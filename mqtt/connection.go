@@ -0,0 +1,226 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package mqtt
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/eclipse-kanto/update-manager/api/types"
+	"github.com/eclipse-kanto/update-manager/logger"
+)
+
+const minResubscribeDelay = time.Second
+
+// connectionState tracks the state of the connection to the MQTT broker and whether the subscriptions of the client are in place.
+// It also keeps the subscriptions of the clients sharing the connection, which are renewed on reconnect.
+// It is safe to call its methods on a nil instance.
+type connectionState struct {
+	lock          sync.Mutex
+	status        types.ConnectionStatus
+	subscriptions map[string]func() error
+}
+
+func newConnectionState() *connectionState {
+	return &connectionState{
+		status: types.ConnectionStatus{
+			State: types.ConnectionStateDisconnected,
+			Since: nowMillis(),
+		},
+		subscriptions: map[string]func() error{},
+	}
+}
+
+// connecting marks the start of the initial connection attempt.
+func (state *connectionState) connecting() {
+	if state == nil {
+		return
+	}
+	state.lock.Lock()
+	defer state.lock.Unlock()
+
+	state.change(types.ConnectionStateConnecting)
+}
+
+// connected marks an established connection to the given broker, counting it as reconnect if the connection has been lost before.
+func (state *connectionState) connected(broker string) {
+	if state == nil {
+		return
+	}
+	state.lock.Lock()
+	defer state.lock.Unlock()
+
+	if state.status.State == types.ConnectionStateReconnecting {
+		state.status.Reconnects++
+	}
+	if broker != state.status.Broker {
+		logger.Info("connected to MQTT broker '%s'", broker)
+		state.status.Broker = broker
+	}
+	state.change(types.ConnectionStateConnected)
+}
+
+// lost marks a lost connection or a failed connection attempt.
+func (state *connectionState) lost(err error) {
+	if state == nil {
+		return
+	}
+	state.lock.Lock()
+	defer state.lock.Unlock()
+
+	if err != nil {
+		state.status.LastError = err.Error()
+	}
+	if state.status.State == types.ConnectionStateConnected {
+		state.change(types.ConnectionStateReconnecting)
+	}
+}
+
+// disconnected marks an explicitly closed connection.
+func (state *connectionState) disconnected() {
+	if state == nil {
+		return
+	}
+	state.lock.Lock()
+	defer state.lock.Unlock()
+
+	state.change(types.ConnectionStateDisconnected)
+}
+
+// subscribed marks the subscriptions of the client as verified for the current connection.
+func (state *connectionState) subscribed() {
+	if state == nil {
+		return
+	}
+	state.lock.Lock()
+	defer state.lock.Unlock()
+
+	state.status.Subscribed = state.status.State == types.ConnectionStateConnected
+}
+
+// addSubscription keeps the given subscribe function to be called on reconnect, replacing any previous one with the same key.
+func (state *connectionState) addSubscription(key string, subscribe func() error) {
+	if state == nil {
+		return
+	}
+	state.lock.Lock()
+	defer state.lock.Unlock()
+
+	state.subscriptions[key] = subscribe
+}
+
+// removeSubscription removes the subscribe function with the given key.
+func (state *connectionState) removeSubscription(key string) {
+	if state == nil {
+		return
+	}
+	state.lock.Lock()
+	defer state.lock.Unlock()
+
+	delete(state.subscriptions, key)
+}
+
+// getSubscriptions returns the subscribe functions, ordered by their keys.
+func (state *connectionState) getSubscriptions() []func() error {
+	if state == nil {
+		return nil
+	}
+	state.lock.Lock()
+	defer state.lock.Unlock()
+
+	keys := make([]string, 0, len(state.subscriptions))
+	for key := range state.subscriptions {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	subscriptions := make([]func() error, len(keys))
+	for i, key := range keys {
+		subscriptions[i] = state.subscriptions[key]
+	}
+	return subscriptions
+}
+
+// isConnected returns true if the connection to the MQTT broker is established.
+func (state *connectionState) isConnected() bool {
+	if state == nil {
+		return false
+	}
+	state.lock.Lock()
+	defer state.lock.Unlock()
+
+	return state.status.State == types.ConnectionStateConnected
+}
+
+// get returns a copy of the current connection status.
+func (state *connectionState) get() *types.ConnectionStatus {
+	if state == nil {
+		return nil
+	}
+	state.lock.Lock()
+	defer state.lock.Unlock()
+
+	status := state.status
+	return &status
+}
+
+func (state *connectionState) change(newState types.ConnectionState) {
+	if state.status.State != newState {
+		logger.Debug("MQTT connection state changed from '%s' to '%s'", state.status.State, newState)
+		state.status.State = newState
+		state.status.Since = nowMillis()
+	}
+	state.status.Subscribed = false
+}
+
+// subscribeAll subscribes the client and renews the subscriptions of the clients sharing its connection,
+// marking the connection as subscribed if all of them succeed.
+func (client *mqttClient) subscribeAll(domain string, subscribe func() error) bool {
+	for _, subscribe := range append([]func() error{subscribe}, client.connection.getSubscriptions()...) {
+		if !client.subscribeWithRetry(domain, subscribe) {
+			return false
+		}
+	}
+	client.connection.subscribed()
+	return true
+}
+
+// subscribeWithRetry calls the given subscribe function until it succeeds, backing off exponentially between the attempts up to
+// the maximum reconnect interval. It gives up if the connection is lost meanwhile, as the subscriptions are renewed on reconnect.
+func (client *mqttClient) subscribeWithRetry(domain string, subscribe func() error) bool {
+	delay := minResubscribeDelay
+	for {
+		err := subscribe()
+		if err == nil {
+			return true
+		}
+		if !client.pahoClient.IsConnectionOpen() {
+			logger.WarnErr(err, "[%s] cannot subscribe, connection to MQTT broker is lost", domain)
+			return false
+		}
+		logger.ErrorErr(err, "[%s] cannot subscribe, retrying in %v", domain, delay)
+		time.Sleep(delay)
+		if delay *= 2; client.mqttConfig.MaxReconnectInterval > 0 && delay > client.mqttConfig.MaxReconnectInterval {
+			delay = client.mqttConfig.MaxReconnectInterval
+		}
+	}
+}
+
+// ConnectionStatus returns the health status of the connection to the MQTT broker.
+func (client *mqttClient) ConnectionStatus() *types.ConnectionStatus {
+	return client.connection.get()
+}
+
+func nowMillis() int64 {
+	return time.Now().UnixNano() / int64(time.Millisecond)
+}
@@ -0,0 +1,128 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package mqtt
+
+import (
+	"testing"
+	"time"
+
+	"github.com/eclipse-kanto/update-manager/api/types"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConnectionState(t *testing.T) {
+	state := newConnectionState()
+	assert.Equal(t, types.ConnectionStateDisconnected, state.get().State)
+
+	state.connecting()
+	state.lost(errors.New("connection refused"))
+	status := state.get()
+	assert.Equal(t, types.ConnectionStateConnecting, status.State)
+	assert.Equal(t, "connection refused", status.LastError)
+
+	state.connected("tcp://localhost:1883")
+	state.subscribed()
+	status = state.get()
+	assert.True(t, status.IsHealthy())
+	assert.Equal(t, "tcp://localhost:1883", status.Broker)
+	assert.Equal(t, 0, status.Reconnects)
+
+	state.lost(errors.New("connection reset"))
+	status = state.get()
+	assert.Equal(t, types.ConnectionStateReconnecting, status.State)
+	assert.False(t, status.Subscribed)
+	assert.False(t, status.IsHealthy())
+	assert.Equal(t, "connection reset", status.LastError)
+
+	state.subscribed()
+	assert.False(t, state.get().Subscribed)
+
+	state.connected("tcp://fallback:1883")
+	status = state.get()
+	assert.Equal(t, types.ConnectionStateConnected, status.State)
+	assert.Equal(t, "tcp://fallback:1883", status.Broker)
+	assert.False(t, status.Subscribed)
+	assert.Equal(t, 1, status.Reconnects)
+
+	state.disconnected()
+	assert.Equal(t, types.ConnectionStateDisconnected, state.get().State)
+}
+
+func TestConnectionStateNil(t *testing.T) {
+	var state *connectionState
+	state.connecting()
+	state.connected("tcp://localhost:1883")
+	state.subscribed()
+	state.lost(errors.New("test error"))
+	state.disconnected()
+	state.addSubscription("test", func() error { return nil })
+	state.removeSubscription("test")
+	assert.Nil(t, state.getSubscriptions())
+	assert.Nil(t, state.get())
+}
+
+func TestSubscribeAll(t *testing.T) {
+	mockCtrl, mockPaho, _ := setupCommonMocks(t)
+	defer mockCtrl.Finish()
+
+	client := newInternalClient("test", mqttTestConfig, mockPaho)
+	client.connection.connected("tcp://localhost:1883")
+
+	var subscribed []string
+	client.connection.addSubscription("b", func() error {
+		subscribed = append(subscribed, "b")
+		return nil
+	})
+	client.connection.addSubscription("a", func() error {
+		subscribed = append(subscribed, "a")
+		return nil
+	})
+	client.connection.addSubscription("c", func() error {
+		return errors.New("not subscribed")
+	})
+	client.connection.removeSubscription("c")
+
+	t.Run("test_subscribe_retried", func(t *testing.T) {
+		mockPaho.EXPECT().IsConnectionOpen().Return(true)
+		attempts := 0
+		start := time.Now()
+		assert.True(t, client.subscribeAll("test", func() error {
+			if attempts++; attempts == 1 {
+				return errors.New("subscribe error")
+			}
+			subscribed = append(subscribed, "client")
+			return nil
+		}))
+		assert.Equal(t, 2, attempts)
+		assert.True(t, time.Since(start) >= minResubscribeDelay)
+		assert.Equal(t, []string{"client", "a", "b"}, subscribed)
+		assert.True(t, client.ConnectionStatus().Subscribed)
+	})
+
+	t.Run("test_subscribe_connection_lost", func(t *testing.T) {
+		client.connection.lost(errors.New("connection reset"))
+		mockPaho.EXPECT().IsConnectionOpen().Return(false)
+		assert.False(t, client.subscribeAll("test", func() error {
+			return errors.New("subscribe error")
+		}))
+		assert.False(t, client.ConnectionStatus().Subscribed)
+	})
+}
+
+func TestRetryDelay(t *testing.T) {
+	assert.Equal(t, connectRetryDelay, retryDelay(0))
+	assert.Equal(t, connectRetryDelay, retryDelay(10*time.Minute))
+	assert.Equal(t, 2*time.Second, retryDelay(2*time.Second))
+}
@@ -31,16 +31,33 @@ type desiredStateClient struct {
 	stateHandler api.StateHandler
 }
 
+// DesiredStateClientOption defines an optional configuration of the desired state client.
+type DesiredStateClientOption func(*desiredStateClient)
+
+// WithTopics overrides the MQTT topics with the given names, e.g. desiredState or currentState, with the given topic templates.
+// The templates could contain the {domain} placeholder and the placeholders of the configured topic variables.
+func WithTopics(topics map[string]string) DesiredStateClientOption {
+	return func(client *desiredStateClient) {
+		client.overrideTopics(client.domain, topics)
+	}
+}
+
 // NewDesiredStateClient instantiates a new client for triggering MQTT requests.
-func NewDesiredStateClient(domain string, updateAgent api.UpdateAgentClient) (api.DesiredStateClient, error) {
-	mqttClient, err := getMQTTClient(updateAgent)
+// The MQTT connection of the given client is reused, it can be either an update agent client or an owner consent agent client.
+func NewDesiredStateClient(domain string, client api.BaseClient, opts ...DesiredStateClientOption) (api.DesiredStateClient, error) {
+	mqttClient, err := getMQTTClient(client)
 	if err != nil {
 		return nil, err
 	}
-	return &desiredStateClient{
+	desiredStateClient := &desiredStateClient{
 		mqttClient: newInternalClient(domain, mqttClient.mqttConfig, mqttClient.pahoClient),
 		domain:     domain,
-	}, nil
+	}
+	desiredStateClient.connection = mqttClient.connection
+	for _, opt := range opts {
+		opt(desiredStateClient)
+	}
+	return desiredStateClient, nil
 }
 
 func (client *desiredStateClient) Domain() string {
@@ -54,12 +71,14 @@ func (client *desiredStateClient) Start(stateHandler api.StateHandler) error {
 		client.stateHandler = nil
 		return fmt.Errorf("[%s] error subscribing for CurrentState/DesiredStateFeedback messages: %w", client.Domain(), err)
 	}
+	client.connection.addSubscription(client.subscriptionKey(), client.subscribe)
 	logger.Debug("[%s] subscribed for CurrentState/DesiredStateFeedback messages", client.Domain())
 	return nil
 }
 
 // Stop removes the client subscription to the MQTT broker for the MQTT topics for desired state feedback and current state messages.
 func (client *desiredStateClient) Stop() error {
+	client.connection.removeSubscription(client.subscriptionKey())
 	if err := client.unsubscribe(); err != nil {
 		return fmt.Errorf("[%s] error unsubscribing for DesiredStateFeedback/CurrentState messages: %w", client.Domain(), err)
 	}
@@ -68,6 +87,11 @@ func (client *desiredStateClient) Stop() error {
 	return nil
 }
 
+// subscriptionKey identifies the subscriptions of the client among the ones renewed on reconnect.
+func (client *desiredStateClient) subscriptionKey() string {
+	return client.topicCurrentState + "," + client.topicDesiredStateFeedback
+}
+
 func (client *desiredStateClient) subscribe() error {
 	topicFilters := make(map[string]byte)
 	topicFilters[client.topicCurrentState] = 1
@@ -121,7 +145,7 @@ func (client *desiredStateClient) SendDesiredState(activityID string, desiredSta
 	if err != nil {
 		return errors.Wrapf(err, "cannot marshal desired state message for activity-id %s", activityID)
 	}
-	token := client.pahoClient.Publish(client.topicDesiredState, 1, false, desiredStateBytes)
+	token := client.publishWithProperties(client.topicDesiredState, false, desiredStateBytes, &publishProperties{messageExpiry: client.mqttConfig.MessageExpiry})
 	if !token.WaitTimeout(client.mqttConfig.AcknowledgeTimeout) {
 		return fmt.Errorf("cannot publish to topic '%s' in '%v'", client.topicDesiredState, client.mqttConfig.AcknowledgeTimeout)
 	}
@@ -134,7 +158,7 @@ func (client *desiredStateClient) SendDesiredStateCommand(activityID string, des
 	if err != nil {
 		return errors.Wrapf(err, "cannot marshal desired state command message for activity-id %s", activityID)
 	}
-	token := client.pahoClient.Publish(client.topicDesiredStateCommand, 1, false, desiredStateCommandBytes)
+	token := client.publishWithProperties(client.topicDesiredStateCommand, false, desiredStateCommandBytes, &publishProperties{messageExpiry: client.mqttConfig.MessageExpiry})
 	if !token.WaitTimeout(client.mqttConfig.AcknowledgeTimeout) {
 		return fmt.Errorf("cannot publish to topic '%s' in '%v'", client.topicDesiredStateCommand, client.mqttConfig.AcknowledgeTimeout)
 	}
@@ -147,7 +171,10 @@ func (client *desiredStateClient) SendCurrentStateGet(activityID string) error {
 	if err != nil {
 		return errors.Wrapf(err, "cannot marshal current state get message for activity-id %s", activityID)
 	}
-	token := client.pahoClient.Publish(client.topicCurrentStateGet, 1, false, currentStateGetBytes)
+	token := client.publishWithProperties(client.topicCurrentStateGet, false, currentStateGetBytes, &publishProperties{
+		responseTopic:   client.topicCurrentState,
+		correlationData: []byte(activityID),
+	})
 	if !token.WaitTimeout(client.mqttConfig.AcknowledgeTimeout) {
 		return fmt.Errorf("cannot publish to topic '%s' in '%v'", client.topicCurrentStateGet, client.mqttConfig.AcknowledgeTimeout)
 	}
@@ -41,6 +41,15 @@ func TestDomain(t *testing.T) {
 	assert.Equal(t, "testDomain", client.Domain())
 }
 
+type testDelegatingClient struct {
+	*mocks.MockUpdateAgentClient
+	delegate api.UpdateAgentClient
+}
+
+func (client *testDelegatingClient) Delegate() api.UpdateAgentClient {
+	return client.delegate
+}
+
 func TestNewDesiredStateClient(t *testing.T) {
 	mockCtrl := gomock.NewController(t)
 	defer mockCtrl.Finish()
@@ -49,7 +58,7 @@ func TestNewDesiredStateClient(t *testing.T) {
 	mockClient := mocks.NewMockUpdateAgentClient(mockCtrl)
 
 	tests := map[string]struct {
-		client api.UpdateAgentClient
+		client api.BaseClient
 		err    string
 	}{
 		"test_update_agent_client": {
@@ -64,6 +73,19 @@ func TestNewDesiredStateClient(t *testing.T) {
 				},
 			},
 		},
+		"test_owner_consent_agent_client": {
+			client: &ownerConsentAgentClient{
+				mqttClient: newInternalClient("testDomain", &internalConnectionConfig{}, mockPaho),
+			},
+		},
+		"test_delegating_client": {
+			client: &testDelegatingClient{
+				MockUpdateAgentClient: mockClient,
+				delegate: &updateAgentClient{
+					mqttClient: newInternalClient("testDomain", &internalConnectionConfig{}, mockPaho),
+				},
+			},
+		},
 		"test_error": {
 			client: mockClient,
 			err:    fmt.Sprintf("unexpected type: %T", mockClient),
@@ -0,0 +1,218 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package mqtt
+
+import (
+	cryptotls "crypto/tls"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/eclipse-kanto/update-manager/api/types"
+	"github.com/eclipse-kanto/update-manager/logger"
+	"github.com/eclipse-kanto/update-manager/util/tls"
+
+	pahomqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/pkg/errors"
+)
+
+// ResolveBrokers returns the brokers to connect to, ordered by priority. The brokers without own TLS settings inherit the ones of the connection config.
+// If no brokers are configured, the single broker address of the connection config is used.
+func ResolveBrokers(config *ConnectionConfig) []*BrokerConfig {
+	brokers := make([]*BrokerConfig, 0, len(config.Brokers))
+	for _, broker := range config.Brokers {
+		if broker == nil || broker.URL == "" {
+			logger.Warn("ignoring MQTT broker config without URL")
+			continue
+		}
+		resolved := *broker
+		if !resolved.hasTLSSettings() {
+			resolved.setTLSSettings(config.CACert, config.Cert, config.Key, config.KeyPassphraseFile, config.CRLFile, config.ServerName)
+		}
+		brokers = append(brokers, &resolved)
+	}
+	if len(brokers) == 0 {
+		broker := &BrokerConfig{URL: config.Broker}
+		broker.setTLSSettings(config.CACert, config.Cert, config.Key, config.KeyPassphraseFile, config.CRLFile, config.ServerName)
+		return []*BrokerConfig{broker}
+	}
+	sort.SliceStable(brokers, func(i, j int) bool {
+		return brokers[i].Priority < brokers[j].Priority
+	})
+	return brokers
+}
+
+func (broker *BrokerConfig) hasTLSSettings() bool {
+	return broker.CACert != "" || broker.Cert != "" || broker.Key != "" || broker.KeyPassphraseFile != "" || broker.CRLFile != "" || broker.ServerName != ""
+}
+
+func (broker *BrokerConfig) setTLSSettings(caCert, cert, key, keyPassphraseFile, crlFile, serverName string) {
+	broker.CACert, broker.Cert, broker.Key = caCert, cert, key
+	broker.KeyPassphraseFile, broker.CRLFile, broker.ServerName = keyPassphraseFile, crlFile, serverName
+}
+
+// sameTLSSettings checks if the broker has the same TLS settings as the given one.
+func (broker *BrokerConfig) sameTLSSettings(other *BrokerConfig) bool {
+	return broker.CACert == other.CACert && broker.Cert == other.Cert && broker.Key == other.Key &&
+		broker.KeyPassphraseFile == other.KeyPassphraseFile && broker.CRLFile == other.CRLFile && broker.ServerName == other.ServerName
+}
+
+// brokers returns the brokers to connect to, ordered by priority.
+func (config *internalConnectionConfig) brokers() []*BrokerConfig {
+	if len(config.Brokers) > 0 {
+		return config.Brokers
+	}
+	broker := &BrokerConfig{URL: config.Broker}
+	broker.setTLSSettings(config.CACert, config.Cert, config.Key, config.KeyPassphraseFile, config.CRLFile, config.ServerName)
+	return []*BrokerConfig{broker}
+}
+
+// brokerURLs parses the URLs of the brokers to connect to and creates the TLS configs of the secure ones, mapped by the broker URL.
+func (config *internalConnectionConfig) brokerURLs() ([]*url.URL, map[string]*cryptotls.Config, error) {
+	brokers := config.brokers()
+	urls := make([]*url.URL, len(brokers))
+	tlsConfigs := map[string]*cryptotls.Config{}
+	for i, broker := range brokers {
+		u, err := url.Parse(broker.URL)
+		if err != nil {
+			return nil, nil, err
+		}
+		urls[i] = u
+		if !IsConnectionSecure(u.Scheme) {
+			continue
+		}
+		if len(broker.CACert) == 0 {
+			return nil, nil, errors.New("connection is secure, but no TLS configuration is provided")
+		}
+		if tlsConfigs[u.String()], err = tls.NewTLSConfigFromSettings(&tls.Settings{
+			CACert:            broker.CACert,
+			Cert:              broker.Cert,
+			Key:               broker.Key,
+			KeyPassphraseFile: broker.KeyPassphraseFile,
+			CRLFile:           broker.CRLFile,
+			ServerName:        broker.ServerName,
+			MinVersion:        config.MinTLSVersion,
+			CipherSuites:      config.CipherSuites,
+		}); err != nil {
+			return nil, nil, err
+		}
+	}
+	return urls, tlsConfigs, nil
+}
+
+// newConnectionAttemptHandler returns a handler, which applies the TLS config of the broker to connect to and keeps track of it as the active broker.
+func newConnectionAttemptHandler(tlsConfigs map[string]*cryptotls.Config, active func(string)) pahomqtt.ConnectionAttemptHandler {
+	return func(broker *url.URL, tlsConfig *cryptotls.Config) *cryptotls.Config {
+		active(broker.Redacted())
+		if brokerTLSConfig, ok := tlsConfigs[broker.String()]; ok {
+			return brokerTLSConfig
+		}
+		return tlsConfig
+	}
+}
+
+// watchPrimaryBroker periodically checks if the primary broker is reachable while connected to a fallback one and switches back to it.
+func (client *mqttClient) watchPrimaryBroker(domain string, stop <-chan struct{}) {
+	primary, err := url.Parse(client.mqttConfig.brokers()[0].URL)
+	if err != nil {
+		return
+	}
+	ticker := time.NewTicker(client.mqttConfig.FailbackInterval)
+	defer ticker.Stop()
+
+	// switching is set while the client is not reconnected after disconnecting from the fallback broker
+	switching := false
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+		if switching && !client.pahoClient.IsConnectionOpen() {
+			switching = !client.reconnect(domain)
+			continue
+		}
+		switching = false
+		status := client.connection.get()
+		if status.State != types.ConnectionStateConnected || status.Broker == primary.Redacted() {
+			continue
+		}
+		if err := probeBroker(primary, client.mqttConfig.ConnectTimeout); err != nil {
+			logger.Trace("[%s] primary MQTT broker '%s' is still not reachable: %v", domain, primary.Redacted(), err)
+			continue
+		}
+		logger.Info("[%s] primary MQTT broker '%s' is reachable again, switching back from '%s'", domain, primary.Redacted(), status.Broker)
+		client.connection.lost(nil)
+		client.pahoClient.Disconnect(disconnectQuiesce)
+		switching = !client.reconnect(domain)
+	}
+}
+
+// reconnect connects the client again, starting with the broker with the highest priority.
+func (client *mqttClient) reconnect(domain string) bool {
+	token := client.pahoClient.Connect()
+	if !token.WaitTimeout(client.mqttConfig.ConnectTimeout) {
+		logger.Warn("[%s] reconnect to MQTT broker timed out", domain)
+		return false
+	}
+	if err := token.Error(); err != nil {
+		logger.WarnErr(err, "[%s] cannot reconnect to MQTT broker", domain)
+		return false
+	}
+	return true
+}
+
+// startFailback starts watching the primary broker, if there are fallback brokers configured.
+func (client *mqttClient) startFailback(domain string) {
+	if len(client.mqttConfig.Brokers) < 2 || client.mqttConfig.FailbackInterval <= 0 {
+		return
+	}
+	client.failback = make(chan struct{})
+	go client.watchPrimaryBroker(domain, client.failback)
+}
+
+// stopFailback stops watching the primary broker.
+func (client *mqttClient) stopFailback() {
+	if client.failback != nil {
+		close(client.failback)
+		client.failback = nil
+	}
+}
+
+// probeBroker checks if a network connection to the given broker could be established.
+func probeBroker(broker *url.URL, timeout time.Duration) error {
+	address := broker.Host
+	if broker.Port() == "" {
+		address = net.JoinHostPort(broker.Hostname(), defaultPort(broker.Scheme))
+	}
+	conn, err := net.DialTimeout("tcp", address, timeout)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+func defaultPort(scheme string) string {
+	switch strings.ToLower(scheme) {
+	case "ws":
+		return "80"
+	case "wss":
+		return "443"
+	}
+	if IsConnectionSecure(scheme) {
+		return "8883"
+	}
+	return "1883"
+}
@@ -35,7 +35,7 @@ func NewOwnerConsentAgentClient(domain string, config *ConnectionConfig) (api.Ow
 		mqttClient: newInternalClient(domain, newInternalConnectionConfig(config), nil),
 		domain:     domain,
 	}
-	pahoClient, err := newClient(client.mqttConfig, client.onConnect)
+	pahoClient, err := newClient(client.mqttConfig, client.connection, client.onConnect)
 	if err == nil {
 		client.pahoClient = pahoClient
 	}
@@ -43,9 +43,7 @@ func NewOwnerConsentAgentClient(domain string, config *ConnectionConfig) (api.Ow
 }
 
 func (client *ownerConsentAgentClient) onConnect(_ pahomqtt.Client) {
-	if err := client.subscribe(); err != nil {
-		logger.ErrorErr(err, "[%s] error subscribing for OwnerConsent requests", client.Domain())
-	} else {
+	if client.subscribeAll(client.Domain(), client.subscribe) {
 		logger.Debug("[%s] subscribed for OwnerConsent requests", client.Domain())
 	}
 }
@@ -53,11 +51,16 @@ func (client *ownerConsentAgentClient) onConnect(_ pahomqtt.Client) {
 // Start connects the client to the MQTT broker.
 func (client *ownerConsentAgentClient) Start(handler api.OwnerConsentAgentHandler) error {
 	client.handler = handler
+	client.connection.connecting()
 	token := client.pahoClient.Connect()
 	if !token.WaitTimeout(client.mqttConfig.ConnectTimeout) {
 		return fmt.Errorf("[%s] connect timed out", client.Domain())
 	}
-	return token.Error()
+	if err := token.Error(); err != nil {
+		return err
+	}
+	client.startFailback(client.Domain())
+	return nil
 }
 
 func (client *ownerConsentAgentClient) Domain() string {
@@ -71,7 +74,9 @@ func (client *ownerConsentAgentClient) Stop() error {
 	} else {
 		logger.Debug("[%s] unsubscribed for OwnerConsent messages", client.Domain())
 	}
+	client.stopFailback()
 	client.pahoClient.Disconnect(disconnectQuiesce)
+	client.connection.disconnected()
 	client.handler = nil
 	return nil
 }
@@ -104,6 +109,7 @@ func (client *ownerConsentAgentClient) handleMessage(mqttClient pahomqtt.Client,
 			logger.ErrorErr(err, "[%s] cannot parse owner consent message", client.Domain())
 			return
 		}
+		client.registerResponse(envelope.ActivityID, message)
 		if err := client.handler.HandleOwnerConsent(envelope.ActivityID, envelope.Timestamp, consent); err != nil {
 			logger.ErrorErr(err, "[%s] error processing owner consent message", client.Domain())
 		}
@@ -111,14 +117,18 @@ func (client *ownerConsentAgentClient) handleMessage(mqttClient pahomqtt.Client,
 }
 
 func (client *ownerConsentAgentClient) SendOwnerConsentFeedback(activityID string, consentFeedback *types.OwnerConsentFeedback) error {
-	logger.Debug("publishing to topic '%s'", client.topicOwnerConsentFeedback)
 	desiredStateBytes, err := types.ToEnvelope(activityID, consentFeedback)
 	if err != nil {
 		return errors.Wrapf(err, "cannot marshal owner consent feedback message for activity-id %s", activityID)
 	}
-	token := client.pahoClient.Publish(client.topicOwnerConsentFeedback, 1, false, desiredStateBytes)
+	topic, properties := client.topicOwnerConsentFeedback, (*publishProperties)(nil)
+	if response := client.takeResponse(activityID); response != nil {
+		topic, properties = response.topic, &publishProperties{correlationData: response.correlationData}
+	}
+	logger.Debug("publishing to topic '%s'", topic)
+	token := client.publishWithProperties(topic, false, desiredStateBytes, properties)
 	if !token.WaitTimeout(client.mqttConfig.AcknowledgeTimeout) {
-		return fmt.Errorf("cannot publish to topic '%s' in '%v'", client.topicOwnerConsentFeedback, client.mqttConfig.AcknowledgeTimeout)
+		return fmt.Errorf("cannot publish to topic '%s' in '%v'", topic, client.mqttConfig.AcknowledgeTimeout)
 	}
 	return token.Error()
 }
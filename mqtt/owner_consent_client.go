@@ -35,10 +35,12 @@ func NewOwnerConsentClient(domain string, updateAgent api.UpdateAgentClient) (ap
 	if err != nil {
 		return nil, err
 	}
-	return &ownerConsentClient{
+	client := &ownerConsentClient{
 		mqttClient: newInternalClient(domain, mqttClient.mqttConfig, mqttClient.pahoClient),
 		domain:     domain,
-	}, nil
+	}
+	client.connection = mqttClient.connection
+	return client, nil
 }
 
 func (client *ownerConsentClient) Domain() string {
@@ -52,12 +54,14 @@ func (client *ownerConsentClient) Start(consentHandler api.OwnerConsentHandler)
 		client.handler = nil
 		return fmt.Errorf("[%s] error subscribing for OwnerConsentFeedback messages: %w", client.Domain(), err)
 	}
+	client.connection.addSubscription(client.topicOwnerConsentFeedback, client.subscribe)
 	logger.Debug("[%s] subscribed for OwnerConsentFeedback messages", client.Domain())
 	return nil
 }
 
 // Stop removes the client subscription to the MQTT broker for the MQTT topics for owner consent.
 func (client *ownerConsentClient) Stop() error {
+	client.connection.removeSubscription(client.topicOwnerConsentFeedback)
 	if err := client.unsubscribe(); err != nil {
 		return fmt.Errorf("[%s] error unsubscribing for OwnerConsentFeedback messages: %w", client.Domain(), err)
 	}
@@ -94,6 +98,10 @@ func (client *ownerConsentClient) handleMessage(mqttClient pahomqtt.Client, mess
 			logger.ErrorErr(err, "[%s] cannot parse owner consent message", client.Domain())
 			return
 		}
+		if !correlationMatches(message, envelope.ActivityID) {
+			logger.Warn("[%s] ignoring owner consent feedback for activity '%s' with mismatching correlation data", client.Domain(), envelope.ActivityID)
+			return
+		}
 		if err := client.handler.HandleOwnerConsentFeedback(envelope.ActivityID, envelope.Timestamp, ownerConsent); err != nil {
 			logger.ErrorErr(err, "[%s] error processing owner consent message", client.Domain())
 		}
@@ -106,7 +114,10 @@ func (client *ownerConsentClient) SendOwnerConsent(activityID string, consent *t
 	if err != nil {
 		return errors.Wrapf(err, "cannot marshal owner consent message for activity-id %s", activityID)
 	}
-	token := client.pahoClient.Publish(client.topicOwnerConsent, 1, false, consentGetBytes)
+	token := client.publishWithProperties(client.topicOwnerConsent, false, consentGetBytes, &publishProperties{
+		responseTopic:   client.topicOwnerConsentFeedback,
+		correlationData: []byte(activityID),
+	})
 	if !token.WaitTimeout(client.mqttConfig.AcknowledgeTimeout) {
 		return fmt.Errorf("cannot publish to topic '%s' in '%v'", client.topicOwnerConsent, client.mqttConfig.AcknowledgeTimeout)
 	}
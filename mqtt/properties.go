@@ -0,0 +1,84 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package mqtt
+
+import (
+	"time"
+
+	pahomqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+// publishProperties holds the MQTT 5 properties of a published message, they are ignored by MQTT 3.1.1 clients.
+type publishProperties struct {
+	responseTopic   string
+	correlationData []byte
+	messageExpiry   time.Duration
+}
+
+// propertiesPublisher is implemented by the MQTT clients, which support publishing messages with MQTT 5 properties.
+type propertiesPublisher interface {
+	PublishWithProperties(topic string, qos byte, retained bool, payload []byte, properties *publishProperties) pahomqtt.Token
+}
+
+// propertiesMessage is implemented by the received messages, which carry MQTT 5 properties.
+type propertiesMessage interface {
+	ResponseTopic() string
+	CorrelationData() []byte
+}
+
+// response is the response topic and correlation data of a received request, which are to be used when responding to it.
+type response struct {
+	topic           string
+	correlationData []byte
+}
+
+// publishWithProperties publishes the given payload with QoS 1 and the given MQTT 5 properties, if supported by the MQTT client.
+func (client *mqttClient) publishWithProperties(topic string, retained bool, payload []byte, properties *publishProperties) pahomqtt.Token {
+	if publisher, ok := client.pahoClient.(propertiesPublisher); ok && properties != nil {
+		return publisher.PublishWithProperties(topic, 1, retained, payload, properties)
+	}
+	return client.pahoClient.Publish(topic, 1, retained, payload)
+}
+
+// registerResponse stores the response topic and correlation data of the given request message for the given activity ID.
+func (client *mqttClient) registerResponse(activityID string, message pahomqtt.Message) {
+	request, ok := message.(propertiesMessage)
+	if !ok || request.ResponseTopic() == "" {
+		return
+	}
+	client.responses.Store(activityID, &response{topic: request.ResponseTopic(), correlationData: request.CorrelationData()})
+}
+
+// takeResponse returns and removes the response topic and correlation data registered for the given activity ID, if any.
+func (client *mqttClient) takeResponse(activityID string) *response {
+	if value, ok := client.responses.LoadAndDelete(activityID); ok {
+		return value.(*response)
+	}
+	return nil
+}
+
+// sharedTopic returns the shared subscription topic filter for the given topic, if a shared subscription group is configured for MQTT 5.
+func (client *mqttClient) sharedTopic(topic string) string {
+	if client.mqttConfig.ProtocolVersion != protocolVersion5 || client.mqttConfig.SharedSubscriptionGroup == "" {
+		return topic
+	}
+	return prefixSharedSubscription + client.mqttConfig.SharedSubscriptionGroup + "/" + topic
+}
+
+// correlationMatches returns true if the given message carries no correlation data or it matches the given activity ID.
+func correlationMatches(message pahomqtt.Message, activityID string) bool {
+	if request, ok := message.(propertiesMessage); ok && len(request.CorrelationData()) > 0 {
+		return string(request.CorrelationData()) == activityID
+	}
+	return true
+}
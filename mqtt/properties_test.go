@@ -0,0 +1,195 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package mqtt
+
+import (
+	"testing"
+	"time"
+
+	"github.com/eclipse-kanto/update-manager/api/types"
+	mqttmocks "github.com/eclipse-kanto/update-manager/mqtt/mocks"
+	"github.com/eclipse-kanto/update-manager/test/mocks"
+
+	"github.com/eclipse/paho.golang/paho"
+	pahomqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+type testPublished struct {
+	topic      string
+	retained   bool
+	payload    []byte
+	properties *publishProperties
+}
+
+// testPropertiesClient is a mocked MQTT client, which supports publishing messages with MQTT 5 properties.
+type testPropertiesClient struct {
+	*mqttmocks.MockClient
+	token     pahomqtt.Token
+	published []*testPublished
+}
+
+func (client *testPropertiesClient) PublishWithProperties(topic string, qos byte, retained bool, payload []byte, properties *publishProperties) pahomqtt.Token {
+	client.published = append(client.published, &testPublished{topic: topic, retained: retained, payload: payload, properties: properties})
+	return client.token
+}
+
+func newTestMessageV5(topic string, payload []byte, responseTopic string, correlationData []byte) pahomqtt.Message {
+	return &messageV5{publish: &paho.Publish{Topic: topic, Payload: payload, Properties: &paho.PublishProperties{
+		ResponseTopic:   responseTopic,
+		CorrelationData: correlationData,
+	}}}
+}
+
+func TestSharedTopic(t *testing.T) {
+	tests := map[string]struct {
+		config   *internalConnectionConfig
+		expected string
+	}{
+		"test_v311":            {config: &internalConnectionConfig{SharedSubscriptionGroup: "group"}, expected: "test/desiredstate"},
+		"test_v5_no_group":     {config: &internalConnectionConfig{ProtocolVersion: "5"}, expected: "test/desiredstate"},
+		"test_v5_with_group":   {config: &internalConnectionConfig{ProtocolVersion: "5", SharedSubscriptionGroup: "group"}, expected: "$share/group/test/desiredstate"},
+		"test_v311_explicitly": {config: &internalConnectionConfig{ProtocolVersion: "3.1.1", SharedSubscriptionGroup: "group"}, expected: "test/desiredstate"},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expected, newInternalClient("test", test.config, nil).sharedTopic("test/desiredstate"))
+		})
+	}
+}
+
+func TestRegisterResponse(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	client := newInternalClient("test", mqttTestConfig, nil)
+	client.registerResponse("activity-1", mqttmocks.NewMockMessage(mockCtrl))
+	client.registerResponse("activity-2", newTestMessageV5("topic", nil, "", nil))
+	client.registerResponse("activity-3", newTestMessageV5("topic", nil, "response", []byte("activity-3")))
+
+	assert.Nil(t, client.takeResponse("activity-1"))
+	assert.Nil(t, client.takeResponse("activity-2"))
+	assert.Equal(t, &response{topic: "response", correlationData: []byte("activity-3")}, client.takeResponse("activity-3"))
+	assert.Nil(t, client.takeResponse("activity-3"))
+
+	assert.True(t, correlationMatches(mqttmocks.NewMockMessage(mockCtrl), "activity-1"))
+	assert.True(t, correlationMatches(newTestMessageV5("topic", nil, "", nil), "activity-1"))
+	assert.True(t, correlationMatches(newTestMessageV5("topic", nil, "", []byte("activity-1")), "activity-1"))
+	assert.False(t, correlationMatches(newTestMessageV5("topic", nil, "", []byte("activity-2")), "activity-1"))
+}
+
+func TestPublishWithProperties(t *testing.T) {
+	mockCtrl, mockPaho, mockToken := setupCommonMocks(t)
+	defer mockCtrl.Finish()
+
+	properties := &publishProperties{messageExpiry: time.Minute}
+	mockPaho.EXPECT().Publish("test/desiredstate", uint8(1), false, []byte("{}")).Return(mockToken)
+	assert.Equal(t, mockToken, newInternalClient("test", mqttTestConfig, mockPaho).publishWithProperties("test/desiredstate", false, []byte("{}"), properties))
+
+	client := &testPropertiesClient{MockClient: mockPaho, token: mockToken}
+	assert.Equal(t, mockToken, newInternalClient("test", mqttTestConfig, client).publishWithProperties("test/desiredstate", true, []byte("{}"), properties))
+	assert.Equal(t, []*testPublished{{topic: "test/desiredstate", retained: true, payload: []byte("{}"), properties: properties}}, client.published)
+}
+
+func TestDesiredStateClientProperties(t *testing.T) {
+	mockCtrl, mockPaho, mockToken := setupCommonMocks(t)
+	defer mockCtrl.Finish()
+
+	config := *mqttTestConfig
+	config.MessageExpiry = time.Hour
+	client := &testPropertiesClient{MockClient: mockPaho, token: mockToken}
+	desiredStateClient := &desiredStateClient{
+		mqttClient: newInternalClient("test", &config, client),
+		domain:     "test",
+	}
+	setupMockToken(mockToken, config.AcknowledgeTimeout, false)
+	setupMockToken(mockToken, config.AcknowledgeTimeout, false)
+
+	assert.NoError(t, desiredStateClient.SendDesiredState("activity-1", &types.DesiredState{}))
+	assert.NoError(t, desiredStateClient.SendCurrentStateGet("activity-2"))
+	assert.Equal(t, 2, len(client.published))
+	assert.Equal(t, "testupdate/desiredstate", client.published[0].topic)
+	assert.Equal(t, &publishProperties{messageExpiry: time.Hour}, client.published[0].properties)
+	assert.Equal(t, "testupdate/currentstate/get", client.published[1].topic)
+	assert.Equal(t, &publishProperties{responseTopic: "testupdate/currentstate", correlationData: []byte("activity-2")}, client.published[1].properties)
+}
+
+func TestCurrentStateGetResponse(t *testing.T) {
+	mockCtrl, mockPaho, mockToken := setupCommonMocks(t)
+	defer mockCtrl.Finish()
+
+	client := &testPropertiesClient{MockClient: mockPaho, token: mockToken}
+	mockHandler := mocks.NewMockUpdateAgentHandler(mockCtrl)
+	updateAgentClient := &updateAgentClient{
+		mqttClient: newInternalClient("test", mqttTestConfig, client),
+		domain:     "test",
+		handler:    mockHandler,
+	}
+	request := func(activityID, responseTopic string) {
+		payload, err := types.ToEnvelope(activityID, nil)
+		assert.NoError(t, err)
+		mockHandler.EXPECT().HandleCurrentStateGet(activityID, gomock.Any())
+		updateAgentClient.handleStateRequest(nil, newTestMessageV5("testupdate/currentstate/get", payload, responseTopic, []byte(activityID)))
+	}
+
+	request("activity-1", "testupdate/currentstate")
+	assert.NoError(t, updateAgentClient.SendCurrentState("activity-1", &types.Inventory{}))
+	assert.Equal(t, 1, len(client.published))
+	assert.Equal(t, "testupdate/currentstate", client.published[0].topic)
+	assert.True(t, client.published[0].retained)
+	assert.Equal(t, []byte("activity-1"), client.published[0].properties.correlationData)
+
+	request("activity-2", "custom/response")
+	mockPaho.EXPECT().Publish("testupdate/currentstate", uint8(1), true, gomock.Any())
+	assert.NoError(t, updateAgentClient.SendCurrentState("activity-2", &types.Inventory{}))
+	assert.Equal(t, 2, len(client.published))
+	assert.Equal(t, "custom/response", client.published[1].topic)
+	assert.False(t, client.published[1].retained)
+	assert.Equal(t, []byte("activity-2"), client.published[1].properties.correlationData)
+}
+
+func TestOwnerConsentResponse(t *testing.T) {
+	mockCtrl, mockPaho, mockToken := setupCommonMocks(t)
+	defer mockCtrl.Finish()
+
+	client := &testPropertiesClient{MockClient: mockPaho, token: mockToken}
+	mockHandler := mocks.NewMockOwnerConsentHandler(mockCtrl)
+	consentClient := &ownerConsentClient{
+		mqttClient: newInternalClient("test", mqttTestConfig, client),
+		domain:     "test",
+		handler:    mockHandler,
+	}
+	mockAgentHandler := mocks.NewMockOwnerConsentAgentHandler(mockCtrl)
+	agentClient := &ownerConsentAgentClient{
+		mqttClient: newInternalClient("test", mqttTestConfig, client),
+		domain:     "test",
+		handler:    mockAgentHandler,
+	}
+
+	setupMockToken(mockToken, mqttTestConfig.AcknowledgeTimeout, false)
+	assert.NoError(t, consentClient.SendOwnerConsent("activity-1", &types.OwnerConsent{}))
+	assert.Equal(t, "testupdate/ownerconsent", client.published[0].topic)
+	assert.Equal(t, &publishProperties{responseTopic: "testupdate/ownerconsentfeedback", correlationData: []byte("activity-1")}, client.published[0].properties)
+
+	mockAgentHandler.EXPECT().HandleOwnerConsent("activity-1", gomock.Any(), gomock.Any())
+	agentClient.handleMessage(nil, newTestMessageV5("testupdate/ownerconsent", client.published[0].payload, "custom/feedback", []byte("activity-1")))
+	setupMockToken(mockToken, mqttTestConfig.AcknowledgeTimeout, false)
+	assert.NoError(t, agentClient.SendOwnerConsentFeedback("activity-1", &types.OwnerConsentFeedback{Status: types.StatusApproved}))
+	assert.Equal(t, "custom/feedback", client.published[1].topic)
+	assert.Equal(t, &publishProperties{correlationData: []byte("activity-1")}, client.published[1].properties)
+
+	mockHandler.EXPECT().HandleOwnerConsentFeedback("activity-1", gomock.Any(), gomock.Any())
+	consentClient.handleMessage(nil, newTestMessageV5("testupdate/ownerconsentfeedback", client.published[1].payload, "", []byte("activity-1")))
+	consentClient.handleMessage(nil, newTestMessageV5("testupdate/ownerconsentfeedback", client.published[1].payload, "", []byte("activity-2")))
+}
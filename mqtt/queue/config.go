@@ -0,0 +1,34 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package queue
+
+const (
+	// default outbound queue config
+	defaultFile        = ""
+	defaultMaxMessages = 1000
+)
+
+// Config represents the outbound message queue config
+type Config struct {
+	Enabled     bool   `json:"enabled"`
+	File        string `json:"file,omitempty"`
+	MaxMessages int    `json:"maxMessages,omitempty"`
+}
+
+// NewDefaultConfig returns a default outbound message queue config instance, the queue is disabled by default
+func NewDefaultConfig() *Config {
+	return &Config{
+		File:        defaultFile,
+		MaxMessages: defaultMaxMessages,
+	}
+}
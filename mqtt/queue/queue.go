@@ -0,0 +1,208 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package queue
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/eclipse-kanto/update-manager/logger"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// KindCurrentState is the kind of the current state messages, only the latest one is kept
+	KindCurrentState = "currentState"
+	// KindDesiredStateFeedback is the kind of the desired state feedback messages, only the latest non-terminal one per activity is kept
+	KindDesiredStateFeedback = "desiredStateFeedback"
+)
+
+// Message is an outbound message, which is kept in the queue until it is successfully sent.
+type Message struct {
+	Kind       string          `json:"kind"`
+	ActivityID string          `json:"activityId,omitempty"`
+	Payload    json.RawMessage `json:"payload"`
+	// Terminal marks the messages, which are never coalesced or dropped, e.g. desired state feedback with a final status
+	Terminal bool `json:"terminal,omitempty"`
+}
+
+// coalesces returns true if the given queued message is outdated by this one and could be dropped.
+func (message *Message) coalesces(queued *Message) bool {
+	if queued.Terminal || queued.Kind != message.Kind {
+		return false
+	}
+	return message.Kind == KindCurrentState || queued.ActivityID == message.ActivityID
+}
+
+// Queue keeps the outbound messages, which could not be sent, e.g. while the MQTT broker is not reachable, and sends them in order later on.
+// Only the latest current state and the latest desired state feedback per activity are kept, except the terminal ones, which are never dropped.
+// All methods are safe to be called on a nil queue, in which case no messages are kept.
+type Queue struct {
+	lock        sync.Mutex
+	file        string
+	maxMessages int
+	messages    []*Message
+	flushing    bool
+}
+
+// NewQueue creates a new outbound message queue with the given configuration, loading the persisted messages, if any.
+// A nil queue is returned, if the outbound message queue is disabled.
+func NewQueue(config *Config) (*Queue, error) {
+	if config == nil || !config.Enabled {
+		return nil, nil
+	}
+	queue := &Queue{
+		file:        config.File,
+		maxMessages: config.MaxMessages,
+	}
+	if queue.maxMessages <= 0 {
+		queue.maxMessages = defaultMaxMessages
+	}
+	if err := queue.load(); err != nil {
+		return nil, err
+	}
+	return queue, nil
+}
+
+// Len returns the number of the queued messages.
+func (queue *Queue) Len() int {
+	if queue == nil {
+		return 0
+	}
+	queue.lock.Lock()
+	defer queue.lock.Unlock()
+	return len(queue.messages)
+}
+
+// Enqueue adds the given message at the end of the queue, dropping the queued messages outdated by it.
+// If the maximum number of messages is exceeded, the oldest non-terminal messages are dropped.
+func (queue *Queue) Enqueue(message *Message) {
+	if queue == nil {
+		return
+	}
+	queue.lock.Lock()
+	defer queue.lock.Unlock()
+
+	messages := queue.messages[:0:0]
+	for _, queued := range queue.messages {
+		if !message.coalesces(queued) {
+			messages = append(messages, queued)
+		}
+	}
+	messages = append(messages, message)
+	for i := 0; len(messages) > queue.maxMessages && i < len(messages); {
+		if messages[i].Terminal {
+			i++
+			continue
+		}
+		logger.Warn("outbound queue is full, dropping %s message for activity '%s'", messages[i].Kind, messages[i].ActivityID)
+		messages = append(messages[:i], messages[i+1:]...)
+	}
+	queue.messages = messages
+	queue.persist()
+}
+
+// Flush sends the queued messages in order using the given function, removing the successfully sent ones.
+// Flushing stops on the first error, which is returned, leaving the remaining messages in the queue.
+// If the queue is already being flushed, the call returns immediately.
+func (queue *Queue) Flush(send func(*Message) error) error {
+	if queue == nil {
+		return nil
+	}
+	queue.lock.Lock()
+	if queue.flushing {
+		queue.lock.Unlock()
+		return nil
+	}
+	queue.flushing = true
+	queue.lock.Unlock()
+
+	for {
+		queue.lock.Lock()
+		if len(queue.messages) == 0 {
+			queue.flushing = false
+			queue.lock.Unlock()
+			return nil
+		}
+		message := queue.messages[0]
+		queue.lock.Unlock()
+
+		err := send(message)
+
+		queue.lock.Lock()
+		if err != nil {
+			queue.flushing = false
+			queue.lock.Unlock()
+			return err
+		}
+		queue.remove(message)
+		queue.persist()
+		queue.lock.Unlock()
+	}
+}
+
+// remove removes the given message, unless already dropped because outdated by a newer one enqueued meanwhile.
+func (queue *Queue) remove(message *Message) {
+	for i, queued := range queue.messages {
+		if queued == message {
+			queue.messages = append(queue.messages[:i], queue.messages[i+1:]...)
+			return
+		}
+	}
+}
+
+func (queue *Queue) load() error {
+	if queue.file == "" {
+		return nil
+	}
+	data, err := os.ReadFile(queue.file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return errors.Wrapf(err, "failed to load outbound queue from %s", queue.file)
+	}
+	if err := json.Unmarshal(data, &queue.messages); err != nil {
+		logger.WarnErr(err, "ignoring invalid outbound queue file %s", queue.file)
+		queue.messages = nil
+	}
+	logger.Debug("loaded %d outbound messages from %s", len(queue.messages), queue.file)
+	return nil
+}
+
+func (queue *Queue) persist() {
+	if err := queue.save(); err != nil {
+		logger.WarnErr(err, "failed to save outbound queue to %s", queue.file)
+	}
+}
+
+func (queue *Queue) save() error {
+	if queue.file == "" {
+		return nil
+	}
+	data, err := json.Marshal(queue.messages)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(queue.file), 0755); err != nil {
+		return err
+	}
+	tmpFile := queue.file + ".tmp"
+	if err := os.WriteFile(tmpFile, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpFile, queue.file)
+}
@@ -0,0 +1,166 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package queue
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func feedback(activityID, status string, terminal bool) *Message {
+	return &Message{
+		Kind:       KindDesiredStateFeedback,
+		ActivityID: activityID,
+		Payload:    []byte(fmt.Sprintf(`{"activityId":"%s","payload":{"status":"%s"}}`, activityID, status)),
+		Terminal:   terminal,
+	}
+}
+
+func currentState(activityID string) *Message {
+	return &Message{Kind: KindCurrentState, ActivityID: activityID, Payload: []byte(fmt.Sprintf(`{"activityId":"%s"}`, activityID))}
+}
+
+func flushAll(t *testing.T, queue *Queue) []*Message {
+	var sent []*Message
+	assert.NoError(t, queue.Flush(func(message *Message) error {
+		sent = append(sent, message)
+		return nil
+	}))
+	return sent
+}
+
+func TestNewQueue(t *testing.T) {
+	queue, err := NewQueue(NewDefaultConfig())
+	assert.NoError(t, err)
+	assert.Nil(t, queue)
+	queue, err = NewQueue(nil)
+	assert.NoError(t, err)
+	assert.Nil(t, queue)
+
+	queue.Enqueue(currentState("a1"))
+	assert.Equal(t, 0, queue.Len())
+	assert.NoError(t, queue.Flush(nil))
+
+	queue, err = NewQueue(&Config{Enabled: true})
+	assert.NoError(t, err)
+	assert.Equal(t, defaultMaxMessages, queue.maxMessages)
+
+	_, err = NewQueue(&Config{Enabled: true, File: t.TempDir()})
+	assert.Error(t, err)
+}
+
+func TestQueueCoalescing(t *testing.T) {
+	queue, err := NewQueue(&Config{Enabled: true})
+	assert.NoError(t, err)
+
+	queue.Enqueue(feedback("a1", "IDENTIFYING", false))
+	queue.Enqueue(currentState("a0"))
+	queue.Enqueue(feedback("a1", "RUNNING", false))
+	queue.Enqueue(feedback("a2", "RUNNING", false))
+	queue.Enqueue(feedback("a1", "COMPLETED", true))
+	queue.Enqueue(currentState("a1"))
+	queue.Enqueue(feedback("a1", "RUNNING", false))
+	assert.Equal(t, 4, queue.Len())
+
+	sent := flushAll(t, queue)
+	assert.Equal(t, []*Message{
+		feedback("a2", "RUNNING", false),
+		feedback("a1", "COMPLETED", true),
+		currentState("a1"),
+		feedback("a1", "RUNNING", false),
+	}, sent)
+	assert.Equal(t, 0, queue.Len())
+}
+
+func TestQueueMaxMessages(t *testing.T) {
+	queue, err := NewQueue(&Config{Enabled: true, MaxMessages: 3})
+	assert.NoError(t, err)
+
+	queue.Enqueue(feedback("a1", "COMPLETED", true))
+	queue.Enqueue(feedback("a2", "RUNNING", false))
+	queue.Enqueue(feedback("a3", "INCOMPLETE", true))
+	queue.Enqueue(feedback("a4", "RUNNING", false))
+	assert.Equal(t, []*Message{
+		feedback("a1", "COMPLETED", true),
+		feedback("a3", "INCOMPLETE", true),
+		feedback("a4", "RUNNING", false),
+	}, queue.messages)
+
+	queue.Enqueue(feedback("a5", "SUPERSEDED", true))
+	queue.Enqueue(feedback("a6", "COMPLETED", true))
+	assert.Equal(t, 4, queue.Len())
+	for _, message := range queue.messages {
+		assert.True(t, message.Terminal)
+	}
+}
+
+func TestQueueFlushError(t *testing.T) {
+	queue, err := NewQueue(&Config{Enabled: true})
+	assert.NoError(t, err)
+
+	queue.Enqueue(feedback("a1", "COMPLETED", true))
+	queue.Enqueue(feedback("a2", "COMPLETED", true))
+	queue.Enqueue(feedback("a3", "COMPLETED", true))
+
+	var sent []string
+	err = queue.Flush(func(message *Message) error {
+		if message.ActivityID == "a2" {
+			return errors.New("not connected")
+		}
+		sent = append(sent, message.ActivityID)
+		return nil
+	})
+	assert.EqualError(t, err, "not connected")
+	assert.Equal(t, []string{"a1"}, sent)
+	assert.Equal(t, 2, queue.Len())
+
+	assert.NoError(t, queue.Flush(func(message *Message) error {
+		assert.NoError(t, queue.Flush(func(*Message) error {
+			t.Fatal("concurrent flush must not send messages")
+			return nil
+		}))
+		sent = append(sent, message.ActivityID)
+		return nil
+	}))
+	assert.Equal(t, []string{"a1", "a2", "a3"}, sent)
+}
+
+func TestQueuePersistence(t *testing.T) {
+	config := &Config{Enabled: true, File: filepath.Join(t.TempDir(), "queue", "queue.json")}
+	queue, err := NewQueue(config)
+	assert.NoError(t, err)
+
+	queue.Enqueue(feedback("a1", "RUNNING", false))
+	queue.Enqueue(feedback("a1", "COMPLETED", true))
+	queue.Enqueue(currentState("a1"))
+
+	queue, err = NewQueue(config)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, queue.Len())
+	sent := flushAll(t, queue)
+	assert.Equal(t, []*Message{feedback("a1", "COMPLETED", true), currentState("a1")}, sent)
+
+	queue, err = NewQueue(config)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, queue.Len())
+
+	assert.NoError(t, os.WriteFile(config.File, []byte("invalid"), 0644))
+	queue, err = NewQueue(config)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, queue.Len())
+}
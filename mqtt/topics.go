@@ -0,0 +1,85 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package mqtt
+
+import (
+	"strings"
+
+	"github.com/eclipse-kanto/update-manager/logger"
+)
+
+const (
+	// placeholderDomain is replaced with the MQTT domain identifier, e.g. containersupdate for the containers domain
+	placeholderDomain = "{domain}"
+
+	// names of the topics, which could be overridden
+	topicNameDesiredState         = "desiredState"
+	topicNameDesiredStateCommand  = "desiredStateCommand"
+	topicNameDesiredStateFeedback = "desiredStateFeedback"
+	topicNameCurrentState         = "currentState"
+	topicNameCurrentStateGet      = "currentStateGet"
+	topicNameOwnerConsent         = "ownerConsent"
+	topicNameOwnerConsentFeedback = "ownerConsentFeedback"
+	topicNameHistory              = "history"
+	topicNameHistoryGet           = "historyGet"
+	topicNameCompliance           = "compliance"
+	topicNameComplianceGet        = "complianceGet"
+)
+
+// expandTopic replaces the {domain} placeholder in the given topic template with the MQTT domain identifier
+// and the other placeholders with the values of the respective topic variables.
+func expandTopic(template, domain string, variables map[string]string) string {
+	topic := strings.ReplaceAll(template, placeholderDomain, domainAsTopic(domain))
+	for name, value := range variables {
+		topic = strings.ReplaceAll(topic, "{"+name+"}", value)
+	}
+	if strings.Contains(topic, "{") {
+		logger.Warn("unresolved placeholders in MQTT topic '%s'", topic)
+	}
+	return topic
+}
+
+// topicPrefix returns the prefix of all topics of the given domain, as configured by the topic prefix template.
+func topicPrefix(domain string, config *internalConnectionConfig) string {
+	template := config.TopicPrefix
+	if template == "" {
+		template = placeholderDomain
+	}
+	return strings.TrimSuffix(expandTopic(template, domain, config.TopicVariables), "/")
+}
+
+// overrideTopics replaces the topics with the given names with the given topic templates, e.g. to communicate with an agent using legacy topic names.
+func (client *mqttClient) overrideTopics(domain string, topics map[string]string) {
+	references := map[string]*string{
+		topicNameDesiredState:         &client.topicDesiredState,
+		topicNameDesiredStateCommand:  &client.topicDesiredStateCommand,
+		topicNameDesiredStateFeedback: &client.topicDesiredStateFeedback,
+		topicNameCurrentState:         &client.topicCurrentState,
+		topicNameCurrentStateGet:      &client.topicCurrentStateGet,
+		topicNameOwnerConsent:         &client.topicOwnerConsent,
+		topicNameOwnerConsentFeedback: &client.topicOwnerConsentFeedback,
+		topicNameHistory:              &client.topicHistory,
+		topicNameHistoryGet:           &client.topicHistoryGet,
+		topicNameCompliance:           &client.topicCompliance,
+		topicNameComplianceGet:        &client.topicComplianceGet,
+	}
+	for name, template := range topics {
+		reference, ok := references[name]
+		if !ok {
+			logger.Warn("[%s] unknown MQTT topic name '%s' cannot be overridden", domain, name)
+			continue
+		}
+		*reference = expandTopic(template, domain, client.mqttConfig.TopicVariables)
+		logger.Debug("[%s] MQTT topic '%s' overridden with '%s'", domain, name, *reference)
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package mqtt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTopicPrefix(t *testing.T) {
+	variables := map[string]string{"tenant": "test-tenant", "device": "test-device"}
+	tests := map[string]struct {
+		domain   string
+		config   *internalConnectionConfig
+		expected string
+	}{
+		"test_not_set":        {domain: "containers", config: &internalConnectionConfig{}, expected: "containersupdate"},
+		"test_default":        {domain: "self-update", config: &internalConnectionConfig{TopicPrefix: "{domain}"}, expected: "selfupdate"},
+		"test_static_prefix":  {domain: "containers", config: &internalConnectionConfig{TopicPrefix: "gateway/{domain}"}, expected: "gateway/containersupdate"},
+		"test_variables":      {domain: "containers", config: &internalConnectionConfig{TopicPrefix: "{tenant}/{device}/{domain}", TopicVariables: variables}, expected: "test-tenant/test-device/containersupdate"},
+		"test_trailing_slash": {domain: "containers", config: &internalConnectionConfig{TopicPrefix: "{device}/", TopicVariables: variables}, expected: "test-device"},
+		"test_unresolved":     {domain: "containers", config: &internalConnectionConfig{TopicPrefix: "{tenant}/{domain}"}, expected: "{tenant}/containersupdate"},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expected, topicPrefix(test.domain, test.config))
+		})
+	}
+}
+
+func TestNewInternalClientTopics(t *testing.T) {
+	client := newInternalClient("containers", &internalConnectionConfig{
+		TopicPrefix:    "{tenant}/{device}/{domain}",
+		TopicVariables: map[string]string{"tenant": "t1", "device": "d1"},
+	}, nil)
+
+	assert.Equal(t, "t1/d1/containersupdate/desiredstate", client.topicDesiredState)
+	assert.Equal(t, "t1/d1/containersupdate/desiredstate/command", client.topicDesiredStateCommand)
+	assert.Equal(t, "t1/d1/containersupdate/desiredstatefeedback", client.topicDesiredStateFeedback)
+	assert.Equal(t, "t1/d1/containersupdate/currentstate", client.topicCurrentState)
+	assert.Equal(t, "t1/d1/containersupdate/currentstate/get", client.topicCurrentStateGet)
+	assert.Equal(t, "t1/d1/containersupdate/ownerconsent", client.topicOwnerConsent)
+	assert.Equal(t, "t1/d1/containersupdate/ownerconsentfeedback", client.topicOwnerConsentFeedback)
+	assert.Equal(t, "t1/d1/containersupdate/history", client.topicHistory)
+	assert.Equal(t, "t1/d1/containersupdate/history/get", client.topicHistoryGet)
+	assert.Equal(t, "t1/d1/containersupdate/compliance", client.topicCompliance)
+	assert.Equal(t, "t1/d1/containersupdate/compliance/get", client.topicComplianceGet)
+}
+
+func TestDesiredStateClientWithTopics(t *testing.T) {
+	config := &internalConnectionConfig{TopicVariables: map[string]string{"device": "d1"}}
+	updateAgentClient := &updateAgentClient{
+		mqttClient: newInternalClient("device", config, nil),
+		domain:     "device",
+	}
+
+	client, err := NewDesiredStateClient("containers", updateAgentClient, WithTopics(map[string]string{
+		"desiredState": "legacy/{device}/containers/apply",
+		"currentState": "legacy/{domain}/state",
+		"unknown":      "legacy/unknown",
+	}))
+	assert.NoError(t, err)
+	stateClient := client.(*desiredStateClient)
+	assert.Equal(t, "legacy/d1/containers/apply", stateClient.topicDesiredState)
+	assert.Equal(t, "legacy/containersupdate/state", stateClient.topicCurrentState)
+	assert.Equal(t, "containersupdate/desiredstate/command", stateClient.topicDesiredStateCommand)
+	assert.Equal(t, "containersupdate/desiredstatefeedback", stateClient.topicDesiredStateFeedback)
+	assert.Equal(t, "deviceupdate/desiredstate", updateAgentClient.topicDesiredState)
+
+	client, err = NewDesiredStateClient("containers", updateAgentClient, WithTopics(nil))
+	assert.NoError(t, err)
+	assert.Equal(t, "containersupdate/desiredstate", client.(*desiredStateClient).topicDesiredState)
+}
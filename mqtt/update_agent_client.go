@@ -14,15 +14,16 @@ package mqtt
 
 import (
 	"fmt"
-	"net/url"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/eclipse-kanto/update-manager/api"
 	"github.com/eclipse-kanto/update-manager/api/types"
 	"github.com/eclipse-kanto/update-manager/logger"
-	"github.com/eclipse-kanto/update-manager/util/tls"
+	"github.com/eclipse-kanto/update-manager/mqtt/queue"
+	"github.com/eclipse-kanto/update-manager/util/jws"
 
 	pahomqtt "github.com/eclipse/paho.mqtt.golang"
 	"github.com/google/uuid"
@@ -39,6 +40,10 @@ const (
 	suffixDesiredStateFeedback = "/desiredstatefeedback"
 	suffixOwnerConsent         = "/ownerconsent"
 	suffixOwnerConsentFeedback = "/ownerconsentfeedback"
+	suffixHistory              = "/history"
+	suffixHistoryGet           = "/history/get"
+	suffixCompliance           = "/compliance"
+	suffixComplianceGet        = "/compliance/get"
 
 	disconnectQuiesce uint = 10000
 )
@@ -56,11 +61,29 @@ type internalConnectionConfig struct {
 	CACert             string
 	Cert               string
 	Key                string
+
+	KeyPassphraseFile string
+	CRLFile           string
+	ServerName        string
+	MinTLSVersion     string
+	CipherSuites      []string
+
+	MaxReconnectInterval time.Duration
+	Brokers              []*BrokerConfig
+	FailbackInterval     time.Duration
+
+	ProtocolVersion         string
+	SharedSubscriptionGroup string
+	MessageExpiry           time.Duration
+
+	TopicPrefix    string
+	TopicVariables map[string]string
 }
 
 func newInternalConnectionConfig(config *ConnectionConfig) *internalConnectionConfig {
-	return &internalConnectionConfig{
-		Broker:             config.Broker,
+	brokers := ResolveBrokers(config)
+	internalConfig := &internalConnectionConfig{
+		Broker:             brokers[0].URL,
 		KeepAlive:          parseDuration("mqtt-conn-keep-alive", config.KeepAlive, defaultKeepAlive),
 		DisconnectTimeout:  parseDuration("mqtt-conn-disconnect-timeout", config.DisconnectTimeout, defaultDisconnectTimeout),
 		Username:           config.Username,
@@ -72,29 +95,59 @@ func newInternalConnectionConfig(config *ConnectionConfig) *internalConnectionCo
 		CACert:             config.CACert,
 		Cert:               config.Cert,
 		Key:                config.Key,
+
+		KeyPassphraseFile: config.KeyPassphraseFile,
+		CRLFile:           config.CRLFile,
+		ServerName:        config.ServerName,
+		MinTLSVersion:     config.MinTLSVersion,
+		CipherSuites:      config.CipherSuites,
+
+		MaxReconnectInterval: parseDuration("mqtt-conn-max-reconnect-interval", config.MaxReconnectInterval, defaultMaxReconnectInterval),
+		Brokers:              brokers,
+		FailbackInterval:     parseDuration("mqtt-conn-failback-interval", config.FailbackInterval, defaultFailbackInterval),
+
+		ProtocolVersion:         config.ProtocolVersion,
+		SharedSubscriptionGroup: config.SharedSubscriptionGroup,
+
+		TopicPrefix:    config.TopicPrefix,
+		TopicVariables: config.TopicVariables,
 	}
+	if config.MessageExpiry != "" {
+		internalConfig.MessageExpiry = parseDuration("mqtt-conn-message-expiry", config.MessageExpiry, "0s")
+	}
+	return internalConfig
 }
 
 type mqttClient struct {
 	mqttConfig *internalConnectionConfig
 	pahoClient pahomqtt.Client
+	connection *connectionState
+	// failback stops watching the primary broker, when closed
+	failback chan struct{}
+	// response topics and correlation data of the received MQTT 5 requests, by activity ID
+	responses sync.Map
 
 	// UM incoming topics
 	topicCurrentState         string
 	topicDesiredStateFeedback string
 	topicOwnerConsentFeedback string
+	topicHistory              string
+	topicCompliance           string
 	// UM outgoing topics
 	topicDesiredState        string
 	topicDesiredStateCommand string
 	topicCurrentStateGet     string
 	topicOwnerConsent        string
+	topicHistoryGet          string
+	topicComplianceGet       string
 }
 
 func newInternalClient(domain string, config *internalConnectionConfig, pahoClient pahomqtt.Client) *mqttClient {
-	mqttPrefix := domainAsTopic(domain)
+	mqttPrefix := topicPrefix(domain, config)
 	return &mqttClient{
 		mqttConfig: config,
 		pahoClient: pahoClient,
+		connection: newConnectionState(),
 
 		topicCurrentState:         mqttPrefix + suffixCurrentState,
 		topicCurrentStateGet:      mqttPrefix + suffixCurrentStateGet,
@@ -103,22 +156,49 @@ func newInternalClient(domain string, config *internalConnectionConfig, pahoClie
 		topicDesiredStateFeedback: mqttPrefix + suffixDesiredStateFeedback,
 		topicOwnerConsent:         mqttPrefix + suffixOwnerConsent,
 		topicOwnerConsentFeedback: mqttPrefix + suffixOwnerConsentFeedback,
+		topicHistory:              mqttPrefix + suffixHistory,
+		topicHistoryGet:           mqttPrefix + suffixHistoryGet,
+		topicCompliance:           mqttPrefix + suffixCompliance,
+		topicComplianceGet:        mqttPrefix + suffixComplianceGet,
 	}
 }
 
 type updateAgentClient struct {
 	*mqttClient
-	domain  string
-	handler api.UpdateAgentHandler
+	domain   string
+	handler  api.UpdateAgentHandler
+	verifier *jws.Verifier
+	queue    *queue.Queue
+}
+
+// UpdateAgentClientOption defines an optional configuration of the update agent client.
+type UpdateAgentClientOption func(*updateAgentClient)
+
+// WithSignatureVerifier makes the update agent client accept only desired states, which are signed and successfully verified by the given verifier.
+func WithSignatureVerifier(verifier *jws.Verifier) UpdateAgentClientOption {
+	return func(client *updateAgentClient) {
+		client.verifier = verifier
+	}
+}
+
+// WithOutboundQueue makes the update agent client keep the current state and desired state feedback messages in the given queue,
+// until they are successfully published, e.g. while the MQTT broker is not reachable.
+func WithOutboundQueue(queue *queue.Queue) UpdateAgentClientOption {
+	return func(client *updateAgentClient) {
+		client.queue = queue
+	}
 }
 
 // NewUpdateAgentClient instantiates a new UpdateAgentClient instance using the provided configuration options.
-func NewUpdateAgentClient(domain string, config *ConnectionConfig) (api.UpdateAgentClient, error) {
+func NewUpdateAgentClient(domain string, config *ConnectionConfig, opts ...UpdateAgentClientOption) (api.UpdateAgentClient, error) {
 	client := &updateAgentClient{
 		mqttClient: newInternalClient(domain, newInternalConnectionConfig(config), nil),
 		domain:     domain,
 	}
-	pahoClient, err := newClient(client.mqttConfig, client.onConnect)
+	for _, opt := range opts {
+		opt(client)
+	}
+	pahoClient, err := newClient(client.mqttConfig, client.connection, client.onConnect)
 	if err == nil {
 		client.pahoClient = pahoClient
 	}
@@ -133,73 +213,83 @@ func (client *updateAgentClient) Domain() string {
 // Start connects the client to the MQTT broker.
 func (client *updateAgentClient) Start(handler api.UpdateAgentHandler) error {
 	client.handler = handler
+	client.connection.connecting()
 	token := client.pahoClient.Connect()
 	if !token.WaitTimeout(client.mqttConfig.ConnectTimeout) {
 		return fmt.Errorf("[%s] connect timed out", client.Domain())
 	}
-	return token.Error()
+	if err := token.Error(); err != nil {
+		return err
+	}
+	client.startFailback(client.Domain())
+	return nil
 }
 
 // Stop disconnects the client from the MQTT broker.
 func (client *updateAgentClient) Stop() error {
 	if err := client.unsubscribeStateTopics(); err != nil {
-		logger.WarnErr(err, "[%s] error unsubscribing for DesiredState/DesiredStateCommand/CurrentStateGet requests", client.Domain())
+		logger.WarnErr(err, "[%s] error unsubscribing for DesiredState/DesiredStateCommand/CurrentStateGet/HistoryGet/ComplianceGet requests", client.Domain())
 	} else {
-		logger.Debug("[%s] unsubscribed for DesiredState/DesiredStateCommand/CurrentStateGet requests", client.Domain())
+		logger.Debug("[%s] unsubscribed for DesiredState/DesiredStateCommand/CurrentStateGet/HistoryGet/ComplianceGet requests", client.Domain())
 	}
+	client.stopFailback()
 	client.pahoClient.Disconnect(disconnectQuiesce)
+	client.connection.disconnected()
 	client.handler = nil
 	return nil
 }
 
 func (client *updateAgentClient) onConnect(_ pahomqtt.Client) {
+	if client.queue != nil {
+		go client.flushQueue(client.sendQueued)
+	}
 	go getAndPublishCurrentState(client.Domain(), client.handler.HandleCurrentStateGet)
 
-	if err := client.subscribeStateTopics(); err != nil {
-		logger.ErrorErr(err, "[%s] error subscribing for DesiredState/DesiredStateCommand/CurrentStateGet requests", client.Domain())
-	} else {
-		logger.Debug("[%s] subscribed for DesiredState/DesiredStateCommand/CurrentStateGet requests", client.Domain())
+	if client.subscribeAll(client.Domain(), client.subscribeStateTopics) {
+		logger.Debug("[%s] subscribed for DesiredState/DesiredStateCommand/CurrentStateGet/HistoryGet/ComplianceGet requests", client.Domain())
 	}
 }
 
 func (client *updateAgentClient) subscribeStateTopics() error {
-	topics := []string{client.topicDesiredState, client.topicDesiredStateCommand, client.topicCurrentStateGet}
-	topicsMap := map[string]byte{
-		client.topicDesiredState:        1,
-		client.topicDesiredStateCommand: 1,
-		client.topicCurrentStateGet:     1,
+	topics := client.stateTopics()
+	topicsMap := make(map[string]byte, len(topics))
+	for _, topic := range topics {
+		topicsMap[topic] = 1
 	}
 	logger.Debug("subscribing for '%s' topics", topics)
 	token := client.pahoClient.SubscribeMultiple(topicsMap, client.handleStateRequest)
 	if !token.WaitTimeout(client.mqttConfig.SubscribeTimeout) {
-		return fmt.Errorf("cannot subscribe for topics '%s,%s,%s' in '%v'", client.topicDesiredState, client.topicDesiredStateCommand, client.topicCurrentStateGet, client.mqttConfig.SubscribeTimeout)
+		return fmt.Errorf("cannot subscribe for topics '%s' in '%v'", strings.Join(topics, ","), client.mqttConfig.SubscribeTimeout)
 	}
 	return token.Error()
 }
 
 func (client *updateAgentClient) unsubscribeStateTopics() error {
-	topics := []string{client.topicDesiredState, client.topicDesiredStateCommand, client.topicCurrentStateGet}
-	logger.Debug("unsubscribing from '%s' topics", client.Domain(), topics)
+	topics := client.stateTopics()
+	logger.Debug("unsubscribing from '%s' topics", topics)
 	token := client.pahoClient.Unsubscribe(topics...)
 	if !token.WaitTimeout(client.mqttConfig.UnsubscribeTimeout) {
-		return fmt.Errorf("cannot unsubscribe from topics '%s,%s,%s' in '%v'", client.topicDesiredState, client.topicDesiredStateCommand, client.topicCurrentStateGet, client.mqttConfig.UnsubscribeTimeout)
+		return fmt.Errorf("cannot unsubscribe from topics '%s' in '%v'", strings.Join(topics, ","), client.mqttConfig.UnsubscribeTimeout)
 	}
 	return token.Error()
 }
 
+// stateTopics returns the topic filters of the update agent requests, which are shared subscriptions if a shared subscription group is configured.
+func (client *updateAgentClient) stateTopics() []string {
+	return []string{
+		client.sharedTopic(client.topicDesiredState),
+		client.sharedTopic(client.topicDesiredStateCommand),
+		client.sharedTopic(client.topicCurrentStateGet),
+		client.sharedTopic(client.topicHistoryGet),
+		client.sharedTopic(client.topicComplianceGet),
+	}
+}
+
 func (client *updateAgentClient) handleStateRequest(mqttClient pahomqtt.Client, message pahomqtt.Message) {
 	topic := message.Topic()
 	if topic == client.topicDesiredState {
 		logger.Debug("[%s] received desired state request", client.Domain())
-		desiredState := &types.DesiredState{}
-		envelope, err := types.FromEnvelope(message.Payload(), desiredState)
-		if err != nil {
-			logger.ErrorErr(err, "[%s] cannot parse desired state message", client.Domain())
-			return
-		}
-		if err := client.handler.HandleDesiredState(envelope.ActivityID, envelope.Timestamp, desiredState); err != nil {
-			logger.ErrorErr(err, "[%s] error processing desired state request", client.Domain())
-		}
+		client.handleDesiredState(message.Payload())
 		return
 	}
 	if topic == client.topicDesiredStateCommand {
@@ -215,17 +305,122 @@ func (client *updateAgentClient) handleStateRequest(mqttClient pahomqtt.Client,
 		}
 		return
 	}
+	if topic == client.topicHistoryGet {
+		client.handleHistoryGet(message.Payload())
+		return
+	}
+	if topic == client.topicComplianceGet {
+		client.handleComplianceGet(message.Payload())
+		return
+	}
 	logger.Trace("[%s] received current state get request", client.Domain())
 	envelope, err := types.FromEnvelope(message.Payload(), nil)
 	if err != nil {
 		logger.ErrorErr(err, "[%s] cannot parse current state get message", client.Domain())
 		return
 	}
+	client.registerResponse(envelope.ActivityID, message)
 	if err := client.handler.HandleCurrentStateGet(envelope.ActivityID, envelope.Timestamp); err != nil {
 		logger.ErrorErr(err, "[%s] error processing current state get request", client.Domain())
 	}
 }
 
+func (client *updateAgentClient) handleDesiredState(payload []byte) {
+	var signer string
+	if client.verifier != nil {
+		if !jws.IsSigned(payload) {
+			client.rejectDesiredState(errors.New("desired state is not signed"))
+			return
+		}
+		verifiedPayload, subject, err := client.verifier.Verify(payload)
+		if err != nil {
+			client.rejectDesiredState(err)
+			return
+		}
+		logger.Debug("[%s] desired state signature of '%s' verified", client.Domain(), subject)
+		payload, signer = verifiedPayload, subject
+	}
+	desiredState := &types.DesiredState{}
+	envelope, err := types.FromEnvelope(payload, desiredState)
+	if err != nil {
+		logger.ErrorErr(err, "[%s] cannot parse desired state message", client.Domain())
+		return
+	}
+	if signedHandler, ok := client.handler.(api.SignedDesiredStateHandler); ok && signer != "" {
+		err = signedHandler.HandleSignedDesiredState(envelope.ActivityID, envelope.Timestamp, signer, desiredState)
+	} else {
+		err = client.handler.HandleDesiredState(envelope.ActivityID, envelope.Timestamp, desiredState)
+	}
+	if err != nil {
+		logger.ErrorErr(err, "[%s] error processing desired state request", client.Domain())
+	}
+}
+
+// rejectDesiredState records the rejection in the activity history, if supported by the handler, and reports IDENTIFICATION_FAILED
+// feedback for a desired state with missing or invalid signature. The activity ID of the rejected desired state cannot be trusted,
+// so it is neither recorded nor echoed in the feedback.
+func (client *updateAgentClient) rejectDesiredState(reason error) {
+	logger.ErrorErr(reason, "[%s] rejected desired state request", client.Domain())
+	reason = errors.Wrap(reason, "desired state signature verification failed")
+	if handler, ok := client.handler.(api.RejectedDesiredStateHandler); ok {
+		handler.HandleRejectedDesiredState("", "", reason)
+	}
+	if err := client.SendDesiredStateFeedback("", &types.DesiredStateFeedback{
+		Status:  types.StatusIdentificationFailed,
+		Message: reason.Error(),
+	}); err != nil {
+		logger.ErrorErr(err, "[%s] error sending desired state feedback", client.Domain())
+	}
+}
+
+func (client *updateAgentClient) handleHistoryGet(payload []byte) {
+	logger.Trace("[%s] received history get request", client.Domain())
+	provider, ok := client.handler.(api.ActivityHistoryProvider)
+	if !ok {
+		logger.Debug("[%s] activity history is not supported", client.Domain())
+		return
+	}
+	query := &types.ActivityHistoryQuery{}
+	envelope, err := types.FromEnvelope(payload, query)
+	if err != nil {
+		logger.ErrorErr(err, "[%s] cannot parse history get message", client.Domain())
+		return
+	}
+	historyBytes, err := types.ToEnvelope(envelope.ActivityID, provider.ActivityHistory(query))
+	if err != nil {
+		logger.ErrorErr(err, "[%s] cannot marshal history message", client.Domain())
+		return
+	}
+	logger.Debug("[%s] publishing activity history...", client.Domain())
+	if err := client.publish(client.topicHistory, false, historyBytes); err != nil {
+		logger.ErrorErr(err, "[%s] error publishing activity history", client.Domain())
+	}
+}
+
+func (client *updateAgentClient) handleComplianceGet(payload []byte) {
+	logger.Trace("[%s] received compliance get request", client.Domain())
+	provider, ok := client.handler.(api.ComplianceProvider)
+	if !ok {
+		logger.Debug("[%s] compliance check is not supported", client.Domain())
+		return
+	}
+	desiredState := &types.DesiredState{}
+	envelope, err := types.FromEnvelope(payload, desiredState)
+	if err != nil {
+		logger.ErrorErr(err, "[%s] cannot parse compliance get message", client.Domain())
+		return
+	}
+	complianceBytes, err := types.ToEnvelope(envelope.ActivityID, provider.Compliance(desiredState))
+	if err != nil {
+		logger.ErrorErr(err, "[%s] cannot marshal compliance message", client.Domain())
+		return
+	}
+	logger.Debug("[%s] publishing compliance report...", client.Domain())
+	if err := client.publish(client.topicCompliance, false, complianceBytes); err != nil {
+		logger.ErrorErr(err, "[%s] error publishing compliance report", client.Domain())
+	}
+}
+
 // SendCurrentState makes the client create envelope raw bytes with the given activityID and current state inventory and send the raw bytes as current state message.
 func (client *updateAgentClient) SendCurrentState(activityID string, currentState *types.Inventory) error {
 	currentStateBytes, err := types.ToEnvelope(activityID, currentState)
@@ -233,11 +428,22 @@ func (client *updateAgentClient) SendCurrentState(activityID string, currentStat
 		return errors.Wrapf(err, "[%s] cannot marshal current state message", client.Domain())
 	}
 	if logger.IsTraceEnabled() {
-		logger.Trace("[%s] publishing current state '%s'....", client.Domain(), currentState)
+		logger.Trace("[%s] publishing current state '%v'....", client.Domain(), currentState)
 	} else {
 		logger.Debug("[%s] publishing current state...", client.Domain())
 	}
-	return client.publish(client.topicCurrentState, true, currentStateBytes)
+	response := client.takeResponse(activityID)
+	if response == nil {
+		return client.publishCurrentState(activityID, currentStateBytes)
+	}
+	if response.topic != client.topicCurrentState {
+		if err := client.publishCurrentState(activityID, currentStateBytes); err != nil {
+			return err
+		}
+	}
+	logger.Debug("responding to topic '%s'", response.topic)
+	client.publishWithProperties(response.topic, response.topic == client.topicCurrentState, currentStateBytes, &publishProperties{correlationData: response.correlationData})
+	return nil
 }
 
 // SendDesiredStateFeedback makes the client create envelope raw bytes with the given activityID and desired state feedback and send the raw bytes as desired state feedback message.
@@ -247,9 +453,70 @@ func (client *updateAgentClient) SendDesiredStateFeedback(activityID string, des
 		return errors.Wrapf(err, "[%s] cannot marshal desired state feedback message", client.Domain())
 	}
 	logger.Debug("[%s] publishing desired state feedback '%s'", client.Domain(), desiredStateFeedbackBytes)
+	if client.queue != nil {
+		client.enqueue(&queue.Message{
+			Kind:       queue.KindDesiredStateFeedback,
+			ActivityID: activityID,
+			Payload:    desiredStateFeedbackBytes,
+			Terminal:   isTerminalStatus(desiredStateFeedback.Status),
+		}, client.pahoClient.IsConnectionOpen(), client.sendQueued)
+		return nil
+	}
 	return client.publish(client.topicDesiredStateFeedback, false, desiredStateFeedbackBytes)
 }
 
+func (client *updateAgentClient) publishCurrentState(activityID string, currentStateBytes []byte) error {
+	if client.queue != nil {
+		client.enqueue(&queue.Message{
+			Kind:       queue.KindCurrentState,
+			ActivityID: activityID,
+			Payload:    currentStateBytes,
+		}, client.pahoClient.IsConnectionOpen(), client.sendQueued)
+		return nil
+	}
+	return client.publish(client.topicCurrentState, true, currentStateBytes)
+}
+
+// enqueue adds the given message to the outbound queue and flushes the queue asynchronously, if connected.
+func (client *updateAgentClient) enqueue(message *queue.Message, connected bool, send func(*queue.Message) error) {
+	client.queue.Enqueue(message)
+	if connected {
+		go client.flushQueue(send)
+	} else {
+		logger.Debug("[%s] not connected, %s message for activity '%s' queued", client.Domain(), message.Kind, message.ActivityID)
+	}
+}
+
+func (client *updateAgentClient) flushQueue(send func(*queue.Message) error) {
+	if err := client.queue.Flush(send); err != nil {
+		logger.WarnErr(err, "[%s] cannot send queued messages, %d messages remain queued", client.Domain(), client.queue.Len())
+	}
+}
+
+// sendQueued publishes the given queued message and waits for its acknowledgement.
+func (client *updateAgentClient) sendQueued(message *queue.Message) error {
+	topic, retained := client.topicDesiredStateFeedback, false
+	if message.Kind == queue.KindCurrentState {
+		topic, retained = client.topicCurrentState, true
+	}
+	logger.Debug("[%s] publishing queued %s message for activity '%s'", client.Domain(), message.Kind, message.ActivityID)
+	token := client.pahoClient.Publish(topic, 1, retained, []byte(message.Payload))
+	if !token.WaitTimeout(client.mqttConfig.AcknowledgeTimeout) {
+		return fmt.Errorf("cannot publish to topic '%s' in '%v'", topic, client.mqttConfig.AcknowledgeTimeout)
+	}
+	return token.Error()
+}
+
+// isTerminalStatus returns true if the given desired state feedback status is a final one, i.e. no further feedback is expected for the activity.
+func isTerminalStatus(status types.StatusType) bool {
+	switch status {
+	case types.StatusCompleted, types.StatusIncomplete, types.StatusIncompleteInconsistent, types.StatusIdentificationFailed, types.StatusSuperseded:
+		return true
+	default:
+		return false
+	}
+}
+
 func (client *updateAgentClient) publish(topic string, retained bool, message []byte) error {
 	logger.Debug("publishing to topic '%s'", topic)
 	client.pahoClient.Publish(topic, 1, retained, message)
@@ -265,38 +532,60 @@ func domainAsTopic(domain string) string {
 	return domain + "update"
 }
 
-func newClient(config *internalConnectionConfig, onConnect pahomqtt.OnConnectHandler) (pahomqtt.Client, error) {
+func newClient(config *internalConnectionConfig, state *connectionState, onConnect pahomqtt.OnConnectHandler) (pahomqtt.Client, error) {
+	switch config.ProtocolVersion {
+	case protocolVersion5:
+		return newClientV5(config, state, onConnect)
+	case "", protocolVersion311:
+	default:
+		return nil, errors.Errorf("unsupported MQTT protocol version '%s'", config.ProtocolVersion)
+	}
+	brokers, tlsConfigs, err := config.brokerURLs()
+	if err != nil {
+		return nil, err
+	}
+	var lock sync.Mutex
+	var activeBroker string
 	clientOptions := pahomqtt.NewClientOptions().
 		SetClientID(uuid.New().String()).
-		AddBroker(config.Broker).
 		SetKeepAlive(config.KeepAlive).
 		SetCleanSession(true).
 		SetAutoReconnect(true).
+		SetMaxReconnectInterval(config.MaxReconnectInterval).
 		SetProtocolVersion(4).
 		SetConnectTimeout(config.ConnectTimeout).
-		SetOnConnectHandler(onConnect).
+		SetConnectionAttemptHandler(newConnectionAttemptHandler(tlsConfigs, func(broker string) {
+			lock.Lock()
+			activeBroker = broker
+			lock.Unlock()
+		})).
+		SetOnConnectHandler(func(client pahomqtt.Client) {
+			lock.Lock()
+			broker := activeBroker
+			lock.Unlock()
+			state.connected(broker)
+			onConnect(client)
+		}).
+		SetConnectionLostHandler(func(_ pahomqtt.Client, err error) {
+			lock.Lock()
+			broker := activeBroker
+			lock.Unlock()
+			logger.WarnErr(err, "connection to MQTT broker '%s' lost", broker)
+			state.lost(err)
+		}).
+		SetReconnectingHandler(func(_ pahomqtt.Client, _ *pahomqtt.ClientOptions) {
+			logger.Debug("reconnecting to MQTT broker")
+		}).
 		SetUsername(config.Username).
 		SetPassword(config.Password)
-
-	u, err := url.Parse(config.Broker)
-	if err != nil {
-		return nil, err
+	for _, broker := range brokers {
+		clientOptions.AddBroker(broker.String())
 	}
-	if isConnectionSecure(u.Scheme) {
-		if len(config.CACert) == 0 {
-			return nil, errors.New("connection is secure, but no TLS configuration is provided")
-		}
-		tlsConfig, err := tls.NewTLSConfig(config.CACert, config.Cert, config.Key)
-		if err != nil {
-			return nil, err
-		}
-		clientOptions.SetTLSConfig(tlsConfig)
-	}
-
 	return pahomqtt.NewClient(clientOptions), nil
 }
 
-func isConnectionSecure(schema string) bool {
+// IsConnectionSecure checks if the given broker URL scheme is for a secure connection.
+func IsConnectionSecure(schema string) bool {
 	switch schema {
 	case "wss", "ssl", "tls", "mqtts", "mqtt+ssl", "tcps":
 		return true
@@ -19,7 +19,10 @@ import (
 
 	"github.com/eclipse-kanto/update-manager/api/types"
 	mqttmocks "github.com/eclipse-kanto/update-manager/mqtt/mocks"
+	"github.com/eclipse-kanto/update-manager/mqtt/queue"
+	"github.com/eclipse-kanto/update-manager/test"
 	"github.com/eclipse-kanto/update-manager/test/mocks"
+	"github.com/eclipse-kanto/update-manager/util/jws"
 
 	pahomqtt "github.com/eclipse/paho.mqtt.golang"
 	"github.com/golang/mock/gomock"
@@ -113,7 +116,7 @@ func TestUpdateAgentClientStop(t *testing.T) {
 				handler:    mockHandler,
 			}
 
-			mockPaho.EXPECT().Unsubscribe(test.domain+"update/desiredstate", test.domain+"update/desiredstate/command", test.domain+"update/currentstate/get").Return(mockToken)
+			mockPaho.EXPECT().Unsubscribe(test.domain+"update/desiredstate", test.domain+"update/desiredstate/command", test.domain+"update/currentstate/get", test.domain+"update/history/get", test.domain+"update/compliance/get").Return(mockToken)
 			mockPaho.EXPECT().Disconnect(disconnectQuiesce)
 			setupMockToken(mockToken, mqttTestConfig.UnsubscribeTimeout, test.isTimedOut)
 
@@ -240,6 +243,8 @@ func TestOnConnect(t *testing.T) {
 			"testupdate/currentstate/get":     1,
 			"testupdate/desiredstate":         1,
 			"testupdate/desiredstate/command": 1,
+			"testupdate/history/get":          1,
+			"testupdate/compliance/get":       1,
 		}
 		mockPaho.EXPECT().SubscribeMultiple(topicsMap, gomock.Any()).Return(mockToken)
 		setupMockToken(mockToken, mqttTestConfig.SubscribeTimeout, false)
@@ -289,6 +294,78 @@ func TestHandleDesiredStateMessage(t *testing.T) {
 	}
 }
 
+func TestHandleSignedDesiredStateMessage(t *testing.T) {
+	signer := test.NewSigner(t, t.TempDir())
+	verifier, err := jws.NewVerifier(&jws.Config{CACert: signer.CACert})
+	assert.NoError(t, err)
+	untrusted := test.NewSigner(t, t.TempDir())
+
+	mockCtrl, mockPaho, mockToken := setupCommonMocks(t)
+	defer mockCtrl.Finish()
+
+	mockMessage := mqttmocks.NewMockMessage(mockCtrl)
+	mockHandler := mocks.NewMockUpdateAgentHandler(mockCtrl)
+	mockSignedHandler := mocks.NewMockSignedDesiredStateHandler(mockCtrl)
+	rejectedHandler := &testRejectedDesiredStateHandler{}
+
+	updateAgentClient := &updateAgentClient{
+		mqttClient: newInternalClient("testdomain", mqttTestConfig, mockPaho),
+		domain:     "testdomain",
+		handler: &struct {
+			*mocks.MockUpdateAgentHandler
+			*mocks.MockSignedDesiredStateHandler
+			*testRejectedDesiredStateHandler
+		}{mockHandler, mockSignedHandler, rejectedHandler},
+		verifier: verifier,
+	}
+	envelope, err := types.ToEnvelope(test.ActivityID, test.DesiredState)
+	assert.NoError(t, err)
+
+	t.Run("test_handle_signed_desired_state_ok", func(t *testing.T) {
+		mockSignedHandler.EXPECT().HandleSignedDesiredState(test.ActivityID, gomock.Any(), test.SignerSubject, test.DesiredState)
+		mockMessage.EXPECT().Topic().Return("testdomainupdate/desiredstate")
+		mockMessage.EXPECT().Payload().Return(signer.Sign(t, envelope))
+
+		updateAgentClient.handleStateRequest(nil, mockMessage)
+	})
+
+	rejected := map[string][]byte{
+		"test_handle_signed_desired_state_not_signed": envelope,
+		"test_handle_signed_desired_state_untrusted":  untrusted.Sign(t, envelope),
+		"test_handle_signed_desired_state_invalid":    []byte("invalid"),
+	}
+	for name, payload := range rejected {
+		t.Run(name, func(t *testing.T) {
+			mockPaho.EXPECT().Publish("testdomainupdate/desiredstatefeedback", uint8(1), false, gomock.Any()).DoAndReturn(
+				func(topic string, qos byte, retained bool, payload interface{}) pahomqtt.Token {
+					feedback := &types.DesiredStateFeedback{}
+					envelope, err := types.FromEnvelope(payload.([]byte), feedback)
+					assert.NoError(t, err)
+					// the activity id of the rejected desired state is not verified, so it is not echoed
+					assert.Equal(t, "", envelope.ActivityID)
+					assert.Equal(t, types.StatusIdentificationFailed, feedback.Status)
+					return mockToken
+				})
+			mockMessage.EXPECT().Topic().Return("testdomainupdate/desiredstate")
+			mockMessage.EXPECT().Payload().Return(payload)
+
+			updateAgentClient.handleStateRequest(nil, mockMessage)
+			assert.Equal(t, "", rejectedHandler.activityID)
+			assert.Contains(t, rejectedHandler.reason.Error(), "desired state signature verification failed")
+		})
+	}
+}
+
+// testRejectedDesiredStateHandler keeps the last desired state rejection.
+type testRejectedDesiredStateHandler struct {
+	activityID string
+	reason     error
+}
+
+func (handler *testRejectedDesiredStateHandler) HandleRejectedDesiredState(activityID string, signer string, reason error) {
+	handler.activityID, handler.reason = activityID, reason
+}
+
 func TestHandleDesiredStateCommandMessage(t *testing.T) {
 	tests := map[string]testCaseIncoming{
 		"test_handle_desired_state_command_ok":         {domain: "testdomain", handlerError: nil, expectedJSONErr: false},
@@ -356,3 +433,166 @@ func TestHandleCurrentStateGetMessage(t *testing.T) {
 		})
 	}
 }
+
+func TestHandleHistoryGetMessage(t *testing.T) {
+	tests := map[string]testCaseIncoming{
+		"test_handle_history_get_ok":         {domain: "testdomain", expectedJSONErr: false},
+		"test_handle_history_get_json_error": {domain: "testdomain", expectedJSONErr: true},
+	}
+
+	mockCtrl, mockPaho, mockToken := setupCommonMocks(t)
+	defer mockCtrl.Finish()
+
+	mockMessage := mqttmocks.NewMockMessage(mockCtrl)
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			testQuery := &types.ActivityHistoryQuery{ActivityID: name, Limit: 5}
+			testHistory := &types.ActivityHistory{Activities: []*types.ActivityRecord{{ActivityID: name, Status: types.StatusCompleted}}}
+			testBytes, expectedCalls := testBytesToEnvelope(t, name, testQuery, test.expectedJSONErr)
+
+			mockProvider := mocks.NewMockActivityHistoryProvider(mockCtrl)
+			mockProvider.EXPECT().ActivityHistory(testQuery).Times(expectedCalls).Return(testHistory)
+			mockPaho.EXPECT().Publish(test.domain+"update/history", uint8(1), false, gomock.Any()).Times(expectedCalls).DoAndReturn(
+				func(topic string, qos byte, retained bool, payload interface{}) pahomqtt.Token {
+					history := &types.ActivityHistory{}
+					envelope, err := types.FromEnvelope(payload.([]byte), history)
+					assert.NoError(t, err)
+					assert.Equal(t, name, envelope.ActivityID)
+					assert.Equal(t, testHistory, history)
+					return mockToken
+				})
+
+			updateAgentClient := &updateAgentClient{
+				mqttClient: newInternalClient(test.domain, mqttTestConfig, mockPaho),
+				domain:     test.domain,
+				handler: &struct {
+					*mocks.MockUpdateAgentHandler
+					*mocks.MockActivityHistoryProvider
+				}{mocks.NewMockUpdateAgentHandler(mockCtrl), mockProvider},
+			}
+			mockMessage.EXPECT().Topic().Return(test.domain + "update/history/get")
+			mockMessage.EXPECT().Payload().Return(testBytes)
+
+			updateAgentClient.handleStateRequest(nil, mockMessage)
+		})
+	}
+
+	t.Run("test_handle_history_get_not_supported", func(t *testing.T) {
+		updateAgentClient := &updateAgentClient{
+			mqttClient: newInternalClient("testdomain", mqttTestConfig, mockPaho),
+			domain:     "testdomain",
+			handler:    mocks.NewMockUpdateAgentHandler(mockCtrl),
+		}
+		mockMessage.EXPECT().Topic().Return("testdomainupdate/history/get")
+		mockMessage.EXPECT().Payload().Return([]byte(`{"activityId":"test"}`))
+
+		updateAgentClient.handleStateRequest(nil, mockMessage)
+	})
+}
+
+func TestHandleComplianceGetMessage(t *testing.T) {
+	tests := map[string]testCaseIncoming{
+		"test_handle_compliance_get_ok":         {domain: "testdomain", expectedJSONErr: false},
+		"test_handle_compliance_get_json_error": {domain: "testdomain", expectedJSONErr: true},
+	}
+
+	mockCtrl, mockPaho, mockToken := setupCommonMocks(t)
+	defer mockCtrl.Finish()
+
+	mockMessage := mqttmocks.NewMockMessage(mockCtrl)
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			testDesiredState := &types.DesiredState{Domains: []*types.Domain{{ID: "containers", Components: []*types.ComponentWithConfig{{Component: types.Component{ID: "app", Version: "1.0"}}}}}}
+			testReport := &types.ComplianceReport{Components: []*types.ComponentCompliance{{Domain: "containers", ID: "app", Status: types.ComplianceMissing, DesiredVersion: "1.0"}}}
+			testBytes, expectedCalls := testBytesToEnvelope(t, name, testDesiredState, test.expectedJSONErr)
+
+			mockProvider := mocks.NewMockComplianceProvider(mockCtrl)
+			mockProvider.EXPECT().Compliance(testDesiredState).Times(expectedCalls).Return(testReport)
+			mockPaho.EXPECT().Publish(test.domain+"update/compliance", uint8(1), false, gomock.Any()).Times(expectedCalls).DoAndReturn(
+				func(topic string, qos byte, retained bool, payload interface{}) pahomqtt.Token {
+					report := &types.ComplianceReport{}
+					envelope, err := types.FromEnvelope(payload.([]byte), report)
+					assert.NoError(t, err)
+					assert.Equal(t, name, envelope.ActivityID)
+					assert.Equal(t, testReport, report)
+					return mockToken
+				})
+
+			updateAgentClient := &updateAgentClient{
+				mqttClient: newInternalClient(test.domain, mqttTestConfig, mockPaho),
+				domain:     test.domain,
+				handler: &struct {
+					*mocks.MockUpdateAgentHandler
+					*mocks.MockComplianceProvider
+				}{mocks.NewMockUpdateAgentHandler(mockCtrl), mockProvider},
+			}
+			mockMessage.EXPECT().Topic().Return(test.domain + "update/compliance/get")
+			mockMessage.EXPECT().Payload().Return(testBytes)
+
+			updateAgentClient.handleStateRequest(nil, mockMessage)
+		})
+	}
+
+	t.Run("test_handle_compliance_get_not_supported", func(t *testing.T) {
+		updateAgentClient := &updateAgentClient{
+			mqttClient: newInternalClient("testdomain", mqttTestConfig, mockPaho),
+			domain:     "testdomain",
+			handler:    mocks.NewMockUpdateAgentHandler(mockCtrl),
+		}
+		mockMessage.EXPECT().Topic().Return("testdomainupdate/compliance/get")
+		mockMessage.EXPECT().Payload().Return([]byte(`{"activityId":"test"}`))
+
+		updateAgentClient.handleStateRequest(nil, mockMessage)
+	})
+}
+
+func TestSendWithOutboundQueue(t *testing.T) {
+	mockCtrl, mockPaho, mockToken := setupCommonMocks(t)
+	defer mockCtrl.Finish()
+
+	outboundQueue, err := queue.NewQueue(&queue.Config{Enabled: true})
+	assert.NoError(t, err)
+	updateAgentClient := &updateAgentClient{
+		domain:     "testdomain",
+		mqttClient: newInternalClient("testdomain", mqttTestConfig, mockPaho),
+	}
+	WithOutboundQueue(outboundQueue)(updateAgentClient)
+
+	mockPaho.EXPECT().IsConnectionOpen().Return(false).Times(4)
+	assert.NoError(t, updateAgentClient.SendDesiredStateFeedback("activity-1", &types.DesiredStateFeedback{Status: types.StatusRunning}))
+	assert.NoError(t, updateAgentClient.SendCurrentState("activity-1", &types.Inventory{}))
+	assert.NoError(t, updateAgentClient.SendDesiredStateFeedback("activity-1", &types.DesiredStateFeedback{Status: types.StatusCompleted}))
+	assert.NoError(t, updateAgentClient.SendCurrentState("activity-2", &types.Inventory{}))
+	assert.Equal(t, 2, outboundQueue.Len())
+
+	mockPaho.EXPECT().Publish("testdomainupdate/desiredstatefeedback", uint8(1), false, gomock.Any()).DoAndReturn(
+		func(topic string, qos byte, retained bool, payload interface{}) pahomqtt.Token {
+			feedback := &types.DesiredStateFeedback{}
+			envelope, err := types.FromEnvelope(payload.([]byte), feedback)
+			assert.NoError(t, err)
+			assert.Equal(t, "activity-1", envelope.ActivityID)
+			assert.Equal(t, types.StatusCompleted, feedback.Status)
+			return mockToken
+		})
+	setupMockToken(mockToken, mqttTestConfig.AcknowledgeTimeout, false)
+	mockPaho.EXPECT().Publish("testdomainupdate/currentstate", uint8(1), true, gomock.Any()).Return(mockToken)
+	setupMockToken(mockToken, mqttTestConfig.AcknowledgeTimeout, true)
+	updateAgentClient.flushQueue(updateAgentClient.sendQueued)
+	assert.Equal(t, 1, outboundQueue.Len())
+
+	mockPaho.EXPECT().Publish("testdomainupdate/currentstate", uint8(1), true, gomock.Any()).Return(mockToken)
+	setupMockToken(mockToken, mqttTestConfig.AcknowledgeTimeout, false)
+	updateAgentClient.flushQueue(updateAgentClient.sendQueued)
+	assert.Equal(t, 0, outboundQueue.Len())
+}
+
+func TestIsTerminalStatus(t *testing.T) {
+	for _, status := range []types.StatusType{types.StatusCompleted, types.StatusIncomplete, types.StatusIncompleteInconsistent, types.StatusIdentificationFailed, types.StatusSuperseded} {
+		assert.True(t, isTerminalStatus(status))
+	}
+	for _, status := range []types.StatusType{types.StatusIdentifying, types.StatusIdentified, types.StatusRunning, types.BaselineStatusUpdating} {
+		assert.False(t, isTerminalStatus(status))
+	}
+}
@@ -19,6 +19,7 @@ import (
 	"github.com/eclipse-kanto/update-manager/api"
 	"github.com/eclipse-kanto/update-manager/api/types"
 	"github.com/eclipse-kanto/update-manager/logger"
+	"github.com/eclipse-kanto/update-manager/mqtt/queue"
 	"github.com/eclipse-kanto/update-manager/things"
 
 	"github.com/eclipse/ditto-clients-golang"
@@ -45,7 +46,7 @@ type updateAgentThingsClient struct {
 }
 
 // NewUpdateAgentThingsClient instantiates a new UpdateAgentClient instance using the provided configuration options.
-func NewUpdateAgentThingsClient(domain string, config *ConnectionConfig) (api.UpdateAgentClient, error) {
+func NewUpdateAgentThingsClient(domain string, config *ConnectionConfig, opts ...UpdateAgentClientOption) (api.UpdateAgentClient, error) {
 	internalConfig := newInternalConnectionConfig(config)
 	client := &updateAgentThingsClient{
 		updateAgentClient: &updateAgentClient{
@@ -53,7 +54,10 @@ func NewUpdateAgentThingsClient(domain string, config *ConnectionConfig) (api.Up
 			domain:     domain,
 		},
 	}
-	pahoClient, err := newClient(internalConfig, client.onConnect)
+	for _, opt := range opts {
+		opt(client.updateAgentClient)
+	}
+	pahoClient, err := newClient(internalConfig, client.connection, client.onConnect)
 	if err == nil {
 		client.pahoClient = pahoClient
 	}
@@ -68,11 +72,16 @@ func (client *updateAgentThingsClient) Domain() string {
 // Start connects the client to the MQTT broker and gets the edge configuration.
 func (client *updateAgentThingsClient) Start(handler api.UpdateAgentHandler) error {
 	client.handler = handler
+	client.connection.connecting()
 	token := client.pahoClient.Connect()
 	if !token.WaitTimeout(client.mqttConfig.ConnectTimeout) {
 		return fmt.Errorf("[%s] connect timed out", client.Domain())
 	}
-	return token.Error()
+	if err := token.Error(); err != nil {
+		return err
+	}
+	client.startFailback(client.Domain())
+	return nil
 }
 
 func (client *updateAgentThingsClient) handleEdgeResponse(_ pahomqtt.Client, message pahomqtt.Message) {
@@ -100,6 +109,9 @@ func (client *updateAgentThingsClient) handleEdgeResponse(_ pahomqtt.Client, mes
 				if err = client.umFeature.Activate(); err != nil {
 					logger.ErrorErr(err, "[%s] could not activate update manager feature", client.Domain())
 				} else {
+					if client.queue != nil {
+						go client.flushQueue(client.sendQueued)
+					}
 					go getAndPublishCurrentState(client.Domain(), client.handler.HandleCurrentStateGet)
 				}
 			})
@@ -108,7 +120,7 @@ func (client *updateAgentThingsClient) handleEdgeResponse(_ pahomqtt.Client, mes
 			logger.ErrorErr(err, "[%s] could not create ditto client", client.Domain())
 			return
 		}
-		client.umFeature = things.NewUpdateManagerFeature(client.Domain(), localCfg.DeviceID, client.dittoClient, client.handler)
+		client.umFeature = things.NewUpdateManagerFeature(client.Domain(), localCfg.DeviceID, client.dittoClient, client.handler, client.verifier)
 
 		if err = client.dittoClient.Connect(); err != nil {
 			logger.ErrorErr(err, "[%s] could not connect to ditto endpoint", client.Domain())
@@ -116,6 +128,12 @@ func (client *updateAgentThingsClient) handleEdgeResponse(_ pahomqtt.Client, mes
 		}
 		client.edgeConfig = localCfg
 		logger.Info("[%s] edge configuration applied [TenantID: %s, DeviceID: %s, PolicyID: %s]", client.Domain(), localCfg.TenantID, localCfg.DeviceID, localCfg.PolicyID)
+	} else {
+		// the connection to the MQTT broker is reestablished, the queued messages are sent and the current state is published with the updated connection status
+		if client.queue != nil {
+			go client.flushQueue(client.sendQueued)
+		}
+		go getAndPublishCurrentState(client.Domain(), client.handler.HandleCurrentStateGet)
 	}
 }
 
@@ -134,29 +152,37 @@ func (client *updateAgentThingsClient) Stop() error {
 		client.dittoClient.Disconnect()
 	}
 
+	client.stopFailback()
 	client.pahoClient.Disconnect(disconnectQuiesce)
+	client.connection.disconnected()
 	client.handler = nil
 	return nil
 }
 
 func (client *updateAgentThingsClient) onConnect(_ pahomqtt.Client) {
+	if client.subscribeAll(client.Domain(), client.requestEdgeConfiguration) {
+		logger.Debug("[%s] subscribed for topic '%s'", client.Domain(), edgeResponseTopic)
+	}
+}
+
+// requestEdgeConfiguration subscribes for the edge configuration responses and requests the edge configuration.
+func (client *updateAgentThingsClient) requestEdgeConfiguration() error {
 	token := client.pahoClient.Subscribe(edgeResponseTopic, 1, client.handleEdgeResponse)
 	if !token.WaitTimeout(client.mqttConfig.SubscribeTimeout) {
-		logger.Error("[%s] cannot subscribe for topic '%s' in '%v'", client.Domain(), edgeResponseTopic, client.mqttConfig.SubscribeTimeout)
-		return
+		return fmt.Errorf("cannot subscribe for topic '%s' in '%v'", edgeResponseTopic, client.mqttConfig.SubscribeTimeout)
 	}
-	if token.Error() != nil {
-		logger.ErrorErr(token.Error(), "[%s] cannot subscribe for topic '%s'", client.Domain(), edgeResponseTopic)
-		return
+	if err := token.Error(); err != nil {
+		return fmt.Errorf("cannot subscribe for topic '%s': %w", edgeResponseTopic, err)
 	}
 
 	token = client.pahoClient.Publish(edgeRequestTopic, 1, false, "")
 	if !token.WaitTimeout(client.mqttConfig.AcknowledgeTimeout) {
-		logger.Error("[%s] cannot publish to topic '%s' in '%v'", client.Domain(), edgeRequestTopic, client.mqttConfig.AcknowledgeTimeout)
+		return fmt.Errorf("cannot publish to topic '%s' in '%v'", edgeRequestTopic, client.mqttConfig.AcknowledgeTimeout)
 	}
-	if token.Error() != nil {
-		logger.ErrorErr(token.Error(), "[%s] cannot publish to topic '%s'", client.Domain(), edgeRequestTopic)
+	if err := token.Error(); err != nil {
+		return fmt.Errorf("cannot publish to topic '%s': %w", edgeRequestTopic, err)
 	}
+	return nil
 }
 
 // SendCurrentState makes the client create envelope with the given activityID and current state inventory and updates the current state property of the feature.
@@ -164,10 +190,63 @@ func (client *updateAgentThingsClient) SendCurrentState(activityID string, curre
 
 	logger.Debug("[%s] publishing current state...", client.Domain())
 
-	return client.umFeature.SetState(activityID, currentState)
+	if client.queue != nil {
+		return client.enqueueThings(queue.KindCurrentState, activityID, currentState, false)
+	}
+	return ignoreNotActive(client.umFeature.SetState(activityID, currentState))
 }
 
 // SendDesiredStateFeedback makes the client create envelope with the given activityID and desired state feedback and send issues a desired state feedback message.
 func (client *updateAgentThingsClient) SendDesiredStateFeedback(activityID string, desiredStateFeedback *types.DesiredStateFeedback) error {
-	return client.umFeature.SendFeedback(activityID, desiredStateFeedback)
+	if client.queue != nil {
+		return client.enqueueThings(queue.KindDesiredStateFeedback, activityID, desiredStateFeedback, isTerminalStatus(desiredStateFeedback.Status))
+	}
+	return ignoreNotActive(client.umFeature.SendFeedback(activityID, desiredStateFeedback))
+}
+
+func (client *updateAgentThingsClient) enqueueThings(kind, activityID string, payload interface{}, terminal bool) error {
+	payloadBytes, err := types.ToEnvelope(activityID, payload)
+	if err != nil {
+		return fmt.Errorf("[%s] cannot marshal %s message: %w", client.Domain(), kind, err)
+	}
+	client.enqueue(&queue.Message{
+		Kind:       kind,
+		ActivityID: activityID,
+		Payload:    payloadBytes,
+		Terminal:   terminal,
+	}, client.connection.isConnected(), client.sendQueued)
+	return nil
+}
+
+// sendQueued sends the given queued message via the update manager feature, failing if the feature is not active,
+// so that the message is kept queued until the feature is activated again.
+func (client *updateAgentThingsClient) sendQueued(message *queue.Message) error {
+	if client.umFeature == nil {
+		return fmt.Errorf("[%s] update manager feature is not activated", client.Domain())
+	}
+	if message.Kind == queue.KindCurrentState {
+		currentState := &types.Inventory{}
+		envelope, err := types.FromEnvelope(message.Payload, currentState)
+		if err != nil {
+			logger.ErrorErr(err, "[%s] dropping invalid queued current state message", client.Domain())
+			return nil
+		}
+		return client.umFeature.SetState(envelope.ActivityID, currentState)
+	}
+	desiredStateFeedback := &types.DesiredStateFeedback{}
+	envelope, err := types.FromEnvelope(message.Payload, desiredStateFeedback)
+	if err != nil {
+		logger.ErrorErr(err, "[%s] dropping invalid queued desired state feedback message", client.Domain())
+		return nil
+	}
+	return client.umFeature.SendFeedback(envelope.ActivityID, desiredStateFeedback)
+}
+
+// ignoreNotActive drops silently the messages sent without the outbound queue while the update manager feature is not active,
+// the outbound queue keeps such messages until the feature is activated.
+func ignoreNotActive(err error) error {
+	if err == things.ErrNotActive {
+		return nil
+	}
+	return err
 }
@@ -21,8 +21,10 @@ import (
 
 	"github.com/eclipse-kanto/update-manager/api/types"
 	mqttmocks "github.com/eclipse-kanto/update-manager/mqtt/mocks"
+	"github.com/eclipse-kanto/update-manager/mqtt/queue"
 	"github.com/eclipse-kanto/update-manager/test"
 	"github.com/eclipse-kanto/update-manager/test/mocks"
+	"github.com/eclipse-kanto/update-manager/things"
 	"github.com/golang/mock/gomock"
 
 	"github.com/stretchr/testify/assert"
@@ -196,8 +198,10 @@ func TestThingOnConnect(t *testing.T) {
 	t.Run("test_onConnect_timeout", func(t *testing.T) {
 		mockPaho.EXPECT().Subscribe(edgeResponseTopic, byte(1), gomock.Any()).Return(mockToken)
 		setupMockToken(mockToken, mqttTestConfig.SubscribeTimeout, true)
+		mockPaho.EXPECT().IsConnectionOpen().Return(false)
 
 		updateAgentThingsClient.onConnect(nil)
+		assert.False(t, updateAgentThingsClient.ConnectionStatus().Subscribed)
 	})
 
 }
@@ -240,3 +244,101 @@ func TestHandleEdgeResponseThingsHandleDesiredStateMessage(t *testing.T) {
 	assert.NotNil(t, updateAgentThingsClient.umFeature)
 	test.AssertWithTimeout(t, testWG, 2*time.Second)
 }
+
+func TestHandleEdgeResponseThingsUnchangedConfiguration(t *testing.T) {
+	mockCtrl, mockPaho, _ := setupCommonMocks(t)
+	defer mockCtrl.Finish()
+
+	mockMessage := mqttmocks.NewMockMessage(mockCtrl)
+	mockHandler := mocks.NewMockUpdateAgentHandler(mockCtrl)
+
+	testEdgeConfig := &edgeConfiguration{DeviceID: "namespace:testDevice", TenantID: "testTenant", PolicyID: "testPolicy"}
+	testBytes, _ := json.Marshal(testEdgeConfig)
+	testWG := &sync.WaitGroup{}
+	testWG.Add(2)
+
+	mockFeature := mocks.NewMockUpdateManagerFeature(mockCtrl)
+	outboundQueue, err := queue.NewQueue(&queue.Config{Enabled: true})
+	assert.NoError(t, err)
+	updateAgentThingsClient := &updateAgentThingsClient{
+		updateAgentClient: &updateAgentClient{
+			mqttClient: newInternalClient(test.Domain, mqttTestConfig, mockPaho),
+			domain:     test.Domain,
+			handler:    mockHandler,
+			queue:      outboundQueue,
+		},
+		edgeConfig: &edgeConfiguration{DeviceID: "namespace:testDevice", TenantID: "testTenant", PolicyID: "testPolicy"},
+		umFeature:  mockFeature,
+	}
+	testFeedback := &types.DesiredStateFeedback{Status: types.StatusCompleted}
+	assert.NoError(t, updateAgentThingsClient.SendDesiredStateFeedback(test.ActivityID, testFeedback))
+
+	// the queued messages are sent and the current state is published again on reconnect, without applying the edge configuration
+	mockFeature.EXPECT().SendFeedback(test.ActivityID, testFeedback).DoAndReturn(func(string, *types.DesiredStateFeedback) error {
+		testWG.Done()
+		return nil
+	})
+	mockMessage.EXPECT().Payload().Return(testBytes)
+	mockHandler.EXPECT().HandleCurrentStateGet(gomock.Any(), gomock.Any()).DoAndReturn(func(string, int64) error {
+		testWG.Done()
+		return nil
+	})
+
+	updateAgentThingsClient.handleEdgeResponse(nil, mockMessage)
+	assert.Nil(t, updateAgentThingsClient.dittoClient)
+	test.AssertWithTimeout(t, testWG, 2*time.Second)
+}
+
+func TestThingsSendWithOutboundQueue(t *testing.T) {
+	mockCtrl, mockPaho, _ := setupCommonMocks(t)
+	mockFeature := mocks.NewMockUpdateManagerFeature(mockCtrl)
+	defer mockCtrl.Finish()
+
+	outboundQueue, err := queue.NewQueue(&queue.Config{Enabled: true})
+	assert.NoError(t, err)
+	updateAgentClient := &updateAgentThingsClient{
+		updateAgentClient: &updateAgentClient{
+			domain:     test.Domain,
+			mqttClient: newInternalClient(test.Domain, mqttTestConfig, mockPaho),
+			queue:      outboundQueue,
+		},
+	}
+	testFeedback := &types.DesiredStateFeedback{Status: types.StatusCompleted}
+	testCurrentState := &types.Inventory{SoftwareNodes: []*types.SoftwareNode{{InventoryNode: types.InventoryNode{ID: "test-node"}}}}
+
+	assert.NoError(t, updateAgentClient.SendDesiredStateFeedback(test.ActivityID, testFeedback))
+	assert.NoError(t, updateAgentClient.SendCurrentState(test.ActivityID, testCurrentState))
+	assert.Equal(t, 2, outboundQueue.Len())
+	updateAgentClient.flushQueue(updateAgentClient.sendQueued)
+	assert.Equal(t, 2, outboundQueue.Len())
+
+	updateAgentClient.umFeature = mockFeature
+	mockFeature.EXPECT().SendFeedback(test.ActivityID, testFeedback).Return(things.ErrNotActive)
+	updateAgentClient.flushQueue(updateAgentClient.sendQueued)
+	assert.Equal(t, 2, outboundQueue.Len())
+
+	mockFeature.EXPECT().SendFeedback(test.ActivityID, testFeedback)
+	mockFeature.EXPECT().SetState(test.ActivityID, testCurrentState)
+	updateAgentClient.flushQueue(updateAgentClient.sendQueued)
+	assert.Equal(t, 0, outboundQueue.Len())
+}
+
+func TestThingsSendWithoutOutboundQueueNotActive(t *testing.T) {
+	mockCtrl, mockPaho, _ := setupCommonMocks(t)
+	mockFeature := mocks.NewMockUpdateManagerFeature(mockCtrl)
+	defer mockCtrl.Finish()
+
+	updateAgentClient := &updateAgentThingsClient{
+		updateAgentClient: &updateAgentClient{
+			domain:     test.Domain,
+			mqttClient: newInternalClient(test.Domain, mqttTestConfig, mockPaho),
+		},
+		umFeature: mockFeature,
+	}
+	testFeedback := &types.DesiredStateFeedback{Status: types.StatusRunning}
+	mockFeature.EXPECT().SendFeedback(test.ActivityID, testFeedback).Return(things.ErrNotActive)
+	assert.NoError(t, updateAgentClient.SendDesiredStateFeedback(test.ActivityID, testFeedback))
+	testErr := fmt.Errorf("test error")
+	mockFeature.EXPECT().SendFeedback(test.ActivityID, testFeedback).Return(testErr)
+	assert.Equal(t, testErr, updateAgentClient.SendDesiredStateFeedback(test.ActivityID, testFeedback))
+}
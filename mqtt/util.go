@@ -18,12 +18,21 @@ import (
 	"github.com/eclipse-kanto/update-manager/api"
 )
 
-func getMQTTClient(client api.UpdateAgentClient) (*mqttClient, error) {
+// delegatingClient is implemented by update agent clients, which wrap another update agent client, e.g. to expose its API over an additional transport.
+type delegatingClient interface {
+	Delegate() api.UpdateAgentClient
+}
+
+func getMQTTClient(client api.BaseClient) (*mqttClient, error) {
 	switch v := client.(type) {
 	case *updateAgentClient:
 		return client.(*updateAgentClient).mqttClient, nil
 	case *updateAgentThingsClient:
 		return client.(*updateAgentThingsClient).mqttClient, nil
+	case *ownerConsentAgentClient:
+		return client.(*ownerConsentAgentClient).mqttClient, nil
+	case delegatingClient:
+		return getMQTTClient(client.(delegatingClient).Delegate())
 	default:
 		return nil, fmt.Errorf("unexpected type: %T", v)
 	}
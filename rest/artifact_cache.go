@@ -0,0 +1,141 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/eclipse-kanto/update-manager/api"
+	"github.com/eclipse-kanto/update-manager/api/types"
+	"github.com/eclipse-kanto/update-manager/logger"
+)
+
+func (client *updateAgentClient) handleCache(writer http.ResponseWriter, request *http.Request) {
+	if !checkMethod(writer, request, http.MethodGet) {
+		return
+	}
+	cache, ok := client.artifactCache(writer)
+	if !ok {
+		return
+	}
+	usage, err := cache.Usage()
+	if err != nil {
+		writeCacheError(writer, err)
+		return
+	}
+	writeJSON(writer, http.StatusOK, usage)
+}
+
+func (client *updateAgentClient) handleCacheArtifact(writer http.ResponseWriter, request *http.Request) {
+	if !checkMethod(writer, request, http.MethodGet) {
+		return
+	}
+	cache, ok := client.artifactCache(writer)
+	if !ok {
+		return
+	}
+	cached, err := cache.Lookup(request.URL.Query().Get(queryDigest))
+	if err != nil {
+		writeCacheError(writer, err)
+		return
+	}
+	writeJSON(writer, http.StatusOK, cached)
+}
+
+func (client *updateAgentClient) handleCacheReservation(writer http.ResponseWriter, request *http.Request) {
+	switch request.Method {
+	case http.MethodPost:
+		cache, ok := client.artifactCache(writer)
+		if !ok {
+			return
+		}
+		requested := &types.ArtifactReservation{}
+		bytes, err := io.ReadAll(http.MaxBytesReader(writer, request.Body, maxRequestSize))
+		if err == nil {
+			err = json.Unmarshal(bytes, requested)
+		}
+		if err != nil {
+			writeError(writer, http.StatusBadRequest, err.Error())
+			return
+		}
+		logger.Debug("[%s] received artifact cache reservation request for artifact %s over local HTTP API", client.Domain(), requested.Digest)
+		reservation, err := cache.Reserve(requested.Digest, requested.Size)
+		if err != nil {
+			writeCacheError(writer, err)
+			return
+		}
+		writeJSON(writer, http.StatusCreated, reservation)
+	case http.MethodDelete:
+		cache, ok := client.artifactCache(writer)
+		if !ok {
+			return
+		}
+		if err := cache.Release(request.URL.Query().Get(queryReservationID)); err != nil {
+			writeCacheError(writer, err)
+			return
+		}
+		writer.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(writer, http.StatusMethodNotAllowed, fmt.Sprintf("method %s is not allowed", request.Method))
+	}
+}
+
+func (client *updateAgentClient) handleCacheCommit(writer http.ResponseWriter, request *http.Request) {
+	if !checkMethod(writer, request, http.MethodPost) {
+		return
+	}
+	cache, ok := client.artifactCache(writer)
+	if !ok {
+		return
+	}
+	logger.Debug("[%s] received artifact cache commit request over local HTTP API", client.Domain())
+	cached, err := cache.Commit(request.URL.Query().Get(queryReservationID))
+	if err != nil {
+		writeCacheError(writer, err)
+		return
+	}
+	writeJSON(writer, http.StatusOK, cached)
+}
+
+// artifactCache returns the artifact cache of the update manager, an error response is written if it is not supported or not enabled.
+func (client *updateAgentClient) artifactCache(writer http.ResponseWriter) (api.ArtifactCache, bool) {
+	provider, ok := client.handler.(api.ArtifactCacheProvider)
+	if !ok {
+		writeError(writer, http.StatusNotImplemented, "artifact cache is not supported")
+		return nil, false
+	}
+	cache := provider.ArtifactCache()
+	if cache == nil {
+		writeError(writer, http.StatusNotImplemented, "artifact cache is not enabled")
+		return nil, false
+	}
+	return cache, true
+}
+
+// writeCacheError writes an error response with the HTTP status code, which corresponds to the given artifact cache error.
+func writeCacheError(writer http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, api.ErrArtifactNotCached), errors.Is(err, api.ErrArtifactReservationNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, api.ErrArtifactCacheFull):
+		status = http.StatusInsufficientStorage
+	case errors.Is(err, api.ErrInvalidArtifact):
+		status = http.StatusBadRequest
+	}
+	writeError(writer, status, err.Error())
+}
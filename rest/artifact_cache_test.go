@@ -0,0 +1,100 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package rest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/eclipse-kanto/update-manager/api"
+	"github.com/eclipse-kanto/update-manager/api/types"
+	"github.com/eclipse-kanto/update-manager/test/mocks"
+	"github.com/eclipse-kanto/update-manager/updatem/cache"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testArtifactCacheHandler struct {
+	*mocks.MockUpdateAgentHandler
+	cache api.ArtifactCache
+}
+
+func (handler *testArtifactCacheHandler) ArtifactCache() api.ArtifactCache {
+	return handler.cache
+}
+
+func TestHandleCacheRequests(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	artifactCache, err := cache.New(&cache.Config{Dir: t.TempDir(), MaxSize: 1, ReservationTimeout: "1m"})
+	require.NoError(t, err)
+	mockHandler := mocks.NewMockUpdateAgentHandler(mockCtrl)
+	client, _ := newTestClient(mockCtrl, &testArtifactCacheHandler{mockHandler, artifactCache})
+
+	sum := sha256.Sum256([]byte("data"))
+	digest := "sha256:" + hex.EncodeToString(sum[:])
+	response := doRequest(client, http.MethodGet, pathCacheArtifact+"?digest="+digest, "")
+	assert.Equal(t, http.StatusNotFound, response.Code)
+
+	response = doRequest(client, http.MethodPost, pathCacheReservation, `{"digest":"`+digest+`","size":4}`)
+	require.Equal(t, http.StatusCreated, response.Code)
+	reservation := &types.ArtifactReservation{}
+	require.NoError(t, json.Unmarshal(response.Body.Bytes(), reservation))
+	assert.Equal(t, digest, reservation.Digest)
+	require.NoError(t, os.WriteFile(reservation.Path, []byte("data"), 0644))
+
+	response = doRequest(client, http.MethodPost, pathCacheCommit+"?reservationId="+reservation.ID, "")
+	assert.Equal(t, http.StatusOK, response.Code)
+	response = doRequest(client, http.MethodGet, pathCacheArtifact+"?digest="+digest, "")
+	assert.Equal(t, http.StatusOK, response.Code)
+	cached := &types.CachedArtifact{}
+	require.NoError(t, json.Unmarshal(response.Body.Bytes(), cached))
+	assert.Equal(t, digest, cached.Digest)
+	assert.Equal(t, int64(4), cached.Size)
+	assert.FileExists(t, cached.Path)
+
+	response = doRequest(client, http.MethodGet, pathCache, "")
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.JSONEq(t, `{"artifacts":1,"size":4,"reserved":0,"maxSize":1048576}`, response.Body.String())
+
+	response = doRequest(client, http.MethodPost, pathCacheReservation, `{"digest":"`+digest+`","size":2000000}`)
+	assert.Equal(t, http.StatusInsufficientStorage, response.Code)
+	response = doRequest(client, http.MethodPost, pathCacheReservation, `{"digest":"md5:abc"}`)
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+	response = doRequest(client, http.MethodPost, pathCacheReservation, `invalid`)
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+
+	response = doRequest(client, http.MethodPost, pathCacheReservation, `{"digest":"`+digest+`"}`)
+	require.Equal(t, http.StatusCreated, response.Code)
+	require.NoError(t, json.Unmarshal(response.Body.Bytes(), reservation))
+	response = doRequest(client, http.MethodDelete, pathCacheReservation+"?reservationId="+reservation.ID, "")
+	assert.Equal(t, http.StatusNoContent, response.Code)
+	response = doRequest(client, http.MethodDelete, pathCacheReservation+"?reservationId="+reservation.ID, "")
+	assert.Equal(t, http.StatusNotFound, response.Code)
+	response = doRequest(client, http.MethodPut, pathCacheReservation, "")
+	assert.Equal(t, http.StatusMethodNotAllowed, response.Code)
+
+	client.handler = &testArtifactCacheHandler{mockHandler, nil}
+	response = doRequest(client, http.MethodGet, pathCache, "")
+	assert.Equal(t, http.StatusNotImplemented, response.Code)
+	client.handler = mockHandler
+	response = doRequest(client, http.MethodPost, pathCacheCommit, "")
+	assert.Equal(t, http.StatusNotImplemented, response.Code)
+}
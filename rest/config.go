@@ -0,0 +1,43 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package rest
+
+const (
+	// default local HTTP API config
+	defaultAddress      = ""
+	defaultReadTimeout  = "1m"
+	defaultWriteTimeout = "2m"
+
+	unixSocketPrefix = "unix://"
+)
+
+// ServerConfig represents the local HTTP API server config
+type ServerConfig struct {
+	Address      string `json:"address,omitempty"`
+	ReadTimeout  string `json:"readTimeout,omitempty"`
+	WriteTimeout string `json:"writeTimeout,omitempty"`
+}
+
+// NewDefaultConfig returns a default local HTTP API server config instance, the local HTTP API is disabled by default
+func NewDefaultConfig() *ServerConfig {
+	return &ServerConfig{
+		Address:      defaultAddress,
+		ReadTimeout:  defaultReadTimeout,
+		WriteTimeout: defaultWriteTimeout,
+	}
+}
+
+// IsEnabled returns true if an address for the local HTTP API is configured.
+func (config *ServerConfig) IsEnabled() bool {
+	return config != nil && config.Address != ""
+}
@@ -0,0 +1,135 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package rest
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/eclipse-kanto/update-manager/api"
+	"github.com/eclipse-kanto/update-manager/api/types"
+	"github.com/eclipse-kanto/update-manager/logger"
+)
+
+type ownerConsentClient struct {
+	*updateAgentClient
+	domain   string
+	delegate api.OwnerConsentClient
+
+	handlerLock sync.Mutex
+	handler     api.OwnerConsentHandler
+}
+
+// NewOwnerConsentClient instantiates a new client for handling owner consent approval through the local HTTP API.
+// If a delegate client is provided, the owner consent is requested through it as well and the first received feedback is taken into account.
+func NewOwnerConsentClient(domain string, updateAgent api.UpdateAgentClient, delegate api.OwnerConsentClient) (api.OwnerConsentClient, error) {
+	var client *updateAgentClient
+	switch v := updateAgent.(type) {
+	case *updateAgentClient:
+		client = v
+	default:
+		return nil, fmt.Errorf("unexpected type: %T", v)
+	}
+	return &ownerConsentClient{
+		updateAgentClient: client,
+		domain:            domain,
+		delegate:          delegate,
+	}, nil
+}
+
+// Domain returns the name of the domain that is handled by this client.
+func (client *ownerConsentClient) Domain() string {
+	return client.domain
+}
+
+// Start sets the consent handler to the local HTTP API and starts the delegate client, if any.
+func (client *ownerConsentClient) Start(consentHandler api.OwnerConsentHandler) error {
+	client.setHandler(consentHandler)
+	client.setConsentHandler(client)
+	if client.delegate != nil {
+		if err := client.delegate.Start(client); err != nil {
+			client.setConsentHandler(nil)
+			client.setHandler(nil)
+			return err
+		}
+	}
+	return nil
+}
+
+// Stop removes the consent handler from the local HTTP API and stops the delegate client, if any.
+func (client *ownerConsentClient) Stop() error {
+	client.setConsentHandler(nil)
+	client.setHandler(nil)
+	client.clearConsent()
+	if client.delegate != nil {
+		return client.delegate.Stop()
+	}
+	return nil
+}
+
+// SendOwnerConsent makes the owner consent request available through the local HTTP API and sends it with the delegate client, if any.
+func (client *ownerConsentClient) SendOwnerConsent(activityID string, consent *types.OwnerConsent) error {
+	client.setState(&client.consent, activityID, consent)
+	if client.delegate != nil {
+		if err := client.delegate.SendOwnerConsent(activityID, consent); err != nil {
+			logger.WarnErr(err, "[%s] cannot request owner consent through delegate client", client.Domain())
+		}
+	}
+	return nil
+}
+
+// HandleOwnerConsentFeedback forwards the first owner consent feedback for the pending owner consent request to the consent handler.
+func (client *ownerConsentClient) HandleOwnerConsentFeedback(activityID string, timestamp int64, consent *types.OwnerConsentFeedback) error {
+	if !client.takeConsent(activityID) {
+		logger.Debug("[%s] ignoring owner consent feedback for activity '%s', no such pending owner consent", client.Domain(), activityID)
+		return nil
+	}
+
+	handler := client.getHandler()
+	if handler == nil {
+		return fmt.Errorf("owner consent handler not available")
+	}
+	return handler.HandleOwnerConsentFeedback(activityID, timestamp, consent)
+}
+
+func (client *ownerConsentClient) takeConsent(activityID string) bool {
+	client.stateLock.Lock()
+	defer client.stateLock.Unlock()
+
+	if client.consent == nil || client.consent.ActivityID != activityID {
+		return false
+	}
+	client.consent = nil
+	return true
+}
+
+func (client *ownerConsentClient) clearConsent() {
+	client.stateLock.Lock()
+	defer client.stateLock.Unlock()
+
+	client.consent = nil
+}
+
+func (client *ownerConsentClient) setHandler(handler api.OwnerConsentHandler) {
+	client.handlerLock.Lock()
+	defer client.handlerLock.Unlock()
+
+	client.handler = handler
+}
+
+func (client *ownerConsentClient) getHandler() api.OwnerConsentHandler {
+	client.handlerLock.Lock()
+	defer client.handlerLock.Unlock()
+
+	return client.handler
+}
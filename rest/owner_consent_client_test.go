@@ -0,0 +1,108 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package rest
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/eclipse-kanto/update-manager/api/types"
+	"github.com/eclipse-kanto/update-manager/test/mocks"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewOwnerConsentClient(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	client, _ := newTestClient(mockCtrl, nil)
+	consentClient, err := NewOwnerConsentClient(testDomain, client, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, testDomain, consentClient.Domain())
+
+	mockClient := mocks.NewMockUpdateAgentClient(mockCtrl)
+	_, err = NewOwnerConsentClient(testDomain, mockClient, nil)
+	assert.EqualError(t, err, fmt.Sprintf("unexpected type: %T", mockClient))
+}
+
+func TestOwnerConsentClientStartStop(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockHandler := mocks.NewMockOwnerConsentHandler(mockCtrl)
+	mockDelegate := mocks.NewMockOwnerConsentClient(mockCtrl)
+	client, _ := newTestClient(mockCtrl, nil)
+	consentClient := &ownerConsentClient{updateAgentClient: client, domain: testDomain, delegate: mockDelegate}
+
+	mockDelegate.EXPECT().Start(consentClient).Return(fmt.Errorf("start error"))
+	assert.EqualError(t, consentClient.Start(mockHandler), "start error")
+	assert.Nil(t, consentClient.getHandler())
+	assert.Nil(t, client.getConsentHandler())
+
+	mockDelegate.EXPECT().Start(consentClient).Return(nil)
+	assert.NoError(t, consentClient.Start(mockHandler))
+	assert.Equal(t, mockHandler, consentClient.getHandler())
+	assert.Equal(t, consentClient, client.getConsentHandler())
+
+	mockDelegate.EXPECT().Stop().Return(nil)
+	assert.NoError(t, consentClient.Stop())
+	assert.Nil(t, consentClient.getHandler())
+	assert.Nil(t, client.getConsentHandler())
+}
+
+func TestOwnerConsentClientSendAndHandle(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockHandler := mocks.NewMockOwnerConsentHandler(mockCtrl)
+	mockDelegate := mocks.NewMockOwnerConsentClient(mockCtrl)
+	client, _ := newTestClient(mockCtrl, nil)
+	consentClient := &ownerConsentClient{updateAgentClient: client, domain: testDomain, delegate: mockDelegate}
+	mockDelegate.EXPECT().Start(consentClient).Return(nil)
+	assert.NoError(t, consentClient.Start(mockHandler))
+
+	response := doRequest(client, http.MethodGet, pathOwnerConsent, "")
+	assert.Equal(t, http.StatusNotFound, response.Code)
+
+	consent := &types.OwnerConsent{Command: types.CommandDownload}
+	mockDelegate.EXPECT().SendOwnerConsent("test-id", consent).Return(fmt.Errorf("send error"))
+	assert.NoError(t, consentClient.SendOwnerConsent("test-id", consent))
+
+	response = doRequest(client, http.MethodGet, pathOwnerConsent, "")
+	assert.Equal(t, http.StatusOK, response.Code)
+	received := &types.OwnerConsent{}
+	envelope, err := types.FromEnvelope(response.Body.Bytes(), received)
+	assert.NoError(t, err)
+	assert.Equal(t, "test-id", envelope.ActivityID)
+	assert.Equal(t, consent, received)
+
+	response = doRequest(client, http.MethodPost, pathOwnerConsent, `{"activityId":"test-id","payload":{"status":"UNKNOWN"}}`)
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+	response = doRequest(client, http.MethodPost, pathOwnerConsent, `{"activityId":"other-id","payload":{"status":"APPROVED"}}`)
+	assert.Equal(t, http.StatusConflict, response.Code)
+
+	mockHandler.EXPECT().HandleOwnerConsentFeedback("test-id", int64(123), &types.OwnerConsentFeedback{Status: types.StatusApproved}).Return(nil)
+	response = doRequest(client, http.MethodPost, pathOwnerConsent, `{"activityId":"test-id","timestamp":123,"payload":{"status":"APPROVED"}}`)
+	assert.Equal(t, http.StatusAccepted, response.Code)
+
+	// the consent is already answered, a late feedback from the delegate is ignored
+	assert.NoError(t, consentClient.HandleOwnerConsentFeedback("test-id", 124, &types.OwnerConsentFeedback{Status: types.StatusDenied}))
+	response = doRequest(client, http.MethodPost, pathOwnerConsent, `{"activityId":"test-id","payload":{"status":"DENIED"}}`)
+	assert.Equal(t, http.StatusConflict, response.Code)
+
+	response = doRequest(client, http.MethodDelete, pathOwnerConsent, "")
+	assert.Equal(t, http.StatusMethodNotAllowed, response.Code)
+}
@@ -0,0 +1,540 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/eclipse-kanto/update-manager/api"
+	"github.com/eclipse-kanto/update-manager/api/types"
+	"github.com/eclipse-kanto/update-manager/api/util"
+	"github.com/eclipse-kanto/update-manager/logger"
+	"github.com/eclipse-kanto/update-manager/util/jws"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+const (
+	pathDesiredState         = "/desiredstate"
+	pathDesiredStateCommand  = "/desiredstate/command"
+	pathDesiredStateFeedback = "/desiredstatefeedback"
+	pathCurrentState         = "/currentstate"
+	pathActivity             = "/activity"
+	pathOwnerConsent         = "/ownerconsent"
+	pathHistory              = "/history"
+	pathHealth               = "/health"
+	pathCache                = "/cache"
+	pathCacheArtifact        = "/cache/artifact"
+	pathCacheReservation     = "/cache/reservation"
+	pathCacheCommit          = "/cache/commit"
+
+	queryActivityID    = "activityId"
+	querySince         = "since"
+	queryLimit         = "limit"
+	queryDigest        = "digest"
+	queryReservationID = "reservationId"
+
+	maxRequestSize  = 10 << 20
+	shutdownTimeout = 5 * time.Second
+
+	jsonContent = "application/json"
+)
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+type acceptedResponse struct {
+	ActivityID string `json:"activityId"`
+}
+
+type updateAgentClient struct {
+	delegate api.UpdateAgentClient
+	config   *ServerConfig
+	verifier *jws.Verifier
+
+	handler        api.UpdateAgentHandler
+	consentHandler api.OwnerConsentHandler
+
+	stateLock sync.Mutex
+	feedback  *types.Envelope
+	consent   *types.Envelope
+
+	server     *http.Server
+	socketPath string
+}
+
+// UpdateAgentClientOption defines an optional configuration of the local HTTP API update agent client.
+type UpdateAgentClientOption func(*updateAgentClient)
+
+// WithSignatureVerifier makes the local HTTP API accept only desired states, which are signed and successfully verified by the given verifier.
+func WithSignatureVerifier(verifier *jws.Verifier) UpdateAgentClientOption {
+	return func(client *updateAgentClient) {
+		client.verifier = verifier
+	}
+}
+
+// NewUpdateAgentClient instantiates a new UpdateAgentClient instance, which exposes the update agent API over a local HTTP server
+// and forwards all outgoing messages to the given delegate client.
+func NewUpdateAgentClient(delegate api.UpdateAgentClient, config *ServerConfig, opts ...UpdateAgentClientOption) (api.UpdateAgentClient, error) {
+	if delegate == nil {
+		return nil, fmt.Errorf("delegate update agent client is not provided")
+	}
+	if !config.IsEnabled() {
+		return nil, fmt.Errorf("[%s] address for the local HTTP API is not provided", delegate.Domain())
+	}
+	client := &updateAgentClient{
+		delegate: delegate,
+		config:   config,
+	}
+	for _, opt := range opts {
+		opt(client)
+	}
+	return client, nil
+}
+
+// Domain returns the name of the domain that is handled by this client.
+func (client *updateAgentClient) Domain() string {
+	return client.delegate.Domain()
+}
+
+// Delegate returns the wrapped update agent client that is used for the communication with the backend and the domain update agents.
+func (client *updateAgentClient) Delegate() api.UpdateAgentClient {
+	return client.delegate
+}
+
+// ConnectionStatus returns the health status of the connection of the delegate client, or nil if the delegate does not provide it.
+func (client *updateAgentClient) ConnectionStatus() *types.ConnectionStatus {
+	if provider, ok := client.delegate.(api.ConnectionStatusProvider); ok {
+		return provider.ConnectionStatus()
+	}
+	return nil
+}
+
+// Start starts the delegate client and the local HTTP server.
+func (client *updateAgentClient) Start(handler api.UpdateAgentHandler) error {
+	client.handler = handler
+	if err := client.delegate.Start(handler); err != nil {
+		return err
+	}
+	listener, err := client.listen()
+	if err != nil {
+		return fmt.Errorf("[%s] cannot start local HTTP API on '%s': %w", client.Domain(), client.config.Address, err)
+	}
+	client.server = &http.Server{
+		Handler:      client.newServeMux(),
+		ReadTimeout:  util.ParseDuration("http-read-timeout", client.config.ReadTimeout, time.Minute, time.Minute),
+		WriteTimeout: util.ParseDuration("http-write-timeout", client.config.WriteTimeout, 2*time.Minute, 2*time.Minute),
+	}
+	go func(server *http.Server) {
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			logger.ErrorErr(err, "[%s] local HTTP API terminated", client.Domain())
+		}
+	}(client.server)
+	logger.Info("[%s] local HTTP API started on '%s'", client.Domain(), client.config.Address)
+	return nil
+}
+
+// Stop shuts down the local HTTP server and stops the delegate client.
+func (client *updateAgentClient) Stop() error {
+	if client.server != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := client.server.Shutdown(ctx); err != nil {
+			logger.WarnErr(err, "[%s] error shutting down local HTTP API", client.Domain())
+		}
+		client.server = nil
+	}
+	if client.socketPath != "" {
+		if err := os.Remove(client.socketPath); err != nil && !os.IsNotExist(err) {
+			logger.WarnErr(err, "[%s] cannot remove unix socket '%s'", client.Domain(), client.socketPath)
+		}
+	}
+	client.handler = nil
+	return client.delegate.Stop()
+}
+
+// SendCurrentState forwards the current state to the delegate client.
+func (client *updateAgentClient) SendCurrentState(activityID string, currentState *types.Inventory) error {
+	return client.delegate.SendCurrentState(activityID, currentState)
+}
+
+// SendDesiredStateFeedback stores the desired state feedback to be served by the local HTTP API and forwards it to the delegate client.
+func (client *updateAgentClient) SendDesiredStateFeedback(activityID string, desiredStateFeedback *types.DesiredStateFeedback) error {
+	client.setState(&client.feedback, activityID, desiredStateFeedback)
+	return client.delegate.SendDesiredStateFeedback(activityID, desiredStateFeedback)
+}
+
+func (client *updateAgentClient) setState(state **types.Envelope, activityID string, payload interface{}) {
+	client.stateLock.Lock()
+	defer client.stateLock.Unlock()
+
+	*state = &types.Envelope{
+		ActivityID: activityID,
+		Timestamp:  time.Now().UnixNano() / int64(time.Millisecond),
+		Payload:    payload,
+	}
+}
+
+func (client *updateAgentClient) getState(state **types.Envelope) *types.Envelope {
+	client.stateLock.Lock()
+	defer client.stateLock.Unlock()
+
+	return *state
+}
+
+func (client *updateAgentClient) setConsentHandler(handler api.OwnerConsentHandler) {
+	client.stateLock.Lock()
+	defer client.stateLock.Unlock()
+
+	client.consentHandler = handler
+}
+
+func (client *updateAgentClient) getConsentHandler() api.OwnerConsentHandler {
+	client.stateLock.Lock()
+	defer client.stateLock.Unlock()
+
+	return client.consentHandler
+}
+
+func (client *updateAgentClient) listen() (net.Listener, error) {
+	address := client.config.Address
+	if strings.HasPrefix(address, unixSocketPrefix) {
+		path := strings.TrimPrefix(address, unixSocketPrefix)
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return nil, err
+		}
+		listener, err := net.Listen("unix", path)
+		if err != nil {
+			return nil, err
+		}
+		if err := os.Chmod(path, 0660); err != nil {
+			listener.Close()
+			return nil, err
+		}
+		client.socketPath = path
+		return listener, nil
+	}
+	if host, _, err := net.SplitHostPort(address); err == nil {
+		if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+			logger.Warn("[%s] local HTTP API is exposed on non-loopback address '%s'", client.Domain(), address)
+		}
+	}
+	return net.Listen("tcp", address)
+}
+
+func (client *updateAgentClient) newServeMux() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc(pathDesiredState, client.handleDesiredState)
+	mux.HandleFunc(pathDesiredStateCommand, client.handleDesiredStateCommand)
+	mux.HandleFunc(pathDesiredStateFeedback, client.handleDesiredStateFeedback)
+	mux.HandleFunc(pathCurrentState, client.handleCurrentState)
+	mux.HandleFunc(pathActivity, client.handleActivity)
+	mux.HandleFunc(pathOwnerConsent, client.handleOwnerConsent)
+	mux.HandleFunc(pathHistory, client.handleHistory)
+	mux.HandleFunc(pathHealth, client.handleHealth)
+	mux.HandleFunc(pathCache, client.handleCache)
+	mux.HandleFunc(pathCacheArtifact, client.handleCacheArtifact)
+	mux.HandleFunc(pathCacheReservation, client.handleCacheReservation)
+	mux.HandleFunc(pathCacheCommit, client.handleCacheCommit)
+	return mux
+}
+
+// rejectDesiredState records the rejection of a desired state with missing or invalid signature in the activity history, if supported
+// by the handler, and responds with 403 Forbidden. The activity ID of the rejected desired state is not verified, so it is not recorded.
+func (client *updateAgentClient) rejectDesiredState(writer http.ResponseWriter, reason error) {
+	logger.ErrorErr(reason, "[%s] rejected desired state request over local HTTP API", client.Domain())
+	if handler, ok := client.handler.(api.RejectedDesiredStateHandler); ok {
+		handler.HandleRejectedDesiredState("", "", errors.Wrap(reason, "desired state signature verification failed"))
+	}
+	writeError(writer, http.StatusForbidden, reason.Error())
+}
+
+func (client *updateAgentClient) handleDesiredState(writer http.ResponseWriter, request *http.Request) {
+	if !checkMethod(writer, request, http.MethodPost) {
+		return
+	}
+	payload, ok := readBody(writer, request)
+	if !ok {
+		return
+	}
+	var signer string
+	if client.verifier != nil {
+		if !jws.IsSigned(payload) {
+			client.rejectDesiredState(writer, errors.New("desired state is not signed"))
+			return
+		}
+		verifiedPayload, subject, err := client.verifier.Verify(payload)
+		if err != nil {
+			client.rejectDesiredState(writer, err)
+			return
+		}
+		logger.Debug("[%s] desired state signature of '%s' verified", client.Domain(), subject)
+		payload, signer = verifiedPayload, subject
+	}
+	desiredState := &types.DesiredState{}
+	envelope, ok := parseEnvelope(writer, payload, desiredState)
+	if !ok {
+		return
+	}
+	if len(desiredState.Domains) == 0 {
+		writeError(writer, http.StatusBadRequest, "desired state is missing")
+		return
+	}
+	logger.Debug("[%s] received desired state request over local HTTP API", client.Domain())
+	var err error
+	if signedHandler, ok := client.handler.(api.SignedDesiredStateHandler); ok && signer != "" {
+		err = signedHandler.HandleSignedDesiredState(envelope.ActivityID, envelope.Timestamp, signer, desiredState)
+	} else {
+		err = client.handler.HandleDesiredState(envelope.ActivityID, envelope.Timestamp, desiredState)
+	}
+	if err != nil {
+		writeError(writer, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(writer, http.StatusAccepted, &acceptedResponse{ActivityID: envelope.ActivityID})
+}
+
+func (client *updateAgentClient) handleDesiredStateCommand(writer http.ResponseWriter, request *http.Request) {
+	if !checkMethod(writer, request, http.MethodPost) {
+		return
+	}
+	desiredStateCommand := &types.DesiredStateCommand{}
+	envelope, ok := readEnvelope(writer, request, desiredStateCommand)
+	if !ok {
+		return
+	}
+	if desiredStateCommand.Command == "" {
+		writeError(writer, http.StatusBadRequest, "desired state command is missing")
+		return
+	}
+	logger.Debug("[%s] received desired state command request over local HTTP API", client.Domain())
+	if err := client.handler.HandleDesiredStateCommand(envelope.ActivityID, envelope.Timestamp, desiredStateCommand); err != nil {
+		writeError(writer, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(writer, http.StatusAccepted, &acceptedResponse{ActivityID: envelope.ActivityID})
+}
+
+func (client *updateAgentClient) handleDesiredStateFeedback(writer http.ResponseWriter, request *http.Request) {
+	if !checkMethod(writer, request, http.MethodGet) {
+		return
+	}
+	feedback := client.getState(&client.feedback)
+	if feedback == nil {
+		writeError(writer, http.StatusNotFound, "no desired state feedback available")
+		return
+	}
+	writeJSON(writer, http.StatusOK, feedback)
+}
+
+func (client *updateAgentClient) handleCurrentState(writer http.ResponseWriter, request *http.Request) {
+	if !checkMethod(writer, request, http.MethodGet) {
+		return
+	}
+	activityID := request.URL.Query().Get(queryActivityID)
+	if activityID == "" {
+		activityID = uuid.New().String()
+	}
+	provider, ok := client.handler.(api.CurrentStateProvider)
+	if !ok {
+		writeError(writer, http.StatusNotImplemented, "current state is not supported")
+		return
+	}
+	logger.Debug("[%s] received current state get request over local HTTP API", client.Domain())
+	currentState, err := provider.CurrentState(request.Context(), activityID)
+	if err != nil {
+		writeError(writer, http.StatusServiceUnavailable, err.Error())
+		return
+	}
+	if currentState == nil {
+		writeError(writer, http.StatusServiceUnavailable, "current state is not available")
+		return
+	}
+	writeJSON(writer, http.StatusOK, &types.Envelope{
+		ActivityID: activityID,
+		Timestamp:  time.Now().UnixNano() / int64(time.Millisecond),
+		Payload:    currentState,
+	})
+}
+
+func (client *updateAgentClient) handleActivity(writer http.ResponseWriter, request *http.Request) {
+	if !checkMethod(writer, request, http.MethodGet) {
+		return
+	}
+	provider, ok := client.handler.(api.ActivityStatusProvider)
+	if !ok {
+		writeError(writer, http.StatusNotImplemented, "activity status is not supported")
+		return
+	}
+	activity := provider.ActivityStatus()
+	if activity == nil {
+		writeError(writer, http.StatusNotFound, "no active update activity")
+		return
+	}
+	writeJSON(writer, http.StatusOK, activity)
+}
+
+func (client *updateAgentClient) handleHealth(writer http.ResponseWriter, request *http.Request) {
+	if !checkMethod(writer, request, http.MethodGet) {
+		return
+	}
+	status := client.ConnectionStatus()
+	if status == nil {
+		writeError(writer, http.StatusNotImplemented, "connection status is not supported")
+		return
+	}
+	if !status.IsHealthy() {
+		writeJSON(writer, http.StatusServiceUnavailable, status)
+		return
+	}
+	writeJSON(writer, http.StatusOK, status)
+}
+
+func (client *updateAgentClient) handleHistory(writer http.ResponseWriter, request *http.Request) {
+	if !checkMethod(writer, request, http.MethodGet) {
+		return
+	}
+	provider, ok := client.handler.(api.ActivityHistoryProvider)
+	if !ok {
+		writeError(writer, http.StatusNotImplemented, "activity history is not supported")
+		return
+	}
+	values := request.URL.Query()
+	query := &types.ActivityHistoryQuery{ActivityID: values.Get(queryActivityID)}
+	if since := values.Get(querySince); since != "" {
+		value, err := strconv.ParseInt(since, 10, 64)
+		if err != nil {
+			writeError(writer, http.StatusBadRequest, fmt.Sprintf("invalid '%s' query parameter: %s", querySince, since))
+			return
+		}
+		query.Since = value
+	}
+	if limit := values.Get(queryLimit); limit != "" {
+		value, err := strconv.Atoi(limit)
+		if err != nil {
+			writeError(writer, http.StatusBadRequest, fmt.Sprintf("invalid '%s' query parameter: %s", queryLimit, limit))
+			return
+		}
+		query.Limit = value
+	}
+	logger.Debug("[%s] received history request over local HTTP API", client.Domain())
+	writeJSON(writer, http.StatusOK, provider.ActivityHistory(query))
+}
+
+func (client *updateAgentClient) handleOwnerConsent(writer http.ResponseWriter, request *http.Request) {
+	switch request.Method {
+	case http.MethodGet:
+		consent := client.getState(&client.consent)
+		if consent == nil {
+			writeError(writer, http.StatusNotFound, "no pending owner consent")
+			return
+		}
+		writeJSON(writer, http.StatusOK, consent)
+	case http.MethodPost:
+		consentHandler := client.getConsentHandler()
+		if consentHandler == nil {
+			writeError(writer, http.StatusNotImplemented, "owner consent handler not available")
+			return
+		}
+		consentFeedback := &types.OwnerConsentFeedback{}
+		envelope, ok := readEnvelope(writer, request, consentFeedback)
+		if !ok {
+			return
+		}
+		if consentFeedback.Status != types.StatusApproved && consentFeedback.Status != types.StatusDenied {
+			writeError(writer, http.StatusBadRequest, fmt.Sprintf("unsupported owner consent status '%s'", consentFeedback.Status))
+			return
+		}
+		consent := client.getState(&client.consent)
+		if consent == nil || consent.ActivityID != envelope.ActivityID {
+			writeError(writer, http.StatusConflict, fmt.Sprintf("no pending owner consent for activity '%s'", envelope.ActivityID))
+			return
+		}
+		logger.Debug("[%s] received owner consent feedback over local HTTP API", client.Domain())
+		if err := consentHandler.HandleOwnerConsentFeedback(envelope.ActivityID, envelope.Timestamp, consentFeedback); err != nil {
+			writeError(writer, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeJSON(writer, http.StatusAccepted, &acceptedResponse{ActivityID: envelope.ActivityID})
+	default:
+		writeError(writer, http.StatusMethodNotAllowed, fmt.Sprintf("method %s is not allowed", request.Method))
+	}
+}
+
+func checkMethod(writer http.ResponseWriter, request *http.Request, method string) bool {
+	if request.Method != method {
+		writeError(writer, http.StatusMethodNotAllowed, fmt.Sprintf("method %s is not allowed", request.Method))
+		return false
+	}
+	return true
+}
+
+func readEnvelope(writer http.ResponseWriter, request *http.Request, payload interface{}) (*types.Envelope, bool) {
+	bytes, ok := readBody(writer, request)
+	if !ok {
+		return nil, false
+	}
+	return parseEnvelope(writer, bytes, payload)
+}
+
+func readBody(writer http.ResponseWriter, request *http.Request) ([]byte, bool) {
+	bytes, err := io.ReadAll(http.MaxBytesReader(writer, request.Body, maxRequestSize))
+	if err != nil {
+		writeError(writer, http.StatusBadRequest, err.Error())
+		return nil, false
+	}
+	return bytes, true
+}
+
+func parseEnvelope(writer http.ResponseWriter, bytes []byte, payload interface{}) (*types.Envelope, bool) {
+	envelope, err := types.FromEnvelope(bytes, payload)
+	if err != nil {
+		writeError(writer, http.StatusBadRequest, err.Error())
+		return nil, false
+	}
+	if envelope.ActivityID == "" {
+		envelope.ActivityID = uuid.New().String()
+	}
+	if envelope.Timestamp == 0 {
+		envelope.Timestamp = time.Now().UnixNano() / int64(time.Millisecond)
+	}
+	return envelope, true
+}
+
+func writeError(writer http.ResponseWriter, status int, message string) {
+	writeJSON(writer, status, &errorResponse{Error: message})
+}
+
+func writeJSON(writer http.ResponseWriter, status int, payload interface{}) {
+	writer.Header().Set("Content-Type", jsonContent)
+	writer.WriteHeader(status)
+	if err := json.NewEncoder(writer).Encode(payload); err != nil {
+		logger.WarnErr(err, "cannot write local HTTP API response")
+	}
+}
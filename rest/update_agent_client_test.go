@@ -0,0 +1,400 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/eclipse-kanto/update-manager/api"
+	"github.com/eclipse-kanto/update-manager/api/types"
+	"github.com/eclipse-kanto/update-manager/test"
+	"github.com/eclipse-kanto/update-manager/test/mocks"
+	"github.com/eclipse-kanto/update-manager/util/jws"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+const testDomain = "device"
+
+type testActivityHandler struct {
+	*mocks.MockUpdateAgentHandler
+	activity *types.ActivityStatus
+}
+
+func (handler *testActivityHandler) ActivityStatus() *types.ActivityStatus {
+	return handler.activity
+}
+
+type testCurrentStateHandler struct {
+	*mocks.MockUpdateAgentHandler
+	currentState *types.Inventory
+	err          error
+	activityID   string
+}
+
+func (handler *testCurrentStateHandler) CurrentState(ctx context.Context, activityID string) (*types.Inventory, error) {
+	handler.activityID = activityID
+	if handler.err != nil {
+		return nil, handler.err
+	}
+	return handler.currentState, nil
+}
+
+func newTestClient(mockCtrl *gomock.Controller, handler api.UpdateAgentHandler) (*updateAgentClient, *mocks.MockUpdateAgentClient) {
+	mockDelegate := mocks.NewMockUpdateAgentClient(mockCtrl)
+	mockDelegate.EXPECT().Domain().Return(testDomain).AnyTimes()
+	return &updateAgentClient{
+		delegate: mockDelegate,
+		config:   &ServerConfig{Address: "127.0.0.1:0"},
+		handler:  handler,
+	}, mockDelegate
+}
+
+func doRequest(client *updateAgentClient, method, path, body string) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	client.newServeMux().ServeHTTP(recorder, httptest.NewRequest(method, path, strings.NewReader(body)))
+	return recorder
+}
+
+func TestNewUpdateAgentClient(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockDelegate := mocks.NewMockUpdateAgentClient(mockCtrl)
+	mockDelegate.EXPECT().Domain().Return(testDomain).AnyTimes()
+
+	client, err := NewUpdateAgentClient(mockDelegate, &ServerConfig{Address: "localhost:8080"})
+	assert.NoError(t, err)
+	assert.Equal(t, testDomain, client.Domain())
+	assert.Equal(t, mockDelegate, client.(*updateAgentClient).Delegate())
+
+	_, err = NewUpdateAgentClient(nil, &ServerConfig{Address: "localhost:8080"})
+	assert.Error(t, err)
+	_, err = NewUpdateAgentClient(mockDelegate, &ServerConfig{})
+	assert.Error(t, err)
+	_, err = NewUpdateAgentClient(mockDelegate, nil)
+	assert.Error(t, err)
+}
+
+func TestUpdateAgentClientStartStop(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockHandler := mocks.NewMockUpdateAgentHandler(mockCtrl)
+	client, mockDelegate := newTestClient(mockCtrl, nil)
+	socket := filepath.Join(t.TempDir(), "um", "um.sock")
+	client.config.Address = unixSocketPrefix + socket
+
+	mockDelegate.EXPECT().Start(mockHandler).Return(nil)
+	assert.NoError(t, client.Start(mockHandler))
+	assert.Equal(t, mockHandler, client.handler)
+
+	httpClient := &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return (&net.Dialer{}).DialContext(ctx, "unix", socket)
+			},
+		},
+	}
+	response, err := httpClient.Get("http://localhost" + pathDesiredStateFeedback)
+	assert.NoError(t, err)
+	response.Body.Close()
+	assert.Equal(t, http.StatusNotFound, response.StatusCode)
+
+	mockDelegate.EXPECT().Stop().Return(nil)
+	assert.NoError(t, client.Stop())
+	assert.Nil(t, client.handler)
+	_, err = os.Stat(socket)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestUpdateAgentClientStartError(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockHandler := mocks.NewMockUpdateAgentHandler(mockCtrl)
+	client, mockDelegate := newTestClient(mockCtrl, nil)
+
+	mockDelegate.EXPECT().Start(mockHandler).Return(fmt.Errorf("delegate error"))
+	assert.EqualError(t, client.Start(mockHandler), "delegate error")
+	assert.Nil(t, client.server)
+
+	client.config.Address = "invalid address"
+	mockDelegate.EXPECT().Start(mockHandler).Return(nil)
+	assert.Error(t, client.Start(mockHandler))
+	assert.Nil(t, client.server)
+}
+
+func TestUpdateAgentClientSendState(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	client, mockDelegate := newTestClient(mockCtrl, nil)
+	inventory := &types.Inventory{SoftwareNodes: []*types.SoftwareNode{{InventoryNode: types.InventoryNode{ID: "update-manager"}}}}
+	feedback := &types.DesiredStateFeedback{Status: types.StatusRunning}
+
+	mockDelegate.EXPECT().SendCurrentState("id-1", inventory).Return(nil)
+	mockDelegate.EXPECT().SendDesiredStateFeedback("id-2", feedback).Return(fmt.Errorf("send error"))
+
+	assert.NoError(t, client.SendCurrentState("id-1", inventory))
+	assert.EqualError(t, client.SendDesiredStateFeedback("id-2", feedback), "send error")
+
+	assert.Equal(t, "id-2", client.feedback.ActivityID)
+	assert.Equal(t, feedback, client.feedback.Payload)
+}
+
+func TestHandleDesiredStateRequest(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockHandler := mocks.NewMockUpdateAgentHandler(mockCtrl)
+	client, _ := newTestClient(mockCtrl, mockHandler)
+
+	tests := map[string]struct {
+		method     string
+		body       string
+		handlerErr error
+		status     int
+	}{
+		"test_accepted": {
+			method: http.MethodPost,
+			body:   `{"activityId":"test-id","timestamp":123,"payload":{"domains":[{"id":"containers"}]}}`,
+			status: http.StatusAccepted,
+		},
+		"test_handler_error": {
+			method:     http.MethodPost,
+			body:       `{"activityId":"test-id","timestamp":123,"payload":{"domains":[{"id":"containers"}]}}`,
+			handlerErr: fmt.Errorf("handler error"),
+			status:     http.StatusInternalServerError,
+		},
+		"test_no_domains": {
+			method: http.MethodPost,
+			body:   `{"activityId":"test-id","payload":{}}`,
+			status: http.StatusBadRequest,
+		},
+		"test_invalid_json": {
+			method: http.MethodPost,
+			body:   `{"activityId":`,
+			status: http.StatusBadRequest,
+		},
+		"test_method_not_allowed": {
+			method: http.MethodGet,
+			status: http.StatusMethodNotAllowed,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if test.status == http.StatusAccepted || test.handlerErr != nil {
+				mockHandler.EXPECT().HandleDesiredState("test-id", int64(123), gomock.Any()).DoAndReturn(
+					func(activityID string, timestamp int64, desiredState *types.DesiredState) error {
+						assert.Equal(t, "containers", desiredState.Domains[0].ID)
+						return test.handlerErr
+					})
+			}
+			response := doRequest(client, test.method, pathDesiredState, test.body)
+			assert.Equal(t, test.status, response.Code)
+			assert.Equal(t, jsonContent, response.Header().Get("Content-Type"))
+			if test.status == http.StatusAccepted {
+				assert.JSONEq(t, `{"activityId":"test-id"}`, response.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandleSignedDesiredStateRequest(t *testing.T) {
+	signer := test.NewSigner(t, t.TempDir())
+	verifier, err := jws.NewVerifier(&jws.Config{CACert: signer.CACert})
+	assert.NoError(t, err)
+	untrusted := test.NewSigner(t, t.TempDir())
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockHandler := mocks.NewMockUpdateAgentHandler(mockCtrl)
+	mockSignedHandler := mocks.NewMockSignedDesiredStateHandler(mockCtrl)
+	client, _ := newTestClient(mockCtrl, &struct {
+		*mocks.MockUpdateAgentHandler
+		*mocks.MockSignedDesiredStateHandler
+	}{mockHandler, mockSignedHandler})
+	client.verifier = verifier
+
+	envelope, err := types.ToEnvelope(test.ActivityID, test.DesiredState)
+	assert.NoError(t, err)
+
+	t.Run("test_signed", func(t *testing.T) {
+		mockSignedHandler.EXPECT().HandleSignedDesiredState(test.ActivityID, gomock.Any(), test.SignerSubject, test.DesiredState)
+		response := doRequest(client, http.MethodPost, pathDesiredState, string(signer.Sign(t, envelope)))
+		assert.Equal(t, http.StatusAccepted, response.Code)
+	})
+
+	rejected := map[string][]byte{
+		"test_not_signed": envelope,
+		"test_untrusted":  untrusted.Sign(t, envelope),
+	}
+	for name, payload := range rejected {
+		t.Run(name, func(t *testing.T) {
+			response := doRequest(client, http.MethodPost, pathDesiredState, string(payload))
+			assert.Equal(t, http.StatusForbidden, response.Code)
+		})
+	}
+}
+
+func TestHandleDesiredStateCommandRequest(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockHandler := mocks.NewMockUpdateAgentHandler(mockCtrl)
+	client, _ := newTestClient(mockCtrl, mockHandler)
+
+	mockHandler.EXPECT().HandleDesiredStateCommand(gomock.Any(), gomock.Any(), &types.DesiredStateCommand{Command: types.CommandCleanup}).Return(nil)
+	response := doRequest(client, http.MethodPost, pathDesiredStateCommand, `{"payload":{"command":"CLEANUP"}}`)
+	assert.Equal(t, http.StatusAccepted, response.Code)
+	accepted := &acceptedResponse{}
+	assert.NoError(t, json.Unmarshal(response.Body.Bytes(), accepted))
+	assert.NotEmpty(t, accepted.ActivityID)
+
+	response = doRequest(client, http.MethodPost, pathDesiredStateCommand, `{"payload":{}}`)
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+}
+
+func TestHandleDesiredStateFeedbackRequest(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	client, _ := newTestClient(mockCtrl, nil)
+
+	response := doRequest(client, http.MethodGet, pathDesiredStateFeedback, "")
+	assert.Equal(t, http.StatusNotFound, response.Code)
+
+	client.setState(&client.feedback, "test-id", &types.DesiredStateFeedback{Status: types.StatusCompleted})
+	response = doRequest(client, http.MethodGet, pathDesiredStateFeedback, "")
+	assert.Equal(t, http.StatusOK, response.Code)
+	feedback := &types.DesiredStateFeedback{}
+	envelope, err := types.FromEnvelope(response.Body.Bytes(), feedback)
+	assert.NoError(t, err)
+	assert.Equal(t, "test-id", envelope.ActivityID)
+	assert.Equal(t, types.StatusCompleted, feedback.Status)
+}
+
+func TestHandleCurrentStateRequest(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	client, _ := newTestClient(mockCtrl, mocks.NewMockUpdateAgentHandler(mockCtrl))
+	response := doRequest(client, http.MethodGet, pathCurrentState, "")
+	assert.Equal(t, http.StatusNotImplemented, response.Code)
+
+	// the current state is got from the update manager without publishing it, i.e. HandleCurrentStateGet is not called
+	inventory := &types.Inventory{SoftwareNodes: []*types.SoftwareNode{{InventoryNode: types.InventoryNode{ID: "update-manager"}}}}
+	handler := &testCurrentStateHandler{MockUpdateAgentHandler: mocks.NewMockUpdateAgentHandler(mockCtrl), currentState: inventory}
+	client.handler = handler
+	response = doRequest(client, http.MethodGet, pathCurrentState+"?activityId=test-id", "")
+	assert.Equal(t, http.StatusOK, response.Code)
+	currentState := &types.Inventory{}
+	envelope, err := types.FromEnvelope(response.Body.Bytes(), currentState)
+	assert.NoError(t, err)
+	assert.Equal(t, "test-id", envelope.ActivityID)
+	assert.Equal(t, inventory, currentState)
+	assert.Equal(t, "test-id", handler.activityID)
+
+	handler.err = fmt.Errorf("get error")
+	response = doRequest(client, http.MethodGet, pathCurrentState, "")
+	assert.Equal(t, http.StatusServiceUnavailable, response.Code)
+	assert.NotEmpty(t, handler.activityID)
+}
+
+func TestHandleActivityRequest(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	handler := &testActivityHandler{MockUpdateAgentHandler: mocks.NewMockUpdateAgentHandler(mockCtrl)}
+	client, _ := newTestClient(mockCtrl, handler)
+
+	response := doRequest(client, http.MethodGet, pathActivity, "")
+	assert.Equal(t, http.StatusNotFound, response.Code)
+
+	handler.activity = &types.ActivityStatus{ActivityID: "test-id", Status: types.StatusRunning}
+	response = doRequest(client, http.MethodGet, pathActivity, "")
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.JSONEq(t, `{"activityId":"test-id","status":"RUNNING"}`, response.Body.String())
+
+	client.handler = handler.MockUpdateAgentHandler
+	response = doRequest(client, http.MethodGet, pathActivity, "")
+	assert.Equal(t, http.StatusNotImplemented, response.Code)
+}
+
+func TestHandleHistoryRequest(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockHandler := mocks.NewMockUpdateAgentHandler(mockCtrl)
+	mockProvider := mocks.NewMockActivityHistoryProvider(mockCtrl)
+	client, _ := newTestClient(mockCtrl, &struct {
+		*mocks.MockUpdateAgentHandler
+		*mocks.MockActivityHistoryProvider
+	}{mockHandler, mockProvider})
+
+	mockProvider.EXPECT().ActivityHistory(&types.ActivityHistoryQuery{ActivityID: "test-id", Since: 100, Limit: 5}).
+		Return(&types.ActivityHistory{Activities: []*types.ActivityRecord{{ActivityID: "test-id", Status: types.StatusCompleted}}})
+	response := doRequest(client, http.MethodGet, pathHistory+"?activityId=test-id&since=100&limit=5", "")
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.JSONEq(t, `{"activities":[{"activityId":"test-id","status":"COMPLETED"}]}`, response.Body.String())
+
+	response = doRequest(client, http.MethodGet, pathHistory+"?limit=all", "")
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+
+	response = doRequest(client, http.MethodPost, pathHistory, "")
+	assert.Equal(t, http.StatusMethodNotAllowed, response.Code)
+
+	client.handler = mockHandler
+	response = doRequest(client, http.MethodGet, pathHistory, "")
+	assert.Equal(t, http.StatusNotImplemented, response.Code)
+}
+
+func TestHandleHealthRequest(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	client, mockDelegate := newTestClient(mockCtrl, nil)
+	response := doRequest(client, http.MethodGet, pathHealth, "")
+	assert.Equal(t, http.StatusNotImplemented, response.Code)
+
+	mockProvider := mocks.NewMockConnectionStatusProvider(mockCtrl)
+	client.delegate = &struct {
+		*mocks.MockUpdateAgentClient
+		*mocks.MockConnectionStatusProvider
+	}{mockDelegate, mockProvider}
+
+	mockProvider.EXPECT().ConnectionStatus().Return(&types.ConnectionStatus{State: types.ConnectionStateConnected, Subscribed: true, Since: 100})
+	response = doRequest(client, http.MethodGet, pathHealth, "")
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.JSONEq(t, `{"state":"CONNECTED","subscribed":true,"since":100,"reconnects":0}`, response.Body.String())
+
+	mockProvider.EXPECT().ConnectionStatus().Return(&types.ConnectionStatus{State: types.ConnectionStateReconnecting, Since: 200, Reconnects: 1, LastError: "connection reset"})
+	response = doRequest(client, http.MethodGet, pathHealth, "")
+	assert.Equal(t, http.StatusServiceUnavailable, response.Code)
+	assert.JSONEq(t, `{"state":"RECONNECTING","subscribed":false,"since":200,"reconnects":1,"lastError":"connection reset"}`, response.Body.String())
+
+	response = doRequest(client, http.MethodPost, pathHealth, "")
+	assert.Equal(t, http.StatusMethodNotAllowed, response.Code)
+}
@@ -89,6 +89,43 @@ func (mr *MockUpdateAgentHandlerMockRecorder) HandleDesiredStateCommand(arg0, ar
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HandleDesiredStateCommand", reflect.TypeOf((*MockUpdateAgentHandler)(nil).HandleDesiredStateCommand), arg0, arg1, arg2)
 }
 
+// MockSignedDesiredStateHandler is a mock of SignedDesiredStateHandler interface.
+type MockSignedDesiredStateHandler struct {
+	ctrl     *gomock.Controller
+	recorder *MockSignedDesiredStateHandlerMockRecorder
+}
+
+// MockSignedDesiredStateHandlerMockRecorder is the mock recorder for MockSignedDesiredStateHandler.
+type MockSignedDesiredStateHandlerMockRecorder struct {
+	mock *MockSignedDesiredStateHandler
+}
+
+// NewMockSignedDesiredStateHandler creates a new mock instance.
+func NewMockSignedDesiredStateHandler(ctrl *gomock.Controller) *MockSignedDesiredStateHandler {
+	mock := &MockSignedDesiredStateHandler{ctrl: ctrl}
+	mock.recorder = &MockSignedDesiredStateHandlerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSignedDesiredStateHandler) EXPECT() *MockSignedDesiredStateHandlerMockRecorder {
+	return m.recorder
+}
+
+// HandleSignedDesiredState mocks base method.
+func (m *MockSignedDesiredStateHandler) HandleSignedDesiredState(arg0 string, arg1 int64, arg2 string, arg3 *types.DesiredState) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HandleSignedDesiredState", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// HandleSignedDesiredState indicates an expected call of HandleSignedDesiredState.
+func (mr *MockSignedDesiredStateHandlerMockRecorder) HandleSignedDesiredState(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HandleSignedDesiredState", reflect.TypeOf((*MockSignedDesiredStateHandler)(nil).HandleSignedDesiredState), arg0, arg1, arg2, arg3)
+}
+
 // MockBaseClient is a mock of BaseClient interface.
 type MockBaseClient struct {
 	ctrl     *gomock.Controller
@@ -284,6 +321,43 @@ func (mr *MockStateHandlerMockRecorder) HandleDesiredStateFeedback(arg0, arg1, a
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HandleDesiredStateFeedback", reflect.TypeOf((*MockStateHandler)(nil).HandleDesiredStateFeedback), arg0, arg1, arg2)
 }
 
+// MockConnectionStatusProvider is a mock of ConnectionStatusProvider interface.
+type MockConnectionStatusProvider struct {
+	ctrl     *gomock.Controller
+	recorder *MockConnectionStatusProviderMockRecorder
+}
+
+// MockConnectionStatusProviderMockRecorder is the mock recorder for MockConnectionStatusProvider.
+type MockConnectionStatusProviderMockRecorder struct {
+	mock *MockConnectionStatusProvider
+}
+
+// NewMockConnectionStatusProvider creates a new mock instance.
+func NewMockConnectionStatusProvider(ctrl *gomock.Controller) *MockConnectionStatusProvider {
+	mock := &MockConnectionStatusProvider{ctrl: ctrl}
+	mock.recorder = &MockConnectionStatusProviderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockConnectionStatusProvider) EXPECT() *MockConnectionStatusProviderMockRecorder {
+	return m.recorder
+}
+
+// ConnectionStatus mocks base method.
+func (m *MockConnectionStatusProvider) ConnectionStatus() *types.ConnectionStatus {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConnectionStatus")
+	ret0, _ := ret[0].(*types.ConnectionStatus)
+	return ret0
+}
+
+// ConnectionStatus indicates an expected call of ConnectionStatus.
+func (mr *MockConnectionStatusProviderMockRecorder) ConnectionStatus() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConnectionStatus", reflect.TypeOf((*MockConnectionStatusProvider)(nil).ConnectionStatus))
+}
+
 // MockDesiredStateClient is a mock of DesiredStateClient interface.
 type MockDesiredStateClient struct {
 	ctrl     *gomock.Controller
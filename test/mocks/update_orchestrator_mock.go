@@ -87,3 +87,114 @@ func (mr *MockUpdateOrchestratorMockRecorder) HandleOwnerConsentFeedback(arg0, a
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HandleOwnerConsentFeedback", reflect.TypeOf((*MockUpdateOrchestrator)(nil).HandleOwnerConsentFeedback), arg0, arg1, arg2)
 }
+
+// MockActivityStatusProvider is a mock of ActivityStatusProvider interface.
+type MockActivityStatusProvider struct {
+	ctrl     *gomock.Controller
+	recorder *MockActivityStatusProviderMockRecorder
+}
+
+// MockActivityStatusProviderMockRecorder is the mock recorder for MockActivityStatusProvider.
+type MockActivityStatusProviderMockRecorder struct {
+	mock *MockActivityStatusProvider
+}
+
+// NewMockActivityStatusProvider creates a new mock instance.
+func NewMockActivityStatusProvider(ctrl *gomock.Controller) *MockActivityStatusProvider {
+	mock := &MockActivityStatusProvider{ctrl: ctrl}
+	mock.recorder = &MockActivityStatusProviderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockActivityStatusProvider) EXPECT() *MockActivityStatusProviderMockRecorder {
+	return m.recorder
+}
+
+// ActivityStatus mocks base method.
+func (m *MockActivityStatusProvider) ActivityStatus() *types.ActivityStatus {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ActivityStatus")
+	ret0, _ := ret[0].(*types.ActivityStatus)
+	return ret0
+}
+
+// ActivityStatus indicates an expected call of ActivityStatus.
+func (mr *MockActivityStatusProviderMockRecorder) ActivityStatus() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ActivityStatus", reflect.TypeOf((*MockActivityStatusProvider)(nil).ActivityStatus))
+}
+
+// MockActivityHistoryProvider is a mock of ActivityHistoryProvider interface.
+type MockActivityHistoryProvider struct {
+	ctrl     *gomock.Controller
+	recorder *MockActivityHistoryProviderMockRecorder
+}
+
+// MockActivityHistoryProviderMockRecorder is the mock recorder for MockActivityHistoryProvider.
+type MockActivityHistoryProviderMockRecorder struct {
+	mock *MockActivityHistoryProvider
+}
+
+// NewMockActivityHistoryProvider creates a new mock instance.
+func NewMockActivityHistoryProvider(ctrl *gomock.Controller) *MockActivityHistoryProvider {
+	mock := &MockActivityHistoryProvider{ctrl: ctrl}
+	mock.recorder = &MockActivityHistoryProviderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockActivityHistoryProvider) EXPECT() *MockActivityHistoryProviderMockRecorder {
+	return m.recorder
+}
+
+// ActivityHistory mocks base method.
+func (m *MockActivityHistoryProvider) ActivityHistory(arg0 *types.ActivityHistoryQuery) *types.ActivityHistory {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ActivityHistory", arg0)
+	ret0, _ := ret[0].(*types.ActivityHistory)
+	return ret0
+}
+
+// ActivityHistory indicates an expected call of ActivityHistory.
+func (mr *MockActivityHistoryProviderMockRecorder) ActivityHistory(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ActivityHistory", reflect.TypeOf((*MockActivityHistoryProvider)(nil).ActivityHistory), arg0)
+}
+
+// MockComplianceProvider is a mock of ComplianceProvider interface.
+type MockComplianceProvider struct {
+	ctrl     *gomock.Controller
+	recorder *MockComplianceProviderMockRecorder
+}
+
+// MockComplianceProviderMockRecorder is the mock recorder for MockComplianceProvider.
+type MockComplianceProviderMockRecorder struct {
+	mock *MockComplianceProvider
+}
+
+// NewMockComplianceProvider creates a new mock instance.
+func NewMockComplianceProvider(ctrl *gomock.Controller) *MockComplianceProvider {
+	mock := &MockComplianceProvider{ctrl: ctrl}
+	mock.recorder = &MockComplianceProviderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockComplianceProvider) EXPECT() *MockComplianceProviderMockRecorder {
+	return m.recorder
+}
+
+// Compliance mocks base method.
+func (m *MockComplianceProvider) Compliance(desiredState *types.DesiredState) *types.ComplianceReport {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Compliance", desiredState)
+	ret0, _ := ret[0].(*types.ComplianceReport)
+	return ret0
+}
+
+// Compliance indicates an expected call of Compliance.
+func (mr *MockComplianceProviderMockRecorder) Compliance(desiredState interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Compliance", reflect.TypeOf((*MockComplianceProvider)(nil).Compliance), desiredState)
+}
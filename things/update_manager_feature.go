@@ -21,11 +21,13 @@ import (
 	"github.com/eclipse-kanto/update-manager/api"
 	"github.com/eclipse-kanto/update-manager/api/types"
 	"github.com/eclipse-kanto/update-manager/logger"
+	"github.com/eclipse-kanto/update-manager/util/jws"
 	"github.com/eclipse/ditto-clients-golang"
 	"github.com/eclipse/ditto-clients-golang/model"
 	"github.com/eclipse/ditto-clients-golang/protocol"
 	"github.com/eclipse/ditto-clients-golang/protocol/things"
 	"github.com/google/uuid"
+	"github.com/pkg/errors"
 )
 
 const (
@@ -34,8 +36,10 @@ const (
 	// updateManagerFeatureDefinition is the feature definition of the update manager
 	updateManagerFeatureDefinition = "com.bosch.iot.suite.edge.update:UpdateManager:1.0.0"
 	// incoming operations
-	updateManagerFeatureOperationApply   = "apply"
-	updateManagerFeatureOperationRefresh = "refresh"
+	updateManagerFeatureOperationApply      = "apply"
+	updateManagerFeatureOperationRefresh    = "refresh"
+	updateManagerFeatureOperationHistory    = "history"
+	updateManagerFeatureOperationCompliance = "compliance"
 	// outgoing messages
 	updateManagerFeatureMessageFeedback = "feedback"
 	updateManagerFeatureMessageConsent  = "consent"
@@ -79,6 +83,9 @@ type applyArgs struct {
 	DesiredState *types.DesiredState `json:"desiredState"`
 }
 
+// ErrNotActive is returned when the current state or a desired state feedback is sent via an update manager feature, which is not active.
+var ErrNotActive = errors.New("update manager feature is not active")
+
 // UpdateManagerFeature describes the update manager feature representation.
 type UpdateManagerFeature interface {
 	Activate() error
@@ -98,15 +105,18 @@ type updateManagerFeature struct {
 	handler              api.UpdateAgentHandler
 	consentHandler       api.OwnerConsentHandler
 	consentCorrelationID string
+	verifier             *jws.Verifier
 }
 
 // NewUpdateManagerFeature creates a new update manager feature representation.
-func NewUpdateManagerFeature(domain string, deviceID string, dittoClient ditto.Client, handler api.UpdateAgentHandler) UpdateManagerFeature {
+// If a signature verifier is provided, only signed apply operations are accepted.
+func NewUpdateManagerFeature(domain string, deviceID string, dittoClient ditto.Client, handler api.UpdateAgentHandler, verifier *jws.Verifier) UpdateManagerFeature {
 	return &updateManagerFeature{
 		domain:      domain,
 		thingID:     model.NewNamespacedIDFrom(deviceID),
 		dittoClient: dittoClient,
 		handler:     handler,
+		verifier:    verifier,
 	}
 }
 
@@ -146,13 +156,13 @@ func (um *updateManagerFeature) Deactivate() {
 	um.active = false
 }
 
-// SetState modifies the state property of the feature.
+// SetState modifies the state property of the feature, failing with ErrNotActive if the feature is not active.
 func (um *updateManagerFeature) SetState(activityID string, currentState *types.Inventory) error {
 	um.Lock()
 	defer um.Unlock()
 
 	if !um.active {
-		return nil
+		return ErrNotActive
 	}
 	properties := &updateManagerProperties{
 		base:      base{ActivityID: activityID, Timestamp: time.Now().UnixNano() / int64(time.Millisecond)},
@@ -163,14 +173,18 @@ func (um *updateManagerFeature) SetState(activityID string, currentState *types.
 	return um.dittoClient.Send(cmd.Envelope(protocol.WithResponseRequired(false), protocol.WithContentType(jsonContent)))
 }
 
-// SendFeedback issues a feedback message to the cloud.
+// SendFeedback issues a feedback message to the cloud, failing with ErrNotActive if the feature is not active.
 func (um *updateManagerFeature) SendFeedback(activityID string, desiredStateFeedback *types.DesiredStateFeedback) error {
 	um.Lock()
 	defer um.Unlock()
 
 	if !um.active {
-		return nil
+		return ErrNotActive
 	}
+	return um.sendFeedback(activityID, desiredStateFeedback)
+}
+
+func (um *updateManagerFeature) sendFeedback(activityID string, desiredStateFeedback *types.DesiredStateFeedback) error {
 	feedback := &feedback{
 		base:                 base{ActivityID: activityID, Timestamp: time.Now().UnixNano() / int64(time.Millisecond)},
 		DesiredStateFeedback: desiredStateFeedback,
@@ -216,6 +230,10 @@ func (um *updateManagerFeature) messagesHandler(requestID string, msg *protocol.
 			um.processApply(requestID, msg)
 		} else if msg.Path == fmt.Sprintf("/features/%s/inbox/messages/%s", updateManagerFeatureID, updateManagerFeatureOperationRefresh) {
 			um.processRefresh(requestID, msg)
+		} else if msg.Path == fmt.Sprintf("/features/%s/inbox/messages/%s", updateManagerFeatureID, updateManagerFeatureOperationHistory) {
+			um.processHistory(requestID, msg)
+		} else if msg.Path == fmt.Sprintf("/features/%s/inbox/messages/%s", updateManagerFeatureID, updateManagerFeatureOperationCompliance) {
+			um.processCompliance(requestID, msg)
 		} else if msg.Path == fmt.Sprintf("/features/%s/inbox/messages/%s", updateManagerFeatureID, updateManagerFeatureMessageConsent) {
 			um.processConsent(requestID, msg)
 		} else {
@@ -227,19 +245,72 @@ func (um *updateManagerFeature) messagesHandler(requestID string, msg *protocol.
 }
 
 func (um *updateManagerFeature) processApply(requestID string, msg *protocol.Envelope) {
+	if um.verifier != nil {
+		um.processSignedApply(requestID, msg)
+		return
+	}
 	args := &applyArgs{}
 	if um.prepare(requestID, msg, updateManagerFeatureOperationApply, args) {
-		if args.DesiredState != nil {
-			um.replySuccess(requestID, msg, updateManagerFeatureOperationApply)
-			go func(handler api.UpdateAgentHandler) {
-				logger.Trace("[%s][%s] processing apply operation", updateManagerFeatureID, um.domain)
-				if err := handler.HandleDesiredState(args.ActivityID, args.Timestamp, args.DesiredState); err != nil {
-					logger.ErrorErr(err, "[%s][%s] error processing apply operation", updateManagerFeatureID, um.domain)
-				}
-			}(um.handler)
+		um.apply(requestID, msg, args, "")
+	}
+}
+
+func (um *updateManagerFeature) processSignedApply(requestID string, msg *protocol.Envelope) {
+	signed, ok := msg.Value.(string)
+	if !ok || !jws.IsSigned([]byte(signed)) {
+		um.rejectApply("desired state is not signed", requestID, msg)
+		return
+	}
+	payload, signer, err := um.verifier.Verify([]byte(signed))
+	if err != nil {
+		um.rejectApply(err.Error(), requestID, msg)
+		return
+	}
+	args := &applyArgs{}
+	if err := json.Unmarshal(payload, args); err != nil {
+		um.replyError(err.Error(), requestID, msg, updateManagerFeatureOperationApply)
+		return
+	}
+	logger.Debug("[%s][%s] desired state signature of '%s' verified", updateManagerFeatureID, um.domain, signer)
+	um.apply(requestID, msg, args, signer)
+}
+
+func (um *updateManagerFeature) apply(requestID string, msg *protocol.Envelope, args *applyArgs, signer string) {
+	if args.DesiredState == nil {
+		um.replyError("desired state is missing", requestID, msg, updateManagerFeatureOperationApply)
+		return
+	}
+	um.replySuccess(requestID, msg, updateManagerFeatureOperationApply)
+	go func(handler api.UpdateAgentHandler) {
+		logger.Trace("[%s][%s] processing apply operation", updateManagerFeatureID, um.domain)
+		var err error
+		if signedHandler, ok := handler.(api.SignedDesiredStateHandler); ok && signer != "" {
+			err = signedHandler.HandleSignedDesiredState(args.ActivityID, args.Timestamp, signer, args.DesiredState)
 		} else {
-			um.replyError("desired state is missing", requestID, msg, updateManagerFeatureOperationApply)
+			err = handler.HandleDesiredState(args.ActivityID, args.Timestamp, args.DesiredState)
+		}
+		if err != nil {
+			logger.ErrorErr(err, "[%s][%s] error processing apply operation", updateManagerFeatureID, um.domain)
 		}
+	}(um.handler)
+}
+
+// rejectApply replies with an error, records the rejection in the activity history, if supported by the handler, and reports
+// IDENTIFICATION_FAILED feedback for an apply operation with missing or invalid signature. The activity ID of the rejected
+// desired state cannot be trusted, so it is neither recorded nor echoed in the feedback.
+func (um *updateManagerFeature) rejectApply(errMsg string, requestID string, msg *protocol.Envelope) {
+	logger.Error("[%s][%s] rejected apply operation: %s", updateManagerFeatureID, um.domain, errMsg)
+	um.replyError(errMsg, requestID, msg, updateManagerFeatureOperationApply)
+
+	reason := "desired state signature verification failed: " + errMsg
+	if handler, ok := um.handler.(api.RejectedDesiredStateHandler); ok {
+		handler.HandleRejectedDesiredState("", "", errors.New(reason))
+	}
+	if err := um.sendFeedback("", &types.DesiredStateFeedback{
+		Status:  types.StatusIdentificationFailed,
+		Message: reason,
+	}); err != nil {
+		logger.ErrorErr(err, "[%s][%s] failed to send desired state feedback", updateManagerFeatureID, um.domain)
 	}
 }
 
@@ -256,6 +327,36 @@ func (um *updateManagerFeature) processRefresh(requestID string, msg *protocol.E
 	}
 }
 
+func (um *updateManagerFeature) processHistory(requestID string, msg *protocol.Envelope) {
+	provider, ok := um.handler.(api.ActivityHistoryProvider)
+	if !ok {
+		um.replyError("activity history not available", requestID, msg, updateManagerFeatureOperationHistory)
+		return
+	}
+	query := &types.ActivityHistoryQuery{}
+	if um.prepare(requestID, msg, updateManagerFeatureOperationHistory, query) {
+		logger.Trace("[%s][%s] processing history operation", updateManagerFeatureID, um.domain)
+		um.reply(requestID, msg.Headers.CorrelationID(), updateManagerFeatureOperationHistory, 200, provider.ActivityHistory(query))
+	}
+}
+
+func (um *updateManagerFeature) processCompliance(requestID string, msg *protocol.Envelope) {
+	provider, ok := um.handler.(api.ComplianceProvider)
+	if !ok {
+		um.replyError("compliance check not available", requestID, msg, updateManagerFeatureOperationCompliance)
+		return
+	}
+	args := &applyArgs{}
+	if um.prepare(requestID, msg, updateManagerFeatureOperationCompliance, args) {
+		if args.DesiredState == nil {
+			um.replyError("desired state is missing", requestID, msg, updateManagerFeatureOperationCompliance)
+			return
+		}
+		logger.Trace("[%s][%s] processing compliance operation", updateManagerFeatureID, um.domain)
+		um.reply(requestID, msg.Headers.CorrelationID(), updateManagerFeatureOperationCompliance, 200, provider.Compliance(args.DesiredState))
+	}
+}
+
 func (um *updateManagerFeature) processConsent(requestID string, msg *protocol.Envelope) {
 	if um.consentHandler == nil {
 		um.replyError("owner consent handler not available", requestID, msg, updateManagerFeatureMessageConsent)
@@ -13,6 +13,7 @@
 package things
 
 import (
+	"encoding/json"
 	"fmt"
 	"sync"
 	"testing"
@@ -21,6 +22,7 @@ import (
 	"github.com/eclipse-kanto/update-manager/api/types"
 	"github.com/eclipse-kanto/update-manager/test"
 	"github.com/eclipse-kanto/update-manager/test/mocks"
+	"github.com/eclipse-kanto/update-manager/util/jws"
 	"github.com/eclipse/ditto-clients-golang/model"
 	"github.com/eclipse/ditto-clients-golang/protocol"
 	"github.com/eclipse/ditto-clients-golang/protocol/things"
@@ -147,7 +149,7 @@ func TestSetState(t *testing.T) {
 		"test_set_state_not_active": {
 			feature: &updateManagerFeature{active: false},
 			mockExecution: func(_ *mocks.MockClient) error {
-				return nil
+				return ErrNotActive
 			},
 		},
 		"test_set_state_error": {
@@ -200,7 +202,7 @@ func TestSendFeedback(t *testing.T) {
 		"test_send_feedback_not_active": {
 			feature: &updateManagerFeature{active: false},
 			mockExecution: func(_ *mocks.MockClient) error {
-				return nil
+				return ErrNotActive
 			},
 		},
 		"test_send_feedback_error": {
@@ -378,6 +380,18 @@ func TestMessageHandler(t *testing.T) {
 				Envelope(protocol.WithResponseRequired(true)),
 			mockExecution: mockThingErrorExecution(updateManagerFeatureOperationRefresh),
 		},
+		"test_message_handler_history_not_available_error": {
+			feature: &updateManagerFeature{active: true, thingID: tesThingID},
+			envelope: things.NewMessage(tesThingID).Feature(updateManagerFeatureID).Inbox(updateManagerFeatureOperationHistory).WithPayload(&types.ActivityHistoryQuery{}).
+				Envelope(protocol.WithResponseRequired(true)),
+			mockExecution: mockThingErrorExecution(updateManagerFeatureOperationHistory),
+		},
+		"test_message_handler_compliance_not_available_error": {
+			feature: &updateManagerFeature{active: true, thingID: tesThingID},
+			envelope: things.NewMessage(tesThingID).Feature(updateManagerFeatureID).Inbox(updateManagerFeatureOperationCompliance).
+				WithPayload(&applyArgs{base: base{ActivityID: test.ActivityID}, DesiredState: test.DesiredState}).Envelope(protocol.WithResponseRequired(true)),
+			mockExecution: mockThingErrorExecution(updateManagerFeatureOperationCompliance),
+		},
 		"test_message_handler_apply_ok": {
 			feature: &updateManagerFeature{active: true, thingID: tesThingID},
 			envelope: things.NewMessage(tesThingID).Feature(updateManagerFeatureID).Inbox(updateManagerFeatureOperationApply).WithPayload(&applyArgs{base: base{ActivityID: test.ActivityID}, DesiredState: test.DesiredState}).
@@ -428,6 +442,162 @@ func TestMessageHandler(t *testing.T) {
 	}
 }
 
+func TestMessageHandlerHistory(t *testing.T) {
+	testRequestID := "testRequestID"
+	testQuery := &types.ActivityHistoryQuery{ActivityID: test.ActivityID, Limit: 1}
+	testHistory := &types.ActivityHistory{Activities: []*types.ActivityRecord{{ActivityID: test.ActivityID, Status: types.StatusCompleted}}}
+
+	feature := &updateManagerFeature{active: true, thingID: tesThingID}
+	mockCtrl, mockDittoClient, mockHandler, _ := setupMocks(t, feature)
+	defer mockCtrl.Finish()
+
+	mockProvider := mocks.NewMockActivityHistoryProvider(mockCtrl)
+	feature.handler = &struct {
+		*mocks.MockUpdateAgentHandler
+		*mocks.MockActivityHistoryProvider
+	}{mockHandler, mockProvider}
+
+	t.Run("test_message_handler_history_ok", func(t *testing.T) {
+		mockProvider.EXPECT().ActivityHistory(testQuery).Return(testHistory)
+		mockDittoClient.EXPECT().Reply(testRequestID, gomock.AssignableToTypeOf(&protocol.Envelope{})).DoAndReturn(
+			func(_ string, message *protocol.Envelope) error {
+				assert.False(t, message.Headers.IsResponseRequired())
+				assert.Equal(t, 200, message.Status)
+				assertLiveMessageTopic(t, *tesThingID, protocol.TopicAction(updateManagerFeatureOperationHistory), message.Topic)
+				assert.Equal(t, fmt.Sprintf(outboxPathFmt, updateManagerFeatureOperationHistory), message.Path)
+				assert.Equal(t, testHistory, message.Value)
+				return nil
+			})
+		feature.messagesHandler(testRequestID, things.NewMessage(tesThingID).Feature(updateManagerFeatureID).Inbox(updateManagerFeatureOperationHistory).
+			WithPayload(testQuery).Envelope(protocol.WithResponseRequired(true)))
+	})
+
+	t.Run("test_message_handler_history_error", func(t *testing.T) {
+		mockDittoClient.EXPECT().Reply(testRequestID, gomock.AssignableToTypeOf(&protocol.Envelope{})).DoAndReturn(
+			func(_ string, message *protocol.Envelope) error {
+				assert.Equal(t, responseStatusBadRequest, message.Status)
+				return nil
+			})
+		feature.messagesHandler(testRequestID, things.NewMessage(tesThingID).Feature(updateManagerFeatureID).Inbox(updateManagerFeatureOperationHistory).
+			WithPayload("invalid payload").Envelope(protocol.WithResponseRequired(true)))
+	})
+}
+
+func TestMessageHandlerCompliance(t *testing.T) {
+	testRequestID := "testRequestID"
+	testReport := &types.ComplianceReport{Compliant: true}
+
+	feature := &updateManagerFeature{active: true, thingID: tesThingID}
+	mockCtrl, mockDittoClient, mockHandler, _ := setupMocks(t, feature)
+	defer mockCtrl.Finish()
+
+	mockProvider := mocks.NewMockComplianceProvider(mockCtrl)
+	feature.handler = &struct {
+		*mocks.MockUpdateAgentHandler
+		*mocks.MockComplianceProvider
+	}{mockHandler, mockProvider}
+
+	t.Run("test_message_handler_compliance_ok", func(t *testing.T) {
+		mockProvider.EXPECT().Compliance(gomock.AssignableToTypeOf(&types.DesiredState{})).Return(testReport)
+		mockDittoClient.EXPECT().Reply(testRequestID, gomock.AssignableToTypeOf(&protocol.Envelope{})).DoAndReturn(
+			func(_ string, message *protocol.Envelope) error {
+				assert.False(t, message.Headers.IsResponseRequired())
+				assert.Equal(t, 200, message.Status)
+				assertLiveMessageTopic(t, *tesThingID, protocol.TopicAction(updateManagerFeatureOperationCompliance), message.Topic)
+				assert.Equal(t, fmt.Sprintf(outboxPathFmt, updateManagerFeatureOperationCompliance), message.Path)
+				assert.Equal(t, testReport, message.Value)
+				return nil
+			})
+		feature.messagesHandler(testRequestID, things.NewMessage(tesThingID).Feature(updateManagerFeatureID).Inbox(updateManagerFeatureOperationCompliance).
+			WithPayload(&applyArgs{base: base{ActivityID: test.ActivityID}, DesiredState: test.DesiredState}).Envelope(protocol.WithResponseRequired(true)))
+	})
+
+	t.Run("test_message_handler_compliance_nil_desired_state_error", func(t *testing.T) {
+		mockDittoClient.EXPECT().Reply(testRequestID, gomock.AssignableToTypeOf(&protocol.Envelope{})).DoAndReturn(
+			func(_ string, message *protocol.Envelope) error {
+				assert.Equal(t, responseStatusBadRequest, message.Status)
+				return nil
+			})
+		feature.messagesHandler(testRequestID, things.NewMessage(tesThingID).Feature(updateManagerFeatureID).Inbox(updateManagerFeatureOperationCompliance).
+			WithPayload(&applyArgs{base: base{ActivityID: test.ActivityID}}).Envelope(protocol.WithResponseRequired(true)))
+	})
+
+	t.Run("test_message_handler_compliance_error", func(t *testing.T) {
+		mockDittoClient.EXPECT().Reply(testRequestID, gomock.AssignableToTypeOf(&protocol.Envelope{})).DoAndReturn(
+			func(_ string, message *protocol.Envelope) error {
+				assert.Equal(t, responseStatusBadRequest, message.Status)
+				return nil
+			})
+		feature.messagesHandler(testRequestID, things.NewMessage(tesThingID).Feature(updateManagerFeatureID).Inbox(updateManagerFeatureOperationCompliance).
+			WithPayload("invalid payload").Envelope(protocol.WithResponseRequired(true)))
+	})
+}
+
+func TestMessageHandlerSignedApply(t *testing.T) {
+	testRequestID := "testRequestID"
+	signer := test.NewSigner(t, t.TempDir())
+	verifier, err := jws.NewVerifier(&jws.Config{CACert: signer.CACert})
+	assert.NoError(t, err)
+	untrusted := test.NewSigner(t, t.TempDir())
+
+	feature := &updateManagerFeature{active: true, thingID: tesThingID, verifier: verifier}
+	mockCtrl, mockDittoClient, mockHandler, _ := setupMocks(t, feature)
+	defer mockCtrl.Finish()
+
+	mockSignedHandler := mocks.NewMockSignedDesiredStateHandler(mockCtrl)
+	feature.handler = &struct {
+		*mocks.MockUpdateAgentHandler
+		*mocks.MockSignedDesiredStateHandler
+	}{mockHandler, mockSignedHandler}
+
+	args := &applyArgs{base: base{ActivityID: test.ActivityID}, DesiredState: test.DesiredState}
+	payload, err := json.Marshal(args)
+	assert.NoError(t, err)
+	applyEnvelope := func(value interface{}) *protocol.Envelope {
+		return things.NewMessage(tesThingID).Feature(updateManagerFeatureID).Inbox(updateManagerFeatureOperationApply).WithPayload(value).
+			Envelope(protocol.WithResponseRequired(true))
+	}
+
+	t.Run("test_message_handler_signed_apply_ok", func(t *testing.T) {
+		mockDittoClient.EXPECT().Reply(testRequestID, gomock.AssignableToTypeOf(&protocol.Envelope{})).DoAndReturn(
+			func(_ string, message *protocol.Envelope) error {
+				assert.Equal(t, 204, message.Status)
+				return nil
+			})
+		testWG.Add(1)
+		mockSignedHandler.EXPECT().HandleSignedDesiredState(test.ActivityID, gomock.Any(), test.SignerSubject, test.DesiredState).DoAndReturn(
+			func(activityID string, timestamp int64, signer string, ds *types.DesiredState) error {
+				testWG.Done()
+				return nil
+			})
+		feature.messagesHandler(testRequestID, applyEnvelope(string(signer.Sign(t, payload))))
+		test.AssertWithTimeout(t, testWG, 2*time.Second)
+	})
+
+	rejected := map[string]interface{}{
+		"test_message_handler_signed_apply_not_signed": args,
+		"test_message_handler_signed_apply_untrusted":  string(untrusted.Sign(t, payload)),
+	}
+	for name, value := range rejected {
+		t.Run(name, func(t *testing.T) {
+			mockDittoClient.EXPECT().Reply(testRequestID, gomock.AssignableToTypeOf(&protocol.Envelope{})).DoAndReturn(
+				func(_ string, message *protocol.Envelope) error {
+					assert.Equal(t, responseStatusBadRequest, message.Status)
+					return nil
+				})
+			mockDittoClient.EXPECT().Send(gomock.AssignableToTypeOf(&protocol.Envelope{})).DoAndReturn(func(message *protocol.Envelope) error {
+				assert.Equal(t, fmt.Sprintf(outboxPathFmt, updateManagerFeatureMessageFeedback), message.Path)
+				feedback := message.Value.(*feedback)
+				// the activity id of the rejected desired state is not verified, so it is not echoed
+				assert.Equal(t, "", feedback.ActivityID)
+				assert.Equal(t, types.StatusIdentificationFailed, feedback.DesiredStateFeedback.Status)
+				return nil
+			})
+			feature.messagesHandler(testRequestID, applyEnvelope(value))
+		})
+	}
+}
+
 func assertTwinCommandTopic(t *testing.T, tesThingID model.NamespacedID, topic *protocol.Topic) {
 	expectedTopic := (&protocol.Topic{}).
 		WithNamespace(tesThingID.Namespace).
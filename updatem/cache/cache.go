@@ -0,0 +1,311 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/eclipse-kanto/update-manager/api"
+	"github.com/eclipse-kanto/update-manager/api/types"
+	"github.com/eclipse-kanto/update-manager/api/util"
+	"github.com/eclipse-kanto/update-manager/logger"
+	"github.com/eclipse-kanto/update-manager/util/download"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+const (
+	digestPrefix    = "sha256:"
+	blobsDir        = "blobs"
+	reservationsDir = "reservations"
+)
+
+var digestPattern = regexp.MustCompile(`^sha256:[a-f0-9]{64}$`)
+
+// Cache is a content-addressed store of downloaded artifacts on the local disk, shared between the domain agents.
+// The least recently used artifacts are evicted, so that the cached artifacts and the pending reservations fit into the disk quota.
+type Cache struct {
+	dir                string
+	maxSize            int64
+	reservationTimeout time.Duration
+	now                func() time.Time
+
+	lock         sync.Mutex
+	artifacts    map[string]*artifact
+	size         int64
+	reservations map[string]*types.ArtifactReservation
+	reserved     int64
+}
+
+type artifact struct {
+	size     int64
+	lastUsed time.Time
+}
+
+// New creates the artifact cache in the configured directory and loads the previously cached artifacts.
+// The pending reservations are not kept after restart. Nil is returned if the artifact cache is not enabled.
+func New(config *Config) (*Cache, error) {
+	if !config.IsEnabled() {
+		return nil, nil
+	}
+	if config.MaxSize <= 0 {
+		return nil, errors.Errorf("invalid artifact cache max size %d, a positive number of megabytes is expected", config.MaxSize)
+	}
+	cache := &Cache{
+		dir:                config.Dir,
+		maxSize:            int64(config.MaxSize) << 20,
+		reservationTimeout: util.ParseDuration("artifact-cache-reservation-timeout", config.ReservationTimeout, time.Hour, time.Hour),
+		now:                time.Now,
+		artifacts:          map[string]*artifact{},
+		reservations:       map[string]*types.ArtifactReservation{},
+	}
+	if err := os.RemoveAll(filepath.Join(cache.dir, reservationsDir)); err != nil {
+		return nil, errors.Wrap(err, "cannot remove the pending artifact cache reservations")
+	}
+	for _, dir := range []string{cache.blobsDir(), filepath.Join(cache.dir, reservationsDir)} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, errors.Wrap(err, "cannot create artifact cache directory")
+		}
+	}
+	if err := cache.load(); err != nil {
+		return nil, err
+	}
+	cache.evict(0)
+	logger.Debug("loaded %d cached artifacts of %d bytes from %s", len(cache.artifacts), cache.size, cache.dir)
+	return cache, nil
+}
+
+// load adds the artifact files in the cache directory to the cache, their modification time is used as last usage time.
+func (cache *Cache) load() error {
+	entries, err := os.ReadDir(cache.blobsDir())
+	if err != nil {
+		return errors.Wrap(err, "cannot read artifact cache directory")
+	}
+	for _, entry := range entries {
+		digest := digestPrefix + entry.Name()
+		if !entry.Type().IsRegular() || !digestPattern.MatchString(digest) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		cache.artifacts[digest] = &artifact{size: info.Size(), lastUsed: info.ModTime()}
+		cache.size += info.Size()
+	}
+	return nil
+}
+
+// Lookup returns the cached artifact with the given digest and marks it as recently used.
+func (cache *Cache) Lookup(digest string) (*types.CachedArtifact, error) {
+	digest, err := checkDigest(digest)
+	if err != nil {
+		return nil, err
+	}
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+
+	cached, ok := cache.artifacts[digest]
+	if !ok {
+		return nil, errors.Wrapf(api.ErrArtifactNotCached, "cannot find artifact %s", digest)
+	}
+	now := cache.now()
+	if err := os.Chtimes(cache.path(digest), now, now); os.IsNotExist(err) {
+		// the artifact file has been removed outside of the cache
+		cache.remove(digest)
+		return nil, errors.Wrapf(api.ErrArtifactNotCached, "cannot find artifact %s", digest)
+	}
+	cached.lastUsed = now
+	return &types.CachedArtifact{Digest: digest, Size: cached.size, Path: cache.path(digest)}, nil
+}
+
+// Reserve reserves space for an artifact with the given digest and size, zero if unknown. The least recently used artifacts
+// are evicted, if needed. The artifact is to be written to the path of the returned reservation before it is committed.
+func (cache *Cache) Reserve(digest string, size int64) (*types.ArtifactReservation, error) {
+	digest, err := checkDigest(digest)
+	if err != nil {
+		return nil, err
+	}
+	if size < 0 {
+		return nil, errors.Wrapf(api.ErrInvalidArtifact, "invalid size %d of artifact %s", size, digest)
+	}
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+
+	cache.expire()
+	if !cache.evict(size) {
+		return nil, errors.Wrapf(api.ErrArtifactCacheFull, "cannot reserve %d bytes for artifact %s", size, digest)
+	}
+	id := uuid.New().String()
+	reservation := &types.ArtifactReservation{
+		ID:      id,
+		Digest:  digest,
+		Size:    size,
+		Path:    filepath.Join(cache.dir, reservationsDir, id),
+		Expires: cache.now().Add(cache.reservationTimeout).UnixNano() / int64(time.Millisecond),
+	}
+	cache.reservations[id] = reservation
+	cache.reserved += size
+	result := *reservation
+	return &result, nil
+}
+
+// Commit verifies the artifact written to the path of the given reservation against its digest and size and stores it in the cache.
+// The reservation is released in any case. If the artifact is already cached, the written artifact is discarded.
+func (cache *Cache) Commit(reservationID string) (*types.CachedArtifact, error) {
+	reservation, err := cache.release(reservationID)
+	if err != nil {
+		return nil, err
+	}
+	defer removeReservationFiles(reservation)
+
+	hash := strings.TrimPrefix(reservation.Digest, digestPrefix)
+	if err := download.Verify(&download.Artifact{Size: reservation.Size, SHA256: hash}, reservation.Path); err != nil {
+		return nil, errors.Wrapf(api.ErrInvalidArtifact, "cannot commit artifact %s: %v", reservation.Digest, err)
+	}
+	info, err := os.Stat(reservation.Path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot commit artifact %s", reservation.Digest)
+	}
+
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+
+	now := cache.now()
+	if cached, ok := cache.artifacts[reservation.Digest]; ok {
+		cached.lastUsed = now
+		return &types.CachedArtifact{Digest: reservation.Digest, Size: cached.size, Path: cache.path(reservation.Digest)}, nil
+	}
+	if !cache.evict(info.Size()) {
+		return nil, errors.Wrapf(api.ErrArtifactCacheFull, "cannot store %d bytes of artifact %s", info.Size(), reservation.Digest)
+	}
+	if err := os.Rename(reservation.Path, cache.path(reservation.Digest)); err != nil {
+		return nil, errors.Wrapf(err, "cannot store artifact %s", reservation.Digest)
+	}
+	cache.artifacts[reservation.Digest] = &artifact{size: info.Size(), lastUsed: now}
+	cache.size += info.Size()
+	logger.Debug("stored artifact %s of %d bytes in the artifact cache", reservation.Digest, info.Size())
+	return &types.CachedArtifact{Digest: reservation.Digest, Size: info.Size(), Path: cache.path(reservation.Digest)}, nil
+}
+
+// Release releases the given reservation and removes the artifact written to its path, if any.
+func (cache *Cache) Release(reservationID string) error {
+	reservation, err := cache.release(reservationID)
+	if err != nil {
+		return err
+	}
+	removeReservationFiles(reservation)
+	return nil
+}
+
+// Usage returns the number and the total size of the cached artifacts, the total size of the pending reservations and the disk quota.
+func (cache *Cache) Usage() (*types.ArtifactCacheUsage, error) {
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+
+	cache.expire()
+	return &types.ArtifactCacheUsage{
+		Artifacts: len(cache.artifacts),
+		Size:      cache.size,
+		Reserved:  cache.reserved,
+		MaxSize:   cache.maxSize,
+	}, nil
+}
+
+func (cache *Cache) release(reservationID string) (*types.ArtifactReservation, error) {
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+
+	cache.expire()
+	reservation, ok := cache.reservations[reservationID]
+	if !ok {
+		return nil, errors.Wrapf(api.ErrArtifactReservationNotFound, "cannot find reservation '%s'", reservationID)
+	}
+	delete(cache.reservations, reservationID)
+	cache.reserved -= reservation.Size
+	return reservation, nil
+}
+
+// expire releases the reservations, which have not been committed in time.
+func (cache *Cache) expire() {
+	now := cache.now().UnixNano() / int64(time.Millisecond)
+	for id, reservation := range cache.reservations {
+		if reservation.Expires <= now {
+			logger.Debug("artifact cache reservation '%s' for artifact %s has expired", id, reservation.Digest)
+			delete(cache.reservations, id)
+			cache.reserved -= reservation.Size
+			removeReservationFiles(reservation)
+		}
+	}
+}
+
+// evict removes the least recently used artifacts until the given size fits into the disk quota together with the cached artifacts
+// and the pending reservations. Nothing is removed and false is returned, if the given size does not fit even without cached artifacts.
+func (cache *Cache) evict(size int64) bool {
+	if cache.reserved+size > cache.maxSize {
+		return false
+	}
+	for cache.size+cache.reserved+size > cache.maxSize {
+		var oldest string
+		for digest, cached := range cache.artifacts {
+			if oldest == "" || cached.lastUsed.Before(cache.artifacts[oldest].lastUsed) {
+				oldest = digest
+			}
+		}
+		logger.Debug("evicting artifact %s of %d bytes from the artifact cache", oldest, cache.artifacts[oldest].size)
+		if err := os.Remove(cache.path(oldest)); err != nil && !os.IsNotExist(err) {
+			logger.WarnErr(err, "cannot remove artifact %s from the artifact cache", oldest)
+		}
+		cache.remove(oldest)
+	}
+	return true
+}
+
+func (cache *Cache) remove(digest string) {
+	if cached, ok := cache.artifacts[digest]; ok {
+		delete(cache.artifacts, digest)
+		cache.size -= cached.size
+	}
+}
+
+// blobsDir returns the directory of the cached artifact files, which are named by the hex encoded SHA-256 checksum of their content.
+func (cache *Cache) blobsDir() string {
+	return filepath.Join(cache.dir, blobsDir, "sha256")
+}
+
+func (cache *Cache) path(digest string) string {
+	return filepath.Join(cache.blobsDir(), strings.TrimPrefix(digest, digestPrefix))
+}
+
+func removeReservationFiles(reservation *types.ArtifactReservation) {
+	for _, file := range []string{reservation.Path, reservation.Path + download.PartSuffix} {
+		if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+			logger.WarnErr(err, "cannot remove artifact cache reservation file %s", file)
+		}
+	}
+}
+
+// checkDigest returns the given digest in lower case, or an error if it is not in the format sha256:<hex>.
+func checkDigest(digest string) (string, error) {
+	normalized := strings.ToLower(digest)
+	if !digestPattern.MatchString(normalized) {
+		return "", errors.Wrapf(api.ErrInvalidArtifact, "invalid digest '%s', sha256:<hex> is expected", digest)
+	}
+	return normalized, nil
+}
@@ -0,0 +1,169 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/eclipse-kanto/update-manager/api"
+	"github.com/eclipse-kanto/update-manager/api/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestCache(t *testing.T, dir string, maxSize int64) (*Cache, *time.Time) {
+	cache, err := New(&Config{Dir: dir, MaxSize: 1, ReservationTimeout: "1m"})
+	require.NoError(t, err)
+	cache.maxSize = maxSize
+	now := time.Unix(1700000000, 0)
+	cache.now = func() time.Time {
+		return now
+	}
+	return cache, &now
+}
+
+func digestOf(data string) string {
+	sum := sha256.Sum256([]byte(data))
+	return digestPrefix + hex.EncodeToString(sum[:])
+}
+
+func store(t *testing.T, cache *Cache, data string) *types.CachedArtifact {
+	reservation, err := cache.Reserve(digestOf(data), int64(len(data)))
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(reservation.Path, []byte(data), 0644))
+	cached, err := cache.Commit(reservation.ID)
+	require.NoError(t, err)
+	return cached
+}
+
+func TestNewDisabled(t *testing.T) {
+	cache, err := New(NewDefaultConfig())
+	assert.NoError(t, err)
+	assert.Nil(t, cache)
+
+	_, err = New(&Config{Dir: t.TempDir()})
+	assert.EqualError(t, err, "invalid artifact cache max size 0, a positive number of megabytes is expected")
+}
+
+func TestStoreAndLookup(t *testing.T) {
+	cache, _ := newTestCache(t, t.TempDir(), 100)
+
+	_, err := cache.Lookup(digestOf("data"))
+	assert.True(t, errors.Is(err, api.ErrArtifactNotCached))
+
+	cached := store(t, cache, "data")
+	assert.Equal(t, digestOf("data"), cached.Digest)
+	assert.Equal(t, int64(4), cached.Size)
+	data, err := os.ReadFile(cached.Path)
+	require.NoError(t, err)
+	assert.Equal(t, "data", string(data))
+
+	found, err := cache.Lookup(digestOf("data"))
+	require.NoError(t, err)
+	assert.Equal(t, cached, found)
+	usage, err := cache.Usage()
+	require.NoError(t, err)
+	assert.Equal(t, &types.ArtifactCacheUsage{Artifacts: 1, Size: 4, MaxSize: 100}, usage)
+
+	// a duplicated artifact is discarded
+	assert.Equal(t, cached, store(t, cache, "data"))
+	usage, _ = cache.Usage()
+	assert.Equal(t, int64(4), usage.Size)
+
+	// the artifacts are loaded on restart and the pending reservations are dropped
+	reservation, err := cache.Reserve(digestOf("other"), 5)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(reservation.Path, []byte("other"), 0644))
+	cache, _ = newTestCache(t, cache.dir, 100)
+	found, err = cache.Lookup(digestOf("data"))
+	require.NoError(t, err)
+	assert.Equal(t, cached, found)
+	assert.NoFileExists(t, reservation.Path)
+	assert.True(t, errors.Is(cache.Release(reservation.ID), api.ErrArtifactReservationNotFound))
+}
+
+func TestCommitInvalid(t *testing.T) {
+	cache, _ := newTestCache(t, t.TempDir(), 100)
+
+	reservation, err := cache.Reserve(digestOf("data"), 4)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(reservation.Path, []byte("tampered"), 0644))
+	_, err = cache.Commit(reservation.ID)
+	assert.True(t, errors.Is(err, api.ErrInvalidArtifact))
+	assert.NoFileExists(t, reservation.Path)
+
+	// the reservation is released by the commit
+	_, err = cache.Commit(reservation.ID)
+	assert.True(t, errors.Is(err, api.ErrArtifactReservationNotFound))
+
+	_, err = cache.Reserve("md5:abc", 4)
+	assert.True(t, errors.Is(err, api.ErrInvalidArtifact))
+	_, err = cache.Reserve(digestOf("data"), -1)
+	assert.True(t, errors.Is(err, api.ErrInvalidArtifact))
+}
+
+func TestEviction(t *testing.T) {
+	cache, now := newTestCache(t, t.TempDir(), 10)
+
+	first := store(t, cache, "aaaa")
+	*now = now.Add(time.Second)
+	second := store(t, cache, "bbbb")
+	*now = now.Add(time.Second)
+	_, err := cache.Lookup(first.Digest)
+	require.NoError(t, err)
+	*now = now.Add(time.Second)
+
+	// the least recently used artifact is evicted
+	store(t, cache, "cccc")
+	assert.NoFileExists(t, second.Path)
+	_, err = cache.Lookup(second.Digest)
+	assert.True(t, errors.Is(err, api.ErrArtifactNotCached))
+	_, err = cache.Lookup(first.Digest)
+	assert.NoError(t, err)
+
+	// an artifact, which does not fit even without the cached artifacts, is rejected without eviction
+	reservation, err := cache.Reserve(digestOf("dddd"), 8)
+	require.NoError(t, err)
+	_, err = cache.Reserve(digestOf("eeee"), 4)
+	assert.True(t, errors.Is(err, api.ErrArtifactCacheFull))
+	usage, _ := cache.Usage()
+	assert.Equal(t, &types.ArtifactCacheUsage{Artifacts: 0, Reserved: 8, MaxSize: 10}, usage)
+
+	// the reserved space is freed on release
+	require.NoError(t, os.WriteFile(reservation.Path, []byte("dddd"), 0644))
+	require.NoError(t, cache.Release(reservation.ID))
+	assert.NoFileExists(t, reservation.Path)
+	store(t, cache, "eeee")
+}
+
+func TestReservationExpiry(t *testing.T) {
+	cache, now := newTestCache(t, t.TempDir(), 10)
+
+	reservation, err := cache.Reserve(digestOf("data"), 0)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(cache.dir, reservationsDir, reservation.ID), reservation.Path)
+	assert.Equal(t, now.Add(time.Minute).UnixNano()/int64(time.Millisecond), reservation.Expires)
+	require.NoError(t, os.WriteFile(reservation.Path, []byte("data"), 0644))
+
+	*now = now.Add(time.Minute)
+	_, err = cache.Commit(reservation.ID)
+	assert.True(t, errors.Is(err, api.ErrArtifactReservationNotFound))
+	assert.NoFileExists(t, reservation.Path)
+}
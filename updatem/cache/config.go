@@ -0,0 +1,41 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package cache
+
+const (
+	// default artifact cache config
+	defaultDir                = ""
+	defaultMaxSize            = 1024
+	defaultReservationTimeout = "1h"
+)
+
+// Config represents the artifact cache config
+type Config struct {
+	Dir                string `json:"dir,omitempty"`
+	MaxSize            int    `json:"maxSize,omitempty"`
+	ReservationTimeout string `json:"reservationTimeout,omitempty"`
+}
+
+// NewDefaultConfig returns a default artifact cache config instance, the artifact cache is disabled by default
+func NewDefaultConfig() *Config {
+	return &Config{
+		Dir:                defaultDir,
+		MaxSize:            defaultMaxSize,
+		ReservationTimeout: defaultReservationTimeout,
+	}
+}
+
+// IsEnabled returns true if a directory for the artifact cache is configured.
+func (config *Config) IsEnabled() bool {
+	return config != nil && config.Dir != ""
+}
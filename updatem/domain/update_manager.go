@@ -22,6 +22,7 @@ import (
 	"github.com/eclipse-kanto/update-manager/api/types"
 	"github.com/eclipse-kanto/update-manager/api/util"
 	"github.com/eclipse-kanto/update-manager/logger"
+	"github.com/eclipse-kanto/update-manager/util/secret"
 
 	"github.com/pkg/errors"
 )
@@ -30,6 +31,7 @@ type domainUpdateManager struct {
 	managerLock      sync.Mutex
 	desiredStateLock sync.Mutex
 	currentStateLock sync.Mutex
+	secretsLock      sync.Mutex
 
 	desiredStateClient api.DesiredStateClient
 	eventCallback      api.UpdateManagerCallback
@@ -38,6 +40,10 @@ type domainUpdateManager struct {
 
 	updateOperation *updateOperation
 	currentState    *internalCurrentState
+
+	secretResolver *secret.Resolver
+	secretKeys     *secret.Keys
+	secrets        secrets
 }
 
 type internalCurrentState struct {
@@ -57,10 +63,15 @@ func newUpdateOperation(activityID string) *updateOperation {
 	}
 }
 
-// NewUpdateManager instantiates a new instance of a domain specific update manager
-func NewUpdateManager(desiredStateClient api.DesiredStateClient, updateConfig *api.UpdateManagerConfig) api.UpdateManager {
+// NewUpdateManager instantiates a new instance of a domain specific update manager, the secret configuration values of the desired state
+// are resolved with the given secret resolver when the desired state is forwarded to the domain agent and their configuration keys are
+// added to the given secret keys, so that the node parameters reported with these keys are redacted in the current state.
+func NewUpdateManager(desiredStateClient api.DesiredStateClient, updateConfig *api.UpdateManagerConfig, secretResolver *secret.Resolver,
+	secretKeys *secret.Keys) api.UpdateManager {
 	return &domainUpdateManager{
 		desiredStateClient: desiredStateClient,
+		secretResolver:     secretResolver,
+		secretKeys:         secretKeys,
 		readTimeout:        util.ParseDuration(updateConfig.Name+"-read-timeout", updateConfig.ReadTimeout, time.Minute, time.Minute),
 		currentState:       &internalCurrentState{},
 	}
@@ -73,15 +84,21 @@ func (updateManager *domainUpdateManager) Apply(ctx context.Context, activityID
 	domainName := updateManager.Name()
 
 	updateManager.updateOperation = newUpdateOperation(activityID)
-	logger.Debug("[%s] processing desired state specification - start", domainName)
+	updateManager.resetSecrets()
+	contextLogger(domainName, activityID, "").Debug("processing desired state specification - start")
 
+	desiredState, err := updateManager.resolveSecrets(desiredState)
+	if err != nil {
+		updateManager.eventCallback.HandleDesiredStateFeedbackEvent(domainName, activityID, "", types.StatusIncomplete, err.Error(), []*types.Action{})
+		return
+	}
 	if err := updateManager.desiredStateClient.SendDesiredState(activityID, desiredState); err != nil {
 		errMessage := fmt.Sprintf("%s. cannot send desired state manifest to domain %s", err.Error(), domainName)
 		updateManager.eventCallback.HandleDesiredStateFeedbackEvent(domainName, activityID, "", types.StatusIncomplete, errMessage, []*types.Action{})
 		return
 	}
 
-	logger.Debug("[%s] processing desired state specification - done", domainName)
+	contextLogger(domainName, activityID, "").Debug("processing desired state specification - done")
 }
 
 func (updateManager *domainUpdateManager) Command(ctx context.Context, activityID string, command *types.DesiredStateCommand) {
@@ -90,14 +107,19 @@ func (updateManager *domainUpdateManager) Command(ctx context.Context, activityI
 
 	domainName := updateManager.Name()
 
-	logger.Debug("[%s] processing desired state command request '%v' with activityId '%s'", domainName, command, activityID)
+	var phase string
+	if command != nil {
+		phase = string(command.Command)
+	}
+	log := contextLogger(domainName, activityID, phase)
+	log.Debug("processing desired state command request '%v'", command)
 
 	if updateManager.updateOperation == nil {
-		logger.Warn("[%s] the desired state command will be skipped, no active desired state apply operation.", domainName)
+		log.Warn("the desired state command will be skipped, no active desired state apply operation.")
 		return
 	}
 	if updateManager.updateOperation.activityID != activityID {
-		logger.Warn("[%s] activity id mismatch for desired state command request - expecting %s, received %s", domainName, updateManager.updateOperation.activityID, activityID)
+		log.Warn("activity id mismatch for desired state command request - expecting %s, received %s", updateManager.updateOperation.activityID, activityID)
 		return
 	}
 	if err := updateManager.desiredStateClient.SendDesiredStateCommand(activityID, command); err != nil {
@@ -105,7 +127,7 @@ func (updateManager *domainUpdateManager) Command(ctx context.Context, activityI
 		updateManager.eventCallback.HandleDesiredStateFeedbackEvent(domainName, activityID, "", types.StatusIncomplete, errMessage, []*types.Action{})
 		return
 	}
-	logger.Debug("[%s] processing desired state command '%v' with activityId '%s' - done", domainName, command, activityID)
+	log.Debug("processing desired state command '%v' - done", command)
 }
 
 func (updateManager *domainUpdateManager) Name() string {
@@ -117,7 +139,7 @@ func (updateManager *domainUpdateManager) Get(ctx context.Context, activityID st
 	defer updateManager.managerLock.Unlock()
 
 	domainName := updateManager.Name()
-	logger.Debug("[%s] processing get current state for activityID '%s'", domainName, activityID)
+	contextLogger(domainName, activityID, "").Debug("processing get current state")
 
 	updateManager.setCurrentStateActivityID(activityID)
 	defer updateManager.setCurrentStateActivityID("")
@@ -156,10 +178,15 @@ func (updateManager *domainUpdateManager) Dispose() error {
 
 func (updateManager *domainUpdateManager) WatchEvents(ctx context.Context) {
 	if err := updateManager.desiredStateClient.Start(updateManager); err != nil {
-		logger.ErrorErr(err, "[%s] cannot subscribe for events", updateManager.Name())
+		contextLogger(updateManager.Name(), "", "").ErrorErr(err, "cannot subscribe for events")
 	}
 }
 
+// contextLogger returns a logger, which adds the given domain, activity ID and phase as context to the log messages.
+func contextLogger(domain, activityID, phase string) *logger.ContextLogger {
+	return logger.WithFields(logger.Fields{Domain: domain, ActivityID: activityID, Phase: phase})
+}
+
 func (updateManager *domainUpdateManager) SetCallback(callback api.UpdateManagerCallback) {
 	updateManager.eventCallback = callback
 }
@@ -14,23 +14,28 @@ package domain
 
 import (
 	"github.com/eclipse-kanto/update-manager/api/types"
-	"github.com/eclipse-kanto/update-manager/logger"
 )
 
 func (updateManager *domainUpdateManager) HandleDesiredStateFeedback(activityID string, timestamp int64, desiredStateFeedback *types.DesiredStateFeedback) error {
 	updateManager.desiredStateLock.Lock()
 	defer updateManager.desiredStateLock.Unlock()
 
+	desiredStateFeedback = updateManager.redactFeedback(desiredStateFeedback)
+	var phase string
+	if desiredStateFeedback != nil {
+		phase = string(desiredStateFeedback.Status)
+	}
+	log := contextLogger(updateManager.Name(), activityID, phase)
 	if updateManager.updateOperation == nil {
-		logger.Debug("[%s] ignoring received desired state feedback: %v", updateManager.Name(), desiredStateFeedback)
+		log.Debug("ignoring received desired state feedback: %v", desiredStateFeedback)
 		return nil
 	}
 
-	logger.Debug("[%s] received desired state feedback: %v", updateManager.Name(), desiredStateFeedback)
+	log.Debug("received desired state feedback: %v", desiredStateFeedback)
 
 	if updateManager.updateOperation.activityID != activityID {
-		logger.Warn("[%s] activity id mismatch for received desired state feedback event  - expecting %s, received %s",
-			updateManager.Name(), updateManager.updateOperation.activityID, activityID)
+		log.Warn("activity id mismatch for received desired state feedback event  - expecting %s, received %s",
+			updateManager.updateOperation.activityID, activityID)
 		return nil
 	}
 
@@ -42,11 +47,13 @@ func (updateManager *domainUpdateManager) HandleCurrentState(activityID string,
 	updateManager.currentStateLock.Lock()
 	defer updateManager.currentStateLock.Unlock()
 
-	logger.Debug("[%s] received current state event: %v", updateManager.Name(), inventory)
+	inventory = updateManager.redactInventory(inventory)
+	log := contextLogger(updateManager.Name(), activityID, "")
+	log.Debug("received current state event: %v", inventory)
 
 	if updateManager.currentState.inventory != nil && (timestamp < updateManager.currentState.timestamp) {
-		logger.Warn("[%s] received current state event with outdated timestamp - last known %s, received %s",
-			updateManager.Name(), updateManager.currentState.timestamp, timestamp)
+		log.Warn("received current state event with outdated timestamp - last known %d, received %d",
+			updateManager.currentState.timestamp, timestamp)
 		return nil
 	}
 
@@ -67,7 +67,7 @@ func TestHandleDesiredStateFeedbackMismatchActivityID(t *testing.T) {
 	defer mockCtrl.Finish()
 
 	desiredStateClient := mocks.NewMockDesiredStateClient(mockCtrl)
-	desiredStateClient.EXPECT().Domain().Return(testDomain).Times(2)
+	desiredStateClient.EXPECT().Domain().Return(testDomain).Times(1)
 	eventCallback := mocks.NewMockUpdateManagerCallback(mockCtrl)
 
 	updateManager := createTestDomainUpdateManager(desiredStateClient, eventCallback)
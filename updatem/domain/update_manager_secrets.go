@@ -0,0 +1,210 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package domain
+
+import (
+	"github.com/eclipse-kanto/update-manager/api/types"
+	"github.com/eclipse-kanto/update-manager/logger"
+	"github.com/eclipse-kanto/update-manager/util/secret"
+
+	"github.com/pkg/errors"
+)
+
+// secrets holds the plain secret values forwarded to the domain agent for the update activity in progress, so that they can be redacted
+// in the desired state feedback and the current state reported by the domain agent. The component configuration keys they are forwarded
+// with are kept in the secret keys of the update manager across the update activities.
+type secrets struct {
+	values []string
+}
+
+// resolveSecrets returns a copy of the given desired state with the secret configuration values resolved, so that only the desired state
+// forwarded to the domain agent holds the plain values. The given desired state is returned as it is, if it has no secret values.
+func (updateManager *domainUpdateManager) resolveSecrets(desiredState *types.DesiredState) (*types.DesiredState, error) {
+	if !hasSecrets(desiredState) {
+		return desiredState, nil
+	}
+	resolved := &types.DesiredState{Baselines: desiredState.Baselines}
+	for _, domain := range desiredState.Domains {
+		if domain == nil {
+			resolved.Domains = append(resolved.Domains, nil)
+			continue
+		}
+		resolvedDomain := &types.Domain{ID: domain.ID}
+		var err error
+		if resolvedDomain.Config, err = updateManager.resolveConfig(domain.ID, "", domain.Config); err != nil {
+			return nil, err
+		}
+		for _, component := range domain.Components {
+			if component == nil {
+				resolvedDomain.Components = append(resolvedDomain.Components, nil)
+				continue
+			}
+			resolvedComponent := &types.ComponentWithConfig{Component: component.Component}
+			if resolvedComponent.Config, err = updateManager.resolveConfig(domain.ID, component.ID, component.Config); err != nil {
+				return nil, err
+			}
+			resolvedDomain.Components = append(resolvedDomain.Components, resolvedComponent)
+		}
+		resolved.Domains = append(resolved.Domains, resolvedDomain)
+	}
+	return resolved, nil
+}
+
+func (updateManager *domainUpdateManager) resolveConfig(domain, component string, config []*types.KeyValuePair) ([]*types.KeyValuePair, error) {
+	var resolved []*types.KeyValuePair
+	for _, pair := range config {
+		if pair == nil || !secret.IsSecret(pair.Value) {
+			resolved = append(resolved, pair)
+			continue
+		}
+		value, err := updateManager.secretResolver.Resolve(pair.Value)
+		if err != nil {
+			if component == "" {
+				return nil, errors.Wrapf(err, "cannot resolve secret configuration '%s' of domain %s", pair.Key, domain)
+			}
+			return nil, errors.Wrapf(err, "cannot resolve secret configuration '%s' of component %s:%s", pair.Key, domain, component)
+		}
+		updateManager.addSecret(domain, component, pair.Key, value)
+		resolved = append(resolved, &types.KeyValuePair{Key: pair.Key, Value: value})
+	}
+	return resolved, nil
+}
+
+func (updateManager *domainUpdateManager) addSecret(domain, component, key, value string) {
+	updateManager.secretsLock.Lock()
+	defer updateManager.secretsLock.Unlock()
+
+	for _, known := range updateManager.secrets.values {
+		if known == value {
+			value = ""
+			break
+		}
+	}
+	if value != "" {
+		updateManager.secrets.values = append(updateManager.secrets.values, value)
+	}
+	if component == "" {
+		return
+	}
+	if err := updateManager.secretKeys.Add(domain+":"+component, key); err != nil {
+		logger.WithFields(logger.Fields{Domain: domain}).ErrorErr(err, "cannot save the secret configuration keys")
+	}
+}
+
+// resetSecrets forgets the secret values forwarded to the domain agent for the previous update activity, so that only the values
+// of the update activity in progress are kept. The parameters reported with the configuration keys of the previous secret values are still redacted.
+func (updateManager *domainUpdateManager) resetSecrets() {
+	updateManager.secretsLock.Lock()
+	defer updateManager.secretsLock.Unlock()
+
+	updateManager.secrets = secrets{}
+}
+
+// redactFeedback returns a copy of the given desired state feedback with the secret values forwarded to the domain agent redacted in its messages.
+func (updateManager *domainUpdateManager) redactFeedback(feedback *types.DesiredStateFeedback) *types.DesiredStateFeedback {
+	updateManager.secretsLock.Lock()
+	defer updateManager.secretsLock.Unlock()
+
+	if feedback == nil || len(updateManager.secrets.values) == 0 {
+		return feedback
+	}
+	values := updateManager.secrets.values
+	redacted := *feedback
+	redacted.Message = secret.Redact(feedback.Message, values)
+	if feedback.Actions != nil {
+		redacted.Actions = make([]*types.Action, 0, len(feedback.Actions))
+	}
+	for _, action := range feedback.Actions {
+		if action != nil {
+			redactedAction := *action
+			redactedAction.Message = secret.Redact(action.Message, values)
+			action = &redactedAction
+		}
+		redacted.Actions = append(redacted.Actions, action)
+	}
+	return &redacted
+}
+
+// redactInventory returns a copy of the given inventory with the node parameters, which hold secret values forwarded to the domain agent
+// or are reported with the configuration key of a secret value for the respective component, redacted.
+func (updateManager *domainUpdateManager) redactInventory(inventory *types.Inventory) *types.Inventory {
+	updateManager.secretsLock.Lock()
+	defer updateManager.secretsLock.Unlock()
+
+	if inventory == nil || (len(updateManager.secrets.values) == 0 && updateManager.secretKeys.Empty()) {
+		return inventory
+	}
+	redacted := &types.Inventory{Associations: inventory.Associations}
+	for _, node := range inventory.HardwareNodes {
+		if node != nil {
+			redactedNode := *node
+			redactedNode.InventoryNode = updateManager.redactNode(node.InventoryNode)
+			node = &redactedNode
+		}
+		redacted.HardwareNodes = append(redacted.HardwareNodes, node)
+	}
+	for _, node := range inventory.SoftwareNodes {
+		if node != nil {
+			redactedNode := *node
+			redactedNode.InventoryNode = updateManager.redactNode(node.InventoryNode)
+			node = &redactedNode
+		}
+		redacted.SoftwareNodes = append(redacted.SoftwareNodes, node)
+	}
+	return redacted
+}
+
+func (updateManager *domainUpdateManager) redactNode(node types.InventoryNode) types.InventoryNode {
+	parameters := node.Parameters
+	node.Parameters = nil
+	for _, parameter := range parameters {
+		if parameter != nil {
+			value := secret.Redact(parameter.Value, updateManager.secrets.values)
+			if updateManager.secretKeys.Contains(node.ID, parameter.Key) {
+				value = secret.Redacted
+			}
+			parameter = &types.KeyValuePair{Key: parameter.Key, Value: value}
+		}
+		node.Parameters = append(node.Parameters, parameter)
+	}
+	return node
+}
+
+func hasSecrets(desiredState *types.DesiredState) bool {
+	if desiredState == nil {
+		return false
+	}
+	for _, domain := range desiredState.Domains {
+		if domain == nil {
+			continue
+		}
+		if hasSecretConfig(domain.Config) {
+			return true
+		}
+		for _, component := range domain.Components {
+			if component != nil && hasSecretConfig(component.Config) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func hasSecretConfig(config []*types.KeyValuePair) bool {
+	for _, pair := range config {
+		if pair != nil && secret.IsSecret(pair.Value) {
+			return true
+		}
+	}
+	return false
+}
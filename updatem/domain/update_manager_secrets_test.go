@@ -0,0 +1,124 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package domain
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/eclipse-kanto/update-manager/api/types"
+	"github.com/eclipse-kanto/update-manager/test/mocks"
+	"github.com/eclipse-kanto/update-manager/util/secret"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyDesiredStateWithSecrets(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	storeDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(storeDir, "db-password"), []byte("p@ssw0rd\n"), 0600))
+	resolver, err := secret.NewResolver(&secret.Config{StoreDir: storeDir})
+	require.NoError(t, err)
+
+	desiredState := func(domainConfig, componentConfig []*types.KeyValuePair) *types.DesiredState {
+		return &types.DesiredState{
+			Domains: []*types.Domain{{
+				ID:     testDomain,
+				Config: domainConfig,
+				Components: []*types.ComponentWithConfig{
+					{Component: types.Component{ID: "app", Version: "1.0.0"}, Config: componentConfig},
+				},
+			}},
+		}
+	}
+	port := &types.KeyValuePair{Key: "port", Value: "5432"}
+	password := &types.KeyValuePair{Key: "password", Value: "secret:db-password"}
+
+	desiredStateClient := mocks.NewMockDesiredStateClient(mockCtrl)
+	desiredStateClient.EXPECT().Domain().Return(testDomain).AnyTimes()
+	eventCallback := mocks.NewMockUpdateManagerCallback(mockCtrl)
+	updateManager := createTestDomainUpdateManager(desiredStateClient, eventCallback)
+	updateManager.secretResolver = resolver
+	keysFile := filepath.Join(t.TempDir(), "secret-keys.json")
+	updateManager.secretKeys, err = secret.NewKeys(&secret.Config{KeysFile: keysFile})
+	require.NoError(t, err)
+	currentNode := func(parameters ...*types.KeyValuePair) *types.SoftwareNode {
+		return &types.SoftwareNode{InventoryNode: types.InventoryNode{ID: testDomain + ":app", Version: "1.0.0", Parameters: parameters}}
+	}
+
+	t.Run("test_apply_resolved_secrets", func(t *testing.T) {
+		testDesiredState := desiredState(nil, []*types.KeyValuePair{port, password})
+		desiredStateClient.EXPECT().SendDesiredState(testActivityID,
+			desiredState(nil, []*types.KeyValuePair{port, {Key: "password", Value: "p@ssw0rd"}})).Return(nil)
+
+		updateManager.Apply(context.Background(), testActivityID, testDesiredState)
+		assert.Equal(t, desiredState(nil, []*types.KeyValuePair{port, password}), testDesiredState)
+	})
+
+	t.Run("test_redact_feedback", func(t *testing.T) {
+		eventCallback.EXPECT().HandleDesiredStateFeedbackEvent(testDomain, testActivityID, "", types.StatusIncomplete, "cannot login with ******",
+			[]*types.Action{{Component: &types.Component{ID: "app"}, Status: types.ActionStatusUpdateFailure, Message: "invalid password ******"}})
+
+		assert.NoError(t, updateManager.HandleDesiredStateFeedback(testActivityID, 0, &types.DesiredStateFeedback{
+			Status:  types.StatusIncomplete,
+			Message: "cannot login with p@ssw0rd",
+			Actions: []*types.Action{{Component: &types.Component{ID: "app"}, Status: types.ActionStatusUpdateFailure, Message: "invalid password p@ssw0rd"}},
+		}))
+	})
+
+	t.Run("test_redact_current_state", func(t *testing.T) {
+		node := func(parameters ...*types.KeyValuePair) *types.SoftwareNode {
+			return &types.SoftwareNode{InventoryNode: types.InventoryNode{ID: testDomain + ":app", Version: "1.0.0", Parameters: parameters}}
+		}
+		inventory := &types.Inventory{SoftwareNodes: []*types.SoftwareNode{
+			node(port, &types.KeyValuePair{Key: "password", Value: "p@ss"}, &types.KeyValuePair{Key: "url", Value: "postgres://admin:p@ssw0rd@db"}),
+		}}
+		eventCallback.EXPECT().HandleCurrentStateEvent(testDomain, testActivityID, &types.Inventory{SoftwareNodes: []*types.SoftwareNode{
+			node(port, &types.KeyValuePair{Key: "password", Value: secret.Redacted}, &types.KeyValuePair{Key: "url", Value: "postgres://admin:******@db"}),
+		}})
+
+		assert.NoError(t, updateManager.HandleCurrentState(testActivityID, 0, inventory))
+		assert.Equal(t, "p@ss", inventory.SoftwareNodes[0].Parameters[1].Value)
+	})
+
+	t.Run("test_redact_current_state_after_new_activity", func(t *testing.T) {
+		desiredStateClient.EXPECT().SendDesiredState("new-activity", desiredState(nil, []*types.KeyValuePair{port})).Return(nil)
+
+		updateManager.Apply(context.Background(), "new-activity", desiredState(nil, []*types.KeyValuePair{port}))
+		assert.Empty(t, updateManager.secrets.values)
+
+		inventory := &types.Inventory{SoftwareNodes: []*types.SoftwareNode{currentNode(port, &types.KeyValuePair{Key: "password", Value: "p@ssw0rd"})}}
+		redacted := &types.Inventory{SoftwareNodes: []*types.SoftwareNode{currentNode(port, &types.KeyValuePair{Key: "password", Value: secret.Redacted})}}
+		eventCallback.EXPECT().HandleCurrentStateEvent(testDomain, "new-activity", redacted)
+		assert.NoError(t, updateManager.HandleCurrentState("new-activity", 1, inventory))
+
+		restarted := createTestDomainUpdateManager(desiredStateClient, eventCallback)
+		restarted.secretKeys, err = secret.NewKeys(&secret.Config{KeysFile: keysFile})
+		require.NoError(t, err)
+		eventCallback.EXPECT().HandleCurrentStateEvent(testDomain, "", redacted)
+		assert.NoError(t, restarted.HandleCurrentState("", 2, inventory))
+	})
+
+	t.Run("test_apply_unresolved_secret", func(t *testing.T) {
+		eventCallback.EXPECT().HandleDesiredStateFeedbackEvent(testDomain, testActivityID, "", types.StatusIncomplete,
+			"cannot resolve secret configuration 'token' of domain test-domain: invalid secret name '../token'", []*types.Action{})
+
+		updateManager.Apply(context.Background(), testActivityID, desiredState([]*types.KeyValuePair{{Key: "token", Value: "secret:../token"}}, nil))
+	})
+}
@@ -22,6 +22,7 @@ import (
 	"github.com/eclipse-kanto/update-manager/api"
 	"github.com/eclipse-kanto/update-manager/api/types"
 	"github.com/eclipse-kanto/update-manager/test/mocks"
+	"github.com/eclipse-kanto/update-manager/util/secret"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
@@ -317,7 +318,9 @@ func TestDesiredStateNotSent(t *testing.T) {
 }
 
 func createTestDomainUpdateManager(desiredStateClient api.DesiredStateClient, eventCallback api.UpdateManagerCallback) *domainUpdateManager {
+	secretKeys, _ := secret.NewKeys(nil)
 	return &domainUpdateManager{
+		secretKeys:         secretKeys,
 		desiredStateLock:   sync.Mutex{},
 		currentStateLock:   sync.Mutex{},
 		desiredStateClient: desiredStateClient,
@@ -0,0 +1,39 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package history
+
+const (
+	// default activity history config
+	defaultFile       = ""
+	defaultFileSize   = 2
+	defaultFileCount  = 10
+	defaultFileMaxAge = 0
+)
+
+// Config represents the activity history store config
+type Config struct {
+	File       string `json:"file,omitempty"`
+	FileSize   int    `json:"fileSize,omitempty"`
+	FileCount  int    `json:"fileCount,omitempty"`
+	FileMaxAge int    `json:"fileMaxAge,omitempty"`
+}
+
+// NewDefaultConfig returns a default activity history config instance, the history is kept only in memory by default
+func NewDefaultConfig() *Config {
+	return &Config{
+		File:       defaultFile,
+		FileSize:   defaultFileSize,
+		FileCount:  defaultFileCount,
+		FileMaxAge: defaultFileMaxAge,
+	}
+}
@@ -0,0 +1,266 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package history
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/eclipse-kanto/update-manager/api/types"
+	"github.com/eclipse-kanto/update-manager/logger"
+)
+
+const (
+	defaultQueryLimit = 20
+	maxQueryLimit     = 100
+)
+
+// Recorder records the course of the update activities into an append-only activity history.
+// All methods are safe to be called on a nil recorder, in which case nothing is recorded.
+type Recorder struct {
+	lock   sync.Mutex
+	store  *store
+	active *types.ActivityRecord
+}
+
+// NewRecorder creates a new activity history recorder with the given configuration.
+func NewRecorder(config *Config) (*Recorder, error) {
+	store, err := newStore(config)
+	if err != nil {
+		return nil, err
+	}
+	return &Recorder{store: store}, nil
+}
+
+// ActivityStarted records the start of a new update activity for the given desired state and its verified signer, if any.
+func (recorder *Recorder) ActivityStarted(activityID string, desiredState *types.DesiredState, signer string) {
+	if recorder == nil {
+		return
+	}
+	recorder.lock.Lock()
+	defer recorder.lock.Unlock()
+
+	recorder.active = &types.ActivityRecord{
+		ActivityID:       activityID,
+		DesiredStateHash: hashDesiredState(desiredState),
+		Signer:           signer,
+		StartTime:        now(),
+		Domains:          map[string]types.StatusType{},
+	}
+	recorder.store.save(recorder.active)
+}
+
+// ActivityRejected records an update activity, which desired state is rejected before it is applied, with the rejection reason
+// and the verified signer of the desired state, if any. The desired state is nil, if the rejected desired state is not known.
+func (recorder *Recorder) ActivityRejected(activityID string, desiredState *types.DesiredState, signer string, reason error) {
+	if recorder == nil {
+		return
+	}
+	recorder.lock.Lock()
+	defer recorder.lock.Unlock()
+
+	timestamp := now()
+	record := &types.ActivityRecord{
+		ActivityID: activityID,
+		Signer:     signer,
+		StartTime:  timestamp,
+		EndTime:    timestamp,
+		Status:     types.StatusIdentificationFailed,
+		Message:    reason.Error(),
+		Phases:     []*types.PhaseRecord{{Status: types.StatusIdentificationFailed, Timestamp: timestamp}},
+		Rejected:   true,
+	}
+	if desiredState != nil {
+		record.DesiredStateHash = hashDesiredState(desiredState)
+	}
+	recorder.store.save(record)
+}
+
+// StatusChanged records a new phase of the update activity, if the status differs from the current one.
+func (recorder *Recorder) StatusChanged(activityID string, status types.StatusType, message string) {
+	recorder.update(activityID, func(record *types.ActivityRecord) bool {
+		if message != "" {
+			record.Message = message
+		}
+		if status == record.Status {
+			return false
+		}
+		record.Status = status
+		record.Phases = append(record.Phases, &types.PhaseRecord{Status: status, Timestamp: now()})
+		return true
+	})
+}
+
+// DomainStatusChanged records the latest status reported for the given domain, it is persisted with the next activity change.
+func (recorder *Recorder) DomainStatusChanged(activityID string, domain string, status types.StatusType) {
+	recorder.update(activityID, func(record *types.ActivityRecord) bool {
+		record.Domains[domain] = status
+		return false
+	})
+}
+
+// ConsentRequested records an owner consent request for the given command.
+func (recorder *Recorder) ConsentRequested(activityID string, command types.CommandType) {
+	recorder.update(activityID, func(record *types.ActivityRecord) bool {
+		record.Consents = append(record.Consents, &types.ConsentRecord{Command: command, RequestTime: now()})
+		return true
+	})
+}
+
+// ConsentReceived records the owner decision for the last owner consent request.
+func (recorder *Recorder) ConsentReceived(activityID string, feedback *types.OwnerConsentFeedback) {
+	recorder.update(activityID, func(record *types.ActivityRecord) bool {
+		if len(record.Consents) == 0 {
+			return false
+		}
+		consent := record.Consents[len(record.Consents)-1]
+		if consent.Status != "" {
+			return false
+		}
+		consent.Status = feedback.Status
+		consent.User = feedback.User
+		consent.DecisionTime = now()
+		return true
+	})
+}
+
+// ActivityFinished records the end of the update activity and whether a reboot is about to be performed.
+func (recorder *Recorder) ActivityFinished(activityID string, rebootRequired bool, rebootEnabled bool) {
+	if recorder == nil {
+		return
+	}
+	recorder.update(activityID, func(record *types.ActivityRecord) bool {
+		record.EndTime = now()
+		if rebootRequired {
+			record.Reboot = &types.RebootRecord{Required: true, Enabled: rebootEnabled}
+			if rebootEnabled {
+				record.Reboot.Timestamp = record.EndTime
+			}
+		}
+		return true
+	})
+
+	recorder.lock.Lock()
+	defer recorder.lock.Unlock()
+	if recorder.active != nil && recorder.active.ActivityID == activityID && (recorder.active.Reboot == nil || !recorder.active.Reboot.Enabled) {
+		recorder.active = nil
+	}
+}
+
+// RebootFailed records that the reboot after the update activity has failed.
+func (recorder *Recorder) RebootFailed(activityID string, err error) {
+	if recorder == nil {
+		return
+	}
+	recorder.update(activityID, func(record *types.ActivityRecord) bool {
+		if record.Reboot == nil {
+			record.Reboot = &types.RebootRecord{Required: true, Enabled: true}
+		}
+		record.Reboot.Error = err.Error()
+		return true
+	})
+
+	recorder.lock.Lock()
+	defer recorder.lock.Unlock()
+	if recorder.active != nil && recorder.active.ActivityID == activityID {
+		recorder.active = nil
+	}
+}
+
+// ActivityHistory returns the recorded activities matching the given query, the latest activities come first.
+func (recorder *Recorder) ActivityHistory(query *types.ActivityHistoryQuery) *types.ActivityHistory {
+	history := &types.ActivityHistory{Activities: []*types.ActivityRecord{}}
+	if recorder == nil {
+		return history
+	}
+	if query == nil {
+		query = &types.ActivityHistoryQuery{}
+	}
+	limit := query.Limit
+	if limit <= 0 {
+		limit = defaultQueryLimit
+	} else if limit > maxQueryLimit {
+		limit = maxQueryLimit
+	}
+
+	recorder.lock.Lock()
+	defer recorder.lock.Unlock()
+
+	records := recorder.store.records
+	for i := len(records) - 1; i >= 0 && len(history.Activities) < limit; i-- {
+		record := records[i]
+		if query.ActivityID != "" && record.ActivityID != query.ActivityID {
+			continue
+		}
+		if record.StartTime < query.Since {
+			continue
+		}
+		history.Activities = append(history.Activities, copyRecord(record))
+	}
+	return history
+}
+
+// Close closes the activity history file.
+func (recorder *Recorder) Close() error {
+	if recorder == nil {
+		return nil
+	}
+	recorder.lock.Lock()
+	defer recorder.lock.Unlock()
+
+	return recorder.store.close()
+}
+
+func (recorder *Recorder) update(activityID string, change func(*types.ActivityRecord) bool) {
+	if recorder == nil {
+		return
+	}
+	recorder.lock.Lock()
+	defer recorder.lock.Unlock()
+
+	if recorder.active == nil || recorder.active.ActivityID != activityID {
+		return
+	}
+	if change(recorder.active) {
+		recorder.store.save(recorder.active)
+	}
+}
+
+func copyRecord(record *types.ActivityRecord) *types.ActivityRecord {
+	result := &types.ActivityRecord{}
+	data, err := json.Marshal(record)
+	if err == nil {
+		err = json.Unmarshal(data, result)
+	}
+	if err != nil {
+		logger.ErrorErr(err, "cannot copy activity history record '%s'", record.ActivityID)
+	}
+	return result
+}
+
+func hashDesiredState(desiredState *types.DesiredState) string {
+	data, err := json.Marshal(desiredState)
+	if err != nil {
+		logger.ErrorErr(err, "cannot calculate desired state hash")
+		return ""
+	}
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:])
+}
+
+func now() int64 {
+	return time.Now().UnixNano() / int64(time.Millisecond)
+}
@@ -0,0 +1,156 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package history
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/eclipse-kanto/update-manager/api/types"
+	"github.com/eclipse-kanto/update-manager/test"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordActivity(t *testing.T) {
+	recorder, err := NewRecorder(NewDefaultConfig())
+	assert.NoError(t, err)
+	defer recorder.Close()
+
+	recorder.ActivityStarted(test.ActivityID, test.DesiredState, test.SignerSubject)
+	recorder.StatusChanged(test.ActivityID, types.StatusIdentifying, "")
+	recorder.StatusChanged(test.ActivityID, types.StatusIdentified, "")
+	recorder.StatusChanged(test.ActivityID, types.StatusIdentified, "")
+	recorder.ConsentRequested(test.ActivityID, types.CommandUpdate)
+	recorder.ConsentReceived(test.ActivityID, &types.OwnerConsentFeedback{Status: types.StatusApproved, User: "operator"})
+	recorder.ConsentReceived(test.ActivityID, &types.OwnerConsentFeedback{Status: types.StatusDenied})
+	recorder.DomainStatusChanged(test.ActivityID, "containers", types.StatusCompleted)
+	recorder.DomainStatusChanged("unknown", "containers", types.StatusIncomplete)
+	recorder.StatusChanged(test.ActivityID, types.StatusCompleted, "done")
+	recorder.ActivityFinished(test.ActivityID, false, true)
+	recorder.StatusChanged(test.ActivityID, types.StatusIncomplete, "")
+
+	history := recorder.ActivityHistory(nil)
+	assert.Equal(t, 1, len(history.Activities))
+
+	record := history.Activities[0]
+	assert.Equal(t, test.ActivityID, record.ActivityID)
+	assert.Equal(t, hashDesiredState(test.DesiredState), record.DesiredStateHash)
+	assert.Equal(t, 64, len(record.DesiredStateHash))
+	assert.Equal(t, test.SignerSubject, record.Signer)
+	assert.True(t, record.StartTime > 0)
+	assert.True(t, record.EndTime >= record.StartTime)
+	assert.Equal(t, types.StatusCompleted, record.Status)
+	assert.Equal(t, "done", record.Message)
+	assert.Equal(t, 3, len(record.Phases))
+	assert.Equal(t, types.StatusIdentifying, record.Phases[0].Status)
+	assert.Equal(t, types.StatusCompleted, record.Phases[2].Status)
+	assert.Equal(t, map[string]types.StatusType{"containers": types.StatusCompleted}, record.Domains)
+	assert.Equal(t, 1, len(record.Consents))
+	assert.Equal(t, types.CommandUpdate, record.Consents[0].Command)
+	assert.Equal(t, types.StatusApproved, record.Consents[0].Status)
+	assert.Equal(t, "operator", record.Consents[0].User)
+	assert.True(t, record.Consents[0].DecisionTime >= record.Consents[0].RequestTime)
+	assert.Nil(t, record.Reboot)
+}
+
+func TestRecordRejectedActivity(t *testing.T) {
+	recorder, err := NewRecorder(nil)
+	assert.NoError(t, err)
+
+	recorder.ActivityStarted("active", test.DesiredState, "")
+	recorder.ActivityRejected(test.ActivityID, test.DesiredState, test.SignerSubject, fmt.Errorf("replayed desired state"))
+	recorder.ActivityRejected("unsigned", nil, "", fmt.Errorf("desired state is not signed"))
+	// the rejected activities do not affect the active one
+	recorder.StatusChanged("active", types.StatusRunning, "")
+
+	history := recorder.ActivityHistory(nil)
+	assert.Equal(t, 3, len(history.Activities))
+	record := history.Activities[0]
+	assert.Equal(t, "unsigned", record.ActivityID)
+	assert.Equal(t, "", record.DesiredStateHash)
+	assert.True(t, record.Rejected)
+	record = history.Activities[1]
+	assert.Equal(t, test.ActivityID, record.ActivityID)
+	assert.Equal(t, hashDesiredState(test.DesiredState), record.DesiredStateHash)
+	assert.Equal(t, test.SignerSubject, record.Signer)
+	assert.Equal(t, types.StatusIdentificationFailed, record.Status)
+	assert.Equal(t, "replayed desired state", record.Message)
+	assert.Equal(t, record.StartTime, record.EndTime)
+	assert.Equal(t, []*types.PhaseRecord{{Status: types.StatusIdentificationFailed, Timestamp: record.StartTime}}, record.Phases)
+	assert.True(t, record.Rejected)
+	assert.Equal(t, types.StatusRunning, history.Activities[2].Status)
+	assert.False(t, history.Activities[2].Rejected)
+}
+
+func TestRecordReboot(t *testing.T) {
+	recorder, err := NewRecorder(nil)
+	assert.NoError(t, err)
+
+	recorder.ActivityStarted("reboot-disabled", test.DesiredState, "")
+	recorder.ActivityFinished("reboot-disabled", true, false)
+	recorder.RebootFailed("reboot-disabled", fmt.Errorf("not expected"))
+
+	recorder.ActivityStarted("reboot-failed", test.DesiredState, "")
+	recorder.ActivityFinished("reboot-failed", true, true)
+	recorder.RebootFailed("reboot-failed", fmt.Errorf("reboot error"))
+
+	history := recorder.ActivityHistory(&types.ActivityHistoryQuery{})
+	assert.Equal(t, 2, len(history.Activities))
+	assert.Equal(t, &types.RebootRecord{Required: true, Enabled: true, Timestamp: history.Activities[0].EndTime, Error: "reboot error"}, history.Activities[0].Reboot)
+	assert.Equal(t, &types.RebootRecord{Required: true, Enabled: false}, history.Activities[1].Reboot)
+}
+
+func TestActivityHistoryQuery(t *testing.T) {
+	recorder, err := NewRecorder(nil)
+	assert.NoError(t, err)
+
+	for i := 0; i < maxQueryLimit+10; i++ {
+		recorder.ActivityStarted(fmt.Sprintf("activity-%d", i), test.DesiredState, "")
+		recorder.ActivityFinished(fmt.Sprintf("activity-%d", i), false, false)
+	}
+
+	history := recorder.ActivityHistory(nil)
+	assert.Equal(t, defaultQueryLimit, len(history.Activities))
+	assert.Equal(t, fmt.Sprintf("activity-%d", maxQueryLimit+9), history.Activities[0].ActivityID)
+
+	history = recorder.ActivityHistory(&types.ActivityHistoryQuery{Limit: 1000})
+	assert.Equal(t, maxQueryLimit, len(history.Activities))
+
+	history = recorder.ActivityHistory(&types.ActivityHistoryQuery{ActivityID: "activity-5"})
+	assert.Equal(t, 1, len(history.Activities))
+	assert.Equal(t, "activity-5", history.Activities[0].ActivityID)
+
+	history = recorder.ActivityHistory(&types.ActivityHistoryQuery{Since: now() + 60000})
+	assert.Equal(t, 0, len(history.Activities))
+
+	history = recorder.ActivityHistory(&types.ActivityHistoryQuery{ActivityID: "activity-5"})
+	history.Activities[0].Status = types.StatusIncomplete
+	assert.Equal(t, types.StatusType(""), recorder.ActivityHistory(&types.ActivityHistoryQuery{ActivityID: "activity-5"}).Activities[0].Status)
+}
+
+func TestNilRecorder(t *testing.T) {
+	var recorder *Recorder
+
+	recorder.ActivityStarted(test.ActivityID, test.DesiredState, "")
+	recorder.ActivityRejected(test.ActivityID, test.DesiredState, "", fmt.Errorf("rejected"))
+	recorder.StatusChanged(test.ActivityID, types.StatusRunning, "")
+	recorder.DomainStatusChanged(test.ActivityID, "containers", types.StatusRunning)
+	recorder.ConsentRequested(test.ActivityID, types.CommandUpdate)
+	recorder.ConsentReceived(test.ActivityID, &types.OwnerConsentFeedback{Status: types.StatusApproved})
+	recorder.ActivityFinished(test.ActivityID, true, true)
+	recorder.RebootFailed(test.ActivityID, fmt.Errorf("reboot error"))
+
+	assert.Equal(t, &types.ActivityHistory{Activities: []*types.ActivityRecord{}}, recorder.ActivityHistory(nil))
+	assert.NoError(t, recorder.Close())
+}
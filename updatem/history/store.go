@@ -0,0 +1,136 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/eclipse-kanto/update-manager/api/types"
+	"github.com/eclipse-kanto/update-manager/logger"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+const maxRecords = 1000
+
+// store keeps the activity records in memory and appends each record change as a JSON line to a rotating file, if configured.
+// The latest line for an activity ID supersedes the previous ones.
+type store struct {
+	writer  io.WriteCloser
+	records []*types.ActivityRecord
+	index   map[string]int
+}
+
+func newStore(config *Config) (*store, error) {
+	s := &store{index: map[string]int{}}
+	if config == nil || config.File == "" {
+		return s, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(config.File), 0755); err != nil {
+		return nil, err
+	}
+	for _, file := range historyFiles(config.File) {
+		if err := s.load(file); err != nil {
+			logger.WarnErr(err, "cannot load activity history from file '%s'", file)
+		}
+	}
+	s.writer = &lumberjack.Logger{
+		Filename:   config.File,
+		MaxSize:    config.FileSize,
+		MaxBackups: config.FileCount,
+		MaxAge:     config.FileMaxAge,
+		LocalTime:  true,
+	}
+	return s, nil
+}
+
+// historyFiles returns the rotated backup files from the oldest to the newest, followed by the current file.
+func historyFiles(file string) []string {
+	ext := filepath.Ext(file)
+	prefix := strings.TrimSuffix(file, ext) + "-"
+	backups, _ := filepath.Glob(prefix + "*" + ext)
+	sort.Strings(backups)
+	return append(backups, file)
+}
+
+func (s *store) load(file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		record := &types.ActivityRecord{}
+		if err := json.Unmarshal(scanner.Bytes(), record); err != nil || (record.ActivityID == "" && !record.Rejected) {
+			logger.Warn("skipping invalid activity history entry in file '%s'", file)
+			continue
+		}
+		s.put(record)
+	}
+	return scanner.Err()
+}
+
+func (s *store) put(record *types.ActivityRecord) {
+	// the rejected activities are never updated, so they are not indexed and do not replace an activity with the same ID
+	if i, ok := s.index[record.ActivityID]; ok && !record.Rejected {
+		s.records[i] = record
+		return
+	}
+	if len(s.records) == maxRecords {
+		if i, ok := s.index[s.records[0].ActivityID]; ok && i == 0 {
+			delete(s.index, s.records[0].ActivityID)
+		}
+		s.records = s.records[1:]
+		for id, i := range s.index {
+			s.index[id] = i - 1
+		}
+	}
+	if !record.Rejected {
+		s.index[record.ActivityID] = len(s.records)
+	}
+	s.records = append(s.records, record)
+}
+
+// save updates the record in memory and appends it to the history file.
+func (s *store) save(record *types.ActivityRecord) {
+	s.put(record)
+	if s.writer == nil {
+		return
+	}
+	data, err := json.Marshal(record)
+	if err == nil {
+		_, err = s.writer.Write(append(data, '\n'))
+	}
+	if err != nil {
+		logger.ErrorErr(err, "cannot write activity history for activity '%s'", record.ActivityID)
+	}
+}
+
+func (s *store) close() error {
+	if s.writer == nil {
+		return nil
+	}
+	return s.writer.Close()
+}
@@ -0,0 +1,91 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package history
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/eclipse-kanto/update-manager/api/types"
+	"github.com/eclipse-kanto/update-manager/test"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStorePersistence(t *testing.T) {
+	config := NewDefaultConfig()
+	config.File = filepath.Join(t.TempDir(), "history", "history.log")
+
+	recorder, err := NewRecorder(config)
+	assert.NoError(t, err)
+	recorder.ActivityStarted(test.ActivityID, test.DesiredState, "")
+	recorder.StatusChanged(test.ActivityID, types.StatusRunning, "")
+	recorder.StatusChanged(test.ActivityID, types.StatusCompleted, "")
+	recorder.ActivityFinished(test.ActivityID, false, true)
+	// the rejected replay of the activity does not replace it
+	recorder.ActivityRejected(test.ActivityID, test.DesiredState, "", fmt.Errorf("replayed desired state"))
+	assert.NoError(t, recorder.Close())
+
+	data, err := os.ReadFile(config.File)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, strings.Count(string(data), "\n"))
+
+	recorder, err = NewRecorder(config)
+	assert.NoError(t, err)
+	defer recorder.Close()
+
+	history := recorder.ActivityHistory(nil)
+	assert.Equal(t, 2, len(history.Activities))
+	assert.Equal(t, test.ActivityID, history.Activities[0].ActivityID)
+	assert.True(t, history.Activities[0].Rejected)
+	assert.Equal(t, test.ActivityID, history.Activities[1].ActivityID)
+	assert.Equal(t, types.StatusCompleted, history.Activities[1].Status)
+	assert.Equal(t, 2, len(history.Activities[1].Phases))
+	assert.True(t, history.Activities[1].EndTime > 0)
+}
+
+func TestStoreLoadBackups(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "history.log")
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "history-2024-01-01T00-00-00.000.log"),
+		[]byte(`{"activityId":"a1","status":"RUNNING"}`+"\n"+`{"activityId":"a2","status":"RUNNING"}`+"\n"), 0644))
+	assert.NoError(t, os.WriteFile(file,
+		[]byte(`{"activityId":"a1","status":"COMPLETED"}`+"\n"+"invalid\n"+`{"status":"FAILED"}`+"\n"), 0644))
+
+	s, err := newStore(&Config{File: file})
+	assert.NoError(t, err)
+	defer s.close()
+
+	assert.Equal(t, 2, len(s.records))
+	assert.Equal(t, "a1", s.records[0].ActivityID)
+	assert.Equal(t, types.StatusCompleted, s.records[0].Status)
+	assert.Equal(t, "a2", s.records[1].ActivityID)
+}
+
+func TestStoreMaxRecords(t *testing.T) {
+	s, err := newStore(nil)
+	assert.NoError(t, err)
+
+	for i := 0; i < maxRecords+5; i++ {
+		s.save(&types.ActivityRecord{ActivityID: fmt.Sprintf("activity-%d", i)})
+	}
+	s.save(&types.ActivityRecord{ActivityID: "activity-10", Status: types.StatusCompleted})
+
+	assert.Equal(t, maxRecords, len(s.records))
+	assert.Equal(t, "activity-5", s.records[0].ActivityID)
+	assert.Equal(t, types.StatusCompleted, s.records[s.index["activity-10"]].Status)
+	assert.NoError(t, s.close())
+}
@@ -0,0 +1,102 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package orchestration
+
+import (
+	"time"
+
+	"github.com/eclipse-kanto/update-manager/api"
+	"github.com/eclipse-kanto/update-manager/api/types"
+	"github.com/eclipse-kanto/update-manager/logger"
+)
+
+const maxPhaseTimerTick = time.Second
+
+// phaseTimer expires after the phase timeout has elapsed. If a connection status provider is set,
+// the time is counted only while the connection to the MQTT broker is established.
+type phaseTimer struct {
+	C    <-chan struct{}
+	stop chan struct{}
+}
+
+func newPhaseTimer(timeout time.Duration, connection api.ConnectionStatusProvider) *phaseTimer {
+	expired := make(chan struct{})
+	timer := &phaseTimer{
+		C:    expired,
+		stop: make(chan struct{}),
+	}
+	if connection == nil {
+		go timer.run(timeout, expired)
+	} else {
+		go timer.runPausable(timeout, connection, expired)
+	}
+	return timer
+}
+
+// Stop releases the resources of the timer.
+func (timer *phaseTimer) Stop() {
+	close(timer.stop)
+}
+
+func (timer *phaseTimer) run(timeout time.Duration, expired chan struct{}) {
+	t := time.NewTimer(timeout)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		close(expired)
+	case <-timer.stop:
+	}
+}
+
+func (timer *phaseTimer) runPausable(timeout time.Duration, connection api.ConnectionStatusProvider, expired chan struct{}) {
+	tick := maxPhaseTimerTick
+	if timeout < tick {
+		tick = timeout
+	}
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+
+	var elapsed time.Duration
+	paused := false
+	last := time.Now()
+	for {
+		select {
+		case now := <-ticker.C:
+			connected := isConnected(connection)
+			if connected && !paused {
+				elapsed += now.Sub(last)
+			}
+			if connected == paused {
+				paused = !connected
+				if paused {
+					logger.Info("connection to MQTT broker is not established, phase timeout paused after %v", elapsed)
+				} else {
+					logger.Info("connection to MQTT broker is established, phase timeout resumed")
+				}
+			}
+			last = now
+			if elapsed >= timeout {
+				close(expired)
+				return
+			}
+		case <-timer.stop:
+			return
+		}
+	}
+}
+
+func isConnected(connection api.ConnectionStatusProvider) bool {
+	status := connection.ConnectionStatus()
+	return status == nil || status.State == types.ConnectionStateConnected
+}
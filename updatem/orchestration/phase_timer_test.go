@@ -0,0 +1,71 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package orchestration
+
+import (
+	"testing"
+	"time"
+
+	"github.com/eclipse-kanto/update-manager/api/types"
+	"github.com/eclipse-kanto/update-manager/test/mocks"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPhaseTimer(t *testing.T) {
+	timer := newPhaseTimer(50*time.Millisecond, nil)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+	case <-time.After(time.Second):
+		t.Fatal("phase timer not expired")
+	}
+}
+
+func TestPhaseTimerStop(t *testing.T) {
+	timer := newPhaseTimer(50*time.Millisecond, nil)
+	timer.Stop()
+
+	select {
+	case <-timer.C:
+		t.Fatal("stopped phase timer expired")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestPhaseTimerPaused(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	connected := &types.ConnectionStatus{State: types.ConnectionStateConnected}
+	reconnecting := &types.ConnectionStatus{State: types.ConnectionStateReconnecting}
+
+	mockProvider := mocks.NewMockConnectionStatusProvider(mockCtrl)
+	gomock.InOrder(
+		mockProvider.EXPECT().ConnectionStatus().Return(reconnecting).Times(5),
+		mockProvider.EXPECT().ConnectionStatus().Return(connected).AnyTimes(),
+	)
+
+	timer := newPhaseTimer(50*time.Millisecond, mockProvider)
+	defer timer.Stop()
+
+	start := time.Now()
+	select {
+	case <-timer.C:
+		assert.True(t, time.Since(start) >= 250*time.Millisecond)
+	case <-time.After(2 * time.Second):
+		t.Fatal("phase timer not expired")
+	}
+}
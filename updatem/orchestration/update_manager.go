@@ -15,6 +15,7 @@ package orchestration
 import (
 	"context"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/eclipse-kanto/update-manager/api"
@@ -23,7 +24,12 @@ import (
 	"github.com/eclipse-kanto/update-manager/config"
 	"github.com/eclipse-kanto/update-manager/logger"
 	"github.com/eclipse-kanto/update-manager/mqtt"
+	"github.com/eclipse-kanto/update-manager/updatem/cache"
 	"github.com/eclipse-kanto/update-manager/updatem/domain"
+	"github.com/eclipse-kanto/update-manager/updatem/history"
+	"github.com/eclipse-kanto/update-manager/updatem/replay"
+	"github.com/eclipse-kanto/update-manager/util/host"
+	"github.com/eclipse-kanto/update-manager/util/secret"
 )
 
 type aggregatedUpdateManager struct {
@@ -36,26 +42,77 @@ type aggregatedUpdateManager struct {
 
 	inProgress         bool
 	activityInProgress string
+	// activeActivityID holds the ID of the update activity in progress, so that it can be reported without the apply lock
+	activeActivityID atomic.Value
+	// startTime is the time the update manager is created, used to report its uptime
+	startTime time.Time
+	// hostInfo collects the details about the host reported as hardware nodes in the inventory, no host nodes are reported if not set
+	hostInfo func() *host.Info
 
 	updateOrchestrator api.UpdateOrchestrator
 	domainsInventory   map[string]*types.Inventory
 
 	rebootManager RebootManager
-	domainAgents  map[string]api.UpdateManager
 	eventCallback api.UpdateManagerCallback
+
+	agentsLock   sync.Mutex
+	domainAgents map[string]api.UpdateManager
+	// watchCtx is the context the domain agents watch events with, the added domain agents are started with it
+	watchCtx context.Context
+	// newDomainAgent creates the domain agents added on configuration reload
+	newDomainAgent func(name string, agentConfig *api.UpdateManagerConfig) (api.UpdateManager, error)
+	// pendingCfg is the reloaded configuration to be applied after the update activity in progress is finished
+	pendingCfg *config.Config
+
+	history     *history.Recorder
+	replayGuard *replay.Guard
+	// artifactCache is the artifact cache shared between the domain agents, nil if not enabled
+	artifactCache *cache.Cache
+	// connection provides the health status of the connection to the MQTT broker reported in the inventory, nil if not supported
+	connection api.ConnectionStatusProvider
 }
 
 // NewUpdateManager instantiates a new Kanto update manager
 func NewUpdateManager(version string, cfg *config.Config, updateAgentClient api.UpdateAgentClient, updateOrchestrator api.UpdateOrchestrator) (api.UpdateManager, error) {
+	secretResolver, err := secret.NewResolver(cfg.Secrets)
+	if err != nil {
+		return nil, err
+	}
+	secretKeys, err := secret.NewKeys(cfg.Secrets)
+	if err != nil {
+		return nil, err
+	}
+	newDomainAgent := func(name string, agentConfig *api.UpdateManagerConfig) (api.UpdateManager, error) {
+		agentConfig.Name = name
+		desiredStateClient, err := mqtt.NewDesiredStateClient(name, updateAgentClient, mqtt.WithTopics(agentConfig.Topics))
+		if err != nil {
+			return nil, err
+		}
+		return domain.NewUpdateManager(desiredStateClient, agentConfig, secretResolver, secretKeys), nil
+	}
 	domainAgents := make(map[string]api.UpdateManager)
-
 	for domainName, agentConfig := range cfg.Agents {
-		agentConfig.Name = domainName
-		desiredStateClient, err := mqtt.NewDesiredStateClient(domainName, updateAgentClient)
+		domainAgent, err := newDomainAgent(domainName, agentConfig)
 		if err != nil {
 			return nil, err
 		}
-		domainAgents[domainName] = domain.NewUpdateManager(desiredStateClient, agentConfig)
+		domainAgents[domainName] = domainAgent
+	}
+	recorder, err := history.NewRecorder(cfg.History)
+	if err != nil {
+		return nil, err
+	}
+	setActivityHistory(updateOrchestrator, recorder)
+	if cfg.PhaseTimeoutPause {
+		setConnectionStatus(updateOrchestrator, updateAgentClient)
+	}
+	replayGuard, err := replay.NewGuard(cfg.Replay)
+	if err != nil {
+		return nil, err
+	}
+	artifactCache, err := cache.New(cfg.ArtifactCache)
+	if err != nil {
+		return nil, err
 	}
 	updateManager := &aggregatedUpdateManager{
 		name:               cfg.Domain,
@@ -65,6 +122,15 @@ func NewUpdateManager(version string, cfg *config.Config, updateAgentClient api.
 		updateOrchestrator: updateOrchestrator,
 		rebootManager:      &rebootManager{},
 		domainAgents:       domainAgents,
+		newDomainAgent:     newDomainAgent,
+		history:            recorder,
+		replayGuard:        replayGuard,
+		artifactCache:      artifactCache,
+		startTime:          time.Now(),
+		hostInfo:           host.Collect,
+	}
+	if provider, ok := updateAgentClient.(api.ConnectionStatusProvider); ok {
+		updateManager.connection = provider
 	}
 	for _, domainAgent := range domainAgents {
 		domainAgent.SetCallback(updateManager)
@@ -73,6 +139,27 @@ func NewUpdateManager(version string, cfg *config.Config, updateAgentClient api.
 	return updateManager, nil
 }
 
+// setActivityHistory sets the activity history recorder to the given update orchestrator, if it supports recording the activity history.
+func setActivityHistory(orchestrator api.UpdateOrchestrator, recorder *history.Recorder) {
+	if uo, ok := orchestrator.(*updateOrchestrator); ok {
+		uo.history = recorder
+	}
+}
+
+// setConnectionStatus makes the given update orchestrator pause its phase timeout while the connection of the given update agent client
+// is not established, if both support it.
+func setConnectionStatus(orchestrator api.UpdateOrchestrator, client api.UpdateAgentClient) {
+	uo, ok := orchestrator.(*updateOrchestrator)
+	if !ok {
+		return
+	}
+	if provider, ok := client.(api.ConnectionStatusProvider); ok {
+		uo.connection = provider
+	} else {
+		logger.Warn("connection status is not supported by the update agent client, phase timeout is not paused while disconnected")
+	}
+}
+
 func (updateManager *aggregatedUpdateManager) Name() string {
 	return updateManager.name
 }
@@ -83,24 +170,47 @@ func (updateManager *aggregatedUpdateManager) Apply(ctx context.Context, activit
 	}
 	defer updateManager.markApplyCompleted()
 
-	logger.Debug("processing desired state specification - start")
-	rebootRequired := updateManager.updateOrchestrator.Apply(ctx, updateManager.domainAgents, activityID, desiredState, updateManager.eventCallback)
-	logger.Debug("processing desired state specification - done")
+	if updateManager.namespaceNodeIDs() {
+		restoreNodeIDs(desiredState)
+	}
+	log := logger.WithFields(logger.Fields{ActivityID: activityID})
+	err := updateManager.resolveVersions(desiredState)
+	if err == nil {
+		err = updateManager.checkReplay(ctx, activityID, desiredState)
+	}
+	if err != nil {
+		log.ErrorErr(err, "Rejected desired state for update activity %s", activityID)
+		updateManager.history.ActivityRejected(activityID, desiredState, api.DesiredStateSigner(ctx), err)
+		if desiredStateCallback := updateManager.eventCallback; desiredStateCallback != nil {
+			desiredStateCallback.HandleDesiredStateFeedbackEvent(updateManager.Name(), activityID, "", types.StatusIdentificationFailed, err.Error(), nil)
+		}
+		return
+	}
+	signer := api.DesiredStateSigner(ctx)
+	if signer != "" {
+		log.Info("desired state for update activity %s is signed by '%s'", activityID, signer)
+	}
+	updateManager.history.ActivityStarted(activityID, desiredState, signer)
+	log.Debug("processing desired state specification - start")
+	rebootRequired := updateManager.updateOrchestrator.Apply(ctx, updateManager.getDomainAgents(), activityID, desiredState, updateManager.eventCallback)
+	log.Debug("processing desired state specification - done")
 
 	if inventory, err := updateManager.Get(ctx, activityID); err == nil {
 		updateManager.eventCallback.HandleCurrentStateEvent(updateManager.Name(), activityID, inventory)
 	} else {
-		logger.Error(err.Error())
+		log.Error(err.Error())
 	}
 
+	updateManager.history.ActivityFinished(activityID, rebootRequired, updateManager.cfg.RebootEnabled)
 	if rebootRequired {
 		if updateManager.cfg.RebootEnabled {
 			timeout := util.ParseDuration("reboot-after", updateManager.cfg.RebootAfter, 30*time.Second, 30*time.Second)
 			if err := updateManager.rebootManager.Reboot(timeout); err != nil {
-				logger.Error(err.Error())
+				log.Error(err.Error())
+				updateManager.history.RebootFailed(activityID, err)
 			}
 		} else {
-			logger.Warn("reboot required but automatic rebooting is disabled")
+			log.Warn("reboot required but automatic rebooting is disabled")
 		}
 	}
 }
@@ -120,9 +230,37 @@ func (updateManager *aggregatedUpdateManager) checkIfInProgress(activityID strin
 	logger.Info("Starting update activity %s ...", activityID)
 	updateManager.inProgress = true
 	updateManager.activityInProgress = activityID
+	updateManager.activeActivityID.Store(activityID)
 	return false
 }
 
+// checkReplay rejects replayed desired states and, if the anti-rollback is enabled, desired states which would downgrade an installed component.
+// The desired state is recorded by the replay guard only if it has passed the anti-rollback check as well.
+func (updateManager *aggregatedUpdateManager) checkReplay(ctx context.Context, activityID string, desiredState *types.DesiredState) error {
+	timestamp, hasTimestamp := api.DesiredStateTimestamp(ctx)
+	if hasTimestamp {
+		if err := updateManager.replayGuard.Check(activityID, timestamp); err != nil {
+			return err
+		}
+	}
+	if err := updateManager.checkRollback(desiredState); err != nil {
+		return err
+	}
+	if hasTimestamp {
+		return updateManager.replayGuard.Record(activityID, timestamp)
+	}
+	return nil
+}
+
+func (updateManager *aggregatedUpdateManager) checkRollback(desiredState *types.DesiredState) error {
+	if updateManager.cfg.Replay == nil || !updateManager.cfg.Replay.AntiRollback {
+		return nil
+	}
+	updateManager.eventLock.Lock()
+	defer updateManager.eventLock.Unlock()
+	return replay.CheckRollback(desiredState, updateManager.domainsInventory)
+}
+
 func (updateManager *aggregatedUpdateManager) markApplyCompleted() {
 	updateManager.applyLock.Lock()
 	defer updateManager.applyLock.Unlock()
@@ -130,6 +268,11 @@ func (updateManager *aggregatedUpdateManager) markApplyCompleted() {
 	logger.Info("Finished update activity %s", updateManager.activityInProgress)
 	updateManager.inProgress = false
 	updateManager.activityInProgress = ""
+	updateManager.activeActivityID.Store("")
+	if pendingCfg := updateManager.pendingCfg; pendingCfg != nil {
+		updateManager.pendingCfg = nil
+		updateManager.applyConfig(pendingCfg)
+	}
 }
 
 func (updateManager *aggregatedUpdateManager) Command(ctx context.Context, activityID string, command *types.DesiredStateCommand) {
@@ -143,29 +286,35 @@ func (updateManager *aggregatedUpdateManager) Get(ctx context.Context, activityI
 	for key, value := range updateManager.domainsInventory {
 		domainsInventory[key] = value
 	}
-	for _, agent := range updateManager.domainAgents {
+	for _, agent := range updateManager.getDomainAgents() {
 		wg.Add(1)
 		go updateInventoryForDomain(ctx, wg, activityID, agent, domainsInventory)
 	}
 	wg.Wait()
 	logger.Debug("got current state from update agents.")
-	return toFullInventory(updateManager.asSoftwareNode(), domainsInventory), nil
+	return updateManager.fullInventory(domainsInventory), nil
 }
 
 func (updateManager *aggregatedUpdateManager) Dispose() error {
 	logger.Debug("disposing update agents...")
-	for _, agent := range updateManager.domainAgents {
+	for _, agent := range updateManager.getDomainAgents() {
 		if err := agent.Dispose(); err != nil {
 			logger.ErrorErr(err, "error disposing update agent %s", agent.Name())
 		}
 	}
 	logger.Debug("disposed update agents.")
+	if err := updateManager.history.Close(); err != nil {
+		logger.ErrorErr(err, "error closing the activity history")
+	}
 	return nil
 }
 
 func (updateManager *aggregatedUpdateManager) WatchEvents(ctx context.Context) {
 	logger.Debug("starting watching events from update agents...")
-	for _, agent := range updateManager.domainAgents {
+	updateManager.agentsLock.Lock()
+	updateManager.watchCtx = ctx
+	updateManager.agentsLock.Unlock()
+	for _, agent := range updateManager.getDomainAgents() {
 		agent.WatchEvents(ctx)
 	}
 	logger.Debug("started watching events from update agents.")
@@ -174,3 +323,44 @@ func (updateManager *aggregatedUpdateManager) WatchEvents(ctx context.Context) {
 func (updateManager *aggregatedUpdateManager) SetCallback(callback api.UpdateManagerCallback) {
 	updateManager.eventCallback = callback
 }
+
+// ActivityStatus returns the status of the update activity in progress, if supported by the update orchestrator.
+func (updateManager *aggregatedUpdateManager) ActivityStatus() *types.ActivityStatus {
+	if provider, ok := updateManager.updateOrchestrator.(api.ActivityStatusProvider); ok {
+		return provider.ActivityStatus()
+	}
+	return nil
+}
+
+// HandleRejectedDesiredState records the update activity, which desired state is rejected before it is applied, in the activity history.
+func (updateManager *aggregatedUpdateManager) HandleRejectedDesiredState(activityID string, signer string, reason error) {
+	updateManager.history.ActivityRejected(activityID, nil, signer, reason)
+}
+
+// ActivityHistory returns the recorded update activities matching the given query.
+func (updateManager *aggregatedUpdateManager) ActivityHistory(query *types.ActivityHistoryQuery) *types.ActivityHistory {
+	return updateManager.history.ActivityHistory(query)
+}
+
+// ArtifactCache returns the artifact cache shared between the domain agents, or nil if the artifact cache is not enabled.
+func (updateManager *aggregatedUpdateManager) ArtifactCache() api.ArtifactCache {
+	if updateManager.artifactCache == nil {
+		return nil
+	}
+	return updateManager.artifactCache
+}
+
+// Compliance compares the given desired state with the last reported current state of the domains, without applying it.
+// The secret configuration values are removed from a copy of the given desired state and, if the node IDs are namespaced,
+// the namespace is removed from its component IDs, as on apply.
+func (updateManager *aggregatedUpdateManager) Compliance(desiredState *types.DesiredState) *types.ComplianceReport {
+	desiredState = copyComponents(desiredState)
+	redactSecretConfig(desiredState)
+	if updateManager.namespaceNodeIDs() {
+		restoreNodeIDs(desiredState)
+	}
+	updateManager.eventLock.Lock()
+	defer updateManager.eventLock.Unlock()
+
+	return checkCompliance(desiredState, updateManager.fullInventory(updateManager.domainsInventory))
+}
@@ -21,6 +21,7 @@ func (updateManager *aggregatedUpdateManager) HandleDesiredStateFeedbackEvent(do
 	updateManager.eventLock.Lock()
 	defer updateManager.eventLock.Unlock()
 
+	updateManager.history.DomainStatusChanged(activityID, domain, status)
 	updateManager.updateOrchestrator.HandleDesiredStateFeedbackEvent(domain, activityID, baseline, status, message, actions)
 }
 
@@ -31,7 +32,7 @@ func (updateManager *aggregatedUpdateManager) HandleCurrentStateEvent(name strin
 	logger.Debug("received current state for domain [%s] and activityID [%s]", name, activityID)
 	updateManager.domainsInventory[name] = currentState
 	if activityID == "" {
-		inventory := toFullInventory(updateManager.asSoftwareNode(), updateManager.domainsInventory)
+		inventory := updateManager.fullInventory(updateManager.domainsInventory)
 		updateManager.eventCallback.HandleCurrentStateEvent(updateManager.Name(), activityID, inventory)
 	}
 }
@@ -46,15 +46,14 @@ func TestHandleDesiredStateFeedbackEvent(t *testing.T) {
 
 var expectedInventory = &types.Inventory{
 	SoftwareNodes: []*types.SoftwareNode{
-		test.MainInventoryNode,
+		describedInventoryNode("domains", "testDomain1", "onlineDomains", "testDomainInventory1,testName"),
 		test.CreateSoftwareNode("testDomain", 1, "", "", types.SoftwareTypeApplication),
 		test.CreateSoftwareNode("testDomain", 2, "", "", types.SoftwareTypeApplication),
 	},
 	Associations: []*types.Association{
-		test.CreateAssociation("device-update-manager", "testDomain-test-1"),
-		test.CreateAssociation("device-update-manager", "testDomain-test-1"),
-		test.CreateAssociation("device-update-manager", "testDomain-test-1"),
-		test.CreateAssociation("device-update-manager", "testDomain-test-2"),
+		test.CreateAssociation("device-update-manager", "testDomain-test:1"),
+		test.CreateAssociation("device-update-manager", "testDomain-test:2"),
+		test.CreateAssociation("testDomain-test:1", "testId"),
 	},
 	HardwareNodes: test.SampleTestHardwareNode,
 }
@@ -65,8 +64,7 @@ var givenInventory = &types.Inventory{
 	},
 	HardwareNodes: test.SampleTestHardwareNode,
 	Associations: []*types.Association{
-		test.CreateAssociation("device-update-manager", "testDomain-test-1"),
-		test.CreateAssociation("device-update-manager", "testDomain-test-2"),
+		test.CreateAssociation("testDomain-test:1", "testId"),
 	},
 }
 
@@ -0,0 +1,149 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package orchestration
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/eclipse-kanto/update-manager/api/types"
+	"github.com/eclipse-kanto/update-manager/util/secret"
+	"github.com/eclipse-kanto/update-manager/util/version"
+)
+
+// checkCompliance compares the given desired state with the given current state, without applying anything.
+// The installed components are looked up in the current state by ID in the format <domain>:<component>, the software nodes
+// with IDs in this format, which are not part of the desired state, are reported as extra components of the respective domain.
+// Only the configuration keys of the desired components are compared, the secret ones are expected to be removed by redactSecretConfig.
+// A desired version is matched according to the version scheme of the component and a desired version constraint is matched
+// if the installed version satisfies it.
+func checkCompliance(desiredState *types.DesiredState, currentState *types.Inventory) *types.ComplianceReport {
+	report := &types.ComplianceReport{Compliant: true}
+	if desiredState == nil {
+		return report
+	}
+	installed := map[string]*types.SoftwareNode{}
+	if currentState != nil {
+		for _, node := range currentState.SoftwareNodes {
+			if node != nil {
+				installed[componentNodeID(node)] = node
+			}
+		}
+	}
+	desiredIDs := map[string]bool{}
+	desiredDomains := map[string]bool{}
+	for _, domain := range desiredState.Domains {
+		if domain == nil {
+			continue
+		}
+		desiredDomains[domain.ID] = true
+		for _, component := range domain.Components {
+			if component == nil {
+				continue
+			}
+			id := domain.ID + ":" + component.ID
+			desiredIDs[id] = true
+			addCompliance(report, checkComponent(domain, component, installed[id]))
+		}
+	}
+	var extraIDs []string
+	for id := range installed {
+		domain, _, ok := strings.Cut(id, ":")
+		if ok && !desiredIDs[id] && desiredDomains[domain] {
+			extraIDs = append(extraIDs, id)
+		}
+	}
+	sort.Strings(extraIDs)
+	for _, id := range extraIDs {
+		domain, componentID, _ := strings.Cut(id, ":")
+		addCompliance(report, &types.ComponentCompliance{Domain: domain, ID: componentID, Status: types.ComplianceExtra, CurrentVersion: installed[id].Version})
+	}
+	return report
+}
+
+func checkComponent(domain *types.Domain, component *types.ComponentWithConfig, node *types.SoftwareNode) *types.ComponentCompliance {
+	result := &types.ComponentCompliance{Domain: domain.ID, ID: component.ID, DesiredVersion: component.Version}
+	if node == nil {
+		result.Status = types.ComplianceMissing
+		return result
+	}
+	result.CurrentVersion = node.Version
+	result.Status = types.ComplianceInstalled
+	if component.Version != "" && !versionMatches(domain, component, node.Version) {
+		result.Status = types.ComplianceVersionMismatch
+	}
+	for _, pair := range component.Config {
+		if pair == nil || pair.Key == types.VersionSchemeKey || pair.Key == types.AvailableVersionsKey {
+			continue
+		}
+		if currentValue, ok := parameterValue(node.Parameters, pair.Key); !ok || currentValue != pair.Value {
+			result.ConfigDifferences = append(result.ConfigDifferences, &types.ConfigDifference{Key: pair.Key, DesiredValue: pair.Value, CurrentValue: currentValue})
+		}
+	}
+	return result
+}
+
+// redactSecretConfig removes the component configuration keys with secret values from the given desired state,
+// as their current values are redacted in the current state reported by the domains.
+func redactSecretConfig(desiredState *types.DesiredState) {
+	if desiredState == nil {
+		return
+	}
+	for _, domain := range desiredState.Domains {
+		if domain == nil {
+			continue
+		}
+		for _, component := range domain.Components {
+			if component == nil {
+				continue
+			}
+			var config []*types.KeyValuePair
+			for _, pair := range component.Config {
+				if pair == nil || !secret.IsSecret(pair.Value) {
+					config = append(config, pair)
+				}
+			}
+			component.Config = config
+		}
+	}
+}
+
+// versionMatches returns true if the given installed version matches the desired version of the component according to its version scheme.
+func versionMatches(domain *types.Domain, component *types.ComponentWithConfig, installedVersion string) bool {
+	scheme, _ := version.ParseScheme(domain.VersionScheme(component))
+	return version.Matches(scheme, component.Version, installedVersion)
+}
+
+func addCompliance(report *types.ComplianceReport, component *types.ComponentCompliance) {
+	if component.Status != types.ComplianceInstalled || len(component.ConfigDifferences) > 0 {
+		report.Compliant = false
+	}
+	report.Components = append(report.Components, component)
+}
+
+// componentNodeID returns the original ID of the given node, if its ID is namespaced, otherwise its ID.
+func componentNodeID(node *types.SoftwareNode) string {
+	if originalID, ok := parameterValue(node.Parameters, types.OriginalIDParameter); ok && originalID != "" {
+		return originalID
+	}
+	return node.ID
+}
+
+func parameterValue(parameters []*types.KeyValuePair, key string) (string, bool) {
+	for _, parameter := range parameters {
+		if parameter != nil && parameter.Key == key {
+			return parameter.Value, true
+		}
+	}
+	return "", false
+}
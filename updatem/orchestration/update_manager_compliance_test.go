@@ -0,0 +1,123 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package orchestration
+
+import (
+	"testing"
+
+	"github.com/eclipse-kanto/update-manager/api/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckCompliance(t *testing.T) {
+	desiredState := &types.DesiredState{
+		Domains: []*types.Domain{
+			{
+				ID: "containers",
+				Components: []*types.ComponentWithConfig{
+					{Component: types.Component{ID: "app", Version: "1.0.0"}, Config: []*types.KeyValuePair{{Key: "port", Value: "8080"}}},
+					{Component: types.Component{ID: "db", Version: "2.0.0"}},
+					{Component: types.Component{ID: "proxy", Version: "3.0.0"}, Config: []*types.KeyValuePair{{Key: "mode", Value: "tls"}, {Key: "host", Value: "local"}}},
+					{Component: types.Component{ID: "cache", Version: "4.0.0"}},
+				},
+			},
+			{
+				ID:         "self-update",
+				Components: []*types.ComponentWithConfig{{Component: types.Component{ID: "os", Version: "5.0.0"}}},
+			},
+		},
+	}
+	softwareNode := func(id, version string, parameters ...*types.KeyValuePair) *types.SoftwareNode {
+		return &types.SoftwareNode{InventoryNode: types.InventoryNode{ID: id, Version: version, Parameters: parameters}}
+	}
+
+	t.Run("test_not_compliant", func(t *testing.T) {
+		currentState := &types.Inventory{
+			SoftwareNodes: []*types.SoftwareNode{
+				softwareNode("device-update-manager", "1.0.0"),
+				softwareNode("containers:app", "1.0.0", &types.KeyValuePair{Key: "port", Value: "8080"}, &types.KeyValuePair{Key: "other", Value: "x"}),
+				softwareNode("containers:db", "1.9.0"),
+				softwareNode("containers:proxy", "3.0.0", &types.KeyValuePair{Key: "mode", Value: "plain"}),
+				softwareNode("containers:old", "0.1.0"),
+				softwareNode("self-update/self-update:os", "5.0.0", &types.KeyValuePair{Key: types.OriginalIDParameter, Value: "self-update:os"}),
+				softwareNode("safety:ecu", "6.0.0"),
+			},
+		}
+		assert.Equal(t, &types.ComplianceReport{
+			Compliant: false,
+			Components: []*types.ComponentCompliance{
+				{Domain: "containers", ID: "app", Status: types.ComplianceInstalled, DesiredVersion: "1.0.0", CurrentVersion: "1.0.0"},
+				{Domain: "containers", ID: "db", Status: types.ComplianceVersionMismatch, DesiredVersion: "2.0.0", CurrentVersion: "1.9.0"},
+				{Domain: "containers", ID: "proxy", Status: types.ComplianceInstalled, DesiredVersion: "3.0.0", CurrentVersion: "3.0.0",
+					ConfigDifferences: []*types.ConfigDifference{{Key: "mode", DesiredValue: "tls", CurrentValue: "plain"}, {Key: "host", DesiredValue: "local"}}},
+				{Domain: "containers", ID: "cache", Status: types.ComplianceMissing, DesiredVersion: "4.0.0"},
+				{Domain: "self-update", ID: "os", Status: types.ComplianceInstalled, DesiredVersion: "5.0.0", CurrentVersion: "5.0.0"},
+				{Domain: "containers", ID: "old", Status: types.ComplianceExtra, CurrentVersion: "0.1.0"},
+			},
+		}, checkCompliance(desiredState, currentState))
+	})
+	t.Run("test_compliant", func(t *testing.T) {
+		currentState := &types.Inventory{
+			SoftwareNodes: []*types.SoftwareNode{
+				softwareNode("containers:app", "1.0.0", &types.KeyValuePair{Key: "port", Value: "8080"}),
+				softwareNode("containers:db", "2.0.0"),
+				softwareNode("containers:proxy", "3.0.0", &types.KeyValuePair{Key: "mode", Value: "tls"}, &types.KeyValuePair{Key: "host", Value: "local"}),
+				softwareNode("containers:cache", "4.0.0"),
+				softwareNode("self-update:os", "5.0.0"),
+			},
+		}
+		report := checkCompliance(desiredState, currentState)
+		assert.True(t, report.Compliant)
+		assert.Len(t, report.Components, 5)
+	})
+	t.Run("test_version_scheme_and_constraint", func(t *testing.T) {
+		desiredState := &types.DesiredState{
+			Domains: []*types.Domain{{
+				ID:     "containers",
+				Config: []*types.KeyValuePair{{Key: types.VersionSchemeKey, Value: "deb"}},
+				Components: []*types.ComponentWithConfig{
+					{Component: types.Component{ID: "app", Version: ">=1.2 <2"}, Config: []*types.KeyValuePair{{Key: types.AvailableVersionsKey, Value: "1.2,1.5"}, {Key: "password", Value: "secret:app-password"}}},
+					{Component: types.Component{ID: "db", Version: "1.0~rc1"}},
+					{Component: types.Component{ID: "proxy", Version: "v3.0"}, Config: []*types.KeyValuePair{{Key: types.VersionSchemeKey, Value: "semver"}}},
+				},
+			}},
+		}
+		currentState := &types.Inventory{
+			SoftwareNodes: []*types.SoftwareNode{
+				softwareNode("containers:app", "1.5"),
+				softwareNode("containers:db", "1.0"),
+				softwareNode("containers:proxy", "3.0.0"),
+			},
+		}
+		redactSecretConfig(desiredState)
+		assert.Len(t, desiredState.Domains[0].Components[0].Config, 1)
+		assert.Equal(t, &types.ComplianceReport{
+			Compliant: false,
+			Components: []*types.ComponentCompliance{
+				{Domain: "containers", ID: "app", Status: types.ComplianceInstalled, DesiredVersion: ">=1.2 <2", CurrentVersion: "1.5"},
+				{Domain: "containers", ID: "db", Status: types.ComplianceVersionMismatch, DesiredVersion: "1.0~rc1", CurrentVersion: "1.0"},
+				{Domain: "containers", ID: "proxy", Status: types.ComplianceInstalled, DesiredVersion: "v3.0", CurrentVersion: "3.0.0"},
+			},
+		}, checkCompliance(desiredState, currentState))
+	})
+	t.Run("test_no_current_state", func(t *testing.T) {
+		report := checkCompliance(desiredState, nil)
+		assert.False(t, report.Compliant)
+		for _, component := range report.Components {
+			assert.Equal(t, types.ComplianceMissing, component.Status)
+		}
+	})
+	t.Run("test_no_desired_state", func(t *testing.T) {
+		assert.Equal(t, &types.ComplianceReport{Compliant: true}, checkCompliance(nil, &types.Inventory{}))
+	})
+}
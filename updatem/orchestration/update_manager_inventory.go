@@ -14,15 +14,22 @@ package orchestration
 
 import (
 	"context"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/eclipse-kanto/update-manager/api"
 	"github.com/eclipse-kanto/update-manager/api/types"
 	"github.com/eclipse-kanto/update-manager/logger"
+	"github.com/eclipse-kanto/update-manager/util/host"
 )
 
 const (
 	updateManagerName = "Update Manager"
+
+	nodeIDSeparator = "/"
 )
 
 func (updateManager *aggregatedUpdateManager) asSoftwareNode() *types.SoftwareNode {
@@ -56,33 +63,314 @@ func updateInventoryForDomain(ctx context.Context, wg *sync.WaitGroup, activityI
 	}
 }
 
+// fullInventory aggregates the given domain inventories, namespacing the node IDs of each domain if configured.
+func (updateManager *aggregatedUpdateManager) fullInventory(domainsInventory map[string]*types.Inventory) *types.Inventory {
+	if updateManager.namespaceNodeIDs() {
+		namespacedInventory := make(map[string]*types.Inventory, len(domainsInventory))
+		for domain, domainInventory := range domainsInventory {
+			namespacedInventory[domain] = namespaceInventory(domain, domainInventory)
+		}
+		domainsInventory = namespacedInventory
+	}
+	updateManagerNode := updateManager.asSoftwareNode()
+	updateManagerNode.Parameters = updateManager.describe(domainsInventory)
+	inventory := toFullInventory(updateManagerNode, domainsInventory)
+	if updateManager.hostInfo != nil {
+		addHostNodes(inventory, updateManagerNode.ID, updateManager.Name(), updateManager.hostInfo())
+	}
+	return inventory
+}
+
+// describe returns the parameters of the update manager software node: the configured domains, the domains which have reported
+// their current state, the owner consent commands, the reboot policy, the update activity in progress, the uptime and the artifact cache usage.
+func (updateManager *aggregatedUpdateManager) describe(domainsInventory map[string]*types.Inventory) []*types.KeyValuePair {
+	var domains, onlineDomains []string
+	for domain := range updateManager.getDomainAgents() {
+		domains = append(domains, domain)
+	}
+	for domain, domainInventory := range domainsInventory {
+		if domainInventory != nil {
+			onlineDomains = append(onlineDomains, domain)
+		}
+	}
+	sort.Strings(domains)
+	sort.Strings(onlineDomains)
+	parameters := []*types.KeyValuePair{
+		{Key: "domains", Value: strings.Join(domains, ",")},
+		{Key: "onlineDomains", Value: strings.Join(onlineDomains, ",")},
+	}
+	if cfg := updateManager.cfg; cfg != nil {
+		commands := make([]string, len(cfg.OwnerConsentCommands))
+		for i, command := range cfg.OwnerConsentCommands {
+			commands[i] = string(command)
+		}
+		parameters = append(parameters,
+			&types.KeyValuePair{Key: "ownerConsentCommands", Value: strings.Join(commands, ",")},
+			&types.KeyValuePair{Key: "rebootEnabled", Value: strconv.FormatBool(cfg.RebootEnabled)})
+		if cfg.RebootEnabled && cfg.RebootAfter != "" {
+			parameters = append(parameters, &types.KeyValuePair{Key: "rebootAfter", Value: cfg.RebootAfter})
+		}
+	}
+	if activityID, _ := updateManager.activeActivityID.Load().(string); activityID != "" {
+		parameters = append(parameters, &types.KeyValuePair{Key: "activityId", Value: activityID})
+	}
+	if !updateManager.startTime.IsZero() {
+		parameters = append(parameters, &types.KeyValuePair{Key: "uptime", Value: time.Since(updateManager.startTime).Round(time.Second).String()})
+	}
+	if updateManager.artifactCache != nil {
+		if usage, err := updateManager.artifactCache.Usage(); err == nil {
+			parameters = append(parameters,
+				&types.KeyValuePair{Key: "artifactCacheArtifacts", Value: strconv.Itoa(usage.Artifacts)},
+				&types.KeyValuePair{Key: "artifactCacheSize", Value: strconv.FormatInt(usage.Size, 10)},
+				&types.KeyValuePair{Key: "artifactCacheMaxSize", Value: strconv.FormatInt(usage.MaxSize, 10)})
+		}
+	}
+	if updateManager.connection != nil {
+		if status := updateManager.connection.ConnectionStatus(); status != nil {
+			parameters = append(parameters,
+				&types.KeyValuePair{Key: "connectionState", Value: string(status.State)},
+				&types.KeyValuePair{Key: "connectionSince", Value: strconv.FormatInt(status.Since, 10)},
+				&types.KeyValuePair{Key: "connectionReconnects", Value: strconv.Itoa(status.Reconnects)})
+			if status.Broker != "" {
+				parameters = append(parameters, &types.KeyValuePair{Key: "connectionBroker", Value: status.Broker})
+			}
+			if status.LastError != "" {
+				parameters = append(parameters, &types.KeyValuePair{Key: "connectionLastError", Value: status.LastError})
+			}
+		}
+	}
+	return parameters
+}
+
+// addHostNodes adds hardware nodes for the host, its CPU and its disk to the given inventory, the host node is linked to the update manager node.
+func addHostNodes(inventory *types.Inventory, updateManagerNodeID, name string, info *host.Info) {
+	if info == nil {
+		return
+	}
+	hostNode := hostHardwareNode(name+"-host", "Host",
+		"os", info.OS, "osRelease", info.OSRelease, "kernel", info.Kernel, "machineId", info.MachineID, "hostname", info.Hostname)
+	cpuNode := hostHardwareNode(name+"-host-cpu", "CPU", "arch", info.Arch, "cores", strconv.Itoa(info.CPUs))
+	nodes := []*types.HardwareNode{hostNode, cpuNode}
+	if info.DiskTotal > 0 {
+		nodes = append(nodes, hostHardwareNode(name+"-host-disk", "Disk", "path", host.DiskPath,
+			"totalBytes", strconv.FormatUint(info.DiskTotal, 10), "freeBytes", strconv.FormatUint(info.DiskFree, 10)))
+	}
+	inventory.HardwareNodes = append(inventory.HardwareNodes, nodes...)
+	inventory.Associations = append(inventory.Associations, &types.Association{SourceID: updateManagerNodeID, TargetID: hostNode.ID})
+	for _, node := range nodes[1:] {
+		inventory.Associations = append(inventory.Associations, &types.Association{SourceID: hostNode.ID, TargetID: node.ID})
+	}
+}
+
+// hostHardwareNode creates a hardware node with the given key and value pairs as parameters, the pairs with empty values are skipped.
+func hostHardwareNode(id, name string, keyValues ...string) *types.HardwareNode {
+	node := &types.HardwareNode{InventoryNode: types.InventoryNode{ID: id, Name: name}}
+	for i := 0; i+1 < len(keyValues); i += 2 {
+		if keyValues[i+1] != "" {
+			node.Parameters = append(node.Parameters, &types.KeyValuePair{Key: keyValues[i], Value: keyValues[i+1]})
+		}
+	}
+	return node
+}
+
+func (updateManager *aggregatedUpdateManager) namespaceNodeIDs() bool {
+	return updateManager.cfg != nil && updateManager.cfg.NamespaceNodeIDs
+}
+
+// namespaceInventory returns a copy of the given inventory with the node IDs and the association endpoints prefixed with the domain name.
+// The original node IDs are kept as a node parameter.
+func namespaceInventory(domain string, inventory *types.Inventory) *types.Inventory {
+	if inventory == nil {
+		return nil
+	}
+	namespaced := &types.Inventory{}
+	for _, node := range inventory.HardwareNodes {
+		if node != nil {
+			namespaced.HardwareNodes = append(namespaced.HardwareNodes, &types.HardwareNode{
+				InventoryNode: namespaceNode(domain, node.InventoryNode),
+				Addressable:   node.Addressable,
+			})
+		}
+	}
+	for _, node := range inventory.SoftwareNodes {
+		if node != nil {
+			namespaced.SoftwareNodes = append(namespaced.SoftwareNodes, &types.SoftwareNode{
+				InventoryNode: namespaceNode(domain, node.InventoryNode),
+				Type:          node.Type,
+			})
+		}
+	}
+	for _, association := range inventory.Associations {
+		if association != nil {
+			namespaced.Associations = append(namespaced.Associations, &types.Association{
+				SourceID: namespaceNodeID(domain, association.SourceID),
+				TargetID: namespaceNodeID(domain, association.TargetID),
+			})
+		}
+	}
+	return namespaced
+}
+
+func namespaceNode(domain string, node types.InventoryNode) types.InventoryNode {
+	parameters := make([]*types.KeyValuePair, 0, len(node.Parameters)+1)
+	parameters = append(parameters, node.Parameters...)
+	node.Parameters = append(parameters, &types.KeyValuePair{Key: types.OriginalIDParameter, Value: node.ID})
+	node.ID = namespaceNodeID(domain, node.ID)
+	return node
+}
+
+func namespaceNodeID(domain, id string) string {
+	return domain + nodeIDSeparator + id
+}
+
+// originalNodeID returns the given ID without the namespace of the given domain.
+func originalNodeID(domain, id string) string {
+	return strings.TrimPrefix(id, domain+nodeIDSeparator)
+}
+
+// copyComponents returns a copy of the given desired state, which component IDs and configurations can be modified without affecting the given desired state.
+func copyComponents(desiredState *types.DesiredState) *types.DesiredState {
+	if desiredState == nil {
+		return nil
+	}
+	copied := &types.DesiredState{}
+	for _, domain := range desiredState.Domains {
+		if domain == nil {
+			copied.Domains = append(copied.Domains, nil)
+			continue
+		}
+		copiedDomain := *domain
+		copiedDomain.Components = make([]*types.ComponentWithConfig, len(domain.Components))
+		for i, component := range domain.Components {
+			if component != nil {
+				copiedComponent := *component
+				copiedDomain.Components[i] = &copiedComponent
+			}
+		}
+		copied.Domains = append(copied.Domains, &copiedDomain)
+	}
+	for _, baseline := range desiredState.Baselines {
+		if baseline == nil {
+			copied.Baselines = append(copied.Baselines, nil)
+			continue
+		}
+		copiedBaseline := *baseline
+		copiedBaseline.Components = append([]string{}, baseline.Components...)
+		copied.Baselines = append(copied.Baselines, &copiedBaseline)
+	}
+	return copied
+}
+
+// restoreNodeIDs removes the domain namespace from the component IDs of the given desired state, so that the domain agents receive their original IDs.
+func restoreNodeIDs(desiredState *types.DesiredState) {
+	if desiredState == nil {
+		return
+	}
+	for _, domain := range desiredState.Domains {
+		if domain == nil {
+			continue
+		}
+		for _, component := range domain.Components {
+			if component != nil {
+				component.ID = originalNodeID(domain.ID, component.ID)
+			}
+		}
+	}
+	for _, baseline := range desiredState.Baselines {
+		if baseline == nil {
+			continue
+		}
+		for i, component := range baseline.Components {
+			if domain, id, ok := strings.Cut(component, ":"); ok {
+				baseline.Components[i] = domain + ":" + originalNodeID(domain, id)
+			}
+		}
+	}
+}
+
 func toFullInventory(updateManagerNode *types.SoftwareNode, domainsInventory map[string]*types.Inventory) *types.Inventory {
 	inventory := &types.Inventory{
 		SoftwareNodes: []*types.SoftwareNode{updateManagerNode},
 	}
-	for domain, domainInventory := range domainsInventory {
-		inventory.HardwareNodes = append(inventory.HardwareNodes, domainInventory.HardwareNodes...)
-		inventory.SoftwareNodes = append(inventory.SoftwareNodes, domainInventory.SoftwareNodes...)
-		rootNode := findDomainSoftwareNode(domain, domainInventory)
-		if rootNode == nil {
+	validInventory := validDomainsInventory(domainsInventory)
+	domains := make([]string, 0, len(validInventory))
+	for domain := range validInventory {
+		domains = append(domains, domain)
+	}
+	sort.Strings(domains)
+	for _, domain := range domains {
+		aggregated := appendDomainInventory(inventory, updateManagerNode.ID, domain, validInventory[domain])
+		if err := types.ValidateInventory(aggregated); err != nil {
+			logger.WithFields(logger.Fields{Domain: domain}).ErrorErr(err, "the current state of the domain conflicts with the device inventory and is quarantined")
 			continue
 		}
-		inventory.Associations = append(inventory.Associations, &types.Association{SourceID: updateManagerNode.ID, TargetID: rootNode.ID})
-		inventory.Associations = append(inventory.Associations, domainInventory.Associations...)
+		inventory = aggregated
 	}
 	return inventory
 }
 
-func findDomainSoftwareNode(domain string, inventory *types.Inventory) *types.SoftwareNode {
-	if len(inventory.SoftwareNodes) == 0 {
-		return nil
+// validDomainsInventory returns the domain inventories, which pass the integrity checks. The invalid inventories are reported and quarantined,
+// i.e. left out of the full inventory.
+func validDomainsInventory(domainsInventory map[string]*types.Inventory) map[string]*types.Inventory {
+	validInventory := map[string]*types.Inventory{}
+	for domain, domainInventory := range domainsInventory {
+		if domainInventory == nil {
+			continue
+		}
+		if err := types.ValidateInventory(domainInventory); err != nil {
+			logger.WithFields(logger.Fields{Domain: domain}).ErrorErr(err, "the current state of the domain is quarantined")
+			continue
+		}
+		validInventory[domain] = domainInventory
+	}
+	return validInventory
+}
+
+// appendDomainInventory returns a copy of the given aggregated inventory extended with the nodes and associations of the given domain inventory.
+// The domain root software node is associated with the update manager node, the nil nodes and associations are skipped.
+func appendDomainInventory(inventory *types.Inventory, updateManagerNodeID, domain string, domainInventory *types.Inventory) *types.Inventory {
+	aggregated := &types.Inventory{
+		HardwareNodes: append([]*types.HardwareNode{}, inventory.HardwareNodes...),
+		SoftwareNodes: append([]*types.SoftwareNode{}, inventory.SoftwareNodes...),
+		Associations:  append([]*types.Association{}, inventory.Associations...),
 	}
+	for _, node := range domainInventory.HardwareNodes {
+		if node != nil {
+			aggregated.HardwareNodes = append(aggregated.HardwareNodes, node)
+		}
+	}
+	for _, node := range domainInventory.SoftwareNodes {
+		if node != nil {
+			aggregated.SoftwareNodes = append(aggregated.SoftwareNodes, node)
+		}
+	}
+	rootNode := findDomainSoftwareNode(domain, domainInventory)
+	if rootNode == nil {
+		return aggregated
+	}
+	aggregated.Associations = append(aggregated.Associations, &types.Association{SourceID: updateManagerNodeID, TargetID: rootNode.ID})
+	for _, association := range domainInventory.Associations {
+		if association != nil {
+			aggregated.Associations = append(aggregated.Associations, association)
+		}
+	}
+	return aggregated
+}
+
+func findDomainSoftwareNode(domain string, inventory *types.Inventory) *types.SoftwareNode {
+	var firstNode *types.SoftwareNode
 	for _, softwareNode := range inventory.SoftwareNodes {
+		if softwareNode == nil {
+			continue
+		}
+		if firstNode == nil {
+			firstNode = softwareNode
+		}
 		if softwareNode.Type != types.SoftwareTypeApplication {
 			continue
 		}
 		for _, parameter := range softwareNode.Parameters {
-			if parameter.Key != "domain" {
+			if parameter == nil || parameter.Key != "domain" {
 				continue
 			}
 			if parameter.Value != domain {
@@ -91,5 +379,5 @@ func findDomainSoftwareNode(domain string, inventory *types.Inventory) *types.So
 			return softwareNode
 		}
 	}
-	return inventory.SoftwareNodes[0]
+	return firstNode
 }
@@ -20,9 +20,12 @@ import (
 	"sync"
 	"testing"
 
+	"github.com/eclipse-kanto/update-manager/api"
 	"github.com/eclipse-kanto/update-manager/api/types"
 	"github.com/eclipse-kanto/update-manager/test"
 	"github.com/eclipse-kanto/update-manager/test/mocks"
+	"github.com/eclipse-kanto/update-manager/updatem/cache"
+	"github.com/eclipse-kanto/update-manager/util/host"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 )
@@ -83,17 +86,13 @@ func TestAggregateDomainsInventory(t *testing.T) {
 					},
 				},
 				"containers": {
-					HardwareNodes: test.SampleTestHardwareNode,
 					SoftwareNodes: []*types.SoftwareNode{
 						test.CreateSoftwareNode("containers", 2, "domain", "containers", types.SoftwareTypeApplication),
 					},
 				},
 			},
 			expected: &types.Inventory{
-				HardwareNodes: []*types.HardwareNode{
-					test.SampleTestHardwareNode[0],
-					test.SampleTestHardwareNode[0],
-				},
+				HardwareNodes: test.SampleTestHardwareNode,
 				SoftwareNodes: []*types.SoftwareNode{
 					test.MainInventoryNode,
 					test.CreateSoftwareNode("safety-domain", 1, "domain", "safety-domain", types.SoftwareTypeContainer),
@@ -169,7 +168,6 @@ func TestAggregateDomainsInventory(t *testing.T) {
 					},
 				},
 				"containers": {
-					HardwareNodes: test.SampleTestHardwareNode,
 					SoftwareNodes: []*types.SoftwareNode{
 						test.CreateSoftwareNode("containers", 2, "domain", "containers", types.SoftwareTypeApplication),
 						test.CreateSoftwareNode("containers", 3, "", "", types.SoftwareTypeApplication),
@@ -183,10 +181,7 @@ func TestAggregateDomainsInventory(t *testing.T) {
 				},
 			},
 			expected: &types.Inventory{
-				HardwareNodes: []*types.HardwareNode{
-					test.SampleTestHardwareNode[0],
-					test.SampleTestHardwareNode[0],
-				},
+				HardwareNodes: test.SampleTestHardwareNode,
 				SoftwareNodes: []*types.SoftwareNode{
 					test.MainInventoryNode,
 					test.CreateSoftwareNode("safety-domain", 2, "domain", "safety-domain", types.SoftwareTypeApplication),
@@ -257,6 +252,95 @@ func TestAggregateDomainsInventory(t *testing.T) {
 				},
 			},
 		},
+		"test_conflicting_domainsInventory_quarantined": {
+			domainsInventory: map[string]*types.Inventory{
+				"containers": {
+					HardwareNodes: test.SampleTestHardwareNode,
+					SoftwareNodes: []*types.SoftwareNode{
+						test.CreateSoftwareNode("containers", 2, "domain", "containers", types.SoftwareTypeApplication),
+					},
+				},
+				"self-update": {
+					HardwareNodes: test.SampleTestHardwareNode,
+					SoftwareNodes: []*types.SoftwareNode{
+						test.CreateSoftwareNode("self-update", 2, "domain", "self-update", types.SoftwareTypeApplication),
+					},
+				},
+				"safety-domain": {
+					SoftwareNodes: []*types.SoftwareNode{
+						test.CreateSoftwareNode("safety-domain", 2, "domain", "safety-domain", types.SoftwareTypeApplication),
+						{InventoryNode: types.InventoryNode{ID: "device-update-manager"}},
+					},
+				},
+			},
+			expected: &types.Inventory{
+				HardwareNodes: test.SampleTestHardwareNode,
+				SoftwareNodes: []*types.SoftwareNode{
+					test.MainInventoryNode,
+					test.CreateSoftwareNode("containers", 2, "domain", "containers", types.SoftwareTypeApplication),
+				},
+				Associations: []*types.Association{
+					test.CreateAssociation("device-update-manager", "containers-test:2"),
+				},
+			},
+		},
+		"test_nil_nodes_skipped": {
+			domainsInventory: map[string]*types.Inventory{
+				"containers": {
+					HardwareNodes: []*types.HardwareNode{nil},
+					SoftwareNodes: []*types.SoftwareNode{
+						nil,
+						test.CreateSoftwareNode("containers", 2, "domain", "containers", types.SoftwareTypeApplication),
+					},
+					Associations: []*types.Association{nil},
+				},
+			},
+			expected: &types.Inventory{
+				SoftwareNodes: []*types.SoftwareNode{
+					test.MainInventoryNode,
+					test.CreateSoftwareNode("containers", 2, "domain", "containers", types.SoftwareTypeApplication),
+				},
+				Associations: []*types.Association{
+					test.CreateAssociation("device-update-manager", "containers-test:2"),
+				},
+			},
+		},
+		"test_invalid_domainsInventory_quarantined": {
+			domainsInventory: map[string]*types.Inventory{
+				"safety-domain": {
+					SoftwareNodes: []*types.SoftwareNode{
+						test.CreateSoftwareNode("safety-domain", 2, "domain", "safety-domain", types.SoftwareTypeApplication),
+						test.CreateSoftwareNode("safety-domain", 3, "", "", types.SoftwareTypeApplication),
+					},
+					Associations: []*types.Association{
+						test.CreateAssociation("safety-domain-test:2", "safety-domain-test:3"),
+						test.CreateAssociation("safety-domain-test:3", "safety-domain-test:2"),
+					},
+				},
+				"containers": {
+					SoftwareNodes: []*types.SoftwareNode{
+						test.CreateSoftwareNode("containers", 2, "domain", "containers", types.SoftwareTypeApplication),
+					},
+					Associations: []*types.Association{
+						test.CreateAssociation("containers-test:2", "containers-test:5"),
+					},
+				},
+				"self-update": {
+					SoftwareNodes: []*types.SoftwareNode{
+						test.CreateSoftwareNode("self-update", 2, "domain", "self-update", types.SoftwareTypeApplication),
+					},
+				},
+			},
+			expected: &types.Inventory{
+				SoftwareNodes: []*types.SoftwareNode{
+					test.MainInventoryNode,
+					test.CreateSoftwareNode("self-update", 2, "domain", "self-update", types.SoftwareTypeApplication),
+				},
+				Associations: []*types.Association{
+					test.CreateAssociation("device-update-manager", "self-update-test:2"),
+				},
+			},
+		},
 	}
 
 	for testName, testCase := range testCases {
@@ -326,3 +410,157 @@ func TestUpdateInventoryForDomain2(t *testing.T) {
 		assert.Equal(t, testCase.testInventory, domainsInventory["testName"])
 	}
 }
+
+func TestFullInventoryNamespaceNodeIDs(t *testing.T) {
+	domainsInventory := map[string]*types.Inventory{
+		"containers": {
+			HardwareNodes: test.SampleTestHardwareNode,
+			SoftwareNodes: []*types.SoftwareNode{
+				test.CreateSoftwareNode("containers", 1, "domain", "containers", types.SoftwareTypeApplication),
+			},
+			Associations: []*types.Association{
+				test.CreateAssociation("containers-test:1", "testId"),
+			},
+		},
+		"self-update": {
+			HardwareNodes: test.SampleTestHardwareNode,
+		},
+	}
+	updateManager := createTestUpdateManager(nil, nil, nil, 0, createTestConfig(false, false), nil, domainsInventory, "development")
+
+	t.Run("test_namespace_disabled", func(t *testing.T) {
+		inventory := updateManager.fullInventory(domainsInventory)
+		assert.Equal(t, test.SampleTestHardwareNode, inventory.HardwareNodes)
+		assert.Len(t, inventory.Associations, 2)
+	})
+	t.Run("test_namespace_enabled", func(t *testing.T) {
+		updateManager.cfg.NamespaceNodeIDs = true
+		defer func() { updateManager.cfg.NamespaceNodeIDs = false }()
+
+		withOriginalID := func(node types.InventoryNode, domain string) types.InventoryNode {
+			node.Parameters = append(append([]*types.KeyValuePair{}, node.Parameters...), &types.KeyValuePair{Key: types.OriginalIDParameter, Value: node.ID})
+			node.ID = domain + "/" + node.ID
+			return node
+		}
+		containersNode := test.CreateSoftwareNode("containers", 1, "domain", "containers", types.SoftwareTypeApplication)
+		expected := &types.Inventory{
+			HardwareNodes: []*types.HardwareNode{
+				{InventoryNode: withOriginalID(test.SampleTestHardwareNode[0].InventoryNode, "containers")},
+				{InventoryNode: withOriginalID(test.SampleTestHardwareNode[0].InventoryNode, "self-update")},
+			},
+			SoftwareNodes: []*types.SoftwareNode{
+				describedInventoryNode("domains", "", "onlineDomains", "containers,self-update", "ownerConsentCommands", "", "rebootEnabled", "false"),
+				{InventoryNode: withOriginalID(containersNode.InventoryNode, "containers"), Type: types.SoftwareTypeApplication},
+			},
+			Associations: []*types.Association{
+				test.CreateAssociation("device-update-manager", "containers/containers-test:1"),
+				test.CreateAssociation("containers/containers-test:1", "containers/testId"),
+			},
+		}
+		test.AssertInventoryWithoutElementsOrder(t, expected, updateManager.fullInventory(domainsInventory))
+		assert.Len(t, domainsInventory["containers"].SoftwareNodes[0].Parameters, 1)
+		assert.Equal(t, "containers-test:1", domainsInventory["containers"].SoftwareNodes[0].ID)
+	})
+}
+
+func TestRestoreNodeIDs(t *testing.T) {
+	desiredState := &types.DesiredState{
+		Baselines: []*types.Baseline{
+			{Title: "baseline", Components: []string{"containers:containers/xyz", "containers:abc", "self-update/os"}},
+		},
+		Domains: []*types.Domain{
+			{
+				ID: "containers",
+				Components: []*types.ComponentWithConfig{
+					{Component: types.Component{ID: "containers/xyz", Version: "1"}},
+					{Component: types.Component{ID: "abc", Version: "2"}},
+					{Component: types.Component{ID: "self-update/os", Version: "3"}},
+				},
+			},
+		},
+	}
+	restoreNodeIDs(desiredState)
+	restoreNodeIDs(nil)
+
+	assert.Equal(t, []string{"containers:xyz", "containers:abc", "self-update/os"}, desiredState.Baselines[0].Components)
+	assert.Equal(t, "xyz", desiredState.Domains[0].Components[0].ID)
+	assert.Equal(t, "abc", desiredState.Domains[0].Components[1].ID)
+	assert.Equal(t, "self-update/os", desiredState.Domains[0].Components[2].ID)
+}
+
+func TestFullInventoryDescribeUpdateManager(t *testing.T) {
+	cfg := createTestConfig(false, true)
+	cfg.RebootAfter = "1m"
+	cfg.OwnerConsentCommands = []types.CommandType{types.CommandDownload, types.CommandActivate}
+	domainsInventory := map[string]*types.Inventory{
+		"testDomain2": {SoftwareNodes: []*types.SoftwareNode{test.CreateSoftwareNode("testDomain2", 1, "", "", types.SoftwareTypeApplication)}},
+		"testDomain3": nil,
+	}
+	updateManager := createTestUpdateManager(nil, map[string]api.UpdateManager{"testDomain1": nil, "testDomain2": nil, "testDomain3": nil},
+		nil, 0, cfg, nil, nil, "development")
+	updateManager.startTime = time.Now().Add(-90 * time.Second)
+	updateManager.activeActivityID.Store(test.ActivityID)
+	updateManager.hostInfo = func() *host.Info {
+		return &host.Info{Arch: "arm64", CPUs: 4, OS: "linux", OSRelease: "Poky 4.0", Kernel: "5.15.0", MachineID: "0123456789abcdef", DiskTotal: 1024, DiskFree: 512}
+	}
+
+	inventory := updateManager.fullInventory(domainsInventory)
+
+	assert.Equal(t, describedInventoryNode("domains", "testDomain1,testDomain2,testDomain3", "onlineDomains", "testDomain2",
+		"ownerConsentCommands", "DOWNLOAD,ACTIVATE", "rebootEnabled", "true", "rebootAfter", "1m", "activityId", test.ActivityID, "uptime", "1m30s"),
+		inventory.SoftwareNodes[0])
+	assert.Equal(t, []*types.HardwareNode{
+		{InventoryNode: types.InventoryNode{ID: "device-host", Name: "Host", Parameters: []*types.KeyValuePair{
+			{Key: "os", Value: "linux"}, {Key: "osRelease", Value: "Poky 4.0"}, {Key: "kernel", Value: "5.15.0"}, {Key: "machineId", Value: "0123456789abcdef"},
+		}}},
+		{InventoryNode: types.InventoryNode{ID: "device-host-cpu", Name: "CPU", Parameters: []*types.KeyValuePair{
+			{Key: "arch", Value: "arm64"}, {Key: "cores", Value: "4"},
+		}}},
+		{InventoryNode: types.InventoryNode{ID: "device-host-disk", Name: "Disk", Parameters: []*types.KeyValuePair{
+			{Key: "path", Value: "/"}, {Key: "totalBytes", Value: "1024"}, {Key: "freeBytes", Value: "512"},
+		}}},
+	}, inventory.HardwareNodes)
+	assert.Equal(t, []*types.Association{
+		test.CreateAssociation("device-update-manager", "testDomain2-test:1"),
+		test.CreateAssociation("device-update-manager", "device-host"),
+		test.CreateAssociation("device-host", "device-host-cpu"),
+		test.CreateAssociation("device-host", "device-host-disk"),
+	}, inventory.Associations)
+	assert.NoError(t, types.ValidateInventory(inventory))
+}
+
+func TestFullInventoryDescribeArtifactCache(t *testing.T) {
+	artifactCache, err := cache.New(&cache.Config{Dir: t.TempDir(), MaxSize: 2, ReservationTimeout: "1h"})
+	assert.NoError(t, err)
+	updateManager := createTestUpdateManager(nil, map[string]api.UpdateManager{"testDomain1": nil}, nil, 0, nil, nil, nil, "development")
+	updateManager.artifactCache = artifactCache
+
+	inventory := updateManager.fullInventory(map[string]*types.Inventory{})
+
+	assert.Equal(t, describedInventoryNode("domains", "testDomain1", "onlineDomains", "",
+		"artifactCacheArtifacts", "0", "artifactCacheSize", "0", "artifactCacheMaxSize", "2097152"), inventory.SoftwareNodes[0])
+	assert.Equal(t, artifactCache, updateManager.ArtifactCache())
+	updateManager.artifactCache = nil
+	assert.Nil(t, updateManager.ArtifactCache())
+}
+
+func TestFullInventoryDescribeConnectionStatus(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockConnection := mocks.NewMockConnectionStatusProvider(mockCtrl)
+	updateManager := createTestUpdateManager(nil, map[string]api.UpdateManager{"testDomain1": nil}, nil, 0, nil, nil, nil, "development")
+	updateManager.connection = mockConnection
+
+	mockConnection.EXPECT().ConnectionStatus().Return(&types.ConnectionStatus{
+		State: types.ConnectionStateConnected, Broker: "tcp://localhost:1883", Subscribed: true, Since: 1000, Reconnects: 2, LastError: "EOF",
+	})
+	inventory := updateManager.fullInventory(map[string]*types.Inventory{})
+	assert.Equal(t, describedInventoryNode("domains", "testDomain1", "onlineDomains", "",
+		"connectionState", "CONNECTED", "connectionSince", "1000", "connectionReconnects", "2",
+		"connectionBroker", "tcp://localhost:1883", "connectionLastError", "EOF"), inventory.SoftwareNodes[0])
+
+	mockConnection.EXPECT().ConnectionStatus().Return(nil)
+	inventory = updateManager.fullInventory(map[string]*types.Inventory{})
+	assert.Equal(t, describedInventoryNode("domains", "testDomain1", "onlineDomains", ""), inventory.SoftwareNodes[0])
+}
@@ -0,0 +1,130 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package orchestration
+
+import (
+	"reflect"
+	"time"
+
+	"github.com/eclipse-kanto/update-manager/api"
+	"github.com/eclipse-kanto/update-manager/api/util"
+	"github.com/eclipse-kanto/update-manager/config"
+	"github.com/eclipse-kanto/update-manager/logger"
+)
+
+// ReloadConfig applies the reloaded configuration, i.e. the timeouts, the report intervals, the owner consent commands, the reboot settings
+// and the domain agents.
+// If an update activity is in progress, the configuration is applied after it is finished, so that the activity is not affected.
+func (updateManager *aggregatedUpdateManager) ReloadConfig(cfg *config.Config) {
+	updateManager.applyLock.Lock()
+	defer updateManager.applyLock.Unlock()
+
+	if updateManager.inProgress {
+		logger.WithFields(logger.Fields{ActivityID: updateManager.activityInProgress}).Info("reloaded configuration will be applied after the update activity is finished")
+		updateManager.pendingCfg = cfg
+		return
+	}
+	updateManager.applyConfig(cfg)
+}
+
+// applyConfig applies the reloaded configuration, the apply lock must be held by the caller and no update activity must be in progress.
+func (updateManager *aggregatedUpdateManager) applyConfig(cfg *config.Config) {
+	updateManager.reconcileDomainAgents(updateManager.cfg.Agents, cfg.Agents)
+	updateManager.cfg = cfg
+	if orchestrator, ok := updateManager.updateOrchestrator.(*updateOrchestrator); ok {
+		orchestrator.setConfig(cfg)
+	}
+	if setter, ok := updateManager.eventCallback.(api.ReportIntervalsSetter); ok {
+		setter.SetReportIntervals(util.ParseDuration("report-feedback-interval", cfg.ReportFeedbackInterval, time.Minute, 0),
+			util.ParseDuration("current-state-delay", cfg.CurrentStateDelay, 30*time.Second, 0))
+	}
+	logger.Info("reloaded configuration applied")
+}
+
+// reconcileDomainAgents disposes the domain agents, which are removed from the configuration, and creates and starts the added ones.
+// The domain agents with changed read timeout or topics are recreated.
+func (updateManager *aggregatedUpdateManager) reconcileDomainAgents(current, reloaded map[string]*api.UpdateManagerConfig) {
+	agents := updateManager.getDomainAgents()
+	var removed []string
+	for name, agent := range agents {
+		agentConfig, ok := reloaded[name]
+		if ok && !domainAgentChanged(current[name], agentConfig) {
+			continue
+		}
+		if err := agent.Dispose(); err != nil {
+			logger.WithFields(logger.Fields{Domain: name}).ErrorErr(err, "error disposing update agent")
+		}
+		delete(agents, name)
+		if !ok {
+			logger.WithFields(logger.Fields{Domain: name}).Info("update agent removed")
+			removed = append(removed, name)
+		}
+	}
+	updateManager.agentsLock.Lock()
+	ctx := updateManager.watchCtx
+	updateManager.agentsLock.Unlock()
+	for name, agentConfig := range reloaded {
+		if _, ok := agents[name]; ok {
+			continue
+		}
+		agent, err := updateManager.newDomainAgent(name, agentConfig)
+		if err != nil {
+			logger.WithFields(logger.Fields{Domain: name}).ErrorErr(err, "cannot create update agent")
+			continue
+		}
+		agent.SetCallback(updateManager)
+		if ctx != nil {
+			agent.WatchEvents(ctx)
+		}
+		agents[name] = agent
+		logger.WithFields(logger.Fields{Domain: name}).Info("update agent configured")
+	}
+
+	updateManager.agentsLock.Lock()
+	updateManager.domainAgents = agents
+	updateManager.agentsLock.Unlock()
+
+	if len(removed) > 0 {
+		updateManager.removeDomainsInventory(removed)
+	}
+}
+
+// removeDomainsInventory removes the current state of the given domains and reports the changed current state.
+func (updateManager *aggregatedUpdateManager) removeDomainsInventory(domains []string) {
+	updateManager.eventLock.Lock()
+	defer updateManager.eventLock.Unlock()
+
+	for _, name := range domains {
+		delete(updateManager.domainsInventory, name)
+	}
+	if updateManager.eventCallback != nil {
+		inventory := updateManager.fullInventory(updateManager.domainsInventory)
+		updateManager.eventCallback.HandleCurrentStateEvent(updateManager.Name(), "", inventory)
+	}
+}
+
+// getDomainAgents returns a copy of the domain agents, so that they can be iterated while the configuration is reloaded.
+func (updateManager *aggregatedUpdateManager) getDomainAgents() map[string]api.UpdateManager {
+	updateManager.agentsLock.Lock()
+	defer updateManager.agentsLock.Unlock()
+
+	agents := make(map[string]api.UpdateManager, len(updateManager.domainAgents))
+	for name, agent := range updateManager.domainAgents {
+		agents[name] = agent
+	}
+	return agents
+}
+
+func domainAgentChanged(current, reloaded *api.UpdateManagerConfig) bool {
+	return current == nil || current.ReadTimeout != reloaded.ReadTimeout || !reflect.DeepEqual(current.Topics, reloaded.Topics)
+}
@@ -0,0 +1,136 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package orchestration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/eclipse-kanto/update-manager/api"
+	"github.com/eclipse-kanto/update-manager/api/types"
+	"github.com/eclipse-kanto/update-manager/test"
+	mocks "github.com/eclipse-kanto/update-manager/test/mocks"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReloadConfig(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	ctx := context.Background()
+	cfg := createTestConfig(false, true)
+	eventCallback := mocks.NewMockUpdateManagerCallback(mockCtrl)
+	domainAgents := createTestDomainUpdateManagers(mockCtrl)
+	orchestrator := NewUpdateOrchestrator(cfg, nil).(*updateOrchestrator)
+	updateManager := createTestUpdateManager(eventCallback, domainAgents, nil, 0, cfg, orchestrator, map[string]*types.Inventory{
+		"testDomain1": {SoftwareNodes: []*types.SoftwareNode{test.CreateSoftwareNode("testDomain1", 1, "", "", types.SoftwareTypeApplication)}},
+		"testDomain2": {SoftwareNodes: []*types.SoftwareNode{test.CreateSoftwareNode("testDomain2", 1, "", "", types.SoftwareTypeApplication)}},
+	}, "development")
+	updateManager.watchCtx = ctx
+
+	created := map[string]*mocks.MockUpdateManager{}
+	updateManager.newDomainAgent = func(name string, agentConfig *api.UpdateManagerConfig) (api.UpdateManager, error) {
+		agent := mocks.NewMockUpdateManager(mockCtrl)
+		agent.EXPECT().SetCallback(updateManager)
+		agent.EXPECT().WatchEvents(ctx)
+		created[name] = agent
+		return agent, nil
+	}
+
+	reloadedCfg := createTestConfig(true, false)
+	reloadedCfg.PhaseTimeout = "1m"
+	reloadedCfg.OwnerConsentCommands = []types.CommandType{types.CommandDownload}
+	delete(reloadedCfg.Agents, "testDomain1")
+	reloadedCfg.Agents["testDomain3"].Topics = map[string]string{"desiredState": "legacy/testDomain3/desiredstate"}
+	reloadedCfg.Agents["testDomain4"] = &api.UpdateManagerConfig{Name: "testDomain4", ReadTimeout: "1m"}
+
+	t.Run("test_reload_deferred_while_in_progress", func(t *testing.T) {
+		assert.False(t, updateManager.checkIfInProgress(test.ActivityID))
+		updateManager.ReloadConfig(reloadedCfg)
+		assert.Equal(t, cfg, updateManager.cfg)
+		assert.Equal(t, reloadedCfg, updateManager.pendingCfg)
+		assert.Equal(t, 3, len(updateManager.getDomainAgents()))
+	})
+
+	t.Run("test_reload_applied_when_finished", func(t *testing.T) {
+		domainAgents["testDomain1"].(*mocks.MockUpdateManager).EXPECT().Dispose()
+		domainAgents["testDomain3"].(*mocks.MockUpdateManager).EXPECT().Dispose()
+		eventCallback.EXPECT().HandleCurrentStateEvent("device", "", gomock.Any()).Do(
+			func(name, activityID string, inventory *types.Inventory) {
+				assert.Equal(t, 2, len(inventory.SoftwareNodes))
+				for _, node := range inventory.SoftwareNodes {
+					assert.NotContains(t, node.ID, "testDomain1")
+				}
+			})
+
+		updateManager.markApplyCompleted()
+
+		assert.Nil(t, updateManager.pendingCfg)
+		assert.Equal(t, reloadedCfg, updateManager.cfg)
+		agents := updateManager.getDomainAgents()
+		assert.Equal(t, 3, len(agents))
+		assert.Equal(t, domainAgents["testDomain2"], agents["testDomain2"])
+		assert.Equal(t, created["testDomain3"], agents["testDomain3"])
+		assert.Equal(t, created["testDomain4"], agents["testDomain4"])
+		assert.Nil(t, updateManager.domainsInventory["testDomain1"])
+		assert.NotNil(t, updateManager.domainsInventory["testDomain2"])
+
+		assert.Equal(t, time.Minute, orchestrator.phaseTimeout)
+		assert.True(t, orchestrator.cfg.Agents["testDomain2"].RebootRequired)
+		assert.Nil(t, orchestrator.cfg.OwnerConsentCommands)
+	})
+
+	t.Run("test_reload_applied_at_once", func(t *testing.T) {
+		unchangedCfg := createTestConfig(true, true)
+		delete(unchangedCfg.Agents, "testDomain1")
+		unchangedCfg.Agents["testDomain3"].Topics = map[string]string{"desiredState": "legacy/testDomain3/desiredstate"}
+		unchangedCfg.Agents["testDomain4"] = &api.UpdateManagerConfig{Name: "testDomain4", ReadTimeout: "1m"}
+
+		updateManager.ReloadConfig(unchangedCfg)
+		assert.Equal(t, unchangedCfg, updateManager.cfg)
+		assert.Equal(t, 3, len(updateManager.getDomainAgents()))
+		assert.Equal(t, 10*time.Minute, orchestrator.phaseTimeout)
+	})
+}
+
+type testReportIntervalsCallback struct {
+	*mocks.MockUpdateManagerCallback
+	desiredStateFeedbackInterval time.Duration
+	currentStateDelay            time.Duration
+}
+
+func (callback *testReportIntervalsCallback) SetReportIntervals(desiredStateFeedbackInterval, currentStateDelay time.Duration) {
+	callback.desiredStateFeedbackInterval = desiredStateFeedbackInterval
+	callback.currentStateDelay = currentStateDelay
+}
+
+func TestReloadConfigReportIntervals(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	callback := &testReportIntervalsCallback{MockUpdateManagerCallback: mocks.NewMockUpdateManagerCallback(mockCtrl)}
+	cfg := createTestConfig(false, false)
+	cfg.Agents = nil
+	updateManager := createTestUpdateManager(callback, map[string]api.UpdateManager{}, nil, 0, cfg, nil, nil, "development")
+
+	reloadedCfg := createTestConfig(false, false)
+	reloadedCfg.Agents = nil
+	reloadedCfg.ReportFeedbackInterval = "5s"
+	reloadedCfg.CurrentStateDelay = "0s"
+	updateManager.ReloadConfig(reloadedCfg)
+	assert.Equal(t, 5*time.Second, callback.desiredStateFeedbackInterval)
+	assert.Equal(t, time.Duration(0), callback.currentStateDelay)
+}
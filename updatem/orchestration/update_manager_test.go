@@ -25,13 +25,16 @@ import (
 	"github.com/eclipse-kanto/update-manager/mqtt"
 	"github.com/eclipse-kanto/update-manager/test"
 	mocks "github.com/eclipse-kanto/update-manager/test/mocks"
+	"github.com/eclipse-kanto/update-manager/updatem/history"
+	"github.com/eclipse-kanto/update-manager/updatem/replay"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 )
 
 var defaultInventory = &types.Inventory{
 	SoftwareNodes: []*types.SoftwareNode{
-		test.MainInventoryNode,
+		describedInventoryNode("domains", "testDomain1,testDomain2,testDomain3", "onlineDomains", "testDomainInventory1,testDomainInventory2",
+			"ownerConsentCommands", "", "rebootEnabled", "false"),
 		test.CreateSoftwareNode("domain", 1, "", "", types.SoftwareTypeApplication),
 		test.CreateSoftwareNode("domain", 2, "", "", types.SoftwareTypeApplication),
 	},
@@ -60,6 +63,30 @@ func TestNewUpdateManager(t *testing.T) {
 		assert.NotNil(t, updateManager.rebootManager)
 		assert.NotNil(t, updateManager.domainAgents)
 		assert.Equal(t, 3, len(updateManager.domainAgents))
+		assert.NotNil(t, updateManager.history)
+	})
+	t.Run("test_orchestrator_history", func(t *testing.T) {
+		uaClient, err := mqtt.NewUpdateAgentClient("device", &mqtt.ConnectionConfig{})
+		assert.NoError(t, err)
+		orchestrator := NewUpdateOrchestrator(cfg, nil)
+		apiUpdateManager, err := NewUpdateManager("dummyVersion", cfg, uaClient, orchestrator)
+		assert.NoError(t, err)
+		assert.Equal(t, apiUpdateManager.(*aggregatedUpdateManager).history, orchestrator.(*updateOrchestrator).history)
+	})
+	t.Run("test_orchestrator_connection_status", func(t *testing.T) {
+		uaClient, err := mqtt.NewUpdateAgentClient("device", &mqtt.ConnectionConfig{})
+		assert.NoError(t, err)
+		pauseCfg := createTestConfig(false, false)
+		pauseCfg.PhaseTimeoutPause = true
+		orchestrator := NewUpdateOrchestrator(pauseCfg, nil)
+		_, err = NewUpdateManager("dummyVersion", pauseCfg, uaClient, orchestrator)
+		assert.NoError(t, err)
+		assert.Equal(t, uaClient, orchestrator.(*updateOrchestrator).connection)
+
+		orchestrator = NewUpdateOrchestrator(cfg, nil)
+		_, err = NewUpdateManager("dummyVersion", cfg, uaClient, orchestrator)
+		assert.NoError(t, err)
+		assert.Nil(t, orchestrator.(*updateOrchestrator).connection)
 	})
 	t.Run("test_error", func(t *testing.T) {
 		mockClient := mocks.NewMockUpdateAgentClient(mockCtrl)
@@ -110,7 +137,8 @@ func TestApplyDesiredState(t *testing.T) {
 	}
 	testInventory := &types.Inventory{
 		SoftwareNodes: []*types.SoftwareNode{
-			test.MainInventoryNode,
+			describedInventoryNode("domains", "testDomain1", "onlineDomains", "", "ownerConsentCommands", "", "rebootEnabled", "false",
+				"activityId", test.ActivityID),
 		},
 	}
 	ctx := context.Background()
@@ -146,7 +174,8 @@ func TestApplyDesiredStateWhileInProgress(t *testing.T) {
 	}
 	testInventory := &types.Inventory{
 		SoftwareNodes: []*types.SoftwareNode{
-			test.MainInventoryNode,
+			describedInventoryNode("domains", "testDomain1", "onlineDomains", "", "ownerConsentCommands", "", "rebootEnabled", "false",
+				"activityId", test.ActivityID),
 		},
 	}
 	ctx := context.Background()
@@ -198,6 +227,7 @@ func TestApplyDesiredStateWhileInProgress(t *testing.T) {
 	idAccepted := "activity-to-be-accepted"
 	domainUpdateManager.EXPECT().Get(ctx, idAccepted).Return(nil, nil)
 	mockUpdateOrchestrator.EXPECT().Apply(context.Background(), domainUpdateManagers, idAccepted, desiredState1, eventCallback).Times(1)
+	testInventory.SoftwareNodes[0].Parameters[4].Value = idAccepted
 	eventCallback.EXPECT().HandleCurrentStateEvent("device", idAccepted, testInventory)
 	updateManager.Apply(ctx, idAccepted, desiredState1)
 	assert.False(t, updateManager.inProgress)
@@ -242,6 +272,19 @@ func TestSetCallback(t *testing.T) {
 	assert.Equal(t, eventCallback, updateManager.eventCallback)
 }
 
+func TestActivityStatus(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	activity := &types.ActivityStatus{ActivityID: test.ActivityID, Status: types.StatusRunning}
+	orchestrator := &updateOrchestrator{activity: activity}
+	updateManager := createTestUpdateManager(nil, nil, nil, 0, createTestConfig(false, false), orchestrator, nil, "1.0.0")
+	assert.Equal(t, activity, updateManager.ActivityStatus())
+
+	updateManager = createTestUpdateManager(nil, nil, nil, 0, createTestConfig(false, false), mocks.NewMockUpdateOrchestrator(mockCtrl), nil, "1.0.0")
+	assert.Nil(t, updateManager.ActivityStatus())
+}
+
 func TestRebootAfterApplyDesiredState(t *testing.T) {
 	mockCtrl := gomock.NewController(t)
 	defer mockCtrl.Finish()
@@ -290,7 +333,8 @@ func TestRebootAfterApplyDesiredState(t *testing.T) {
 		t.Run(testValue.name, func(t *testing.T) {
 			testInventory := &types.Inventory{
 				SoftwareNodes: []*types.SoftwareNode{
-					test.MainInventoryNode,
+					describedInventoryNode("domains", "", "onlineDomains", "", "ownerConsentCommands", "",
+						"rebootEnabled", fmt.Sprint(testValue.rebootEnabled), "activityId", test.ActivityID),
 				},
 			}
 			eventCallback := mocks.NewMockUpdateManagerCallback(mockCtrl)
@@ -312,6 +356,124 @@ func TestRebootAfterApplyDesiredState(t *testing.T) {
 	}
 }
 
+func TestActivityHistory(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	eventCallback := mocks.NewMockUpdateManagerCallback(mockCtrl)
+	eventCallback.EXPECT().HandleCurrentStateEvent("device", test.ActivityID, gomock.Any())
+	mockUpdateOrchestrator := mocks.NewMockUpdateOrchestrator(mockCtrl)
+	rebootManager := mocks.NewMockRebootManager(mockCtrl)
+	rebootManager.EXPECT().Reboot(30 * time.Second).Return(fmt.Errorf("reboot error"))
+
+	domainUpdateManagers := map[string]api.UpdateManager{}
+	updateManager := createTestUpdateManager(eventCallback, domainUpdateManagers, rebootManager, 0, createTestConfig(true, true), mockUpdateOrchestrator, nil, "development")
+	recorder, err := history.NewRecorder(nil)
+	assert.NoError(t, err)
+	updateManager.history = recorder
+
+	ctx := api.WithDesiredStateSigner(context.Background(), test.SignerSubject)
+	mockUpdateOrchestrator.EXPECT().Apply(ctx, domainUpdateManagers, test.ActivityID, test.DesiredState, eventCallback).DoAndReturn(
+		func(ctx context.Context, domainAgents map[string]api.UpdateManager, activityID string, desiredState *types.DesiredState, desiredStateCallback api.DesiredStateFeedbackHandler) bool {
+			mockUpdateOrchestrator.EXPECT().HandleDesiredStateFeedbackEvent("testDomain1", activityID, "", types.StatusCompleted, "", nil)
+			updateManager.HandleDesiredStateFeedbackEvent("testDomain1", activityID, "", types.StatusCompleted, "", nil)
+			recorder.StatusChanged(activityID, types.StatusCompleted, "")
+			return true
+		})
+	updateManager.Apply(ctx, test.ActivityID, test.DesiredState)
+
+	activities := updateManager.ActivityHistory(&types.ActivityHistoryQuery{ActivityID: test.ActivityID}).Activities
+	assert.Equal(t, 1, len(activities))
+	assert.Equal(t, test.SignerSubject, activities[0].Signer)
+	assert.Equal(t, types.StatusCompleted, activities[0].Status)
+	assert.Equal(t, map[string]types.StatusType{"testDomain1": types.StatusCompleted}, activities[0].Domains)
+	assert.Equal(t, "reboot error", activities[0].Reboot.Error)
+	assert.True(t, activities[0].EndTime > 0)
+}
+
+func TestApplyDesiredStateReplay(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	eventCallback := mocks.NewMockUpdateManagerCallback(mockCtrl)
+	mockUpdateOrchestrator := mocks.NewMockUpdateOrchestrator(mockCtrl)
+	domainUpdateManagers := map[string]api.UpdateManager{}
+	updateManager := createTestUpdateManager(eventCallback, domainUpdateManagers, nil, 0, createTestConfig(false, false), mockUpdateOrchestrator, nil, "development")
+	guard, err := replay.NewGuard(&replay.Config{Enabled: true, MaxAge: "1h"})
+	assert.NoError(t, err)
+	updateManager.replayGuard = guard
+	recorder, err := history.NewRecorder(nil)
+	assert.NoError(t, err)
+	updateManager.history = recorder
+
+	ctx := api.WithDesiredStateTimestamp(context.Background(), time.Now().UnixMilli())
+	mockUpdateOrchestrator.EXPECT().Apply(ctx, domainUpdateManagers, test.ActivityID, test.DesiredState, eventCallback).Return(false)
+	eventCallback.EXPECT().HandleCurrentStateEvent("device", test.ActivityID, gomock.Any())
+	updateManager.Apply(ctx, test.ActivityID, test.DesiredState)
+
+	eventCallback.EXPECT().HandleDesiredStateFeedbackEvent("device", test.ActivityID, "", types.StatusIdentificationFailed, gomock.Any(), nil)
+	updateManager.Apply(ctx, test.ActivityID, test.DesiredState)
+
+	eventCallback.EXPECT().HandleDesiredStateFeedbackEvent("device", "old-activity", "", types.StatusIdentificationFailed, gomock.Any(), nil)
+	ctx = api.WithDesiredStateSigner(api.WithDesiredStateTimestamp(context.Background(), time.Now().Add(-2*time.Hour).UnixMilli()), test.SignerSubject)
+	updateManager.Apply(ctx, "old-activity", test.DesiredState)
+
+	// the rejected desired states are recorded in the activity history
+	activities := updateManager.ActivityHistory(nil).Activities
+	assert.Equal(t, 3, len(activities))
+	assert.Equal(t, "old-activity", activities[0].ActivityID)
+	assert.Equal(t, test.SignerSubject, activities[0].Signer)
+	assert.Equal(t, types.StatusIdentificationFailed, activities[0].Status)
+	assert.NotEmpty(t, activities[0].Message)
+	assert.Equal(t, test.ActivityID, activities[1].ActivityID)
+	assert.Equal(t, types.StatusIdentificationFailed, activities[1].Status)
+}
+
+func TestApplyDesiredStateRollback(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	eventCallback := mocks.NewMockUpdateManagerCallback(mockCtrl)
+	mockUpdateOrchestrator := mocks.NewMockUpdateOrchestrator(mockCtrl)
+	cfg := createTestConfig(false, false)
+	cfg.Replay = &replay.Config{AntiRollback: true}
+	domainsInventory := map[string]*types.Inventory{
+		"containers": {SoftwareNodes: []*types.SoftwareNode{{InventoryNode: types.InventoryNode{ID: "containers:app", Version: "2.0.0"}}}},
+	}
+	updateManager := createTestUpdateManager(eventCallback, map[string]api.UpdateManager{}, nil, 0, cfg, mockUpdateOrchestrator, domainsInventory, "development")
+	desiredState := &types.DesiredState{
+		Domains: []*types.Domain{{ID: "containers", Components: []*types.ComponentWithConfig{{Component: types.Component{ID: "app", Version: "1.0.0"}}}}},
+	}
+
+	eventCallback.EXPECT().HandleDesiredStateFeedbackEvent("device", test.ActivityID, "", types.StatusIdentificationFailed,
+		"rollback of component containers:app from version 2.0.0 to 1.0.0 is not allowed", nil)
+	updateManager.Apply(context.Background(), test.ActivityID, desiredState)
+
+	// a desired state rejected as rollback is not recorded by the replay guard
+	guard, err := replay.NewGuard(&replay.Config{Enabled: true, MaxAge: "1h"})
+	assert.NoError(t, err)
+	updateManager.replayGuard = guard
+	now := time.Now()
+	eventCallback.EXPECT().HandleDesiredStateFeedbackEvent("device", test.ActivityID, "", types.StatusIdentificationFailed, gomock.Any(), nil)
+	updateManager.Apply(api.WithDesiredStateTimestamp(context.Background(), now.UnixMilli()), test.ActivityID, desiredState)
+
+	desiredState.Domains[0].Components[0].Version = "3.0.0"
+	ctx := api.WithDesiredStateTimestamp(context.Background(), now.Add(-time.Second).UnixMilli())
+	mockUpdateOrchestrator.EXPECT().Apply(ctx, gomock.Any(), test.ActivityID, desiredState, eventCallback).Return(false)
+	eventCallback.EXPECT().HandleCurrentStateEvent("device", test.ActivityID, gomock.Any())
+	updateManager.Apply(ctx, test.ActivityID, desiredState)
+}
+
+// describedInventoryNode returns the main inventory node with the given key and value pairs as parameters.
+func describedInventoryNode(keyValues ...string) *types.SoftwareNode {
+	node := *test.MainInventoryNode
+	node.Parameters = []*types.KeyValuePair{}
+	for i := 0; i+1 < len(keyValues); i += 2 {
+		node.Parameters = append(node.Parameters, &types.KeyValuePair{Key: keyValues[i], Value: keyValues[i+1]})
+	}
+	return &node
+}
+
 func createTestDomainUpdateManagers(mockCtrl *gomock.Controller) map[string]api.UpdateManager {
 	domainUpdateManagers := map[string]api.UpdateManager{}
 	for i := 1; i < 4; i++ {
@@ -353,3 +515,46 @@ func createTestConfig(rebootRequired, rebootEnabled bool) *config.Config {
 		RebootEnabled: rebootEnabled,
 	}
 }
+
+func TestCompliance(t *testing.T) {
+	domainsInventory := map[string]*types.Inventory{
+		"containers": {
+			SoftwareNodes: []*types.SoftwareNode{
+				{InventoryNode: types.InventoryNode{ID: "containers:app", Version: "1.0.0"}},
+			},
+		},
+	}
+	desiredState := &types.DesiredState{
+		Domains: []*types.Domain{
+			{ID: "containers", Components: []*types.ComponentWithConfig{{Component: types.Component{ID: "app", Version: "1.0.0"}}}},
+		},
+	}
+	updateManager := createTestUpdateManager(nil, nil, nil, 0, createTestConfig(false, false), nil, domainsInventory, "development")
+
+	assert.True(t, updateManager.Compliance(desiredState).Compliant)
+
+	desiredState.Domains[0].Components[0].Config = []*types.KeyValuePair{{Key: "password", Value: "secret:app-password"}}
+	assert.True(t, updateManager.Compliance(desiredState).Compliant)
+	assert.Len(t, desiredState.Domains[0].Components[0].Config, 1)
+
+	updateManager.cfg.NamespaceNodeIDs = true
+	assert.True(t, updateManager.Compliance(desiredState).Compliant)
+
+	namespacedState := &types.DesiredState{
+		Domains: []*types.Domain{
+			{ID: "containers", Components: []*types.ComponentWithConfig{{Component: types.Component{ID: "containers/app", Version: "1.0.0"}}}},
+		},
+		Baselines: []*types.Baseline{{Title: "app", Components: []string{"containers:containers/app"}}},
+	}
+	report := updateManager.Compliance(namespacedState)
+	assert.True(t, report.Compliant)
+	assert.Equal(t, "app", report.Components[0].ID)
+	assert.Equal(t, types.ComplianceInstalled, report.Components[0].Status)
+	assert.Equal(t, "containers/app", namespacedState.Domains[0].Components[0].ID)
+	assert.Equal(t, "containers:containers/app", namespacedState.Baselines[0].Components[0])
+
+	desiredState.Domains[0].Components[0].Version = "2.0.0"
+	report = updateManager.Compliance(desiredState)
+	assert.False(t, report.Compliant)
+	assert.Equal(t, types.ComplianceVersionMismatch, report.Components[0].Status)
+}
@@ -0,0 +1,100 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package orchestration
+
+import (
+	"strings"
+
+	"github.com/eclipse-kanto/update-manager/api/types"
+	"github.com/eclipse-kanto/update-manager/logger"
+	"github.com/eclipse-kanto/update-manager/util/version"
+
+	"github.com/pkg/errors"
+)
+
+// resolveVersions replaces the version constraints of the desired components with concrete versions, so that the domain agents
+// receive the resolved versions. A constraint is resolved to the highest of the available versions of the component, which satisfies it,
+// or, if no versions are available, to the installed version, if it satisfies the constraint.
+func (updateManager *aggregatedUpdateManager) resolveVersions(desiredState *types.DesiredState) error {
+	if desiredState == nil {
+		return nil
+	}
+	updateManager.eventLock.Lock()
+	defer updateManager.eventLock.Unlock()
+
+	for _, domain := range desiredState.Domains {
+		if domain == nil {
+			continue
+		}
+		for _, component := range domain.Components {
+			if component == nil || !version.IsConstraint(component.Version) {
+				continue
+			}
+			resolved, err := resolveVersion(domain, component, installedVersion(updateManager.domainsInventory[domain.ID], domain.ID+":"+component.ID))
+			if err != nil {
+				return err
+			}
+			logger.Debug("[%s] resolved version constraint '%s' of component %s to version %s", domain.ID, component.Version, component.ID, resolved)
+			component.Version = resolved
+			component.Config = withoutKey(component.Config, types.AvailableVersionsKey)
+		}
+	}
+	return nil
+}
+
+func resolveVersion(domain *types.Domain, component *types.ComponentWithConfig, installed string) (string, error) {
+	scheme, _ := version.ParseScheme(domain.VersionScheme(component))
+	constraint, err := version.ParseConstraint(component.Version, scheme)
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid version of component %s:%s", domain.ID, component.ID)
+	}
+	var available []string
+	for _, pair := range component.Config {
+		if pair != nil && pair.Key == types.AvailableVersionsKey {
+			for _, availableVersion := range strings.Split(pair.Value, ",") {
+				if availableVersion = strings.TrimSpace(availableVersion); availableVersion != "" {
+					available = append(available, availableVersion)
+				}
+			}
+		}
+	}
+	if len(available) == 0 && installed != "" {
+		available = []string{installed}
+	}
+	if resolved, ok := constraint.Resolve(available...); ok {
+		return resolved, nil
+	}
+	return "", errors.Errorf("no version of component %s:%s satisfies the version constraint '%s'", domain.ID, component.ID, constraint)
+}
+
+func installedVersion(inventory *types.Inventory, id string) string {
+	if inventory == nil {
+		return ""
+	}
+	for _, node := range inventory.SoftwareNodes {
+		if node != nil && node.ID == id {
+			return node.Version
+		}
+	}
+	return ""
+}
+
+func withoutKey(config []*types.KeyValuePair, key string) []*types.KeyValuePair {
+	var result []*types.KeyValuePair
+	for _, pair := range config {
+		if pair != nil && pair.Key != key {
+			result = append(result, pair)
+		}
+	}
+	return result
+}
@@ -0,0 +1,95 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package orchestration
+
+import (
+	"context"
+	"testing"
+
+	"github.com/eclipse-kanto/update-manager/api"
+	"github.com/eclipse-kanto/update-manager/api/types"
+	"github.com/eclipse-kanto/update-manager/test"
+	"github.com/eclipse-kanto/update-manager/test/mocks"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveVersions(t *testing.T) {
+	domainsInventory := map[string]*types.Inventory{
+		"containers": {SoftwareNodes: []*types.SoftwareNode{{InventoryNode: types.InventoryNode{ID: "containers:app", Version: "1.5.0"}}}},
+	}
+	updateManager := createTestUpdateManager(nil, nil, nil, 0, createTestConfig(false, false), nil, domainsInventory, "development")
+	desiredState := func(version string, config ...*types.KeyValuePair) *types.DesiredState {
+		return &types.DesiredState{
+			Domains: []*types.Domain{{ID: "containers", Components: []*types.ComponentWithConfig{
+				{Component: types.Component{ID: "app", Version: version}, Config: config},
+				{Component: types.Component{ID: "db", Version: "1.0.0"}},
+			}}},
+		}
+	}
+	available := &types.KeyValuePair{Key: types.AvailableVersionsKey, Value: "1.1.0, 1.9.0,1.10.0,2.0.0"}
+	port := &types.KeyValuePair{Key: "port", Value: "8080"}
+
+	t.Run("test_resolve_available_version", func(t *testing.T) {
+		state := desiredState(">=1.2 <2", available, port)
+		assert.NoError(t, updateManager.resolveVersions(state))
+		assert.Equal(t, desiredState("1.10.0", port), state)
+	})
+	t.Run("test_resolve_installed_version", func(t *testing.T) {
+		state := desiredState(">=1.2 <2")
+		assert.NoError(t, updateManager.resolveVersions(state))
+		assert.Equal(t, desiredState("1.5.0"), state)
+	})
+	t.Run("test_concrete_version", func(t *testing.T) {
+		state := desiredState("1.0.0", available)
+		assert.NoError(t, updateManager.resolveVersions(state))
+		assert.Equal(t, desiredState("1.0.0", available), state)
+	})
+	t.Run("test_no_satisfying_version", func(t *testing.T) {
+		assert.EqualError(t, updateManager.resolveVersions(desiredState(">=2.1", available)),
+			"no version of component containers:app satisfies the version constraint '>=2.1'")
+		assert.EqualError(t, updateManager.resolveVersions(desiredState(">=2.1")),
+			"no version of component containers:app satisfies the version constraint '>=2.1'")
+	})
+	t.Run("test_invalid_constraint", func(t *testing.T) {
+		assert.EqualError(t, updateManager.resolveVersions(desiredState("=>1.0")),
+			"invalid version of component containers:app: invalid operator '=>' in version constraint '=>1.0'")
+	})
+}
+
+func TestApplyDesiredStateVersionConstraint(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	eventCallback := mocks.NewMockUpdateManagerCallback(mockCtrl)
+	mockUpdateOrchestrator := mocks.NewMockUpdateOrchestrator(mockCtrl)
+	domainsInventory := map[string]*types.Inventory{
+		"containers": {SoftwareNodes: []*types.SoftwareNode{{InventoryNode: types.InventoryNode{ID: "containers:app", Version: "1.0.0"}}}},
+	}
+	domainUpdateManagers := map[string]api.UpdateManager{}
+	updateManager := createTestUpdateManager(eventCallback, domainUpdateManagers, nil, 0, createTestConfig(false, false), mockUpdateOrchestrator, domainsInventory, "development")
+	desiredState := func(version string) *types.DesiredState {
+		return &types.DesiredState{
+			Domains: []*types.Domain{{ID: "containers", Components: []*types.ComponentWithConfig{{Component: types.Component{ID: "app", Version: version}}}}},
+		}
+	}
+
+	mockUpdateOrchestrator.EXPECT().Apply(context.Background(), domainUpdateManagers, test.ActivityID, desiredState("1.0.0"), eventCallback).Return(false)
+	eventCallback.EXPECT().HandleCurrentStateEvent("device", test.ActivityID, gomock.Any())
+	updateManager.Apply(context.Background(), test.ActivityID, desiredState("<2"))
+
+	eventCallback.EXPECT().HandleDesiredStateFeedbackEvent("device", test.ActivityID, "", types.StatusIdentificationFailed,
+		"no version of component containers:app satisfies the version constraint '>=2'", nil)
+	updateManager.Apply(context.Background(), test.ActivityID, desiredState(">=2"))
+}
@@ -23,6 +23,7 @@ import (
 
 type updateOperation struct {
 	activityID string
+	phase      types.CommandType
 
 	statusLock    sync.Mutex
 	status        types.StatusType
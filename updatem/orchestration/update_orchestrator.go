@@ -22,11 +22,13 @@ import (
 	"github.com/eclipse-kanto/update-manager/api/util"
 	"github.com/eclipse-kanto/update-manager/config"
 	"github.com/eclipse-kanto/update-manager/logger"
+	"github.com/eclipse-kanto/update-manager/updatem/history"
 )
 
 type updateOrchestrator struct {
 	operationLock sync.Mutex
 	actionsLock   sync.Mutex
+	activityLock  sync.Mutex
 
 	cfg                 *config.Config
 	phaseTimeout        time.Duration
@@ -34,6 +36,11 @@ type updateOrchestrator struct {
 	ownerConsentClient  api.OwnerConsentClient
 
 	operation *updateOperation
+	activity  *types.ActivityStatus
+
+	history *history.Recorder
+	// connection pauses the phase timeout while the connection to the MQTT broker is not established, if set
+	connection api.ConnectionStatusProvider
 }
 
 func (orchestrator *updateOrchestrator) Name() string {
@@ -51,6 +58,19 @@ func NewUpdateOrchestrator(cfg *config.Config, ownerApprovalClient api.OwnerCons
 	return ua
 }
 
+// setConfig applies the reloaded configuration, it must not be called while an update activity is in progress.
+func (orchestrator *updateOrchestrator) setConfig(cfg *config.Config) {
+	if orchestrator.ownerConsentClient == nil && len(cfg.OwnerConsentCommands) > 0 {
+		logger.Warn("owner consent commands are ignored until restart, as the owner consent client is not started")
+		withoutConsent := *cfg
+		withoutConsent.OwnerConsentCommands = nil
+		cfg = &withoutConsent
+	}
+	orchestrator.cfg = cfg
+	orchestrator.phaseTimeout = util.ParseDuration("phase-timeout", cfg.PhaseTimeout, 10*time.Minute, 10*time.Minute)
+	orchestrator.ownerConsentTimeout = util.ParseDuration("owner-consent-timeout", cfg.OwnerConsentTimeout, 30*time.Minute, 30*time.Minute)
+}
+
 // Apply is called by the update manager.
 // It triggers the update process with the given activity ID and desired state specification and orchestrates the process on the given domain update agents.
 // The method returns true if reboot is required after the operation is complete.
@@ -73,23 +93,62 @@ func (orchestrator *updateOrchestrator) Apply(ctx context.Context, domainAgents
 		orchestrator.disposeUpdateOperation()
 	}()
 
+	log := logger.WithFields(logger.Fields{ActivityID: activityID})
 	if err := orchestrator.setupUpdateOperation(domainAgents, activityID, desiredState, desiredStateCallback); err != nil {
-		logger.Error(err.Error())
+		log.Error(err.Error())
 		applyErr = err
 		return false
 	}
 
 	rebootRequired, applyErr := orchestrator.apply(ctx)
 	if applyErr != nil {
-		logger.Error("failed to apply '%s' desired state: %v", activityID, applyErr)
+		log.Error("failed to apply '%s' desired state: %v", activityID, applyErr)
 	}
 	return rebootRequired
 }
 
 func (orchestrator *updateOrchestrator) HandleOwnerConsentFeedback(activityID string, timestamp int64, consent *types.OwnerConsentFeedback) error {
 	if orchestrator.operation != nil && activityID == orchestrator.operation.activityID {
-		logger.Info("owner consent received with status: %v, timestamp: %d", consent.Status, timestamp)
+		logger.WithFields(logger.Fields{ActivityID: activityID}).Info("owner consent received with status: %v, timestamp: %d", consent.Status, timestamp)
+		orchestrator.history.ConsentReceived(activityID, consent)
 		orchestrator.operation.ownerConsented <- consent.Status == types.StatusApproved
 	}
 	return nil
 }
+
+// ActivityStatus returns a snapshot of the status of the update activity in progress or nil if there is no such activity.
+func (orchestrator *updateOrchestrator) ActivityStatus() *types.ActivityStatus {
+	orchestrator.activityLock.Lock()
+	defer orchestrator.activityLock.Unlock()
+
+	if orchestrator.activity == nil {
+		return nil
+	}
+	activity := *orchestrator.activity
+	return &activity
+}
+
+// refreshActivityStatus updates the activity status snapshot, the operation lock must be held by the caller.
+func (orchestrator *updateOrchestrator) refreshActivityStatus() {
+	var activity *types.ActivityStatus
+	if operation := orchestrator.operation; operation != nil && operation.activityID != "" {
+		operation.statusLock.Lock()
+		status := operation.status
+		operation.statusLock.Unlock()
+
+		domains := make(map[string]types.StatusType, len(operation.domains))
+		for domain, domainStatus := range operation.domains {
+			domains[domain] = domainStatus
+		}
+		activity = &types.ActivityStatus{
+			ActivityID: operation.activityID,
+			Status:     util.FixIncompleteInconsistentStatus(status),
+			Domains:    domains,
+			Actions:    orchestrator.toActionsList(),
+		}
+	}
+
+	orchestrator.activityLock.Lock()
+	defer orchestrator.activityLock.Unlock()
+	orchestrator.activity = activity
+}
@@ -73,8 +73,11 @@ func (orchestrator *updateOrchestrator) waitCommandSignal(ctx context.Context, c
 }
 
 func (orchestrator *updateOrchestrator) waitSignal(ctx context.Context, signal chan bool) (bool, bool, bool, error) {
+	timer := newPhaseTimer(orchestrator.phaseTimeout, orchestrator.connection)
+	defer timer.Stop()
+
 	select {
-	case <-time.After(orchestrator.phaseTimeout):
+	case <-timer.C:
 		return false, false, true, fmt.Errorf("not received in %v", orchestrator.phaseTimeout)
 	case <-orchestrator.operation.errChan:
 		return false, false, false, fmt.Errorf(orchestrator.operation.errMsg)
@@ -109,6 +112,7 @@ func handleCommandSignal(ctx context.Context, command types.CommandType, orchest
 		orchestrator.operation.rollbackChan <- true
 	}
 
+	orchestrator.operation.phase = command
 	executeCommand := func(statuses ...types.StatusType) {
 		for domain, domainStatus := range orchestrator.operation.domains {
 			if util.Contains(statuses, domainStatus) {
@@ -129,7 +133,7 @@ func handleCommandSignal(ctx context.Context, command types.CommandType, orchest
 	case types.CommandRollback:
 		executeCommand(types.BaselineStatusDownloadSuccess, types.BaselineStatusUpdateSuccess)
 	default:
-		logger.Error("unknown command %s", command)
+		logger.WithFields(logger.Fields{ActivityID: orchestrator.operation.activityID, Phase: string(command)}).Error("unknown command")
 	}
 }
 
@@ -151,13 +155,14 @@ func (orchestrator *updateOrchestrator) getOwnerConsent(ctx context.Context, com
 	}
 	defer func() {
 		if err := orchestrator.ownerConsentClient.Stop(); err != nil {
-			logger.Error("failed to stop owner consent client: %v", err)
+			logger.WithFields(logger.Fields{ActivityID: orchestrator.operation.activityID, Phase: string(command)}).ErrorErr(err, "failed to stop owner consent client")
 		}
 	}()
 
 	if err := orchestrator.ownerConsentClient.SendOwnerConsent(orchestrator.operation.activityID, &types.OwnerConsent{Command: command}); err != nil {
 		return err
 	}
+	orchestrator.history.ConsentRequested(orchestrator.operation.activityID, command)
 
 	select {
 	case approved := <-orchestrator.operation.ownerConsented:
@@ -206,6 +211,7 @@ func (orchestrator *updateOrchestrator) setupUpdateOperation(domainAgents map[st
 		return err
 	}
 	orchestrator.operation = operation
+	orchestrator.refreshActivityStatus()
 	return nil
 }
 
@@ -213,4 +219,5 @@ func (orchestrator *updateOrchestrator) disposeUpdateOperation() {
 	orchestrator.operationLock.Lock()
 	defer orchestrator.operationLock.Unlock()
 	orchestrator.operation = nil
+	orchestrator.refreshActivityStatus()
 }
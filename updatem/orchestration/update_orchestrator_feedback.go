@@ -52,28 +52,30 @@ func (orchestrator *updateOrchestrator) HandleDesiredStateFeedbackEvent(domain,
 	if !orchestrator.validateActivity(domain, activityID) {
 		return
 	}
+	log := logger.WithFields(logger.Fields{Domain: domain, ActivityID: activityID, Phase: string(orchestrator.operation.phase)})
 
 	orchestrator.updateActions(domain, actions)
 
 	if handler, ok := statusHandlers[status]; !ok {
-		logger.Warn("received desired state feedback event for baseline [%s] and domain [%s] with unsupported status '%s'", baseline, domain, status)
+		log.Warn("received desired state feedback event for baseline [%s] with unsupported status '%s'", baseline, status)
 	} else {
 		handler(orchestrator, domain, message, actions)
 	}
-
+	orchestrator.refreshActivityStatus()
 }
 
 func (orchestrator *updateOrchestrator) validateActivity(domain, activityID string) bool {
+	log := logger.WithFields(logger.Fields{Domain: domain, ActivityID: activityID})
 	if orchestrator.operation == nil {
-		logger.Warn("received desired state feedback event for domain [%s], but there is no active update operation", domain)
+		log.Warn("received desired state feedback event, but there is no active update operation")
 		return false
 	}
 	if orchestrator.operation.activityID != activityID {
-		logger.Warn("activity id mismatch for received desired state feedback event for domain [%s]  - expecting %s, received %s", domain, orchestrator.operation.activityID, activityID)
+		log.Warn("activity id mismatch for received desired state feedback event - expecting %s, received %s", orchestrator.operation.activityID, activityID)
 		return false
 	}
 	if _, ok := orchestrator.operation.domains[domain]; !ok {
-		logger.Warn("received desired state feedback event for unexpected domain [%s]", domain)
+		log.Warn("received desired state feedback event for unexpected domain")
 		return false
 	}
 	return true
@@ -403,11 +405,11 @@ func (orchestrator *updateOrchestrator) checkIdentificationStatus(domain string)
 	}
 	domainUpdateStatus := orchestrator.operation.domains[domain]
 	if domainUpdateStatus == types.StatusIdentified {
-		logger.Warn("update for domain [%s] has already identified", domain)
+		logger.WithFields(logger.Fields{Domain: domain, ActivityID: orchestrator.operation.activityID}).Warn("update has already identified")
 		return false
 	}
 	if domainUpdateStatus == types.StatusIdentificationFailed {
-		logger.Warn("update for domain [%s] has already failed identification", domain)
+		logger.WithFields(logger.Fields{Domain: domain, ActivityID: orchestrator.operation.activityID}).Warn("update has already failed identification")
 		return false
 	}
 	return true
@@ -440,6 +442,8 @@ func (orchestrator *updateOrchestrator) toActionsList() []*types.Action {
 }
 
 func (orchestrator *updateOrchestrator) notifyFeedback(status types.StatusType, message string) {
+	status = util.FixIncompleteInconsistentStatus(status)
+	orchestrator.history.StatusChanged(orchestrator.operation.activityID, status, message)
 	orchestrator.operation.desiredStateCallback.HandleDesiredStateFeedbackEvent(
-		orchestrator.Name(), orchestrator.operation.activityID, "", util.FixIncompleteInconsistentStatus(status), message, orchestrator.toActionsList())
+		orchestrator.Name(), orchestrator.operation.activityID, "", status, message, orchestrator.toActionsList())
 }
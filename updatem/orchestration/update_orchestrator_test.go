@@ -123,6 +123,32 @@ func TestHandleOwnerConsent(t *testing.T) {
 	})
 }
 
+func TestUpdOrchActivityStatus(t *testing.T) {
+	updateOrchestrator := &updateOrchestrator{}
+	assert.Nil(t, updateOrchestrator.ActivityStatus())
+
+	action := &types.Action{Component: &types.Component{ID: "testComponent"}, Status: types.ActionStatusUpdating}
+	updateOrchestrator.operation = &updateOperation{
+		activityID: test.ActivityID,
+		status:     types.StatusRunning,
+		domains:    map[string]types.StatusType{"domain1": types.StatusRunning},
+		actions:    map[string]map[string]*types.Action{"domain1": {"testComponent": action}},
+	}
+	updateOrchestrator.refreshActivityStatus()
+	assert.Equal(t, &types.ActivityStatus{
+		ActivityID: test.ActivityID,
+		Status:     types.StatusRunning,
+		Domains:    map[string]types.StatusType{"domain1": types.StatusRunning},
+		Actions:    []*types.Action{action},
+	}, updateOrchestrator.ActivityStatus())
+
+	updateOrchestrator.operation.domains["domain1"] = types.StatusCompleted
+	assert.Equal(t, types.StatusRunning, updateOrchestrator.ActivityStatus().Domains["domain1"])
+
+	updateOrchestrator.disposeUpdateOperation()
+	assert.Nil(t, updateOrchestrator.ActivityStatus())
+}
+
 func applyDesiredState(ctx context.Context, updOrch *updateOrchestrator, done chan bool, domainAgents map[string]api.UpdateManager, activityID string, desiredState *types.DesiredState, apiDesState api.DesiredStateFeedbackHandler) {
 	updOrch.Apply(ctx, domainAgents, activityID, desiredState, apiDesState)
 	done <- true
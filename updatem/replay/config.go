@@ -0,0 +1,38 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package replay
+
+const (
+	// default replay protection config
+	defaultMaxAge      = "24h"
+	defaultFile        = ""
+	defaultActivityIDs = 1000
+)
+
+// Config represents the replay and rollback protection config
+type Config struct {
+	Enabled      bool   `json:"enabled"`
+	MaxAge       string `json:"maxAge,omitempty"`
+	File         string `json:"file,omitempty"`
+	ActivityIDs  int    `json:"activityIds,omitempty"`
+	AntiRollback bool   `json:"antiRollback"`
+}
+
+// NewDefaultConfig returns a default replay protection config instance, both the replay and the rollback protection are disabled by default
+func NewDefaultConfig() *Config {
+	return &Config{
+		MaxAge:      defaultMaxAge,
+		File:        defaultFile,
+		ActivityIDs: defaultActivityIDs,
+	}
+}
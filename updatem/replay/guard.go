@@ -0,0 +1,166 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package replay
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/eclipse-kanto/update-manager/api/util"
+	"github.com/eclipse-kanto/update-manager/logger"
+
+	"github.com/pkg/errors"
+)
+
+// allowedClockSkew is the tolerance for timestamps in the future, which covers clock differences between the backend and the device.
+const allowedClockSkew = 5 * time.Minute
+
+type state struct {
+	LastTimestamp int64    `json:"lastTimestamp"`
+	ActivityIDs   []string `json:"activityIds"`
+}
+
+// Guard rejects replayed desired states, based on the timestamp and the activity ID of their envelope.
+// All methods are safe to be called on a nil guard, in which case all desired states are accepted.
+type Guard struct {
+	lock        sync.Mutex
+	maxAge      time.Duration
+	file        string
+	activityIDs int
+	state       *state
+	seen        map[string]bool
+}
+
+// NewGuard creates a new replay guard with the given configuration, loading the persisted state, if any.
+// A nil guard is returned, if the replay protection is disabled.
+func NewGuard(config *Config) (*Guard, error) {
+	if config == nil || !config.Enabled {
+		return nil, nil
+	}
+	guard := &Guard{
+		maxAge:      util.ParseDuration("replay-max-age", config.MaxAge, 24*time.Hour, 0),
+		file:        config.File,
+		activityIDs: config.ActivityIDs,
+		state:       &state{},
+		seen:        map[string]bool{},
+	}
+	if guard.activityIDs <= 0 {
+		guard.activityIDs = defaultActivityIDs
+	}
+	if err := guard.load(); err != nil {
+		return nil, err
+	}
+	return guard, nil
+}
+
+// Check checks if a desired state with the given activity ID and envelope timestamp in milliseconds is not a replay.
+// Desired states are rejected, if older than the configured max age, older than the last accepted one or if their activity ID
+// has already been seen. The desired state is not recorded, so that it can still be rejected by further checks.
+func (guard *Guard) Check(activityID string, timestamp int64) error {
+	if guard == nil {
+		return nil
+	}
+	guard.lock.Lock()
+	defer guard.lock.Unlock()
+
+	return guard.check(activityID, timestamp)
+}
+
+// Record records a desired state with the given activity ID and envelope timestamp in milliseconds as the last accepted one,
+// once it has passed all checks. The desired state is checked again, so that it is rejected if another desired state with
+// the same activity ID or a newer timestamp has been recorded in the meantime.
+func (guard *Guard) Record(activityID string, timestamp int64) error {
+	if guard == nil {
+		return nil
+	}
+	guard.lock.Lock()
+	defer guard.lock.Unlock()
+
+	if err := guard.check(activityID, timestamp); err != nil {
+		return err
+	}
+	guard.state.LastTimestamp = timestamp
+	guard.state.ActivityIDs = append(guard.state.ActivityIDs, activityID)
+	guard.seen[activityID] = true
+	if len(guard.state.ActivityIDs) > guard.activityIDs {
+		delete(guard.seen, guard.state.ActivityIDs[0])
+		guard.state.ActivityIDs = guard.state.ActivityIDs[1:]
+	}
+	if err := guard.save(); err != nil {
+		logger.ErrorErr(err, "cannot persist the replay protection state")
+	}
+	return nil
+}
+
+func (guard *Guard) check(activityID string, timestamp int64) error {
+	now := time.Now()
+	sent := time.UnixMilli(timestamp)
+	if guard.maxAge > 0 && now.Sub(sent) > guard.maxAge {
+		return errors.Errorf("desired state timestamp %d is older than the max age of %v", timestamp, guard.maxAge)
+	}
+	if sent.Sub(now) > allowedClockSkew {
+		return errors.Errorf("desired state timestamp %d is in the future", timestamp)
+	}
+	if timestamp < guard.state.LastTimestamp {
+		return errors.Errorf("desired state timestamp %d is older than the last accepted one %d", timestamp, guard.state.LastTimestamp)
+	}
+	if guard.seen[activityID] {
+		return errors.Errorf("desired state with activity ID %s has already been received", activityID)
+	}
+	return nil
+}
+
+func (guard *Guard) load() error {
+	if guard.file == "" {
+		return nil
+	}
+	data, err := os.ReadFile(guard.file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return errors.Wrap(err, "cannot load the replay protection state")
+	}
+	if err := json.Unmarshal(data, guard.state); err != nil {
+		return errors.Wrapf(err, "invalid replay protection state in file '%s'", guard.file)
+	}
+	if len(guard.state.ActivityIDs) > guard.activityIDs {
+		guard.state.ActivityIDs = guard.state.ActivityIDs[len(guard.state.ActivityIDs)-guard.activityIDs:]
+	}
+	for _, activityID := range guard.state.ActivityIDs {
+		guard.seen[activityID] = true
+	}
+	return nil
+}
+
+// save writes the state into a temporary file and renames it afterwards, so that the persisted state is never partially written.
+func (guard *Guard) save() error {
+	if guard.file == "" {
+		return nil
+	}
+	data, err := json.Marshal(guard.state)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(guard.file), 0755); err != nil {
+		return err
+	}
+	tmpFile := guard.file + ".tmp"
+	if err := os.WriteFile(tmpFile, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpFile, guard.file)
+}
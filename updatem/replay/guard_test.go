@@ -0,0 +1,99 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package replay
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/eclipse-kanto/update-manager/test"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewGuard(t *testing.T) {
+	guard, err := NewGuard(nil)
+	assert.NoError(t, err)
+	assert.Nil(t, guard)
+	assert.NoError(t, guard.Check(test.ActivityID, 0))
+	assert.NoError(t, guard.Record(test.ActivityID, 0))
+
+	guard, err = NewGuard(NewDefaultConfig())
+	assert.NoError(t, err)
+	assert.Nil(t, guard)
+
+	file := filepath.Join(t.TempDir(), "replay.json")
+	assert.NoError(t, os.WriteFile(file, []byte("invalid"), 0644))
+	_, err = NewGuard(&Config{Enabled: true, File: file})
+	assert.Error(t, err)
+}
+
+func TestGuardRecord(t *testing.T) {
+	guard, err := NewGuard(&Config{Enabled: true, MaxAge: "1h", ActivityIDs: 2})
+	assert.NoError(t, err)
+
+	now := time.Now()
+	assert.NoError(t, guard.Record("activity-1", now.Add(-time.Minute).UnixMilli()))
+	assert.NoError(t, guard.Record("activity-2", now.UnixMilli()))
+	assert.NoError(t, guard.Record("activity-3", now.UnixMilli()))
+
+	tests := map[string]struct {
+		activityID string
+		timestamp  int64
+	}{
+		"test_accept_too_old":         {activityID: "activity-4", timestamp: now.Add(-2 * time.Hour).UnixMilli()},
+		"test_accept_in_future":       {activityID: "activity-4", timestamp: now.Add(time.Hour).UnixMilli()},
+		"test_accept_older_than_last": {activityID: "activity-4", timestamp: now.Add(-time.Minute).UnixMilli()},
+		"test_accept_seen_activity":   {activityID: "activity-3", timestamp: now.UnixMilli()},
+		"test_accept_zero_timestamp":  {activityID: "activity-4", timestamp: 0},
+	}
+	for name, testCase := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Error(t, guard.Check(testCase.activityID, testCase.timestamp))
+			assert.Error(t, guard.Record(testCase.activityID, testCase.timestamp))
+		})
+	}
+
+	// only the last 2 activity IDs are remembered
+	assert.NoError(t, guard.Record("activity-1", now.UnixMilli()))
+}
+
+func TestGuardCheck(t *testing.T) {
+	guard, err := NewGuard(&Config{Enabled: true, MaxAge: "1h"})
+	assert.NoError(t, err)
+
+	now := time.Now()
+	assert.NoError(t, guard.Check("activity-1", now.UnixMilli()))
+	// a checked desired state is not recorded
+	assert.NoError(t, guard.Check("activity-1", now.UnixMilli()))
+	assert.NoError(t, guard.Check("activity-2", now.Add(-time.Minute).UnixMilli()))
+
+	assert.NoError(t, guard.Record("activity-1", now.UnixMilli()))
+	assert.Error(t, guard.Check("activity-1", now.UnixMilli()))
+	assert.Error(t, guard.Check("activity-2", now.Add(-time.Minute).UnixMilli()))
+}
+
+func TestGuardPersistence(t *testing.T) {
+	config := &Config{Enabled: true, MaxAge: "0", File: filepath.Join(t.TempDir(), "replay", "replay.json")}
+	guard, err := NewGuard(config)
+	assert.NoError(t, err)
+	assert.NoError(t, guard.Record(test.ActivityID, 1000))
+
+	guard, err = NewGuard(config)
+	assert.NoError(t, err)
+	assert.Error(t, guard.Record(test.ActivityID, 2000))
+	assert.Error(t, guard.Record("other", 500))
+	assert.NoError(t, guard.Record("other", 1000))
+}
@@ -0,0 +1,71 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package replay
+
+import (
+	"strconv"
+
+	"github.com/eclipse-kanto/update-manager/api/types"
+	"github.com/eclipse-kanto/update-manager/util/version"
+
+	"github.com/pkg/errors"
+)
+
+// allowRollbackKey is the key of the domain or component configuration, which explicitly allows installing a lower version.
+const allowRollbackKey = "allowRollback"
+
+// CheckRollback returns an error, if the desired state contains a component with a version lower than the installed one,
+// unless the rollback is explicitly allowed for the domain or for the component. The installed components are looked up
+// in the inventory of the respective domain by ID in the format <domain>:<component>. The versions are compared according to
+// the version scheme of the component, the unresolved version constraints are not checked.
+func CheckRollback(desiredState *types.DesiredState, domainsInventory map[string]*types.Inventory) error {
+	for _, domain := range desiredState.Domains {
+		inventory := domainsInventory[domain.ID]
+		if inventory == nil || isRollbackAllowed(domain.Config) {
+			continue
+		}
+		for _, component := range domain.Components {
+			if component.Version == "" || version.IsConstraint(component.Version) || isRollbackAllowed(component.Config) {
+				continue
+			}
+			installed := findSoftwareNode(inventory, domain.ID+":"+component.ID)
+			if installed == nil || installed.Version == "" {
+				continue
+			}
+			scheme, _ := version.ParseScheme(domain.VersionScheme(component))
+			if version.CompareWith(scheme, component.Version, installed.Version) < 0 {
+				return errors.Errorf("rollback of component %s:%s from version %s to %s is not allowed", domain.ID, component.ID, installed.Version, component.Version)
+			}
+		}
+	}
+	return nil
+}
+
+func isRollbackAllowed(config []*types.KeyValuePair) bool {
+	for _, pair := range config {
+		if pair.Key == allowRollbackKey {
+			allowed, _ := strconv.ParseBool(pair.Value)
+			return allowed
+		}
+	}
+	return false
+}
+
+func findSoftwareNode(inventory *types.Inventory, id string) *types.SoftwareNode {
+	for _, node := range inventory.SoftwareNodes {
+		if node.ID == id {
+			return node
+		}
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package replay
+
+import (
+	"testing"
+
+	"github.com/eclipse-kanto/update-manager/api/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckRollback(t *testing.T) {
+	inventory := map[string]*types.Inventory{
+		"containers": {
+			SoftwareNodes: []*types.SoftwareNode{
+				{InventoryNode: types.InventoryNode{ID: "containers:app", Version: "1.10.0"}},
+				{InventoryNode: types.InventoryNode{ID: "containers:db"}},
+			},
+		},
+	}
+	desiredState := func(version string, domainConfig, componentConfig []*types.KeyValuePair) *types.DesiredState {
+		return &types.DesiredState{
+			Domains: []*types.Domain{
+				{ID: "containers", Config: domainConfig, Components: []*types.ComponentWithConfig{
+					{Component: types.Component{ID: "app", Version: version}, Config: componentConfig},
+					{Component: types.Component{ID: "db", Version: "1.0.0"}},
+					{Component: types.Component{ID: "new", Version: "0.1.0"}},
+				}},
+				{ID: "self-update", Components: []*types.ComponentWithConfig{{Component: types.Component{ID: "os", Version: "0.1"}}}},
+			},
+		}
+	}
+	allowed := []*types.KeyValuePair{{Key: "allowRollback", Value: "true"}}
+
+	assert.NoError(t, CheckRollback(desiredState("1.10.0", nil, nil), inventory))
+	assert.NoError(t, CheckRollback(desiredState("1.11.0", nil, nil), inventory))
+	assert.NoError(t, CheckRollback(desiredState("", nil, nil), inventory))
+	assert.EqualError(t, CheckRollback(desiredState("1.9.0", nil, nil), inventory), "rollback of component containers:app from version 1.10.0 to 1.9.0 is not allowed")
+	assert.NoError(t, CheckRollback(desiredState("1.9.0", allowed, nil), inventory))
+	assert.NoError(t, CheckRollback(desiredState("1.9.0", nil, allowed), inventory))
+	assert.Error(t, CheckRollback(desiredState("1.9.0", nil, []*types.KeyValuePair{{Key: "allowRollback", Value: "false"}}), inventory))
+	assert.NoError(t, CheckRollback(desiredState(">=1.0", nil, nil), inventory))
+	assert.Error(t, CheckRollback(desiredState("1.10.0~rc1", []*types.KeyValuePair{{Key: "versionScheme", Value: "deb"}}, nil), inventory))
+	assert.NoError(t, CheckRollback(desiredState("1.10.0-1", []*types.KeyValuePair{{Key: "versionScheme", Value: "deb"}}, nil), inventory))
+}
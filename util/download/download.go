@@ -0,0 +1,190 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package download
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// PartSuffix is the suffix of the file, which holds the partially downloaded artifact until it is complete and verified.
+const PartSuffix = ".part"
+
+var sha256Pattern = regexp.MustCompile(`^[a-fA-F0-9]{64}$`)
+
+// Artifact defines an artifact to be downloaded.
+type Artifact struct {
+	// URL is the HTTP or HTTPS address of the artifact
+	URL string
+	// Size is the expected size of the artifact in bytes, not checked if zero
+	Size int64
+	// SHA256 is the expected hex encoded SHA-256 checksum of the artifact
+	SHA256 string
+}
+
+// Validate returns an error if the artifact has no valid HTTP or HTTPS URL, a negative size or no valid SHA-256 checksum.
+func (artifact *Artifact) Validate() error {
+	if !strings.HasPrefix(artifact.URL, "http://") && !strings.HasPrefix(artifact.URL, "https://") {
+		return errors.Errorf("invalid artifact URL '%s', an HTTP or HTTPS URL is expected", artifact.URL)
+	}
+	if artifact.Size < 0 {
+		return errors.Errorf("invalid artifact size %d", artifact.Size)
+	}
+	if !sha256Pattern.MatchString(artifact.SHA256) {
+		return errors.Errorf("invalid artifact SHA-256 checksum '%s'", artifact.SHA256)
+	}
+	return nil
+}
+
+// Download downloads the given artifact to the given file using the given HTTP client. The artifact is first written to the file with
+// the PartSuffix, so that an interrupted download is resumed with an HTTP range request, if supported by the server. The file is
+// created only if the size and the checksum of the downloaded artifact match, otherwise the partial file is removed and an error is returned.
+// The progress function, if any, receives the number of downloaded bytes and the total size, if known, or zero.
+func Download(ctx context.Context, client *http.Client, artifact *Artifact, file string, progress func(downloaded, total int64)) error {
+	if err := artifact.Validate(); err != nil {
+		return err
+	}
+	part := file + PartSuffix
+	offset := int64(0)
+	if info, err := os.Stat(part); err == nil {
+		offset = info.Size()
+	}
+	if artifact.Size > 0 && offset >= artifact.Size {
+		// the partial file is either complete or invalid, the verification decides
+		return complete(artifact, part, file)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, artifact.URL, nil)
+	if err != nil {
+		return errors.Wrap(err, "invalid artifact request")
+	}
+	if offset > 0 {
+		request.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+	response, err := client.Do(request)
+	if err != nil {
+		return errors.Wrap(err, "cannot download artifact")
+	}
+	defer response.Body.Close()
+
+	flags := os.O_CREATE | os.O_WRONLY
+	switch response.StatusCode {
+	case http.StatusOK:
+		offset = 0
+		flags |= os.O_TRUNC
+	case http.StatusPartialContent:
+		if start, ok := rangeStart(response.Header.Get("Content-Range")); !ok || start != offset {
+			return errors.Errorf("unexpected content range '%s' of artifact download", response.Header.Get("Content-Range"))
+		}
+		flags |= os.O_APPEND
+	case http.StatusRequestedRangeNotSatisfiable:
+		return complete(artifact, part, file)
+	default:
+		return errors.Errorf("cannot download artifact, unexpected HTTP status %s", response.Status)
+	}
+
+	out, err := os.OpenFile(part, flags, 0644)
+	if err != nil {
+		return errors.Wrap(err, "cannot create artifact file")
+	}
+	total := artifact.Size
+	if total == 0 && response.ContentLength > 0 {
+		total = offset + response.ContentLength
+	}
+	writer := io.Writer(out)
+	if progress != nil {
+		writer = &progressWriter{writer: out, downloaded: offset, total: total, progress: progress}
+		progress(offset, total)
+	}
+	body := io.Reader(response.Body)
+	if artifact.Size > 0 {
+		// a single byte more than expected is read, so that a larger artifact is detected without downloading it completely
+		body = io.LimitReader(response.Body, artifact.Size-offset+1)
+	}
+	written, err := io.Copy(writer, body)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		// the partial file is kept, so that the download is resumed next time
+		return errors.Wrap(err, "artifact download interrupted")
+	}
+	if artifact.Size > 0 && offset+written > artifact.Size {
+		os.Remove(part)
+		return errors.Errorf("artifact size mismatch, expected %d bytes, downloaded more", artifact.Size)
+	}
+	return complete(artifact, part, file)
+}
+
+// complete verifies the downloaded partial file and renames it to the given file, the partial file is removed if it is invalid.
+func complete(artifact *Artifact, part, file string) error {
+	if err := Verify(artifact, part); err != nil {
+		os.Remove(part)
+		return err
+	}
+	return errors.Wrap(os.Rename(part, file), "cannot store artifact file")
+}
+
+// Verify returns an error if the size or the checksum of the given file does not match the given artifact.
+func Verify(artifact *Artifact, file string) error {
+	in, err := os.Open(file)
+	if err != nil {
+		return errors.Wrap(err, "cannot open artifact file")
+	}
+	defer in.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, in)
+	if err != nil {
+		return errors.Wrap(err, "cannot read artifact file")
+	}
+	if artifact.Size > 0 && size != artifact.Size {
+		return errors.Errorf("artifact size mismatch, expected %d bytes, downloaded %d bytes", artifact.Size, size)
+	}
+	if checksum := hex.EncodeToString(hash.Sum(nil)); !strings.EqualFold(checksum, artifact.SHA256) {
+		return errors.Errorf("artifact SHA-256 checksum mismatch, expected %s, downloaded %s", strings.ToLower(artifact.SHA256), checksum)
+	}
+	return nil
+}
+
+// rangeStart returns the first byte position of the given Content-Range header value, e.g. 100 for "bytes 100-199/200".
+func rangeStart(contentRange string) (int64, bool) {
+	var start, end int64
+	if _, err := fmt.Sscanf(contentRange, "bytes %d-%d", &start, &end); err != nil {
+		return 0, false
+	}
+	return start, true
+}
+
+type progressWriter struct {
+	writer     io.Writer
+	downloaded int64
+	total      int64
+	progress   func(downloaded, total int64)
+}
+
+func (writer *progressWriter) Write(data []byte) (int, error) {
+	n, err := writer.writer.Write(data)
+	writer.downloaded += int64(n)
+	writer.progress(writer.downloaded, writer.total)
+	return n, err
+}
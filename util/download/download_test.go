@@ -0,0 +1,195 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package download
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testContent = bytes.Repeat([]byte("0123456789"), 10000)
+
+type testServer struct {
+	*httptest.Server
+	lock   sync.Mutex
+	ranges []string
+	// limit is the number of bytes served before the connection is interrupted, not limited if zero
+	limit int
+}
+
+func newTestServer(t *testing.T, supportRanges bool) *testServer {
+	server := &testServer{}
+	server.Server = httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		server.lock.Lock()
+		server.ranges = append(server.ranges, request.Header.Get("Range"))
+		limit := server.limit
+		server.limit = 0
+		server.lock.Unlock()
+
+		if request.URL.Path != "/artifact" {
+			http.NotFound(writer, request)
+			return
+		}
+		if limit > 0 {
+			writer.Header().Set("Content-Length", strconv.Itoa(len(testContent)))
+			writer.Write(testContent[:limit])
+			if hijacker, ok := writer.(http.Hijacker); ok {
+				if conn, _, err := hijacker.Hijack(); err == nil {
+					conn.Close()
+				}
+			}
+			return
+		}
+		if supportRanges {
+			http.ServeContent(writer, request, "artifact", time.Time{}, bytes.NewReader(testContent))
+			return
+		}
+		writer.Write(testContent)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func testArtifact(url string) *Artifact {
+	checksum := sha256.Sum256(testContent)
+	return &Artifact{URL: url + "/artifact", Size: int64(len(testContent)), SHA256: hex.EncodeToString(checksum[:])}
+}
+
+func TestDownload(t *testing.T) {
+	server := newTestServer(t, true)
+	file := filepath.Join(t.TempDir(), "artifact")
+
+	var progress []int64
+	err := Download(context.Background(), server.Client(), testArtifact(server.URL), file, func(downloaded, total int64) {
+		assert.Equal(t, int64(len(testContent)), total)
+		progress = append(progress, downloaded)
+	})
+	require.NoError(t, err)
+	content, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Equal(t, testContent, content)
+	assert.NoFileExists(t, file+PartSuffix)
+	assert.Equal(t, int64(0), progress[0])
+	assert.Equal(t, int64(len(testContent)), progress[len(progress)-1])
+}
+
+func TestDownloadResume(t *testing.T) {
+	server := newTestServer(t, true)
+	file := filepath.Join(t.TempDir(), "artifact")
+
+	server.limit = 30000
+	assert.Error(t, Download(context.Background(), server.Client(), testArtifact(server.URL), file, nil))
+	info, err := os.Stat(file + PartSuffix)
+	require.NoError(t, err)
+	assert.Equal(t, int64(30000), info.Size())
+
+	require.NoError(t, Download(context.Background(), server.Client(), testArtifact(server.URL), file, nil))
+	content, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Equal(t, testContent, content)
+	assert.Equal(t, []string{"", "bytes=30000-"}, server.ranges)
+}
+
+func TestDownloadRangesNotSupported(t *testing.T) {
+	server := newTestServer(t, false)
+	file := filepath.Join(t.TempDir(), "artifact")
+	require.NoError(t, os.WriteFile(file+PartSuffix, []byte("stale"), 0644))
+
+	require.NoError(t, Download(context.Background(), server.Client(), testArtifact(server.URL), file, nil))
+	content, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Equal(t, testContent, content)
+	assert.Equal(t, []string{"bytes=5-"}, server.ranges)
+}
+
+func TestDownloadComplete(t *testing.T) {
+	server := newTestServer(t, true)
+	file := filepath.Join(t.TempDir(), "artifact")
+	require.NoError(t, os.WriteFile(file+PartSuffix, testContent, 0644))
+
+	require.NoError(t, Download(context.Background(), server.Client(), testArtifact(server.URL), file, nil))
+	assert.FileExists(t, file)
+	assert.Empty(t, server.ranges)
+}
+
+func TestDownloadErrors(t *testing.T) {
+	server := newTestServer(t, true)
+	dir := t.TempDir()
+
+	t.Run("test_checksum_mismatch", func(t *testing.T) {
+		artifact := testArtifact(server.URL)
+		artifact.SHA256 = strings.Repeat("0", 64)
+		err := Download(context.Background(), server.Client(), artifact, filepath.Join(dir, "mismatch"), nil)
+		assert.EqualError(t, err, "artifact SHA-256 checksum mismatch, expected "+artifact.SHA256+", downloaded "+testArtifact("").SHA256)
+		assert.NoFileExists(t, filepath.Join(dir, "mismatch"+PartSuffix))
+		assert.NoFileExists(t, filepath.Join(dir, "mismatch"))
+	})
+	t.Run("test_size_mismatch", func(t *testing.T) {
+		artifact := testArtifact(server.URL)
+		artifact.Size = 10
+		file := filepath.Join(dir, "size")
+		require.NoError(t, os.WriteFile(file+PartSuffix, testContent[:20], 0644))
+		assert.EqualError(t, Download(context.Background(), server.Client(), artifact, file, nil),
+			"artifact size mismatch, expected 10 bytes, downloaded 20 bytes")
+		assert.NoFileExists(t, file+PartSuffix)
+	})
+	t.Run("test_size_exceeded", func(t *testing.T) {
+		artifact := testArtifact(server.URL)
+		artifact.Size = 10
+		file := filepath.Join(dir, "exceeded")
+		var downloaded int64
+		assert.EqualError(t, Download(context.Background(), server.Client(), artifact, file, func(current, total int64) {
+			downloaded = current
+		}), "artifact size mismatch, expected 10 bytes, downloaded more")
+		// the download is stopped right after the expected size is exceeded
+		assert.Equal(t, int64(11), downloaded)
+		assert.NoFileExists(t, file+PartSuffix)
+		assert.NoFileExists(t, file)
+	})
+	t.Run("test_not_found", func(t *testing.T) {
+		artifact := testArtifact(server.URL)
+		artifact.URL = server.URL + "/missing"
+		assert.EqualError(t, Download(context.Background(), server.Client(), artifact, filepath.Join(dir, "missing"), nil),
+			"cannot download artifact, unexpected HTTP status 404 Not Found")
+	})
+	t.Run("test_cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		assert.Error(t, Download(ctx, server.Client(), testArtifact(server.URL), filepath.Join(dir, "cancelled"), nil))
+	})
+}
+
+func TestArtifactValidate(t *testing.T) {
+	assert.NoError(t, testArtifact("https://example.com").Validate())
+	for expectedErr, artifact := range map[string]*Artifact{
+		"invalid artifact URL 'file:///etc/passwd', an HTTP or HTTPS URL is expected": {URL: "file:///etc/passwd", SHA256: testArtifact("").SHA256},
+		"invalid artifact size -1":                {URL: "http://example.com", Size: -1, SHA256: testArtifact("").SHA256},
+		"invalid artifact SHA-256 checksum 'abc'": {URL: "http://example.com", SHA256: "abc"},
+	} {
+		assert.EqualError(t, artifact.Validate(), expectedErr)
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package host
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+)
+
+// DiskPath is the path of the file system, which disk space is reported.
+const DiskPath = "/"
+
+// Info holds the details about the host the update manager is running on.
+// The details, which cannot be collected on the current platform, are left empty.
+type Info struct {
+	Arch      string
+	CPUs      int
+	OS        string
+	OSRelease string
+	Kernel    string
+	MachineID string
+	Hostname  string
+	DiskTotal uint64
+	DiskFree  uint64
+}
+
+// Collect returns the details about the host, collected locally.
+func Collect() *Info {
+	info := &Info{
+		Arch: runtime.GOARCH,
+		CPUs: runtime.NumCPU(),
+		OS:   runtime.GOOS,
+	}
+	info.Hostname, _ = os.Hostname()
+	collectPlatformInfo(info)
+	return info
+}
+
+// readFirstLine returns the first non-empty line of the first readable file of the given ones.
+func readFirstLine(files ...string) string {
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		if line := strings.TrimSpace(strings.SplitN(string(data), "\n", 2)[0]); line != "" {
+			return line
+		}
+	}
+	return ""
+}
+
+// parseOSRelease returns the pretty name of the operating system from the given os-release content,
+// or its name and version if the pretty name is not set.
+func parseOSRelease(reader io.Reader) string {
+	values := map[string]string{}
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), "=")
+		if !ok || strings.HasPrefix(key, "#") {
+			continue
+		}
+		values[key] = strings.Trim(value, `"'`)
+	}
+	if prettyName := values["PRETTY_NAME"]; prettyName != "" {
+		return prettyName
+	}
+	return strings.TrimSpace(values["NAME"] + " " + values["VERSION"])
+}
@@ -0,0 +1,45 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+//go:build linux
+// +build linux
+
+package host
+
+import (
+	"os"
+	"syscall"
+)
+
+var (
+	osReleaseFiles = []string{"/etc/os-release", "/usr/lib/os-release"}
+	machineIDFiles = []string{"/etc/machine-id", "/var/lib/dbus/machine-id"}
+	kernelFile     = "/proc/sys/kernel/osrelease"
+)
+
+func collectPlatformInfo(info *Info) {
+	for _, file := range osReleaseFiles {
+		if f, err := os.Open(file); err == nil {
+			info.OSRelease = parseOSRelease(f)
+			f.Close()
+			break
+		}
+	}
+	info.Kernel = readFirstLine(kernelFile)
+	info.MachineID = readFirstLine(machineIDFiles...)
+
+	stat := syscall.Statfs_t{}
+	if err := syscall.Statfs(DiskPath, &stat); err == nil {
+		info.DiskTotal = stat.Blocks * uint64(stat.Bsize)
+		info.DiskFree = stat.Bavail * uint64(stat.Bsize)
+	}
+}
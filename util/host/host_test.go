@@ -0,0 +1,64 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package host
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollect(t *testing.T) {
+	info := Collect()
+	assert.Equal(t, runtime.GOARCH, info.Arch)
+	assert.Equal(t, runtime.GOOS, info.OS)
+	assert.Equal(t, runtime.NumCPU(), info.CPUs)
+	assert.True(t, info.DiskFree <= info.DiskTotal)
+}
+
+func TestParseOSRelease(t *testing.T) {
+	testCases := map[string]struct {
+		content  string
+		expected string
+	}{
+		"test_pretty_name": {
+			content:  "NAME=\"Poky\"\nVERSION=\"4.0\"\n# comment\nPRETTY_NAME=\"Poky (Yocto Project Reference Distro) 4.0\"\n",
+			expected: "Poky (Yocto Project Reference Distro) 4.0",
+		},
+		"test_name_and_version": {
+			content:  "NAME=Debian\nVERSION='12 (bookworm)'\n",
+			expected: "Debian 12 (bookworm)",
+		},
+		"test_empty": {},
+	}
+	for testName, testCase := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			assert.Equal(t, testCase.expected, parseOSRelease(strings.NewReader(testCase.content)))
+		})
+	}
+}
+
+func TestReadFirstLine(t *testing.T) {
+	dir := t.TempDir()
+	empty := filepath.Join(dir, "empty")
+	machineID := filepath.Join(dir, "machine-id")
+	assert.NoError(t, os.WriteFile(empty, []byte("\n"), 0644))
+	assert.NoError(t, os.WriteFile(machineID, []byte("0123456789abcdef\n"), 0644))
+
+	assert.Equal(t, "0123456789abcdef", readFirstLine(filepath.Join(dir, "missing"), empty, machineID))
+	assert.Equal(t, "", readFirstLine(filepath.Join(dir, "missing")))
+}
@@ -0,0 +1,28 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package jws
+
+// Config represents the desired state signature verification config
+type Config struct {
+	CACert string `json:"caCert,omitempty"`
+}
+
+// NewDefaultConfig returns a default signature verification config instance, the verification is disabled by default
+func NewDefaultConfig() *Config {
+	return &Config{}
+}
+
+// IsEnabled returns true if the desired state signature verification is configured.
+func (config *Config) IsEnabled() bool {
+	return config != nil && config.CACert != ""
+}
@@ -0,0 +1,204 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package jws
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	_ "crypto/sha256" // registers the SHA-256 hash function
+	_ "crypto/sha512" // registers the SHA-384 and SHA-512 hash functions
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// algorithms maps the supported JWS algorithms to their hash functions, EdDSA signs the input without prior hashing.
+var algorithms = map[string]crypto.Hash{
+	"RS256": crypto.SHA256,
+	"RS384": crypto.SHA384,
+	"RS512": crypto.SHA512,
+	"PS256": crypto.SHA256,
+	"PS384": crypto.SHA384,
+	"PS512": crypto.SHA512,
+	"ES256": crypto.SHA256,
+	"ES384": crypto.SHA384,
+	"ES512": crypto.SHA512,
+	"EdDSA": 0,
+}
+
+// curveSizes maps the hash functions of the ECDSA algorithms to the bit sizes of the respective curves.
+var curveSizes = map[crypto.Hash]int{
+	crypto.SHA256: 256,
+	crypto.SHA384: 384,
+	crypto.SHA512: 521,
+}
+
+type header struct {
+	Algorithm string   `json:"alg"`
+	Chain     []string `json:"x5c"`
+	Critical  []string `json:"crit,omitempty"`
+}
+
+// Verifier verifies messages in JWS compact serialization, signed with the private key of an X.509 certificate,
+// which is chained to the configured trusted CA certificates. The certificate chain is carried in the "x5c" header.
+type Verifier struct {
+	roots *x509.CertPool
+}
+
+// NewVerifier creates a new signature verifier, trusting the CA certificates from the configured PEM file.
+func NewVerifier(config *Config) (*Verifier, error) {
+	if !config.IsEnabled() {
+		return nil, errors.New("CA certificates for the signature verification are not provided")
+	}
+	caCert, err := os.ReadFile(config.CACert)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load signature CA")
+	}
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(caCert) {
+		return nil, errors.Errorf("failed to parse signature CA %s", config.CACert)
+	}
+	return &Verifier{roots: roots}, nil
+}
+
+// IsSigned returns true if the given message looks like a JWS compact serialization, i.e. it is not a JSON object.
+func IsSigned(message []byte) bool {
+	message = bytes.TrimSpace(message)
+	return len(message) > 0 && message[0] != '{' && bytes.Count(message, []byte{'.'}) == 2
+}
+
+// Payload returns the payload of the given JWS message without verifying its signature.
+func Payload(message []byte) ([]byte, error) {
+	parts := strings.Split(string(bytes.TrimSpace(message)), ".")
+	if len(parts) != 3 {
+		return nil, errors.New("invalid JWS compact serialization")
+	}
+	return base64.RawURLEncoding.DecodeString(parts[1])
+}
+
+// Verify verifies the signature of the given JWS message and returns its payload and the subject of the signer certificate.
+func (verifier *Verifier) Verify(message []byte) ([]byte, string, error) {
+	parts := strings.Split(string(bytes.TrimSpace(message)), ".")
+	if len(parts) != 3 {
+		return nil, "", errors.New("invalid JWS compact serialization")
+	}
+	headerBytes, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, "", errors.Wrap(err, "invalid JWS header encoding")
+	}
+	h := &header{}
+	if err := json.Unmarshal(headerBytes, h); err != nil {
+		return nil, "", errors.Wrap(err, "invalid JWS header")
+	}
+	if len(h.Critical) > 0 {
+		return nil, "", errors.Errorf("unsupported critical JWS header parameters %v", h.Critical)
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, "", errors.Wrap(err, "invalid JWS payload encoding")
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, "", errors.Wrap(err, "invalid JWS signature encoding")
+	}
+	certificate, err := verifier.verifyChain(h.Chain)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := verifySignature(h.Algorithm, certificate.PublicKey, []byte(parts[0]+"."+parts[1]), signature); err != nil {
+		return nil, "", err
+	}
+	return payload, certificate.Subject.String(), nil
+}
+
+func (verifier *Verifier) verifyChain(chain []string) (*x509.Certificate, error) {
+	if len(chain) == 0 {
+		return nil, errors.New("signer certificate chain is missing")
+	}
+	certificates := make([]*x509.Certificate, len(chain))
+	for i, encoded := range chain {
+		der, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid signer certificate encoding")
+		}
+		if certificates[i], err = x509.ParseCertificate(der); err != nil {
+			return nil, errors.Wrap(err, "invalid signer certificate")
+		}
+	}
+	intermediates := x509.NewCertPool()
+	for _, certificate := range certificates[1:] {
+		intermediates.AddCert(certificate)
+	}
+	if _, err := certificates[0].Verify(x509.VerifyOptions{
+		Roots:         verifier.roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	}); err != nil {
+		return nil, errors.Wrap(err, "untrusted signer certificate")
+	}
+	return certificates[0], nil
+}
+
+func verifySignature(algorithm string, publicKey crypto.PublicKey, input, signature []byte) error {
+	hash, ok := algorithms[algorithm]
+	if !ok {
+		return errors.Errorf("unsupported signature algorithm '%s'", algorithm)
+	}
+	if hash == 0 {
+		if key, ok := publicKey.(ed25519.PublicKey); ok {
+			if !ed25519.Verify(key, input, signature) {
+				return errors.New("invalid signature")
+			}
+			return nil
+		}
+		return errors.Errorf("signer key does not match algorithm %s", algorithm)
+	}
+	hasher := hash.New()
+	hasher.Write(input)
+	digest := hasher.Sum(nil)
+
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		var err error
+		if strings.HasPrefix(algorithm, "RS") {
+			err = rsa.VerifyPKCS1v15(key, hash, digest, signature)
+		} else if strings.HasPrefix(algorithm, "PS") {
+			err = rsa.VerifyPSS(key, hash, digest, signature, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+		} else {
+			return errors.Errorf("signer key does not match algorithm %s", algorithm)
+		}
+		if err != nil {
+			return errors.Wrap(err, "invalid signature")
+		}
+		return nil
+	case *ecdsa.PublicKey:
+		if !strings.HasPrefix(algorithm, "ES") || key.Curve.Params().BitSize != curveSizes[hash] {
+			return errors.Errorf("signer key does not match algorithm %s", algorithm)
+		}
+		size := (key.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size || !ecdsa.Verify(key, digest, new(big.Int).SetBytes(signature[:size]), new(big.Int).SetBytes(signature[size:])) {
+			return errors.New("invalid signature")
+		}
+		return nil
+	default:
+		return errors.Errorf("signer key does not match algorithm %s", algorithm)
+	}
+}
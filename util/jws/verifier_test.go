@@ -0,0 +1,121 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package jws
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/eclipse-kanto/update-manager/test"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testPayload = `{"activityId":"test","payload":{"domains":[{"id":"containers"}]}}`
+
+func TestNewVerifier(t *testing.T) {
+	dir := t.TempDir()
+	signer := test.NewSigner(t, dir)
+	invalidFile := filepath.Join(dir, "invalid.pem")
+	assert.NoError(t, os.WriteFile(invalidFile, []byte("invalid"), 0644))
+
+	verifier, err := NewVerifier(&Config{CACert: signer.CACert})
+	assert.NoError(t, err)
+	assert.NotNil(t, verifier)
+
+	_, err = NewVerifier(NewDefaultConfig())
+	assert.Error(t, err)
+	_, err = NewVerifier(nil)
+	assert.Error(t, err)
+	_, err = NewVerifier(&Config{CACert: filepath.Join(dir, "nonexisting.pem")})
+	assert.Error(t, err)
+	_, err = NewVerifier(&Config{CACert: invalidFile})
+	assert.EqualError(t, err, "failed to parse signature CA "+invalidFile)
+}
+
+func TestVerify(t *testing.T) {
+	signer := test.NewSigner(t, t.TempDir())
+	verifier, err := NewVerifier(&Config{CACert: signer.CACert})
+	assert.NoError(t, err)
+
+	message := signer.Sign(t, []byte(testPayload))
+	assert.True(t, IsSigned(message))
+	assert.False(t, IsSigned([]byte(testPayload)))
+
+	payload, subject, err := verifier.Verify(message)
+	assert.NoError(t, err)
+	assert.Equal(t, testPayload, string(payload))
+	assert.Equal(t, test.SignerSubject, subject)
+
+	payload, err = Payload(message)
+	assert.NoError(t, err)
+	assert.Equal(t, testPayload, string(payload))
+}
+
+func TestVerifyErrors(t *testing.T) {
+	signer := test.NewSigner(t, t.TempDir())
+	verifier, err := NewVerifier(&Config{CACert: signer.CACert})
+	assert.NoError(t, err)
+	untrusted := test.NewSigner(t, t.TempDir())
+
+	parts := strings.Split(string(signer.Sign(t, []byte(testPayload))), ".")
+	tampered := parts[0] + "." + base64.RawURLEncoding.EncodeToString([]byte(`{"activityId":"other"}`)) + "." + parts[2]
+	encode := func(header string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(header)) + "." + parts[1] + "." + parts[2]
+	}
+
+	tests := map[string]string{
+		"test_not_jws":              testPayload,
+		"test_invalid_header":       "!." + parts[1] + "." + parts[2],
+		"test_invalid_header_json":  encode("invalid"),
+		"test_missing_chain":        encode(`{"alg":"ES256"}`),
+		"test_invalid_chain":        encode(`{"alg":"ES256","x5c":["invalid"]}`),
+		"test_critical_header":      encode(`{"alg":"ES256","crit":["exp"]}`),
+		"test_untrusted_signer":     string(untrusted.Sign(t, []byte(testPayload))),
+		"test_tampered_payload":     tampered,
+		"test_invalid_signature":    parts[0] + "." + parts[1] + "." + base64.RawURLEncoding.EncodeToString([]byte("invalid")),
+		"test_invalid_payload":      parts[0] + ".!." + parts[2],
+		"test_invalid_signature_64": parts[0] + "." + parts[1] + ".!",
+	}
+	for name, message := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, _, err := verifier.Verify([]byte(message))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestVerifySignatureAlgorithms(t *testing.T) {
+	signer := test.NewSigner(t, t.TempDir())
+	verifier, err := NewVerifier(&Config{CACert: signer.CACert})
+	assert.NoError(t, err)
+
+	parts := strings.Split(string(signer.Sign(t, []byte(testPayload))), ".")
+	chain, err := verifier.verifyChain([]string{})
+	assert.Error(t, err)
+	assert.Nil(t, chain)
+
+	header, err := base64.RawURLEncoding.DecodeString(parts[0])
+	assert.NoError(t, err)
+	x5c := string(header[strings.Index(string(header), `"x5c"`):])
+	for _, algorithm := range []string{"none", "", "HS256", "RS256", "PS256", "ES384", "EdDSA"} {
+		t.Run("test_algorithm_"+algorithm, func(t *testing.T) {
+			message := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"`+algorithm+`",`+x5c)) + "." + parts[1] + "." + parts[2]
+			_, _, err := verifier.Verify([]byte(message))
+			assert.Error(t, err)
+		})
+	}
+}
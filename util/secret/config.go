@@ -0,0 +1,26 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package secret
+
+// Config represents the secret configuration values config
+type Config struct {
+	KeyFile  string `json:"keyFile,omitempty"`
+	StoreDir string `json:"storeDir,omitempty"`
+	KeysFile string `json:"keysFile,omitempty"`
+}
+
+// NewDefaultConfig returns a default secret configuration values config instance, neither a device key nor a secret store is configured by default
+// and the configuration keys of the secret values are kept only in memory
+func NewDefaultConfig() *Config {
+	return &Config{}
+}
@@ -0,0 +1,120 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package secret
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// Keys holds the configuration keys of the secret values forwarded for the installed components, so that the node parameters reported
+// with these keys are redacted in the current state even after the update activity, which has forwarded the secret values, is finished.
+// The keys are kept in the given file, if any, so that they are known after a restart as well. The secret values are never persisted.
+type Keys struct {
+	lock sync.Mutex
+	file string
+	keys map[string][]string
+}
+
+// NewKeys creates the secret configuration keys, which are loaded from and saved into the configured keys file, or kept only in memory
+// if no keys file is configured.
+func NewKeys(config *Config) (*Keys, error) {
+	keys := &Keys{keys: map[string][]string{}}
+	if config == nil || config.KeysFile == "" {
+		return keys, nil
+	}
+	file := config.KeysFile
+	keys.file = file
+	data, err := os.ReadFile(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return keys, nil
+		}
+		return nil, errors.Wrap(err, "cannot load the secret configuration keys")
+	}
+	if err := json.Unmarshal(data, &keys.keys); err != nil {
+		return nil, errors.Wrapf(err, "invalid secret configuration keys in file '%s'", file)
+	}
+	return keys, nil
+}
+
+// Add adds the given configuration keys of secret values for the node with the given ID. The keys, which have already been added
+// for the node, are kept, so that the values still reported with them are redacted. The keys are saved if any of them is new.
+func (keys *Keys) Add(nodeID string, configKeys ...string) error {
+	keys.lock.Lock()
+	defer keys.lock.Unlock()
+
+	known := keys.keys[nodeID]
+	changed := false
+	for _, key := range configKeys {
+		if !contains(known, key) {
+			known = append(known, key)
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	sort.Strings(known)
+	keys.keys[nodeID] = known
+	return keys.save()
+}
+
+// Contains returns true if the given configuration key has been added for the node with the given ID.
+func (keys *Keys) Contains(nodeID, key string) bool {
+	keys.lock.Lock()
+	defer keys.lock.Unlock()
+
+	return contains(keys.keys[nodeID], key)
+}
+
+// Empty returns true if no configuration keys have been added.
+func (keys *Keys) Empty() bool {
+	keys.lock.Lock()
+	defer keys.lock.Unlock()
+
+	return len(keys.keys) == 0
+}
+
+// save writes the keys into a temporary file and renames it afterwards, so that the persisted keys are never partially written.
+func (keys *Keys) save() error {
+	if keys.file == "" {
+		return nil
+	}
+	data, err := json.Marshal(keys.keys)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(keys.file), 0755); err != nil {
+		return err
+	}
+	tmpFile := keys.file + ".tmp"
+	if err := os.WriteFile(tmpFile, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmpFile, keys.file)
+}
+
+func contains(values []string, value string) bool {
+	for _, known := range values {
+		if known == value {
+			return true
+		}
+	}
+	return false
+}
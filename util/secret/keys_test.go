@@ -0,0 +1,48 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package secret
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeys(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "state", "secret-keys.json")
+	keys, err := NewKeys(&Config{KeysFile: file})
+	require.NoError(t, err)
+	assert.True(t, keys.Empty())
+
+	require.NoError(t, keys.Add("containers:app", "password", "token"))
+	require.NoError(t, keys.Add("containers:app", "password"))
+	assert.True(t, keys.Contains("containers:app", "password"))
+	assert.False(t, keys.Contains("containers:db", "password"))
+
+	reloaded, err := NewKeys(&Config{KeysFile: file})
+	require.NoError(t, err)
+	assert.False(t, reloaded.Empty())
+	assert.True(t, reloaded.Contains("containers:app", "token"))
+
+	require.NoError(t, os.WriteFile(file, []byte("{"), 0600))
+	_, err = NewKeys(&Config{KeysFile: file})
+	assert.Error(t, err)
+
+	inMemory, err := NewKeys(nil)
+	require.NoError(t, err)
+	require.NoError(t, inMemory.Add("containers:app", "password"))
+	assert.True(t, inMemory.Contains("containers:app", "password"))
+}
@@ -0,0 +1,199 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package secret
+
+import (
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rsa"
+	_ "crypto/sha1"   // registers the SHA-1 hash function
+	_ "crypto/sha256" // registers the SHA-256 hash function
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// EncryptedPrefix is the prefix of a secret value, which is encrypted with the device key in JWE compact serialization
+	EncryptedPrefix = "encrypted:"
+	// ReferencePrefix is the prefix of a secret value, which is referenced by name from the local secret store
+	ReferencePrefix = "secret:"
+	// Redacted replaces the secret values in logs, feedback and current state
+	Redacted = "******"
+)
+
+// algorithms maps the supported JWE key management algorithms to the hash functions of the RSA-OAEP decryption.
+var algorithms = map[string]crypto.Hash{
+	"RSA-OAEP":     crypto.SHA1,
+	"RSA-OAEP-256": crypto.SHA256,
+}
+
+// keySizes maps the supported JWE content encryption algorithms to their key sizes in bytes.
+var keySizes = map[string]int{
+	"A128GCM": 16,
+	"A192GCM": 24,
+	"A256GCM": 32,
+}
+
+var referencePattern = regexp.MustCompile(`^[A-Za-z0-9_-][A-Za-z0-9._-]*$`)
+
+type header struct {
+	Algorithm  string `json:"alg"`
+	Encryption string `json:"enc"`
+}
+
+// Resolver resolves the secret values: it decrypts the values encrypted with the public key of the device
+// and reads the referenced values from the local secret store, i.e. a directory with a file per secret.
+type Resolver struct {
+	key      *rsa.PrivateKey
+	storeDir string
+}
+
+// IsSecret returns true if the given value is encrypted or references the local secret store.
+func IsSecret(value string) bool {
+	return strings.HasPrefix(value, EncryptedPrefix) || strings.HasPrefix(value, ReferencePrefix)
+}
+
+// NewResolver creates a new secret resolver, using the configured PEM encoded RSA private key of the device and secret store directory.
+// The secrets, which require a device key or a secret store that is not configured, cannot be resolved.
+func NewResolver(config *Config) (*Resolver, error) {
+	resolver := &Resolver{}
+	if config == nil {
+		return resolver, nil
+	}
+	resolver.storeDir = config.StoreDir
+	if config.KeyFile == "" {
+		return resolver, nil
+	}
+	data, err := os.ReadFile(config.KeyFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load secrets device key")
+	}
+	if resolver.key, err = parsePrivateKey(data); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse secrets device key %s", config.KeyFile)
+	}
+	return resolver, nil
+}
+
+// Resolve returns the plain value of the given secret value, the values, which are not secret, are returned as they are.
+func (resolver *Resolver) Resolve(value string) (string, error) {
+	if encrypted := strings.TrimPrefix(value, EncryptedPrefix); encrypted != value {
+		if resolver == nil || resolver.key == nil {
+			return "", errors.New("no device key is configured to decrypt the secret value")
+		}
+		plain, err := decrypt(resolver.key, encrypted)
+		return string(plain), err
+	}
+	if name := strings.TrimPrefix(value, ReferencePrefix); name != value {
+		if resolver == nil || resolver.storeDir == "" {
+			return "", errors.Errorf("no secret store is configured to resolve the secret '%s'", name)
+		}
+		if !referencePattern.MatchString(name) {
+			return "", errors.Errorf("invalid secret name '%s'", name)
+		}
+		data, err := os.ReadFile(filepath.Join(resolver.storeDir, name))
+		if err != nil {
+			return "", errors.Wrapf(err, "cannot read secret '%s'", name)
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	}
+	return value, nil
+}
+
+// Redact replaces all occurrences of the given secret values in the given text.
+func Redact(text string, values []string) string {
+	for _, value := range values {
+		if value != "" {
+			text = strings.ReplaceAll(text, value, Redacted)
+		}
+	}
+	return text
+}
+
+func parsePrivateKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM encoded private key")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("the private key is not an RSA key")
+	}
+	return rsaKey, nil
+}
+
+// decrypt decrypts the given JWE compact serialization, the content encryption key is expected to be encrypted with RSA-OAEP
+// and the content with AES-GCM.
+func decrypt(key *rsa.PrivateKey, compact string) ([]byte, error) {
+	parts := strings.Split(compact, ".")
+	if len(parts) != 5 {
+		return nil, errors.New("invalid JWE compact serialization")
+	}
+	decoded := make([][]byte, len(parts))
+	for i, part := range parts {
+		var err error
+		if decoded[i], err = base64.RawURLEncoding.DecodeString(part); err != nil {
+			return nil, errors.Wrap(err, "invalid JWE encoding")
+		}
+	}
+	h := &header{}
+	if err := json.Unmarshal(decoded[0], h); err != nil {
+		return nil, errors.Wrap(err, "invalid JWE header")
+	}
+	hash, ok := algorithms[h.Algorithm]
+	if !ok {
+		return nil, errors.Errorf("unsupported JWE algorithm '%s'", h.Algorithm)
+	}
+	keySize, ok := keySizes[h.Encryption]
+	if !ok {
+		return nil, errors.Errorf("unsupported JWE encryption '%s'", h.Encryption)
+	}
+	contentKey, err := rsa.DecryptOAEP(hash.New(), nil, key, decoded[1], nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot decrypt JWE content encryption key")
+	}
+	if len(contentKey) != keySize {
+		return nil, errors.Errorf("invalid JWE content encryption key size %d", len(contentKey))
+	}
+	block, err := aes.NewCipher(contentKey)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(decoded[2]) != gcm.NonceSize() {
+		return nil, errors.Errorf("invalid JWE initialization vector size %d", len(decoded[2]))
+	}
+	plain, err := gcm.Open(nil, decoded[2], append(decoded[3], decoded[4]...), []byte(parts[0]))
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot decrypt JWE content")
+	}
+	return plain, nil
+}
@@ -0,0 +1,135 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package secret
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolve(t *testing.T) {
+	dir := t.TempDir()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	keyFile := filepath.Join(dir, "device.key")
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}), 0600))
+	storeDir := filepath.Join(dir, "store")
+	require.NoError(t, os.Mkdir(storeDir, 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(storeDir, "db-password"), []byte("s3cr3t\n"), 0600))
+
+	resolver, err := NewResolver(&Config{KeyFile: keyFile, StoreDir: storeDir})
+	require.NoError(t, err)
+
+	t.Run("test_resolve_encrypted", func(t *testing.T) {
+		value, err := resolver.Resolve(EncryptedPrefix + encrypt(t, &key.PublicKey, "A256GCM", "p@ssw0rd"))
+		assert.NoError(t, err)
+		assert.Equal(t, "p@ssw0rd", value)
+	})
+	t.Run("test_resolve_reference", func(t *testing.T) {
+		value, err := resolver.Resolve(ReferencePrefix + "db-password")
+		assert.NoError(t, err)
+		assert.Equal(t, "s3cr3t", value)
+	})
+	t.Run("test_resolve_plain", func(t *testing.T) {
+		value, err := resolver.Resolve("8080")
+		assert.NoError(t, err)
+		assert.Equal(t, "8080", value)
+	})
+	t.Run("test_resolve_errors", func(t *testing.T) {
+		encrypted := encrypt(t, &key.PublicKey, "A128GCM", "p@ssw0rd")
+		parts := strings.Split(encrypted, ".")
+		parts[3] = base64.RawURLEncoding.EncodeToString([]byte("tampered"))
+		for value, expectedErr := range map[string]string{
+			EncryptedPrefix + "invalid":                "invalid JWE compact serialization",
+			EncryptedPrefix + strings.Join(parts, "."): "cannot decrypt JWE content: cipher: message authentication failed",
+			EncryptedPrefix + "e30.YQ.YQ.YQ.YQ":        "unsupported JWE algorithm ''",
+			ReferencePrefix + "../device.key":          "invalid secret name '../device.key'",
+			ReferencePrefix + "missing":                "cannot read secret 'missing'",
+			EncryptedPrefix + strings.Repeat("a", 10):  "invalid JWE compact serialization",
+			EncryptedPrefix + "!.a.b.c.d":              "invalid JWE encoding",
+			EncryptedPrefix + encryptedWithHeader(`{"alg":"RSA-OAEP-256","enc":"A256CBC-HS512"}`): "unsupported JWE encryption 'A256CBC-HS512'",
+		} {
+			_, err := resolver.Resolve(value)
+			if assert.Error(t, err, value) {
+				assert.True(t, strings.HasPrefix(err.Error(), expectedErr), err.Error())
+			}
+		}
+	})
+	t.Run("test_resolve_not_configured", func(t *testing.T) {
+		resolver, err := NewResolver(NewDefaultConfig())
+		require.NoError(t, err)
+		_, err = resolver.Resolve(EncryptedPrefix + encrypt(t, &key.PublicKey, "A256GCM", "p@ssw0rd"))
+		assert.EqualError(t, err, "no device key is configured to decrypt the secret value")
+		_, err = resolver.Resolve(ReferencePrefix + "db-password")
+		assert.EqualError(t, err, "no secret store is configured to resolve the secret 'db-password'")
+	})
+}
+
+func TestNewResolverError(t *testing.T) {
+	dir := t.TempDir()
+	_, err := NewResolver(&Config{KeyFile: filepath.Join(dir, "missing.key")})
+	assert.Error(t, err)
+
+	invalidKeyFile := filepath.Join(dir, "invalid.key")
+	require.NoError(t, os.WriteFile(invalidKeyFile, []byte("invalid"), 0600))
+	_, err = NewResolver(&Config{KeyFile: invalidKeyFile})
+	assert.EqualError(t, err, "failed to parse secrets device key "+invalidKeyFile+": no PEM encoded private key")
+}
+
+func TestIsSecret(t *testing.T) {
+	assert.True(t, IsSecret("encrypted:abc"))
+	assert.True(t, IsSecret("secret:db-password"))
+	assert.False(t, IsSecret("8080"))
+}
+
+func TestRedact(t *testing.T) {
+	assert.Equal(t, "login with ****** failed for ******", Redact("login with p@ss failed for admin", []string{"p@ss", "admin", ""}))
+}
+
+// encrypt returns the given plain text encrypted with RSA-OAEP-256 and the given AES-GCM content encryption in JWE compact serialization.
+func encrypt(t *testing.T, key *rsa.PublicKey, encryption, plain string) string {
+	contentKey := make([]byte, keySizes[encryption])
+	_, err := rand.Read(contentKey)
+	require.NoError(t, err)
+	encryptedKey, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, key, contentKey, nil)
+	require.NoError(t, err)
+	block, err := aes.NewCipher(contentKey)
+	require.NoError(t, err)
+	gcm, err := cipher.NewGCM(block)
+	require.NoError(t, err)
+	iv := make([]byte, gcm.NonceSize())
+	_, err = rand.Read(iv)
+	require.NoError(t, err)
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RSA-OAEP-256","enc":"` + encryption + `"}`))
+	sealed := gcm.Seal(nil, iv, []byte(plain), []byte(header))
+	tagStart := len(sealed) - gcm.Overhead()
+	return strings.Join([]string{header, base64.RawURLEncoding.EncodeToString(encryptedKey), base64.RawURLEncoding.EncodeToString(iv),
+		base64.RawURLEncoding.EncodeToString(sealed[:tagStart]), base64.RawURLEncoding.EncodeToString(sealed[tagStart:])}, ".")
+}
+
+func encryptedWithHeader(header string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(header)) + ".YQ.YQ.YQ.YQ"
+}
@@ -0,0 +1,141 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package tls
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/eclipse-kanto/update-manager/logger"
+
+	"github.com/pkg/errors"
+)
+
+// certificateReloader keeps the client certificate and reloads it if the certificate or the key file has changed.
+type certificateReloader struct {
+	lock           sync.Mutex
+	cert           string
+	key            string
+	passphraseFile string
+	modTime        time.Time
+	certificate    *tls.Certificate
+}
+
+func newCertificateReloader(cert, key, passphraseFile string) (*certificateReloader, error) {
+	reloader := &certificateReloader{
+		cert:           cert,
+		key:            key,
+		passphraseFile: passphraseFile,
+	}
+	if err := reloader.reload(); err != nil {
+		return nil, err
+	}
+	return reloader, nil
+}
+
+// getClientCertificate returns the client certificate, reloading it first if its files have changed.
+// If the changed files cannot be loaded, e.g. as they are still being rotated, the previous certificate is returned.
+func (reloader *certificateReloader) getClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	reloader.lock.Lock()
+	defer reloader.lock.Unlock()
+
+	modTime, err := lastModified(reloader.cert, reloader.key, reloader.passphraseFile)
+	if err != nil {
+		logger.WarnErr(err, "cannot check the certificate files for changes, using the loaded certificate")
+	} else if !modTime.Equal(reloader.modTime) {
+		if err := reloader.reload(); err != nil {
+			logger.WarnErr(err, "cannot reload the changed certificate '%s', using the loaded certificate", reloader.cert)
+		} else {
+			logger.Info("certificate '%s' reloaded", reloader.cert)
+		}
+	}
+	return reloader.certificate, nil
+}
+
+func (reloader *certificateReloader) reload() error {
+	// on error, the zero modification time makes the next handshake reload the files
+	modTime, _ := lastModified(reloader.cert, reloader.key, reloader.passphraseFile)
+	certificate, err := loadX509KeyPair(reloader.cert, reloader.key, reloader.passphraseFile)
+	if err != nil {
+		return err
+	}
+	reloader.certificate = &certificate
+	reloader.modTime = modTime
+	return nil
+}
+
+// loadX509KeyPair loads the certificate and the private key, decrypting the key with the passphrase from the given file if set.
+func loadX509KeyPair(cert, key, passphraseFile string) (tls.Certificate, error) {
+	if len(passphraseFile) == 0 {
+		return tls.LoadX509KeyPair(cert, key)
+	}
+	certPEM, err := os.ReadFile(cert)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	keyPEM, err := os.ReadFile(key)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	if keyPEM, err = decryptKey(keyPEM, passphraseFile); err != nil {
+		return tls.Certificate{}, err
+	}
+	return tls.X509KeyPair(certPEM, keyPEM)
+}
+
+// decryptKey decrypts a PEM encoded private key, which is encrypted as specified in RFC 1423.
+func decryptKey(keyPEM []byte, passphraseFile string) ([]byte, error) {
+	passphrase, err := os.ReadFile(passphraseFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read the key passphrase")
+	}
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return nil, errors.New("failed to find any PEM data in key input")
+	}
+	if block.Type == "ENCRYPTED PRIVATE KEY" {
+		return nil, errors.New("encrypted PKCS #8 private keys are not supported")
+	}
+	// RFC 1423 is the only private key encryption supported by the standard library
+	if !x509.IsEncryptedPEMBlock(block) {
+		return nil, errors.New("the private key is not encrypted")
+	}
+	der, err := x509.DecryptPEMBlock(block, []byte(strings.TrimRight(string(passphrase), "\r\n")))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decrypt the private key")
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: block.Type, Bytes: der}), nil
+}
+
+// lastModified returns the latest modification time of the given files, the empty file names are skipped.
+func lastModified(files ...string) (time.Time, error) {
+	var modTime time.Time
+	for _, file := range files {
+		if len(file) == 0 {
+			continue
+		}
+		info, err := os.Stat(file)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if info.ModTime().After(modTime) {
+			modTime = info.ModTime()
+		}
+	}
+	return modTime, nil
+}
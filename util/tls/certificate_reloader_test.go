@@ -0,0 +1,86 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package tls
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCertificateReloader(t *testing.T) {
+	dir := t.TempDir()
+	cert := filepath.Join(dir, "cert.pem")
+	key := filepath.Join(dir, "key.pem")
+	passphrase := filepath.Join(dir, "passphrase.txt")
+	assert.NoError(t, os.WriteFile(cert, mustReadFile(t, certPath), 0600))
+	assert.NoError(t, os.WriteFile(key, mustReadFile(t, encryptedKeyPath), 0600))
+	assert.NoError(t, os.WriteFile(passphrase, mustReadFile(t, passphrasePath), 0600))
+
+	reloader, err := newCertificateReloader(cert, key, passphrase)
+	assert.NoError(t, err)
+	loaded, err := reloader.getClientCertificate(nil)
+	assert.NoError(t, err)
+	assert.NotNil(t, loaded)
+
+	t.Run("test_not_changed", func(t *testing.T) {
+		current, err := reloader.getClientCertificate(nil)
+		assert.NoError(t, err)
+		assert.Same(t, loaded, current)
+	})
+
+	t.Run("test_invalid_change_ignored", func(t *testing.T) {
+		assert.NoError(t, os.WriteFile(key, []byte("rotating"), 0600))
+		assert.NoError(t, os.Chtimes(key, time.Now(), time.Now().Add(time.Minute)))
+		current, err := reloader.getClientCertificate(nil)
+		assert.NoError(t, err)
+		assert.Same(t, loaded, current)
+	})
+
+	t.Run("test_reloaded", func(t *testing.T) {
+		assert.NoError(t, os.WriteFile(key, mustReadFile(t, encryptedKeyPath), 0600))
+		assert.NoError(t, os.Chtimes(key, time.Now(), time.Now().Add(2*time.Minute)))
+		current, err := reloader.getClientCertificate(nil)
+		assert.NoError(t, err)
+		assert.NotSame(t, loaded, current)
+		assert.Equal(t, loaded.Certificate, current.Certificate)
+	})
+
+	t.Run("test_removed", func(t *testing.T) {
+		before, _ := reloader.getClientCertificate(nil)
+		assert.NoError(t, os.Remove(cert))
+		current, err := reloader.getClientCertificate(nil)
+		assert.NoError(t, err)
+		assert.Same(t, before, current)
+	})
+}
+
+func TestLastModified(t *testing.T) {
+	dir := t.TempDir()
+	older := filepath.Join(dir, "older")
+	newer := filepath.Join(dir, "newer")
+	assert.NoError(t, os.WriteFile(older, nil, 0600))
+	assert.NoError(t, os.WriteFile(newer, nil, 0600))
+	modTime := time.Now().Add(time.Hour).Truncate(time.Second)
+	assert.NoError(t, os.Chtimes(newer, modTime, modTime))
+
+	lastModTime, err := lastModified(older, "", newer)
+	assert.NoError(t, err)
+	assert.True(t, modTime.Equal(lastModTime))
+
+	_, err = lastModified(older, nonExisting)
+	assert.Error(t, err)
+}
@@ -0,0 +1,139 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package tls
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/eclipse-kanto/update-manager/logger"
+
+	"github.com/pkg/errors"
+)
+
+const pemTypeCRL = "X509 CRL"
+
+// revocationChecker verifies the peer certificates against the certificate revocation lists from a file, reloading them if the file has changed.
+type revocationChecker struct {
+	lock    sync.Mutex
+	file    string
+	modTime time.Time
+	lists   []*x509.RevocationList
+}
+
+func newRevocationChecker(file string) (*revocationChecker, error) {
+	checker := &revocationChecker{file: file}
+	if err := checker.reload(); err != nil {
+		return nil, err
+	}
+	return checker, nil
+}
+
+// verifyPeerCertificate is called after the peer certificate chains are verified and fails
+// if all verified chains contain a certificate revoked by a revocation list of its issuer.
+func (checker *revocationChecker) verifyPeerCertificate(_ [][]byte, verifiedChains [][]*x509.Certificate) error {
+	lists := checker.get()
+	var err error
+	for _, chain := range verifiedChains {
+		if err = verifyChain(chain, lists); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// get returns the revocation lists, reloading them first if the file has changed.
+// If the changed file cannot be loaded, the previous revocation lists are returned.
+func (checker *revocationChecker) get() []*x509.RevocationList {
+	checker.lock.Lock()
+	defer checker.lock.Unlock()
+
+	modTime, err := lastModified(checker.file)
+	if err != nil {
+		logger.WarnErr(err, "cannot check the CRL file for changes, using the loaded CRL")
+	} else if !modTime.Equal(checker.modTime) {
+		if err := checker.reload(); err != nil {
+			logger.WarnErr(err, "cannot reload the changed CRL file '%s', using the loaded CRL", checker.file)
+		} else {
+			logger.Info("CRL file '%s' reloaded", checker.file)
+		}
+	}
+	return checker.lists
+}
+
+func (checker *revocationChecker) reload() error {
+	// on error, the zero modification time makes the next handshake reload the file
+	modTime, _ := lastModified(checker.file)
+	lists, err := loadRevocationLists(checker.file)
+	if err != nil {
+		return err
+	}
+	checker.lists = lists
+	checker.modTime = modTime
+	return nil
+}
+
+// loadRevocationLists parses the PEM encoded revocation lists from the given file, or a single DER encoded one if it has no PEM data.
+func loadRevocationLists(file string) ([]*x509.RevocationList, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Contains(data, []byte("-----BEGIN")) {
+		list, err := x509.ParseRevocationList(data)
+		if err != nil {
+			return nil, err
+		}
+		return []*x509.RevocationList{list}, nil
+	}
+	var lists []*x509.RevocationList
+	for block, rest := pem.Decode(data); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type != pemTypeCRL {
+			continue
+		}
+		list, err := x509.ParseRevocationList(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		lists = append(lists, list)
+	}
+	if len(lists) == 0 {
+		return nil, errors.Errorf("no CRL found in %s", file)
+	}
+	return lists, nil
+}
+
+// verifyChain checks each certificate of the chain against the revocation lists signed by its issuer.
+func verifyChain(chain []*x509.Certificate, lists []*x509.RevocationList) error {
+	for i := 0; i < len(chain)-1; i++ {
+		cert, issuer := chain[i], chain[i+1]
+		for _, list := range lists {
+			if !bytes.Equal(list.RawIssuer, cert.RawIssuer) || list.CheckSignatureFrom(issuer) != nil {
+				continue
+			}
+			if !list.NextUpdate.IsZero() && time.Now().After(list.NextUpdate) {
+				logger.Warn("CRL of issuer '%s' is outdated since %v", issuer.Subject, list.NextUpdate)
+			}
+			for _, revoked := range list.RevokedCertificateEntries {
+				if revoked.SerialNumber != nil && revoked.SerialNumber.Cmp(cert.SerialNumber) == 0 {
+					return errors.Errorf("certificate '%s' with serial number %s is revoked", cert.Subject, cert.SerialNumber)
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package tls
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCA(t *testing.T) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+	return &testCA{cert: cert, key: key}
+}
+
+func (ca *testCA) issue(t *testing.T, serial int64) *x509.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "test-broker"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	assert.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+	return cert
+}
+
+func (ca *testCA) revoke(t *testing.T, file string, serials ...int64) {
+	var revoked []x509.RevocationListEntry
+	for _, serial := range serials {
+		revoked = append(revoked, x509.RevocationListEntry{SerialNumber: big.NewInt(serial), RevocationTime: time.Now()})
+	}
+	der, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		Number:                    big.NewInt(time.Now().UnixNano()),
+		ThisUpdate:                time.Now().Add(-time.Minute),
+		NextUpdate:                time.Now().Add(time.Hour),
+		RevokedCertificateEntries: revoked,
+	}, ca.cert, ca.key)
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: pemTypeCRL, Bytes: der}), 0600))
+}
+
+func TestRevocationChecker(t *testing.T) {
+	ca := newTestCA(t)
+	valid := ca.issue(t, 10)
+	revoked := ca.issue(t, 11)
+	crlFile := filepath.Join(t.TempDir(), "ca.crl")
+	ca.revoke(t, crlFile, 11)
+
+	checker, err := newRevocationChecker(crlFile)
+	assert.NoError(t, err)
+	assert.NoError(t, checker.verifyPeerCertificate(nil, [][]*x509.Certificate{{valid, ca.cert}}))
+	assert.EqualError(t, checker.verifyPeerCertificate(nil, [][]*x509.Certificate{{revoked, ca.cert}}),
+		"certificate 'CN=test-broker' with serial number 11 is revoked")
+
+	t.Run("test_crl_of_other_issuer", func(t *testing.T) {
+		other := newTestCA(t)
+		assert.NoError(t, checker.verifyPeerCertificate(nil, [][]*x509.Certificate{{other.issue(t, 11), other.cert}}))
+	})
+
+	t.Run("test_crl_reloaded", func(t *testing.T) {
+		ca.revoke(t, crlFile, 10, 11)
+		assert.NoError(t, os.Chtimes(crlFile, time.Now(), time.Now().Add(time.Minute)))
+		assert.Error(t, checker.verifyPeerCertificate(nil, [][]*x509.Certificate{{valid, ca.cert}}))
+	})
+
+	t.Run("test_invalid_crl_not_reloaded", func(t *testing.T) {
+		assert.NoError(t, os.WriteFile(crlFile, []byte("invalid"), 0600))
+		assert.NoError(t, os.Chtimes(crlFile, time.Now(), time.Now().Add(2*time.Minute)))
+		assert.Error(t, checker.verifyPeerCertificate(nil, [][]*x509.Certificate{{valid, ca.cert}}))
+	})
+}
+
+func TestLoadRevocationLists(t *testing.T) {
+	ca := newTestCA(t)
+	dir := t.TempDir()
+	pemFile := filepath.Join(dir, "ca.crl")
+	ca.revoke(t, pemFile, 1)
+
+	lists, err := loadRevocationLists(pemFile)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(lists))
+
+	derFile := filepath.Join(dir, "ca.der")
+	block, _ := pem.Decode(mustReadFile(t, pemFile))
+	assert.NoError(t, os.WriteFile(derFile, block.Bytes, 0600))
+	lists, err = loadRevocationLists(derFile)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(lists))
+
+	_, err = loadRevocationLists(caCertPath)
+	assert.EqualError(t, err, "no CRL found in "+caCertPath)
+	_, err = loadRevocationLists(nonExisting)
+	assert.Error(t, err)
+}
+
+func mustReadFile(t *testing.T, file string) []byte {
+	data, err := os.ReadFile(file)
+	assert.NoError(t, err)
+	return data
+}
@@ -16,40 +16,129 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"os"
+	"strings"
 
 	"github.com/pkg/errors"
 )
 
+// Settings defines the TLS settings of a connection.
+type Settings struct {
+	// CACert is the PEM encoded CA certificates file
+	CACert string
+	// Cert and Key are the PEM encoded certificate and private key files, they are reloaded on change
+	Cert string
+	Key  string
+	// KeyPassphraseFile is the file holding the passphrase of an encrypted private key
+	KeyPassphraseFile string
+	// CRLFile is the PEM or DER encoded certificate revocation lists file, it is reloaded on change
+	CRLFile string
+	// ServerName overrides the host name used to verify the server certificate
+	ServerName string
+	// MinVersion is the minimum TLS version, 1.2 or 1.3. TLS 1.2 is used if not set
+	MinVersion string
+	// CipherSuites are the names of the TLS 1.2 cipher suites, all secure cipher suites are used if not set
+	CipherSuites []string
+}
+
 // NewTLSConfig initializes the TLS.
 func NewTLSConfig(rootCert, cert, key string) (*tls.Config, error) {
-	caCert, err := os.ReadFile(rootCert)
+	return NewTLSConfigFromSettings(&Settings{CACert: rootCert, Cert: cert, Key: key})
+}
+
+// NewTLSConfigFromSettings initializes the TLS with the given settings.
+// The client certificate and the certificate revocation lists are reloaded on handshake if their files have changed.
+func NewTLSConfigFromSettings(settings *Settings) (*tls.Config, error) {
+	caCert, err := os.ReadFile(settings.CACert)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to load CA")
 	}
 	caCertPool := x509.NewCertPool()
 	if !caCertPool.AppendCertsFromPEM(caCert) {
-		return nil, errors.Errorf("failed to parse CA %s", rootCert)
+		return nil, errors.Errorf("failed to parse CA %s", settings.CACert)
+	}
+
+	minVersion, err := parseVersion(settings.MinVersion)
+	if err != nil {
+		return nil, err
+	}
+	cipherSuites, err := parseCipherSuites(settings.CipherSuites)
+	if err != nil {
+		return nil, err
 	}
 
 	tlsConfig := &tls.Config{
 		InsecureSkipVerify: false,
 		RootCAs:            caCertPool,
-		MinVersion:         tls.VersionTLS12,
+		ServerName:         settings.ServerName,
+		MinVersion:         minVersion,
 		MaxVersion:         tls.VersionTLS13,
-		CipherSuites:       supportedCipherSuites(),
+		CipherSuites:       cipherSuites,
 	}
 
-	if len(cert) > 0 || len(key) > 0 {
-		cert, err := tls.LoadX509KeyPair(cert, key)
+	if len(settings.Cert) > 0 || len(settings.Key) > 0 {
+		reloader, err := newCertificateReloader(settings.Cert, settings.Key, settings.KeyPassphraseFile)
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to load X509 key pair")
 		}
-		tlsConfig.Certificates = []tls.Certificate{cert}
+		tlsConfig.Certificates = []tls.Certificate{*reloader.certificate}
+		tlsConfig.GetClientCertificate = reloader.getClientCertificate
+	}
+
+	if len(settings.CRLFile) > 0 {
+		checker, err := newRevocationChecker(settings.CRLFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to load CRL")
+		}
+		tlsConfig.VerifyPeerCertificate = checker.verifyPeerCertificate
 	}
 
 	return tlsConfig, nil
 }
 
+// Validate checks the minimum TLS version and the cipher suites, without loading the certificates, keys and CRLs.
+func (settings *Settings) Validate() error {
+	if _, err := parseVersion(settings.MinVersion); err != nil {
+		return err
+	}
+	_, err := parseCipherSuites(settings.CipherSuites)
+	return err
+}
+
+func parseVersion(version string) (uint16, error) {
+	switch strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(version)), "TLS") {
+	case "", "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	default:
+		return 0, errors.Errorf("unsupported minimum TLS version %s, the supported values are: 1.2, 1.3", version)
+	}
+}
+
+func parseCipherSuites(names []string) ([]uint16, error) {
+	if len(names) == 0 {
+		return supportedCipherSuites(), nil
+	}
+	cid := make([]uint16, len(names))
+	for i, name := range names {
+		cs := cipherSuite(strings.TrimSpace(name))
+		if cs == nil {
+			return nil, errors.Errorf("unsupported cipher suite %s", name)
+		}
+		cid[i] = cs.ID
+	}
+	return cid, nil
+}
+
+func cipherSuite(name string) *tls.CipherSuite {
+	for _, cs := range tls.CipherSuites() {
+		if cs.Name == name {
+			return cs
+		}
+	}
+	return nil
+}
+
 func supportedCipherSuites() []uint16 {
 	cs := tls.CipherSuites()
 	cid := make([]uint16, len(cs))
@@ -17,15 +17,20 @@ import (
 	"errors"
 	"fmt"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
 const (
-	nonExisting  = "nonexisting.test"
-	invalidFile  = "testdata/invalid.pem"
-	caCertPath   = "testdata/ca.crt"
-	certPath     = "testdata/certificate.pem"
-	keyPath      = "testdata/key.pem"
+	nonExisting = "nonexisting.test"
+	invalidFile = "testdata/invalid.pem"
+	caCertPath  = "testdata/ca.crt"
+	certPath    = "testdata/certificate.pem"
+	keyPath     = "testdata/key.pem"
+
+	encryptedKeyPath = "testdata/key-encrypted.pem"
+	passphrasePath   = "testdata/passphrase.txt"
+
 	caError      = "failed to load CA: open %s: no such file or directory"
 	keyPairError = "failed to load X509 key pair: open %s: no such file or directory"
 	invalidError = "failed to load X509 key pair: tls: failed to find any PEM data in key input"
@@ -101,3 +106,110 @@ func TestNewTLSConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestNewTLSConfigFromSettings(t *testing.T) {
+	tests := map[string]struct {
+		Settings      *Settings
+		ExpectedError string
+	}{
+		"valid_hardened_settings": {
+			Settings: &Settings{
+				CACert:       caCertPath,
+				Cert:         certPath,
+				Key:          keyPath,
+				ServerName:   "broker.local",
+				MinVersion:   "1.3",
+				CipherSuites: []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256", " TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384"},
+			},
+		},
+		"valid_encrypted_key": {
+			Settings: &Settings{CACert: caCertPath, Cert: certPath, Key: encryptedKeyPath, KeyPassphraseFile: passphrasePath},
+		},
+		"encrypted_key_without_passphrase": {
+			Settings:      &Settings{CACert: caCertPath, Cert: certPath, Key: encryptedKeyPath},
+			ExpectedError: "failed to load X509 key pair: tls: failed to parse private key",
+		},
+		"invalid_passphrase": {
+			Settings:      &Settings{CACert: caCertPath, Cert: certPath, Key: encryptedKeyPath, KeyPassphraseFile: caCertPath},
+			ExpectedError: "failed to load X509 key pair: failed to decrypt the private key: x509: decryption password incorrect",
+		},
+		"not_encrypted_key": {
+			Settings:      &Settings{CACert: caCertPath, Cert: certPath, Key: keyPath, KeyPassphraseFile: passphrasePath},
+			ExpectedError: "failed to load X509 key pair: the private key is not encrypted",
+		},
+		"non_existing_passphrase_file": {
+			Settings:      &Settings{CACert: caCertPath, Cert: certPath, Key: encryptedKeyPath, KeyPassphraseFile: nonExisting},
+			ExpectedError: fmt.Sprintf("failed to load X509 key pair: failed to read the key passphrase: open %s: no such file or directory", nonExisting),
+		},
+		"non_existing_crl_file": {
+			Settings:      &Settings{CACert: caCertPath, CRLFile: nonExisting},
+			ExpectedError: fmt.Sprintf("failed to load CRL: open %s: no such file or directory", nonExisting),
+		},
+		"unsupported_min_version": {
+			Settings:      &Settings{CACert: caCertPath, MinVersion: "1.1"},
+			ExpectedError: "unsupported minimum TLS version 1.1, the supported values are: 1.2, 1.3",
+		},
+		"insecure_cipher_suite": {
+			Settings:      &Settings{CACert: caCertPath, CipherSuites: []string{"TLS_RSA_WITH_RC4_128_SHA"}},
+			ExpectedError: "unsupported cipher suite TLS_RSA_WITH_RC4_128_SHA",
+		},
+	}
+
+	for testName, testCase := range tests {
+		t.Run(testName, func(t *testing.T) {
+			cfg, err := NewTLSConfigFromSettings(testCase.Settings)
+			if len(testCase.ExpectedError) > 0 {
+				if err == nil || !strings.HasPrefix(err.Error(), testCase.ExpectedError) {
+					t.Fatalf("expected error : %s, got: %v", testCase.ExpectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.ServerName != testCase.Settings.ServerName {
+				t.Fatalf("invalid server name %s", cfg.ServerName)
+			}
+			if len(testCase.Settings.Cert) > 0 && (len(cfg.Certificates) == 0 || cfg.GetClientCertificate == nil) {
+				t.Fatal("client certificate must be set")
+			}
+			if len(testCase.Settings.CipherSuites) > 0 && len(cfg.CipherSuites) != len(testCase.Settings.CipherSuites) {
+				t.Fatalf("invalid cipher suites %v", cfg.CipherSuites)
+			}
+			if testCase.Settings.MinVersion == "1.3" && cfg.MinVersion != tls.VersionTLS13 {
+				t.Fatalf("invalid min TLS version %d", cfg.MinVersion)
+			}
+		})
+	}
+}
+
+func TestSettingsValidate(t *testing.T) {
+	tests := map[string]struct {
+		Settings      *Settings
+		ExpectedError string
+	}{
+		"valid_without_files_loaded": {
+			Settings: &Settings{CACert: "missing.crt", MinVersion: "TLS1.3", CipherSuites: []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"}},
+		},
+		"unsupported_min_version": {
+			Settings:      &Settings{MinVersion: "1.0"},
+			ExpectedError: "unsupported minimum TLS version 1.0, the supported values are: 1.2, 1.3",
+		},
+		"unsupported_cipher_suite": {
+			Settings:      &Settings{CipherSuites: []string{"TLS_RSA_WITH_RC4_128_SHA"}},
+			ExpectedError: "unsupported cipher suite TLS_RSA_WITH_RC4_128_SHA",
+		},
+	}
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			err := test.Settings.Validate()
+			if len(test.ExpectedError) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			} else if err == nil || err.Error() != test.ExpectedError {
+				t.Fatalf("expected error '%s', got '%v'", test.ExpectedError, err)
+			}
+		})
+	}
+}
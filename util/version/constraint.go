@@ -0,0 +1,136 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package version
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const operatorChars = "=!<>"
+
+// Constraint is a version constraint, e.g. '>=1.2 <2' or '1.0 || >=2.0'. The conditions separated by whitespace or comma
+// must all be satisfied, the alternatives separated by '||' are satisfied if any of them is satisfied.
+type Constraint struct {
+	scheme       Scheme
+	alternatives [][]condition
+	text         string
+}
+
+type condition struct {
+	operator string
+	version  string
+}
+
+// IsConstraint returns true if the given version is a constraint rather than a concrete version,
+// i.e. it contains an operator, whitespace, a comma or '||'.
+func IsConstraint(version string) bool {
+	return strings.ContainsAny(version, operatorChars+" \t,|")
+}
+
+// ParseConstraint parses the given version constraint, the versions are compared according to the given scheme.
+// The supported operators are '=', '==', '!=', '<', '<=', '>' and '>=', a version without operator must be matched exactly.
+func ParseConstraint(constraint string, scheme Scheme) (*Constraint, error) {
+	result := &Constraint{scheme: scheme, text: strings.TrimSpace(constraint)}
+	for _, alternative := range strings.Split(constraint, "||") {
+		var conditions []condition
+		operator := ""
+		for _, token := range strings.FieldsFunc(alternative, func(r rune) bool { return r == ' ' || r == '\t' || r == ',' }) {
+			version := strings.TrimLeft(token, operatorChars)
+			operator += token[:len(token)-len(version)]
+			if version == "" {
+				continue // the version follows the operator after whitespace
+			}
+			switch operator {
+			case "":
+				operator = "="
+			case "==":
+				operator = "="
+			case "=", "!=", "<", "<=", ">", ">=":
+			default:
+				return nil, errors.Errorf("invalid operator '%s' in version constraint '%s'", operator, constraint)
+			}
+			conditions = append(conditions, condition{operator: operator, version: version})
+			operator = ""
+		}
+		if operator != "" {
+			return nil, errors.Errorf("missing version after operator '%s' in version constraint '%s'", operator, constraint)
+		}
+		if len(conditions) == 0 {
+			return nil, errors.Errorf("empty alternative in version constraint '%s'", constraint)
+		}
+		result.alternatives = append(result.alternatives, conditions)
+	}
+	return result, nil
+}
+
+// Check returns true if the given version satisfies the constraint.
+func (constraint *Constraint) Check(version string) bool {
+	for _, conditions := range constraint.alternatives {
+		satisfied := true
+		for _, condition := range conditions {
+			if !condition.check(constraint.scheme, version) {
+				satisfied = false
+				break
+			}
+		}
+		if satisfied {
+			return true
+		}
+	}
+	return false
+}
+
+// Resolve returns the highest of the given versions, which satisfies the constraint, or false if there is no such version.
+func (constraint *Constraint) Resolve(versions ...string) (string, bool) {
+	resolved, ok := "", false
+	for _, version := range versions {
+		if constraint.Check(version) && (!ok || CompareWith(constraint.scheme, version, resolved) > 0) {
+			resolved, ok = version, true
+		}
+	}
+	return resolved, ok
+}
+
+// String returns the constraint as it is parsed.
+func (constraint *Constraint) String() string {
+	return constraint.text
+}
+
+// Matches returns true if the given installed version matches the desired version according to the given scheme,
+// i.e. it is equal to the desired version or satisfies the desired version constraint.
+func Matches(scheme Scheme, desired, installed string) bool {
+	if IsConstraint(desired) {
+		constraint, err := ParseConstraint(desired, scheme)
+		return err == nil && constraint.Check(installed)
+	}
+	return Equal(scheme, desired, installed)
+}
+
+func (condition condition) check(scheme Scheme, version string) bool {
+	result := CompareWith(scheme, version, condition.version)
+	switch condition.operator {
+	case "!=":
+		return result != 0
+	case "<":
+		return result < 0
+	case "<=":
+		return result <= 0
+	case ">":
+		return result > 0
+	case ">=":
+		return result >= 0
+	}
+	return result == 0
+}
@@ -0,0 +1,111 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package version
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsConstraint(t *testing.T) {
+	assert.False(t, IsConstraint("1.2.3"))
+	assert.False(t, IsConstraint("1:2.30-0ubuntu1"))
+	assert.False(t, IsConstraint("1.0^git1~rc1"))
+	assert.True(t, IsConstraint(">=1.2"))
+	assert.True(t, IsConstraint("1.2 || 1.3"))
+	assert.True(t, IsConstraint("1.2, 1.3"))
+}
+
+func TestConstraintCheck(t *testing.T) {
+	tests := map[string]struct {
+		constraint string
+		satisfied  []string
+		violated   []string
+	}{
+		"test_range": {
+			constraint: ">=1.2 <2",
+			satisfied:  []string{"1.2", "1.2.0", "1.10.1", "v1.99.0"},
+			violated:   []string{"1.1.9", "2.0.0", "10.0"},
+		},
+		"test_operator_with_whitespace": {
+			constraint: ">= 1.2, != 1.5",
+			satisfied:  []string{"1.2", "1.6"},
+			violated:   []string{"1.1", "1.5.0"},
+		},
+		"test_alternatives": {
+			constraint: "1.0 || >2.0 <=3.0",
+			satisfied:  []string{"1.0.0", "2.1", "3.0"},
+			violated:   []string{"1.1", "2.0", "3.0.1"},
+		},
+		"test_exact": {
+			constraint: "==1.0",
+			satisfied:  []string{"1.0", "v1.0.0"},
+			violated:   []string{"1.0.1"},
+		},
+	}
+	for name, testCase := range tests {
+		t.Run(name, func(t *testing.T) {
+			constraint, err := ParseConstraint(testCase.constraint, SchemeAuto)
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.constraint, constraint.String())
+			for _, version := range testCase.satisfied {
+				assert.True(t, constraint.Check(version), version)
+			}
+			for _, version := range testCase.violated {
+				assert.False(t, constraint.Check(version), version)
+			}
+		})
+	}
+}
+
+func TestConstraintCheckWithScheme(t *testing.T) {
+	constraint, err := ParseConstraint(">=1.0", SchemeDebian)
+	assert.NoError(t, err)
+	assert.False(t, constraint.Check("1.0~rc1"))
+	assert.True(t, constraint.Check("1.0-1"))
+}
+
+func TestParseConstraintError(t *testing.T) {
+	for constraint, expectedErr := range map[string]string{
+		"=>1.0":     "invalid operator '=>' in version constraint '=>1.0'",
+		">=1.0 <":   "missing version after operator '<' in version constraint '>=1.0 <'",
+		"1.0 || ":   "empty alternative in version constraint '1.0 || '",
+		"":          "empty alternative in version constraint ''",
+		">>1.0":     "invalid operator '>>' in version constraint '>>1.0'",
+		"!1.0 2.0 ": "invalid operator '!' in version constraint '!1.0 2.0 '",
+	} {
+		_, err := ParseConstraint(constraint, SchemeAuto)
+		assert.EqualError(t, err, expectedErr)
+	}
+}
+
+func TestConstraintResolve(t *testing.T) {
+	constraint, err := ParseConstraint(">=1.2 <2", SchemeAuto)
+	assert.NoError(t, err)
+
+	resolved, ok := constraint.Resolve("1.1.0", "1.10.0", "1.9.0", "2.0.0")
+	assert.True(t, ok)
+	assert.Equal(t, "1.10.0", resolved)
+
+	_, ok = constraint.Resolve("1.1.0", "2.0.0")
+	assert.False(t, ok)
+}
+
+func TestMatches(t *testing.T) {
+	assert.True(t, Matches(SchemeSemVer, "v3.0", "3.0.0"))
+	assert.False(t, Matches(SchemeDebian, "1.0~rc1", "1.0"))
+	assert.True(t, Matches(SchemeAuto, ">=1.2 <2", "1.5"))
+	assert.False(t, Matches(SchemeAuto, ">=1.2 <2", "2.0"))
+	assert.False(t, Matches(SchemeAuto, ">= <2", "1.5"))
+}
@@ -0,0 +1,346 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package version
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Scheme defines the rules, which the versions are ordered by.
+type Scheme string
+
+const (
+	// SchemeAuto detects the scheme from the compared versions: semantic versions and dates are compared as such, all other versions as Debian versions
+	SchemeAuto Scheme = ""
+	// SchemeSemVer orders the versions according to the Semantic Versioning 2.0.0 precedence rules
+	SchemeSemVer Scheme = "semver"
+	// SchemeDebian orders the versions as dpkg does, i.e. [epoch:]upstream[-revision] with '~' sorting before anything
+	SchemeDebian Scheme = "deb"
+	// SchemeRPM orders the versions as rpm does, i.e. [epoch:]version[-release] with '~' sorting before and '^' after anything
+	SchemeRPM Scheme = "rpm"
+	// SchemeDate orders the date-based versions, i.e. YYYYMMDD, YYYY.MM.DD or YYYY-MM-DD with an optional suffix
+	SchemeDate Scheme = "date"
+)
+
+var datePattern = regexp.MustCompile(`^(\d{4})([-.]?)(0[1-9]|1[0-2])([-.]?)(0[1-9]|[12]\d|3[01])(.*)$`)
+
+// ParseScheme returns the scheme with the given name, an empty name stands for the automatically detected scheme.
+func ParseScheme(name string) (Scheme, error) {
+	switch scheme := Scheme(strings.ToLower(strings.TrimSpace(name))); scheme {
+	case SchemeAuto, SchemeSemVer, SchemeDebian, SchemeRPM, SchemeDate:
+		return scheme, nil
+	}
+	return SchemeAuto, errors.Errorf("unknown version scheme '%s'", name)
+}
+
+// Compare compares two versions with automatically detected scheme.
+// The result is negative if v1 is lower than v2, positive if v1 is greater than v2 and zero if both are equal.
+func Compare(v1, v2 string) int {
+	return CompareWith(SchemeAuto, v1, v2)
+}
+
+// CompareWith compares two versions according to the given scheme. The versions, which are not valid
+// for the semantic or the date-based scheme, are compared as Debian versions.
+func CompareWith(scheme Scheme, v1, v2 string) int {
+	if v1 == v2 {
+		return 0
+	}
+	switch scheme {
+	case SchemeAuto, SchemeSemVer, SchemeDate:
+		if scheme != SchemeSemVer {
+			if d1, d2 := parseDate(v1), parseDate(v2); d1 != nil && d2 != nil {
+				return compareDates(d1, d2)
+			}
+		}
+		if scheme != SchemeDate {
+			if s1, s2 := parseSemVer(v1), parseSemVer(v2); s1 != nil && s2 != nil {
+				return compareSemVers(s1, s2)
+			}
+		}
+	case SchemeRPM:
+		return compareRPM(v1, v2)
+	}
+	return compareDebian(v1, v2)
+}
+
+// Equal returns true if both versions are equal according to the given scheme, e.g. v1.0.0 and 1.0.0 are equal semantic versions.
+func Equal(scheme Scheme, v1, v2 string) bool {
+	return CompareWith(scheme, v1, v2) == 0
+}
+
+type semVer struct {
+	core       [3]string
+	preRelease []string
+}
+
+// parseSemVer parses a semantic version with optional 'v' prefix, the missing minor and patch versions are considered zero
+// and the build metadata is ignored. Nil is returned if the given version is not a semantic version.
+func parseSemVer(version string) *semVer {
+	version = strings.TrimPrefix(strings.TrimPrefix(version, "v"), "V")
+	version, _, _ = strings.Cut(version, "+")
+	core, preRelease, hasPreRelease := strings.Cut(version, "-")
+	parts := strings.Split(core, ".")
+	if len(parts) > 3 {
+		return nil
+	}
+	result := &semVer{core: [3]string{"0", "0", "0"}}
+	for i, part := range parts {
+		if !isNumeric(part) {
+			return nil
+		}
+		result.core[i] = part
+	}
+	if hasPreRelease {
+		result.preRelease = strings.Split(preRelease, ".")
+		for _, identifier := range result.preRelease {
+			if identifier == "" {
+				return nil
+			}
+		}
+	}
+	return result
+}
+
+func compareSemVers(s1, s2 *semVer) int {
+	for i := range s1.core {
+		if result := compareNumeric(s1.core[i], s2.core[i]); result != 0 {
+			return result
+		}
+	}
+	switch {
+	case len(s1.preRelease) == 0 && len(s2.preRelease) == 0:
+		return 0
+	case len(s1.preRelease) == 0:
+		return 1
+	case len(s2.preRelease) == 0:
+		return -1
+	}
+	for i := 0; i < len(s1.preRelease) && i < len(s2.preRelease); i++ {
+		id1, id2 := s1.preRelease[i], s2.preRelease[i]
+		numeric1, numeric2 := isNumeric(id1), isNumeric(id2)
+		var result int
+		switch {
+		case numeric1 && numeric2:
+			result = compareNumeric(id1, id2)
+		case numeric1:
+			result = -1
+		case numeric2:
+			result = 1
+		default:
+			result = strings.Compare(id1, id2)
+		}
+		if result != 0 {
+			return result
+		}
+	}
+	return compareInts(len(s1.preRelease), len(s2.preRelease))
+}
+
+type date struct {
+	year, month, day string
+	suffix           string
+}
+
+// parseDate parses a date-based version, nil is returned if the given version does not start with a valid date.
+func parseDate(version string) *date {
+	match := datePattern.FindStringSubmatch(version)
+	if match == nil || match[2] != match[4] {
+		return nil
+	}
+	return &date{year: match[1], month: match[3], day: match[5], suffix: strings.TrimLeft(match[6], "-._")}
+}
+
+func compareDates(d1, d2 *date) int {
+	if result := strings.Compare(d1.year+d1.month+d1.day, d2.year+d2.month+d2.day); result != 0 {
+		return result
+	}
+	return compareDebian(d1.suffix, d2.suffix)
+}
+
+// splitEpochRelease splits a version in the format [epoch:]version[-release], the epoch is empty if not numeric.
+func splitEpochRelease(version string) (epoch, upstream, release string) {
+	if e, rest, ok := strings.Cut(version, ":"); ok && isNumeric(e) {
+		epoch, version = e, rest
+	}
+	if i := strings.LastIndex(version, "-"); i >= 0 {
+		return epoch, version[:i], version[i+1:]
+	}
+	return epoch, version, ""
+}
+
+func compareDebian(v1, v2 string) int {
+	epoch1, upstream1, revision1 := splitEpochRelease(v1)
+	epoch2, upstream2, revision2 := splitEpochRelease(v2)
+	if result := compareNumeric(epoch1, epoch2); result != 0 {
+		return result
+	}
+	if result := compareDebianPart(upstream1, upstream2); result != 0 {
+		return result
+	}
+	return compareDebianPart(revision1, revision2)
+}
+
+// compareDebianPart compares alternating non-digit and digit parts as dpkg does: in the non-digit parts letters sort before
+// non-letters and '~' before anything, even the end of the part, the digit parts are compared numerically.
+func compareDebianPart(a, b string) int {
+	for a != "" || b != "" {
+		for (a != "" && !isDigit(a[0])) || (b != "" && !isDigit(b[0])) {
+			if result := compareInts(debianOrder(a), debianOrder(b)); result != 0 {
+				return result
+			}
+			a, b = a[1:], b[1:]
+		}
+		var digits1, digits2 string
+		digits1, a = leadingDigits(a)
+		digits2, b = leadingDigits(b)
+		if result := compareNumeric(digits1, digits2); result != 0 {
+			return result
+		}
+	}
+	return 0
+}
+
+func debianOrder(s string) int {
+	switch {
+	case s == "" || isDigit(s[0]):
+		return 0
+	case isLetter(s[0]):
+		return int(s[0])
+	case s[0] == '~':
+		return -1
+	}
+	return int(s[0]) + 256
+}
+
+func compareRPM(v1, v2 string) int {
+	epoch1, version1, release1 := splitEpochRelease(v1)
+	epoch2, version2, release2 := splitEpochRelease(v2)
+	if result := compareNumeric(epoch1, epoch2); result != 0 {
+		return result
+	}
+	if result := compareRPMPart(version1, version2); result != 0 || release1 == "" || release2 == "" {
+		return result
+	}
+	return compareRPMPart(release1, release2)
+}
+
+// compareRPMPart compares the alphanumeric segments as rpmvercmp does: the separators are ignored, numeric segments are newer
+// than alphabetic ones, '~' sorts before anything, even the end of the part, and '^' after anything but the end of the part.
+func compareRPMPart(a, b string) int {
+	isSegment := func(c byte) bool {
+		return isDigit(c) || isLetter(c) || c == '~' || c == '^'
+	}
+	for a != "" || b != "" {
+		a = strings.TrimLeftFunc(a, func(r rune) bool { return r > 0x7f || !isSegment(byte(r)) })
+		b = strings.TrimLeftFunc(b, func(r rune) bool { return r > 0x7f || !isSegment(byte(r)) })
+		if strings.HasPrefix(a, "~") || strings.HasPrefix(b, "~") {
+			if !strings.HasPrefix(a, "~") {
+				return 1
+			}
+			if !strings.HasPrefix(b, "~") {
+				return -1
+			}
+			a, b = a[1:], b[1:]
+			continue
+		}
+		if strings.HasPrefix(a, "^") || strings.HasPrefix(b, "^") {
+			switch {
+			case a == "":
+				return -1
+			case b == "":
+				return 1
+			case a[0] != '^':
+				return 1
+			case b[0] != '^':
+				return -1
+			}
+			a, b = a[1:], b[1:]
+			continue
+		}
+		if a == "" || b == "" {
+			break
+		}
+		var segment1, segment2 string
+		if isDigit(a[0]) {
+			segment1, a = leadingDigits(a)
+			segment2, b = leadingDigits(b)
+			if segment2 == "" {
+				return 1
+			}
+			if result := compareNumeric(segment1, segment2); result != 0 {
+				return result
+			}
+			continue
+		}
+		segment1, a = leadingLetters(a)
+		segment2, b = leadingLetters(b)
+		if segment2 == "" {
+			return -1
+		}
+		if result := strings.Compare(segment1, segment2); result != 0 {
+			return result
+		}
+	}
+	return compareInts(len(a), len(b))
+}
+
+// compareNumeric compares two non-negative numbers of arbitrary length, an empty string is considered zero.
+func compareNumeric(n1, n2 string) int {
+	n1, n2 = strings.TrimLeft(n1, "0"), strings.TrimLeft(n2, "0")
+	if result := compareInts(len(n1), len(n2)); result != 0 {
+		return result
+	}
+	return strings.Compare(n1, n2)
+}
+
+func compareInts(i1, i2 int) int {
+	switch {
+	case i1 < i2:
+		return -1
+	case i1 > i2:
+		return 1
+	}
+	return 0
+}
+
+func leadingDigits(s string) (string, string) {
+	i := 0
+	for i < len(s) && isDigit(s[i]) {
+		i++
+	}
+	return s[:i], s[i:]
+}
+
+func leadingLetters(s string) (string, string) {
+	i := 0
+	for i < len(s) && isLetter(s[i]) {
+		i++
+	}
+	return s[:i], s[i:]
+}
+
+func isNumeric(s string) bool {
+	digits, rest := leadingDigits(s)
+	return digits != "" && rest == ""
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
@@ -0,0 +1,94 @@
+// Copyright (c) 2024 Contributors to the Eclipse Foundation
+//
+// See the NOTICE file(s) distributed with this work for additional
+// information regarding copyright ownership.
+//
+// This program and the accompanying materials are made available under the
+// terms of the Eclipse Public License 2.0 which is available at
+// https://www.eclipse.org/legal/epl-2.0, or the Apache License, Version 2.0
+// which is available at https://www.apache.org/licenses/LICENSE-2.0.
+//
+// SPDX-License-Identifier: EPL-2.0 OR Apache-2.0
+
+package version
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompareWith(t *testing.T) {
+	tests := []struct {
+		scheme   Scheme
+		v1, v2   string
+		expected int
+	}{
+		// automatically detected
+		{SchemeAuto, "1.0.0", "1.0.0", 0},
+		{SchemeAuto, "v1.0.0", "1.0.0", 0},
+		{SchemeAuto, "1.2.0", "1.10.0", -1},
+		{SchemeAuto, "2.0", "1.9.9", 1},
+		{SchemeAuto, "1.0", "1.0.1", -1},
+		{SchemeAuto, "1.0.0-b", "1.0.0-a", 1},
+		{SchemeAuto, "2024.01.15", "20240116", -1},
+		{SchemeAuto, "1.2.3.4", "1.2.3.10", -1},
+		{SchemeAuto, "1:1.0", "2.0", 1},
+		// semantic versions
+		{SchemeSemVer, "1.0.0-alpha", "1.0.0", -1},
+		{SchemeSemVer, "1.0.0-alpha", "1.0.0-alpha.1", -1},
+		{SchemeSemVer, "1.0.0-alpha.1", "1.0.0-alpha.beta", -1},
+		{SchemeSemVer, "1.0.0-beta.2", "1.0.0-beta.11", -1},
+		{SchemeSemVer, "1.0.0-rc.1", "1.0.0", -1},
+		{SchemeSemVer, "1.0.0+build.1", "1.0.0+build.2", 0},
+		{SchemeSemVer, "18446744073709551616.0.0", "18446744073709551615.0.0", 1},
+		// Debian versions
+		{SchemeDebian, "1.0~rc1", "1.0", -1},
+		{SchemeDebian, "1.0", "1.0+b1", -1},
+		{SchemeDebian, "1.0-1", "1.0-2", -1},
+		{SchemeDebian, "1:0.9", "2.0", 1},
+		{SchemeDebian, "2.30-0ubuntu1", "2.30-0ubuntu10", -1},
+		{SchemeDebian, "1.0a", "1.0.", -1},
+		// RPM versions
+		{SchemeRPM, "1.0~rc1", "1.0", -1},
+		{SchemeRPM, "1.0^git1", "1.0", 1},
+		{SchemeRPM, "1.0^git1", "1.0.1", -1},
+		{SchemeRPM, "1.0a", "1.0.1", -1},
+		{SchemeRPM, "2.1-1.el9", "2.1-2.el9", -1},
+		{SchemeRPM, "2.1", "2.1-2.el9", 0},
+		{SchemeRPM, "1:1.0", "2.0", 1},
+		// date-based versions
+		{SchemeDate, "2024-01-15", "2024-02-01", -1},
+		{SchemeDate, "2024.01.15.2", "2024.01.15.10", -1},
+		{SchemeDate, "20240115", "2024.01.15", 0},
+	}
+	for _, testCase := range tests {
+		t.Run(string(testCase.scheme)+"_"+testCase.v1+"_"+testCase.v2, func(t *testing.T) {
+			result := CompareWith(testCase.scheme, testCase.v1, testCase.v2)
+			switch {
+			case testCase.expected < 0:
+				assert.True(t, result < 0)
+				assert.True(t, CompareWith(testCase.scheme, testCase.v2, testCase.v1) > 0)
+			case testCase.expected > 0:
+				assert.True(t, result > 0)
+				assert.True(t, CompareWith(testCase.scheme, testCase.v2, testCase.v1) < 0)
+			default:
+				assert.Equal(t, 0, result)
+				assert.True(t, Equal(testCase.scheme, testCase.v1, testCase.v2))
+			}
+		})
+	}
+}
+
+func TestParseScheme(t *testing.T) {
+	for _, name := range []string{"", "semver", "deb", "RPM", " date "} {
+		_, err := ParseScheme(name)
+		assert.NoError(t, err, name)
+	}
+	scheme, err := ParseScheme("Deb")
+	assert.NoError(t, err)
+	assert.Equal(t, SchemeDebian, scheme)
+
+	_, err = ParseScheme("calver")
+	assert.EqualError(t, err, "unknown version scheme 'calver'")
+}